@@ -0,0 +1,100 @@
+// Package backup takes snapshots of spreadsheets, keeps them in a local
+// directory or a Cloud Storage bucket, and replays them into new or
+// existing spreadsheets.
+//
+// A snapshot is a directory named <spreadsheet ID>/<UTC time> holding one
+// plain JSON file per tab, with its values and optionally its formulas and
+// formats, and a manifest.json describing the spreadsheet and its tabs.
+// The manifest is written last, so a snapshot without one is incomplete
+// and ignored.
+package backup
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// timeFormat orders snapshot names chronologically.
+const timeFormat = "20060102T150405Z"
+
+// Snapshot is a stored snapshot.
+type Snapshot struct {
+	Name string
+	Time time.Time
+}
+
+// List returns the snapshots of a spreadsheet, oldest first.
+func List(ctx context.Context, store Store, spreadsheetID string) ([]Snapshot, error) {
+	names, err := store.List(ctx, spreadsheetID+"/")
+	if err != nil {
+		return nil, fmt.Errorf("backup: unable to list snapshots: %w", err)
+	}
+	var out []Snapshot
+	for _, name := range names {
+		if path.Base(name) != ManifestFile {
+			continue
+		}
+		dir := path.Dir(name)
+		t, err := time.Parse(timeFormat, path.Base(dir))
+		if err != nil || path.Dir(dir) != spreadsheetID {
+			continue
+		}
+		out = append(out, Snapshot{Name: dir, Time: t})
+	}
+	return out, nil
+}
+
+// Retention says which snapshots to keep. A snapshot is deleted if it is
+// not among the Keep most recent or is older than MaxAge; zero values
+// disable the respective rule. The most recent snapshot is always kept.
+type Retention struct {
+	Keep   int
+	MaxAge time.Duration
+}
+
+// Prune deletes the snapshots of a spreadsheet that fall outside r and
+// returns their names.
+func Prune(ctx context.Context, store Store, spreadsheetID string, r Retention) ([]string, error) {
+	snaps, err := List(ctx, store, spreadsheetID)
+	if err != nil {
+		return nil, err
+	}
+	var deleted []string
+	for i, s := range snaps {
+		age := len(snaps) - i // 1 for the most recent
+		if age == 1 {
+			break
+		}
+		tooMany := r.Keep > 0 && age > r.Keep
+		tooOld := r.MaxAge > 0 && time.Since(s.Time) > r.MaxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := remove(ctx, store, s.Name); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, s.Name)
+	}
+	return deleted, nil
+}
+
+// remove deletes the snapshot in dir, its manifest first so that a partly
+// deleted snapshot is no longer listed.
+func remove(ctx context.Context, store Store, dir string) error {
+	if err := store.Delete(ctx, path.Join(dir, ManifestFile)); err != nil {
+		return fmt.Errorf("backup: unable to delete %s: %w", dir, err)
+	}
+	names, err := store.List(ctx, dir+"/")
+	if err != nil {
+		return fmt.Errorf("backup: unable to list %s: %w", dir, err)
+	}
+	for _, name := range names {
+		if err := store.Delete(ctx, name); err != nil {
+			return fmt.Errorf("backup: unable to delete %s: %w", name, err)
+		}
+	}
+	return nil
+}
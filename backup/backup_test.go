@@ -0,0 +1,111 @@
+package backup
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetstest"
+)
+
+func TestBackupRestore(t *testing.T) {
+	ctx := context.Background()
+	srv := sheetstest.NewServer()
+	defer srv.Close()
+	srv.Seed(&sheetstest.Spreadsheet{ID: "s", Title: "Sales", Tabs: []*sheetstest.Tab{
+		{Title: "Sales 2024", Rows: [][]interface{}{{"Item", "Qty", "Total"}, {"a", 2.0, "=B2*3"}}},
+		{Title: "Sales/2024", Rows: [][]interface{}{{"x"}}},
+		{Title: "Empty"},
+	}})
+	svc, err := srv.SheetsService(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := Dir(t.TempDir())
+
+	first, err := Backup(ctx, svc, store, "s", Options{Formulas: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadManifest(ctx, store, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Title != "Sales" || len(m.Tabs) != 3 || m.Tabs[0].File == m.Tabs[1].File {
+		t.Errorf("manifest = %+v", m)
+	}
+
+	// A second snapshot needs a later name.
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	second, err := Backup(ctx, svc, store, "s", Options{Formulas: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Files of an incomplete snapshot are not listed.
+	if err := putJSON(ctx, store, "s/20000101T000000Z/001-x.json", &TabData{}); err != nil {
+		t.Fatal(err)
+	}
+	snaps, err := List(ctx, store, "s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snaps) != 2 || snaps[0].Name != first || snaps[1].Name != second {
+		t.Fatalf("List = %+v, want %s and %s", snaps, first, second)
+	}
+
+	deleted, err := Prune(ctx, store, "s", Retention{Keep: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(deleted, []string{first}) {
+		t.Errorf("Prune deleted %v, want %s", deleted, first)
+	}
+	if names, _ := store.List(ctx, first+"/"); len(names) != 0 {
+		t.Errorf("Prune left %v", names)
+	}
+
+	ss, err := Restore(ctx, svc, store, second, "", "Restored")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := srv.Spreadsheet(ss.SpreadsheetId)
+	if got == nil || got.Title != "Restored" || len(got.Tabs) != 3 {
+		t.Fatalf("restored spreadsheet = %+v", got)
+	}
+	want := [][]interface{}{{"Item", "Qty", "Total"}, {"a", 2.0, "=B2*3"}}
+	if v := srv.Values(ss.SpreadsheetId, "Sales 2024"); !reflect.DeepEqual(v, want) {
+		t.Errorf("restored Sales 2024 = %v, want %v", v, want)
+	}
+	if v := srv.Values(ss.SpreadsheetId, "Sales/2024"); !reflect.DeepEqual(v, [][]interface{}{{"x"}}) {
+		t.Errorf("restored Sales/2024 = %v", v)
+	}
+}
+
+func TestRestoreValuesAsRaw(t *testing.T) {
+	ctx := context.Background()
+	srv := sheetstest.NewServer()
+	defer srv.Close()
+	rows := [][]interface{}{{"code", "part", "note"}, {"00123", "1/2", "=not a formula"}}
+	srv.Seed(&sheetstest.Spreadsheet{ID: "s", Title: "Parts", Tabs: []*sheetstest.Tab{{Title: "Parts", Rows: rows}}})
+	svc, err := srv.SheetsService(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := Dir(t.TempDir())
+	name, err := Backup(ctx, svc, store, "s", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss, err := Restore(ctx, svc, store, name, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := srv.Values(ss.SpreadsheetId, "Parts"); !reflect.DeepEqual(v, rows) {
+		t.Errorf("restored Parts = %#v, want %#v", v, rows)
+	}
+	if n := srv.Count("values.batchUpdate"); n != 1 {
+		t.Errorf("Restore made %d values.batchUpdate calls, want 1", n)
+	}
+}
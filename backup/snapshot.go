@@ -0,0 +1,417 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// ManifestFile is the name of the manifest within a snapshot. It is
+// written last, so a snapshot without one is incomplete.
+const ManifestFile = "manifest.json"
+
+// Options select what Backup saves besides the displayed values.
+type Options struct {
+	// Formulas saves the formulas of the cells that have one.
+	Formulas bool
+	// Formats saves the format of every cell.
+	Formats bool
+}
+
+// Manifest describes a snapshot: the spreadsheet's properties and its
+// tabs in display order.
+type Manifest struct {
+	SpreadsheetID string    `json:"spreadsheet_id"`
+	Title         string    `json:"title"`
+	Locale        string    `json:"locale,omitempty"`
+	TimeZone      string    `json:"time_zone,omitempty"`
+	Time          time.Time `json:"time"`
+	Tabs          []*Tab    `json:"tabs"`
+}
+
+// Tab holds the properties of a saved tab and the name of the file,
+// relative to the snapshot, with its TabData.
+type Tab struct {
+	Title         string        `json:"title"`
+	File          string        `json:"file"`
+	Rows          int64         `json:"rows"`
+	Columns       int64         `json:"columns"`
+	FrozenRows    int64         `json:"frozen_rows,omitempty"`
+	FrozenColumns int64         `json:"frozen_columns,omitempty"`
+	Hidden        bool          `json:"hidden,omitempty"`
+	Color         *sheets.Color `json:"color,omitempty"`
+}
+
+// TabData is the content of a saved tab, row by row from A1.
+type TabData struct {
+	Values [][]interface{} `json:"values"`
+	// Formulas holds the cells as entered, formulas included; it is only
+	// saved with Options.Formulas.
+	Formulas [][]interface{}        `json:"formulas,omitempty"`
+	Formats  [][]*sheets.CellFormat `json:"formats,omitempty"`
+}
+
+// Backup snapshots a spreadsheet into store: every tab as a JSON file of
+// values, and optionally formulas and formats, under a directory named
+// <spreadsheet ID>/<UTC time>, which it returns, along with a Manifest.
+// The files are plain JSON, so tools other than Restore can read them.
+func Backup(ctx context.Context, srv *sheets.Service, store Store, spreadsheetID string, opts Options) (string, error) {
+	ss, err := srv.Spreadsheets.Get(spreadsheetID).
+		Fields("spreadsheetId,properties(title,locale,timeZone),sheets.properties").Context(ctx).Do()
+	if err != nil {
+		return "", fmt.Errorf("backup: unable to get spreadsheet: %w", err)
+	}
+	now := time.Now().UTC()
+	dir := path.Join(spreadsheetID, now.Format(timeFormat))
+	m := &Manifest{SpreadsheetID: spreadsheetID, Time: now}
+	if p := ss.Properties; p != nil {
+		m.Title, m.Locale, m.TimeZone = p.Title, p.Locale, p.TimeZone
+	}
+	var ranges []string
+	for _, sh := range ss.Sheets {
+		p := sh.Properties
+		if p.SheetType != "" && p.SheetType != "GRID" {
+			continue
+		}
+		t := &Tab{
+			Title:  p.Title,
+			File:   fmt.Sprintf("%03d-%s.json", len(m.Tabs)+1, fileName(p.Title)),
+			Hidden: p.Hidden,
+		}
+		if g := p.GridProperties; g != nil {
+			t.Rows, t.Columns = g.RowCount, g.ColumnCount
+			t.FrozenRows, t.FrozenColumns = g.FrozenRowCount, g.FrozenColumnCount
+		}
+		if p.TabColorStyle != nil && p.TabColorStyle.RgbColor != nil {
+			t.Color = p.TabColorStyle.RgbColor
+		}
+		m.Tabs = append(m.Tabs, t)
+		ranges = append(ranges, sheetsclient.QuoteTab(p.Title))
+	}
+	if len(ranges) == 0 {
+		return "", fmt.Errorf("backup: %s has no tabs with cells", spreadsheetID)
+	}
+
+	data := make([]*TabData, len(m.Tabs))
+	for i := range data {
+		data[i] = &TabData{Values: [][]interface{}{}}
+	}
+	values, err := batchGet(ctx, srv, spreadsheetID, ranges, "FORMATTED_VALUE")
+	if err != nil {
+		return "", err
+	}
+	for i, v := range values {
+		if v != nil {
+			data[i].Values = v
+		}
+	}
+	if opts.Formulas {
+		formulas, err := batchGet(ctx, srv, spreadsheetID, ranges, "FORMULA")
+		if err != nil {
+			return "", err
+		}
+		for i, v := range formulas {
+			data[i].Formulas = v
+		}
+	}
+	if opts.Formats {
+		grid, err := srv.Spreadsheets.Get(spreadsheetID).Ranges(ranges...).
+			Fields("sheets.data(startRow,startColumn,rowData.values.userEnteredFormat)").Context(ctx).Do()
+		if err != nil {
+			return "", fmt.Errorf("backup: unable to get formats: %w", err)
+		}
+		for i, sh := range grid.Sheets {
+			if i < len(data) && len(sh.Data) > 0 {
+				data[i].Formats = formats(sh.Data[0])
+			}
+		}
+	}
+
+	for i, t := range m.Tabs {
+		if err := putJSON(ctx, store, path.Join(dir, t.File), data[i]); err != nil {
+			return "", err
+		}
+	}
+	if err := putJSON(ctx, store, path.Join(dir, ManifestFile), m); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+func batchGet(ctx context.Context, srv *sheets.Service, spreadsheetID string, ranges []string, render string) ([][][]interface{}, error) {
+	resp, err := srv.Spreadsheets.Values.BatchGet(spreadsheetID).Ranges(ranges...).
+		ValueRenderOption(render).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("backup: unable to read values: %w", err)
+	}
+	out := make([][][]interface{}, len(ranges))
+	for i, vr := range resp.ValueRanges {
+		if i < len(out) {
+			out[i] = vr.Values
+		}
+	}
+	return out, nil
+}
+
+// formats returns the cell formats of grid data from A1, trimming the
+// empty rows and cells at the end.
+func formats(d *sheets.GridData) [][]*sheets.CellFormat {
+	var out [][]*sheets.CellFormat
+	for i, r := range d.RowData {
+		var row []*sheets.CellFormat
+		for j, v := range r.Values {
+			if v.UserEnteredFormat != nil {
+				for len(row) < int(d.StartColumn)+j {
+					row = append(row, nil)
+				}
+				row = append(row, v.UserEnteredFormat)
+			}
+		}
+		if row == nil {
+			continue
+		}
+		for len(out) < int(d.StartRow)+i {
+			out = append(out, nil)
+		}
+		out = append(out, row)
+	}
+	return out
+}
+
+func putJSON(ctx context.Context, store Store, name string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("backup: unable to encode %s: %w", name, err)
+	}
+	if err := store.Put(ctx, name, bytes.NewReader(append(b, '\n'))); err != nil {
+		return fmt.Errorf("backup: unable to store %s: %w", name, err)
+	}
+	return nil
+}
+
+// fileName makes a tab title safe to use in a file name.
+func fileName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, title)
+	if r := []rune(name); len(r) > 60 {
+		name = string(r[:60])
+	}
+	return name
+}
+
+// LoadManifest reads the manifest of the snapshot in dir, as returned by
+// Backup.
+func LoadManifest(ctx context.Context, store Store, dir string) (*Manifest, error) {
+	m := &Manifest{}
+	if err := getJSON(ctx, store, path.Join(dir, ManifestFile), m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Restore rebuilds the tabs of the snapshot in dir, with their values
+// or formulas, formats if saved, and grid size, frozen rows and columns,
+// visibility and color. Values of a snapshot without formulas are written
+// back as text, as they were displayed. Without a spreadsheetID a new spreadsheet is
+// created, titled title or else the original title; with one, that
+// spreadsheet is overwritten: its tabs named like saved ones are
+// cleared and reused, the others deleted. It returns the spreadsheet's ID
+// and URL.
+func Restore(ctx context.Context, srv *sheets.Service, store Store, dir, spreadsheetID, title string) (*sheets.Spreadsheet, error) {
+	m, err := LoadManifest(ctx, store, dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Tabs) == 0 {
+		return nil, fmt.Errorf("backup: %s has no tabs", dir)
+	}
+	data := make([]*TabData, len(m.Tabs))
+	for i, t := range m.Tabs {
+		data[i] = &TabData{}
+		if err := getJSON(ctx, store, path.Join(dir, t.File), data[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	var ss *sheets.Spreadsheet
+	if spreadsheetID == "" {
+		ss, err = createFrom(ctx, srv, m, title)
+	} else {
+		ss, err = overwrite(ctx, srv, spreadsheetID, m, data, title)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Formatted values are written as RAW so that text such as "00123"
+	// or "1/2" is not parsed again; only formula snapshots are entered.
+	raw := &sheets.BatchUpdateValuesRequest{ValueInputOption: "RAW"}
+	entered := &sheets.BatchUpdateValuesRequest{ValueInputOption: "USER_ENTERED"}
+	var reqs []*sheets.Request
+	ids := map[string]int64{}
+	for _, sh := range ss.Sheets {
+		ids[sh.Properties.Title] = sh.Properties.SheetId
+	}
+	for i, t := range m.Tabs {
+		req, values := entered, data[i].Formulas
+		if values == nil {
+			req, values = raw, data[i].Values
+		}
+		if len(values) > 0 {
+			req.Data = append(req.Data, &sheets.ValueRange{
+				Range:  sheetsclient.QuoteTab(t.Title) + "!A1",
+				Values: values,
+			})
+		}
+		if len(data[i].Formats) > 0 {
+			rows := make([]*sheets.RowData, len(data[i].Formats))
+			for r, fr := range data[i].Formats {
+				rows[r] = &sheets.RowData{}
+				for _, f := range fr {
+					rows[r].Values = append(rows[r].Values, &sheets.CellData{UserEnteredFormat: f})
+				}
+			}
+			reqs = append(reqs, &sheets.Request{UpdateCells: &sheets.UpdateCellsRequest{
+				Start:  &sheets.GridCoordinate{SheetId: ids[t.Title], ForceSendFields: []string{"SheetId", "RowIndex", "ColumnIndex"}},
+				Rows:   rows,
+				Fields: "userEnteredFormat",
+			}})
+		}
+	}
+	for _, req := range []*sheets.BatchUpdateValuesRequest{raw, entered} {
+		if len(req.Data) == 0 {
+			continue
+		}
+		if _, err := srv.Spreadsheets.Values.BatchUpdate(ss.SpreadsheetId, req).Context(ctx).Do(); err != nil {
+			return nil, fmt.Errorf("backup: unable to write values: %w", err)
+		}
+	}
+	if len(reqs) > 0 {
+		_, err := srv.Spreadsheets.BatchUpdate(ss.SpreadsheetId, &sheets.BatchUpdateSpreadsheetRequest{Requests: reqs}).Context(ctx).Do()
+		if err != nil {
+			return nil, fmt.Errorf("backup: unable to write formats: %w", err)
+		}
+	}
+	return &sheets.Spreadsheet{SpreadsheetId: ss.SpreadsheetId, SpreadsheetUrl: ss.SpreadsheetUrl}, nil
+}
+
+// tabProperties returns the properties of a saved tab at index.
+func tabProperties(t *Tab, index int) *sheets.SheetProperties {
+	p := &sheets.SheetProperties{
+		Title:  t.Title,
+		Index:  int64(index),
+		Hidden: t.Hidden,
+		GridProperties: &sheets.GridProperties{
+			RowCount:          t.Rows,
+			ColumnCount:       t.Columns,
+			FrozenRowCount:    t.FrozenRows,
+			FrozenColumnCount: t.FrozenColumns,
+			ForceSendFields:   []string{"FrozenRowCount", "FrozenColumnCount"},
+		},
+		ForceSendFields: []string{"Index", "Hidden"},
+	}
+	if t.Color != nil {
+		p.TabColorStyle = &sheets.ColorStyle{RgbColor: t.Color}
+	}
+	return p
+}
+
+func createFrom(ctx context.Context, srv *sheets.Service, m *Manifest, title string) (*sheets.Spreadsheet, error) {
+	if title == "" {
+		title = m.Title
+	}
+	ss := &sheets.Spreadsheet{Properties: &sheets.SpreadsheetProperties{Title: title, Locale: m.Locale, TimeZone: m.TimeZone}}
+	for i, t := range m.Tabs {
+		ss.Sheets = append(ss.Sheets, &sheets.Sheet{Properties: tabProperties(t, i)})
+	}
+	created, err := srv.Spreadsheets.Create(ss).
+		Fields("spreadsheetId", "spreadsheetUrl", "sheets.properties(sheetId,title)").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("backup: unable to create spreadsheet: %w", err)
+	}
+	return created, nil
+}
+
+// overwrite reshapes the tabs of an existing spreadsheet to those of m,
+// clearing the ones kept.
+func overwrite(ctx context.Context, srv *sheets.Service, spreadsheetID string, m *Manifest, data []*TabData, title string) (*sheets.Spreadsheet, error) {
+	ss, err := srv.Spreadsheets.Get(spreadsheetID).
+		Fields("spreadsheetId,spreadsheetUrl,sheets.properties(sheetId,title)").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("backup: unable to get spreadsheet: %w", err)
+	}
+	existing := map[string]int64{}
+	for _, sh := range ss.Sheets {
+		existing[sh.Properties.Title] = sh.Properties.SheetId
+	}
+	props := &sheets.SpreadsheetProperties{Title: title, Locale: m.Locale, TimeZone: m.TimeZone}
+	fields := "locale,timeZone"
+	if title != "" {
+		fields += ",title"
+	}
+	reqs := []*sheets.Request{{UpdateSpreadsheetProperties: &sheets.UpdateSpreadsheetPropertiesRequest{Properties: props, Fields: fields}}}
+	keep := map[string]bool{}
+	for i, t := range m.Tabs {
+		p := tabProperties(t, i)
+		id, ok := existing[t.Title]
+		if !ok {
+			reqs = append(reqs, &sheets.Request{AddSheet: &sheets.AddSheetRequest{Properties: p}})
+			continue
+		}
+		keep[t.Title] = true
+		clear := "userEnteredValue"
+		if len(data[i].Formats) > 0 {
+			clear += ",userEnteredFormat"
+		}
+		reqs = append(reqs, &sheets.Request{UpdateCells: &sheets.UpdateCellsRequest{
+			Range:  &sheets.GridRange{SheetId: id, ForceSendFields: []string{"SheetId"}},
+			Fields: clear,
+		}})
+		p.SheetId = id
+		p.ForceSendFields = append(p.ForceSendFields, "SheetId")
+		reqs = append(reqs, &sheets.Request{UpdateSheetProperties: &sheets.UpdateSheetPropertiesRequest{
+			Properties: p,
+			Fields:     "index,hidden,tabColorStyle,gridProperties.rowCount,gridProperties.columnCount,gridProperties.frozenRowCount,gridProperties.frozenColumnCount",
+		}})
+	}
+	for _, sh := range ss.Sheets {
+		if !keep[sh.Properties.Title] {
+			reqs = append(reqs, &sheets.Request{DeleteSheet: &sheets.DeleteSheetRequest{SheetId: sh.Properties.SheetId, ForceSendFields: []string{"SheetId"}}})
+		}
+	}
+	_, err = srv.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{Requests: reqs}).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("backup: unable to reshape tabs: %w", err)
+	}
+	ss, err = srv.Spreadsheets.Get(spreadsheetID).
+		Fields("spreadsheetId,spreadsheetUrl,sheets.properties(sheetId,title)").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("backup: unable to get spreadsheet: %w", err)
+	}
+	return ss, nil
+}
+
+func getJSON(ctx context.Context, store Store, name string, v interface{}) error {
+	rc, err := store.Get(ctx, name)
+	if err != nil {
+		return fmt.Errorf("backup: unable to open %s: %w", name, err)
+	}
+	defer rc.Close()
+	if err := json.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("backup: unable to decode %s: %w", name, err)
+	}
+	return nil
+}
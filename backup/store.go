@@ -0,0 +1,165 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"golang.org/x/net/context"
+	"google.golang.org/api/iterator"
+)
+
+// Store holds snapshot files under slash separated names.
+type Store interface {
+	Put(ctx context.Context, name string, r io.Reader) error
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	// List returns the names under prefix in lexical order.
+	List(ctx context.Context, prefix string) ([]string, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// OpenStore returns the store for dest, which is either a gs://bucket/prefix
+// URL or a local directory. GCS access uses Application Default
+// Credentials.
+func OpenStore(ctx context.Context, dest string) (Store, error) {
+	if !strings.HasPrefix(dest, "gs://") {
+		return Dir(dest), nil
+	}
+	path := strings.TrimPrefix(dest, "gs://")
+	bucket, prefix := path, ""
+	if i := strings.Index(path, "/"); i >= 0 {
+		bucket, prefix = path[:i], strings.Trim(path[i+1:], "/")
+	}
+	if bucket == "" {
+		return nil, fmt.Errorf("backup: invalid destination %q", dest)
+	}
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("backup: unable to create Cloud Storage client: %w", err)
+	}
+	return &GCS{Bucket: client.Bucket(bucket), Prefix: prefix}, nil
+}
+
+// Dir is a Store in a local directory.
+type Dir string
+
+func (d Dir) path(name string) string { return filepath.Join(string(d), filepath.FromSlash(name)) }
+
+// Put implements Store. The file is written under a temporary name and
+// renamed, so a partial snapshot is never listed.
+func (d Dir) Put(ctx context.Context, name string, r io.Reader) error {
+	p := d.path(name)
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(p+".tmp", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), p)
+}
+
+// Get implements Store.
+func (d Dir) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	return os.Open(d.path(name))
+}
+
+// List implements Store.
+func (d Dir) List(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	err := filepath.Walk(string(d), func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == string(d) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if info.IsDir() || strings.HasSuffix(p, ".tmp") {
+			return nil
+		}
+		rel, err := filepath.Rel(string(d), p)
+		if err != nil {
+			return err
+		}
+		if name := filepath.ToSlash(rel); strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	sort.Strings(names)
+	return names, err
+}
+
+// Delete implements Store.
+func (d Dir) Delete(ctx context.Context, name string) error {
+	return os.Remove(d.path(name))
+}
+
+// GCS is a Store in a Cloud Storage bucket, below an optional prefix.
+type GCS struct {
+	Bucket *storage.BucketHandle
+	Prefix string
+}
+
+func (g *GCS) object(name string) string {
+	if g.Prefix == "" {
+		return name
+	}
+	return g.Prefix + "/" + name
+}
+
+// Put implements Store.
+func (g *GCS) Put(ctx context.Context, name string, r io.Reader) error {
+	w := g.Bucket.Object(g.object(name)).NewWriter(ctx)
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// Get implements Store.
+func (g *GCS) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	return g.Bucket.Object(g.object(name)).NewReader(ctx)
+}
+
+// List implements Store.
+func (g *GCS) List(ctx context.Context, prefix string) ([]string, error) {
+	it := g.Bucket.Objects(ctx, &storage.Query{Prefix: g.object(prefix)})
+	var names []string
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := attrs.Name
+		if g.Prefix != "" {
+			name = strings.TrimPrefix(name, g.Prefix+"/")
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Delete implements Store.
+func (g *GCS) Delete(ctx context.Context, name string) error {
+	return g.Bucket.Object(g.object(name)).Delete(ctx)
+}
@@ -0,0 +1,337 @@
+package bqsheets
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/storage"
+	"golang.org/x/net/context"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// chunkRows is the number of rows written to a sheet per request.
+const chunkRows = 5000
+
+// Bridge copies data between spreadsheets and BigQuery.
+type Bridge struct {
+	Sheets   *sheets.Service
+	BigQuery *bigquery.Client
+	// Storage and StagingBucket enable staging through Cloud Storage.
+	// Both are optional.
+	Storage       *storage.Client
+	StagingBucket string
+}
+
+func (b *Bridge) staging() bool {
+	return b.Storage != nil && b.StagingBucket != ""
+}
+
+// ReadRange fetches a range as strings, header row first.
+func (b *Bridge) ReadRange(ctx context.Context, spreadsheetID, rng string) ([][]string, error) {
+	resp, err := b.Sheets.Spreadsheets.Values.Get(spreadsheetID, rng).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("bqsheets: unable to read %s: %w", rng, err)
+	}
+	rows := make([][]string, len(resp.Values))
+	for i, v := range resp.Values {
+		rows[i] = make([]string, len(v))
+		for j, c := range v {
+			rows[i][j] = fmt.Sprint(c)
+		}
+	}
+	return rows, nil
+}
+
+// Load writes rows, header first, into table. The schema is inferred from
+// the data unless schema is non-nil. With truncate, existing table content
+// is replaced; otherwise rows are appended.
+func (b *Bridge) Load(ctx context.Context, table *bigquery.Table, rows [][]string, schema bigquery.Schema, truncate bool) error {
+	if len(rows) == 0 {
+		return fmt.Errorf("bqsheets: nothing to load, the header row is missing")
+	}
+	if schema == nil {
+		schema = InferSchema(rows[0], rows[1:])
+	}
+
+	var src bigquery.LoadSource
+	if b.staging() {
+		name := fmt.Sprintf("bqsheets/load-%s-%d.csv", table.TableID, time.Now().UnixNano())
+		obj := b.Storage.Bucket(b.StagingBucket).Object(name)
+		w := obj.NewWriter(ctx)
+		w.ContentType = "text/csv"
+		if err := writeCSV(w, rows, len(schema)); err != nil {
+			w.Close()
+			return fmt.Errorf("bqsheets: unable to stage data: %w", err)
+		}
+		if err := w.Close(); err != nil {
+			return fmt.Errorf("bqsheets: unable to stage data: %w", err)
+		}
+		defer obj.Delete(ctx)
+		ref := bigquery.NewGCSReference(fmt.Sprintf("gs://%s/%s", b.StagingBucket, name))
+		ref.SkipLeadingRows = 1
+		ref.Schema = schema
+		ref.AllowQuotedNewlines = true
+		src = ref
+	} else {
+		pr, pw := io.Pipe()
+		go func() { pw.CloseWithError(writeCSV(pw, rows, len(schema))) }()
+		rs := bigquery.NewReaderSource(pr)
+		rs.SkipLeadingRows = 1
+		rs.Schema = schema
+		rs.AllowQuotedNewlines = true
+		src = rs
+	}
+
+	loader := table.LoaderFrom(src)
+	loader.CreateDisposition = bigquery.CreateIfNeeded
+	loader.WriteDisposition = bigquery.WriteAppend
+	if truncate {
+		loader.WriteDisposition = bigquery.WriteTruncate
+	}
+	return runJob(ctx, loader.Run)
+}
+
+// writeCSV writes rows padded to width columns.
+func writeCSV(w io.Writer, rows [][]string, width int) error {
+	cw := csv.NewWriter(w)
+	for _, r := range rows {
+		rec := make([]string, width)
+		copy(rec, r)
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func runJob(ctx context.Context, run func(context.Context) (*bigquery.Job, error)) error {
+	job, err := run(ctx)
+	if err != nil {
+		return fmt.Errorf("bqsheets: unable to start job: %w", err)
+	}
+	status, err := job.Wait(ctx)
+	if err != nil {
+		return fmt.Errorf("bqsheets: job %s failed: %w", job.ID(), err)
+	}
+	if err := status.Err(); err != nil {
+		return fmt.Errorf("bqsheets: job %s failed: %w", job.ID(), err)
+	}
+	return nil
+}
+
+// Publish runs query and replaces the content of tab with its result,
+// header row first. It returns the number of data rows written.
+func (b *Bridge) Publish(ctx context.Context, query, spreadsheetID, tab string) (int, error) {
+	job, err := b.BigQuery.Query(query).Run(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("bqsheets: unable to run query: %w", err)
+	}
+	status, err := job.Wait(ctx)
+	if err == nil {
+		err = status.Err()
+	}
+	if err != nil {
+		return 0, fmt.Errorf("bqsheets: query failed: %w", err)
+	}
+
+	w := &tabWriter{srv: b.Sheets, spreadsheetID: spreadsheetID, tab: tab}
+	if err := w.clear(ctx); err != nil {
+		return 0, err
+	}
+	if b.staging() {
+		err = b.publishStaged(ctx, job, w)
+	} else {
+		err = publishRows(ctx, job, w)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := w.flush(ctx); err != nil {
+		return 0, err
+	}
+	return w.rows - 1, nil
+}
+
+func publishRows(ctx context.Context, job *bigquery.Job, w *tabWriter) error {
+	it, err := job.Read(ctx)
+	if err != nil {
+		return fmt.Errorf("bqsheets: unable to read query result: %w", err)
+	}
+	for first := true; ; first = false {
+		var row []bigquery.Value
+		err := it.Next(&row)
+		if err == iterator.Done {
+			if first {
+				// Still write the header of an empty result.
+				return w.writeHeader(ctx, it.Schema)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("bqsheets: unable to read query result: %w", err)
+		}
+		if first {
+			if err := w.writeHeader(ctx, it.Schema); err != nil {
+				return err
+			}
+		}
+		vals := make([]interface{}, len(row))
+		for i, v := range row {
+			vals[i] = cellValue(v)
+		}
+		if err := w.write(ctx, vals); err != nil {
+			return err
+		}
+	}
+}
+
+// publishStaged extracts the query's destination table to Cloud Storage
+// as CSV shards and streams them into the sheet.
+func (b *Bridge) publishStaged(ctx context.Context, job *bigquery.Job, w *tabWriter) error {
+	cfg, err := job.Config()
+	if err != nil {
+		return err
+	}
+	qc, ok := cfg.(*bigquery.QueryConfig)
+	if !ok || qc.Dst == nil {
+		return fmt.Errorf("bqsheets: query job %s has no destination table", job.ID())
+	}
+	prefix := fmt.Sprintf("bqsheets/extract-%s/", job.ID())
+	ref := bigquery.NewGCSReference(fmt.Sprintf("gs://%s/%sshard-*.csv", b.StagingBucket, prefix))
+	ref.DestinationFormat = bigquery.CSV
+	if err := runJob(ctx, qc.Dst.ExtractorTo(ref).Run); err != nil {
+		return err
+	}
+
+	bucket := b.Storage.Bucket(b.StagingBucket)
+	it := bucket.Objects(ctx, &storage.Query{Prefix: prefix})
+	var names []string
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("bqsheets: unable to list staged files: %w", err)
+		}
+		names = append(names, attrs.Name)
+	}
+	defer func() {
+		for _, name := range names {
+			bucket.Object(name).Delete(ctx)
+		}
+	}()
+
+	for i, name := range names {
+		r, err := bucket.Object(name).NewReader(ctx)
+		if err != nil {
+			return fmt.Errorf("bqsheets: unable to read %s: %w", name, err)
+		}
+		err = copyCSV(ctx, r, w, i > 0)
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyCSV streams one extracted shard into w. Every shard starts with a
+// header row, which is kept only for the first one.
+func copyCSV(ctx context.Context, r io.Reader, w *tabWriter, skipHeader bool) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	for first := true; ; first = false {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("bqsheets: unable to parse extracted data: %w", err)
+		}
+		if first && skipHeader {
+			continue
+		}
+		vals := make([]interface{}, len(rec))
+		for i, v := range rec {
+			vals[i] = v
+		}
+		if err := w.write(ctx, vals); err != nil {
+			return err
+		}
+	}
+}
+
+func cellValue(v bigquery.Value) interface{} {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return t.Format("2006-01-02 15:04:05")
+	case []byte:
+		return string(t)
+	case int64, float64, bool, string:
+		return t
+	}
+	return fmt.Sprint(v)
+}
+
+// tabWriter writes rows to a tab in chunks.
+type tabWriter struct {
+	srv           *sheets.Service
+	spreadsheetID string
+	tab           string
+	rows          int // rows written or buffered so far
+	buf           [][]interface{}
+}
+
+func (w *tabWriter) rng() string {
+	return sheetsclient.QuoteTab(w.tab)
+}
+
+func (w *tabWriter) clear(ctx context.Context) error {
+	if _, err := w.srv.Spreadsheets.Values.Clear(w.spreadsheetID, w.rng(), &sheets.ClearValuesRequest{}).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("bqsheets: unable to clear tab %q: %w", w.tab, err)
+	}
+	return nil
+}
+
+func (w *tabWriter) writeHeader(ctx context.Context, schema bigquery.Schema) error {
+	header := make([]interface{}, len(schema))
+	for i, f := range schema {
+		header[i] = f.Name
+	}
+	return w.write(ctx, header)
+}
+
+func (w *tabWriter) write(ctx context.Context, row []interface{}) error {
+	w.buf = append(w.buf, row)
+	w.rows++
+	if len(w.buf) >= chunkRows {
+		return w.flush(ctx)
+	}
+	return nil
+}
+
+func (w *tabWriter) flush(ctx context.Context) error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	// Appending, unlike updating, grows the grid as needed.
+	vr := &sheets.ValueRange{Values: w.buf}
+	start := w.rows - len(w.buf) + 1
+	_, err := w.srv.Spreadsheets.Values.Append(w.spreadsheetID, w.rng()+"!A1", vr).
+		ValueInputOption("RAW").InsertDataOption("INSERT_ROWS").Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("bqsheets: unable to write rows %d-%d: %w", start, w.rows, err)
+	}
+	w.buf = nil
+	return nil
+}
@@ -0,0 +1,105 @@
+// Package bqsheets moves data between spreadsheets and BigQuery.
+//
+// Small data sets are sent inline. When a staging bucket is configured,
+// data is written to Cloud Storage first and loaded or extracted from
+// there, which is how BigQuery prefers to handle large volumes.
+package bqsheets
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+)
+
+var notField = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// FieldName turns a header cell into a valid BigQuery column name.
+func FieldName(header string) string {
+	s := strings.Trim(notField.ReplaceAllString(strings.TrimSpace(header), "_"), "_")
+	if s == "" || (s[0] >= '0' && s[0] <= '9') {
+		s = "_" + s
+	}
+	if len(s) > 300 {
+		s = s[:300]
+	}
+	return s
+}
+
+// FieldNames maps a header row to distinct column names.
+func FieldNames(header []string) []string {
+	names := make([]string, len(header))
+	seen := map[string]bool{}
+	for i, h := range header {
+		name := FieldName(h)
+		for base, n := name, 2; seen[strings.ToLower(name)]; n++ {
+			name = base + "_" + strconv.Itoa(n)
+		}
+		seen[strings.ToLower(name)] = true
+		names[i] = name
+	}
+	return names
+}
+
+// InferSchema derives a schema from header and the rows below it. A column
+// gets the narrowest type that accepts all its non-empty values, falling
+// back to STRING. Every column is nullable.
+func InferSchema(header []string, rows [][]string) bigquery.Schema {
+	names := FieldNames(header)
+	schema := make(bigquery.Schema, len(names))
+	for i, name := range names {
+		schema[i] = &bigquery.FieldSchema{Name: name, Type: inferType(rows, i)}
+	}
+	return schema
+}
+
+func inferType(rows [][]string, col int) bigquery.FieldType {
+	candidates := []bigquery.FieldType{
+		bigquery.IntegerFieldType,
+		bigquery.FloatFieldType,
+		bigquery.BooleanFieldType,
+		bigquery.DateFieldType,
+		bigquery.TimestampFieldType,
+	}
+	seen := false
+	for _, row := range rows {
+		if col >= len(row) || row[col] == "" {
+			continue
+		}
+		seen = true
+		var keep []bigquery.FieldType
+		for _, t := range candidates {
+			if accepts(t, row[col]) {
+				keep = append(keep, t)
+			}
+		}
+		if candidates = keep; len(candidates) == 0 {
+			break
+		}
+	}
+	if !seen || len(candidates) == 0 {
+		return bigquery.StringFieldType
+	}
+	return candidates[0]
+}
+
+func accepts(t bigquery.FieldType, v string) bool {
+	var err error
+	switch t {
+	case bigquery.IntegerFieldType:
+		_, err = strconv.ParseInt(v, 10, 64)
+	case bigquery.FloatFieldType:
+		_, err = strconv.ParseFloat(v, 64)
+	case bigquery.BooleanFieldType:
+		_, err = strconv.ParseBool(v)
+	case bigquery.DateFieldType:
+		_, err = time.Parse("2006-01-02", v)
+	case bigquery.TimestampFieldType:
+		if _, err = time.Parse(time.RFC3339, v); err != nil {
+			_, err = time.Parse("2006-01-02 15:04:05", v)
+		}
+	}
+	return err == nil
+}
@@ -0,0 +1,144 @@
+package charts
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// Builder builds a Chart from a single data range with a chain of calls:
+//
+//	id, err := charts.LineChart("Sales!A1:C13").
+//		Title("Monthly sales").
+//		At("Report!H2").
+//		Size(600, 371).
+//		Add(ctx, srv, spreadsheetID)
+//
+// The first column of the range is the domain and every other column a
+// series; the first row holds the headers naming the series. The first
+// invalid argument is reported by Chart or Add.
+type Builder struct {
+	chart Chart
+	err   error
+}
+
+// LineChart starts a line chart of rng.
+func LineChart(rng string) *Builder { return Build(Line, rng) }
+
+// ColumnChart starts a column chart of rng.
+func ColumnChart(rng string) *Builder { return Build(Column, rng) }
+
+// PieChart starts a pie chart of rng, which must have two columns: the
+// slice labels and their values.
+func PieChart(rng string) *Builder { return Build(Pie, rng) }
+
+// Build starts a chart of type t drawn from rng, e.g. "Sales!A1:C13".
+// The range must name its first and last column.
+func Build(t Type, rng string) *Builder {
+	b := &Builder{chart: Chart{Type: t, Headers: 1}}
+	if !strings.Contains(rng, "!") {
+		b.err = fmt.Errorf("charts: range %q has no tab", rng)
+		return b
+	}
+	tab, cells := sheetsclient.SplitRange(rng)
+	g, err := sheetsclient.GridRange(0, cells)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	if cells == "" || g.EndColumnIndex == 0 {
+		b.err = fmt.Errorf("charts: range %q must name its columns, e.g. %s!A1:C", rng, tab)
+		return b
+	}
+	if g.EndColumnIndex-g.StartColumnIndex < 2 {
+		b.err = fmt.Errorf("charts: range %q needs a domain column and at least one series column", rng)
+		return b
+	}
+	if t == Pie && g.EndColumnIndex-g.StartColumnIndex != 2 {
+		b.err = fmt.Errorf("charts: pie chart range %q must have two columns", rng)
+		return b
+	}
+	column := func(i int64) string {
+		return sheetsclient.FormatRange(tab, &sheets.GridRange{
+			StartRowIndex:    g.StartRowIndex,
+			EndRowIndex:      g.EndRowIndex,
+			StartColumnIndex: i,
+			EndColumnIndex:   i + 1,
+		})
+	}
+	b.chart.Domain = column(g.StartColumnIndex)
+	for i := g.StartColumnIndex + 1; i < g.EndColumnIndex; i++ {
+		b.chart.Series = append(b.chart.Series, column(i))
+	}
+	return b
+}
+
+// Title sets the chart title.
+func (b *Builder) Title(title string) *Builder {
+	b.chart.Title = title
+	return b
+}
+
+// Headers sets the number of header rows at the top of the range;
+// defaults to 1. Zero plots every row.
+func (b *Builder) Headers(n int64) *Builder {
+	b.chart.Headers = n
+	return b
+}
+
+// Stacked stacks the series of a line or column chart.
+func (b *Builder) Stacked() *Builder {
+	b.chart.Stacked = true
+	return b
+}
+
+// Legend places the legend, e.g. "BOTTOM_LEGEND" or "NO_LEGEND".
+func (b *Builder) Legend(position string) *Builder {
+	b.chart.Legend = position
+	return b
+}
+
+// AxisTitles sets the titles of the x and y axes.
+func (b *Builder) AxisTitles(x, y string) *Builder {
+	b.chart.XTitle, b.chart.YTitle = x, y
+	return b
+}
+
+// At places the chart's top left corner over a cell, e.g. "Report!H2".
+// Without it the chart gets its own chart sheet.
+func (b *Builder) At(anchor string) *Builder {
+	if !strings.Contains(anchor, "!") && b.err == nil {
+		b.err = fmt.Errorf("charts: anchor %q has no tab", anchor)
+	}
+	b.chart.Anchor = anchor
+	return b
+}
+
+// Size sets the width and height of the chart in pixels.
+func (b *Builder) Size(width, height int64) *Builder {
+	b.chart.Width, b.chart.Height = width, height
+	return b
+}
+
+// Chart returns the chart built.
+func (b *Builder) Chart() (*Chart, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	c := b.chart
+	c.Series = append([]string(nil), b.chart.Series...)
+	return &c, nil
+}
+
+// Add creates the chart and returns its ID.
+func (b *Builder) Add(ctx context.Context, srv *sheets.Service, spreadsheetID string) (int64, error) {
+	c, err := b.Chart()
+	if err != nil {
+		return 0, err
+	}
+	return Add(ctx, srv, spreadsheetID, c)
+}
@@ -0,0 +1,309 @@
+// Package charts creates, lists and updates charts on a spreadsheet, so
+// generated reports can include charts next to their tables.
+package charts
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// Type is the kind of chart.
+type Type string
+
+// Chart types. Bar charts are horizontal, Column charts vertical.
+const (
+	Line    Type = "LINE"
+	Area    Type = "AREA"
+	Bar     Type = "BAR"
+	Column  Type = "COLUMN"
+	Scatter Type = "SCATTER"
+	Pie     Type = "PIE"
+)
+
+// Chart describes a chart drawn from ranges of a spreadsheet. Ranges are
+// in A1 notation and must include the tab, e.g. "Data!A1:A20".
+type Chart struct {
+	Title string
+	Type  Type
+	// Domain holds the x axis values, or the slice labels of a pie chart.
+	Domain string
+	// Series holds one range per plotted series, aligned with Domain. Pie
+	// charts take exactly one.
+	Series []string
+	// Headers is the number of header rows at the top of the ranges; they
+	// name the series instead of being plotted.
+	Headers int64
+	Stacked bool
+	// Legend is a legend position such as "BOTTOM_LEGEND" or "NO_LEGEND";
+	// defaults to "RIGHT_LEGEND".
+	Legend         string
+	XTitle, YTitle string
+
+	// Anchor is the cell, e.g. "Report!H2", the chart's top left corner
+	// is placed over. Without one the chart gets its own chart sheet.
+	Anchor        string
+	Width, Height int64
+}
+
+// Info describes an existing chart.
+type Info struct {
+	ID     int64
+	Tab    string
+	Title  string
+	Type   Type
+	Domain string
+	Series []string
+}
+
+// Add creates c and returns the new chart's ID.
+func Add(ctx context.Context, srv *sheets.Service, spreadsheetID string, c *Chart) (int64, error) {
+	tabs, err := sheetsclient.LoadTabs(ctx, srv, spreadsheetID)
+	if err != nil {
+		return 0, err
+	}
+	spec, err := c.spec(tabs)
+	if err != nil {
+		return 0, err
+	}
+	pos := &sheets.EmbeddedObjectPosition{NewSheet: true}
+	if c.Anchor != "" {
+		g, err := tabs.GridRange(c.Anchor)
+		if err != nil {
+			return 0, err
+		}
+		pos = &sheets.EmbeddedObjectPosition{OverlayPosition: &sheets.OverlayPosition{
+			AnchorCell: &sheets.GridCoordinate{
+				SheetId:         g.SheetId,
+				RowIndex:        g.StartRowIndex,
+				ColumnIndex:     g.StartColumnIndex,
+				ForceSendFields: []string{"SheetId", "RowIndex", "ColumnIndex"},
+			},
+			WidthPixels:  c.Width,
+			HeightPixels: c.Height,
+		}}
+	}
+	resp, err := srv.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{AddChart: &sheets.AddChartRequest{
+			Chart: &sheets.EmbeddedChart{Spec: spec, Position: pos},
+		}}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return 0, fmt.Errorf("charts: unable to add chart %q: %w", c.Title, err)
+	}
+	return resp.Replies[0].AddChart.Chart.ChartId, nil
+}
+
+// Update replaces the spec of an existing chart with c. The chart keeps
+// its position; c.Anchor is ignored.
+func Update(ctx context.Context, srv *sheets.Service, spreadsheetID string, chartID int64, c *Chart) error {
+	tabs, err := sheetsclient.LoadTabs(ctx, srv, spreadsheetID)
+	if err != nil {
+		return err
+	}
+	spec, err := c.spec(tabs)
+	if err != nil {
+		return err
+	}
+	return updateSpec(ctx, srv, spreadsheetID, chartID, spec)
+}
+
+// SetRanges points an existing chart at new data ranges, e.g. after the
+// data moved or grew, while keeping everything else about the chart,
+// including styling edited by hand.
+func SetRanges(ctx context.Context, srv *sheets.Service, spreadsheetID string, chartID int64, domain string, series ...string) error {
+	resp, err := srv.Spreadsheets.Get(spreadsheetID).
+		Fields("sheets(properties(sheetId,title),charts(chartId,spec)),namedRanges").Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("charts: unable to get charts: %w", err)
+	}
+	tabs := sheetsclient.NewTabs(resp)
+	var spec *sheets.ChartSpec
+	for _, sh := range resp.Sheets {
+		for _, ch := range sh.Charts {
+			if ch.ChartId == chartID {
+				spec = ch.Spec
+			}
+		}
+	}
+	if spec == nil {
+		return fmt.Errorf("charts: no chart with ID %d", chartID)
+	}
+	d, err := data(tabs, domain)
+	if err != nil {
+		return err
+	}
+	switch {
+	case spec.BasicChart != nil:
+		b := spec.BasicChart
+		b.Domains = []*sheets.BasicChartDomain{{Domain: d}}
+		old := b.Series
+		b.Series = nil
+		for i, rng := range series {
+			s, err := data(tabs, rng)
+			if err != nil {
+				return err
+			}
+			// Keep the styling of the series at the same position.
+			bs := &sheets.BasicChartSeries{TargetAxis: "LEFT_AXIS"}
+			if b.ChartType == string(Bar) {
+				bs.TargetAxis = "BOTTOM_AXIS"
+			}
+			if i < len(old) {
+				bs = old[i]
+			}
+			bs.Series = s
+			b.Series = append(b.Series, bs)
+		}
+	case spec.PieChart != nil:
+		if len(series) != 1 {
+			return fmt.Errorf("charts: pie charts take one series, got %d", len(series))
+		}
+		s, err := data(tabs, series[0])
+		if err != nil {
+			return err
+		}
+		spec.PieChart.Domain, spec.PieChart.Series = d, s
+	default:
+		return fmt.Errorf("charts: chart %d is not a basic or pie chart", chartID)
+	}
+	return updateSpec(ctx, srv, spreadsheetID, chartID, spec)
+}
+
+func updateSpec(ctx context.Context, srv *sheets.Service, spreadsheetID string, chartID int64, spec *sheets.ChartSpec) error {
+	_, err := srv.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{UpdateChartSpec: &sheets.UpdateChartSpecRequest{ChartId: chartID, Spec: spec}}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("charts: unable to update chart %d: %w", chartID, err)
+	}
+	return nil
+}
+
+// Delete removes a chart.
+func Delete(ctx context.Context, srv *sheets.Service, spreadsheetID string, chartID int64) error {
+	_, err := srv.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{DeleteEmbeddedObject: &sheets.DeleteEmbeddedObjectRequest{ObjectId: chartID}}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("charts: unable to delete chart %d: %w", chartID, err)
+	}
+	return nil
+}
+
+// List returns the basic and pie charts of a spreadsheet.
+func List(ctx context.Context, srv *sheets.Service, spreadsheetID string) ([]*Info, error) {
+	resp, err := srv.Spreadsheets.Get(spreadsheetID).
+		Fields("sheets(properties(sheetId,title),charts(chartId,spec))").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("charts: unable to get charts: %w", err)
+	}
+	names := map[int64]string{}
+	for _, sh := range resp.Sheets {
+		names[sh.Properties.SheetId] = sh.Properties.Title
+	}
+	rangeOf := func(d *sheets.ChartData) string {
+		if d == nil || d.SourceRange == nil || len(d.SourceRange.Sources) == 0 {
+			return ""
+		}
+		g := d.SourceRange.Sources[0]
+		return sheetsclient.FormatRange(names[g.SheetId], g)
+	}
+
+	var out []*Info
+	for _, sh := range resp.Sheets {
+		for _, ch := range sh.Charts {
+			info := &Info{ID: ch.ChartId, Tab: sh.Properties.Title, Title: ch.Spec.Title}
+			switch {
+			case ch.Spec.BasicChart != nil:
+				b := ch.Spec.BasicChart
+				info.Type = Type(b.ChartType)
+				if len(b.Domains) > 0 {
+					info.Domain = rangeOf(b.Domains[0].Domain)
+				}
+				for _, s := range b.Series {
+					info.Series = append(info.Series, rangeOf(s.Series))
+				}
+			case ch.Spec.PieChart != nil:
+				info.Type = Pie
+				info.Domain = rangeOf(ch.Spec.PieChart.Domain)
+				info.Series = []string{rangeOf(ch.Spec.PieChart.Series)}
+			default:
+				continue
+			}
+			out = append(out, info)
+		}
+	}
+	return out, nil
+}
+
+func (c *Chart) spec(tabs *sheetsclient.Tabs) (*sheets.ChartSpec, error) {
+	if len(c.Series) == 0 {
+		return nil, fmt.Errorf("charts: chart %q has no series", c.Title)
+	}
+	legend := c.Legend
+	if legend == "" {
+		legend = "RIGHT_LEGEND"
+	}
+	domain, err := data(tabs, c.Domain)
+	if err != nil {
+		return nil, err
+	}
+	spec := &sheets.ChartSpec{Title: c.Title}
+
+	if c.Type == Pie {
+		if len(c.Series) != 1 {
+			return nil, fmt.Errorf("charts: pie charts take one series, got %d", len(c.Series))
+		}
+		s, err := data(tabs, c.Series[0])
+		if err != nil {
+			return nil, err
+		}
+		spec.PieChart = &sheets.PieChartSpec{Domain: domain, Series: s, LegendPosition: legend}
+		return spec, nil
+	}
+
+	switch c.Type {
+	case Line, Area, Bar, Column, Scatter:
+	default:
+		return nil, fmt.Errorf("charts: unknown chart type %q", c.Type)
+	}
+	// For horizontal bars the values run along the bottom axis.
+	domainAxis, valueAxis := "BOTTOM_AXIS", "LEFT_AXIS"
+	if c.Type == Bar {
+		domainAxis, valueAxis = valueAxis, domainAxis
+	}
+	b := &sheets.BasicChartSpec{
+		ChartType:      string(c.Type),
+		LegendPosition: legend,
+		HeaderCount:    c.Headers,
+		Domains:        []*sheets.BasicChartDomain{{Domain: domain}},
+		Axis: []*sheets.BasicChartAxis{
+			{Position: domainAxis, Title: c.XTitle},
+			{Position: valueAxis, Title: c.YTitle},
+		},
+	}
+	if c.Stacked {
+		b.StackedType = "STACKED"
+	}
+	for _, rng := range c.Series {
+		s, err := data(tabs, rng)
+		if err != nil {
+			return nil, err
+		}
+		b.Series = append(b.Series, &sheets.BasicChartSeries{Series: s, TargetAxis: valueAxis})
+	}
+	spec.BasicChart = b
+	return spec, nil
+}
+
+func data(tabs *sheetsclient.Tabs, rng string) (*sheets.ChartData, error) {
+	g, err := tabs.GridRange(rng)
+	if err != nil {
+		return nil, err
+	}
+	return &sheets.ChartData{SourceRange: &sheets.ChartSourceRange{Sources: []*sheets.GridRange{g}}}, nil
+}
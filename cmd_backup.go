@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prantoran/GoogleSheets_GO/backup"
+)
+
+func runBackup(args []string) {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	ids := fs.String("spreadsheet", "", "comma separated IDs of the spreadsheets to back up")
+	dest := fs.String("dest", "backups", "local directory or gs://bucket/prefix to store snapshots in")
+	every := fs.Duration("every", 0, "keep running and take a snapshot at this interval")
+	keep := fs.Int("keep", 0, "keep at most this many snapshots per spreadsheet")
+	maxAge := fs.Duration("max-age", 0, "delete snapshots older than this")
+	var opts backup.Options
+	fs.BoolVar(&opts.Formulas, "formulas", true, "also save formulas")
+	fs.BoolVar(&opts.Formats, "formats", true, "also save cell formats")
+	fs.Parse(args)
+	// Accept the IDs before the flags, as in "backup ID -dest dir".
+	if fs.NArg() > 0 && *ids == "" {
+		*ids = fs.Arg(0)
+		fs.Parse(fs.Args()[1:])
+	}
+	if *ids == "" || fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: backup -spreadsheet ID,... [-dest DIR|gs://BUCKET/PREFIX] [flags]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	store, err := backup.OpenStore(ctx, *dest)
+	checkError("Unable to open backup destination: ", err)
+	srv := newSheetsService(ctx)
+	retention := backup.Retention{Keep: *keep, MaxAge: *maxAge}
+
+	for {
+		failed := false
+		for _, id := range strings.Split(*ids, ",") {
+			name, err := backup.Backup(ctx, srv, store, id, opts)
+			if err != nil {
+				log.Print(err)
+				failed = true
+				continue
+			}
+			log.Printf("Saved %s", name)
+			deleted, err := backup.Prune(ctx, store, id, retention)
+			for _, d := range deleted {
+				log.Printf("Deleted %s", d)
+			}
+			if err != nil {
+				log.Print(err)
+			}
+		}
+		if *every <= 0 {
+			if failed {
+				os.Exit(1)
+			}
+			return
+		}
+		time.Sleep(*every)
+	}
+}
+
+func runRestore(args []string) {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	from := fs.String("from", "", "snapshot directory, local or gs://bucket/prefix")
+	dest := fs.String("dest", "backups", "backup destination to take the latest snapshot from")
+	id := fs.String("spreadsheet", "", "restore the latest snapshot of this spreadsheet from -dest")
+	title := fs.String("title", "", "title of the restored spreadsheet (default: the original title)")
+	into := fs.String("into", "", "overwrite this spreadsheet instead of creating one")
+	force := fs.Bool("force", false, "with -into, allow replacing the spreadsheet's tabs")
+	fs.Parse(args)
+	if (*from == "") == (*id == "") || fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: restore (-from SNAPSHOT | -spreadsheet ID [-dest DIR|gs://BUCKET/PREFIX]) [-title TITLE] [-into ID -force]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+	if *into != "" && !*force {
+		log.Fatalf("Restoring into %s deletes or clears all of its tabs; rerun with -force to do so", *into)
+	}
+
+	ctx := commandContext()
+	src, dir := *from, ""
+	if src == "" {
+		src = *dest
+	}
+	store, err := backup.OpenStore(ctx, src)
+	checkError("Unable to open backup destination: ", err)
+	if *id != "" {
+		snaps, err := backup.List(ctx, store, *id)
+		checkError("Unable to list snapshots: ", err)
+		if len(snaps) == 0 {
+			log.Fatalf("No snapshots of %s in %s", *id, *dest)
+		}
+		dir = snaps[len(snaps)-1].Name
+	}
+	ss, err := backup.Restore(ctx, newSheetsService(ctx), store, dir, *into, *title)
+	checkError("Unable to restore snapshot: ", err)
+	if dir == "" {
+		dir = src
+	}
+	fmt.Printf("Restored %s to %s\n", dir, ss.SpreadsheetUrl)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+func runBatchUpdate(args []string) {
+	fs := flag.NewFlagSet("batch-update", flag.ExitOnError)
+	spreadsheetID := fs.String("spreadsheet", "", "spreadsheet ID")
+	in := fs.String("in", "", "JSON or CSV file mapping ranges to values (default stdin)")
+	format := fs.String("format", "", "input format, json or csv (default from the -in extension, else json)")
+	raw := fs.Bool("raw", false, "store values as given instead of parsing numbers, dates and formulas")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, `usage: batch-update -spreadsheet ID [-in FILE] [flags]
+
+Writes several ranges in one atomic request. JSON input maps ranges to rows:
+  {"Sheet1!A1": [["Name", "Qty"], ["a", 1]], "Totals!B2": [["=SUM(Sheet1!B:B)"]]}
+CSV input has the range in the first column and the cells of one row after
+it; rows of the same range are written one below the other:
+  Sheet1!A1,Name,Qty
+  Sheet1!A1,a,1`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *spreadsheetID == "" || fs.NArg() > 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *format == "" {
+		*format = "json"
+		if strings.HasSuffix(strings.ToLower(*in), ".csv") {
+			*format = "csv"
+		}
+	}
+
+	r := io.Reader(os.Stdin)
+	if *in != "" && *in != "-" {
+		f, err := os.Open(*in)
+		checkError("Unable to open input file: ", err)
+		defer f.Close()
+		r = f
+	}
+	data := map[string][][]interface{}{}
+	switch *format {
+	case "json":
+		b, err := ioutil.ReadAll(r)
+		checkError("Unable to read input: ", err)
+		checkError("Unable to parse input: ", json.Unmarshal(b, &data))
+	case "csv":
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		records, err := cr.ReadAll()
+		checkError("Unable to parse input: ", err)
+		for _, rec := range records {
+			if len(rec) == 0 || rec[0] == "" {
+				continue
+			}
+			row := make([]interface{}, len(rec)-1)
+			for i, v := range rec[1:] {
+				row[i] = v
+			}
+			data[rec[0]] = append(data[rec[0]], row)
+		}
+	default:
+		checkError("Unable to parse input: ", fmt.Errorf("unknown format %q", *format))
+	}
+
+	ctx := commandContext()
+	c := newClient(ctx)
+	if *raw {
+		c.ValueInputOption = "RAW"
+	}
+	res, err := c.BatchUpdate(ctx, *spreadsheetID, data)
+	checkError("Unable to update ranges: ", err)
+	for _, rng := range res.Ranges {
+		fmt.Println(rng)
+	}
+	fmt.Printf("Updated %d cells in %d rows across %d ranges\n", res.Cells, res.Rows, len(res.Ranges))
+}
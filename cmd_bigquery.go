@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/storage"
+	"golang.org/x/net/context"
+
+	"github.com/prantoran/GoogleSheets_GO/bqsheets"
+)
+
+// newBridge connects to BigQuery, and to Cloud Storage when a staging
+// bucket is given, using Application Default Credentials.
+func newBridge(ctx context.Context, project, bucket string) *bqsheets.Bridge {
+	bq, err := bigquery.NewClient(ctx, project)
+	checkError("Unable to create BigQuery client: ", err)
+	b := &bqsheets.Bridge{Sheets: newSheetsService(ctx), BigQuery: bq, StagingBucket: bucket}
+	if bucket != "" {
+		b.Storage, err = storage.NewClient(ctx)
+		checkError("Unable to create Cloud Storage client: ", err)
+	}
+	return b
+}
+
+func runBQLoad(args []string) {
+	fs := flag.NewFlagSet("bq-load", flag.ExitOnError)
+	spreadsheetID := fs.String("spreadsheet", "", "spreadsheet ID")
+	rng := fs.String("range", "", "range to load, header row first")
+	csvPath := fs.String("csv", "", "load this exported CSV file instead of reading the sheet")
+	table := fs.String("table", "", "destination table as [project.]dataset.table")
+	project := fs.String("project", os.Getenv("GOOGLE_CLOUD_PROJECT"), "BigQuery project (default $GOOGLE_CLOUD_PROJECT)")
+	bucket := fs.String("bucket", "", "Cloud Storage bucket used to stage the data")
+	appendRows := fs.Bool("append", false, "append to the table instead of replacing its content")
+	fs.Parse(args)
+
+	projectID, dataset, tableID, ok := parseTableRef(*table, *project)
+	if !ok || (*csvPath == "") == (*spreadsheetID == "" || *rng == "") {
+		fmt.Fprintln(os.Stderr, "usage: bq-load -table [project.]dataset.table (-spreadsheet ID -range RANGE | -csv FILE) [flags]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	b := newBridge(ctx, projectID, *bucket)
+	var rows [][]string
+	var err error
+	if *csvPath != "" {
+		f, err := os.Open(*csvPath)
+		checkError("Unable to open CSV file: ", err)
+		r := csv.NewReader(f)
+		r.FieldsPerRecord = -1
+		rows, err = r.ReadAll()
+		f.Close()
+		checkError("Unable to read CSV file: ", err)
+	} else {
+		rows, err = b.ReadRange(ctx, *spreadsheetID, *rng)
+		checkError("Unable to read range: ", err)
+	}
+
+	dst := b.BigQuery.DatasetInProject(projectID, dataset).Table(tableID)
+	checkError("Unable to load table: ", b.Load(ctx, dst, rows, nil, !*appendRows))
+	fmt.Printf("Loaded %d rows into %s.%s.%s\n", len(rows)-1, projectID, dataset, tableID)
+}
+
+func runBQPublish(args []string) {
+	fs := flag.NewFlagSet("bq-publish", flag.ExitOnError)
+	query := fs.String("query", "", "standard SQL query")
+	queryFile := fs.String("query-file", "", "read the query from this file")
+	spreadsheetID := fs.String("spreadsheet", "", "spreadsheet ID")
+	tab := fs.String("tab", "", "tab replaced with the query result")
+	project := fs.String("project", os.Getenv("GOOGLE_CLOUD_PROJECT"), "BigQuery project (default $GOOGLE_CLOUD_PROJECT)")
+	bucket := fs.String("bucket", "", "Cloud Storage bucket used to stage large results")
+	fs.Parse(args)
+
+	if *queryFile != "" {
+		b, err := ioutil.ReadFile(*queryFile)
+		checkError("Unable to read query file: ", err)
+		*query = string(b)
+	}
+	if *query == "" || *spreadsheetID == "" || *tab == "" || *project == "" {
+		fmt.Fprintln(os.Stderr, "usage: bq-publish (-query SQL | -query-file FILE) -spreadsheet ID -tab TAB [flags]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	n, err := newBridge(ctx, *project, *bucket).Publish(ctx, *query, *spreadsheetID, *tab)
+	checkError("Unable to publish query result: ", err)
+	fmt.Printf("Wrote %d rows to %s\n", n, *tab)
+}
+
+// parseTableRef splits "[project.]dataset.table", using defaultProject when
+// the project is omitted.
+func parseTableRef(ref, defaultProject string) (project, dataset, table string, ok bool) {
+	parts := strings.Split(ref, ".")
+	switch len(parts) {
+	case 2:
+		project, dataset, table = defaultProject, parts[0], parts[1]
+	case 3:
+		project, dataset, table = parts[0], parts[1], parts[2]
+	default:
+		return "", "", "", false
+	}
+	return project, dataset, table, project != "" && dataset != "" && table != ""
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/prantoran/GoogleSheets_GO/charts"
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+const chartUsage = `usage:
+  chart add -type line|column|pie -range RANGE [flags] SPREADSHEET_ID
+                                         add a chart and print its ID
+  chart list SPREADSHEET_ID              print the charts of a spreadsheet
+  chart delete SPREADSHEET_ID CHART_ID...
+
+The first column of the range is the domain, every other column a series
+and the first row their names, e.g.
+  chart add -type column -range Sales!A1:C13 -title "Monthly sales" -at Report!H2 ID
+
+Flags:
+`
+
+func runChart(args []string) {
+	nargs := map[string]int{"add": 1, "list": 1, "delete": 2}
+	if len(args) == 0 || nargs[args[0]] == 0 {
+		fmt.Fprint(os.Stderr, chartUsage)
+		os.Exit(2)
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("chart "+action, flag.ExitOnError)
+	typ := fs.String("type", "line", "add: chart type: line, column or pie")
+	rng := fs.String("range", "", "add: data range, domain column first, e.g. \"Sales!A1:C13\"")
+	title := fs.String("title", "", "add: chart title")
+	anchor := fs.String("at", "", "add: cell to place the chart over, e.g. \"Report!H2\"; default a new chart sheet")
+	width := fs.Int64("width", 0, "add: width in pixels")
+	height := fs.Int64("height", 0, "add: height in pixels")
+	headers := fs.Int64("headers", 1, "add: number of header rows in the range")
+	stacked := fs.Bool("stacked", false, "add: stack the series")
+	legend := fs.String("legend", "", "add: legend position, e.g. BOTTOM_LEGEND or NO_LEGEND")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, chartUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+	if fs.NArg() < nargs[action] || (action != "delete" && fs.NArg() != 1) || (action == "add" && *rng == "") {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	srv := newSheetsService(ctx)
+	id := fs.Arg(0)
+	switch action {
+	case "add":
+		// The builder splits the range into columns, so a named range is
+		// resolved to its cells first.
+		source, err := sheetsclient.FromService(srv).ResolveRange(ctx, id, *rng)
+		checkError("Unable to resolve range: ", err)
+		var b *charts.Builder
+		switch strings.ToLower(*typ) {
+		case "line":
+			b = charts.LineChart(source)
+		case "column":
+			b = charts.ColumnChart(source)
+		case "pie":
+			b = charts.PieChart(source)
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown chart type %q\n", *typ)
+			os.Exit(2)
+		}
+		b.Title(*title).Headers(*headers).Legend(*legend)
+		if *stacked {
+			b.Stacked()
+		}
+		if *anchor != "" {
+			b.At(*anchor).Size(*width, *height)
+		}
+		chartID, err := b.Add(ctx, srv, id)
+		checkError("Unable to add chart: ", err)
+		fmt.Println(chartID)
+	case "list":
+		list, err := charts.List(ctx, srv, id)
+		checkError("Unable to list charts: ", err)
+		for _, c := range list {
+			fmt.Printf("%d\t%s\t%s\t%s\t%s\n", c.ID, c.Tab, c.Type, c.Title, strings.Join(append([]string{c.Domain}, c.Series...), " "))
+		}
+	case "delete":
+		for _, arg := range fs.Args()[1:] {
+			chartID, err := strconv.ParseInt(arg, 10, 64)
+			checkError("Invalid chart ID: ", err)
+			checkError("Unable to delete chart: ", charts.Delete(ctx, srv, id, chartID))
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+
+	"github.com/prantoran/GoogleSheets_GO/comments"
+)
+
+const commentsUsage = `usage:
+  comments list [-resolved] [-json] SPREADSHEET_ID
+  comments add [-tab TAB -row N] SPREADSHEET_ID MESSAGE
+  comments reply SPREADSHEET_ID COMMENT_ID MESSAGE
+  comments resolve SPREADSHEET_ID COMMENT_ID [MESSAGE]
+
+`
+
+func runComments(args []string) {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, commentsUsage)
+		os.Exit(2)
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("comments "+action, flag.ExitOnError)
+	resolved := fs.Bool("resolved", false, "include resolved threads")
+	asJSON := fs.Bool("json", false, "print comments as JSON")
+	tab := fs.String("tab", "", "tab of the row to flag")
+	row := fs.Int("row", 0, "1-based row to flag")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, commentsUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+	want := map[string]int{"list": 1, "add": 2, "reply": 3, "resolve": 2}
+	if n, ok := want[action]; !ok || fs.NArg() < n {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	// Comments are not covered by the metadata scope the other commands
+	// use.
+	scopes = append(scopes, drive.DriveScope)
+	ctx := commandContext()
+	srv := newDriveService(ctx)
+	id := fs.Arg(0)
+
+	switch action {
+	case "list":
+		list, err := comments.List(ctx, srv, id, *resolved)
+		checkError("Unable to list comments: ", err)
+		if *asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			checkError("Unable to write comments: ", enc.Encode(list))
+			return
+		}
+		for _, c := range list {
+			state := ""
+			if c.Resolved {
+				state = " [resolved]"
+			}
+			fmt.Printf("%s %s %s%s\n  %s\n", c.ID, c.Created.Format("2006-01-02 15:04"), c.Author, state, c.Content)
+			if c.Quoted != "" {
+				fmt.Printf("  > %s\n", c.Quoted)
+			}
+			for _, r := range c.Replies {
+				fmt.Printf("    %s %s: %s\n", r.Created.Format("2006-01-02 15:04"), r.Author, strings.TrimSpace(r.Action+" "+r.Content))
+			}
+		}
+	case "add":
+		message := strings.Join(fs.Args()[1:], " ")
+		var c *comments.Comment
+		var err error
+		if *tab != "" && *row > 0 {
+			c, err = comments.FlagRow(ctx, srv, newSheetsService(ctx), id, *tab, *row, message)
+		} else {
+			c, err = comments.Create(ctx, srv, id, message, "")
+		}
+		checkError("Unable to add comment: ", err)
+		fmt.Println(c.ID)
+	case "reply":
+		checkError("Unable to reply: ", comments.AddReply(ctx, srv, id, fs.Arg(1), strings.Join(fs.Args()[2:], " ")))
+	case "resolve":
+		checkError("Unable to resolve comment: ", comments.Resolve(ctx, srv, id, fs.Arg(1), strings.Join(fs.Args()[2:], " ")))
+	}
+}
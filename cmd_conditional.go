@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/prantoran/GoogleSheets_GO/formatting"
+)
+
+const conditionalUsage = `usage:
+  conditional list [-json] SPREADSHEET_ID
+  conditional add -range RANGE -when CONDITION [-value V...] [style flags] SPREADSHEET_ID
+  conditional add -range RANGE -scale MIN_COLOR[,MID_COLOR],MAX_COLOR SPREADSHEET_ID
+  conditional delete -tab TAB -index N SPREADSHEET_ID
+
+Conditions are types such as NUMBER_GREATER, TEXT_CONTAINS, BLANK or
+CUSTOM_FORMULA, e.g. -when NUMBER_GREATER -value 100 -background "#F4CCCC".
+
+Flags:
+`
+
+func runConditional(args []string) {
+	if len(args) == 0 || (args[0] != "list" && args[0] != "add" && args[0] != "delete") {
+		fmt.Fprint(os.Stderr, conditionalUsage)
+		os.Exit(2)
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("conditional "+action, flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "list: print the rules as JSON")
+	var ranges, values stringList
+	fs.Var(&ranges, "range", "add: range the rule applies to; may be repeated")
+	when := fs.String("when", "", "add: condition type")
+	fs.Var(&values, "value", "add: value or formula of the condition; repeat for conditions taking two")
+	scale := fs.String("scale", "", "add: comma separated colors of a color scale from the minimum to the maximum")
+	bold := fs.Bool("bold", false, "add: bold text")
+	italic := fs.Bool("italic", false, "add: italic text")
+	strike := fs.Bool("strikethrough", false, "add: struck through text")
+	color := fs.String("color", "", "add: text color as #RRGGBB")
+	background := fs.String("background", "", "add: fill color as #RRGGBB")
+	tab := fs.String("tab", "", "delete: tab of the rule")
+	index := fs.Int64("index", 0, "add: position of the new rule, 0 being evaluated first; delete: rule to delete")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, conditionalUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+	if fs.NArg() != 1 || (action == "add" && (len(ranges) == 0 || (*when == "") == (*scale == ""))) || (action == "delete" && *tab == "") {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	srv := newSheetsService(ctx)
+	spreadsheetID := fs.Arg(0)
+	switch action {
+	case "list":
+		rules, err := formatting.Rules(ctx, srv, spreadsheetID)
+		checkError("Unable to list conditional format rules: ", err)
+		if *asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			checkError("Unable to write rules: ", enc.Encode(rules))
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "TAB\tINDEX\tRANGES\tRULE")
+		for _, r := range rules {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", r.Tab, r.Index, strings.Join(r.Ranges, ", "), describeRule(r))
+		}
+		checkError("Unable to write rules: ", w.Flush())
+	case "add":
+		r := &formatting.Rule{Ranges: ranges, Index: *index}
+		if *scale != "" {
+			if r.Scale = formatting.ColorScale(strings.Split(*scale, ",")...); r.Scale == nil {
+				fs.Usage()
+				os.Exit(2)
+			}
+		} else {
+			r.Condition, r.Values = *when, values
+			r.Style = &formatting.Style{Bold: *bold, Italic: *italic, Strikethrough: *strike, Color: *color, Background: *background}
+		}
+		checkError("Unable to add conditional format rule: ", formatting.AddRule(ctx, srv, spreadsheetID, r))
+		fmt.Printf("Added rule to %s\n", strings.Join(ranges, ", "))
+	case "delete":
+		checkError("Unable to delete conditional format rule: ", formatting.DeleteRule(ctx, srv, spreadsheetID, *tab, *index))
+		fmt.Printf("Deleted rule %d of %s\n", *index, *tab)
+	}
+}
+
+// describeRule summarizes a rule on one line, e.g.
+// "NUMBER_GREATER 100: bold #F4CCCC".
+func describeRule(r *formatting.Rule) string {
+	if len(r.Scale) > 0 {
+		var colors []string
+		for _, p := range r.Scale {
+			colors = append(colors, p.Color)
+		}
+		return "color scale " + strings.Join(colors, " → ")
+	}
+	s := strings.TrimSpace(r.Condition + " " + strings.Join(r.Values, " "))
+	var style []string
+	if st := r.Style; st != nil {
+		for _, f := range []struct {
+			on   bool
+			name string
+		}{{st.Bold, "bold"}, {st.Italic, "italic"}, {st.Underline, "underline"}, {st.Strikethrough, "strikethrough"}} {
+			if f.on {
+				style = append(style, f.name)
+			}
+		}
+		if st.Color != "" {
+			style = append(style, "text "+st.Color)
+		}
+		if st.Background != "" {
+			style = append(style, st.Background)
+		}
+	}
+	if len(style) > 0 {
+		s += ": " + strings.Join(style, " ")
+	}
+	return s
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+func runCreate(args []string) {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	tabs := fs.String("tabs", "", "comma separated names of the initial tabs (default one tab, Sheet1)")
+	locale := fs.String("locale", "", "locale such as en_GB, deciding date and number formats")
+	timeZone := fs.String("timezone", "", "time zone such as Europe/Berlin")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: create [-tabs A,B] [-locale LOCALE] [-timezone ZONE] TITLE")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	opts := &sheetsclient.CreateOptions{Title: fs.Arg(0), Locale: *locale, TimeZone: *timeZone}
+	if *tabs != "" {
+		opts.Tabs = strings.Split(*tabs, ",")
+	}
+	ctx := commandContext()
+	s, err := newClient(ctx).Create(ctx, opts)
+	checkError("Unable to create spreadsheet: ", err)
+	fmt.Println(s.ID)
+	fmt.Println(s.URL)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/prantoran/GoogleSheets_GO/daemon"
+)
+
+func runDaemon(args []string) {
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	configPath := fs.String("config", "daemon.json", "daemon configuration file")
+	listen := fs.String("listen", "", "address to serve /healthz and /status on (overrides the config)")
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "usage: daemon [-config FILE] [-listen ADDR]")
+		os.Exit(2)
+	}
+
+	config, err := daemon.LoadConfig(*configPath)
+	checkError("Unable to load daemon config: ", err)
+	if *listen != "" {
+		config.Listen = *listen
+	}
+	self, err := os.Executable()
+	checkError("Unable to find own executable: ", err)
+
+	// Each job runs as a child process of this binary, so that commands
+	// exiting on errors only end their own run. Children get the global
+	// flags of the daemon and are asked to stop with SIGTERM when their
+	// context is cancelled.
+	global := globalArgs()
+	runner := func(ctx context.Context, j *daemon.Job, out io.Writer) error {
+		args := append(append(append([]string{}, global...), j.Command), j.Args...)
+		cmd := exec.CommandContext(ctx, self, args...)
+		cmd.Cancel = func() error { return cmd.Process.Signal(syscall.SIGTERM) }
+		cmd.WaitDelay = 30 * time.Second
+		cmd.Stdout, cmd.Stderr = out, out
+		return cmd.Run()
+	}
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	d := daemon.New(config, runner, logger)
+	d.Start()
+
+	srv := &http.Server{Addr: config.Listen, Handler: d}
+	go func() {
+		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+			logger.Error("status server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	<-sig
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	srv.Shutdown(ctx)
+	d.Stop(ctx)
+}
+
+// globalArgs returns the global flags set on the command line, so that
+// jobs use the same credentials, profile and limits as the daemon. The
+// -timeout of the daemon is left out; jobs have their own.
+func globalArgs() []string {
+	var args []string
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name != "timeout" {
+			args = append(args, "-"+f.Name+"="+f.Value.String())
+		}
+	})
+	return args
+}
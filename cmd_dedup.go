@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/prantoran/GoogleSheets_GO/dedup"
+)
+
+func runDedup(args []string) {
+	fs := flag.NewFlagSet("dedup", flag.ExitOnError)
+	key := fs.String("key", "", "comma separated header names identifying a row")
+	keep := fs.String("keep", "first", "row to keep of each duplicate group: first or last")
+	ignoreCase := fs.Bool("ignore-case", false, "compare keys case-insensitively")
+	del := fs.Bool("delete", false, "delete the duplicates; without it they are only reported")
+	asJSON := fs.Bool("json", false, "print the duplicate groups as JSON")
+	rng := fs.String("range", "", "range to deduplicate instead of TAB, e.g. \"Data!C1:F\", starting at its header")
+	keyColumns := fs.String("key-columns", "", "comma separated letters of further columns identifying a row, e.g. \"A,B\"")
+	// Accept flags after the positional arguments, as in "dedup ID TAB -key Email".
+	var pos []string
+	for len(args) > 0 {
+		fs.Parse(args)
+		if fs.NArg() == 0 {
+			break
+		}
+		pos = append(pos, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if *rng != "" && len(pos) == 1 {
+		pos = append(pos, *rng)
+	}
+	if len(pos) != 2 || (*keep != "first" && *keep != "last") {
+		fmt.Fprintln(os.Stderr, "usage: dedup SPREADSHEET_ID TAB|RANGE [-key NAME[,NAME]] [-key-columns A[,B]] [-keep first|last] [-delete]")
+		fmt.Fprintln(os.Stderr, "Without -key or -key-columns rows are duplicates when all their cells match.")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	opts := dedup.Options{Keys: columnList(*key), Columns: columnList(*keyColumns), IgnoreCase: *ignoreCase}
+	if *keep == "last" {
+		opts.Keep = dedup.Last
+	}
+	ctx := commandContext()
+	groups, err := dedup.Dedup(ctx, newSheetsService(ctx), pos[0], pos[1], opts, !*del)
+	checkError("Unable to deduplicate rows: ", err)
+
+	n := 0
+	for _, g := range groups {
+		n += len(g.Delete)
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		checkError("Unable to write duplicates: ", enc.Encode(groups))
+	} else {
+		for _, g := range groups {
+			fmt.Printf("%s: keeping row %d, duplicates %s\n", strings.Join(g.Key, ", "), g.Kept, joinInts(g.Delete))
+		}
+	}
+	if *del {
+		fmt.Fprintf(os.Stderr, "Deleted %d duplicate rows\n", n)
+	} else {
+		fmt.Fprintf(os.Stderr, "Found %d duplicate rows; use -delete to remove them\n", n)
+	}
+}
+
+func joinInts(ns []int) string {
+	s := make([]string, len(ns))
+	for i, n := range ns {
+		s[i] = fmt.Sprint(n)
+	}
+	return strings.Join(s, ", ")
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/prantoran/GoogleSheets_GO/formula"
+)
+
+func runDeps(args []string) {
+	fs := flag.NewFlagSet("deps", flag.ExitOnError)
+	format := fs.String("format", "json", "output format: json or dot")
+	tabs := fs.String("tabs", "", "comma separated tabs to analyze (default all)")
+	fs.Parse(args)
+	if fs.NArg() != 1 || (*format != "json" && *format != "dot") {
+		fmt.Fprintln(os.Stderr, "usage: deps [-format json|dot] [-tabs TABS] SPREADSHEET_ID")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	srv := newSheetsService(ctx)
+	var names []string
+	if *tabs != "" {
+		names = strings.Split(*tabs, ",")
+	}
+	wb, err := formula.Load(ctx, srv, fs.Arg(0), names...)
+	checkError("Unable to read formulas: ", err)
+	resp, err := srv.Spreadsheets.Get(fs.Arg(0)).Fields("namedRanges.name").Context(ctx).Do()
+	checkError("Unable to get named ranges: ", err)
+	namedRanges := []string{}
+	for _, nr := range resp.NamedRanges {
+		namedRanges = append(namedRanges, nr.Name)
+	}
+
+	g := formula.Analyze(wb, namedRanges)
+	if *format == "dot" {
+		checkError("Unable to write graph: ", g.WriteDOT(os.Stdout))
+	} else {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		checkError("Unable to write graph: ", enc.Encode(g))
+	}
+	fmt.Fprintf(os.Stderr, "%d formulas, %d broken, %d circular groups\n", len(g.Cells), len(g.Broken), len(g.Cycles))
+	if len(g.Broken) > 0 || len(g.Cycles) > 0 {
+		os.Exit(1)
+	}
+}
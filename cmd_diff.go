@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/prantoran/GoogleSheets_GO/watch"
+)
+
+const diffUsage = `usage: diff [-key COLUMN] [-json] SPREADSHEET_ID!RANGE SPREADSHEET_ID!RANGE
+
+Compares two ranges with a header row, e.g. two tabs of one spreadsheet or
+the same tab of two copies:
+  diff -key SKU 1AbC!Inventory 1XyZ!Inventory!A1:F
+
+Rows are matched by the value of the key column, or by position without
+-key. The exit status is 1 if the ranges differ.
+
+Flags:
+`
+
+// diffJSON is the -json form of a comparison.
+type diffJSON struct {
+	From      string     `json:"from"`
+	To        string     `json:"to"`
+	OldHeader []string   `json:"old_header,omitempty"`
+	NewHeader []string   `json:"new_header,omitempty"`
+	Added     int        `json:"added"`
+	Removed   int        `json:"removed"`
+	Changed   int        `json:"changed"`
+	Rows      []*diffRow `json:"rows"`
+}
+
+type diffRow struct {
+	Type    string            `json:"type"`
+	Key     string            `json:"key"`
+	OldRow  int               `json:"old_row,omitempty"`
+	NewRow  int               `json:"new_row,omitempty"`
+	Old     map[string]string `json:"old,omitempty"`
+	New     map[string]string `json:"new,omitempty"`
+	Columns []string          `json:"columns,omitempty"`
+}
+
+func runDiff(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	key := fs.String("key", "", "header column identifying rows (default compare rows by position)")
+	asJSON := fs.Bool("json", false, "print the differences as JSON")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, diffUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	c := newClient(ctx)
+	var snaps [2]*watch.Snapshot
+	for i, arg := range fs.Args() {
+		j := strings.Index(arg, "!")
+		if j <= 0 || j == len(arg)-1 {
+			fmt.Fprintf(os.Stderr, "Invalid %q, want SPREADSHEET_ID!RANGE\n", arg)
+			os.Exit(2)
+		}
+		values, err := c.ReadRange(ctx, arg[:j], arg[j+1:])
+		checkError("Unable to read range: ", err)
+		snaps[i] = watch.NewSnapshot(values)
+	}
+	if *key != "" {
+		for i, s := range snaps {
+			if indexOfString(s.Header, *key) < 0 {
+				fmt.Fprintf(os.Stderr, "%s has no column %q\n", fs.Arg(i), *key)
+				os.Exit(1)
+			}
+		}
+	}
+
+	events := watch.Diff(snaps[0], snaps[1], *key)
+	headerChanged := strings.Join(snaps[0].Header, "\x00") != strings.Join(snaps[1].Header, "\x00")
+	if *asJSON {
+		out := &diffJSON{From: fs.Arg(0), To: fs.Arg(1), Rows: []*diffRow{}}
+		if headerChanged {
+			out.OldHeader, out.NewHeader = snaps[0].Header, snaps[1].Header
+		}
+		for _, e := range events {
+			r := &diffRow{Key: e.Key}
+			switch e.Type {
+			case watch.RowAdded:
+				r.Type = "added"
+				out.Added++
+			case watch.RowDeleted:
+				r.Type = "removed"
+				out.Removed++
+			default:
+				r.Type = "changed"
+				r.Columns = changedColumns(e)
+				out.Changed++
+			}
+			if e.Old != nil {
+				r.OldRow, r.Old = e.Old.Number, e.Old.Fields()
+			}
+			if e.New != nil {
+				r.NewRow, r.New = e.New.Number, e.New.Fields()
+			}
+			out.Rows = append(out.Rows, r)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		checkError("Unable to write differences: ", enc.Encode(out))
+	} else if headerChanged || len(events) > 0 {
+		fmt.Printf("--- %s\n+++ %s\n", fs.Arg(0), fs.Arg(1))
+		if headerChanged {
+			fmt.Printf("@@ header @@\n-%s\n+%s\n", strings.Join(snaps[0].Header, ", "), strings.Join(snaps[1].Header, ", "))
+		}
+		for _, e := range events {
+			switch e.Type {
+			case watch.RowAdded:
+				fmt.Printf("@@ [%s] +row %d @@\n+%s\n", e.Key, e.New.Number, strings.Join(e.New.Values, ", "))
+			case watch.RowDeleted:
+				fmt.Printf("@@ [%s] -row %d @@\n-%s\n", e.Key, e.Old.Number, strings.Join(e.Old.Values, ", "))
+			default:
+				fmt.Printf("@@ [%s] -row %d +row %d: %s @@\n-%s\n+%s\n", e.Key, e.Old.Number, e.New.Number,
+					strings.Join(changedColumns(e), ", "), strings.Join(e.Old.Values, ", "), strings.Join(e.New.Values, ", "))
+			}
+		}
+	}
+	if headerChanged || len(events) > 0 {
+		os.Exit(1)
+	}
+}
+
+// changedColumns returns the columns whose values differ in a changed row.
+func changedColumns(e watch.Event) []string {
+	var cols []string
+	old, changed := e.Old.Fields(), e.New.Fields()
+	for _, h := range e.New.Header() {
+		if changed[h] != old[h] {
+			cols = append(cols, h)
+		}
+	}
+	for _, h := range e.Old.Header() {
+		if _, ok := changed[h]; !ok && old[h] != "" {
+			cols = append(cols, h)
+		}
+	}
+	return cols
+}
+
+func indexOfString(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
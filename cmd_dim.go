@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+const dimUsage = `usage:
+  dim insert -spreadsheet ID -range RANGE [-inherit-before]   insert empty rows or columns
+  dim delete -spreadsheet ID -range RANGE                     delete rows or columns
+
+The range is whole rows, e.g. "Data!2:4", or whole columns, e.g. "Data!C:C".
+
+Flags:
+`
+
+func runDim(args []string) {
+	if len(args) == 0 || (args[0] != "insert" && args[0] != "delete") {
+		fmt.Fprint(os.Stderr, dimUsage)
+		os.Exit(2)
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("dim "+action, flag.ExitOnError)
+	spreadsheetID, rng := rangeFlags(fs)
+	inherit := fs.Bool("inherit-before", false, "insert: format the new cells like the row or column before them instead of after")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, dimUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+	if *spreadsheetID == "" || *rng == "" || fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	c := newClient(ctx)
+	if action == "insert" {
+		checkError("Unable to insert: ", c.InsertDimension(ctx, *spreadsheetID, *rng, *inherit))
+		fmt.Printf("Inserted %s\n", *rng)
+		return
+	}
+	checkError("Unable to delete: ", c.DeleteDimension(ctx, *spreadsheetID, *rng))
+	fmt.Printf("Deleted %s\n", *rng)
+}
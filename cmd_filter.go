@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/prantoran/GoogleSheets_GO/filters"
+)
+
+const filterUsage = `usage:
+  filter set -range RANGE [-hide COLUMN=VALUE...] [-when COLUMN:CONDITION[:VALUE...]...] [-sort COLUMN[:desc]...] SPREADSHEET_ID
+  filter get SPREADSHEET_ID TAB       print the basic filter of a tab as JSON
+  filter clear SPREADSHEET_ID TAB     remove the basic filter, showing every row
+
+Columns are named by their header in the first row of the range, e.g.
+  filter set -range Orders -hide Status=Done -when Total:NUMBER_GREATER:100 -sort Date:desc ID
+
+Flags:
+`
+
+// criteriaFlags are the flags describing the criteria and sort order of a
+// basic filter or filter view.
+type criteriaFlags struct {
+	hide, when, sort stringList
+}
+
+func (c *criteriaFlags) register(fs *flag.FlagSet) {
+	fs.Var(&c.hide, "hide", "hide the rows with this value in a column, as COLUMN=VALUE; may be repeated")
+	fs.Var(&c.when, "when", "show only the rows meeting a condition, as COLUMN:CONDITION[:VALUE...], e.g. Total:NUMBER_GREATER:100; may be repeated")
+	fs.Var(&c.sort, "sort", "sort by a column, as COLUMN or COLUMN:desc; may be repeated")
+}
+
+// build returns the criteria and sort keys given by the flags.
+func (c *criteriaFlags) build() ([]filters.Criterion, []filters.SortKey, error) {
+	var criteria []filters.Criterion
+	byColumn := map[string]int{}
+	for _, h := range c.hide {
+		i := strings.Index(h, "=")
+		if i <= 0 {
+			return nil, nil, fmt.Errorf("invalid -hide %q, want COLUMN=VALUE", h)
+		}
+		col := h[:i]
+		if j, ok := byColumn[col]; ok {
+			criteria[j].Hidden = append(criteria[j].Hidden, h[i+1:])
+			continue
+		}
+		byColumn[col] = len(criteria)
+		criteria = append(criteria, filters.Criterion{Column: col, Hidden: []string{h[i+1:]}})
+	}
+	for _, w := range c.when {
+		parts := strings.Split(w, ":")
+		if len(parts) < 2 || parts[0] == "" {
+			return nil, nil, fmt.Errorf("invalid -when %q, want COLUMN:CONDITION[:VALUE...]", w)
+		}
+		if j, ok := byColumn[parts[0]]; ok {
+			criteria[j].Condition, criteria[j].Values = strings.ToUpper(parts[1]), parts[2:]
+			continue
+		}
+		byColumn[parts[0]] = len(criteria)
+		criteria = append(criteria, filters.Criterion{Column: parts[0], Condition: strings.ToUpper(parts[1]), Values: parts[2:]})
+	}
+	var keys []filters.SortKey
+	for _, s := range c.sort {
+		col, order := s, ""
+		if i := strings.LastIndex(s, ":"); i >= 0 {
+			col, order = s[:i], strings.ToLower(s[i+1:])
+		}
+		if col == "" || (order != "" && order != "asc" && order != "desc") {
+			return nil, nil, fmt.Errorf("invalid -sort %q, want COLUMN[:asc|desc]", s)
+		}
+		keys = append(keys, filters.SortKey{Column: col, Descending: order == "desc"})
+	}
+	return criteria, keys, nil
+}
+
+func runFilter(args []string) {
+	nargs := map[string]int{"set": 1, "get": 2, "clear": 2}
+	if len(args) == 0 || nargs[args[0]] == 0 {
+		fmt.Fprint(os.Stderr, filterUsage)
+		os.Exit(2)
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("filter "+action, flag.ExitOnError)
+	rng := fs.String("range", "", "set: range to filter, header row first, e.g. \"Orders!A1:F\" or \"Orders\"")
+	var cf criteriaFlags
+	cf.register(fs)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, filterUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+	if fs.NArg() != nargs[action] || (action == "set" && *rng == "") {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	srv := newSheetsService(ctx)
+	id := fs.Arg(0)
+	switch action {
+	case "set":
+		criteria, keys, err := cf.build()
+		checkError("Invalid filter: ", err)
+		f := &filters.BasicFilter{Range: *rng, Criteria: criteria, Sort: keys}
+		checkError("Unable to set basic filter: ", filters.ApplyBasicFilter(ctx, srv, id, f))
+		fmt.Printf("Filtered %s\n", *rng)
+	case "get":
+		f, err := filters.GetBasicFilter(ctx, srv, id, fs.Arg(1))
+		checkError("Unable to get basic filter: ", err)
+		if f == nil {
+			fmt.Fprintf(os.Stderr, "%s has no basic filter\n", fs.Arg(1))
+			return
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		checkError("Unable to write basic filter: ", enc.Encode(f))
+	case "clear":
+		checkError("Unable to clear basic filter: ", filters.ClearBasicFilter(ctx, srv, id, fs.Arg(1)))
+		fmt.Printf("Cleared the basic filter of %s\n", fs.Arg(1))
+	}
+}
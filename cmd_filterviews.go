@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"github.com/prantoran/GoogleSheets_GO/filters"
+)
+
+const filterViewsUsage = `usage:
+  filterviews list SPREADSHEET_ID                  print the filter views as JSON
+  filterviews apply -f views.json SPREADSHEET_ID   add or update filter views by title
+  filterviews add -title TITLE -range RANGE [criteria flags] SPREADSHEET_ID
+                                                   add a filter view; criteria flags as for filter set
+  filterviews delete SPREADSHEET_ID VIEW_ID...     delete filter views
+
+`
+
+func runFilterViews(args []string) {
+	if len(args) == 0 || (args[0] != "list" && args[0] != "apply" && args[0] != "add" && args[0] != "delete") {
+		fmt.Fprint(os.Stderr, filterViewsUsage)
+		os.Exit(2)
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("filterviews "+action, flag.ExitOnError)
+	file := fs.String("f", "", `JSON list of views, e.g. [{"title": "Open", "range": "Data", "criteria": [{"column": "Status", "hidden": ["Done"]}]}]`)
+	title := fs.String("title", "", "add: title of the view")
+	rng := fs.String("range", "", "add: range to filter, header row first")
+	var cf criteriaFlags
+	cf.register(fs)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, filterViewsUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+	if fs.NArg() == 0 || (action == "apply" && *file == "") || (action == "add" && (*title == "" || *rng == "" || fs.NArg() != 1)) || (action == "delete" && fs.NArg() < 2) {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	srv := newSheetsService(ctx)
+	id := fs.Arg(0)
+	switch action {
+	case "list":
+		views, err := filters.ListViews(ctx, srv, id)
+		checkError("Unable to list filter views: ", err)
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		checkError("Unable to write filter views: ", enc.Encode(views))
+	case "apply":
+		b, err := ioutil.ReadFile(*file)
+		checkError("Unable to read filter views: ", err)
+		var views []*filters.View
+		checkError("Unable to parse filter views: ", json.Unmarshal(b, &views))
+		checkError("Unable to apply filter views: ", filters.ApplyViews(ctx, srv, id, views))
+		for _, v := range views {
+			fmt.Printf("%d\t%s\n", v.ID, v.Title)
+		}
+	case "add":
+		criteria, keys, err := cf.build()
+		checkError("Invalid filter: ", err)
+		v := &filters.View{Title: *title, Range: *rng, Criteria: criteria, Sort: keys}
+		checkError("Unable to add filter view: ", filters.AddView(ctx, srv, id, v))
+		fmt.Printf("%d\t%s\n", v.ID, v.Title)
+	case "delete":
+		for _, arg := range fs.Args()[1:] {
+			viewID, err := strconv.ParseInt(arg, 10, 64)
+			checkError("Invalid filter view ID: ", err)
+			checkError("Unable to delete filter view: ", filters.DeleteView(ctx, srv, id, viewID))
+		}
+	}
+}
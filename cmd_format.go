@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/prantoran/GoogleSheets_GO/formatting"
+)
+
+func runFormat(args []string) {
+	fs := flag.NewFlagSet("format", flag.ExitOnError)
+	spreadsheetID := fs.String("spreadsheet", "", "spreadsheet ID")
+	var ranges stringList
+	fs.Var(&ranges, "range", "A1 range or tab to format; repeat to format several the same way")
+	bold := fs.Bool("bold", false, "bold text")
+	italic := fs.Bool("italic", false, "italic text")
+	underline := fs.Bool("underline", false, "underlined text")
+	strike := fs.Bool("strikethrough", false, "struck through text")
+	font := fs.String("font", "", "font family, e.g. \"Roboto Mono\"")
+	size := fs.Int64("size", 0, "font size in points")
+	color := fs.String("color", "", "text color as #RRGGBB")
+	background := fs.String("background", "", "fill color as #RRGGBB")
+	numberFormat := fs.String("number-format", "", "number format pattern, e.g. \"0.00%\", \"#,##0\" or \"yyyy-mm-dd\"")
+	align := fs.String("align", "", "horizontal alignment: left, center or right")
+	valign := fs.String("valign", "", "vertical alignment: top, middle or bottom")
+	wrap := fs.String("wrap", "", "long text: overflow, clip or wrap")
+	clearFormat := fs.Bool("clear", false, "reset the format first; alone, clears all formatting")
+	fs.Parse(args)
+	if *spreadsheetID == "" || len(ranges) == 0 || fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: format -spreadsheet ID -range RANGE [-range RANGE...] [-bold] [-background #RRGGBB] [-number-format PATTERN] [flags]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	var builders []*formatting.Builder
+	for _, rng := range ranges {
+		b := formatting.Format(rng)
+		if *clearFormat {
+			b.Clear()
+		}
+		if *bold {
+			b.Bold()
+		}
+		if *italic {
+			b.Italic()
+		}
+		if *underline {
+			b.Underline()
+		}
+		if *strike {
+			b.Strikethrough()
+		}
+		if *font != "" {
+			b.Font(*font)
+		}
+		if *size != 0 {
+			b.FontSize(*size)
+		}
+		if *color != "" {
+			b.Color(*color)
+		}
+		if *background != "" {
+			b.Background(*background)
+		}
+		if *numberFormat != "" {
+			b.NumberFormat(*numberFormat)
+		}
+		if *align != "" {
+			b.Align(*align)
+		}
+		if *valign != "" {
+			b.VerticalAlign(*valign)
+		}
+		if *wrap != "" {
+			b.Wrap(*wrap)
+		}
+		builders = append(builders, b)
+	}
+	ctx := commandContext()
+	checkError("Unable to format: ", formatting.Apply(ctx, newSheetsService(ctx), *spreadsheetID, builders...))
+	fmt.Printf("Formatted %d ranges\n", len(ranges))
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/prantoran/GoogleSheets_GO/forms"
+)
+
+func runForms(args []string) {
+	fs := flag.NewFlagSet("forms", flag.ExitOnError)
+	spreadsheetID := fs.String("spreadsheet", "", "ID of the form's response spreadsheet")
+	tab := fs.String("tab", forms.DefaultTab, "response tab")
+	cursorPath := fs.String("cursor", "", "only print responses newer than the ones recorded in this file, then update it")
+	fs.Parse(args)
+	if *spreadsheetID == "" {
+		fmt.Fprintln(os.Stderr, "usage: forms -spreadsheet ID [-tab TAB] [-cursor FILE]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	r := &forms.Reader{Sheets: newSheetsService(ctx), SpreadsheetID: *spreadsheetID, Tab: *tab}
+	var resps []*forms.Response
+	var err error
+	if *cursorPath != "" {
+		r.Cursor = &forms.Cursor{}
+		if b, err := ioutil.ReadFile(*cursorPath); err == nil {
+			checkError("Unable to parse cursor: ", json.Unmarshal(b, r.Cursor))
+		} else if !os.IsNotExist(err) {
+			checkError("Unable to read cursor: ", err)
+		}
+		resps, err = r.ReadNew(ctx)
+	} else {
+		resps, err = r.ReadAll(ctx)
+	}
+	checkError("Unable to read responses: ", err)
+
+	// One JSON object per response, for piping into other tools.
+	enc := json.NewEncoder(os.Stdout)
+	for _, resp := range resps {
+		checkError("Unable to write response: ", enc.Encode(resp))
+	}
+	if *cursorPath != "" {
+		b, _ := json.Marshal(r.Cursor)
+		checkError("Unable to save cursor: ", ioutil.WriteFile(*cursorPath, b, 0600))
+	}
+}
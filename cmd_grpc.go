@@ -0,0 +1,75 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsrpc"
+)
+
+func runGRPC(args []string) {
+	fs := flag.NewFlagSet("grpc", flag.ExitOnError)
+	listen := fs.String("listen", "localhost:50051", "address to listen on")
+	spreadsheets := fs.String("spreadsheets", "", "comma separated IDs of the spreadsheets calls may use")
+	tokensFile := fs.String("tokens", "", "file with one accepted bearer token per line (default $SHEETS_GRPC_TOKENS, comma separated)")
+	certFile := fs.String("tls-cert", "", "serve TLS with this certificate file")
+	keyFile := fs.String("tls-key", "", "private key of -tls-cert")
+	clientCA := fs.String("tls-client-ca", "", "require client certificates signed by this CA file, instead of or besides tokens")
+	interval := fs.Duration("interval", 30*time.Second, "default poll interval of Watch calls")
+	fs.Parse(args)
+	if *spreadsheets == "" || (*certFile == "") != (*keyFile == "") || (*clientCA != "" && *certFile == "") {
+		fmt.Fprintln(os.Stderr, "usage: grpc -spreadsheets ID,... [-tokens FILE] [-tls-cert FILE -tls-key FILE [-tls-client-ca FILE]] [flags]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+	tokens := readKeys(*tokensFile, "SHEETS_GRPC_TOKENS")
+	if len(tokens) == 0 && *clientCA == "" {
+		log.Fatal("No tokens or client CA configured; use -tokens, $SHEETS_GRPC_TOKENS or -tls-client-ca")
+	}
+
+	var opts []grpc.ServerOption
+	if *certFile != "" {
+		cert, err := tls.LoadX509KeyPair(*certFile, *keyFile)
+		checkError("Unable to load TLS certificate: ", err)
+		cfg := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+		if *clientCA != "" {
+			pem, err := ioutil.ReadFile(*clientCA)
+			checkError("Unable to read client CA: ", err)
+			cfg.ClientCAs = x509.NewCertPool()
+			if !cfg.ClientCAs.AppendCertsFromPEM(pem) {
+				log.Fatalf("No certificates found in %s", *clientCA)
+			}
+			cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(cfg)))
+	}
+	if len(tokens) > 0 {
+		auth := sheetsrpc.TokenAuth{Tokens: tokens}
+		opts = append(opts, grpc.UnaryInterceptor(auth.Unary()), grpc.StreamInterceptor(auth.Stream()))
+	}
+
+	lis, err := net.Listen("tcp", *listen)
+	checkError("Unable to listen: ", err)
+	if addr, ok := lis.Addr().(*net.TCPAddr); ok && *certFile == "" && !addr.IP.IsLoopback() {
+		log.Printf("Warning: serving on %s without TLS sends tokens in the clear", lis.Addr())
+	}
+	s := grpc.NewServer(opts...)
+	sheetsrpc.RegisterSheetsServer(s, &sheetsrpc.Server{
+		Sheets:          newSheetsService(commandContext()),
+		Spreadsheets:    strings.Split(*spreadsheets, ","),
+		DefaultInterval: *interval,
+	})
+	log.Printf("Serving gRPC on %s", lis.Addr())
+	checkError("gRPC server failed: ", s.Serve(lis))
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prantoran/GoogleSheets_GO/history"
+)
+
+func runHistory(args []string) {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	spreadsheetID := fs.String("spreadsheet", "", "spreadsheet ID")
+	tabs := fs.String("tabs", "", `comma separated tabs to record, optionally with a key column, e.g. "Inventory:SKU,Notes"`)
+	dir := fs.String("repo", "sheet-history", "git repository to commit snapshots to")
+	format := fs.String("format", "csv", "file format, csv or json")
+	author := fs.String("author", "", `commit author, e.g. "Sheets History <history@example.com>"`)
+	every := fs.Duration("every", 0, "keep running and record at this interval")
+	fs.Parse(args)
+	if *spreadsheetID == "" || *tabs == "" || (*format != "csv" && *format != "json") {
+		fmt.Fprintln(os.Stderr, "usage: history -spreadsheet ID -tabs TAB[:KEY],... [flags]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	rec := &history.Recorder{
+		Sheets:        newSheetsService(ctx),
+		SpreadsheetID: *spreadsheetID,
+		Dir:           *dir,
+		Format:        history.Format(*format),
+		Author:        *author,
+	}
+	for _, spec := range strings.Split(*tabs, ",") {
+		rec.Tabs = append(rec.Tabs, history.ParseTab(spec))
+	}
+
+	for {
+		changes, err := rec.Record(ctx)
+		if err != nil && *every <= 0 {
+			log.Fatal(err)
+		}
+		switch {
+		case err != nil:
+			log.Print(err)
+		case len(changes) == 0:
+			log.Print("No changes")
+		default:
+			for _, c := range changes {
+				log.Print(c)
+			}
+		}
+		if *every <= 0 {
+			return
+		}
+		time.Sleep(*every)
+	}
+}
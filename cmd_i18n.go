@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/prantoran/GoogleSheets_GO/i18nsheet"
+)
+
+const i18nUsage = `usage:
+  i18n export [flags] SOURCE [TRANSLATION...]   write a catalog into the translations tab
+  i18n import [flags] [LOCALE...]               write translations from the tab to files
+
+Catalogs are go-i18n JSON (.json) or gettext (.po, .pot) files. The locale
+is the last dot separated part of the file name, e.g. active.de.json is de.
+
+`
+
+func runI18n(args []string) {
+	if len(args) == 0 || (args[0] != "export" && args[0] != "import") {
+		fmt.Fprint(os.Stderr, i18nUsage)
+		os.Exit(2)
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("i18n "+action, flag.ExitOnError)
+	spreadsheetID := fs.String("spreadsheet", "", "spreadsheet ID")
+	tab := fs.String("tab", "Translations", "translations tab")
+	outDir := fs.String("out", ".", "directory to write imported catalogs to")
+	format := fs.String("format", "json", "format of imported catalogs, json or po")
+	prefix := fs.String("prefix", "", `file name prefix of imported catalogs, e.g. "active."`)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, i18nUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+	if *spreadsheetID == "" || (action == "export" && fs.NArg() == 0) {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	sheet := &i18nsheet.Sheet{Sheets: newSheetsService(ctx), SpreadsheetID: *spreadsheetID, Tab: *tab}
+
+	if action == "export" {
+		source := readCatalog(fs.Arg(0))
+		var translations []*i18nsheet.Catalog
+		for _, path := range fs.Args()[1:] {
+			translations = append(translations, readCatalog(path))
+		}
+		rep, err := sheet.Export(ctx, source, translations)
+		checkError("Unable to export catalog: ", err)
+		fmt.Printf("Exported %d messages: %d new, %d with changed source, %d removed\n",
+			rep.Messages, len(rep.Added), len(rep.Changed), len(rep.Removed))
+		for loc, n := range rep.Missing {
+			fmt.Printf("  %s: %d untranslated\n", loc, n)
+		}
+		return
+	}
+
+	catalogs, rep, err := sheet.Import(ctx, fs.Args()...)
+	checkError("Unable to import translations: ", err)
+	for _, c := range catalogs {
+		path := filepath.Join(*outDir, *prefix+c.Locale+"."+*format)
+		f, err := os.Create(path)
+		checkError("Unable to create catalog: ", err)
+		if *format == "po" {
+			err = i18nsheet.WritePO(f, c)
+		} else {
+			err = i18nsheet.WriteJSON(f, c)
+		}
+		checkError("Unable to write catalog: ", err)
+		checkError("Unable to write catalog: ", f.Close())
+		fmt.Printf("Wrote %s: %d messages, %d untranslated\n", path, len(c.Messages), len(rep.Missing[c.Locale]))
+	}
+	if len(rep.Changed) > 0 {
+		log.Printf("%d messages still need review after a source change: %s",
+			len(rep.Changed), strings.Join(rep.Changed, ", "))
+	}
+}
+
+// readCatalog reads a go-i18n or gettext file, taking the locale from its
+// name.
+func readCatalog(path string) *i18nsheet.Catalog {
+	f, err := os.Open(path)
+	checkError("Unable to open catalog: ", err)
+	defer f.Close()
+	ext := filepath.Ext(path)
+	name := strings.TrimSuffix(filepath.Base(path), ext)
+	locale := name[strings.LastIndex(name, ".")+1:]
+	var c *i18nsheet.Catalog
+	switch ext {
+	case ".po", ".pot":
+		c, err = i18nsheet.ReadPO(f, locale)
+	case ".json":
+		c, err = i18nsheet.ReadJSON(f, locale)
+	default:
+		log.Fatalf("Unknown catalog format %q", ext)
+	}
+	checkError("Unable to read catalog: ", err)
+	return c
+}
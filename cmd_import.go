@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetimport"
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	spreadsheetID := fs.String("spreadsheet", "", "spreadsheet ID; xlsx files create a new spreadsheet without one")
+	target := fs.String("range", "", "tab or A1 range to write, e.g. \"Data\" or \"Data!B2\"; missing tabs are created. xlsx files write each worksheet to the tab of the same name instead")
+	in := fs.String("in", "", "file to import (default stdin)")
+	format := fs.String("format", "", "input format: csv, tsv, json, ndjson or xlsx (default from the -in extension, else csv)")
+	title := fs.String("title", "", "xlsx: title of the new spreadsheet (default the file name)")
+	appendRows := fs.Bool("append", false, "append after the existing rows instead of replacing them")
+	raw := fs.Bool("raw", false, "store values as given instead of parsing numbers, dates and formulas")
+	freeze := fs.Bool("freeze-header", false, "freeze the first row of the tab written")
+	autofit := fs.Bool("autofit", false, "fit the width of the written columns to their content")
+	fs.Parse(args)
+	if *format == "" {
+		*format = "csv"
+		if ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(*in)), "."); ext != "" {
+			*format = ext
+		}
+	}
+	xlsx := *format == "xlsx"
+	if (!xlsx && (*spreadsheetID == "" || *target == "")) || (xlsx && (*in == "" || *target != "")) || fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: import -spreadsheet ID -range TAB|RANGE [-in FILE] [-format csv|tsv|json|ndjson] [-append] [-raw] [-freeze-header] [-autofit]")
+		fmt.Fprintln(os.Stderr, "       import [-spreadsheet ID] -in FILE.xlsx [-title TITLE] [-append]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+	if xlsx {
+		importXLSX(*spreadsheetID, *in, *title, sheetimport.Options{Append: *appendRows})
+		return
+	}
+
+	r := io.Reader(os.Stdin)
+	if *in != "" && *in != "-" {
+		f, err := os.Open(*in)
+		checkError("Unable to open input file: ", err)
+		defer f.Close()
+		r = f
+	}
+	var rows [][]interface{}
+	var err error
+	switch *format {
+	case "csv":
+		rows, err = sheetimport.CSV(r, ',')
+	case "tsv":
+		rows, err = sheetimport.CSV(r, '\t')
+	case "json", "ndjson", "jsonl":
+		rows, err = sheetimport.JSON(r)
+	default:
+		err = fmt.Errorf("unknown format %q", *format)
+	}
+	checkError("Unable to read input: ", err)
+
+	ctx := commandContext()
+	res, err := sheetimport.Write(ctx, newSheetsService(ctx), *spreadsheetID, *target, rows, sheetimport.Options{Append: *appendRows, Raw: *raw})
+	checkError("Unable to import: ", err)
+	if res.CreatedTab {
+		fmt.Println("Created tab")
+	}
+	fmt.Printf("Wrote %d rows (%d cells) to %s\n", res.Rows, res.Cells, res.Range)
+	if *freeze {
+		tab, _ := sheetsclient.SplitRange(res.Range)
+		checkError("Unable to freeze header: ", newClient(ctx).Freeze(ctx, *spreadsheetID, tab, 1, 0))
+	}
+	if *autofit {
+		checkError("Unable to resize columns: ", newClient(ctx).AutoResizeColumns(ctx, *spreadsheetID, res.Range))
+	}
+}
+
+// importXLSX uploads the worksheets of a workbook into a new spreadsheet,
+// or into the tabs of the same names of an existing one.
+func importXLSX(spreadsheetID, path, title string, opts sheetimport.Options) {
+	f, err := os.Open(path)
+	checkError("Unable to open input file: ", err)
+	defer f.Close()
+	st, err := f.Stat()
+	checkError("Unable to open input file: ", err)
+	tabs, err := sheetimport.XLSX(f, st.Size())
+	checkError("Unable to read input: ", err)
+
+	ctx := commandContext()
+	srv := newSheetsService(ctx)
+	if spreadsheetID == "" {
+		if title == "" {
+			title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		id, err := sheetimport.Create(ctx, srv, title, tabs)
+		checkError("Unable to import: ", err)
+		fmt.Printf("Created https://docs.google.com/spreadsheets/d/%s with %d tabs\n", id, len(tabs))
+		return
+	}
+	for _, t := range tabs {
+		res, err := sheetimport.Write(ctx, srv, spreadsheetID, sheetsclient.QuoteTab(t.Name), t.Rows, opts)
+		checkError("Unable to import "+t.Name+": ", err)
+		fmt.Printf("Wrote %d rows (%d cells) to %s\n", res.Rows, res.Cells, res.Range)
+	}
+}
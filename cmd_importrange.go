@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/prantoran/GoogleSheets_GO/importrange"
+)
+
+func runImportRange(args []string) {
+	fs := flag.NewFlagSet("importrange", flag.ExitOnError)
+	src := fs.String("src", "", "source spreadsheet ID")
+	srcRange := fs.String("src-range", "", "source range, e.g. Data!A1:F")
+	dst := fs.String("dst", "", "destination spreadsheet ID")
+	dstRange := fs.String("dst-range", "", "destination tab and optional top left cell, e.g. Imported or Imported!B2")
+	formatted := fs.Bool("formatted", false, "copy values as displayed instead of the underlying numbers")
+	noNote := fs.Bool("no-note", false, "do not add a provenance note to the destination")
+	every := fs.Duration("every", 0, "keep running and copy at this interval")
+	fs.Parse(args)
+	if *src == "" || *srcRange == "" || *dst == "" || *dstRange == "" {
+		fmt.Fprintln(os.Stderr, "usage: importrange -src ID -src-range RANGE -dst ID -dst-range TAB[!CELL] [flags]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	job := &importrange.Job{
+		Sheets:      newSheetsService(ctx),
+		SourceID:    *src,
+		SourceRange: *srcRange,
+		DestID:      *dst,
+		DestRange:   *dstRange,
+		Formatted:   *formatted,
+		NoNote:      *noNote,
+	}
+	for {
+		res, err := job.Run(ctx)
+		if err != nil {
+			if *every <= 0 {
+				log.Fatal(err)
+			}
+			log.Print(err)
+		} else {
+			log.Printf("Copied %d rows, %d columns to %s", res.Rows, res.Columns, *dstRange)
+		}
+		if *every <= 0 {
+			return
+		}
+		time.Sleep(*every)
+	}
+}
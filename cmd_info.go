@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+func runInfo(args []string) {
+	fs := flag.NewFlagSet("info", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the information as JSON")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: info [-json] SPREADSHEET_ID")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	s, err := newClient(ctx).Info(ctx, fs.Arg(0))
+	checkError("Unable to get spreadsheet: ", err)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		checkError("Unable to write information: ", enc.Encode(s))
+		return
+	}
+	fmt.Printf("Title:     %s\n", s.Title)
+	fmt.Printf("Locale:    %s\n", s.Locale)
+	fmt.Printf("Time zone: %s\n", s.TimeZone)
+	fmt.Printf("URL:       %s\n\n", s.URL)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SHEET ID\tTITLE\tROWS\tCOLUMNS\tSTATUS")
+	for _, t := range s.Tabs {
+		var status []string
+		if t.Type != "" && t.Type != "GRID" {
+			status = append(status, strings.ToLower(t.Type))
+		}
+		if t.Hidden {
+			status = append(status, "hidden")
+		}
+		if t.Protected {
+			status = append(status, "protected")
+		}
+		if t.ProtectedRanges > 0 {
+			status = append(status, fmt.Sprintf("%d protected ranges", t.ProtectedRanges))
+		}
+		fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%s\n", t.ID, t.Title, t.Rows, t.Columns, strings.Join(status, ", "))
+	}
+	checkError("Unable to write information: ", w.Flush())
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/prantoran/GoogleSheets_GO/drivelist"
+)
+
+func runList(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	q := &drivelist.Query{}
+	fs.StringVar(&q.Name, "name", "", "only spreadsheets whose name contains this text")
+	fs.StringVar(&q.Owner, "owner", "", `only spreadsheets owned by this email address, or "me"`)
+	modifiedAfter := fs.String("modified-after", "", "only spreadsheets modified after this date (2006-01-02) or time (RFC 3339)")
+	fs.BoolVar(&q.Trashed, "trashed", false, "include spreadsheets in the trash")
+	fs.IntVar(&q.Limit, "limit", 0, "print at most this many spreadsheets")
+	asJSON := fs.Bool("json", false, "print the spreadsheets as JSON")
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "usage: list [-name TEXT] [-owner EMAIL] [-modified-after DATE] [-json]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+	if *modifiedAfter != "" {
+		t, err := time.ParseInLocation("2006-01-02", *modifiedAfter, time.Local)
+		if err != nil {
+			t, err = time.Parse(time.RFC3339, *modifiedAfter)
+		}
+		checkError("Invalid -modified-after: ", err)
+		q.ModifiedAfter = t
+	}
+
+	ctx := commandContext()
+	files, err := drivelist.List(ctx, newDriveService(ctx), q)
+	checkError("Unable to list spreadsheets: ", err)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		checkError("Unable to write spreadsheets: ", enc.Encode(files))
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tOWNERS\tMODIFIED")
+	for _, f := range files {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.ID, f.Name, strings.Join(f.Owners, ", "), f.Modified.Local().Format("2006-01-02 15:04"))
+	}
+	checkError("Unable to write spreadsheets: ", w.Flush())
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"time"
+
+	"google.golang.org/api/gmail/v1"
+
+	"github.com/prantoran/GoogleSheets_GO/mailmerge"
+)
+
+func runMailMerge(args []string) {
+	fs := flag.NewFlagSet("mailmerge", flag.ExitOnError)
+	spreadsheetID := fs.String("spreadsheet", "", "spreadsheet ID")
+	tab := fs.String("tab", "", "tab with one recipient per row, header row first")
+	to := fs.String("to", "{{.Email}}", "recipient template")
+	subject := fs.String("subject", "", "subject template")
+	bodyFile := fs.String("body", "", "file with the body template")
+	html := fs.Bool("html", false, "the body is an HTML template")
+	from := fs.String("from", "", "sender address")
+	status := fs.String("status", "Sent At", "column recording when each row was sent")
+	via := fs.String("via", "smtp", "send through smtp or gmail")
+	smtpAddr := fs.String("smtp", "localhost:587", "SMTP server host:port")
+	smtpUser := fs.String("smtp-user", "", "SMTP username; the password is read from $SMTP_PASSWORD")
+	dryRun := fs.Bool("dry-run", false, "print the messages instead of sending them")
+	interval := fs.Duration("interval", time.Second, "minimum delay between messages")
+	limit := fs.Int("limit", 0, "send at most this many messages")
+	fs.Parse(args)
+	if *spreadsheetID == "" || *tab == "" || *subject == "" || *bodyFile == "" {
+		fmt.Fprintln(os.Stderr, "usage: mailmerge -spreadsheet ID -tab TAB -subject TMPL -body FILE [flags]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	body, err := ioutil.ReadFile(*bodyFile)
+	checkError("Unable to read body template: ", err)
+	tmpl, err := mailmerge.NewTemplate(*to, *subject, string(body), *html)
+	checkError("Unable to parse templates: ", err)
+
+	ctx := commandContext()
+	m := &mailmerge.Merge{
+		SpreadsheetID: *spreadsheetID,
+		Tab:           *tab,
+		Template:      tmpl,
+		From:          *from,
+		StatusColumn:  *status,
+		DryRun:        *dryRun,
+		Interval:      *interval,
+		Limit:         *limit,
+	}
+	switch *via {
+	case "smtp":
+		m.Sender = &mailmerge.SMTP{Addr: *smtpAddr, Username: *smtpUser, Password: os.Getenv("SMTP_PASSWORD")}
+	case "gmail":
+		scopes = append(scopes, gmail.GmailSendScope)
+		srv, err := gmail.New(newHTTPClient(ctx))
+		checkError("Unable to retrieve Gmail Client ", err)
+		m.Sender = &mailmerge.Gmail{Service: srv}
+	default:
+		log.Fatalf("Unknown sender %q; use smtp or gmail", *via)
+	}
+	m.Sheets = newSheetsService(ctx)
+	m.OnMessage = func(row int, msg *mailmerge.Message, err error) {
+		switch {
+		case err != nil:
+			log.Printf("row %d: %v", row, err)
+		case *dryRun:
+			fmt.Printf("--- row %d\nTo: %s\nSubject: %s\n\n%s\n", row, msg.To, msg.Subject, msg.Body)
+		default:
+			log.Printf("row %d: sent to %s", row, msg.To)
+		}
+	}
+	rep, err := m.Run(ctx)
+	checkError("Mail merge failed: ", err)
+	log.Print(rep)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/prantoran/GoogleSheets_GO/mask"
+)
+
+func runMask(args []string) {
+	fs := flag.NewFlagSet("mask", flag.ExitOnError)
+	spreadsheetID := fs.String("spreadsheet", "", "spreadsheet ID")
+	rng := fs.String("range", "", "range to export, header row first")
+	rules := fs.String("rules", "mask.json", `JSON masking rules, e.g. {"salt": "...", "rules": [{"column": "Email", "transform": "fake-email"}]}`)
+	out := fs.String("out", "", "CSV file to write (default stdout)")
+	fs.Parse(args)
+	if *spreadsheetID == "" || *rng == "" {
+		fmt.Fprintln(os.Stderr, "usage: mask -spreadsheet ID -range RANGE [-rules mask.json] [-out FILE]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	cfg, err := mask.LoadConfig(*rules)
+	checkError("Unable to load masking rules: ", err)
+	ctx := commandContext()
+	resp, err := newSheetsService(ctx).Spreadsheets.Values.Get(*spreadsheetID, *rng).Context(ctx).Do()
+	checkError("Unable to read range: ", err)
+	rows := make([][]string, len(resp.Values))
+	for i, row := range resp.Values {
+		for _, v := range row {
+			rows[i] = append(rows[i], fmt.Sprint(v))
+		}
+	}
+	masked, err := mask.Table(cfg, rows)
+	checkError("Unable to mask rows: ", err)
+
+	w := os.Stdout
+	if *out != "" {
+		w, err = os.Create(*out)
+		checkError("Unable to create output file: ", err)
+		defer w.Close()
+	}
+	cw := csv.NewWriter(w)
+	checkError("Unable to write CSV: ", cw.WriteAll(masked))
+}
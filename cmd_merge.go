@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+func runMerge(args []string) {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	spreadsheetID, rng := rangeFlags(fs)
+	typ := fs.String("type", "all", "merge into one cell (all), one cell per column (columns) or per row (rows)")
+	unmerge := fs.Bool("unmerge", false, "split the merged cells of the range instead")
+	fs.Parse(args)
+	requireRange(fs, "merge -spreadsheet ID -range RANGE [-type all|columns|rows] [-unmerge]", *spreadsheetID, *rng)
+
+	ctx := commandContext()
+	c := newClient(ctx)
+	if *unmerge {
+		checkError("Unable to unmerge cells: ", c.Unmerge(ctx, *spreadsheetID, *rng))
+		fmt.Printf("Unmerged %s\n", *rng)
+		return
+	}
+	mt, err := sheetsclient.ParseMergeType(*typ)
+	checkError("Invalid -type: ", err)
+	checkError("Unable to merge cells: ", c.Merge(ctx, *spreadsheetID, *rng, mt))
+	fmt.Printf("Merged %s\n", *rng)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+const metadataUsage = `usage:
+  metadata list -spreadsheet ID [-key KEY] [-location LOCATION]
+  metadata add -spreadsheet ID -key KEY -value VALUE [-location LOCATION]
+  metadata delete -spreadsheet ID (-id METADATA_ID | -key KEY [-location LOCATION])
+
+A location is empty for the spreadsheet, a tab name, or whole rows or
+columns, e.g. "Data!5:5" or "Data!C:C". list and delete match metadata at
+or within the location, e.g. on any row of a tab.
+
+Flags:
+`
+
+func runMetadata(args []string) {
+	if len(args) == 0 || (args[0] != "list" && args[0] != "add" && args[0] != "delete") {
+		fmt.Fprint(os.Stderr, metadataUsage)
+		os.Exit(2)
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("metadata "+action, flag.ExitOnError)
+	spreadsheetID := fs.String("spreadsheet", "", "spreadsheet ID")
+	key := fs.String("key", "", "metadata key")
+	value := fs.String("value", "", "add: metadata value")
+	loc := fs.String("location", "", "spreadsheet if empty, a tab, or rows or columns such as \"Data!5:5\"")
+	id := fs.Int64("id", 0, "delete: ID of the metadata to delete")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, metadataUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+	switch {
+	case *spreadsheetID == "" || fs.NArg() != 0,
+		action == "list" && *key == "" && *loc == "",
+		action == "add" && *key == "",
+		action == "delete" && (*id == 0) == (*key == "" && *loc == ""):
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	c := newClient(ctx)
+	switch action {
+	case "list":
+		list, err := c.FindMetadata(ctx, *spreadsheetID, *key, *loc)
+		checkError("Unable to find metadata: ", err)
+		for _, m := range list {
+			printMetadata(m)
+		}
+	case "add":
+		m, err := c.AddMetadata(ctx, *spreadsheetID, *loc, *key, *value)
+		checkError("Unable to add metadata: ", err)
+		printMetadata(m)
+	case "delete":
+		if *id != 0 {
+			checkError("Unable to delete metadata: ", c.DeleteMetadataID(ctx, *spreadsheetID, *id))
+			fmt.Printf("Deleted metadata %d\n", *id)
+			return
+		}
+		n, err := c.DeleteMetadata(ctx, *spreadsheetID, *key, *loc)
+		checkError("Unable to delete metadata: ", err)
+		fmt.Printf("Deleted %d metadata\n", n)
+	}
+}
+
+func printMetadata(m *sheetsclient.Metadata) {
+	loc := m.Location
+	if loc == "" {
+		loc = "(spreadsheet)"
+	}
+	fmt.Printf("%d\t%s\t%s=%s\n", m.ID, loc, m.Key, m.Value)
+}
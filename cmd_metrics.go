@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetmetrics"
+)
+
+func runMetricsExporter(args []string) {
+	fs := flag.NewFlagSet("metrics-exporter", flag.ExitOnError)
+	configPath := fs.String("config", "metrics.json", "exporter configuration file")
+	listen := fs.String("listen", "", "address to serve /metrics on (overrides the config)")
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "usage: metrics-exporter [-config FILE] [-listen ADDR]")
+		os.Exit(2)
+	}
+
+	config, err := sheetmetrics.LoadConfig(*configPath)
+	checkError("Unable to load exporter config: ", err)
+	if *listen != "" {
+		config.Listen = *listen
+	}
+
+	ctx := commandContext()
+	exporter := sheetmetrics.NewExporter(newSheetsService(ctx), config)
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(exporter)
+	go exporter.Run(ctx, func(err error) { log.Print(err) })
+
+	http.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	log.Printf("Serving metrics on %s/metrics", config.Listen)
+	log.Fatal(http.ListenAndServe(config.Listen, nil))
+}
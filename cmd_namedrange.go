@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+const namedRangeUsage = `usage:
+  named-range list [-json] SPREADSHEET_ID
+  named-range add SPREADSHEET_ID NAME RANGE
+  named-range delete SPREADSHEET_ID NAME
+
+Every command taking a range also takes the name of a named range.
+
+Flags:
+`
+
+func runNamedRange(args []string) {
+	if len(args) == 0 || (args[0] != "list" && args[0] != "add" && args[0] != "delete") {
+		fmt.Fprint(os.Stderr, namedRangeUsage)
+		os.Exit(2)
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("named-range "+action, flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "list: print the named ranges as JSON")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, namedRangeUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+	want := map[string]int{"list": 1, "add": 3, "delete": 2}[action]
+	if fs.NArg() != want {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	c := newClient(ctx)
+	switch action {
+	case "list":
+		all, err := c.NamedRanges(ctx, fs.Arg(0))
+		checkError("Unable to list named ranges: ", err)
+		if *asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			checkError("Unable to write named ranges: ", enc.Encode(all))
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tRANGE")
+		for _, nr := range all {
+			fmt.Fprintf(w, "%s\t%s\n", nr.Name, nr.Range)
+		}
+		checkError("Unable to write named ranges: ", w.Flush())
+	case "add":
+		nr, err := c.AddNamedRange(ctx, fs.Arg(0), fs.Arg(1), fs.Arg(2))
+		checkError("Unable to add named range: ", err)
+		fmt.Printf("Named %s %s\n", nr.Range, nr.Name)
+	case "delete":
+		checkError("Unable to delete named range: ", c.DeleteNamedRange(ctx, fs.Arg(0), fs.Arg(1)))
+		fmt.Printf("Deleted named range %s\n", fs.Arg(1))
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+)
+
+const notesUsage = `usage:
+  notes get -spreadsheet ID -range RANGE                  print the notes of a range as CSV
+  notes set -spreadsheet ID -range RANGE -note TEXT       set the note of the first cell
+  notes set -spreadsheet ID -range RANGE [-in FILE]       set notes from CSV, one field per cell
+  notes clear -spreadsheet ID -range RANGE                remove the notes of a range
+
+Flags:
+`
+
+func runNotes(args []string) {
+	if len(args) == 0 || (args[0] != "get" && args[0] != "set" && args[0] != "clear") {
+		fmt.Fprint(os.Stderr, notesUsage)
+		os.Exit(2)
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("notes "+action, flag.ExitOnError)
+	spreadsheetID, rng := rangeFlags(fs)
+	note := fs.String("note", "", "set: note of the first cell of the range")
+	in := fs.String("in", "", "set: CSV file with the notes (default stdin)")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, notesUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+	if *spreadsheetID == "" || *rng == "" || fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	c := newClient(ctx)
+	switch action {
+	case "get":
+		notes, err := c.Notes(ctx, *spreadsheetID, *rng)
+		checkError("Unable to read notes: ", err)
+		w := csv.NewWriter(os.Stdout)
+		checkError("Unable to write notes: ", w.WriteAll(notes))
+	case "set":
+		var notes [][]string
+		if *note != "" {
+			notes = [][]string{{*note}}
+		} else {
+			for _, row := range readInput(*in) {
+				r := make([]string, len(row))
+				for i, v := range row {
+					r[i] = fmt.Sprint(v)
+				}
+				notes = append(notes, r)
+			}
+		}
+		checkError("Unable to set notes: ", c.SetNotes(ctx, *spreadsheetID, *rng, notes))
+		fmt.Printf("Set the notes of %s\n", *rng)
+	case "clear":
+		checkError("Unable to clear notes: ", c.ClearNotes(ctx, *spreadsheetID, *rng))
+		fmt.Printf("Cleared the notes of %s\n", *rng)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/prantoran/GoogleSheets_GO/notify"
+	"github.com/prantoran/GoogleSheets_GO/watch"
+)
+
+func runNotify(args []string) {
+	fs := flag.NewFlagSet("notify", flag.ExitOnError)
+	configPath := fs.String("config", "notify.json", "notifier configuration file")
+	interval := fs.Duration("interval", time.Minute, "poll interval")
+	cursor := fs.String("cursor", "", "file keeping the last seen state across restarts")
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "usage: notify [-config FILE] [-interval D] [-cursor FILE]")
+		os.Exit(2)
+	}
+
+	config, err := notify.LoadConfig(*configPath)
+	checkError("Unable to load notifier config: ", err)
+
+	ctx := commandContext()
+	w := &watch.Watcher{
+		Sheets:        newSheetsService(ctx),
+		SpreadsheetID: config.SpreadsheetID,
+		Range:         config.Range,
+		KeyColumn:     config.Key,
+		Interval:      *interval,
+	}
+	if *cursor != "" {
+		w.Cursor = watch.FileCursor(*cursor)
+	}
+	w.OnBatch((&notify.Notifier{Config: config}).Handler())
+	w.OnError(func(err error) { log.Print(err) })
+	log.Printf("Watching %s for %d channels", config.Name, len(config.Channels))
+	log.Fatal(w.Run(ctx))
+}
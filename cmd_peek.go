@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/prantoran/GoogleSheets_GO/peek"
+)
+
+func runHead(args []string)   { runPeek("head", args) }
+func runTail(args []string)   { runPeek("tail", args) }
+func runSample(args []string) { runPeek("sample", args) }
+
+func runPeek(name string, args []string) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	n := fs.Int("n", 10, "number of rows")
+	seed := fs.Int64("seed", 1, "random seed for sample")
+	noHeader := fs.Bool("no-header", false, "do not print the header row")
+	numbers := fs.Bool("row-numbers", false, "prefix each row with its sheet row number")
+	fs.Parse(args)
+	if fs.NArg() != 2 || *n < 0 {
+		fmt.Fprintf(os.Stderr, "usage: %s [-n ROWS] [flags] SPREADSHEET_ID TAB\n", name)
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	srv := newSheetsService(ctx)
+	var w *peek.Window
+	var err error
+	switch name {
+	case "head":
+		w, err = peek.Head(ctx, srv, fs.Arg(0), fs.Arg(1), *n)
+	case "tail":
+		w, err = peek.Tail(ctx, srv, fs.Arg(0), fs.Arg(1), *n)
+	case "sample":
+		w, err = peek.Sample(ctx, srv, fs.Arg(0), fs.Arg(1), *n, *seed)
+	}
+	checkError("Unable to read rows: ", err)
+
+	out := csv.NewWriter(os.Stdout)
+	record := func(prefix string, row []interface{}) []string {
+		var rec []string
+		if *numbers {
+			rec = append(rec, prefix)
+		}
+		for _, v := range row {
+			rec = append(rec, fmt.Sprint(v))
+		}
+		return rec
+	}
+	if !*noHeader {
+		checkError("Unable to write rows: ", out.Write(record("row", w.Header)))
+	}
+	for i, row := range w.Rows {
+		checkError("Unable to write rows: ", out.Write(record(strconv.Itoa(w.Numbers[i]), row)))
+	}
+	out.Flush()
+	checkError("Unable to write rows: ", out.Error())
+	if w.Total > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d rows\n", len(w.Rows), w.Total)
+	}
+}
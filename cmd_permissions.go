@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+
+	"github.com/prantoran/GoogleSheets_GO/driveperm"
+)
+
+const permissionsUsage = `usage:
+  permissions audit [flags] SPREADSHEET_ID...     list permissions and flag exposure
+  permissions enforce [flags] SPREADSHEET_ID...   apply a permission policy
+
+`
+
+func runPermissions(args []string) {
+	if len(args) == 0 || (args[0] != "audit" && args[0] != "enforce") {
+		fmt.Fprint(os.Stderr, permissionsUsage)
+		os.Exit(2)
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("permissions "+action, flag.ExitOnError)
+	allow := fs.String("allow-domains", "", "comma separated domains whose users are not reported as external")
+	asJSON := fs.Bool("json", false, "print the audit as JSON")
+	policyPath := fs.String("policy", "", `JSON policy, e.g. {"grants": [{"type": "group", "role": "writer", "email": "team@example.com"}], "exclusive": true}`)
+	dryRun := fs.Bool("dry-run", false, "only print the changes enforce would make")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, permissionsUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+	if fs.NArg() == 0 || (action == "enforce" && *policyPath == "") {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	if action == "audit" {
+		var domains []string
+		if *allow != "" {
+			domains = strings.Split(*allow, ",")
+		}
+		reports, err := driveperm.Audit(ctx, newDriveService(ctx), fs.Args(), domains)
+		checkError("Unable to audit permissions: ", err)
+		if *asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			checkError("Unable to write audit: ", enc.Encode(reports))
+			return
+		}
+		exposed := 0
+		for _, r := range reports {
+			fmt.Printf("%s (%s)\n", r.Name, r.FileID)
+			for _, p := range r.Permissions {
+				fmt.Printf("  %s\n", p)
+			}
+			for _, p := range r.Public {
+				fmt.Printf("  ! public: %s\n", p.Who())
+			}
+			for _, p := range r.DomainWide {
+				fmt.Printf("  ! domain-wide: %s\n", p.Who())
+			}
+			for _, p := range r.External {
+				fmt.Printf("  ! external: %s\n", p.Who())
+			}
+			if r.Exposed() {
+				exposed++
+			}
+		}
+		if exposed > 0 {
+			fmt.Printf("%d of %d spreadsheets are exposed\n", exposed, len(reports))
+			os.Exit(1)
+		}
+		return
+	}
+
+	b, err := ioutil.ReadFile(*policyPath)
+	checkError("Unable to read policy: ", err)
+	policy := &driveperm.Policy{}
+	checkError("Unable to parse policy: ", json.Unmarshal(b, policy))
+	// Changing permissions needs full Drive access, which the other
+	// commands do not ask for.
+	scopes = append(scopes, drive.DriveScope)
+	actions, err := driveperm.Enforce(ctx, newDriveService(ctx), fs.Args(), policy, *dryRun)
+	failed := false
+	for _, a := range actions {
+		fmt.Println(a)
+		failed = failed || a.Error != nil
+	}
+	checkError("Unable to enforce policy: ", err)
+	if failed {
+		os.Exit(1)
+	}
+}
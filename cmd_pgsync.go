@@ -0,0 +1,73 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	_ "github.com/lib/pq"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsync"
+)
+
+func runPgSync(args []string) {
+	fs := flag.NewFlagSet("pgsync", flag.ExitOnError)
+	dsn := fs.String("dsn", os.Getenv("DATABASE_URL"), "PostgreSQL connection string (default $DATABASE_URL)")
+	spreadsheetID := fs.String("spreadsheet", "", "spreadsheet ID")
+	tab := fs.String("tab", "", "tab to sync")
+	key := fs.String("key", "", "header of the key column")
+	table := fs.String("table", "", "table to sync with (default: sanitized tab name)")
+	direction := fs.String("direction", "sheet-to-db", "sheet-to-db, db-to-sheet or both")
+	columns := fs.String("columns", "", `column mapping as "Header=column,..." (default: all columns)`)
+	batch := fs.Int("batch", 500, "rows per upsert statement")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: pgsync -spreadsheet ID -tab TAB -key COLUMN [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	dir, ok := sheetsync.ParseDirection(*direction)
+	if *spreadsheetID == "" || *tab == "" || *key == "" || *dsn == "" || !ok {
+		fs.Usage()
+		os.Exit(2)
+	}
+	mapping := map[string]string{}
+	for _, pair := range strings.Split(*columns, ",") {
+		if pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			fs.Usage()
+			os.Exit(2)
+		}
+		mapping[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	if *table == "" {
+		*table = sheetsync.SanitizeColumn(*tab)
+	}
+
+	db, err := sql.Open("postgres", *dsn)
+	checkError("Unable to open database: ", err)
+	defer db.Close()
+
+	ctx := commandContext()
+	s := &sheetsync.PostgresSync{
+		Sheets:        newSheetsService(ctx),
+		DB:            db,
+		SpreadsheetID: *spreadsheetID,
+		Tab:           sheetsync.Tab{Name: *tab, KeyColumn: *key},
+		Table:         *table,
+		Columns:       mapping,
+		Direction:     dir,
+		BatchSize:     *batch,
+	}
+	report, err := s.Run(ctx)
+	checkError("Unable to sync: ", err)
+	fmt.Println(report)
+	for _, c := range report.Conflicts {
+		fmt.Printf("  conflict: %s\n", c.Key)
+	}
+}
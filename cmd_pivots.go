@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/prantoran/GoogleSheets_GO/pivot"
+)
+
+const pivotsUsage = `usage:
+  pivots export SPREADSHEET_ID > pivots.json        print the pivot table definitions
+  pivots apply -f pivots.json SPREADSHEET_ID        (re)create pivot tables from definitions
+
+`
+
+func runPivots(args []string) {
+	if len(args) == 0 || (args[0] != "export" && args[0] != "apply") {
+		fmt.Fprint(os.Stderr, pivotsUsage)
+		os.Exit(2)
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("pivots "+action, flag.ExitOnError)
+	file := fs.String("f", "", "JSON file with a list of pivot table definitions")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, pivotsUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+	if fs.NArg() != 1 || (action == "apply" && *file == "") {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	srv := newSheetsService(ctx)
+	if action == "export" {
+		tables, err := pivot.Read(ctx, srv, fs.Arg(0))
+		checkError("Unable to read pivot tables: ", err)
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		checkError("Unable to write pivot tables: ", enc.Encode(tables))
+		return
+	}
+
+	b, err := ioutil.ReadFile(*file)
+	checkError("Unable to read pivot definitions: ", err)
+	var tables []*pivot.Table
+	checkError("Unable to parse pivot definitions: ", json.Unmarshal(b, &tables))
+	for _, t := range tables {
+		checkError("Unable to create pivot table: ", pivot.Create(ctx, srv, fs.Arg(0), t))
+		fmt.Printf("Created pivot table at %s from %s\n", t.Anchor, t.Source)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+const profileUsage = `usage:
+  profile list                          list the profiles, marking the active one
+  profile add NAME [-client-secret FILE] [-key FILE]
+                                        create a profile with its own OAuth client or service account key
+  profile use NAME                      act as NAME when -profile is not given
+  profile use -none                     go back to the default credentials
+  profile delete NAME                   delete a profile and its cached token
+
+The first command run with a profile authorizes it and caches its token.
+
+Flags:
+`
+
+func runProfile(args []string) {
+	nargs := map[string]int{"list": 0, "add": 1, "use": 1, "delete": 1}
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	action := args[0]
+	if _, ok := nargs[action]; !ok {
+		fmt.Fprint(os.Stderr, profileUsage)
+		os.Exit(2)
+	}
+	fs := flag.NewFlagSet("profile "+action, flag.ExitOnError)
+	secret := fs.String("client-secret", "", "add: client_secret.json of the profile's OAuth client")
+	key := fs.String("key", "", "add: service account JSON key to act as instead of a user")
+	none := fs.Bool("none", false, "use: stop using a profile by default")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, profileUsage)
+		fs.PrintDefaults()
+	}
+	// Accept flags after the name, as in "profile add work -client-secret FILE".
+	var pos []string
+	for args = args[1:]; len(args) > 0; args = fs.Args()[1:] {
+		fs.Parse(args)
+		if fs.NArg() == 0 {
+			break
+		}
+		pos = append(pos, fs.Arg(0))
+	}
+	want := nargs[action]
+	if action == "use" && *none {
+		want = 0
+	}
+	if len(pos) != want {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	switch action {
+	case "list":
+		names, err := sheetsclient.Profiles()
+		checkError("Unable to list profiles: ", err)
+		active, err := sheetsclient.ActiveProfile()
+		checkError("Unable to read active profile: ", err)
+		for _, name := range names {
+			mark := " "
+			if name == active {
+				mark = "*"
+			}
+			fmt.Printf("%s %s\n", mark, name)
+		}
+	case "add":
+		p, err := sheetsclient.OpenProfile(pos[0])
+		checkError("Invalid profile: ", err)
+		if *secret == "" && *key == "" {
+			*secret = "client_secret.json"
+		}
+		checkError("Unable to create profile: ", p.Create(*secret, *key))
+		fmt.Printf("Created profile %s in %s\n", p.Name, p.Dir)
+	case "use":
+		if *none {
+			checkError("Unable to reset active profile: ", sheetsclient.SetActiveProfile(""))
+			fmt.Println("Using the default credentials")
+			return
+		}
+		p, err := sheetsclient.OpenProfile(pos[0])
+		checkError("Invalid profile: ", err)
+		if !p.Exists() {
+			fmt.Fprintf(os.Stderr, "No profile named %q\n", p.Name)
+			os.Exit(1)
+		}
+		checkError("Unable to set active profile: ", sheetsclient.SetActiveProfile(p.Name))
+		fmt.Printf("Using profile %s\n", p.Name)
+	case "delete":
+		p, err := sheetsclient.OpenProfile(pos[0])
+		checkError("Invalid profile: ", err)
+		if !p.Exists() {
+			fmt.Fprintf(os.Stderr, "No profile named %q\n", p.Name)
+			os.Exit(1)
+		}
+		checkError("Unable to delete profile: ", p.Delete())
+		fmt.Printf("Deleted profile %s\n", p.Name)
+	}
+}
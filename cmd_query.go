@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/prantoran/GoogleSheets_GO/export"
+	"github.com/prantoran/GoogleSheets_GO/sheetquery"
+)
+
+func runQuery(args []string) {
+	fs := flag.NewFlagSet("query", flag.ExitOnError)
+	spreadsheetID := fs.String("spreadsheet", "", "spreadsheet ID")
+	format := fs.String("format", "table", "table, csv, tsv, json, markdown or html")
+	out := fs.String("out", "", "file to write (default stdout)")
+	schema := fs.String("schema", "", "column types overriding the inferred ones, e.g. \"Zip=string\"")
+	fs.Parse(args)
+	if *spreadsheetID == "" || fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: query -spreadsheet ID [-format FORMAT] \"SELECT name, SUM(amount) FROM 'Sheet1!A1:D' GROUP BY name\"")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+	types, err := export.ParseSchema(*schema)
+	checkError("Invalid -schema: ", err)
+
+	ctx := commandContext()
+	rows, err := sheetquery.Run(ctx, newSheetsService(ctx), *spreadsheetID, fs.Arg(0), types)
+	checkError("Unable to run query: ", err)
+
+	w := io.Writer(os.Stdout)
+	if *out != "" && *out != "-" {
+		f, err := os.Create(*out)
+		checkError("Unable to create output file: ", err)
+		defer func() { checkError("Unable to write output file: ", f.Close()) }()
+		w = f
+	}
+	switch *format {
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, row := range rows {
+			for _, v := range row {
+				if v == nil {
+					v = ""
+				}
+				fmt.Fprintf(tw, "%v\t", v)
+			}
+			fmt.Fprintln(tw)
+		}
+		err = tw.Flush()
+	case "csv":
+		err = export.CSV(w, rows, ',')
+	case "tsv":
+		err = export.CSV(w, rows, '\t')
+	case "json":
+		err = export.JSON(w, rows, export.JSONOptions{Header: true})
+	case "markdown", "md":
+		err = export.Markdown(w, rows)
+	case "html":
+		err = export.HTML(w, rows, export.HTMLOptions{Header: true})
+	default:
+		err = fmt.Errorf("unknown format %q", *format)
+	}
+	checkError("Unable to write result: ", err)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"google.golang.org/api/drive/v3"
+
+	"github.com/prantoran/GoogleSheets_GO/sheettemplate"
+)
+
+func runRender(args []string) {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	templateID := fs.String("template", "", "ID of the template spreadsheet")
+	dataPath := fs.String("data", "", `JSON object with the placeholder values, e.g. {"customer": "ACME"}`)
+	folder := fs.String("folder", "", "Drive folder to create the spreadsheet in")
+	allowMissing := fs.Bool("allow-missing", false, "leave placeholders without data instead of failing")
+	list := fs.Bool("list", false, "only list the placeholders of the template")
+	fs.Parse(args)
+	if *templateID == "" || (*dataPath == "" && !*list) {
+		fmt.Fprintln(os.Stderr, "usage: render -template ID (-data FILE | -list) [flags]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	// Copying files the tool did not create needs the full Drive scope.
+	scopes = append(scopes, drive.DriveScope)
+	ctx := commandContext()
+	t := &sheettemplate.Template{
+		Drive:        newDriveService(ctx),
+		Sheets:       newSheetsService(ctx),
+		TemplateID:   *templateID,
+		Folder:       *folder,
+		AllowMissing: *allowMissing,
+	}
+	if *list {
+		names, err := t.Placeholders(ctx)
+		checkError("Unable to read template: ", err)
+		for _, n := range names {
+			fmt.Println(n)
+		}
+		return
+	}
+
+	b, err := ioutil.ReadFile(*dataPath)
+	checkError("Unable to read data file: ", err)
+	var data map[string]string
+	checkError("Unable to parse data file: ", json.Unmarshal(b, &data))
+	f, err := t.Render(ctx, data)
+	checkError("Unable to render template: ", err)
+	fmt.Printf("%s\t%s\t%s\n", f.ID, f.Title, f.URL)
+}
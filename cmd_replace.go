@@ -0,0 +1,31 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+func runReplace(args []string) {
+	fs := flag.NewFlagSet("replace", flag.ExitOnError)
+	spreadsheetID := fs.String("spreadsheet", "", "spreadsheet ID")
+	scope := fs.String("range", "", "A1 range, tab or named range to search; default every tab")
+	var opts sheetsclient.ReplaceOptions
+	fs.BoolVar(&opts.Regexp, "regex", false, "FIND is a regular expression; REPLACEMENT may use $1, $2...")
+	fs.BoolVar(&opts.MatchCase, "match-case", false, "match letter case exactly")
+	fs.BoolVar(&opts.MatchEntireCell, "entire-cell", false, "only match cells whose whole value is FIND")
+	fs.BoolVar(&opts.IncludeFormulas, "formulas", false, "also replace within formulas")
+	fs.Parse(args)
+	if *spreadsheetID == "" || fs.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "usage: replace -spreadsheet ID [-range RANGE] [-regex] [-match-case] [-entire-cell] [-formulas] FIND REPLACEMENT")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	r, err := newClient(ctx).Replace(ctx, *spreadsheetID, *scope, fs.Arg(0), fs.Arg(1), &opts)
+	checkError("Unable to replace: ", err)
+	fmt.Printf("Replaced %d occurrences in %d cells on %d tabs\n", r.Occurrences, r.Values+r.Formulas, r.Tabs)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+
+	"github.com/prantoran/GoogleSheets_GO/revisions"
+)
+
+func runRevisions(args []string) {
+	fs := flag.NewFlagSet("revisions", flag.ExitOnError)
+	output := fs.String("export", "", "write the spreadsheet as of -revision or -at to this file")
+	revID := fs.String("revision", "", "revision ID to export")
+	at := fs.String("at", "", `export the revision current at this time, RFC 3339 or "2006-01-02 15:04" local time`)
+	format := fs.String("format", "", "export format: xlsx, ods, pdf, csv or tsv (default from the file extension)")
+	fs.Parse(args)
+	if fs.NArg() != 1 || (*output != "" && (*revID == "") == (*at == "")) {
+		fmt.Fprintln(os.Stderr, "usage: revisions SPREADSHEET_ID\n       revisions -export FILE (-revision ID | -at TIME) SPREADSHEET_ID")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	if *output != "" {
+		// Downloading content needs more than the metadata scope.
+		scopes = append(scopes, drive.DriveReadonlyScope)
+	}
+	ctx := commandContext()
+	revs, err := revisions.List(ctx, newDriveService(ctx), fs.Arg(0))
+	checkError("Unable to list revisions: ", err)
+
+	if *output == "" {
+		for _, r := range revs {
+			fmt.Printf("%-12s %s  %s %s\n", r.ID, r.Modified.Local().Format("2006-01-02 15:04:05"), r.Author, r.Email)
+		}
+		return
+	}
+
+	var rev *revisions.Revision
+	if *revID != "" {
+		rev = revisions.Find(revs, *revID)
+	} else {
+		t, err := time.Parse(time.RFC3339, *at)
+		if err != nil {
+			t, err = time.ParseInLocation("2006-01-02 15:04", *at, time.Local)
+		}
+		checkError("Unable to parse -at: ", err)
+		rev = revisions.At(revs, t)
+	}
+	if rev == nil {
+		log.Fatal("No matching revision")
+	}
+	f := *format
+	if f == "" {
+		f = strings.TrimPrefix(filepath.Ext(*output), ".")
+	}
+	out, err := os.Create(*output)
+	checkError("Unable to create export file: ", err)
+	err = revisions.Export(ctx, newHTTPClient(ctx), rev, f, out)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	checkError("Unable to export revision: ", err)
+	fmt.Printf("Exported revision %s of %s by %s to %s\n", rev.ID, rev.Modified.Local().Format("2006-01-02 15:04"), rev.Author, *output)
+}
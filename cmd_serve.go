@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetapi"
+)
+
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	spreadsheetID := fs.String("spreadsheet", "", "spreadsheet ID")
+	tabs := fs.String("tabs", "", `comma separated tabs to expose; suffix ":append" to allow POST, e.g. "Inventory,Orders:append"`)
+	listen := fs.String("listen", ":8080", "address to listen on")
+	keysFile := fs.String("keys", "", "file with one accepted API key per line (default $SHEETS_API_KEYS, comma separated)")
+	cacheTTL := fs.Duration("cache", 0, "reuse tab reads for this long")
+	rateLimit := fs.Float64("rate", 5, "requests per second allowed per API key, 0 for unlimited")
+	burst := fs.Int("burst", 10, "request burst allowed per API key")
+	userEntered := fs.Bool("user-entered", false, "parse appended values as typed into Sheets, which lets clients write formulas")
+	fs.Parse(args)
+	if *spreadsheetID == "" || *tabs == "" {
+		fmt.Fprintln(os.Stderr, "usage: serve -spreadsheet ID -tabs TAB[:append],... [flags]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	srv := &sheetapi.Server{
+		Sheets:        newSheetsService(commandContext()),
+		SpreadsheetID: *spreadsheetID,
+		CacheTTL:      *cacheTTL,
+		RateLimit:     *rateLimit,
+		Burst:         *burst,
+		UserEntered:   *userEntered,
+	}
+	for _, spec := range strings.Split(*tabs, ",") {
+		name := strings.TrimSuffix(spec, ":append")
+		srv.Tabs = append(srv.Tabs, sheetapi.Tab{Name: name, Append: name != spec})
+	}
+	srv.APIKeys = readKeys(*keysFile, "SHEETS_API_KEYS")
+	if len(srv.APIKeys) == 0 {
+		log.Fatal("No API keys configured; use -keys or $SHEETS_API_KEYS")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/tabs/", srv)
+	log.Printf("Serving %d tabs on %s", len(srv.Tabs), *listen)
+	log.Fatal(http.ListenAndServe(*listen, mux))
+}
+
+// readKeys returns the keys listed one per line in file, skipping blank
+// lines and # comments, or else the comma separated keys of the env
+// variable.
+func readKeys(file, env string) []string {
+	if file == "" {
+		if v := os.Getenv(env); v != "" {
+			return strings.Split(v, ",")
+		}
+		return nil
+	}
+	f, err := os.Open(file)
+	checkError("Unable to open keys file: ", err)
+	defer f.Close()
+	var keys []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if k := strings.TrimSpace(sc.Text()); k != "" && !strings.HasPrefix(k, "#") {
+			keys = append(keys, k)
+		}
+	}
+	checkError("Unable to read keys file: ", sc.Err())
+	return keys
+}
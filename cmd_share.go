@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"google.golang.org/api/drive/v3"
+
+	"github.com/prantoran/GoogleSheets_GO/driveperm"
+)
+
+func runShare(args []string) {
+	fs := flag.NewFlagSet("share", flag.ExitOnError)
+	email := fs.String("email", "", "email address of the user or group to share with")
+	domain := fs.String("domain", "", "share with everyone in this domain")
+	anyone := fs.Bool("anyone", false, "share with anyone who has the link")
+	group := fs.Bool("group", false, "the -email address is a group")
+	role := fs.String("role", "reader", "reader, commenter or writer")
+	notify := fs.Bool("notify", false, "send the sharing email to the user or group")
+	revoke := fs.Bool("revoke", false, "remove the access of -email, -domain or -anyone instead")
+	list := fs.Bool("list", false, "only list the current permissions")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: share [-email ADDRESS | -domain DOMAIN | -anyone] [-role ROLE] [-revoke] SPREADSHEET_ID")
+		fmt.Fprintln(os.Stderr, "       share -list SPREADSHEET_ID")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	g := driveperm.Grant{Type: "user", Role: *role, Email: *email}
+	switch {
+	case *group:
+		g.Type = "group"
+	case *domain != "":
+		g.Type, g.Domain = "domain", *domain
+	case *anyone:
+		g.Type = "anyone"
+	}
+	if fs.NArg() != 1 || (!*list && g.Email == "" && g.Domain == "" && g.Type != "anyone") {
+		fs.Usage()
+		os.Exit(2)
+	}
+	id := fs.Arg(0)
+
+	ctx := commandContext()
+	if *list {
+		perms, err := driveperm.List(ctx, newDriveService(ctx), id)
+		checkError("Unable to list permissions: ", err)
+		for _, p := range perms {
+			fmt.Println(p)
+		}
+		return
+	}
+	// Changing permissions needs full Drive access, which the other
+	// commands do not ask for.
+	scopes = append(scopes, drive.DriveScope)
+	srv := newDriveService(ctx)
+	if *revoke {
+		who := g.Email + g.Domain
+		if g.Type == "anyone" {
+			who = "anyone"
+		}
+		actions, err := driveperm.Revoke(ctx, srv, id, who)
+		for _, a := range actions {
+			fmt.Println(a)
+		}
+		checkError("Unable to revoke access: ", err)
+		return
+	}
+	a, err := driveperm.Share(ctx, srv, id, g, *notify)
+	checkError("Unable to share: ", err)
+	fmt.Println(a)
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+func runSort(args []string) {
+	fs := flag.NewFlagSet("sort", flag.ExitOnError)
+	spreadsheetID, rng := rangeFlags(fs)
+	by := fs.String("by", "1", "columns to sort by in order of precedence, as positions within the range or letters with an optional order, e.g. \"3:desc,1:asc\"")
+	fs.Parse(args)
+	requireRange(fs, "sort -spreadsheet ID -range RANGE [-by COLUMN[:asc|desc],...]", *spreadsheetID, *rng)
+
+	keys, err := sheetsclient.ParseSortKeys(*by)
+	checkError("Invalid -by: ", err)
+	ctx := commandContext()
+	checkError("Unable to sort: ", newClient(ctx).Sort(ctx, *spreadsheetID, *rng, keys...))
+	fmt.Printf("Sorted %s\n", *rng)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/prantoran/GoogleSheets_GO/spec"
+)
+
+// runPlan and runApply share their flags; apply only makes changes after
+// printing the plan.
+func runPlan(args []string)  { runSpec("plan", args) }
+func runApply(args []string) { runSpec("apply", args) }
+
+func runSpec(name string, args []string) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	specPath := fs.String("spec", "spreadsheet.yaml", "YAML spec describing the spreadsheet")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fmt.Fprintf(os.Stderr, "usage: %s [-spec FILE] SPREADSHEET_ID...\n", name)
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	s, err := spec.Load(*specPath)
+	checkError("Unable to load spec: ", err)
+	ctx := commandContext()
+	srv := newSheetsService(ctx)
+	for _, id := range fs.Args() {
+		plan, err := spec.Diff(ctx, srv, id, s)
+		checkError("Unable to plan changes: ", err)
+		fmt.Printf("%s:\n%s", id, plan)
+		if name == "apply" && len(plan.Changes) > 0 {
+			checkError("Unable to apply changes: ", spec.Apply(ctx, srv, plan))
+			fmt.Printf("Applied %d changes.\n", len(plan.Changes))
+		}
+	}
+}
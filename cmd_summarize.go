@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+	"github.com/prantoran/GoogleSheets_GO/summarize"
+)
+
+func runSummarize(args []string) {
+	fs := flag.NewFlagSet("summarize", flag.ExitOnError)
+	groupBy := fs.String("group-by", "", "comma separated columns to group by")
+	aggSpec := fs.String("agg", "count(*)", "aggregations, e.g. 'sum(Revenue),avg(Price),count(*)'")
+	format := fs.String("format", "csv", "output format: csv or json")
+	outTab := fs.String("out-tab", "", "write the result into this tab of the spreadsheet instead of stdout")
+	pageSize := fs.Int("page-size", 5000, "rows read per request")
+	fs.Parse(args)
+	if fs.NArg() != 2 || (*format != "csv" && *format != "json") {
+		fmt.Fprintln(os.Stderr, "usage: summarize [-group-by COLUMNS] [-agg AGGS] [flags] SPREADSHEET_ID TAB")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+	aggs, err := summarize.ParseAggs(*aggSpec)
+	checkError("Invalid -agg: ", err)
+	var groups []string
+	if *groupBy != "" {
+		groups = strings.Split(*groupBy, ",")
+	}
+
+	ctx := commandContext()
+	srv := newSheetsService(ctx)
+	s, err := summarize.Tab(ctx, srv, fs.Arg(0), fs.Arg(1), groups, aggs, *pageSize)
+	checkError("Unable to summarize tab: ", err)
+	header, rows := s.Header(), s.Rows()
+
+	if *outTab != "" {
+		values := [][]interface{}{make([]interface{}, len(header))}
+		for i, h := range header {
+			values[0][i] = h
+		}
+		values = append(values, rows...)
+		writeSummaryTab(ctx, srv, fs.Arg(0), *outTab, values)
+		fmt.Printf("Wrote %d groups to %s\n", len(rows), *outTab)
+		return
+	}
+	if *format == "json" {
+		var objs []map[string]interface{}
+		for _, row := range rows {
+			obj := map[string]interface{}{}
+			for i, h := range header {
+				obj[h] = row[i]
+			}
+			objs = append(objs, obj)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		checkError("Unable to write summary: ", enc.Encode(objs))
+		return
+	}
+	w := csv.NewWriter(os.Stdout)
+	w.Write(header)
+	for _, row := range rows {
+		rec := make([]string, len(row))
+		for i, v := range row {
+			rec[i] = fmt.Sprint(v)
+		}
+		w.Write(rec)
+	}
+	w.Flush()
+	checkError("Unable to write summary: ", w.Error())
+}
+
+// writeSummaryTab replaces the content of tab, creating it if needed.
+func writeSummaryTab(ctx context.Context, srv *sheets.Service, id, tab string, values [][]interface{}) {
+	resp, err := srv.Spreadsheets.Get(id).Fields("sheets.properties.title").Context(ctx).Do()
+	checkError("Unable to get tabs: ", err)
+	exists := false
+	for _, sh := range resp.Sheets {
+		exists = exists || sh.Properties.Title == tab
+	}
+	if !exists {
+		_, err = srv.Spreadsheets.BatchUpdate(id, &sheets.BatchUpdateSpreadsheetRequest{
+			Requests: []*sheets.Request{{AddSheet: &sheets.AddSheetRequest{Properties: &sheets.SheetProperties{Title: tab}}}},
+		}).Context(ctx).Do()
+		checkError("Unable to create tab: ", err)
+	}
+	rng := sheetsclient.QuoteTab(tab)
+	_, err = srv.Spreadsheets.Values.Clear(id, rng, &sheets.ClearValuesRequest{}).Context(ctx).Do()
+	checkError("Unable to clear tab: ", err)
+	_, err = srv.Spreadsheets.Values.Update(id, rng, &sheets.ValueRange{Values: values}).
+		ValueInputOption("RAW").Context(ctx).Do()
+	checkError("Unable to write tab: ", err)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+	"golang.org/x/net/context"
+
+	"github.com/prantoran/GoogleSheets_GO/publish"
+	"github.com/prantoran/GoogleSheets_GO/sheetsync"
+)
+
+const syncUsage = `usage:
+  sync [flags] TAB[:KEYCOLUMN]...               sync tabs with the mirror
+  sync status [flags] TAB[:KEYCOLUMN]...        report pending changes and conflicts
+  sync resolve [flags] TAB KEY local|remote     settle a conflict
+  sync -csv FILE [-prefer local|remote|abort] [flags] TAB:KEYCOLUMN
+                                                sync a tab with a CSV file
+
+Tabs given without a key column are tracked with row developer metadata.
+
+Flags:
+`
+
+func runSync(args []string) {
+	action := "sync"
+	if len(args) > 0 && (args[0] == "status" || args[0] == "resolve") {
+		action, args = args[0], args[1:]
+	}
+
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	dbPath := fs.String("db", "sheets.db", "path of the SQLite mirror")
+	spreadsheetID := fs.String("spreadsheet", "", "spreadsheet ID")
+	policyName := fs.String("policy", "manual", "conflict policy: manual or lww")
+	csvPath := fs.String("csv", "", "sync with this CSV file instead of the mirror")
+	statePath := fs.String("state", "", "with -csv, the state file (default FILE.sync.json)")
+	prefer := fs.String("prefer", "abort", "with -csv, settle rows changed on both sides: local, remote or abort")
+	publishURL := fs.String("publish", "", "publish applied changes to kafka://brokers/topic or nats://host/subject")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, syncUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *csvPath != "" {
+		syncCSV(action, *spreadsheetID, *csvPath, *statePath, *prefer, fs)
+		return
+	}
+
+	db, err := sql.Open("sqlite3", *dbPath)
+	checkError("Unable to open mirror: ", err)
+	defer db.Close()
+	mirror, err := sheetsync.OpenMirror(db)
+	checkError("Unable to open mirror: ", err)
+
+	if action == "resolve" {
+		if fs.NArg() != 3 || (fs.Arg(2) != "local" && fs.Arg(2) != "remote") {
+			fs.Usage()
+			os.Exit(2)
+		}
+		keep := sheetsync.Local
+		if fs.Arg(2) == "remote" {
+			keep = sheetsync.Remote
+		}
+		checkError("Unable to resolve conflict: ", mirror.Resolve(fs.Arg(0), fs.Arg(1), keep))
+		fmt.Printf("Kept %s version of %q; run sync to apply it.\n", fs.Arg(2), fs.Arg(1))
+		return
+	}
+
+	policy, ok := sheetsync.ParsePolicy(*policyName)
+	if *spreadsheetID == "" || fs.NArg() == 0 || !ok {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	engine := &sheetsync.Engine{
+		Sheets:        newSheetsService(ctx),
+		Mirror:        mirror,
+		SpreadsheetID: *spreadsheetID,
+		Policy:        policy,
+	}
+	if policy == sheetsync.LastWriterWins {
+		engine.Drive = newDriveService(ctx)
+	}
+	if *publishURL != "" {
+		pub, err := publish.Open(*publishURL)
+		checkError("Unable to open publisher: ", err)
+		defer pub.Close()
+		engine.OnChanges = func(ctx context.Context, res *sheetsync.Result) error {
+			return pub.Publish(ctx, publish.FromSync(*spreadsheetID, res))
+		}
+	}
+
+	for _, spec := range fs.Args() {
+		tab := sheetsync.ParseTab(spec)
+		if action == "status" {
+			st, err := engine.Status(ctx, tab)
+			checkError("Unable to get sync status: ", err)
+			printSyncStatus(st)
+			continue
+		}
+		res, err := engine.Sync(ctx, tab)
+		checkError("Unable to sync: ", err)
+		fmt.Println(res)
+		for _, c := range res.Conflicts {
+			fmt.Printf("  conflict: %s\n", c.Key)
+		}
+	}
+}
+
+// syncCSV runs sync and sync status with -csv.
+func syncCSV(action, spreadsheetID, path, statePath, prefer string, fs *flag.FlagSet) {
+	resolution, ok := sheetsync.ParseResolution(prefer)
+	if action == "resolve" || spreadsheetID == "" || fs.NArg() != 1 || !ok {
+		fs.Usage()
+		os.Exit(2)
+	}
+	ctx := commandContext()
+	s := &sheetsync.CSVSync{
+		Sheets:        newSheetsService(ctx),
+		SpreadsheetID: spreadsheetID,
+		Tab:           sheetsync.ParseTab(fs.Arg(0)),
+		Path:          path,
+		StatePath:     statePath,
+		Resolution:    resolution,
+	}
+	if action == "status" {
+		st, err := s.Status(ctx)
+		checkError("Unable to get sync status: ", err)
+		printSyncStatus(st)
+		return
+	}
+	res, err := s.Sync(ctx)
+	if ce, ok := err.(*sheetsync.ConflictError); ok {
+		for _, c := range ce.Conflicts {
+			fmt.Printf("  conflict: %s\n    local:  %s\n    remote: %s\n", c.Key, describe(c.Local), describe(c.Remote))
+		}
+		fmt.Fprintln(os.Stderr, "Nothing was synced; rerun with -prefer local or -prefer remote.")
+		os.Exit(1)
+	}
+	checkError("Unable to sync: ", err)
+	fmt.Println(res)
+}
+
+func printSyncStatus(st *sheetsync.TabStatus) {
+	last := "never"
+	if !st.LastSync.IsZero() {
+		last = st.LastSync.Local().Format("2006-01-02 15:04:05")
+	}
+	fmt.Printf("%s (last sync: %s)\n", st.Tab, last)
+	fmt.Printf("  %d local changes to push\n", st.Outgoing)
+	fmt.Printf("  %d remote changes to pull\n", st.Incoming)
+	if len(st.Conflicts) == 0 {
+		return
+	}
+	fmt.Printf("  %d conflicts:\n", len(st.Conflicts))
+	for _, c := range st.Conflicts {
+		fmt.Printf("    %s\n      local:  %s\n      remote: %s\n", c.Key, describe(c.Local), describe(c.Remote))
+	}
+}
+
+func describe(r *sheetsync.Record) string {
+	if r == nil {
+		return "(deleted)"
+	}
+	return strings.Join(r.Values, ", ")
+}
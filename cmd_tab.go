@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+const tabUsage = `usage:
+  tab list -spreadsheet ID                  list the tabs
+  tab add -spreadsheet ID TITLE             add an empty tab
+  tab rename -spreadsheet ID TITLE NEW      rename a tab
+  tab duplicate -spreadsheet ID [-index N] TITLE NEW
+                                            copy a tab, e.g. a monthly template
+  tab freeze -spreadsheet ID [-rows N] [-columns N] TITLE
+                                            keep rows and columns in view, e.g. the header
+  tab delete -spreadsheet ID -confirm TITLE delete a tab and its content
+
+`
+
+func runTab(args []string) {
+	nargs := map[string]int{"list": 0, "add": 1, "rename": 2, "duplicate": 2, "freeze": 1, "delete": 1}
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, tabUsage)
+		os.Exit(2)
+	}
+	action := args[0]
+	n, ok := nargs[action]
+	fs := flag.NewFlagSet("tab "+action, flag.ExitOnError)
+	spreadsheetID := fs.String("spreadsheet", "", "spreadsheet ID")
+	index := fs.Int64("index", -1, "duplicate: position of the copy, 0 for first; last by default")
+	confirm := fs.Bool("confirm", false, "delete: really delete the tab")
+	rows := fs.Int64("rows", 1, "freeze: rows to freeze; 0 unfreezes")
+	columns := fs.Int64("columns", 0, "freeze: columns to freeze")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, tabUsage)
+		fs.PrintDefaults()
+	}
+	if !ok {
+		fs.Usage()
+		os.Exit(2)
+	}
+	fs.Parse(args[1:])
+	if *spreadsheetID == "" || fs.NArg() != n {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	c := newClient(ctx)
+	switch action {
+	case "list":
+		tabs, err := c.Tabs(ctx, *spreadsheetID)
+		checkError("Unable to list tabs: ", err)
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "SHEET ID\tTITLE\tROWS\tCOLUMNS\tFROZEN")
+		for _, t := range tabs {
+			fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%d,%d\n", t.ID, t.Title, t.Rows, t.Columns, t.FrozenRows, t.FrozenColumns)
+		}
+		checkError("Unable to list tabs: ", w.Flush())
+	case "add":
+		t, err := c.AddTab(ctx, *spreadsheetID, fs.Arg(0))
+		checkError("Unable to add tab: ", err)
+		fmt.Printf("Added %q with sheet ID %d\n", t.Title, t.ID)
+	case "rename":
+		checkError("Unable to rename tab: ", c.RenameTab(ctx, *spreadsheetID, fs.Arg(0), fs.Arg(1)))
+		fmt.Printf("Renamed %q to %q\n", fs.Arg(0), fs.Arg(1))
+	case "duplicate":
+		t, err := c.DuplicateTab(ctx, *spreadsheetID, fs.Arg(0), fs.Arg(1), *index)
+		checkError("Unable to duplicate tab: ", err)
+		fmt.Printf("Copied %q to %q at position %d with sheet ID %d\n", fs.Arg(0), t.Title, t.Index, t.ID)
+	case "freeze":
+		checkError("Unable to freeze tab: ", c.Freeze(ctx, *spreadsheetID, fs.Arg(0), *rows, *columns))
+		fmt.Printf("Froze %d rows and %d columns of %q\n", *rows, *columns, fs.Arg(0))
+	case "delete":
+		if !*confirm {
+			fmt.Fprintf(os.Stderr, "Not deleting %q; rerun with -confirm.\n", fs.Arg(0))
+			os.Exit(1)
+		}
+		checkError("Unable to delete tab: ", c.DeleteTab(ctx, *spreadsheetID, fs.Arg(0)))
+		fmt.Printf("Deleted %q\n", fs.Arg(0))
+	}
+}
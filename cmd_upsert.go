@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func runUpsert(args []string) {
+	fs := flag.NewFlagSet("upsert", flag.ExitOnError)
+	spreadsheetID, rng := rangeFlags(fs)
+	key := fs.String("key", "", "header name of the column matching rows")
+	in := fs.String("in", "", "CSV file with the rows, in the table's column order and without a header (default stdin)")
+	skip := fs.Int("skip", 0, "rows above the header of the table, e.g. a title")
+	raw := fs.Bool("raw", false, "store values as given instead of parsing numbers, dates and formulas")
+	fs.Parse(args)
+	if *spreadsheetID == "" || *rng == "" || *key == "" || fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: upsert -spreadsheet ID -range RANGE -key COLUMN [-in FILE] [-skip N] [-raw]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	c := newClient(ctx)
+	c.SkipRows = *skip
+	if *raw {
+		c.ValueInputOption = "RAW"
+	}
+	res, err := c.Upsert(ctx, *spreadsheetID, *rng, readInput(*in), *key)
+	checkError("Unable to upsert: ", err)
+	fmt.Printf("Updated %d rows and appended %d to %s\n", res.Updated, res.Appended, *rng)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/prantoran/GoogleSheets_GO/schema"
+)
+
+func runValidate(args []string) {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	schemaPath := fs.String("schema", "schema.json", "JSON schema of the spreadsheet")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: validate [-schema schema.json] [-json] SPREADSHEET_ID...")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	s, err := schema.Load(*schemaPath)
+	checkError("Unable to load schema: ", err)
+	ctx := commandContext()
+	srv := newSheetsService(ctx)
+	failed := false
+	var reports []*schema.Report
+	for _, id := range fs.Args() {
+		r, err := schema.Validate(ctx, srv, id, s)
+		checkError("Unable to validate spreadsheet: ", err)
+		reports = append(reports, r)
+		if !r.OK() {
+			failed = true
+		}
+		if *asJSON {
+			continue
+		}
+		for _, v := range r.Violations {
+			fmt.Printf("%s: %s\n", id, v)
+		}
+		if r.OK() {
+			fmt.Printf("%s: ok\n", id)
+		}
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		checkError("Unable to write report: ", enc.Encode(reports))
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
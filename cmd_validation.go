@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/prantoran/GoogleSheets_GO/validation"
+)
+
+const validationUsage = `usage:
+  validation set -range RANGE -list A,B,C [flags] SPREADSHEET_ID
+  validation set -range RANGE -from RANGE [flags] SPREADSHEET_ID
+  validation set -range RANGE -between MIN,MAX [flags] SPREADSHEET_ID
+  validation set -range RANGE -formula FORMULA [flags] SPREADSHEET_ID
+  validation set -range RANGE -checkbox SPREADSHEET_ID
+  validation clear -range RANGE SPREADSHEET_ID
+
+Flags:
+`
+
+func runValidation(args []string) {
+	if len(args) == 0 || (args[0] != "set" && args[0] != "clear") {
+		fmt.Fprint(os.Stderr, validationUsage)
+		os.Exit(2)
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("validation "+action, flag.ExitOnError)
+	rng := fs.String("range", "", "cells to validate, e.g. \"Intake!C2:C\"")
+	list := fs.String("list", "", "set: dropdown of these comma separated values")
+	from := fs.String("from", "", "set: dropdown of the values in this range, e.g. \"Lists!A2:A\"")
+	between := fs.String("between", "", "set: numbers from MIN to MAX, e.g. \"1,10\"")
+	formula := fs.String("formula", "", "set: custom formula for the top left cell, e.g. \"=ISEMAIL(C2)\"")
+	checkbox := fs.Bool("checkbox", false, "set: checkboxes")
+	strict := fs.Bool("strict", false, "set: reject invalid input instead of flagging it")
+	message := fs.String("message", "", "set: help text shown on the cells")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, validationUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+	if fs.NArg() != 1 || *rng == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var r *validation.Rule
+	if action == "set" {
+		n := 0
+		for _, given := range []bool{*list != "", *from != "", *between != "", *formula != "", *checkbox} {
+			if given {
+				n++
+			}
+		}
+		if n != 1 {
+			fs.Usage()
+			os.Exit(2)
+		}
+		switch {
+		case *list != "":
+			values := strings.Split(*list, ",")
+			for i, v := range values {
+				values[i] = strings.TrimSpace(v)
+			}
+			r = validation.OneOf(values...)
+		case *from != "":
+			r = validation.OneOfRange(*from)
+		case *between != "":
+			bounds := strings.Split(*between, ",")
+			if len(bounds) != 2 {
+				fs.Usage()
+				os.Exit(2)
+			}
+			min, err := strconv.ParseFloat(strings.TrimSpace(bounds[0]), 64)
+			checkError("Invalid -between: ", err)
+			max, err := strconv.ParseFloat(strings.TrimSpace(bounds[1]), 64)
+			checkError("Invalid -between: ", err)
+			r = validation.NumberBetween(min, max)
+		case *formula != "":
+			r = validation.Formula(*formula)
+		default:
+			r = validation.Checkbox()
+		}
+		r.Strict, r.Message = *strict, *message
+	}
+
+	ctx := commandContext()
+	checkError("Unable to set data validation: ", validation.Set(ctx, newSheetsService(ctx), fs.Arg(0), *rng, r))
+	if r == nil {
+		fmt.Printf("Cleared data validation of %s\n", *rng)
+	} else {
+		fmt.Printf("Set %s validation on %s\n", r.Condition, *rng)
+	}
+}
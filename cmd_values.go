@@ -0,0 +1,400 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"golang.org/x/net/context"
+
+	"github.com/prantoran/GoogleSheets_GO/export"
+	"github.com/prantoran/GoogleSheets_GO/rowfilter"
+	"github.com/prantoran/GoogleSheets_GO/sheetcache"
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// rangeFlags registers the -spreadsheet and -range flags shared by the
+// value commands.
+func rangeFlags(fs *flag.FlagSet) (spreadsheetID, rng *string) {
+	return fs.String("spreadsheet", "", "spreadsheet ID"), fs.String("range", "", "A1 range, e.g. \"Sheet1!A2:F\"")
+}
+
+// requireRange exits with usage if the range flags are missing.
+func requireRange(fs *flag.FlagSet, usage string, spreadsheetID, rng string) {
+	if spreadsheetID == "" || rng == "" || fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: "+usage)
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+}
+
+// headerFlags registers the -skip, -columns and -where flags of get and
+// export, which take the first row after the skipped ones as the header.
+func headerFlags(fs *flag.FlagSet) (skip *int, columns, where *string) {
+	return fs.Int("skip", 0, "leading rows above the header to leave out, e.g. a title"),
+		fs.String("columns", "", "keep only these columns, by letter or header name, e.g. \"A,C,E\" or \"name,major\""),
+		fs.String("where", "", "keep only the rows matching this expression over the header names, e.g. \"major == 'CS' and year >= 3\"")
+}
+
+// readerFor returns a client reading values rendered as named by render,
+// and unformatted dates as serial numbers or formatted strings.
+func readerFor(ctx context.Context, render string, serialDates bool) *sheetsclient.Client {
+	opt, err := sheetsclient.ParseValueRender(render)
+	checkError("Invalid -render: ", err)
+	c := newClient(ctx)
+	c.ValueRenderOption = opt
+	if !serialDates {
+		c.DateTimeRenderOption = "FORMATTED_STRING"
+	}
+	return c
+}
+
+// selectRows applies -skip, -columns and -where to rows.
+func selectRows(rows [][]interface{}, skip int, columns, where string) [][]interface{} {
+	rows, err := rowfilter.Apply(sheetsclient.SkipRows(rows, skip), columnList(columns), where)
+	checkError("Unable to filter rows: ", err)
+	return rows
+}
+
+// columnList splits the value of -columns.
+func columnList(columns string) []string {
+	if columns == "" {
+		return nil
+	}
+	return strings.Split(columns, ",")
+}
+
+// stringList is a flag that may be repeated.
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ", ") }
+func (l *stringList) Set(s string) error { *l = append(*l, s); return nil }
+
+func runGet(args []string) {
+	fs := flag.NewFlagSet("get", flag.ExitOnError)
+	spreadsheetID := fs.String("spreadsheet", "", "spreadsheet ID")
+	var ranges stringList
+	fs.Var(&ranges, "range", "A1 range, e.g. \"Sheet1!A2:F\"; repeat to read several ranges in one request")
+	cacheDir := fs.String("cache-dir", "", "reuse values cached in this directory while the spreadsheet is unchanged")
+	render := fs.String("render", "formatted", "values to read: formatted as displayed, unformatted numbers, or formula")
+	serial := fs.Bool("serial-dates", false, "with -render unformatted or formula, read dates and times as serial numbers instead of as displayed")
+	skip, columns, where := headerFlags(fs)
+	fs.Parse(args)
+	if *spreadsheetID == "" || len(ranges) == 0 || fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: get -spreadsheet ID -range RANGE [-range RANGE...] [-cache-dir DIR] [-render formatted|unformatted|formula] [-skip N] [-columns COLS] [-where EXPR]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	reader := readerFor(ctx, *render, *serial)
+	var values map[string][][]interface{}
+	var err error
+	if *cacheDir != "" {
+		c := &sheetcache.Cache{Dir: *cacheDir, Sheets: reader.Sheets, Drive: newDriveService(ctx), ValueRenderOption: reader.ValueRenderOption}
+		values, _, err = c.BatchGet(ctx, *spreadsheetID, ranges...)
+	} else {
+		values, err = reader.BatchGet(ctx, *spreadsheetID, ranges...)
+	}
+	checkError("Unable to retrieve data from sheet: ", err)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for i, rng := range ranges {
+		if len(ranges) > 1 {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "== %s ==\n", rng)
+		}
+		rows := selectRows(values[rng], *skip, *columns, *where)
+		if len(rows) == 0 {
+			fmt.Fprintln(w, "No data found.")
+		}
+		for _, row := range rows {
+			for _, v := range row {
+				fmt.Fprintf(w, "%v\t", v)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	checkError("Unable to write rows: ", w.Flush())
+}
+
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	spreadsheetID := fs.String("spreadsheet", "", "spreadsheet ID")
+	var ranges stringList
+	fs.Var(&ranges, "range", "A1 range or tab to export; xlsx and sqlite take several, and every tab without any")
+	format := fs.String("format", "csv", "output format: csv, tsv, json, markdown, html, xlsx, parquet or sqlite")
+	out := fs.String("out", "", "file to write (default stdout); the database for sqlite")
+	header := fs.Bool("header", false, "json: write objects keyed by the header row instead of row arrays; html: write the header row as <th>")
+	pretty := fs.Bool("pretty", false, "json: indent the output")
+	style := fs.Bool("style", false, "html: add a minimal stylesheet")
+	schema := fs.String("schema", "", "parquet, sqlite: column types overriding the inferred ones, e.g. \"Zip=string,Qty=int\"; types are string, float, int, bool, date and timestamp")
+	inline := fs.Bool("inline-style", false, "html: with -style, use inline styles, which survive email clients")
+	concurrency := fs.Int("concurrency", 4, "xlsx, sqlite: tabs read in parallel")
+	cacheDir := fs.String("cache-dir", "", "csv, tsv, json, markdown, html: reuse values cached in this directory while the spreadsheet is unchanged")
+	chunk := fs.Int("chunk", 0, "csv, tsv: stream the range this many rows per request instead of reading it at once")
+	render := fs.String("render", "formatted", "csv, tsv, json, markdown, html: values to read: formatted as displayed, unformatted numbers, or formula")
+	serial := fs.Bool("serial-dates", false, "with -render unformatted or formula, read dates and times as serial numbers instead of as displayed")
+	skip, columns, where := headerFlags(fs)
+	fs.Parse(args)
+	// xlsx and sqlite export several tables, and they and parquet need
+	// typed values.
+	multi := *format == "xlsx" || *format == "sqlite"
+	typed := multi || *format == "parquet"
+	if *spreadsheetID == "" || fs.NArg() > 0 || (!multi && len(ranges) != 1) || (*format == "sqlite" && *out == "") {
+		fmt.Fprintln(os.Stderr, "usage: export -spreadsheet ID -range RANGE [-format csv|tsv|json|markdown|html|xlsx|parquet|sqlite] [-out FILE] [flags]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	reader := readerFor(ctx, *render, *serial)
+	streamed := *chunk > 0 && (*format == "csv" || *format == "tsv")
+	var rows [][]interface{}
+	var tables []*export.Table
+	var err error
+	if typed {
+		// Load reads bare names as tabs; look up named ranges first.
+		for i, rng := range ranges {
+			ranges[i], err = reader.ResolveRange(ctx, *spreadsheetID, rng)
+			checkError("Unable to resolve range: ", err)
+		}
+		if multi {
+			tables, err = export.LoadConcurrent(ctx, reader.Sheets, *spreadsheetID, *concurrency, ranges...)
+		} else {
+			tables, err = export.Load(ctx, reader.Sheets, *spreadsheetID, ranges...)
+		}
+		if !multi && len(tables) == 1 {
+			rows = tables[0].Rows
+		}
+	} else if *cacheDir != "" && !streamed {
+		c := &sheetcache.Cache{Dir: *cacheDir, Sheets: reader.Sheets, Drive: newDriveService(ctx), ValueRenderOption: reader.ValueRenderOption}
+		rows, err = c.ReadRange(ctx, *spreadsheetID, ranges[0])
+	} else if !streamed {
+		rows, err = reader.ReadRange(ctx, *spreadsheetID, ranges[0])
+	}
+	checkError("Unable to retrieve data from sheet: ", err)
+	rows = selectRows(rows, *skip, *columns, *where)
+	for _, t := range tables {
+		t.Rows = selectRows(t.Rows, *skip, *columns, *where)
+	}
+
+	if *format == "sqlite" {
+		types, err := export.ParseSchema(*schema)
+		checkError("Unable to export: ", err)
+		db, err := sql.Open("sqlite3", *out)
+		checkError("Unable to open database: ", err)
+		defer db.Close()
+		checkError("Unable to export: ", export.SQLite(ctx, db, tables, types))
+		return
+	}
+	w := io.Writer(os.Stdout)
+	if *out != "" && *out != "-" {
+		f, err := os.Create(*out)
+		checkError("Unable to create output file: ", err)
+		defer func() { checkError("Unable to write output file: ", f.Close()) }()
+		w = f
+	}
+	if streamed {
+		comma := ','
+		if *format == "tsv" {
+			comma = '\t'
+		}
+		checkError("Unable to export: ", streamCSV(ctx, reader, w, *spreadsheetID, ranges[0], *chunk, comma, *skip, *columns, *where))
+		return
+	}
+	switch *format {
+	case "csv":
+		err = export.CSV(w, rows, ',')
+	case "tsv":
+		err = export.CSV(w, rows, '\t')
+	case "json":
+		err = export.JSON(w, rows, export.JSONOptions{Header: *header, Pretty: *pretty})
+	case "markdown", "md":
+		err = export.Markdown(w, rows)
+	case "html":
+		err = export.HTML(w, rows, export.HTMLOptions{Header: *header, Style: *style, Inline: *inline})
+	case "xlsx":
+		err = export.XLSX(w, tables)
+	case "parquet":
+		var types map[string]string
+		if types, err = export.ParseSchema(*schema); err == nil {
+			err = export.Parquet(w, rows, types)
+		}
+	default:
+		err = fmt.Errorf("unknown format %q", *format)
+	}
+	checkError("Unable to export: ", err)
+}
+
+// streamCSV writes rng as CSV, reading and writing chunk rows at a time.
+// The first skip rows are left out, and with columns or where the next
+// one is taken as the header.
+func streamCSV(ctx context.Context, c *sheetsclient.Client, w io.Writer, spreadsheetID, rng string, chunk int, comma rune, skip int, columns, where string) error {
+	it := c.Rows(ctx, spreadsheetID, rng, chunk)
+	batch := make([][]interface{}, 0, chunk)
+	var f *rowfilter.Filter
+	for n := 1; it.Next(); n++ {
+		row := it.Row()
+		switch {
+		case n <= skip:
+			continue
+		case f == nil && (columns != "" || where != ""):
+			var err error
+			if f, err = rowfilter.New(row, columnList(columns), where); err != nil {
+				return err
+			}
+			row = f.Header()
+		case f != nil && !f.Match(row):
+			continue
+		case f != nil:
+			row = f.Project(row)
+		}
+		if batch = append(batch, row); len(batch) == chunk {
+			if err := export.CSV(w, batch, comma); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	return export.CSV(w, batch, comma)
+}
+
+// readInput reads the CSV rows written by update and append from path, or
+// from stdin if path is empty or "-".
+func readInput(path string) [][]interface{} {
+	r := io.Reader(os.Stdin)
+	if path != "" && path != "-" {
+		f, err := os.Open(path)
+		checkError("Unable to open input file: ", err)
+		defer f.Close()
+		r = f
+	}
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	checkError("Unable to read CSV input: ", err)
+	values := make([][]interface{}, len(records))
+	for i, rec := range records {
+		values[i] = make([]interface{}, len(rec))
+		for j, v := range rec {
+			values[i][j] = v
+		}
+	}
+	return values
+}
+
+func runUpdate(args []string) {
+	fs := flag.NewFlagSet("update", flag.ExitOnError)
+	spreadsheetID, rng := rangeFlags(fs)
+	in := fs.String("in", "", "CSV file with the values (default stdin)")
+	raw := fs.Bool("raw", false, "store values as given instead of parsing numbers, dates and formulas")
+	autofit := fs.Bool("autofit", false, "fit the width of the written columns to their content")
+	fs.Parse(args)
+	requireRange(fs, "update -spreadsheet ID -range RANGE [-in FILE] [-raw] [-autofit]", *spreadsheetID, *rng)
+
+	ctx := commandContext()
+	c := newClient(ctx)
+	if *raw {
+		c.ValueInputOption = "RAW"
+	}
+	values := readInput(*in)
+	n, err := c.WriteRange(ctx, *spreadsheetID, *rng, values)
+	checkError("Unable to update range: ", err)
+	fmt.Printf("Updated %d cells\n", n)
+	if *autofit {
+		written, err := sheetsclient.Extent(*rng, values)
+		checkError("Unable to resize columns: ", err)
+		checkError("Unable to resize columns: ", c.AutoResizeColumns(ctx, *spreadsheetID, written))
+	}
+}
+
+func runAppend(args []string) {
+	fs := flag.NewFlagSet("append", flag.ExitOnError)
+	spreadsheetID, rng := rangeFlags(fs)
+	in := fs.String("in", "", "CSV file with the rows (default stdin)")
+	raw := fs.Bool("raw", false, "store values as given instead of parsing numbers, dates and formulas")
+	insert := fs.Bool("insert-rows", false, "insert new rows instead of writing over the empty cells below the table")
+	autofit := fs.Bool("autofit", false, "fit the width of the written columns to their content")
+	fs.Parse(args)
+	if *spreadsheetID == "" || *rng == "" {
+		fmt.Fprintln(os.Stderr, "usage: append -spreadsheet ID -range RANGE [-in FILE] [flags] [VALUE...]")
+		fmt.Fprintln(os.Stderr, "\nAppends the CSV rows read from -in, or the VALUEs as one row.")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	var rows [][]interface{}
+	if fs.NArg() > 0 {
+		row := make([]interface{}, fs.NArg())
+		for i, v := range fs.Args() {
+			row[i] = v
+		}
+		rows = [][]interface{}{row}
+	} else {
+		rows = readInput(*in)
+	}
+	opts := &sheetsclient.AppendOptions{}
+	if *raw {
+		opts.ValueInputOption = "RAW"
+	}
+	if *insert {
+		opts.InsertDataOption = "INSERT_ROWS"
+	}
+	ctx := commandContext()
+	c := newClient(ctx)
+	u, err := c.Append(ctx, *spreadsheetID, *rng, rows, opts)
+	checkError("Unable to append rows: ", err)
+	fmt.Printf("Appended %d rows to %s\n", u.UpdatedRows, u.UpdatedRange)
+	if *autofit {
+		checkError("Unable to resize columns: ", c.AutoResizeColumns(ctx, *spreadsheetID, u.UpdatedRange))
+	}
+}
+
+func runClear(args []string) {
+	fs := flag.NewFlagSet("clear", flag.ExitOnError)
+	spreadsheetID := fs.String("spreadsheet", "", "spreadsheet ID")
+	var ranges stringList
+	fs.Var(&ranges, "range", "A1 range to clear, e.g. \"Sheet1!A2:F\"; repeat to clear several ranges in one request")
+	confirm := fs.Bool("confirm", false, "clear the ranges; without it, only report what would be cleared")
+	fs.Parse(args)
+	if *spreadsheetID == "" || len(ranges) == 0 || fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: clear -spreadsheet ID -range RANGE [-range RANGE...] -confirm")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	c := newClient(ctx)
+	if !*confirm {
+		values, err := c.BatchGet(ctx, *spreadsheetID, ranges...)
+		checkError("Unable to read ranges: ", err)
+		for _, rng := range ranges {
+			cells := 0
+			for _, row := range values[rng] {
+				for _, v := range row {
+					if fmt.Sprint(v) != "" {
+						cells++
+					}
+				}
+			}
+			fmt.Printf("Would clear %s: %d rows, %d non-empty cells\n", rng, len(values[rng]), cells)
+		}
+		fmt.Fprintln(os.Stderr, "Nothing cleared; rerun with -confirm.")
+		os.Exit(1)
+	}
+	cleared, err := c.Clear(ctx, *spreadsheetID, ranges...)
+	checkError("Unable to clear ranges: ", err)
+	for _, rng := range cleared {
+		fmt.Printf("Cleared %s\n", rng)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/prantoran/GoogleSheets_GO/publish"
+	"github.com/prantoran/GoogleSheets_GO/watch"
+)
+
+func runWatch(args []string) {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	spreadsheetID := fs.String("spreadsheet", "", "spreadsheet ID, which may also be given as the argument")
+	rng := fs.String("range", "", "A1 range with a header row, e.g. \"Orders\" or \"Orders!A:F\"")
+	key := fs.String("key", "", "header column identifying rows (default compare rows by position)")
+	interval := fs.Duration("interval", 30*time.Second, "poll interval")
+	cursor := fs.String("cursor", "", "file keeping the last snapshot, so changes made while stopped are reported")
+	asJSON := fs.Bool("json", false, "print one JSON event per line")
+	publishURL := fs.String("publish", "", "publish changes to kafka://brokers/topic or nats://host/subject; polls are retried until the broker acknowledges them")
+	push := addPushFlags(fs)
+	fs.Parse(args)
+	// Accept the ID before the flags, as in "watch ID -range Orders".
+	if fs.NArg() > 0 && *spreadsheetID == "" {
+		*spreadsheetID = fs.Arg(0)
+		fs.Parse(fs.Args()[1:])
+	}
+	if *spreadsheetID == "" || *rng == "" || fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: watch SPREADSHEET_ID -range RANGE [-key COLUMN] [-interval D] [-json] [-publish URL] [-push-address URL [-listen ADDR]]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	w := &watch.Watcher{
+		Sheets:        newSheetsService(ctx),
+		SpreadsheetID: *spreadsheetID,
+		Range:         *rng,
+		KeyColumn:     *key,
+		Interval:      *interval,
+	}
+	if *cursor != "" {
+		w.Cursor = watch.FileCursor(*cursor)
+	}
+	var pub publish.Publisher
+	if *publishURL != "" {
+		var err error
+		pub, err = publish.Open(*publishURL)
+		checkError("Unable to open publisher: ", err)
+		w.OnBatch(func(ctx context.Context, events []watch.Event) error {
+			return pub.Publish(ctx, publish.FromWatch(*spreadsheetID, *rng, events))
+		})
+	}
+	enc := json.NewEncoder(os.Stdout)
+	w.OnEvent(func(e watch.Event) {
+		if *asJSON {
+			checkError("Unable to write event: ", enc.Encode(watchEvent(e)))
+			return
+		}
+		fmt.Println(formatEvent(e))
+	})
+	w.OnError(func(err error) { log.Print(err) })
+	stop := push.start(ctx, w)
+	log.Printf("Watching %s every %s", *rng, *interval)
+	err := w.Run(ctx)
+	stop()
+	if pub != nil {
+		pub.Close()
+	}
+	log.Fatal(err)
+}
+
+// pushFlags are the flags that make a command poll on Drive change
+// notifications instead of only every -interval.
+type pushFlags struct {
+	address, listen *string
+}
+
+func addPushFlags(fs *flag.FlagSet) *pushFlags {
+	return &pushFlags{
+		address: fs.String("push-address", "", "public HTTPS URL of -listen that Drive sends change notifications to; the range is then read on every change and -interval is a fallback"),
+		listen:  fs.String("listen", ":8080", "address serving Drive change notifications with -push-address"),
+	}
+}
+
+// start serves Drive notifications on -listen and keeps a notification
+// channel open on the watched spreadsheet, so that w polls on every
+// change. The returned function stops the channel. Without -push-address
+// it does nothing.
+func (f *pushFlags) start(ctx context.Context, w *watch.Watcher) (stop func()) {
+	if *f.address == "" {
+		return func() {}
+	}
+	token := make([]byte, 16)
+	_, err := rand.Read(token)
+	checkError("Unable to generate channel token: ", err)
+	p := &watch.PushChannel{
+		Drive:   newDriveService(ctx),
+		FileID:  w.SpreadsheetID,
+		Address: *f.address,
+		Token:   hex.EncodeToString(token),
+	}
+	w.Trigger = p.Notifications()
+	lis, err := net.Listen("tcp", *f.listen)
+	checkError("Unable to listen: ", err)
+	go func() { checkError("Notification server failed: ", http.Serve(lis, p)) }()
+	log.Printf("Serving Drive notifications on %s for %s", lis.Addr(), *f.address)
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := p.Run(ctx); ctx.Err() == nil {
+			log.Printf("Push notifications stopped, polling every %s: %v", w.Interval, err)
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// jsonEvent is the -json form of a watch.Event.
+type jsonEvent struct {
+	Time string            `json:"time"`
+	Type string            `json:"type"`
+	Key  string            `json:"key"`
+	Row  int               `json:"row"`
+	Old  map[string]string `json:"old,omitempty"`
+	New  map[string]string `json:"new,omitempty"`
+}
+
+func watchEvent(e watch.Event) *jsonEvent {
+	je := &jsonEvent{Time: time.Now().UTC().Format(time.RFC3339), Type: e.Type.String(), Key: e.Key}
+	if e.Old != nil {
+		je.Row, je.Old = e.Old.Number, e.Old.Fields()
+	}
+	if e.New != nil {
+		je.Row, je.New = e.New.Number, e.New.Fields()
+	}
+	return je
+}
+
+// formatEvent prints added rows with a leading "+" and deleted ones with
+// a "-", whole, and for changed rows, marked "~", only the changed cells,
+// e.g. `~ row 4 [A-02]: qty "2" -> "5"`.
+func formatEvent(e watch.Event) string {
+	switch e.Type {
+	case watch.RowAdded:
+		return fmt.Sprintf("+ row %d [%s]: %s", e.New.Number, e.Key, strings.Join(e.New.Values, ", "))
+	case watch.RowDeleted:
+		return fmt.Sprintf("- row %d [%s]: %s", e.Old.Number, e.Key, strings.Join(e.Old.Values, ", "))
+	}
+	var changes []string
+	old := e.Old.Fields()
+	for _, h := range e.New.Header() {
+		if v := e.New.Get(h); v != old[h] {
+			changes = append(changes, fmt.Sprintf("%s %q -> %q", h, old[h], v))
+		}
+	}
+	return fmt.Sprintf("~ row %d [%s]: %s", e.New.Number, e.Key, strings.Join(changes, ", "))
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prantoran/GoogleSheets_GO/watch"
+	"github.com/prantoran/GoogleSheets_GO/webhook"
+)
+
+func runWebhooks(args []string) {
+	fs := flag.NewFlagSet("webhooks", flag.ExitOnError)
+	spreadsheetID := fs.String("spreadsheet", "", "spreadsheet ID")
+	rng := fs.String("range", "", "range to watch, header row first")
+	key := fs.String("key", "", "header of the column identifying rows")
+	urls := fs.String("urls", "", "comma separated endpoints to POST changes to")
+	secret := fs.String("secret", os.Getenv("WEBHOOK_SECRET"), "HMAC signing secret")
+	interval := fs.Duration("interval", 30*time.Second, "poll interval")
+	cursor := fs.String("cursor", "", "file keeping the last seen state across restarts")
+	push := addPushFlags(fs)
+	fs.Parse(args)
+	if *spreadsheetID == "" || *rng == "" || *urls == "" {
+		fmt.Fprintln(os.Stderr, "usage: webhooks -spreadsheet ID -range RANGE -urls URL,... [-push-address URL [-listen ADDR]] [flags]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	ctx := commandContext()
+	sender := &webhook.Sender{Drive: newDriveService(ctx)}
+	for _, u := range strings.Split(*urls, ",") {
+		sender.Endpoints = append(sender.Endpoints, webhook.Endpoint{URL: u, Secret: *secret})
+	}
+	w := &watch.Watcher{
+		Sheets:        newSheetsService(ctx),
+		SpreadsheetID: *spreadsheetID,
+		Range:         *rng,
+		KeyColumn:     *key,
+		Interval:      *interval,
+	}
+	if *cursor != "" {
+		w.Cursor = watch.FileCursor(*cursor)
+	}
+	w.OnBatch(sender.Handler(*spreadsheetID, *rng))
+	w.OnError(func(err error) { log.Print(err) })
+	stop := push.start(ctx, w)
+	log.Printf("Watching %s, delivering to %d endpoints", *rng, len(sender.Endpoints))
+	err := w.Run(ctx)
+	stop()
+	log.Fatal(err)
+}
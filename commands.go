@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// command is a subcommand of the tool, run with the arguments that follow
+// its name.
+type command struct {
+	summary string
+	run     func(args []string)
+}
+
+var commands = map[string]command{
+	"append":           {"append CSV rows after a table", runAppend},
+	"apply":            {"converge spreadsheets to a YAML spec", runApply},
+	"backup":           {"snapshot spreadsheets to a directory or GCS", runBackup},
+	"batch-update":     {"write several ranges in one atomic request", runBatchUpdate},
+	"bq-load":          {"load a range or CSV export into a BigQuery table", runBQLoad},
+	"bq-publish":       {"write a BigQuery query result into a tab", runBQPublish},
+	"chart":            {"add, list or delete charts", runChart},
+	"clear":            {"clear the values of a range", runClear},
+	"comments":         {"list, add and resolve Drive comments", runComments},
+	"conditional":      {"list, add or delete conditional format rules", runConditional},
+	"create":           {"create a spreadsheet and print its ID and URL", runCreate},
+	"daemon":           {"run commands on cron schedules", runDaemon},
+	"dedup":            {"report or delete duplicate rows", runDedup},
+	"deps":             {"analyze formula dependencies as JSON or DOT", runDeps},
+	"diff":             {"compare two ranges row by row", runDiff},
+	"dim":              {"insert or delete rows and columns", runDim},
+	"export":           {"write ranges as CSV, JSON, Markdown, HTML, XLSX, Parquet or SQLite", runExport},
+	"filter":           {"set, print or clear the basic filter of a tab", runFilter},
+	"filterviews":      {"list, add, apply or delete filter views", runFilterViews},
+	"format":           {"set fonts, colors, alignment and number formats of ranges", runFormat},
+	"forms":            {"print Google Forms responses as JSON", runForms},
+	"get":              {"print a range as aligned text", runGet},
+	"grpc":             {"serve the Sheets gRPC service", runGRPC},
+	"head":             {"print the first rows of a tab", runHead},
+	"history":          {"commit tab snapshots to a git repository", runHistory},
+	"i18n":             {"sync message catalogs with a translations tab", runI18n},
+	"import":           {"write CSV, JSON or XLSX files into a spreadsheet", runImport},
+	"importrange":      {"copy a range into another spreadsheet", runImportRange},
+	"info":             {"print spreadsheet properties and tabs", runInfo},
+	"list":             {"list and search the spreadsheets you can access", runList},
+	"mailmerge":        {"send one templated email per row", runMailMerge},
+	"mask":             {"export a range as CSV with masked columns", runMask},
+	"merge":            {"merge or unmerge the cells of a range", runMerge},
+	"metadata":         {"list, add or delete developer metadata", runMetadata},
+	"metrics-exporter": {"serve sheet values as Prometheus metrics", runMetricsExporter},
+	"named-range":      {"list, add or delete named ranges", runNamedRange},
+	"notes":            {"read, set or clear cell notes", runNotes},
+	"notify":           {"post change summaries to Slack or Google Chat", runNotify},
+	"permissions":      {"audit or enforce spreadsheet sharing", runPermissions},
+	"pgsync":           {"sync a tab with a PostgreSQL table", runPgSync},
+	"pivots":           {"export or apply pivot table definitions", runPivots},
+	"plan":             {"show how spreadsheets differ from a YAML spec", runPlan},
+	"profile":          {"manage named credential profiles", runProfile},
+	"query":            {"run SQL over ranges of a spreadsheet", runQuery},
+	"render":           {"create a spreadsheet from a template", runRender},
+	"replace":          {"find and replace text in a range, tab or spreadsheet", runReplace},
+	"restore":          {"replay a snapshot into a new or existing spreadsheet", runRestore},
+	"revisions":        {"list revisions or export one", runRevisions},
+	"sample":           {"print random rows of a tab", runSample},
+	"serve":            {"serve tabs as a JSON REST API", runServe},
+	"share":            {"grant, revoke or list access to a spreadsheet", runShare},
+	"sort":             {"sort the rows of a range by one or more columns", runSort},
+	"summarize":        {"group rows and aggregate columns", runSummarize},
+	"sync":             {"sync tabs with a local SQLite mirror", runSync},
+	"tab":              {"list, add, rename, duplicate or delete tabs", runTab},
+	"tail":             {"print the last rows of a tab", runTail},
+	"update":           {"overwrite a range with CSV values", runUpdate},
+	"upsert":           {"update rows by a key column and append the rest", runUpsert},
+	"validate":         {"check spreadsheets against a JSON schema", runValidate},
+	"validation":       {"set dropdowns and other data validation on ranges", runValidation},
+	"watch":            {"poll a range and print row changes as they happen", runWatch},
+	"webhooks":         {"POST signed change payloads to HTTP endpoints", runWebhooks},
+}
+
+// runCommand runs the named subcommand, or prints the list of commands.
+func runCommand(name string, args []string) {
+	cmd, ok := commands[name]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+	cmd.run(args)
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(os.Stderr, "usage: %s [global flags] command [flags]\n\n", filepath.Base(os.Args[0]))
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-18s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nGlobal flags:")
+	flag.PrintDefaults()
+}
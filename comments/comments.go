@@ -0,0 +1,164 @@
+// Package comments reads and writes the Drive comments of a spreadsheet,
+// so review workflows such as flagging a row for follow-up can be
+// automated.
+//
+// Drive does not let API clients anchor comments to cells of a
+// spreadsheet: comments made here show up as comments on the whole file.
+// FlagRow therefore quotes the flagged row and names it in the comment,
+// so readers know what it refers to.
+package comments
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// Comment is a comment thread.
+type Comment struct {
+	ID       string    `json:"id"`
+	Author   string    `json:"author"`
+	Content  string    `json:"content"`
+	Quoted   string    `json:"quoted,omitempty"`
+	Anchor   string    `json:"anchor,omitempty"`
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+	Resolved bool      `json:"resolved"`
+	Replies  []Reply   `json:"replies,omitempty"`
+}
+
+// Reply is a reply in a comment thread. Action is "resolve" or "reopen"
+// for replies that changed the state of the thread.
+type Reply struct {
+	ID      string    `json:"id"`
+	Author  string    `json:"author"`
+	Content string    `json:"content"`
+	Action  string    `json:"action,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+const commentFields = "id,author(displayName,emailAddress),content,quotedFileContent,anchor,createdTime,modifiedTime,resolved,deleted," +
+	"replies(id,author(displayName,emailAddress),content,action,createdTime,deleted)"
+
+// List returns the comments on a file, oldest first. Resolved threads are
+// only included if resolved is set.
+func List(ctx context.Context, srv *drive.Service, fileID string, resolved bool) ([]*Comment, error) {
+	var out []*Comment
+	call := srv.Comments.List(fileID).Fields("nextPageToken,comments(" + commentFields + ")").PageSize(100)
+	err := call.Pages(ctx, func(page *drive.CommentList) error {
+		for _, c := range page.Comments {
+			if c.Deleted || (c.Resolved && !resolved) {
+				continue
+			}
+			out = append(out, fromDrive(c))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("comments: unable to list comments: %w", err)
+	}
+	return out, nil
+}
+
+// Create adds a comment to a file. quoted, if not empty, is shown as the
+// content the comment is about.
+func Create(ctx context.Context, srv *drive.Service, fileID, content, quoted string) (*Comment, error) {
+	c := &drive.Comment{Content: content}
+	if quoted != "" {
+		c.QuotedFileContent = &drive.CommentQuotedFileContent{MimeType: "text/plain", Value: quoted}
+	}
+	created, err := srv.Comments.Create(fileID, c).Fields(commentFields).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("comments: unable to create comment: %w", err)
+	}
+	return fromDrive(created), nil
+}
+
+// AddReply adds a reply to a comment thread.
+func AddReply(ctx context.Context, srv *drive.Service, fileID, commentID, content string) error {
+	return reply(ctx, srv, fileID, commentID, &drive.Reply{Content: content})
+}
+
+// Resolve marks a comment thread as resolved, with an optional message.
+func Resolve(ctx context.Context, srv *drive.Service, fileID, commentID, message string) error {
+	return reply(ctx, srv, fileID, commentID, &drive.Reply{Content: message, Action: "resolve"})
+}
+
+// Reopen reopens a resolved comment thread.
+func Reopen(ctx context.Context, srv *drive.Service, fileID, commentID, message string) error {
+	return reply(ctx, srv, fileID, commentID, &drive.Reply{Content: message, Action: "reopen"})
+}
+
+func reply(ctx context.Context, srv *drive.Service, fileID, commentID string, r *drive.Reply) error {
+	if _, err := srv.Replies.Create(fileID, commentID, r).Fields("id").Context(ctx).Do(); err != nil {
+		return fmt.Errorf("comments: unable to reply to %s: %w", commentID, err)
+	}
+	return nil
+}
+
+// FlagRow comments on a row of a tab, quoting its content. row is the
+// 1-based row number.
+func FlagRow(ctx context.Context, srv *drive.Service, sheetsSrv *sheets.Service, spreadsheetID, tab string, row int, message string) (*Comment, error) {
+	rng := fmt.Sprintf("%s!%d:%d", sheetsclient.QuoteTab(tab), row, row)
+	resp, err := sheetsSrv.Spreadsheets.Values.Get(spreadsheetID, rng).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("comments: unable to read %s: %w", rng, err)
+	}
+	var cells []string
+	if len(resp.Values) > 0 {
+		for _, v := range resp.Values[0] {
+			cells = append(cells, fmt.Sprint(v))
+		}
+	}
+	content := fmt.Sprintf("%s (%s, row %d)", message, tab, row)
+	return Create(ctx, srv, spreadsheetID, content, strings.Join(cells, " | "))
+}
+
+func fromDrive(c *drive.Comment) *Comment {
+	out := &Comment{
+		ID:       c.Id,
+		Author:   author(c.Author),
+		Content:  c.Content,
+		Anchor:   c.Anchor,
+		Created:  parseTime(c.CreatedTime),
+		Modified: parseTime(c.ModifiedTime),
+		Resolved: c.Resolved,
+	}
+	if c.QuotedFileContent != nil {
+		out.Quoted = c.QuotedFileContent.Value
+	}
+	for _, r := range c.Replies {
+		if r.Deleted {
+			continue
+		}
+		out.Replies = append(out.Replies, Reply{
+			ID:      r.Id,
+			Author:  author(r.Author),
+			Content: r.Content,
+			Action:  r.Action,
+			Created: parseTime(r.CreatedTime),
+		})
+	}
+	return out
+}
+
+func author(u *drive.User) string {
+	switch {
+	case u == nil:
+		return ""
+	case u.EmailAddress != "":
+		return u.DisplayName + " <" + u.EmailAddress + ">"
+	}
+	return u.DisplayName
+}
+
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
@@ -0,0 +1,81 @@
+// Package daemon runs the tool's commands as scheduled jobs in one long
+// lived process, with a health endpoint and per-job status.
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Config lists the jobs to run. It is usually read from a JSON file, e.g.
+//
+//	{
+//	  "listen": ":8081",
+//	  "jobs": [
+//	    {"name": "nightly-backup", "schedule": "0 2 * * *",
+//	     "command": "backup", "args": ["-spreadsheet", "1zFj...", "-dest", "gs://backups/sheets", "-keep", "30"]},
+//	    {"name": "inventory-sync", "schedule": "*/10 * * * *", "timeout": "5m",
+//	     "command": "sync", "args": ["-spreadsheet", "1zFj...", "Inventory:SKU"]}
+//	  ]
+//	}
+//
+// Schedules use the standard five cron fields, or descriptors such as
+// "@hourly" and "@every 15m".
+type Config struct {
+	Listen string `json:"listen"`
+	Jobs   []*Job `json:"jobs"`
+}
+
+// Job is a command run on a schedule.
+type Job struct {
+	Name     string   `json:"name"`
+	Schedule string   `json:"schedule"`
+	Command  string   `json:"command"`
+	Args     []string `json:"args"`
+	// Timeout, such as "10m", stops a run that takes longer. Runs are
+	// not limited by default.
+	Timeout string `json:"timeout"`
+
+	schedule cron.Schedule
+	timeout  time.Duration
+}
+
+// LoadConfig reads and checks a configuration file.
+func LoadConfig(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{Listen: ":8081"}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("daemon: invalid config %s: %w", path, err)
+	}
+	if len(c.Jobs) == 0 {
+		return nil, fmt.Errorf("daemon: %s: no jobs configured", path)
+	}
+	names := map[string]bool{}
+	for i, j := range c.Jobs {
+		switch {
+		case j.Name == "":
+			return nil, fmt.Errorf("daemon: %s: job %d has no name", path, i)
+		case names[j.Name]:
+			return nil, fmt.Errorf("daemon: %s: duplicate job %q", path, j.Name)
+		case j.Command == "":
+			return nil, fmt.Errorf("daemon: %s: job %s has no command", path, j.Name)
+		}
+		names[j.Name] = true
+		if j.schedule, err = cron.ParseStandard(j.Schedule); err != nil {
+			return nil, fmt.Errorf("daemon: %s: job %s: invalid schedule: %w", path, j.Name, err)
+		}
+		if j.Timeout != "" {
+			if j.timeout, err = time.ParseDuration(j.Timeout); err != nil {
+				return nil, fmt.Errorf("daemon: %s: job %s: invalid timeout: %w", path, j.Name, err)
+			}
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,185 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"golang.org/x/net/context"
+)
+
+// Runner runs one job to completion, writing its output to out. The
+// command line tool runs every job as a child process of itself, so a job
+// that exits cannot take the daemon down with it.
+type Runner func(ctx context.Context, job *Job, out io.Writer) error
+
+// Status is the state of a job as reported by the status endpoint.
+type Status struct {
+	Name      string    `json:"name"`
+	Schedule  string    `json:"schedule"`
+	Running   bool      `json:"running"`
+	Runs      int       `json:"runs"`
+	Failures  int       `json:"failures"`
+	LastStart time.Time `json:"last_start,omitempty"`
+	LastEnd   time.Time `json:"last_end,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+	Next      time.Time `json:"next"`
+}
+
+// Daemon schedules the jobs of a Config. A job whose previous run is still
+// going when it is due again is skipped for that slot.
+type Daemon struct {
+	config *Config
+	run    Runner
+	log    *slog.Logger
+	cron   *cron.Cron
+	// ctx is the parent of job contexts; cancel stops running jobs.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	status map[string]*Status
+	ids    map[string]cron.EntryID
+}
+
+// New prepares a daemon; call Start to begin scheduling.
+func New(config *Config, run Runner, logger *slog.Logger) *Daemon {
+	d := &Daemon{
+		config: config,
+		run:    run,
+		log:    logger,
+		cron:   cron.New(),
+		status: map[string]*Status{},
+		ids:    map[string]cron.EntryID{},
+	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	for _, j := range config.Jobs {
+		j := j
+		d.status[j.Name] = &Status{Name: j.Name, Schedule: j.Schedule}
+		d.ids[j.Name] = d.cron.Schedule(j.schedule, cron.FuncJob(func() { d.runJob(j) }))
+	}
+	return d
+}
+
+// Start begins scheduling jobs in the background.
+func (d *Daemon) Start() {
+	d.cron.Start()
+	d.log.Info("daemon started", "jobs", len(d.config.Jobs))
+}
+
+// Stop stops scheduling, cancels the contexts of running jobs and waits
+// for them to return or ctx to be done.
+func (d *Daemon) Stop(ctx context.Context) {
+	done := d.cron.Stop()
+	d.cancel()
+	select {
+	case <-done.Done():
+	case <-ctx.Done():
+	}
+	d.log.Info("daemon stopped")
+}
+
+func (d *Daemon) runJob(j *Job) {
+	d.mu.Lock()
+	st := d.status[j.Name]
+	if st.Running {
+		d.mu.Unlock()
+		d.log.Warn("job skipped, previous run still going", "job", j.Name)
+		return
+	}
+	st.Running = true
+	st.LastStart = time.Now()
+	st.Runs++
+	d.mu.Unlock()
+
+	ctx := d.ctx
+	if j.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.timeout)
+		defer cancel()
+	}
+	logger := d.log.With("job", j.Name, "run", st.Runs)
+	logger.Info("job started", "command", j.Command)
+	out := &lineLogger{log: logger}
+	err := d.run(ctx, j, out)
+	out.Flush()
+
+	d.mu.Lock()
+	st.Running = false
+	st.LastEnd = time.Now()
+	st.LastError = ""
+	if err != nil {
+		st.Failures++
+		st.LastError = err.Error()
+	}
+	elapsed := st.LastEnd.Sub(st.LastStart)
+	d.mu.Unlock()
+
+	if err != nil {
+		logger.Error("job failed", "error", err, "duration", elapsed.String())
+	} else {
+		logger.Info("job finished", "duration", elapsed.String())
+	}
+}
+
+// Statuses returns the status of every job, sorted by name.
+func (d *Daemon) Statuses() []Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := make([]Status, 0, len(d.status))
+	for name, st := range d.status {
+		s := *st
+		s.Next = d.cron.Entry(d.ids[name]).Next
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// ServeHTTP serves /healthz, which answers 200 while the daemon runs, and
+// /status, the JSON list of job statuses.
+func (d *Daemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/healthz":
+		w.Write([]byte("ok\n"))
+	case "/status":
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(d.Statuses())
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// lineLogger logs the output of a job line by line.
+type lineLogger struct {
+	log *slog.Logger
+	buf []byte
+}
+
+func (l *lineLogger) Write(p []byte) (int, error) {
+	l.buf = append(l.buf, p...)
+	for {
+		i := bytes.IndexByte(l.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		l.log.Info("output", "line", string(l.buf[:i]))
+		l.buf = l.buf[i+1:]
+	}
+}
+
+// Flush logs a trailing line without newline.
+func (l *lineLogger) Flush() {
+	if len(l.buf) > 0 {
+		l.log.Info("output", "line", string(l.buf))
+		l.buf = nil
+	}
+}
@@ -0,0 +1,217 @@
+// Package dedup finds rows of a tab that share the same key and deletes
+// the duplicates.
+package dedup
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// Keep selects which row of a duplicate group survives.
+type Keep int
+
+const (
+	First Keep = iota
+	Last
+)
+
+// Options configure how rows are compared.
+type Options struct {
+	// Keys are the header names of the columns identifying a row.
+	Keys []string
+	// Columns are letters such as "A" or "C" of further key columns. A
+	// row is identified by all of its cells if neither Keys nor Columns
+	// are given.
+	Columns []string
+	Keep    Keep
+	// IgnoreCase compares keys case-insensitively. Surrounding spaces
+	// are always ignored.
+	IgnoreCase bool
+}
+
+// Group is a set of rows sharing a key. Rows are 1-based sheet row
+// numbers in sheet order.
+type Group struct {
+	Key    []string `json:"key"`
+	Rows   []int    `json:"rows"`
+	Kept   int      `json:"kept"`
+	Delete []int    `json:"delete"`
+}
+
+// Find groups the duplicate rows of values, whose first row is the header
+// and is row 1 of the sheet starting at column A. Rows with an empty key
+// are never duplicates.
+func Find(values [][]interface{}, opts Options) ([]*Group, error) {
+	return find(values, 0, 0, opts)
+}
+
+// find is Find for values read from the given zero based row and column.
+func find(values [][]interface{}, row0, col0 int, opts Options) ([]*Group, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	var cols []int
+	for _, k := range opts.Keys {
+		i := indexOf(values[0], k)
+		if i < 0 {
+			return nil, fmt.Errorf("dedup: no column named %q", k)
+		}
+		cols = append(cols, i)
+	}
+	for _, c := range opts.Columns {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if c == "" || strings.Trim(c, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+			return nil, fmt.Errorf("dedup: invalid column %q", c)
+		}
+		i := sheetsclient.ColumnNumber(c) - 1 - col0
+		if i < 0 {
+			return nil, fmt.Errorf("dedup: column %s is left of the range", c)
+		}
+		cols = append(cols, i)
+	}
+	if len(cols) == 0 {
+		width := 0
+		for _, row := range values {
+			if len(row) > width {
+				width = len(row)
+			}
+		}
+		for i := 0; i < width; i++ {
+			cols = append(cols, i)
+		}
+	}
+
+	groups := map[string]*Group{}
+	var order []*Group
+	for n, row := range values[1:] {
+		key := make([]string, len(cols))
+		empty := true
+		for j, c := range cols {
+			if c < len(row) {
+				key[j] = strings.TrimSpace(fmt.Sprint(row[c]))
+			}
+			if key[j] != "" {
+				empty = false
+			}
+		}
+		if empty {
+			continue
+		}
+		id := strings.Join(key, "\x00")
+		if opts.IgnoreCase {
+			id = strings.ToLower(id)
+		}
+		g, ok := groups[id]
+		if !ok {
+			g = &Group{Key: key}
+			groups[id] = g
+			order = append(order, g)
+		}
+		g.Rows = append(g.Rows, row0+n+2)
+	}
+
+	var out []*Group
+	for _, g := range order {
+		if len(g.Rows) < 2 {
+			continue
+		}
+		if opts.Keep == Last {
+			g.Kept = g.Rows[len(g.Rows)-1]
+			g.Delete = g.Rows[:len(g.Rows)-1]
+		} else {
+			g.Kept = g.Rows[0]
+			g.Delete = g.Rows[1:]
+		}
+		out = append(out, g)
+	}
+	return out, nil
+}
+
+// Dedup reads rng, a tab such as "Data", an A1 range such as "Data!C3:F"
+// or a named range, whose first row is the header, finds its duplicate
+// rows and, unless dryRun is set, deletes them in a single batch update.
+// It returns the duplicate groups.
+func Dedup(ctx context.Context, srv *sheets.Service, spreadsheetID, rng string, opts Options, dryRun bool) ([]*Group, error) {
+	tabs, err := sheetsclient.LoadTabs(ctx, srv, spreadsheetID)
+	if err != nil {
+		return nil, err
+	}
+	g, err := tabs.GridRange(rng)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := srv.Spreadsheets.Values.Get(spreadsheetID, tabs.A1(g)).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("dedup: unable to read %s: %w", rng, err)
+	}
+	groups, err := find(resp.Values, int(g.StartRowIndex), int(g.StartColumnIndex), opts)
+	if err != nil || dryRun || len(groups) == 0 {
+		return groups, err
+	}
+	var rows []int
+	for _, gr := range groups {
+		rows = append(rows, gr.Delete...)
+	}
+	if err := deleteRows(ctx, srv, spreadsheetID, g, rows); err != nil {
+		return nil, fmt.Errorf("dedup: unable to delete rows of %s: %w", rng, err)
+	}
+	return groups, nil
+}
+
+// deleteRows deletes the given 1-based rows of g. Rows of a range bounded
+// by columns are deleted only within those columns, shifting the cells
+// below up, so that cells beside the range are kept.
+func deleteRows(ctx context.Context, srv *sheets.Service, spreadsheetID string, g *sheets.GridRange, rows []int) error {
+	// Delete from the bottom up so earlier deletions do not shift the
+	// rows still to be deleted, merging adjacent rows into one request.
+	sorted := append([]int(nil), rows...)
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+	var reqs []*sheets.Request
+	for i := 0; i < len(sorted); {
+		end := sorted[i]
+		start := end
+		for i++; i < len(sorted) && sorted[i] >= start-1; i++ {
+			start = sorted[i]
+		}
+		if g.StartColumnIndex > 0 || g.EndColumnIndex > 0 {
+			reqs = append(reqs, &sheets.Request{DeleteRange: &sheets.DeleteRangeRequest{
+				Range: &sheets.GridRange{
+					SheetId:          g.SheetId,
+					StartRowIndex:    int64(start - 1),
+					EndRowIndex:      int64(end),
+					StartColumnIndex: g.StartColumnIndex,
+					EndColumnIndex:   g.EndColumnIndex,
+					ForceSendFields:  []string{"SheetId", "StartRowIndex", "StartColumnIndex"},
+				},
+				ShiftDimension: "ROWS",
+			}})
+			continue
+		}
+		reqs = append(reqs, &sheets.Request{DeleteDimension: &sheets.DeleteDimensionRequest{
+			Range: &sheets.DimensionRange{
+				SheetId:         g.SheetId,
+				Dimension:       "ROWS",
+				StartIndex:      int64(start - 1),
+				EndIndex:        int64(end),
+				ForceSendFields: []string{"SheetId", "StartIndex"},
+			},
+		}})
+	}
+	_, err := srv.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{Requests: reqs}).Context(ctx).Do()
+	return err
+}
+
+func indexOf(header []interface{}, name string) int {
+	for i, h := range header {
+		if strings.TrimSpace(fmt.Sprint(h)) == name {
+			return i
+		}
+	}
+	return -1
+}
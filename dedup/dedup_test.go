@@ -0,0 +1,137 @@
+package dedup
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetstest"
+)
+
+func TestDedup(t *testing.T) {
+	ctx := context.Background()
+	rows := [][]interface{}{
+		{"title"},
+		{},
+		{"", "Email", "Name", "Team"},
+		{"", "a@x", "Ann", "red"},
+		{"", "b@x", "Bob", "red"},
+		{"", " A@x ", "Ann", "blue"},
+		{"", "a@x", "Ann", "red"},
+		{"", "b@x", "Bob", "red"},
+		{"", "", "", "red"},
+	}
+	tests := []struct {
+		rng     string
+		opts    Options
+		deleted []int
+	}{
+		{"Data!B3:D", Options{Keys: []string{"Email"}}, []int{7, 8}},
+		{"Data!B3:D", Options{Keys: []string{"Email"}, IgnoreCase: true}, []int{6, 7, 8}},
+		{"Data!B3:D", Options{Keys: []string{"Email"}, IgnoreCase: true, Keep: Last}, []int{4, 6, 5}},
+		{"Data!B3:D", Options{Columns: []string{"D"}}, []int{5, 7, 8, 9}},
+		{"Data!B3:D", Options{Keys: []string{"Name"}, Columns: []string{"d"}}, []int{7, 8}},
+		{"Data!B3:D", Options{}, []int{7, 8}},
+		{"People", Options{}, []int{7, 8}},
+	}
+	for _, tt := range tests {
+		// Each run deletes rows of its own copy.
+		data := make([][]interface{}, len(rows))
+		for i, r := range rows {
+			data[i] = append([]interface{}{}, r...)
+		}
+		srv := sheetstest.NewServer()
+		srv.Seed(&sheetstest.Spreadsheet{
+			ID:          "s",
+			Tabs:        []*sheetstest.Tab{{Title: "Data", Rows: data}},
+			NamedRanges: map[string]string{"People": "Data!B3:D"},
+		})
+		svc, err := srv.SheetsService(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		groups, err := Dedup(ctx, svc, "s", tt.rng, tt.opts, false)
+		if err != nil {
+			t.Errorf("Dedup(%s, %+v): %v", tt.rng, tt.opts, err)
+			srv.Close()
+			continue
+		}
+		var deleted []int
+		for _, g := range groups {
+			deleted = append(deleted, g.Delete...)
+		}
+		if !reflect.DeepEqual(deleted, tt.deleted) {
+			t.Errorf("Dedup(%s, %+v) deleted rows %v, want %v", tt.rng, tt.opts, deleted, tt.deleted)
+		}
+		if got, want := len(srv.Values("s", "Data")), len(rows)-len(tt.deleted); got != want {
+			t.Errorf("Dedup(%s, %+v) left %d rows, want %d", tt.rng, tt.opts, got, want)
+		}
+		srv.Close()
+	}
+}
+
+func TestDedupKeepsCellsBesideRange(t *testing.T) {
+	ctx := context.Background()
+	srv := sheetstest.NewServer()
+	defer srv.Close()
+	srv.Seed(&sheetstest.Spreadsheet{ID: "s", Tabs: []*sheetstest.Tab{{Title: "Data", Rows: [][]interface{}{
+		{"id", "Email", "Name", "note"},
+		{"1", "a@x", "Ann", "n1"},
+		{"2", "a@x", "Ann", "n2"},
+		{"3", "b@x", "Bob", "n3"},
+		{"4", "b@x", "Bob"},
+		{"5", "c@x", "Cid", "n5"},
+	}}}})
+	svc, err := srv.SheetsService(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Dedup(ctx, svc, "s", "Data!B1:C", Options{Keys: []string{"Email"}}, false); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]interface{}{
+		{"id", "Email", "Name", "note"},
+		{"1", "a@x", "Ann", "n1"},
+		{"2", "b@x", "Bob", "n2"},
+		{"3", "c@x", "Cid", "n3"},
+		{"4"},
+		{"5", "", "", "n5"},
+	}
+	if got := srv.Values("s", "Data"); !reflect.DeepEqual(got, want) {
+		t.Errorf("after Dedup, Data =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestDedupErrors(t *testing.T) {
+	ctx := context.Background()
+	srv := sheetstest.NewServer()
+	defer srv.Close()
+	srv.Seed(&sheetstest.Spreadsheet{ID: "s", Tabs: []*sheetstest.Tab{{Title: "Data", Rows: [][]interface{}{
+		{"", "Email"}, {"", "a"}, {"", "a"},
+	}}}})
+	svc, err := srv.SheetsService(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		rng  string
+		opts Options
+	}{
+		{"Data!B1:B", Options{Keys: []string{"Name"}}},
+		{"Data!B1:B", Options{Columns: []string{"A"}}},
+		{"Data!B1:B", Options{Columns: []string{"B2"}}},
+		{"Missing", Options{}},
+	} {
+		if _, err := Dedup(ctx, svc, "s", tt.rng, tt.opts, false); err == nil {
+			t.Errorf("Dedup(%s, %+v) succeeded", tt.rng, tt.opts)
+		}
+	}
+	groups, err := Dedup(ctx, svc, "s", "Data", Options{Keys: []string{"Email"}}, true)
+	if err != nil || len(groups) != 1 || groups[0].Kept != 2 {
+		t.Errorf("dry run = %v, %v", groups, err)
+	}
+	if srv.Count("spreadsheets.batchUpdate") != 0 {
+		t.Error("a dry run deleted rows")
+	}
+}
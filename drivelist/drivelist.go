@@ -0,0 +1,94 @@
+// Package drivelist finds the spreadsheets a user can access through the
+// Drive API, so that IDs need not be copied from the browser.
+package drivelist
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/drive/v3"
+)
+
+const mimeType = "application/vnd.google-apps.spreadsheet"
+
+// errLimit stops paging once Query.Limit results are found.
+var errLimit = errors.New("drivelist: limit reached")
+
+// Query selects spreadsheets. Zero fields match everything.
+type Query struct {
+	// Name matches spreadsheets whose name contains it, ignoring case.
+	Name string
+	// Owner matches spreadsheets owned by this email address; "me" is the
+	// authorized user.
+	Owner string
+	// ModifiedAfter matches spreadsheets last changed after this time.
+	ModifiedAfter time.Time
+	// Trashed includes spreadsheets in the trash.
+	Trashed bool
+	// Limit caps the number of results; 0 means no limit.
+	Limit int
+}
+
+// String returns the Drive search expression for q.
+func (q *Query) String() string {
+	terms := []string{"mimeType = '" + mimeType + "'"}
+	if !q.Trashed {
+		terms = append(terms, "trashed = false")
+	}
+	if q.Name != "" {
+		terms = append(terms, "name contains "+quote(q.Name))
+	}
+	if q.Owner != "" {
+		terms = append(terms, quote(q.Owner)+" in owners")
+	}
+	if !q.ModifiedAfter.IsZero() {
+		terms = append(terms, "modifiedTime > "+quote(q.ModifiedAfter.UTC().Format(time.RFC3339)))
+	}
+	return strings.Join(terms, " and ")
+}
+
+func quote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	return "'" + strings.Replace(s, "'", `\'`, -1) + "'"
+}
+
+// File is a spreadsheet found in Drive.
+type File struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Owners   []string  `json:"owners"`
+	Modified time.Time `json:"modified"`
+	URL      string    `json:"url"`
+}
+
+// List returns the spreadsheets matching q, most recently modified first.
+// Spreadsheets in shared drives are included.
+func List(ctx context.Context, srv *drive.Service, q *Query) ([]*File, error) {
+	var out []*File
+	call := srv.Files.List().Q(q.String()).OrderBy("modifiedTime desc").
+		SupportsAllDrives(true).IncludeItemsFromAllDrives(true).Corpora("allDrives").
+		Fields("nextPageToken,files(id,name,owners(emailAddress),modifiedTime,webViewLink)").PageSize(100)
+	err := call.Pages(ctx, func(page *drive.FileList) error {
+		for _, f := range page.Files {
+			file := &File{ID: f.Id, Name: f.Name, URL: f.WebViewLink}
+			for _, o := range f.Owners {
+				file.Owners = append(file.Owners, o.EmailAddress)
+			}
+			if t, err := time.Parse(time.RFC3339, f.ModifiedTime); err == nil {
+				file.Modified = t
+			}
+			out = append(out, file)
+			if q.Limit > 0 && len(out) == q.Limit {
+				return errLimit
+			}
+		}
+		return nil
+	})
+	if err != nil && err != errLimit {
+		return nil, fmt.Errorf("drivelist: unable to list spreadsheets: %w", err)
+	}
+	return out, nil
+}
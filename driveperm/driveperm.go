@@ -0,0 +1,281 @@
+// Package driveperm audits and manages who can access spreadsheets through
+// Drive permissions, including permissions inherited from shared drives
+// and folders and link sharing.
+package driveperm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/drive/v3"
+)
+
+// Permission is one grant on a file.
+type Permission struct {
+	ID string `json:"id"`
+	// Type is user, group, domain or anyone.
+	Type string `json:"type"`
+	// Role is owner, organizer, fileOrganizer, writer, commenter or
+	// reader.
+	Role   string `json:"role"`
+	Email  string `json:"email,omitempty"`
+	Domain string `json:"domain,omitempty"`
+	// Discoverable is false for link sharing: anyone or everyone in the
+	// domain who has the link, rather than anyone who searches.
+	Discoverable bool   `json:"discoverable,omitempty"`
+	Expiration   string `json:"expiration,omitempty"`
+	// InheritedFrom is the ID of the folder or shared drive the grant
+	// comes from; such grants cannot be changed on the file itself.
+	InheritedFrom string `json:"inherited_from,omitempty"`
+}
+
+// Inherited reports whether p comes from a parent folder or shared drive.
+func (p *Permission) Inherited() bool { return p.InheritedFrom != "" }
+
+// Who describes the grantee, e.g. "alice@example.com", "domain example.com"
+// or "anyone with the link".
+func (p *Permission) Who() string {
+	switch p.Type {
+	case "anyone":
+		if p.Discoverable {
+			return "anyone"
+		}
+		return "anyone with the link"
+	case "domain":
+		if p.Discoverable {
+			return "domain " + p.Domain
+		}
+		return "domain " + p.Domain + " with the link"
+	}
+	return p.Email
+}
+
+func (p *Permission) String() string {
+	s := p.Role + ": " + p.Who()
+	if p.Inherited() {
+		s += " (inherited from " + p.InheritedFrom + ")"
+	}
+	return s
+}
+
+const permissionFields = "nextPageToken,permissions(id,type,role,emailAddress,domain,allowFileDiscovery,expirationTime,permissionDetails)"
+
+// List returns every permission on a file.
+func List(ctx context.Context, srv *drive.Service, fileID string) ([]*Permission, error) {
+	var out []*Permission
+	call := srv.Permissions.List(fileID).SupportsAllDrives(true).Fields(permissionFields).PageSize(100)
+	err := call.Pages(ctx, func(page *drive.PermissionList) error {
+		for _, p := range page.Permissions {
+			perm := &Permission{
+				ID:           p.Id,
+				Type:         p.Type,
+				Role:         p.Role,
+				Email:        p.EmailAddress,
+				Domain:       p.Domain,
+				Discoverable: p.AllowFileDiscovery,
+				Expiration:   p.ExpirationTime,
+			}
+			// Permission details exist for files in shared drives and
+			// tell direct grants from inherited ones.
+			for _, d := range p.PermissionDetails {
+				if d.Inherited {
+					perm.InheritedFrom = d.InheritedFrom
+				} else {
+					perm.InheritedFrom = ""
+					break
+				}
+			}
+			out = append(out, perm)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("driveperm: unable to list permissions of %s: %w", fileID, err)
+	}
+	return out, nil
+}
+
+// Report is the audit of one file.
+type Report struct {
+	FileID      string        `json:"file_id"`
+	Name        string        `json:"name"`
+	Permissions []*Permission `json:"permissions"`
+	// Public holds the grants to anyone, with or without the link.
+	Public []*Permission `json:"public,omitempty"`
+	// DomainWide holds the grants to a whole domain.
+	DomainWide []*Permission `json:"domain_wide,omitempty"`
+	// External holds user and group grants outside the allowed domains.
+	External []*Permission `json:"external,omitempty"`
+}
+
+// Exposed reports whether the file is shared beyond named people in the
+// allowed domains.
+func (r *Report) Exposed() bool {
+	return len(r.Public)+len(r.DomainWide)+len(r.External) > 0
+}
+
+// Audit lists the permissions of each file and flags public, domain-wide
+// and, if allowedDomains is not empty, external grants.
+func Audit(ctx context.Context, srv *drive.Service, fileIDs []string, allowedDomains []string) ([]*Report, error) {
+	var out []*Report
+	for _, id := range fileIDs {
+		f, err := srv.Files.Get(id).SupportsAllDrives(true).Fields("name").Context(ctx).Do()
+		if err != nil {
+			return nil, fmt.Errorf("driveperm: unable to get %s: %w", id, err)
+		}
+		perms, err := List(ctx, srv, id)
+		if err != nil {
+			return nil, err
+		}
+		r := &Report{FileID: id, Name: f.Name, Permissions: perms}
+		for _, p := range perms {
+			switch {
+			case p.Type == "anyone":
+				r.Public = append(r.Public, p)
+			case p.Type == "domain":
+				r.DomainWide = append(r.DomainWide, p)
+			case len(allowedDomains) > 0 && !inDomains(p.Email, allowedDomains):
+				r.External = append(r.External, p)
+			}
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func inDomains(email string, domains []string) bool {
+	i := strings.LastIndex(email, "@")
+	if i < 0 {
+		return false
+	}
+	for _, d := range domains {
+		if strings.EqualFold(email[i+1:], d) {
+			return true
+		}
+	}
+	return false
+}
+
+// Grant is a desired permission.
+type Grant struct {
+	Type   string `json:"type"`
+	Role   string `json:"role"`
+	Email  string `json:"email,omitempty"`
+	Domain string `json:"domain,omitempty"`
+	// Discoverable applies to anyone and domain grants.
+	Discoverable bool `json:"discoverable,omitempty"`
+}
+
+func (g Grant) key() string {
+	return g.Type + ":" + strings.ToLower(g.Email+g.Domain)
+}
+
+func (p *Permission) key() string {
+	return p.Type + ":" + strings.ToLower(p.Email+p.Domain)
+}
+
+// Policy is the permission set a file should have.
+type Policy struct {
+	Grants []Grant `json:"grants"`
+	// Exclusive removes direct grants the policy does not list. Owners
+	// and inherited grants are never removed.
+	Exclusive bool `json:"exclusive"`
+	// Notify sends the usual sharing emails to new grantees.
+	Notify bool `json:"notify"`
+}
+
+// Action is one change made, or planned, by Enforce.
+type Action struct {
+	FileID string
+	// Op is "add", "update" or "remove".
+	Op    string
+	Who   string
+	Role  string
+	Error error
+}
+
+func (a Action) String() string {
+	s := fmt.Sprintf("%s: %s %s", a.FileID, a.Op, a.Who)
+	if a.Role != "" {
+		s += " as " + a.Role
+	}
+	if a.Error != nil {
+		s += ": " + a.Error.Error()
+	}
+	return s
+}
+
+// plan compares the current permissions with the policy.
+func plan(perms []*Permission, policy *Policy) (adds []Grant, updates map[string]Grant, removes []*Permission) {
+	current := map[string]*Permission{}
+	for _, p := range perms {
+		if !p.Inherited() {
+			current[p.key()] = p
+		}
+	}
+	updates = map[string]Grant{}
+	wanted := map[string]bool{}
+	for _, g := range policy.Grants {
+		wanted[g.key()] = true
+		p, ok := current[g.key()]
+		switch {
+		case !ok:
+			adds = append(adds, g)
+		case p.Role != g.Role && p.Role != "owner":
+			updates[p.ID] = g
+		}
+	}
+	if policy.Exclusive {
+		for k, p := range current {
+			if !wanted[k] && p.Role != "owner" {
+				removes = append(removes, p)
+			}
+		}
+		sort.Slice(removes, func(i, j int) bool { return removes[i].key() < removes[j].key() })
+	}
+	return adds, updates, removes
+}
+
+// Enforce converges the permissions of every file to policy. With dryRun
+// set it only returns the actions it would take. Failures on one file are
+// recorded in the actions and do not stop the others.
+func Enforce(ctx context.Context, srv *drive.Service, fileIDs []string, policy *Policy, dryRun bool) ([]Action, error) {
+	var actions []Action
+	for _, id := range fileIDs {
+		perms, err := List(ctx, srv, id)
+		if err != nil {
+			return actions, err
+		}
+		byID := map[string]*Permission{}
+		for _, p := range perms {
+			byID[p.ID] = p
+		}
+		adds, updates, removes := plan(perms, policy)
+
+		for _, g := range adds {
+			a := Action{FileID: id, Op: "add", Who: (&Permission{Type: g.Type, Email: g.Email, Domain: g.Domain, Discoverable: g.Discoverable}).Who(), Role: g.Role}
+			if !dryRun {
+				p := &drive.Permission{Type: g.Type, Role: g.Role, EmailAddress: g.Email, Domain: g.Domain, AllowFileDiscovery: g.Discoverable}
+				_, a.Error = srv.Permissions.Create(id, p).SupportsAllDrives(true).SendNotificationEmail(policy.Notify && (g.Type == "user" || g.Type == "group")).Context(ctx).Do()
+			}
+			actions = append(actions, a)
+		}
+		for pid, g := range updates {
+			a := Action{FileID: id, Op: "update", Who: byID[pid].Who(), Role: g.Role}
+			if !dryRun {
+				_, a.Error = srv.Permissions.Update(id, pid, &drive.Permission{Role: g.Role}).SupportsAllDrives(true).Context(ctx).Do()
+			}
+			actions = append(actions, a)
+		}
+		for _, p := range removes {
+			a := Action{FileID: id, Op: "remove", Who: p.Who()}
+			if !dryRun {
+				a.Error = srv.Permissions.Delete(id, p.ID).SupportsAllDrives(true).Context(ctx).Do()
+			}
+			actions = append(actions, a)
+		}
+	}
+	return actions, nil
+}
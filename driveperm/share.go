@@ -0,0 +1,78 @@
+package driveperm
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/drive/v3"
+)
+
+// Share grants g on a file. A direct grant to the same grantee is changed
+// to the new role rather than duplicated; owners are left alone. Notify
+// sends the usual sharing email to users and groups.
+func Share(ctx context.Context, srv *drive.Service, fileID string, g Grant, notify bool) (*Action, error) {
+	perms, err := List(ctx, srv, fileID)
+	if err != nil {
+		return nil, err
+	}
+	a := &Action{FileID: fileID, Op: "add", Who: (&Permission{Type: g.Type, Email: g.Email, Domain: g.Domain, Discoverable: g.Discoverable}).Who(), Role: g.Role}
+	for _, p := range perms {
+		if p.Inherited() || p.key() != g.key() {
+			continue
+		}
+		if p.Role == "owner" {
+			return nil, fmt.Errorf("driveperm: %s owns %s", a.Who, fileID)
+		}
+		a.Op = "update"
+		if p.Role != g.Role {
+			_, err = srv.Permissions.Update(fileID, p.ID, &drive.Permission{Role: g.Role}).SupportsAllDrives(true).Context(ctx).Do()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("driveperm: unable to update %s on %s: %w", a.Who, fileID, err)
+		}
+		return a, nil
+	}
+	p := &drive.Permission{Type: g.Type, Role: g.Role, EmailAddress: g.Email, Domain: g.Domain, AllowFileDiscovery: g.Discoverable}
+	_, err = srv.Permissions.Create(fileID, p).SupportsAllDrives(true).
+		SendNotificationEmail(notify && (g.Type == "user" || g.Type == "group")).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("driveperm: unable to share %s with %s: %w", fileID, a.Who, err)
+	}
+	return a, nil
+}
+
+// Revoke removes the direct grants to who, an email address or domain, or
+// "anyone" for link sharing. Inherited grants must be removed from the
+// folder or shared drive they come from and are reported as an error.
+func Revoke(ctx context.Context, srv *drive.Service, fileID, who string) ([]Action, error) {
+	perms, err := List(ctx, srv, fileID)
+	if err != nil {
+		return nil, err
+	}
+	var actions []Action
+	inherited := false
+	for _, p := range perms {
+		if !strings.EqualFold(p.Email, who) && !strings.EqualFold(p.Domain, who) && !(who == "anyone" && p.Type == "anyone") {
+			continue
+		}
+		if p.Inherited() {
+			inherited = true
+			continue
+		}
+		if p.Role == "owner" {
+			return actions, fmt.Errorf("driveperm: %s owns %s", who, fileID)
+		}
+		if err := srv.Permissions.Delete(fileID, p.ID).SupportsAllDrives(true).Context(ctx).Do(); err != nil {
+			return actions, fmt.Errorf("driveperm: unable to revoke %s on %s: %w", p.Who(), fileID, err)
+		}
+		actions = append(actions, Action{FileID: fileID, Op: "remove", Who: p.Who()})
+	}
+	if inherited {
+		return actions, fmt.Errorf("driveperm: %s keeps access to %s through a parent folder or shared drive", who, fileID)
+	}
+	if len(actions) == 0 {
+		return nil, fmt.Errorf("driveperm: %s has no access to %s", who, fileID)
+	}
+	return actions, nil
+}
@@ -0,0 +1,76 @@
+package export
+
+import (
+	"fmt"
+	"sync"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+)
+
+// LoadConcurrent is like Load but reads each range, or each tab if none
+// are given, with its own request, up to concurrency at a time. It is much
+// faster than Load for spreadsheets with many large tabs; requests are
+// still paced by the rate limiter of srv's HTTP client, if any. The first
+// error cancels the remaining requests.
+func LoadConcurrent(ctx context.Context, srv *sheets.Service, spreadsheetID string, concurrency int, ranges ...string) ([]*Table, error) {
+	if len(ranges) == 0 {
+		resp, err := srv.Spreadsheets.Get(spreadsheetID).Fields("sheets.properties(title,sheetType)").Context(ctx).Do()
+		if err != nil {
+			return nil, fmt.Errorf("export: unable to get tabs of %s: %w", spreadsheetID, err)
+		}
+		for _, s := range resp.Sheets {
+			if s.Properties.SheetType == "" || s.Properties.SheetType == "GRID" {
+				ranges = append(ranges, s.Properties.Title)
+			}
+		}
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make([][]*Table, len(ranges))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for w := 0; w < concurrency && w < len(ranges); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				tables, err := Load(ctx, srv, spreadsheetID, ranges[i])
+				if err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					continue
+				}
+				results[i] = tables
+			}
+		}()
+	}
+feed:
+	for i := range ranges {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var tables []*Table
+	for _, r := range results {
+		tables = append(tables, r...)
+	}
+	return tables, nil
+}
@@ -0,0 +1,118 @@
+// Package export writes sheet values in file formats other programs
+// read. Rows are the values returned by the Sheets API, header first when
+// a format uses one.
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// CSV writes rows as comma separated values, or with another separator
+// such as '\t'.
+func CSV(w io.Writer, rows [][]interface{}, comma rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	for _, row := range rows {
+		if err := cw.Write(texts(row)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// JSONOptions tune JSON.
+type JSONOptions struct {
+	// Header writes an array of objects keyed by the first row instead of
+	// an array of row arrays. Keys keep the column order; blank headers
+	// are replaced by the column letter.
+	Header bool
+	// Pretty indents the output.
+	Pretty bool
+}
+
+// JSON writes rows as a JSON array of arrays, or of objects.
+func JSON(w io.Writer, rows [][]interface{}, opts JSONOptions) error {
+	var items []json.RawMessage
+	if opts.Header && len(rows) > 0 {
+		keys := Header(rows[0])
+		for _, row := range rows[1:] {
+			var buf bytes.Buffer
+			buf.WriteByte('{')
+			for i, k := range keys {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				var v interface{} = ""
+				if i < len(row) {
+					v = row[i]
+				}
+				kb, _ := json.Marshal(k)
+				vb, err := json.Marshal(v)
+				if err != nil {
+					return err
+				}
+				buf.Write(kb)
+				buf.WriteByte(':')
+				buf.Write(vb)
+			}
+			buf.WriteByte('}')
+			items = append(items, buf.Bytes())
+		}
+	} else {
+		for _, row := range rows {
+			b, err := json.Marshal(row)
+			if err != nil {
+				return err
+			}
+			items = append(items, b)
+		}
+	}
+	if items == nil {
+		items = []json.RawMessage{}
+	}
+	enc := json.NewEncoder(w)
+	if opts.Pretty {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(items)
+}
+
+// Header returns the column names of a header row, naming blank columns
+// by their letter and making duplicates unique with a numeric suffix.
+func Header(row []interface{}) []string {
+	names := make([]string, len(row))
+	seen := map[string]int{}
+	for i, v := range row {
+		name := fmt.Sprint(v)
+		if name == "" {
+			name = sheetsclient.ColumnLetters(i + 1)
+		}
+		if n := seen[name]; n > 0 {
+			seen[name]++
+			name += "_" + strconv.Itoa(n+1)
+		} else {
+			seen[name] = 1
+		}
+		names[i] = name
+	}
+	return names
+}
+
+func texts(row []interface{}) []string {
+	out := make([]string, len(row))
+	for i, v := range row {
+		out[i] = fmt.Sprint(v)
+	}
+	return out
+}
+
+// columnName converts a zero based column index to its letter, e.g. 27 to
+// "AB".
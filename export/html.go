@@ -0,0 +1,83 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// HTMLOptions tune HTML.
+type HTMLOptions struct {
+	// Header writes the first row as <th> cells in a <thead>.
+	Header bool
+	// Style adds a small stylesheet before the table, scoped to its class,
+	// with borders, padding and striped rows; inline styles are used
+	// instead when Inline is also set, since email clients drop <style>.
+	Style  bool
+	Inline bool
+	// Class is the class of the table; defaults to "gsheet".
+	Class string
+}
+
+const htmlStyle = `<style>
+table.%[1]s { border-collapse: collapse; font-family: sans-serif; font-size: 14px; }
+table.%[1]s th, table.%[1]s td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+table.%[1]s th { background: #f3f3f3; font-weight: bold; }
+table.%[1]s tbody tr:nth-child(even) { background: #fafafa; }
+table.%[1]s td.num { text-align: right; }
+</style>
+`
+
+// HTML writes rows as a standalone <table> element that can be embedded
+// in a page or an email. Cell text is escaped and line breaks become <br>.
+func HTML(w io.Writer, rows [][]interface{}, opts HTMLOptions) error {
+	class := opts.Class
+	if class == "" {
+		class = "gsheet"
+	}
+	inline := opts.Style && opts.Inline
+	attr := func(style string) string {
+		if !inline {
+			return ""
+		}
+		return ` style="` + style + `"`
+	}
+	cell := func(v interface{}) string {
+		s := html.EscapeString(fmt.Sprint(v))
+		return strings.Replace(strings.Replace(s, "\r\n", "<br>", -1), "\n", "<br>", -1)
+	}
+
+	bw := bufio.NewWriter(w)
+	if opts.Style && !opts.Inline {
+		fmt.Fprintf(bw, htmlStyle, class)
+	}
+	fmt.Fprintf(bw, "<table class=\"%s\"%s>\n", html.EscapeString(class), attr("border-collapse:collapse;font-family:sans-serif;font-size:14px"))
+	if opts.Header && len(rows) > 0 {
+		bw.WriteString("<thead>\n<tr>")
+		for _, v := range rows[0] {
+			fmt.Fprintf(bw, "<th%s>%s</th>", attr("border:1px solid #ccc;padding:4px 8px;text-align:left;background:#f3f3f3"), cell(v))
+		}
+		bw.WriteString("</tr>\n</thead>\n")
+		rows = rows[1:]
+	}
+	bw.WriteString("<tbody>\n")
+	for _, row := range rows {
+		bw.WriteString("<tr>")
+		for _, v := range row {
+			s := fmt.Sprint(v)
+			align, num := "left", ""
+			if s != "" && isNumber(s) {
+				align = "right"
+				if !inline {
+					num = ` class="num"`
+				}
+			}
+			fmt.Fprintf(bw, "<td%s%s>%s</td>", num, attr("border:1px solid #ccc;padding:4px 8px;text-align:"+align), cell(v))
+		}
+		bw.WriteString("</tr>\n")
+	}
+	bw.WriteString("</tbody>\n</table>\n")
+	return bw.Flush()
+}
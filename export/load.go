@@ -0,0 +1,93 @@
+package export
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// Table is a tab, or a range of one, with typed values: string, float64,
+// bool or time.Time. Empty cells are nil.
+type Table struct {
+	Name string
+	Rows [][]interface{}
+}
+
+// Load reads ranges with their types, one table per range. A range may be
+// a tab name or an A1 range; without ranges, every tab is read. Numbers
+// formatted as dates or times are returned as time.Time in UTC.
+func Load(ctx context.Context, srv *sheets.Service, spreadsheetID string, ranges ...string) ([]*Table, error) {
+	quoted := make([]string, len(ranges))
+	for i, r := range ranges {
+		quoted[i] = r
+		if !strings.Contains(r, "!") && !strings.HasPrefix(r, "'") {
+			// A bare tab name; quote it so it is not read as a cell.
+			quoted[i] = sheetsclient.QuoteTab(r)
+		}
+	}
+	resp, err := srv.Spreadsheets.Get(spreadsheetID).Ranges(quoted...).IncludeGridData(true).
+		Fields("sheets(properties(title),data(rowData(values(effectiveValue,effectiveFormat/numberFormat/type))))").
+		Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("export: unable to read %s: %w", spreadsheetID, err)
+	}
+	var tables []*Table
+	for _, s := range resp.Sheets {
+		for _, data := range s.Data {
+			t := &Table{Name: s.Properties.Title}
+			for _, rd := range data.RowData {
+				row := make([]interface{}, len(rd.Values))
+				for i, c := range rd.Values {
+					row[i] = cellValue(c)
+				}
+				t.Rows = append(t.Rows, trimRow(row))
+			}
+			for len(t.Rows) > 0 && len(t.Rows[len(t.Rows)-1]) == 0 {
+				t.Rows = t.Rows[:len(t.Rows)-1]
+			}
+			tables = append(tables, t)
+		}
+	}
+	return tables, nil
+}
+
+func trimRow(row []interface{}) []interface{} {
+	for len(row) > 0 && row[len(row)-1] == nil {
+		row = row[:len(row)-1]
+	}
+	return row
+}
+
+// epoch is day zero of spreadsheet serial numbers.
+var epoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+func cellValue(c *sheets.CellData) interface{} {
+	v := c.EffectiveValue
+	switch {
+	case v == nil:
+		return nil
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return *v.BoolValue
+	case v.ErrorValue != nil:
+		return v.ErrorValue.Type
+	case v.NumberValue != nil:
+		n := *v.NumberValue
+		if f := c.EffectiveFormat; f != nil && f.NumberFormat != nil {
+			switch f.NumberFormat.Type {
+			case "DATE", "DATE_TIME", "TIME":
+				ms := math.Round(n * 24 * 60 * 60 * 1000)
+				return epoch.Add(time.Duration(ms) * time.Millisecond)
+			}
+		}
+		return n
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Markdown writes rows as a GitHub flavored Markdown table, padding cells
+// so the columns line up in plain text too. The first row is the header;
+// columns holding only numbers are right aligned. Pipes are escaped and
+// line breaks become <br>.
+func Markdown(w io.Writer, rows [][]interface{}) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	cols := 0
+	for _, row := range rows {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+	cells := make([][]string, len(rows))
+	widths := make([]int, cols)
+	numeric := make([]bool, cols)
+	for i := range numeric {
+		numeric[i] = len(rows) > 1
+	}
+	for r, row := range rows {
+		cells[r] = make([]string, cols)
+		for c := 0; c < cols; c++ {
+			s := ""
+			if c < len(row) {
+				s = fmt.Sprint(row[c])
+			}
+			if r > 0 && s != "" && !isNumber(s) {
+				numeric[c] = false
+			}
+			s = strings.Replace(s, "|", `\|`, -1)
+			s = strings.Replace(strings.Replace(s, "\r\n", "<br>", -1), "\n", "<br>", -1)
+			cells[r][c] = s
+			if n := utf8.RuneCountInString(s); n > widths[c] {
+				widths[c] = n
+			}
+		}
+	}
+	for c := range widths {
+		if widths[c] < 3 {
+			widths[c] = 3
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	line := func(row []string) {
+		bw.WriteString("|")
+		for c, s := range row {
+			pad := strings.Repeat(" ", widths[c]-utf8.RuneCountInString(s))
+			if numeric[c] {
+				bw.WriteString(" " + pad + s + " |")
+			} else {
+				bw.WriteString(" " + s + pad + " |")
+			}
+		}
+		bw.WriteString("\n")
+	}
+	line(cells[0])
+	sep := make([]string, cols)
+	for c := range sep {
+		sep[c] = strings.Repeat("-", widths[c])
+		if numeric[c] {
+			sep[c] = strings.Repeat("-", widths[c]-1) + ":"
+		}
+	}
+	line(sep)
+	for _, row := range cells[1:] {
+		line(row)
+	}
+	return bw.Flush()
+}
+
+// isNumber reports whether s reads as a number once currency signs,
+// thousands separators and a percent sign are removed.
+func isNumber(s string) bool {
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(strings.TrimLeft(s, "$€£¥"), "%")
+	_, err := strconv.ParseFloat(strings.Replace(s, ",", "", -1), 64)
+	return err == nil
+}
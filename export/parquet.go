@@ -0,0 +1,256 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/apache/arrow/go/v15/arrow/array"
+	"github.com/apache/arrow/go/v15/arrow/memory"
+	"github.com/apache/arrow/go/v15/parquet"
+	"github.com/apache/arrow/go/v15/parquet/compress"
+	"github.com/apache/arrow/go/v15/parquet/pqarrow"
+)
+
+// Column types of Parquet and SQLite exports.
+const (
+	String    = "string"
+	Float     = "float"
+	Int       = "int"
+	Bool      = "bool"
+	Date      = "date"
+	Timestamp = "timestamp"
+)
+
+// ParseSchema parses type overrides written as "Name=type,Other=type".
+func ParseSchema(s string) (map[string]string, error) {
+	schema := map[string]string{}
+	if strings.TrimSpace(s) == "" {
+		return schema, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		i := strings.LastIndex(part, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("export: invalid schema entry %q, want column=type", part)
+		}
+		name, typ := strings.TrimSpace(part[:i]), strings.ToLower(strings.TrimSpace(part[i+1:]))
+		switch typ {
+		case String, Float, Int, Bool, Date, Timestamp:
+		default:
+			return nil, fmt.Errorf("export: unknown type %q for %s", typ, name)
+		}
+		schema[name] = typ
+	}
+	return schema, nil
+}
+
+// InferTypes returns the type of each column of a typed table, header
+// first: the type shared by all its non-empty values, with times that all
+// fall on midnight being dates, or string when values are mixed. schema
+// overrides the types of the columns it names.
+func InferTypes(rows [][]interface{}, schema map[string]string) ([]string, []string, error) {
+	if len(rows) == 0 {
+		return nil, nil, fmt.Errorf("export: no header row")
+	}
+	header := Header(rows[0])
+	types := make([]string, len(header))
+	for c := range header {
+		typ := ""
+		for _, row := range rows[1:] {
+			if c >= len(row) || row[c] == nil || row[c] == "" {
+				continue
+			}
+			t := String
+			switch v := row[c].(type) {
+			case float64:
+				t = Float
+			case bool:
+				t = Bool
+			case time.Time:
+				t = Timestamp
+				if v.Equal(v.Truncate(24 * time.Hour)) {
+					t = Date
+				}
+			}
+			switch {
+			case typ == "":
+				typ = t
+			case typ == t:
+			case (typ == Date && t == Timestamp) || (typ == Timestamp && t == Date):
+				typ = Timestamp
+			default:
+				typ = String
+			}
+		}
+		if typ == "" {
+			typ = String
+		}
+		types[c] = typ
+	}
+	for name, typ := range schema {
+		found := false
+		for c, h := range header {
+			if h == name {
+				types[c], found = typ, true
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("export: schema names unknown column %q", name)
+		}
+	}
+	return header, types, nil
+}
+
+// convert turns a typed value into one of type typ: string, float64, int64,
+// bool or time.Time. Empty values return nil.
+func convert(v interface{}, typ string) (interface{}, error) {
+	if v == nil || v == "" {
+		return nil, nil
+	}
+	switch typ {
+	case String:
+		if t, ok := v.(time.Time); ok {
+			if t.Equal(t.Truncate(24 * time.Hour)) {
+				return t.Format("2006-01-02"), nil
+			}
+			return t.Format("2006-01-02 15:04:05"), nil
+		}
+		if f, ok := v.(float64); ok {
+			return strconv.FormatFloat(f, 'f', -1, 64), nil
+		}
+		return fmt.Sprint(v), nil
+	case Float, Int:
+		var f float64
+		switch v := v.(type) {
+		case float64:
+			f = v
+		case bool:
+			if v {
+				f = 1
+			}
+		case string:
+			var err error
+			if f, err = strconv.ParseFloat(strings.Replace(strings.TrimSpace(v), ",", "", -1), 64); err != nil {
+				return nil, fmt.Errorf("%q is not a number", v)
+			}
+		default:
+			return nil, fmt.Errorf("%v is not a number", v)
+		}
+		if typ == Int {
+			if f != math.Trunc(f) {
+				return nil, fmt.Errorf("%v is not an integer", f)
+			}
+			return int64(f), nil
+		}
+		return f, nil
+	case Bool:
+		switch v := v.(type) {
+		case bool:
+			return v, nil
+		case string:
+			switch strings.ToLower(strings.TrimSpace(v)) {
+			case "true", "yes", "1":
+				return true, nil
+			case "false", "no", "0":
+				return false, nil
+			}
+		}
+		return nil, fmt.Errorf("%v is not a boolean", v)
+	case Date, Timestamp:
+		switch v := v.(type) {
+		case time.Time:
+			return v, nil
+		case float64:
+			ms := math.Round(v * 24 * 60 * 60 * 1000)
+			return epoch.Add(time.Duration(ms) * time.Millisecond), nil
+		case string:
+			for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02", "1/2/2006 15:04:05", "1/2/2006"} {
+				if t, err := time.Parse(layout, strings.TrimSpace(v)); err == nil {
+					return t, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("%v is not a date", v)
+	}
+	return nil, fmt.Errorf("unknown type %q", typ)
+}
+
+// Parquet writes a typed table, header first, as a Snappy compressed
+// Parquet file with one nullable column per header cell. Column types are
+// inferred as by InferTypes, and schema forces the types of the columns
+// it names.
+func Parquet(w io.Writer, rows [][]interface{}, schema map[string]string) error {
+	header, types, err := InferTypes(rows, schema)
+	if err != nil {
+		return err
+	}
+	fields := make([]arrow.Field, len(header))
+	for i, name := range header {
+		var dt arrow.DataType
+		switch types[i] {
+		case String:
+			dt = arrow.BinaryTypes.String
+		case Float:
+			dt = arrow.PrimitiveTypes.Float64
+		case Int:
+			dt = arrow.PrimitiveTypes.Int64
+		case Bool:
+			dt = arrow.FixedWidthTypes.Boolean
+		case Date:
+			dt = arrow.FixedWidthTypes.Date32
+		case Timestamp:
+			dt = &arrow.TimestampType{Unit: arrow.Millisecond, TimeZone: "UTC"}
+		}
+		fields[i] = arrow.Field{Name: name, Type: dt, Nullable: true}
+	}
+	as := arrow.NewSchema(fields, nil)
+
+	b := array.NewRecordBuilder(memory.DefaultAllocator, as)
+	defer b.Release()
+	for r, row := range rows[1:] {
+		for c := range header {
+			var v interface{}
+			if c < len(row) {
+				if v, err = convert(row[c], types[c]); err != nil {
+					return fmt.Errorf("export: row %d: %s: %w", r+2, header[c], err)
+				}
+			}
+			fb := b.Field(c)
+			if v == nil {
+				fb.AppendNull()
+				continue
+			}
+			switch fb := fb.(type) {
+			case *array.StringBuilder:
+				fb.Append(v.(string))
+			case *array.Float64Builder:
+				fb.Append(v.(float64))
+			case *array.Int64Builder:
+				fb.Append(v.(int64))
+			case *array.BooleanBuilder:
+				fb.Append(v.(bool))
+			case *array.Date32Builder:
+				fb.Append(arrow.Date32FromTime(v.(time.Time)))
+			case *array.TimestampBuilder:
+				fb.Append(arrow.Timestamp(v.(time.Time).UnixNano() / int64(time.Millisecond)))
+			}
+		}
+	}
+	rec := b.NewRecord()
+	defer rec.Release()
+
+	props := parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Snappy))
+	fw, err := pqarrow.NewFileWriter(as, w, props, pqarrow.DefaultWriterProps())
+	if err != nil {
+		return fmt.Errorf("export: unable to create Parquet writer: %w", err)
+	}
+	if err := fw.Write(rec); err != nil {
+		fw.Close()
+		return fmt.Errorf("export: unable to write Parquet file: %w", err)
+	}
+	return fw.Close()
+}
@@ -0,0 +1,152 @@
+package export
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/net/context"
+)
+
+// SQLite stores every table in db, which must use an SQLite driver, as a
+// table of the same name, replacing any existing one. Table and column
+// names are sanitized to lower case identifiers, e.g. "Unit Price ($)"
+// becomes unit_price. Columns are typed as inferred by InferTypes, with
+// schema overriding, and stored as TEXT, REAL or INTEGER; booleans are 0
+// or 1 and dates and timestamps ISO 8601 text, which SQLite's date
+// functions understand. Everything is written in one transaction.
+func SQLite(ctx context.Context, db *sql.DB, tables []*Table, schema map[string]string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("export: unable to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	tableNames := map[string]bool{}
+	for _, t := range tables {
+		if err := sqliteTable(ctx, tx, t, uniqueIdent(t.Name, "sheet", tableNames), schema); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("export: unable to commit: %w", err)
+	}
+	return nil
+}
+
+func sqliteTable(ctx context.Context, tx *sql.Tx, t *Table, name string, schema map[string]string) error {
+	if len(t.Rows) == 0 {
+		return nil
+	}
+	// Overrides naming columns this tab lacks are meant for other tabs.
+	header := Header(t.Rows[0])
+	own := map[string]string{}
+	for _, h := range header {
+		if typ, ok := schema[h]; ok {
+			own[h] = typ
+		}
+	}
+	header, types, err := InferTypes(t.Rows, own)
+	if err != nil {
+		return err
+	}
+	cols := make([]string, len(header))
+	seen := map[string]bool{}
+	defs := make([]string, len(header))
+	for i, h := range header {
+		cols[i] = quoteIdent(uniqueIdent(h, "col"+strconv.Itoa(i+1), seen))
+		sqlType := "TEXT"
+		switch types[i] {
+		case Float:
+			sqlType = "REAL"
+		case Int, Bool:
+			sqlType = "INTEGER"
+		}
+		defs[i] = cols[i] + " " + sqlType
+	}
+	stmts := []string{
+		"DROP TABLE IF EXISTS " + quoteIdent(name),
+		fmt.Sprintf("CREATE TABLE %s (%s)", quoteIdent(name), strings.Join(defs, ", ")),
+	}
+	for _, s := range stmts {
+		if _, err := tx.ExecContext(ctx, s); err != nil {
+			return fmt.Errorf("export: unable to create table %s: %w", name, err)
+		}
+	}
+
+	marks := strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ")
+	insert, err := tx.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoteIdent(name), strings.Join(cols, ", "), marks))
+	if err != nil {
+		return fmt.Errorf("export: unable to prepare insert into %s: %w", name, err)
+	}
+	defer insert.Close()
+	for r, row := range t.Rows[1:] {
+		if len(row) == 0 {
+			continue
+		}
+		args := make([]interface{}, len(cols))
+		for c := range cols {
+			if c >= len(row) {
+				continue
+			}
+			v, err := convert(row[c], types[c])
+			if err != nil {
+				return fmt.Errorf("export: %s: row %d: %s: %w", t.Name, r+2, header[c], err)
+			}
+			switch x := v.(type) {
+			case time.Time:
+				if types[c] == Date {
+					v = x.Format("2006-01-02")
+				} else {
+					v = x.Format("2006-01-02 15:04:05")
+				}
+			case bool:
+				if x {
+					v = 1
+				} else {
+					v = 0
+				}
+			}
+			args[c] = v
+		}
+		if _, err := insert.ExecContext(ctx, args...); err != nil {
+			return fmt.Errorf("export: unable to insert into %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// uniqueIdent sanitizes s into a lower case identifier of letters, digits
+// and underscores not yet in seen, and adds it there.
+func uniqueIdent(s, fallback string, seen map[string]bool) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+			underscore = false
+		} else if !underscore && b.Len() > 0 {
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+	name := strings.TrimSuffix(b.String(), "_")
+	if name == "" {
+		name = fallback
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	unique := name
+	for n := 2; seen[unique]; n++ {
+		unique = name + "_" + strconv.Itoa(n)
+	}
+	seen[unique] = true
+	return unique
+}
+
+func quoteIdent(s string) string {
+	return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+}
@@ -0,0 +1,179 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// XLSX writes tables as the sheets of an Excel workbook. Strings, numbers,
+// booleans and times keep their type; times are formatted as dates when
+// they fall on midnight, as times of day on day zero, and as date and time
+// otherwise. Sheet names are shortened and stripped of the characters
+// Excel does not allow.
+func XLSX(w io.Writer, tables []*Table) error {
+	z := zip.NewWriter(w)
+	add := func(name string, write func(b *bytes.Buffer)) error {
+		var b bytes.Buffer
+		b.WriteString(xml.Header)
+		write(&b)
+		fw, err := z.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = fw.Write(b.Bytes())
+		return err
+	}
+
+	err := add("[Content_Types].xml", func(b *bytes.Buffer) {
+		b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+		b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+		b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+		b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+		b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+		for i := range tables {
+			fmt.Fprintf(b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+		}
+		b.WriteString(`</Types>`)
+	})
+	if err != nil {
+		return err
+	}
+	err = add("_rels/.rels", func(b *bytes.Buffer) {
+		b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+		b.WriteString(`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>`)
+		b.WriteString(`</Relationships>`)
+	})
+	if err != nil {
+		return err
+	}
+	err = add("xl/workbook.xml", func(b *bytes.Buffer) {
+		b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+		for i, name := range sheetNames(tables) {
+			fmt.Fprintf(b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escape(name), i+1, i+1)
+		}
+		b.WriteString(`</sheets></workbook>`)
+	})
+	if err != nil {
+		return err
+	}
+	err = add("xl/_rels/workbook.xml.rels", func(b *bytes.Buffer) {
+		b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+		for i := range tables {
+			fmt.Fprintf(b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+		}
+		fmt.Fprintf(b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(tables)+1)
+		b.WriteString(`</Relationships>`)
+	})
+	if err != nil {
+		return err
+	}
+	err = add("xl/styles.xml", func(b *bytes.Buffer) {
+		// Cell styles 1 to 3 use the built in date, date time and time
+		// formats.
+		b.WriteString(`<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+		b.WriteString(`<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>`)
+		b.WriteString(`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>`)
+		b.WriteString(`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>`)
+		b.WriteString(`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>`)
+		b.WriteString(`<cellXfs count="4"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>`)
+		for _, id := range []int{14, 22, 21} {
+			fmt.Fprintf(b, `<xf numFmtId="%d" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>`, id)
+		}
+		b.WriteString(`</cellXfs></styleSheet>`)
+	})
+	if err != nil {
+		return err
+	}
+	for i, t := range tables {
+		if err := add(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), func(b *bytes.Buffer) { worksheet(b, t) }); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+func worksheet(b *bytes.Buffer, t *Table) {
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for r, row := range t.Rows {
+		fmt.Fprintf(b, `<row r="%d">`, r+1)
+		for c, v := range row {
+			ref := sheetsclient.ColumnLetters(c+1) + strconv.Itoa(r+1)
+			switch v := v.(type) {
+			case nil:
+			case string:
+				if v != "" {
+					fmt.Fprintf(b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, escape(v))
+				}
+			case bool:
+				n := 0
+				if v {
+					n = 1
+				}
+				fmt.Fprintf(b, `<c r="%s" t="b"><v>%d</v></c>`, ref, n)
+			case float64:
+				fmt.Fprintf(b, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v, 'g', -1, 64))
+			case time.Time:
+				serial := float64(v.Sub(epoch)) / float64(24*time.Hour)
+				style := 2
+				switch {
+				case v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 && v.Nanosecond() == 0:
+					style = 1
+				case serial < 1:
+					style = 3
+				}
+				fmt.Fprintf(b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(serial, 'g', -1, 64))
+			default:
+				fmt.Fprintf(b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, escape(fmt.Sprint(v)))
+			}
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+}
+
+// sheetNames makes the table names valid and unique Excel sheet names.
+func sheetNames(tables []*Table) []string {
+	names := make([]string, len(tables))
+	seen := map[string]bool{}
+	for i, t := range tables {
+		base := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`[]:*?/\`, r) {
+				return '_'
+			}
+			return r
+		}, t.Name)
+		if base == "" {
+			base = "Sheet" + strconv.Itoa(i+1)
+		}
+		name := truncate(base, 31)
+		for n := 2; seen[strings.ToLower(name)]; n++ {
+			suffix := " (" + strconv.Itoa(n) + ")"
+			name = truncate(base, 31-len(suffix)) + suffix
+		}
+		seen[strings.ToLower(name)] = true
+		names[i] = name
+	}
+	return names
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) > n {
+		r = r[:n]
+	}
+	return string(r)
+}
+
+func escape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
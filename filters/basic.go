@@ -0,0 +1,103 @@
+package filters
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+)
+
+// BasicFilter is the filter of a tab shown to every viewer. A tab has at
+// most one.
+type BasicFilter struct {
+	Range    string      `json:"range"`
+	Criteria []Criterion `json:"criteria,omitempty"`
+	Sort     []SortKey   `json:"sort,omitempty"`
+}
+
+// SetBasicFilter filters rng, e.g. "Data!A1:F" or just "Data", replacing
+// the tab's basic filter. Without criteria the filter only adds the
+// filter buttons to the header.
+func SetBasicFilter(ctx context.Context, srv *sheets.Service, spreadsheetID, rng string, criteria ...Criterion) error {
+	return ApplyBasicFilter(ctx, srv, spreadsheetID, &BasicFilter{Range: rng, Criteria: criteria})
+}
+
+// ApplyBasicFilter sets f as the basic filter of its tab. Rows are sorted
+// by f.Sort.
+func ApplyBasicFilter(ctx context.Context, srv *sheets.Service, spreadsheetID string, f *BasicFilter) error {
+	t, err := load(ctx, srv, spreadsheetID)
+	if err != nil {
+		return err
+	}
+	g, err := t.GridRange(f.Range)
+	if err != nil {
+		return err
+	}
+	bf := &sheets.BasicFilter{Range: g}
+	if bf.FilterSpecs, err = t.filterSpecs(ctx, g, f.Criteria); err != nil {
+		return err
+	}
+	if bf.SortSpecs, err = t.sortSpecs(ctx, g, f.Sort); err != nil {
+		return err
+	}
+	_, err = srv.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{SetBasicFilter: &sheets.SetBasicFilterRequest{Filter: bf}}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("filters: unable to set basic filter on %s: %w", f.Range, err)
+	}
+	return nil
+}
+
+// GetBasicFilter returns the basic filter of tab, or nil if it has none.
+func GetBasicFilter(ctx context.Context, srv *sheets.Service, spreadsheetID, tab string) (*BasicFilter, error) {
+	t, err := load(ctx, srv, spreadsheetID)
+	if err != nil {
+		return nil, err
+	}
+	id, ok := t.IDs[tab]
+	if !ok {
+		return nil, fmt.Errorf("filters: no tab named %q", tab)
+	}
+	resp, err := srv.Spreadsheets.Get(spreadsheetID).Fields("sheets(properties.sheetId,basicFilter)").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("filters: unable to get basic filter: %w", err)
+	}
+	for _, sh := range resp.Sheets {
+		bf := sh.BasicFilter
+		if sh.Properties.SheetId != id || bf == nil {
+			continue
+		}
+		f := &BasicFilter{Range: t.A1(bf.Range)}
+		if f.Criteria, err = t.criteria(ctx, bf.Range, bf.FilterSpecs); err != nil {
+			return nil, err
+		}
+		if f.Sort, err = t.sortKeys(ctx, bf.Range, bf.SortSpecs); err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+	return nil, nil
+}
+
+// ClearBasicFilter removes the basic filter of tab, showing all rows.
+func ClearBasicFilter(ctx context.Context, srv *sheets.Service, spreadsheetID, tab string) error {
+	t, err := load(ctx, srv, spreadsheetID)
+	if err != nil {
+		return err
+	}
+	id, ok := t.IDs[tab]
+	if !ok {
+		return fmt.Errorf("filters: no tab named %q", tab)
+	}
+	_, err = srv.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{ClearBasicFilter: &sheets.ClearBasicFilterRequest{
+			SheetId:         id,
+			ForceSendFields: []string{"SheetId"},
+		}}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("filters: unable to clear basic filter of %s: %w", tab, err)
+	}
+	return nil
+}
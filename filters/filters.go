@@ -0,0 +1,182 @@
+// Package filters manages filter views, slicers and the basic filter of
+// a spreadsheet. Columns are referred to by their header in the first row
+// of the filtered range.
+package filters
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// Criterion filters the rows of a range on the values of one column.
+type Criterion struct {
+	Column string `json:"column"`
+	// Hidden lists values whose rows are hidden.
+	Hidden []string `json:"hidden,omitempty"`
+	// Condition is a condition type such as "TEXT_EQ", "TEXT_CONTAINS",
+	// "NUMBER_GREATER", "DATE_BEFORE", "BLANK" or "CUSTOM_FORMULA", applied
+	// to Values. Rows not matching it are hidden.
+	Condition string   `json:"condition,omitempty"`
+	Values    []string `json:"values,omitempty"`
+}
+
+// SortKey sorts the filtered rows by a column.
+type SortKey struct {
+	Column     string `json:"column"`
+	Descending bool   `json:"descending,omitempty"`
+}
+
+// table holds the tabs of a spreadsheet, for resolving ranges and headers.
+type table struct {
+	*sheetsclient.Tabs
+	srv           *sheets.Service
+	spreadsheetID string
+	headers       map[string][]string
+}
+
+func load(ctx context.Context, srv *sheets.Service, spreadsheetID string) (*table, error) {
+	tabs, err := sheetsclient.LoadTabs(ctx, srv, spreadsheetID)
+	if err != nil {
+		return nil, err
+	}
+	return &table{Tabs: tabs, srv: srv, spreadsheetID: spreadsheetID, headers: map[string][]string{}}, nil
+}
+
+// header returns the first row of g, indexed by absolute column.
+func (t *table) header(ctx context.Context, g *sheets.GridRange) ([]string, error) {
+	tab := t.Titles[g.SheetId]
+	rng := fmt.Sprintf("%s!%d:%d", sheetsclient.QuoteTab(tab), g.StartRowIndex+1, g.StartRowIndex+1)
+	if h, ok := t.headers[rng]; ok {
+		return h, nil
+	}
+	resp, err := t.srv.Spreadsheets.Values.Get(t.spreadsheetID, rng).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("filters: unable to read header of %s: %w", tab, err)
+	}
+	var h []string
+	if len(resp.Values) > 0 {
+		for _, v := range resp.Values[0] {
+			h = append(h, fmt.Sprint(v))
+		}
+	}
+	t.headers[rng] = h
+	return h, nil
+}
+
+// column returns the absolute index of the named column within g.
+func (t *table) column(ctx context.Context, g *sheets.GridRange, name string) (int64, error) {
+	h, err := t.header(ctx, g)
+	if err != nil {
+		return 0, err
+	}
+	for i := g.StartColumnIndex; i < int64(len(h)); i++ {
+		if g.EndColumnIndex > 0 && i >= g.EndColumnIndex {
+			break
+		}
+		if h[i] == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("filters: %s has no column %q", t.A1(g), name)
+}
+
+// columnName returns the header of an absolute column index, falling back
+// to its letters.
+func (t *table) columnName(ctx context.Context, g *sheets.GridRange, i int64) (string, error) {
+	h, err := t.header(ctx, g)
+	if err != nil {
+		return "", err
+	}
+	if i < int64(len(h)) && h[i] != "" {
+		return h[i], nil
+	}
+	return sheetsclient.ColumnLetters(int(i) + 1), nil
+}
+
+func (t *table) filterSpecs(ctx context.Context, g *sheets.GridRange, criteria []Criterion) ([]*sheets.FilterSpec, error) {
+	var specs []*sheets.FilterSpec
+	for _, c := range criteria {
+		col, err := t.column(ctx, g, c.Column)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, &sheets.FilterSpec{
+			ColumnIndex:     col,
+			FilterCriteria:  filterCriteria(c),
+			ForceSendFields: []string{"ColumnIndex"},
+		})
+	}
+	return specs, nil
+}
+
+func (t *table) sortSpecs(ctx context.Context, g *sheets.GridRange, keys []SortKey) ([]*sheets.SortSpec, error) {
+	var specs []*sheets.SortSpec
+	for _, k := range keys {
+		col, err := t.column(ctx, g, k.Column)
+		if err != nil {
+			return nil, err
+		}
+		s := &sheets.SortSpec{DimensionIndex: col, SortOrder: "ASCENDING", ForceSendFields: []string{"DimensionIndex"}}
+		if k.Descending {
+			s.SortOrder = "DESCENDING"
+		}
+		specs = append(specs, s)
+	}
+	return specs, nil
+}
+
+func (t *table) criteria(ctx context.Context, g *sheets.GridRange, specs []*sheets.FilterSpec) ([]Criterion, error) {
+	var out []Criterion
+	for _, s := range specs {
+		name, err := t.columnName(ctx, g, s.ColumnIndex)
+		if err != nil {
+			return nil, err
+		}
+		c := fromFilterCriteria(s.FilterCriteria)
+		c.Column = name
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func (t *table) sortKeys(ctx context.Context, g *sheets.GridRange, specs []*sheets.SortSpec) ([]SortKey, error) {
+	var out []SortKey
+	for _, s := range specs {
+		name, err := t.columnName(ctx, g, s.DimensionIndex)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, SortKey{Column: name, Descending: s.SortOrder == "DESCENDING"})
+	}
+	return out, nil
+}
+
+func filterCriteria(c Criterion) *sheets.FilterCriteria {
+	fc := &sheets.FilterCriteria{HiddenValues: c.Hidden}
+	if c.Condition != "" {
+		fc.Condition = &sheets.BooleanCondition{Type: c.Condition}
+		for _, v := range c.Values {
+			fc.Condition.Values = append(fc.Condition.Values, &sheets.ConditionValue{UserEnteredValue: v})
+		}
+	}
+	return fc
+}
+
+func fromFilterCriteria(fc *sheets.FilterCriteria) Criterion {
+	var c Criterion
+	if fc == nil {
+		return c
+	}
+	c.Hidden = fc.HiddenValues
+	if fc.Condition != nil {
+		c.Condition = fc.Condition.Type
+		for _, v := range fc.Condition.Values {
+			c.Values = append(c.Values, v.UserEnteredValue)
+		}
+	}
+	return c
+}
@@ -0,0 +1,167 @@
+package filters
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+)
+
+// Slicer is an interactive filter control bound to one column of a data
+// range, placed over the cells of a tab.
+type Slicer struct {
+	// ID is assigned by Sheets when the slicer is added.
+	ID    int64  `json:"id,omitempty"`
+	Title string `json:"title,omitempty"`
+	// Range is the data range the slicer filters, e.g. "Data!A1:F".
+	Range  string `json:"range"`
+	Column string `json:"column"`
+	// Default is the filter applied when the slicer is created; viewers
+	// change it interactively.
+	Default *Criterion `json:"default,omitempty"`
+	// ApplyToPivots also filters pivot tables built from Range.
+	ApplyToPivots bool `json:"applyToPivots,omitempty"`
+	// Anchor is the cell, e.g. "Dashboard!B2", the slicer's top left
+	// corner is placed over.
+	Anchor string `json:"anchor"`
+	Width  int64  `json:"width,omitempty"`
+	Height int64  `json:"height,omitempty"`
+}
+
+// AddSlicer creates s and sets its ID.
+func AddSlicer(ctx context.Context, srv *sheets.Service, spreadsheetID string, s *Slicer) error {
+	t, err := load(ctx, srv, spreadsheetID)
+	if err != nil {
+		return err
+	}
+	spec, err := t.slicerSpec(ctx, s)
+	if err != nil {
+		return err
+	}
+	anchor, err := t.GridRange(s.Anchor)
+	if err != nil {
+		return err
+	}
+	pos := &sheets.EmbeddedObjectPosition{OverlayPosition: &sheets.OverlayPosition{
+		AnchorCell: &sheets.GridCoordinate{
+			SheetId:         anchor.SheetId,
+			RowIndex:        anchor.StartRowIndex,
+			ColumnIndex:     anchor.StartColumnIndex,
+			ForceSendFields: []string{"SheetId", "RowIndex", "ColumnIndex"},
+		},
+		WidthPixels:  s.Width,
+		HeightPixels: s.Height,
+	}}
+	resp, err := srv.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{AddSlicer: &sheets.AddSlicerRequest{
+			Slicer: &sheets.Slicer{Spec: spec, Position: pos},
+		}}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("filters: unable to add slicer on %s: %w", s.Column, err)
+	}
+	s.ID = resp.Replies[0].AddSlicer.Slicer.SlicerId
+	return nil
+}
+
+// UpdateSlicer replaces the spec of the slicer with ID s.ID, e.g. to bind
+// it to a moved data range. Its position is kept; s.Anchor is ignored.
+func UpdateSlicer(ctx context.Context, srv *sheets.Service, spreadsheetID string, s *Slicer) error {
+	t, err := load(ctx, srv, spreadsheetID)
+	if err != nil {
+		return err
+	}
+	spec, err := t.slicerSpec(ctx, s)
+	if err != nil {
+		return err
+	}
+	_, err = srv.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{UpdateSlicerSpec: &sheets.UpdateSlicerSpecRequest{
+			SlicerId: s.ID,
+			Spec:     spec,
+			Fields:   "dataRange,columnIndex,filterCriteria,title,applyToPivotTables",
+		}}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("filters: unable to update slicer %d: %w", s.ID, err)
+	}
+	return nil
+}
+
+// DeleteSlicer removes a slicer.
+func DeleteSlicer(ctx context.Context, srv *sheets.Service, spreadsheetID string, id int64) error {
+	_, err := srv.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{DeleteEmbeddedObject: &sheets.DeleteEmbeddedObjectRequest{ObjectId: id}}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("filters: unable to delete slicer %d: %w", id, err)
+	}
+	return nil
+}
+
+// ListSlicers returns the slicers of a spreadsheet.
+func ListSlicers(ctx context.Context, srv *sheets.Service, spreadsheetID string) ([]*Slicer, error) {
+	t, err := load(ctx, srv, spreadsheetID)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := srv.Spreadsheets.Get(spreadsheetID).Fields("sheets.slicers").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("filters: unable to get slicers: %w", err)
+	}
+	var out []*Slicer
+	for _, sh := range resp.Sheets {
+		for _, sl := range sh.Slicers {
+			spec := sl.Spec
+			if spec == nil || spec.DataRange == nil {
+				continue
+			}
+			s := &Slicer{ID: sl.SlicerId, Title: spec.Title, Range: t.A1(spec.DataRange), ApplyToPivots: spec.ApplyToPivotTables}
+			if s.Column, err = t.columnName(ctx, spec.DataRange, spec.ColumnIndex); err != nil {
+				return nil, err
+			}
+			if spec.FilterCriteria != nil {
+				c := fromFilterCriteria(spec.FilterCriteria)
+				c.Column = s.Column
+				s.Default = &c
+			}
+			if sl.Position != nil && sl.Position.OverlayPosition != nil {
+				o := sl.Position.OverlayPosition
+				if a := o.AnchorCell; a != nil {
+					s.Anchor = t.A1(&sheets.GridRange{
+						SheetId:          a.SheetId,
+						StartRowIndex:    a.RowIndex,
+						EndRowIndex:      a.RowIndex + 1,
+						StartColumnIndex: a.ColumnIndex,
+						EndColumnIndex:   a.ColumnIndex + 1,
+					})
+				}
+				s.Width, s.Height = o.WidthPixels, o.HeightPixels
+			}
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func (t *table) slicerSpec(ctx context.Context, s *Slicer) (*sheets.SlicerSpec, error) {
+	g, err := t.GridRange(s.Range)
+	if err != nil {
+		return nil, err
+	}
+	col, err := t.column(ctx, g, s.Column)
+	if err != nil {
+		return nil, err
+	}
+	spec := &sheets.SlicerSpec{
+		DataRange:          g,
+		ColumnIndex:        col,
+		Title:              s.Title,
+		ApplyToPivotTables: s.ApplyToPivots,
+		ForceSendFields:    []string{"ColumnIndex", "ApplyToPivotTables"},
+	}
+	if s.Default != nil {
+		spec.FilterCriteria = filterCriteria(*s.Default)
+	}
+	return spec, nil
+}
@@ -0,0 +1,141 @@
+package filters
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+)
+
+// View is a saved filter view: criteria and sort order over a range that
+// each viewer can switch to without affecting others.
+type View struct {
+	// ID is assigned by Sheets when the view is added.
+	ID    int64  `json:"id,omitempty"`
+	Title string `json:"title"`
+	// Range is the filtered range, e.g. "Data!A1:F" or just "Data".
+	Range    string      `json:"range"`
+	Criteria []Criterion `json:"criteria,omitempty"`
+	Sort     []SortKey   `json:"sort,omitempty"`
+}
+
+// ListViews returns the filter views of a spreadsheet.
+func ListViews(ctx context.Context, srv *sheets.Service, spreadsheetID string) ([]*View, error) {
+	t, err := load(ctx, srv, spreadsheetID)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := srv.Spreadsheets.Get(spreadsheetID).Fields("sheets.filterViews").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("filters: unable to get filter views: %w", err)
+	}
+	var out []*View
+	for _, sh := range resp.Sheets {
+		for _, fv := range sh.FilterViews {
+			v := &View{ID: fv.FilterViewId, Title: fv.Title, Range: t.A1(fv.Range)}
+			if v.Criteria, err = t.criteria(ctx, fv.Range, fv.FilterSpecs); err != nil {
+				return nil, err
+			}
+			if v.Sort, err = t.sortKeys(ctx, fv.Range, fv.SortSpecs); err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+// AddView creates v and sets its ID.
+func AddView(ctx context.Context, srv *sheets.Service, spreadsheetID string, v *View) error {
+	t, err := load(ctx, srv, spreadsheetID)
+	if err != nil {
+		return err
+	}
+	fv, err := t.filterView(ctx, v)
+	if err != nil {
+		return err
+	}
+	resp, err := srv.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{AddFilterView: &sheets.AddFilterViewRequest{Filter: fv}}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("filters: unable to add filter view %q: %w", v.Title, err)
+	}
+	v.ID = resp.Replies[0].AddFilterView.Filter.FilterViewId
+	return nil
+}
+
+// UpdateView replaces the filter view with ID v.ID by v.
+func UpdateView(ctx context.Context, srv *sheets.Service, spreadsheetID string, v *View) error {
+	t, err := load(ctx, srv, spreadsheetID)
+	if err != nil {
+		return err
+	}
+	fv, err := t.filterView(ctx, v)
+	if err != nil {
+		return err
+	}
+	fv.FilterViewId = v.ID
+	_, err = srv.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{UpdateFilterView: &sheets.UpdateFilterViewRequest{
+			Filter: fv,
+			Fields: "title,range,filterSpecs,sortSpecs",
+		}}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("filters: unable to update filter view %q: %w", v.Title, err)
+	}
+	return nil
+}
+
+// DeleteView removes a filter view.
+func DeleteView(ctx context.Context, srv *sheets.Service, spreadsheetID string, id int64) error {
+	_, err := srv.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{DeleteFilterView: &sheets.DeleteFilterViewRequest{FilterId: id}}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("filters: unable to delete filter view %d: %w", id, err)
+	}
+	return nil
+}
+
+// ApplyViews makes the spreadsheet's filter views match views by title:
+// views with a known title are updated, the others added. Existing views
+// not in views are left alone. IDs of views are set.
+func ApplyViews(ctx context.Context, srv *sheets.Service, spreadsheetID string, views []*View) error {
+	existing, err := ListViews(ctx, srv, spreadsheetID)
+	if err != nil {
+		return err
+	}
+	byTitle := map[string]int64{}
+	for _, v := range existing {
+		byTitle[v.Title] = v.ID
+	}
+	for _, v := range views {
+		if id, ok := byTitle[v.Title]; ok {
+			v.ID = id
+			err = UpdateView(ctx, srv, spreadsheetID, v)
+		} else {
+			err = AddView(ctx, srv, spreadsheetID, v)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *table) filterView(ctx context.Context, v *View) (*sheets.FilterView, error) {
+	g, err := t.GridRange(v.Range)
+	if err != nil {
+		return nil, err
+	}
+	fv := &sheets.FilterView{Title: v.Title, Range: g}
+	if fv.FilterSpecs, err = t.filterSpecs(ctx, g, v.Criteria); err != nil {
+		return nil, err
+	}
+	if fv.SortSpecs, err = t.sortSpecs(ctx, g, v.Sort); err != nil {
+		return nil, err
+	}
+	return fv, nil
+}
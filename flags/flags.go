@@ -0,0 +1,264 @@
+// Package flags serves feature flags defined in a spreadsheet tab.
+//
+// The tab has a header row and one flag per row:
+//
+//	Name          | Type | Default | staging | production | Description
+//	new_checkout  | bool | false   | true    |            | Redesigned checkout
+//	search_limit  | int  | 20      |         | 50         |
+//
+// Every column besides Name, Type, Default and Description is an
+// environment; a non-empty cell overrides the default there. Flags are
+// loaded once and served from memory, and a watcher swaps in edited
+// values after checking that every value parses as the flag's type.
+package flags
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetconfig"
+)
+
+// Flag is a row of the flags tab.
+type Flag struct {
+	Name        string            `sheet:",required"`
+	Type        string            `sheet:",required"`
+	Default     string            `sheet:"default"`
+	Description string            `sheet:"description"`
+	Overrides   map[string]string `sheet:",rest"`
+}
+
+// Value returns the value of f in env.
+func (f *Flag) Value(env string) string {
+	if v, ok := f.Overrides[env]; ok {
+		return v
+	}
+	return f.Default
+}
+
+// table is the decoded flags tab.
+type table []Flag
+
+// Validate implements sheetconfig.Validator.
+func (t *table) Validate() error {
+	seen := map[string]bool{}
+	for _, f := range *t {
+		if seen[f.Name] {
+			return fmt.Errorf("duplicate flag %q", f.Name)
+		}
+		seen[f.Name] = true
+		values := []string{f.Default}
+		for _, v := range f.Overrides {
+			values = append(values, v)
+		}
+		for _, v := range values {
+			if err := check(f.Type, v); err != nil {
+				return fmt.Errorf("flag %s: %w", f.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// check parses v as typ. An empty cell is the zero value of any type.
+func check(typ, v string) error {
+	var err error
+	if v == "" {
+		v = zero(typ)
+	}
+	switch strings.ToLower(typ) {
+	case "bool":
+		_, err = parseBool(v)
+	case "int":
+		_, err = strconv.ParseInt(v, 10, 64)
+	case "float":
+		_, err = strconv.ParseFloat(v, 64)
+	case "duration":
+		_, err = time.ParseDuration(v)
+	case "string":
+	default:
+		return fmt.Errorf("unknown type %q", typ)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s %q", typ, v)
+	}
+	return nil
+}
+
+func zero(typ string) string {
+	switch strings.ToLower(typ) {
+	case "bool":
+		return "false"
+	case "int", "float":
+		return "0"
+	case "duration":
+		return "0s"
+	}
+	return ""
+}
+
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q", s)
+}
+
+// Change describes a flag whose value changed in the environment of a Set.
+// Old is empty for new flags and New for removed ones.
+type Change struct {
+	Name     string
+	Old, New string
+}
+
+// Set holds the flags of one environment.
+type Set struct {
+	loader *sheetconfig.Loader
+	env    string
+
+	mu       sync.RWMutex
+	flags    map[string]*Flag
+	handlers []func(Change)
+}
+
+// New returns the flag set of env defined by the given range. Call Load
+// before using it.
+func New(srv *sheets.Service, spreadsheetID, rng, env string) *Set {
+	return &Set{
+		loader: &sheetconfig.Loader{Sheets: srv, SpreadsheetID: spreadsheetID, Range: rng, Layout: sheetconfig.Table},
+		env:    env,
+		flags:  map[string]*Flag{},
+	}
+}
+
+// Load reads the flags.
+func (s *Set) Load(ctx context.Context) error {
+	var t table
+	if err := s.loader.Load(ctx, &t); err != nil {
+		return err
+	}
+	s.swap(t)
+	return nil
+}
+
+// Watch reloads the flags at the given interval until ctx is done,
+// reporting invalid edits to onError; values keep their last valid state
+// meanwhile. It returns ctx.Err().
+func (s *Set) Watch(ctx context.Context, interval time.Duration, onError func(error)) error {
+	s.loader.Interval = interval
+	s.loader.OnError = onError
+	return s.loader.Watch(ctx, &table{}, func(cfg interface{}) {
+		s.swap(*cfg.(*table))
+	})
+}
+
+// OnChange registers fn to be called, after the swap, for every flag whose
+// value changed in this environment.
+func (s *Set) OnChange(fn func(Change)) {
+	s.mu.Lock()
+	s.handlers = append(s.handlers, fn)
+	s.mu.Unlock()
+}
+
+func (s *Set) swap(t table) {
+	flags := make(map[string]*Flag, len(t))
+	for i := range t {
+		flags[t[i].Name] = &t[i]
+	}
+
+	s.mu.Lock()
+	old := s.flags
+	s.flags = flags
+	handlers := s.handlers
+	s.mu.Unlock()
+
+	var changes []Change
+	for name, f := range flags {
+		var prev string
+		if o, ok := old[name]; ok {
+			prev = o.Value(s.env)
+		}
+		if v := f.Value(s.env); v != prev || old[name] == nil {
+			changes = append(changes, Change{Name: name, Old: prev, New: v})
+		}
+	}
+	for name, o := range old {
+		if flags[name] == nil {
+			changes = append(changes, Change{Name: name, Old: o.Value(s.env)})
+		}
+	}
+	for _, c := range changes {
+		for _, fn := range handlers {
+			fn(c)
+		}
+	}
+}
+
+// lookup returns the value of a flag, if it exists and has type typ.
+func (s *Set) lookup(name, typ string) (string, bool) {
+	s.mu.RLock()
+	f, ok := s.flags[name]
+	s.mu.RUnlock()
+	if !ok || !strings.EqualFold(f.Type, typ) {
+		return "", false
+	}
+	return f.Value(s.env), true
+}
+
+// Bool returns the value of a bool flag, or fallback if there is no such
+// flag.
+func (s *Set) Bool(name string, fallback bool) bool {
+	v, ok := s.lookup(name, "bool")
+	if !ok {
+		return fallback
+	}
+	b, _ := parseBool(v)
+	return b
+}
+
+// String returns the value of a string flag, or fallback.
+func (s *Set) String(name, fallback string) string {
+	if v, ok := s.lookup(name, "string"); ok {
+		return v
+	}
+	return fallback
+}
+
+// Int returns the value of an int flag, or fallback.
+func (s *Set) Int(name string, fallback int) int {
+	v, ok := s.lookup(name, "int")
+	if !ok {
+		return fallback
+	}
+	n, _ := strconv.Atoi(v)
+	return n
+}
+
+// Float returns the value of a float flag, or fallback.
+func (s *Set) Float(name string, fallback float64) float64 {
+	v, ok := s.lookup(name, "float")
+	if !ok {
+		return fallback
+	}
+	f, _ := strconv.ParseFloat(v, 64)
+	return f
+}
+
+// Duration returns the value of a duration flag, or fallback.
+func (s *Set) Duration(name string, fallback time.Duration) time.Duration {
+	v, ok := s.lookup(name, "duration")
+	if !ok {
+		return fallback
+	}
+	d, _ := time.ParseDuration(v)
+	return d
+}
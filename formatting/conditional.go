@@ -0,0 +1,250 @@
+package formatting
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// Rule is a conditional format rule: either Condition with Style, applied
+// to the cells meeting it, or a color Scale.
+type Rule struct {
+	// Tab and Index locate an existing rule; rules are evaluated in
+	// index order within their tab.
+	Tab   string `json:"tab,omitempty"`
+	Index int64  `json:"index"`
+	// Ranges are A1 ranges or named ranges.
+	Ranges []string `json:"ranges"`
+	// Condition is a condition type such as "NUMBER_GREATER",
+	// "NUMBER_BETWEEN", "TEXT_CONTAINS", "BLANK" or "CUSTOM_FORMULA",
+	// applied to Values, e.g. "100" or "=$C2>$D2".
+	Condition string   `json:"condition,omitempty"`
+	Values    []string `json:"values,omitempty"`
+	Style     *Style   `json:"style,omitempty"`
+	// Scale colors the cells on a gradient between two or three points.
+	Scale []ScalePoint `json:"scale,omitempty"`
+}
+
+// Style is the format a rule applies. Colors are "#RRGGBB".
+type Style struct {
+	Bold          bool   `json:"bold,omitempty"`
+	Italic        bool   `json:"italic,omitempty"`
+	Underline     bool   `json:"underline,omitempty"`
+	Strikethrough bool   `json:"strikethrough,omitempty"`
+	Color         string `json:"color,omitempty"`
+	Background    string `json:"background,omitempty"`
+}
+
+// ScalePoint is a point of a color scale: Type is "MIN", "MAX", "NUMBER",
+// "PERCENT" or "PERCENTILE", the last three at Value.
+type ScalePoint struct {
+	Type  string `json:"type"`
+	Value string `json:"value,omitempty"`
+	Color string `json:"color"`
+}
+
+// ColorScale returns the points of a scale from the minimum to the
+// maximum through the given colors: two colors for the ends, or three
+// with the median in the middle. Other numbers of colors return nil.
+func ColorScale(colors ...string) []ScalePoint {
+	if len(colors) != 2 && len(colors) != 3 {
+		return nil
+	}
+	points := []ScalePoint{{Type: "MIN", Color: colors[0]}}
+	if len(colors) == 3 {
+		points = append(points, ScalePoint{Type: "PERCENTILE", Value: "50", Color: colors[1]})
+	}
+	return append(points, ScalePoint{Type: "MAX", Color: colors[len(colors)-1]})
+}
+
+// AddRule adds r to the tab of its ranges at r.Index, 0 being evaluated
+// first.
+func AddRule(ctx context.Context, srv *sheets.Service, spreadsheetID string, r *Rule) error {
+	tabs, err := sheetsclient.LoadTabs(ctx, srv, spreadsheetID)
+	if err != nil {
+		return err
+	}
+	rule, err := r.rule(tabs)
+	if err != nil {
+		return err
+	}
+	_, err = srv.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{AddConditionalFormatRule: &sheets.AddConditionalFormatRuleRequest{
+			Rule:            rule,
+			Index:           r.Index,
+			ForceSendFields: []string{"Index"},
+		}}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("formatting: unable to add conditional format rule: %w", err)
+	}
+	return nil
+}
+
+// Rules lists the conditional format rules of every tab.
+func Rules(ctx context.Context, srv *sheets.Service, spreadsheetID string) ([]*Rule, error) {
+	resp, err := srv.Spreadsheets.Get(spreadsheetID).
+		Fields("sheets(properties(sheetId,title),conditionalFormats)").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("formatting: unable to get conditional format rules: %w", err)
+	}
+	names := map[int64]string{}
+	for _, sh := range resp.Sheets {
+		names[sh.Properties.SheetId] = sh.Properties.Title
+	}
+	var out []*Rule
+	for _, sh := range resp.Sheets {
+		for i, cf := range sh.ConditionalFormats {
+			r := &Rule{Tab: sh.Properties.Title, Index: int64(i)}
+			for _, g := range cf.Ranges {
+				r.Ranges = append(r.Ranges, sheetsclient.FormatRange(names[g.SheetId], g))
+			}
+			if b := cf.BooleanRule; b != nil {
+				if b.Condition != nil {
+					r.Condition = b.Condition.Type
+					for _, v := range b.Condition.Values {
+						r.Values = append(r.Values, v.UserEnteredValue)
+					}
+				}
+				r.Style = style(b.Format)
+			}
+			if g := cf.GradientRule; g != nil {
+				for _, p := range []*sheets.InterpolationPoint{g.Minpoint, g.Midpoint, g.Maxpoint} {
+					if p != nil {
+						r.Scale = append(r.Scale, ScalePoint{Type: p.Type, Value: p.Value, Color: colorHex(p.ColorStyle, p.Color)})
+					}
+				}
+			}
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+// DeleteRule deletes the rule at index of tab. The rules after it move up.
+func DeleteRule(ctx context.Context, srv *sheets.Service, spreadsheetID, tab string, index int64) error {
+	tabs, err := sheetsclient.LoadTabs(ctx, srv, spreadsheetID)
+	if err != nil {
+		return err
+	}
+	id, ok := tabs.IDs[tab]
+	if !ok {
+		return fmt.Errorf("formatting: no tab named %q", tab)
+	}
+	_, err = srv.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{DeleteConditionalFormatRule: &sheets.DeleteConditionalFormatRuleRequest{
+			SheetId:         id,
+			Index:           index,
+			ForceSendFields: []string{"SheetId", "Index"},
+		}}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("formatting: unable to delete conditional format rule %d of %s: %w", index, tab, err)
+	}
+	return nil
+}
+
+func (r *Rule) rule(tabs *sheetsclient.Tabs) (*sheets.ConditionalFormatRule, error) {
+	if len(r.Ranges) == 0 {
+		return nil, fmt.Errorf("formatting: conditional format rule has no ranges")
+	}
+	rule := &sheets.ConditionalFormatRule{}
+	for _, rng := range r.Ranges {
+		g, err := tabs.GridRange(rng)
+		if err != nil {
+			return nil, err
+		}
+		rule.Ranges = append(rule.Ranges, g)
+	}
+	switch {
+	case len(r.Scale) > 0 && r.Condition != "":
+		return nil, fmt.Errorf("formatting: a conditional format rule has either a condition or a scale")
+	case len(r.Scale) > 0:
+		if len(r.Scale) != 2 && len(r.Scale) != 3 {
+			return nil, fmt.Errorf("formatting: a color scale has 2 or 3 points, not %d", len(r.Scale))
+		}
+		points := make([]*sheets.InterpolationPoint, len(r.Scale))
+		for i, p := range r.Scale {
+			c, err := ParseColor(p.Color)
+			if err != nil {
+				return nil, err
+			}
+			points[i] = &sheets.InterpolationPoint{Type: strings.ToUpper(p.Type), Value: p.Value, ColorStyle: &sheets.ColorStyle{RgbColor: c}}
+		}
+		g := &sheets.GradientRule{Minpoint: points[0], Maxpoint: points[len(points)-1]}
+		if len(points) == 3 {
+			g.Midpoint = points[1]
+		}
+		rule.GradientRule = g
+	case r.Condition != "":
+		cond := &sheets.BooleanCondition{Type: strings.ToUpper(r.Condition)}
+		for _, v := range r.Values {
+			cond.Values = append(cond.Values, &sheets.ConditionValue{UserEnteredValue: v})
+		}
+		format, err := r.Style.format()
+		if err != nil {
+			return nil, err
+		}
+		rule.BooleanRule = &sheets.BooleanRule{Condition: cond, Format: format}
+	default:
+		return nil, fmt.Errorf("formatting: conditional format rule needs a condition or a scale")
+	}
+	return rule, nil
+}
+
+// format converts s to the subset of CellFormat rules may set.
+func (s *Style) format() (*sheets.CellFormat, error) {
+	if s == nil {
+		return nil, fmt.Errorf("formatting: conditional format rule has no style")
+	}
+	f := &sheets.CellFormat{TextFormat: &sheets.TextFormat{
+		Bold:          s.Bold,
+		Italic:        s.Italic,
+		Underline:     s.Underline,
+		Strikethrough: s.Strikethrough,
+	}}
+	if s.Color != "" {
+		c, err := ParseColor(s.Color)
+		if err != nil {
+			return nil, err
+		}
+		f.TextFormat.ForegroundColorStyle = &sheets.ColorStyle{RgbColor: c}
+	}
+	if s.Background != "" {
+		c, err := ParseColor(s.Background)
+		if err != nil {
+			return nil, err
+		}
+		f.BackgroundColorStyle = &sheets.ColorStyle{RgbColor: c}
+	}
+	return f, nil
+}
+
+// style is the inverse of Style.format.
+func style(f *sheets.CellFormat) *Style {
+	if f == nil {
+		return nil
+	}
+	s := &Style{Background: colorHex(f.BackgroundColorStyle, f.BackgroundColor)}
+	if t := f.TextFormat; t != nil {
+		s.Bold, s.Italic, s.Underline, s.Strikethrough = t.Bold, t.Italic, t.Underline, t.Strikethrough
+		s.Color = colorHex(t.ForegroundColorStyle, t.ForegroundColor)
+	}
+	return s
+}
+
+// colorHex formats a color as "#RRGGBB", preferring the color style, or
+// returns "" for none or a theme color.
+func colorHex(cs *sheets.ColorStyle, c *sheets.Color) string {
+	if cs != nil {
+		c = cs.RgbColor
+	}
+	if c == nil {
+		return ""
+	}
+	return fmt.Sprintf("#%02X%02X%02X", int(c.Red*255+0.5), int(c.Green*255+0.5), int(c.Blue*255+0.5))
+}
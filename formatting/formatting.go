@@ -0,0 +1,281 @@
+// Package formatting styles ranges of a spreadsheet: fonts, colors,
+// alignment and number formats, built with a chain of calls and sent in
+// one batch update:
+//
+//	err := formatting.Apply(ctx, srv, spreadsheetID,
+//		formatting.Format("Report!A1:F1").Bold().Background("#FFF2CC"),
+//		formatting.Format("Report!F2:F").NumberFormat("0.00%"))
+//
+// Only the properties set are changed; the rest of each cell's format is
+// kept.
+package formatting
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// Builder describes the format set on a range. Its methods return the
+// builder so that calls can be chained; the first invalid argument is
+// reported by Apply or Request.
+type Builder struct {
+	rng    string
+	format sheets.CellFormat
+	fields []string
+	clear  bool
+	err    error
+}
+
+// Format starts the format of rng, e.g. "Report!A1:F1", a whole tab or,
+// with Apply, a named range.
+func Format(rng string) *Builder {
+	return &Builder{rng: rng}
+}
+
+// Range returns the range the builder formats.
+func (b *Builder) Range() string { return b.rng }
+
+func (b *Builder) text() *sheets.TextFormat {
+	if b.format.TextFormat == nil {
+		b.format.TextFormat = &sheets.TextFormat{}
+	}
+	return b.format.TextFormat
+}
+
+func (b *Builder) set(field string) *Builder {
+	b.fields = append(b.fields, "userEnteredFormat."+field)
+	return b
+}
+
+func (b *Builder) fail(err error) *Builder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+// Bold makes the text bold.
+func (b *Builder) Bold() *Builder {
+	b.text().Bold = true
+	return b.set("textFormat.bold")
+}
+
+// Italic makes the text italic.
+func (b *Builder) Italic() *Builder {
+	b.text().Italic = true
+	return b.set("textFormat.italic")
+}
+
+// Underline underlines the text.
+func (b *Builder) Underline() *Builder {
+	b.text().Underline = true
+	return b.set("textFormat.underline")
+}
+
+// Strikethrough strikes the text through.
+func (b *Builder) Strikethrough() *Builder {
+	b.text().Strikethrough = true
+	return b.set("textFormat.strikethrough")
+}
+
+// Font sets the font family, e.g. "Roboto Mono".
+func (b *Builder) Font(family string) *Builder {
+	b.text().FontFamily = family
+	return b.set("textFormat.fontFamily")
+}
+
+// FontSize sets the font size in points.
+func (b *Builder) FontSize(points int64) *Builder {
+	if points <= 0 {
+		return b.fail(fmt.Errorf("formatting: invalid font size %d", points))
+	}
+	b.text().FontSize = points
+	return b.set("textFormat.fontSize")
+}
+
+// Color sets the text color, given as "#RRGGBB" or "#RGB".
+func (b *Builder) Color(hex string) *Builder {
+	c, err := ParseColor(hex)
+	if err != nil {
+		return b.fail(err)
+	}
+	b.text().ForegroundColorStyle = &sheets.ColorStyle{RgbColor: c}
+	return b.set("textFormat.foregroundColorStyle")
+}
+
+// Background sets the fill color, given as "#RRGGBB" or "#RGB".
+func (b *Builder) Background(hex string) *Builder {
+	c, err := ParseColor(hex)
+	if err != nil {
+		return b.fail(err)
+	}
+	b.format.BackgroundColorStyle = &sheets.ColorStyle{RgbColor: c}
+	return b.set("backgroundColorStyle")
+}
+
+// NumberFormat sets the number format pattern, e.g. "0.00%", "#,##0" or
+// "yyyy-mm-dd". The format type is guessed from the pattern; use
+// NumberFormatType to give it.
+func (b *Builder) NumberFormat(pattern string) *Builder {
+	return b.NumberFormatType(guessType(pattern), pattern)
+}
+
+// NumberFormatType sets the number format with its type, one of "TEXT",
+// "NUMBER", "PERCENT", "CURRENCY", "DATE", "TIME", "DATE_TIME" or
+// "SCIENTIFIC". An empty pattern uses the locale's default for the type.
+func (b *Builder) NumberFormatType(typ, pattern string) *Builder {
+	b.format.NumberFormat = &sheets.NumberFormat{Type: strings.ToUpper(typ), Pattern: pattern}
+	return b.set("numberFormat")
+}
+
+// Align sets the horizontal alignment: "LEFT", "CENTER" or "RIGHT".
+func (b *Builder) Align(horizontal string) *Builder {
+	h := strings.ToUpper(horizontal)
+	if h != "LEFT" && h != "CENTER" && h != "RIGHT" {
+		return b.fail(fmt.Errorf("formatting: invalid alignment %q", horizontal))
+	}
+	b.format.HorizontalAlignment = h
+	return b.set("horizontalAlignment")
+}
+
+// VerticalAlign sets the vertical alignment: "TOP", "MIDDLE" or "BOTTOM".
+func (b *Builder) VerticalAlign(vertical string) *Builder {
+	v := strings.ToUpper(vertical)
+	if v != "TOP" && v != "MIDDLE" && v != "BOTTOM" {
+		return b.fail(fmt.Errorf("formatting: invalid vertical alignment %q", vertical))
+	}
+	b.format.VerticalAlignment = v
+	return b.set("verticalAlignment")
+}
+
+// Wrap sets how text too long for its cell is shown: "OVERFLOW_CELL",
+// "CLIP" or "WRAP".
+func (b *Builder) Wrap(strategy string) *Builder {
+	w := strings.ToUpper(strategy)
+	if w == "OVERFLOW" {
+		w = "OVERFLOW_CELL"
+	}
+	if w != "OVERFLOW_CELL" && w != "CLIP" && w != "WRAP" {
+		return b.fail(fmt.Errorf("formatting: invalid wrap strategy %q", strategy))
+	}
+	b.format.WrapStrategy = w
+	return b.set("wrapStrategy")
+}
+
+// Clear resets the range to the default format before the properties set
+// on b are applied.
+func (b *Builder) Clear() *Builder {
+	b.clear = true
+	return b
+}
+
+// Request returns the batch update request applying b, resolving its range
+// with the tabs and named ranges of the spreadsheet.
+func (b *Builder) Request(tabs *sheetsclient.Tabs) (*sheets.Request, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	fields := strings.Join(b.fields, ",")
+	if b.clear {
+		fields = "userEnteredFormat"
+	}
+	if fields == "" {
+		return nil, fmt.Errorf("formatting: no format given for %s", b.rng)
+	}
+	g, err := tabs.GridRange(b.rng)
+	if err != nil {
+		return nil, err
+	}
+	format := b.format
+	return &sheets.Request{RepeatCell: &sheets.RepeatCellRequest{
+		Range:  g,
+		Cell:   &sheets.CellData{UserEnteredFormat: &format},
+		Fields: fields,
+	}}, nil
+}
+
+// Apply formats the ranges of builders in one batch update.
+func Apply(ctx context.Context, srv *sheets.Service, spreadsheetID string, builders ...*Builder) error {
+	tabs, err := sheetsclient.LoadTabs(ctx, srv, spreadsheetID)
+	if err != nil {
+		return err
+	}
+	var reqs []*sheets.Request
+	for _, b := range builders {
+		req, err := b.Request(tabs)
+		if err != nil {
+			return err
+		}
+		reqs = append(reqs, req)
+	}
+	if len(reqs) == 0 {
+		return nil
+	}
+	_, err = srv.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{Requests: reqs}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("formatting: unable to format %s: %w", spreadsheetID, err)
+	}
+	return nil
+}
+
+// ParseColor parses "#RRGGBB" or "#RGB", with or without the "#".
+func ParseColor(hex string) (*sheets.Color, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(hex), "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	n, err := strconv.ParseUint(s, 16, 32)
+	if len(s) != 6 || err != nil {
+		return nil, fmt.Errorf("formatting: invalid color %q", hex)
+	}
+	return &sheets.Color{
+		Red:             float64(n>>16&0xff) / 255,
+		Green:           float64(n>>8&0xff) / 255,
+		Blue:            float64(n&0xff) / 255,
+		ForceSendFields: []string{"Red", "Green", "Blue"},
+	}, nil
+}
+
+// guessType returns the number format type of pattern.
+func guessType(pattern string) string {
+	p := strings.ToLower(pattern)
+	// Quoted text and escaped characters are literal.
+	var b strings.Builder
+	quoted := false
+	for i := 0; i < len(p); i++ {
+		switch {
+		case p[i] == '"':
+			quoted = !quoted
+		case p[i] == '\\':
+			i++
+		case !quoted:
+			b.WriteByte(p[i])
+		}
+	}
+	p = b.String()
+	date := strings.ContainsAny(p, "yd") || strings.Contains(p, "mmm")
+	clock := strings.ContainsAny(p, "hs") || strings.Contains(p, "am/pm")
+	switch {
+	case p == "@":
+		return "TEXT"
+	case date && clock:
+		return "DATE_TIME"
+	case date:
+		return "DATE"
+	case clock:
+		return "TIME"
+	case strings.Contains(p, "%"):
+		return "PERCENT"
+	case strings.Contains(p, "e+") || strings.Contains(p, "e-"):
+		return "SCIENTIFIC"
+	case strings.ContainsAny(p, "$€£¥"):
+		return "CURRENCY"
+	}
+	return "NUMBER"
+}
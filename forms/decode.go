@@ -0,0 +1,101 @@
+package forms
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Decode stores the response in the struct pointed to by dst. Fields are
+// matched to questions by their `form:"Question text"` tag, or by name
+// ignoring case. The special titles "Timestamp" and "Email Address" map to
+// the response's timestamp and email. Slice fields receive checkbox
+// choices; numeric and bool fields are parsed, with "Yes" counting as true.
+func (resp *Response) Decode(dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("forms: cannot decode into %T", dst)
+	}
+	v = v.Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" || sf.Tag.Get("form") == "-" {
+			continue
+		}
+		title := sf.Tag.Get("form")
+		fv := v.Field(i)
+		if title == TimestampColumn && sf.Type == timeType {
+			fv.Set(reflect.ValueOf(resp.Timestamp))
+			continue
+		}
+		answer, ok := resp.lookup(title, sf.Name)
+		if !ok {
+			continue
+		}
+		if err := setAnswer(fv, answer); err != nil {
+			return fmt.Errorf("forms: row %d: %s: %w", resp.Row, sf.Name, err)
+		}
+	}
+	return nil
+}
+
+func (resp *Response) lookup(title, name string) (string, bool) {
+	if title == EmailColumn || (title == "" && strings.EqualFold(name, "email")) {
+		return resp.Email, resp.Email != ""
+	}
+	if title != "" {
+		a, ok := resp.Answers[title]
+		return a, ok
+	}
+	for q, a := range resp.Answers {
+		if strings.EqualFold(q, name) {
+			return a, true
+		}
+	}
+	return "", false
+}
+
+func setAnswer(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		switch strings.ToLower(s) {
+		case "yes", "true", "y":
+			v.SetBool(true)
+		case "no", "false", "n":
+			v.SetBool(false)
+		default:
+			return fmt.Errorf("invalid yes/no answer %q", s)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.Replace(s, ",", "", -1), 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.Replace(s, ",", "", -1), 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		choices := Choices(s)
+		sl := reflect.MakeSlice(v.Type(), len(choices), len(choices))
+		for i, c := range choices {
+			if err := setAnswer(sl.Index(i), c); err != nil {
+				return err
+			}
+		}
+		v.Set(sl)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
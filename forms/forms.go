@@ -0,0 +1,236 @@
+// Package forms reads the responses a Google Form collects into its
+// response sheet.
+//
+// A response sheet has a header row of "Timestamp", optionally "Email
+// Address", and one column per question titled with the question text.
+// Checkbox answers hold the chosen options joined by ", ", and grid
+// questions get one column per row titled "Question [Row]".
+package forms
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// DefaultTab is the tab name Forms gives the first response sheet.
+const DefaultTab = "Form Responses 1"
+
+// Columns set by Forms itself rather than by questions.
+const (
+	TimestampColumn = "Timestamp"
+	EmailColumn     = "Email Address"
+)
+
+// Response is one submitted form.
+type Response struct {
+	// Row is the 1-based row of the response in the sheet.
+	Row       int
+	Timestamp time.Time
+	Email     string
+	// Answers maps question titles to the answers as displayed in the
+	// sheet; unanswered questions are absent.
+	Answers map[string]string
+}
+
+// Cursor remembers how far responses have been read. It can be stored as
+// JSON between runs.
+type Cursor struct {
+	Row       int       `json:"row"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Reader reads a response sheet.
+type Reader struct {
+	Sheets        *sheets.Service
+	SpreadsheetID string
+	// Tab defaults to DefaultTab.
+	Tab string
+	// Cursor is advanced by ReadNew. A nil cursor reads every response.
+	Cursor *Cursor
+
+	header []string
+	loc    *time.Location
+}
+
+// Questions returns the question titles of the form, in column order. It
+// is only known after a read.
+func (r *Reader) Questions() []string {
+	var q []string
+	for _, h := range r.header {
+		if h != TimestampColumn && h != EmailColumn && h != "" {
+			q = append(q, h)
+		}
+	}
+	return q
+}
+
+// ReadAll returns every response.
+func (r *Reader) ReadAll(ctx context.Context) ([]*Response, error) {
+	return r.read(ctx, 2)
+}
+
+// ReadNew returns the responses submitted after the cursor and advances
+// it. Responses are appended at the bottom, so normally only the rows
+// after the cursor are read; if rows above it were deleted, the whole sheet
+// is read again and filtered by timestamp.
+func (r *Reader) ReadNew(ctx context.Context) ([]*Response, error) {
+	if r.Cursor == nil {
+		r.Cursor = &Cursor{}
+	}
+	c := *r.Cursor
+	from := 2
+	if c.Row >= 2 {
+		from = c.Row
+	}
+	resps, err := r.read(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	if c.Row >= 2 && (len(resps) == 0 || resps[0].Row != c.Row || !resps[0].Timestamp.Equal(c.Timestamp)) {
+		// The last seen response moved.
+		if resps, err = r.read(ctx, 2); err != nil {
+			return nil, err
+		}
+	}
+	var out []*Response
+	for _, resp := range resps {
+		if resp.Timestamp.After(c.Timestamp) {
+			out = append(out, resp)
+		}
+	}
+	if len(resps) > 0 {
+		last := resps[len(resps)-1]
+		r.Cursor.Row, r.Cursor.Timestamp = last.Row, last.Timestamp
+	}
+	return out, nil
+}
+
+// read returns the responses from the given 1-based row on.
+func (r *Reader) read(ctx context.Context, from int) ([]*Response, error) {
+	tab := r.Tab
+	if tab == "" {
+		tab = DefaultTab
+	}
+	if r.loc == nil {
+		ss, err := r.Sheets.Spreadsheets.Get(r.SpreadsheetID).Fields("properties.timeZone").Context(ctx).Do()
+		if err != nil {
+			return nil, fmt.Errorf("forms: unable to get spreadsheet: %w", err)
+		}
+		if r.loc, err = time.LoadLocation(ss.Properties.TimeZone); err != nil {
+			r.loc = time.UTC
+		}
+	}
+	q := sheetsclient.QuoteTab(tab)
+	head, err := r.Sheets.Spreadsheets.Values.Get(r.SpreadsheetID, q+"!1:1").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("forms: unable to read tab %q: %w", tab, err)
+	}
+	if len(head.Values) == 0 {
+		return nil, fmt.Errorf("forms: tab %q has no header row", tab)
+	}
+	r.header = toStrings(head.Values[0])
+	tsCol, emailCol := indexOf(r.header, TimestampColumn), indexOf(r.header, EmailColumn)
+	if tsCol < 0 {
+		return nil, fmt.Errorf("forms: tab %q has no %s column", tab, TimestampColumn)
+	}
+	formatted, err := r.Sheets.Spreadsheets.Values.Get(r.SpreadsheetID,
+		fmt.Sprintf("%s!A%d:%s", q, from, sheetsclient.ColumnLetters(len(r.header)))).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("forms: unable to read responses: %w", err)
+	}
+	// Timestamps are read again as serial numbers, which unlike the
+	// displayed values do not depend on the locale of the spreadsheet.
+	col := sheetsclient.ColumnLetters(tsCol + 1)
+	serials, err := r.Sheets.Spreadsheets.Values.Get(r.SpreadsheetID, fmt.Sprintf("%s!%s%d:%s", q, col, from, col)).
+		ValueRenderOption("UNFORMATTED_VALUE").DateTimeRenderOption("SERIAL_NUMBER").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("forms: unable to read timestamps: %w", err)
+	}
+
+	var out []*Response
+	for i, v := range formatted.Values {
+		row := toStrings(v)
+		if len(strings.Join(row, "")) == 0 {
+			continue
+		}
+		resp := &Response{Row: from + i, Answers: map[string]string{}}
+		if i < len(serials.Values) && len(serials.Values[i]) > 0 {
+			if f, ok := serials.Values[i][0].(float64); ok {
+				resp.Timestamp = serialTime(f, r.loc)
+			}
+		}
+		for j, h := range r.header {
+			if j >= len(row) || row[j] == "" {
+				continue
+			}
+			switch j {
+			case tsCol:
+			case emailCol:
+				resp.Email = row[j]
+			default:
+				resp.Answers[h] = row[j]
+			}
+		}
+		out = append(out, resp)
+	}
+	return out, nil
+}
+
+// serialTime converts a spreadsheet serial date, days since 1899-12-30 in
+// the spreadsheet's time zone, to a time.
+func serialTime(serial float64, loc *time.Location) time.Time {
+	days := math.Floor(serial)
+	secs := math.Round((serial - days) * 86400)
+	return time.Date(1899, 12, 30, 0, 0, 0, 0, loc).AddDate(0, 0, int(days)).Add(time.Duration(secs) * time.Second)
+}
+
+// Choices splits a checkbox answer into the selected options.
+func Choices(answer string) []string {
+	if answer == "" {
+		return nil
+	}
+	return strings.Split(answer, ", ")
+}
+
+// Grid returns the answers of a grid question, keyed by grid row.
+func (resp *Response) Grid(question string) map[string]string {
+	prefix := question + " ["
+	out := map[string]string{}
+	for h, a := range resp.Answers {
+		if strings.HasPrefix(h, prefix) && strings.HasSuffix(h, "]") {
+			out[h[len(prefix):len(h)-1]] = a
+		}
+	}
+	return out
+}
+
+// columnName converts a 0-based column index to its A1 letters.
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func toStrings(row []interface{}) []string {
+	out := make([]string, len(row))
+	for i, v := range row {
+		if f, ok := v.(float64); ok {
+			out[i] = strconv.FormatFloat(f, 'f', -1, 64)
+			continue
+		}
+		out[i] = fmt.Sprint(v)
+	}
+	return out
+}
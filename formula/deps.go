@@ -0,0 +1,226 @@
+package formula
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Graph is the dependency graph of the formulas of a workbook.
+type Graph struct {
+	Cells []*Node `json:"cells"`
+	// Cycles lists groups of cells that depend on each other.
+	Cycles [][]string `json:"cycles,omitempty"`
+	Broken []*Problem `json:"broken,omitempty"`
+}
+
+// Node is a formula cell and the ranges it reads.
+type Node struct {
+	Cell    string   `json:"cell"`
+	Formula string   `json:"formula"`
+	Refs    []string `json:"refs,omitempty"`
+	Names   []string `json:"names,omitempty"`
+	// CrossTab is set when the formula reads other tabs.
+	CrossTab bool `json:"crossTab,omitempty"`
+
+	tab   string
+	col   int
+	row   int
+	refs  []Ref
+	edges []*Node
+}
+
+// Problem is a formula that cannot work as written.
+type Problem struct {
+	Cell    string `json:"cell"`
+	Formula string `json:"formula"`
+	Reason  string `json:"reason"`
+}
+
+// Analyze parses every formula of w. Names not in namedRanges are
+// reported as broken; pass nil to skip that check.
+func Analyze(w *Workbook, namedRanges []string) *Graph {
+	known := map[string]bool{}
+	for _, n := range namedRanges {
+		known[strings.ToUpper(n)] = true
+	}
+	tabs := make([]string, 0, len(w.tabs))
+	for tab := range w.tabs {
+		tabs = append(tabs, tab)
+	}
+	sort.Strings(tabs)
+
+	g := &Graph{}
+	byTab := map[string][]*Node{}
+	for _, tab := range tabs {
+		for r, row := range w.tabs[tab] {
+			for c, raw := range row {
+				s, ok := raw.(string)
+				if !ok || !strings.HasPrefix(s, "=") {
+					continue
+				}
+				n := &Node{Cell: Ref{Tab: tab, Range: Range{c, r, c, r}}.String(), Formula: s, tab: tab, col: c, row: r}
+				g.Cells = append(g.Cells, n)
+				byTab[tab] = append(byTab[tab], n)
+				problem := func(format string, args ...interface{}) {
+					g.Broken = append(g.Broken, &Problem{Cell: n.Cell, Formula: s, Reason: fmt.Sprintf(format, args...)})
+				}
+
+				e, err := Parse(s)
+				if err != nil {
+					problem("%v", err)
+					continue
+				}
+				Walk(e, func(e Expr) {
+					switch e := e.(type) {
+					case Ref:
+						if e.Tab == "" {
+							e.Tab = tab
+						}
+						n.refs = append(n.refs, e)
+						n.Refs = append(n.Refs, e.String())
+						if e.Tab != tab {
+							n.CrossTab = true
+						}
+						if _, ok := w.tabs[e.Tab]; !ok {
+							problem("references missing tab %q", e.Tab)
+						}
+					case Name:
+						n.Names = append(n.Names, string(e))
+						if namedRanges != nil && !known[strings.ToUpper(string(e))] {
+							problem("unknown named range %s", e)
+						}
+					case ErrorLit:
+						if Error(e) == ErrRef {
+							problem("references a deleted range")
+						}
+					}
+				})
+			}
+		}
+	}
+
+	for _, n := range g.Cells {
+		for _, ref := range n.refs {
+			for _, m := range byTab[ref.Tab] {
+				if ref.Contains(m.col, m.row) {
+					n.edges = append(n.edges, m)
+				}
+			}
+		}
+	}
+	g.Cycles = cycles(g.Cells)
+	return g
+}
+
+// Walk calls fn for e and every expression nested in it.
+func Walk(e Expr, fn func(Expr)) {
+	if e == nil {
+		return
+	}
+	fn(e)
+	switch e := e.(type) {
+	case Unary:
+		Walk(e.X, fn)
+	case Binary:
+		Walk(e.L, fn)
+		Walk(e.R, fn)
+	case Call:
+		for _, a := range e.Args {
+			Walk(a, fn)
+		}
+	}
+}
+
+// cycles finds the strongly connected components of the graph with more
+// than one cell, or a cell reading itself, using Tarjan's algorithm.
+func cycles(nodes []*Node) [][]string {
+	index := map[*Node]int{}
+	low := map[*Node]int{}
+	onStack := map[*Node]bool{}
+	var stack []*Node
+	var out [][]string
+
+	var visit func(n *Node)
+	visit = func(n *Node) {
+		index[n] = len(index)
+		low[n] = index[n]
+		stack = append(stack, n)
+		onStack[n] = true
+		self := false
+		for _, m := range n.edges {
+			if m == n {
+				self = true
+			}
+			if _, seen := index[m]; !seen {
+				visit(m)
+				if low[m] < low[n] {
+					low[n] = low[m]
+				}
+			} else if onStack[m] && index[m] < low[n] {
+				low[n] = index[m]
+			}
+		}
+		if low[n] != index[n] {
+			return
+		}
+		var scc []string
+		for {
+			m := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[m] = false
+			scc = append(scc, m.Cell)
+			if m == n {
+				break
+			}
+		}
+		if len(scc) > 1 || self {
+			sort.Strings(scc)
+			out = append(out, scc)
+		}
+	}
+	for _, n := range nodes {
+		if _, seen := index[n]; !seen {
+			visit(n)
+		}
+	}
+	return out
+}
+
+// WriteDOT writes g in Graphviz format, with an edge from every range to
+// the formulas reading it. Cross-tab edges are dashed, broken and circular
+// cells red.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	bad := map[string]bool{}
+	for _, p := range g.Broken {
+		bad[p.Cell] = true
+	}
+	for _, c := range g.Cycles {
+		for _, cell := range c {
+			bad[cell] = true
+		}
+	}
+	var b strings.Builder
+	b.WriteString("digraph formulas {\n\trankdir=LR;\n\tnode [shape=box];\n")
+	for _, n := range g.Cells {
+		attrs := fmt.Sprintf("tooltip=%q", n.Formula)
+		if bad[n.Cell] {
+			attrs += ", color=red"
+		}
+		fmt.Fprintf(&b, "\t%q [%s];\n", n.Cell, attrs)
+		for i, ref := range n.Refs {
+			style := ""
+			if n.refs[i].Tab != n.tab {
+				style = " [style=dashed]"
+			}
+			fmt.Fprintf(&b, "\t%q -> %q%s;\n", ref, n.Cell, style)
+		}
+		for _, name := range n.Names {
+			fmt.Fprintf(&b, "\t%q -> %q;\n", name, n.Cell)
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
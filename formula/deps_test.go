@@ -0,0 +1,88 @@
+package formula
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetstest"
+)
+
+func TestAnalyze(t *testing.T) {
+	ctx := context.Background()
+	srv := sheetstest.NewServer()
+	defer srv.Close()
+	srv.Seed(&sheetstest.Spreadsheet{ID: "s", Tabs: []*sheetstest.Tab{
+		{Title: "Data", Rows: [][]interface{}{
+			{1.0, "=A1*2", "=C2", "=Rate*A1"},
+			{"=Other!A1", "=SUM(A1:A)", "=C1"},
+			{"=#REF!+1", "=1+"},
+		}},
+		{Title: "Other", Rows: [][]interface{}{{"=Data!B1"}}},
+	}})
+	svc, err := srv.SheetsService(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := Load(ctx, svc, "s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := Analyze(w, []string{"rate"})
+
+	cells := map[string]*Node{}
+	for _, n := range g.Cells {
+		cells[n.Cell] = n
+	}
+	if len(cells) != 9 {
+		t.Errorf("found %d formula cells, want 9", len(cells))
+	}
+	if n := cells["'Data'!B2"]; n == nil || !reflect.DeepEqual(n.Refs, []string{"'Data'!A1:A"}) || n.CrossTab {
+		t.Errorf("Data!B2 = %+v, want a same-tab ref to A1:A", n)
+	}
+	if n := cells["'Data'!A2"]; n == nil || !n.CrossTab {
+		t.Errorf("Data!A2 = %+v, want a cross-tab formula", n)
+	}
+	if n := cells["'Data'!D1"]; n == nil || !reflect.DeepEqual(n.Names, []string{"Rate"}) {
+		t.Errorf("Data!D1 = %+v, want the name Rate", n)
+	}
+
+	// C1 and C2 read each other; B2 reads A2 reads Other!A1 reads B1,
+	// which closes no loop.
+	if want := [][]string{{"'Data'!C1", "'Data'!C2"}}; !reflect.DeepEqual(g.Cycles, want) {
+		t.Errorf("cycles = %v, want %v", g.Cycles, want)
+	}
+
+	broken := map[string]string{}
+	for _, p := range g.Broken {
+		broken[p.Cell] = p.Reason
+	}
+	if len(broken) != 2 {
+		t.Errorf("broken = %v, want Data!A3 and Data!B3", broken)
+	}
+	if r := broken["'Data'!A3"]; r != "references a deleted range" {
+		t.Errorf("Data!A3 broken because %q", r)
+	}
+	if r := broken["'Data'!B3"]; !strings.Contains(r, "unexpected end of formula") {
+		t.Errorf("Data!B3 broken because %q", r)
+	}
+	if g := Analyze(w, []string{}); len(g.Broken) != 3 {
+		t.Errorf("without named ranges, %d broken cells, want 3", len(g.Broken))
+	}
+
+	var b strings.Builder
+	if err := g.WriteDOT(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"'Data'!B1" -> "'Other'!A1" [style=dashed];`,
+		`"'Data'!C1" [tooltip="=C2", color=red];`,
+		`"Rate" -> "'Data'!D1";`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("DOT output lacks %s:\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,442 @@
+package formula
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Value is the result of evaluating a cell: float64, string, bool, nil
+// for an empty cell, or an Error. Ranges passed to functions evaluate to
+// *Area.
+type Value interface{}
+
+// Error is a spreadsheet error value such as #N/A. It is a value like any
+// other: it propagates through formulas rather than aborting evaluation.
+type Error string
+
+// Error values.
+const (
+	ErrDiv0  Error = "#DIV/0!"
+	ErrValue Error = "#VALUE!"
+	ErrRef   Error = "#REF!"
+	ErrNA    Error = "#N/A"
+	ErrName  Error = "#NAME?"
+	ErrNum   Error = "#NUM!"
+)
+
+func (e Error) Error() string { return string(e) }
+
+// Area is the value of a multi-cell range, by row.
+type Area struct {
+	Rows [][]Value
+}
+
+// UnsupportedError is returned when a formula uses a function outside of
+// the supported subset, so the result cannot be predicted offline.
+type UnsupportedError struct {
+	Func string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("formula: unsupported function %s", e.Func)
+}
+
+// Workbook evaluates formulas over a snapshot of tab values, as returned
+// with the FORMULA value render option: strings starting with "=" are
+// formulas, everything else literal values. Results are cached until the
+// workbook is modified with Set.
+type Workbook struct {
+	tabs       map[string][][]interface{}
+	values     map[cellKey]Value
+	evaluating map[cellKey]bool
+	err        error
+}
+
+type cellKey struct {
+	tab      string
+	col, row int
+}
+
+// NewWorkbook returns a workbook over tabs, keyed by tab name.
+func NewWorkbook(tabs map[string][][]interface{}) *Workbook {
+	if tabs == nil {
+		tabs = map[string][][]interface{}{}
+	}
+	return &Workbook{tabs: tabs, values: map[cellKey]Value{}, evaluating: map[cellKey]bool{}}
+}
+
+// Tabs returns the raw values of the snapshot.
+func (w *Workbook) Tabs() map[string][][]interface{} { return w.tabs }
+
+// Set changes a cell, e.g. Set("Data", "C2", "=A2*B2"), to predict the
+// effect of a write.
+func (w *Workbook) Set(tab, cell string, v interface{}) error {
+	col, row, err := ParseCell(cell)
+	if err != nil {
+		return err
+	}
+	rows := w.tabs[tab]
+	for len(rows) <= row {
+		rows = append(rows, nil)
+	}
+	for len(rows[row]) <= col {
+		rows[row] = append(rows[row], "")
+	}
+	rows[row][col] = v
+	w.tabs[tab] = rows
+	w.values = map[cellKey]Value{}
+	return nil
+}
+
+// Value returns the evaluated value of a cell such as "B3".
+func (w *Workbook) Value(tab, cell string) (Value, error) {
+	col, row, err := ParseCell(cell)
+	if err != nil {
+		return nil, err
+	}
+	w.err = nil
+	v := w.cell(tab, col, row)
+	return v, w.err
+}
+
+// Eval evaluates formula as if it were entered on tab.
+func (w *Workbook) Eval(tab, formula string) (Value, error) {
+	e, err := Parse(formula)
+	if err != nil {
+		return nil, err
+	}
+	w.err = nil
+	v := w.scalar(tab, e)
+	return v, w.err
+}
+
+// EvalTab returns the evaluated values of every cell of tab.
+func (w *Workbook) EvalTab(tab string) ([][]Value, error) {
+	rows, ok := w.tabs[tab]
+	if !ok {
+		return nil, fmt.Errorf("formula: no tab named %q", tab)
+	}
+	w.err = nil
+	out := make([][]Value, len(rows))
+	for r, row := range rows {
+		out[r] = make([]Value, len(row))
+		for c := range row {
+			out[r][c] = w.cell(tab, c, r)
+			if w.err != nil {
+				return nil, fmt.Errorf("formula: unable to evaluate %s!%s%d: %w", tab, ColumnName(c), r+1, w.err)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (w *Workbook) cell(tab string, col, row int) Value {
+	rows, ok := w.tabs[tab]
+	if !ok {
+		return ErrRef
+	}
+	if row >= len(rows) || col >= len(rows[row]) {
+		return nil
+	}
+	raw := rows[row][col]
+	s, isString := raw.(string)
+	if !isString || !strings.HasPrefix(s, "=") {
+		if isString && s == "" {
+			return nil
+		}
+		return raw
+	}
+
+	k := cellKey{tab, col, row}
+	if v, ok := w.values[k]; ok {
+		return v
+	}
+	if w.evaluating[k] {
+		// A circular reference, which Sheets reports as #REF!.
+		return ErrRef
+	}
+	w.evaluating[k] = true
+	defer delete(w.evaluating, k)
+	e, err := Parse(s)
+	var v Value
+	if err != nil {
+		v = ErrName
+	} else {
+		v = w.scalar(tab, e)
+	}
+	if w.err == nil {
+		w.values[k] = v
+	}
+	return v
+}
+
+// area returns the values of r, clipped to the data of its tab.
+func (w *Workbook) area(tab string, r Ref) Value {
+	if r.Tab != "" {
+		tab = r.Tab
+	}
+	rows, ok := w.tabs[tab]
+	if !ok {
+		return ErrRef
+	}
+	if r.Cell() {
+		return w.cell(tab, r.Col1, r.Row1)
+	}
+	r1, r2, c1, c2 := r.Row1, r.Row2, r.Col1, r.Col2
+	if r1 < 0 {
+		r1 = 0
+	}
+	if r2 < 0 {
+		r2 = len(rows) - 1
+	}
+	if c1 < 0 {
+		c1, c2 = 0, 0
+		for _, row := range rows {
+			if len(row)-1 > c2 {
+				c2 = len(row) - 1
+			}
+		}
+	}
+	a := &Area{}
+	for i := r1; i <= r2; i++ {
+		row := make([]Value, 0, c2-c1+1)
+		for j := c1; j <= c2; j++ {
+			row = append(row, w.cell(tab, j, i))
+		}
+		a.Rows = append(a.Rows, row)
+	}
+	return a
+}
+
+// eval evaluates e, leaving ranges as areas.
+func (w *Workbook) eval(tab string, e Expr) Value {
+	switch e := e.(type) {
+	case nil:
+		return nil
+	case Number:
+		return float64(e)
+	case String:
+		return string(e)
+	case Bool:
+		return bool(e)
+	case Name:
+		return ErrName
+	case ErrorLit:
+		return Error(e)
+	case Ref:
+		return w.area(tab, e)
+	case Unary:
+		x := toNumber(w.scalar(tab, e.X))
+		if err, ok := x.(Error); ok {
+			return err
+		}
+		switch e.Op {
+		case "-":
+			return -x.(float64)
+		case "%":
+			return x.(float64) / 100
+		}
+		return x
+	case Binary:
+		return w.binary(tab, e)
+	case Call:
+		fn, ok := functions[e.Func]
+		if !ok {
+			if w.err == nil {
+				w.err = &UnsupportedError{Func: e.Func}
+			}
+			return ErrName
+		}
+		return fn(w, tab, e.Args)
+	}
+	return ErrValue
+}
+
+// scalar evaluates e to a single value.
+func (w *Workbook) scalar(tab string, e Expr) Value {
+	v := w.eval(tab, e)
+	if a, ok := v.(*Area); ok {
+		if len(a.Rows) == 1 && len(a.Rows[0]) == 1 {
+			return a.Rows[0][0]
+		}
+		return ErrValue
+	}
+	return v
+}
+
+func (w *Workbook) binary(tab string, e Binary) Value {
+	l, r := w.scalar(tab, e.L), w.scalar(tab, e.R)
+	if err, ok := l.(Error); ok {
+		return err
+	}
+	if err, ok := r.(Error); ok {
+		return err
+	}
+	switch e.Op {
+	case "&":
+		return toText(l) + toText(r)
+	case "=":
+		return compare(l, r) == 0
+	case "<>":
+		return compare(l, r) != 0
+	case "<":
+		return compare(l, r) < 0
+	case ">":
+		return compare(l, r) > 0
+	case "<=":
+		return compare(l, r) <= 0
+	case ">=":
+		return compare(l, r) >= 0
+	}
+
+	ln, rn := toNumber(l), toNumber(r)
+	if err, ok := ln.(Error); ok {
+		return err
+	}
+	if err, ok := rn.(Error); ok {
+		return err
+	}
+	a, b := ln.(float64), rn.(float64)
+	switch e.Op {
+	case "+":
+		return a + b
+	case "-":
+		return a - b
+	case "*":
+		return a * b
+	case "/":
+		if b == 0 {
+			return ErrDiv0
+		}
+		return a / b
+	case "^":
+		v := math.Pow(a, b)
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return ErrNum
+		}
+		return v
+	}
+	return ErrValue
+}
+
+// toNumber converts v to a float64, or returns an Error.
+func toNumber(v Value) Value {
+	switch v := v.(type) {
+	case nil:
+		return 0.0
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case bool:
+		if v {
+			return 1.0
+		}
+		return 0.0
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return ErrValue
+		}
+		return f
+	case Error:
+		return v
+	}
+	return ErrValue
+}
+
+// toBool converts v to a bool, or returns an Error.
+func toBool(v Value) Value {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case string:
+		switch strings.ToUpper(v) {
+		case "TRUE":
+			return true
+		case "FALSE", "":
+			return false
+		}
+		return ErrValue
+	case Error:
+		return v
+	}
+	n := toNumber(v)
+	if f, ok := n.(float64); ok {
+		return f != 0
+	}
+	return n
+}
+
+// toText formats v the way concatenation does.
+func toText(v Value) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+	return fmt.Sprint(v)
+}
+
+// compare orders values as Sheets does: numbers before text before
+// booleans, with text compared case-insensitively. An empty cell equals
+// 0, "" and FALSE.
+func compare(a, b Value) int {
+	rank := func(v Value) int {
+		switch v.(type) {
+		case string:
+			return 1
+		case bool:
+			return 2
+		}
+		return 0
+	}
+	if a == nil {
+		a = zeroLike(b)
+	}
+	if b == nil {
+		b = zeroLike(a)
+	}
+	if ra, rb := rank(a), rank(b); ra != rb {
+		return ra - rb
+	}
+	switch a := a.(type) {
+	case string:
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b.(string)))
+	case bool:
+		switch {
+		case a == b.(bool):
+			return 0
+		case b.(bool):
+			return -1
+		}
+		return 1
+	}
+	x, _ := toNumber(a).(float64)
+	y, _ := toNumber(b).(float64)
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+func zeroLike(v Value) Value {
+	switch v.(type) {
+	case string:
+		return ""
+	case bool:
+		return false
+	}
+	return 0.0
+}
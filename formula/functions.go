@@ -0,0 +1,340 @@
+package formula
+
+import (
+	"math"
+	"strings"
+)
+
+// function evaluates a call given its unevaluated arguments, so IF and
+// IFERROR only evaluate the branch they pick.
+type function func(w *Workbook, tab string, args []Expr) Value
+
+var functions map[string]function
+
+func init() {
+	// Assigned in init to break the initialization cycle through eval.
+	functions = map[string]function{
+		"IF":          fnIf,
+		"IFERROR":     fnIfError,
+		"AND":         fnAnd,
+		"OR":          fnOr,
+		"NOT":         fnNot,
+		"SUM":         aggregate(func(ns []float64) Value { return sum(ns) }),
+		"AVERAGE":     aggregate(average),
+		"MIN":         aggregate(minimum),
+		"MAX":         aggregate(maximum),
+		"COUNT":       fnCount,
+		"COUNTA":      fnCountA,
+		"ROUND":       fnRound,
+		"ABS":         fnAbs,
+		"VLOOKUP":     fnVLookup,
+		"CONCATENATE": fnConcatenate,
+		"CONCAT":      fnConcatenate,
+		"LEN":         text(func(s string) Value { return float64(len([]rune(s))) }),
+		"UPPER":       text(func(s string) Value { return strings.ToUpper(s) }),
+		"LOWER":       text(func(s string) Value { return strings.ToLower(s) }),
+		"TRIM":        text(func(s string) Value { return strings.Join(strings.Fields(s), " ") }),
+	}
+}
+
+func fnIf(w *Workbook, tab string, args []Expr) Value {
+	if len(args) < 2 || len(args) > 3 {
+		return ErrNA
+	}
+	cond := toBool(w.scalar(tab, args[0]))
+	if err, ok := cond.(Error); ok {
+		return err
+	}
+	if cond.(bool) {
+		return w.scalar(tab, args[1])
+	}
+	if len(args) == 3 {
+		return w.scalar(tab, args[2])
+	}
+	return false
+}
+
+func fnIfError(w *Workbook, tab string, args []Expr) Value {
+	if len(args) < 1 || len(args) > 2 {
+		return ErrNA
+	}
+	v := w.scalar(tab, args[0])
+	if _, ok := v.(Error); ok {
+		if len(args) == 2 {
+			return w.scalar(tab, args[1])
+		}
+		return nil
+	}
+	return v
+}
+
+func fnAnd(w *Workbook, tab string, args []Expr) Value { return logical(w, tab, args, true) }
+func fnOr(w *Workbook, tab string, args []Expr) Value  { return logical(w, tab, args, false) }
+
+func logical(w *Workbook, tab string, args []Expr, and bool) Value {
+	if len(args) == 0 {
+		return ErrNA
+	}
+	result := and
+	for _, v := range values(w, tab, args) {
+		if v == nil {
+			continue
+		}
+		b := toBool(v)
+		if err, ok := b.(Error); ok {
+			return err
+		}
+		if and {
+			result = result && b.(bool)
+		} else {
+			result = result || b.(bool)
+		}
+	}
+	return result
+}
+
+func fnNot(w *Workbook, tab string, args []Expr) Value {
+	if len(args) != 1 {
+		return ErrNA
+	}
+	b := toBool(w.scalar(tab, args[0]))
+	if err, ok := b.(Error); ok {
+		return err
+	}
+	return !b.(bool)
+}
+
+// values evaluates args, flattening ranges.
+func values(w *Workbook, tab string, args []Expr) []Value {
+	var out []Value
+	for _, arg := range args {
+		v := w.eval(tab, arg)
+		if a, ok := v.(*Area); ok {
+			for _, row := range a.Rows {
+				out = append(out, row...)
+			}
+			continue
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// numbers collects the numeric arguments of an aggregate. Text and
+// booleans inside ranges are skipped; arguments given directly must be
+// convertible to numbers.
+func numbers(w *Workbook, tab string, args []Expr) ([]float64, Value) {
+	var out []float64
+	for _, arg := range args {
+		v := w.eval(tab, arg)
+		if a, ok := v.(*Area); ok {
+			for _, row := range a.Rows {
+				for _, c := range row {
+					switch c := c.(type) {
+					case float64:
+						out = append(out, c)
+					case Error:
+						return nil, c
+					}
+				}
+			}
+			continue
+		}
+		if _, isRef := arg.(Ref); isRef && v == nil {
+			continue
+		}
+		n := toNumber(v)
+		if err, ok := n.(Error); ok {
+			return nil, err
+		}
+		out = append(out, n.(float64))
+	}
+	return out, nil
+}
+
+func aggregate(fn func([]float64) Value) function {
+	return func(w *Workbook, tab string, args []Expr) Value {
+		ns, err := numbers(w, tab, args)
+		if err != nil {
+			return err
+		}
+		return fn(ns)
+	}
+}
+
+func sum(ns []float64) float64 {
+	total := 0.0
+	for _, n := range ns {
+		total += n
+	}
+	return total
+}
+
+func average(ns []float64) Value {
+	if len(ns) == 0 {
+		return ErrDiv0
+	}
+	return sum(ns) / float64(len(ns))
+}
+
+func minimum(ns []float64) Value {
+	if len(ns) == 0 {
+		return 0.0
+	}
+	m := ns[0]
+	for _, n := range ns[1:] {
+		m = math.Min(m, n)
+	}
+	return m
+}
+
+func maximum(ns []float64) Value {
+	if len(ns) == 0 {
+		return 0.0
+	}
+	m := ns[0]
+	for _, n := range ns[1:] {
+		m = math.Max(m, n)
+	}
+	return m
+}
+
+func fnCount(w *Workbook, tab string, args []Expr) Value {
+	n := 0.0
+	for _, v := range values(w, tab, args) {
+		if _, ok := v.(float64); ok {
+			n++
+		}
+	}
+	return n
+}
+
+func fnCountA(w *Workbook, tab string, args []Expr) Value {
+	n := 0.0
+	for _, v := range values(w, tab, args) {
+		if v != nil {
+			n++
+		}
+	}
+	return n
+}
+
+func fnRound(w *Workbook, tab string, args []Expr) Value {
+	if len(args) < 1 || len(args) > 2 {
+		return ErrNA
+	}
+	x := toNumber(w.scalar(tab, args[0]))
+	if err, ok := x.(Error); ok {
+		return err
+	}
+	places := Value(0.0)
+	if len(args) == 2 {
+		if places = toNumber(w.scalar(tab, args[1])); places == nil {
+			places = 0.0
+		}
+		if err, ok := places.(Error); ok {
+			return err
+		}
+	}
+	p := math.Pow(10, math.Trunc(places.(float64)))
+	// Sheets rounds halves away from zero.
+	return math.Round(x.(float64)*p) / p
+}
+
+func fnAbs(w *Workbook, tab string, args []Expr) Value {
+	if len(args) != 1 {
+		return ErrNA
+	}
+	x := toNumber(w.scalar(tab, args[0]))
+	if err, ok := x.(Error); ok {
+		return err
+	}
+	return math.Abs(x.(float64))
+}
+
+// fnVLookup implements VLOOKUP(key, range, index, [is_sorted]). As in
+// Sheets, is_sorted defaults to TRUE, which finds the last row whose first
+// column is at most key.
+func fnVLookup(w *Workbook, tab string, args []Expr) Value {
+	if len(args) < 3 || len(args) > 4 {
+		return ErrNA
+	}
+	key := w.scalar(tab, args[0])
+	if err, ok := key.(Error); ok {
+		return err
+	}
+	a, ok := w.eval(tab, args[1]).(*Area)
+	if !ok {
+		return ErrValue
+	}
+	idx := toNumber(w.scalar(tab, args[2]))
+	if err, ok := idx.(Error); ok {
+		return err
+	}
+	i := int(idx.(float64))
+	if i < 1 {
+		return ErrValue
+	}
+	sorted := true
+	if len(args) == 4 && args[3] != nil {
+		b := toBool(w.scalar(tab, args[3]))
+		if err, ok := b.(Error); ok {
+			return err
+		}
+		sorted = b.(bool)
+	}
+
+	match := -1
+	for r, row := range a.Rows {
+		if len(row) == 0 {
+			continue
+		}
+		c := compare(row[0], key)
+		if !sorted {
+			if c == 0 {
+				match = r
+				break
+			}
+			continue
+		}
+		if c > 0 {
+			break
+		}
+		match = r
+	}
+	if match < 0 {
+		return ErrNA
+	}
+	row := a.Rows[match]
+	if i > len(row) {
+		if w := len(a.Rows[0]); i > w {
+			return ErrRef
+		}
+		return nil
+	}
+	return row[i-1]
+}
+
+func fnConcatenate(w *Workbook, tab string, args []Expr) Value {
+	var b strings.Builder
+	for _, v := range values(w, tab, args) {
+		if err, ok := v.(Error); ok {
+			return err
+		}
+		b.WriteString(toText(v))
+	}
+	return b.String()
+}
+
+func text(fn func(string) Value) function {
+	return func(w *Workbook, tab string, args []Expr) Value {
+		if len(args) != 1 {
+			return ErrNA
+		}
+		v := w.scalar(tab, args[0])
+		if err, ok := v.(Error); ok {
+			return err
+		}
+		return fn(toText(v))
+	}
+}
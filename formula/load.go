@@ -0,0 +1,38 @@
+package formula
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// Load snapshots the formulas and values of the given tabs, or of every
+// tab if none are given.
+func Load(ctx context.Context, srv *sheets.Service, spreadsheetID string, tabs ...string) (*Workbook, error) {
+	if len(tabs) == 0 {
+		resp, err := srv.Spreadsheets.Get(spreadsheetID).Fields("sheets.properties.title").Context(ctx).Do()
+		if err != nil {
+			return nil, fmt.Errorf("formula: unable to get tabs: %w", err)
+		}
+		for _, sh := range resp.Sheets {
+			tabs = append(tabs, sh.Properties.Title)
+		}
+	}
+	ranges := make([]string, len(tabs))
+	for i, tab := range tabs {
+		ranges[i] = sheetsclient.QuoteTab(tab)
+	}
+	resp, err := srv.Spreadsheets.Values.BatchGet(spreadsheetID).Ranges(ranges...).
+		ValueRenderOption("FORMULA").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("formula: unable to read tabs: %w", err)
+	}
+	data := map[string][][]interface{}{}
+	for i, vr := range resp.ValueRanges {
+		data[tabs[i]] = vr.Values
+	}
+	return NewWorkbook(data), nil
+}
@@ -0,0 +1,71 @@
+package formula
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetstest"
+)
+
+func TestLoad(t *testing.T) {
+	ctx := context.Background()
+	srv := sheetstest.NewServer()
+	defer srv.Close()
+	srv.Seed(&sheetstest.Spreadsheet{ID: "s", Tabs: []*sheetstest.Tab{
+		{Title: "Orders", Rows: [][]interface{}{
+			{"Item", "Qty", "Price", "Total"},
+			{"a", 2.0, 1.5, "=B2*C2"},
+			{"b", 4.0, 2.0, "=B3*C3"},
+		}},
+		{Title: "My Summary", Rows: [][]interface{}{{"=SUM(Orders!D2:D)", `=IF(A1>10,"big","small")`}}},
+	}})
+	svc, err := srv.SheetsService(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := Load(ctx, svc, "s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if srv.Count("values.batchGet") != 1 {
+		t.Errorf("Load made %d batchGet requests, want 1", srv.Count("values.batchGet"))
+	}
+	if r := srv.Requests(); r[len(r)-1].Query.Get("valueRenderOption") != "FORMULA" {
+		t.Errorf("Load read values with %q, want FORMULA", r[len(r)-1].Query.Get("valueRenderOption"))
+	}
+	tests := []struct {
+		tab, cell string
+		want      Value
+	}{
+		{"Orders", "D2", 3.0},
+		{"Orders", "D3", 8.0},
+		{"My Summary", "A1", 11.0},
+		{"My Summary", "B1", "big"},
+	}
+	for _, tt := range tests {
+		v, err := w.Value(tt.tab, tt.cell)
+		if err != nil || v != tt.want {
+			t.Errorf("%s!%s = %v, %v, want %v", tt.tab, tt.cell, v, err, tt.want)
+		}
+	}
+
+	// Predict the effect of an edit without writing it.
+	if err := w.Set("Orders", "B3", 1.0); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := w.Value("My Summary", "B1"); err != nil || v != "small" {
+		t.Errorf("after the edit, My Summary!B1 = %v, %v, want small", v, err)
+	}
+	if got := srv.Values("s", "Orders")[2][1]; got != 4.0 {
+		t.Errorf("Set wrote %v to the sheet", got)
+	}
+
+	if w, err = Load(ctx, svc, "s", "Orders"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := w.Tabs()["My Summary"]; ok {
+		t.Error("Load of Orders also loaded My Summary")
+	}
+}
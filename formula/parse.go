@@ -0,0 +1,516 @@
+// Package formula parses spreadsheet formulas and evaluates a practical
+// subset of them offline, so computed columns can be predicted without
+// writing to a live sheet.
+package formula
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// Expr is a node of a parsed formula.
+type Expr interface{ expr() }
+
+type (
+	// Number is a numeric literal.
+	Number float64
+	// String is a string literal.
+	String string
+	// Bool is TRUE or FALSE.
+	Bool bool
+	// Name is a named range or an unknown identifier.
+	Name string
+	// ErrorLit is an error literal such as #REF!, which Sheets leaves in
+	// formulas whose references were deleted.
+	ErrorLit Error
+	// Unary is a prefix minus or plus, or a postfix percent.
+	Unary struct {
+		Op string
+		X  Expr
+	}
+	// Binary is an arithmetic, concatenation or comparison operator.
+	Binary struct {
+		Op   string
+		L, R Expr
+	}
+	// Call is a function call.
+	Call struct {
+		Func string
+		Args []Expr
+	}
+	// Ref is a reference to a cell or range, on Tab if it is not empty.
+	Ref struct {
+		Tab string
+		Range
+	}
+)
+
+func (Number) expr()   {}
+func (String) expr()   {}
+func (Bool) expr()     {}
+func (Name) expr()     {}
+func (ErrorLit) expr() {}
+func (Unary) expr()    {}
+func (Binary) expr()   {}
+func (Call) expr()     {}
+func (Ref) expr()      {}
+
+// Range is a zero based, inclusive block of cells. Negative indexes are
+// unbounded: whole columns such as A:B have negative rows, whole rows
+// such as 2:5 negative columns, and A2:B a negative Row2.
+type Range struct {
+	Col1, Row1, Col2, Row2 int
+}
+
+// Cell reports whether r is a single cell.
+func (r Range) Cell() bool { return r.Col1 == r.Col2 && r.Row1 == r.Row2 && r.Row1 >= 0 && r.Col1 >= 0 }
+
+// Contains reports whether the cell at col, row is in r.
+func (r Range) Contains(col, row int) bool {
+	if r.Col1 >= 0 && (col < r.Col1 || col > r.Col2) {
+		return false
+	}
+	return r.Row1 < 0 || (row >= r.Row1 && (r.Row2 < 0 || row <= r.Row2))
+}
+
+func (r Range) String() string {
+	cell := func(col, row int) string {
+		s := ""
+		if col >= 0 {
+			s = ColumnName(col)
+		}
+		if row >= 0 {
+			s += strconv.Itoa(row + 1)
+		}
+		return s
+	}
+	if r.Cell() {
+		return cell(r.Col1, r.Row1)
+	}
+	return cell(r.Col1, r.Row1) + ":" + cell(r.Col2, r.Row2)
+}
+
+func (r Ref) String() string {
+	if r.Tab == "" {
+		return r.Range.String()
+	}
+	return sheetsclient.QuoteTab(r.Tab) + "!" + r.Range.String()
+}
+
+// ColumnName returns the letters of a zero based column index.
+func ColumnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// ParseCell parses a cell such as "B3" into zero based indexes.
+func ParseCell(s string) (col, row int, err error) {
+	col, row, ok := parsePart(s)
+	if !ok || col < 0 || row < 0 {
+		return 0, 0, fmt.Errorf("formula: invalid cell %q", s)
+	}
+	return col, row, nil
+}
+
+var partRE = regexp.MustCompile(`^\$?([A-Za-z]{0,3})\$?([0-9]*)$`)
+
+// parsePart parses one end of a range: a cell, a column or a row.
+func parsePart(s string) (col, row int, ok bool) {
+	m := partRE.FindStringSubmatch(s)
+	if m == nil || (m[1] == "" && m[2] == "") {
+		return 0, 0, false
+	}
+	col, row = -1, -1
+	if m[1] != "" {
+		col = 0
+		for _, c := range strings.ToUpper(m[1]) {
+			col = col*26 + int(c-'A'+1)
+		}
+		col--
+	}
+	if m[2] != "" {
+		n, err := strconv.Atoi(m[2])
+		if err != nil || n < 1 {
+			return 0, 0, false
+		}
+		row = n - 1
+	}
+	return col, row, true
+}
+
+// Parse parses a formula, with or without its leading "=".
+// Errors give the byte offset in src of the token at fault.
+func Parse(src string) (Expr, error) {
+	trimmed := strings.TrimSpace(src)
+	p := &parser{src: strings.TrimPrefix(trimmed, "="), base: strings.Index(src, trimmed)}
+	if len(p.src) < len(trimmed) {
+		p.base++
+	}
+	p.next()
+	e := p.compare()
+	if p.err == nil && p.tok.kind != tEOF {
+		p.fail("unexpected %q", p.tok.text)
+	}
+	if p.err != nil {
+		return nil, fmt.Errorf("formula: unable to parse %q: %v", src, p.err)
+	}
+	return e, nil
+}
+
+type tokenKind int
+
+const (
+	tEOF tokenKind = iota
+	tNumber
+	tString
+	tWord
+	tFunc
+	tRef
+	tOp
+	tLParen
+	tRParen
+	tComma
+	tError
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	ref  Ref
+}
+
+type parser struct {
+	src string
+	pos int
+	// start is the offset of tok in src, and base the offset of src in
+	// the formula as given to Parse.
+	start, base int
+	tok         token
+	err         error
+}
+
+func (p *parser) fail(format string, args ...interface{}) {
+	if p.err == nil {
+		p.err = fmt.Errorf("%s at offset %d", fmt.Sprintf(format, args...), p.base+p.start)
+	}
+	p.tok = token{kind: tEOF}
+}
+
+var comparisons = map[string]bool{"=": true, "<>": true, "<": true, ">": true, "<=": true, ">=": true}
+
+func (p *parser) compare() Expr {
+	l := p.concat()
+	for p.tok.kind == tOp && comparisons[p.tok.text] {
+		op := p.tok.text
+		p.next()
+		l = Binary{Op: op, L: l, R: p.concat()}
+	}
+	return l
+}
+
+func (p *parser) concat() Expr {
+	l := p.additive()
+	for p.tok.kind == tOp && p.tok.text == "&" {
+		p.next()
+		l = Binary{Op: "&", L: l, R: p.additive()}
+	}
+	return l
+}
+
+func (p *parser) additive() Expr {
+	l := p.multiplicative()
+	for p.tok.kind == tOp && (p.tok.text == "+" || p.tok.text == "-") {
+		op := p.tok.text
+		p.next()
+		l = Binary{Op: op, L: l, R: p.multiplicative()}
+	}
+	return l
+}
+
+func (p *parser) multiplicative() Expr {
+	l := p.power()
+	for p.tok.kind == tOp && (p.tok.text == "*" || p.tok.text == "/") {
+		op := p.tok.text
+		p.next()
+		l = Binary{Op: op, L: l, R: p.power()}
+	}
+	return l
+}
+
+func (p *parser) power() Expr {
+	l := p.unary()
+	for p.tok.kind == tOp && p.tok.text == "^" {
+		p.next()
+		l = Binary{Op: "^", L: l, R: p.unary()}
+	}
+	return l
+}
+
+// unary binds tighter than ^, so -2^2 is 4 as in Sheets.
+func (p *parser) unary() Expr {
+	if p.tok.kind == tOp && (p.tok.text == "-" || p.tok.text == "+") {
+		op := p.tok.text
+		p.next()
+		return Unary{Op: op, X: p.unary()}
+	}
+	x := p.primary()
+	for p.tok.kind == tOp && p.tok.text == "%" {
+		p.next()
+		x = Unary{Op: "%", X: x}
+	}
+	return x
+}
+
+func (p *parser) primary() Expr {
+	t := p.tok
+	switch t.kind {
+	case tNumber:
+		p.next()
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			p.fail("invalid number %q", t.text)
+		}
+		return Number(f)
+	case tString:
+		p.next()
+		return String(t.text)
+	case tRef:
+		p.next()
+		return t.ref
+	case tError:
+		p.next()
+		return ErrorLit(t.text)
+	case tWord:
+		p.next()
+		switch strings.ToUpper(t.text) {
+		case "TRUE":
+			return Bool(true)
+		case "FALSE":
+			return Bool(false)
+		}
+		return Name(t.text)
+	case tFunc:
+		p.next() // the name
+		p.next() // (
+		call := Call{Func: strings.ToUpper(t.text)}
+		if p.tok.kind == tRParen {
+			p.next()
+			return call
+		}
+		for {
+			if p.tok.kind == tComma || p.tok.kind == tRParen {
+				// An omitted argument, as in IF(A1,,1).
+				call.Args = append(call.Args, nil)
+			} else {
+				call.Args = append(call.Args, p.compare())
+			}
+			if p.tok.kind == tRParen {
+				p.next()
+				return call
+			}
+			if p.tok.kind != tComma {
+				p.fail("expected , or ) in call to %s", call.Func)
+				return call
+			}
+			p.next()
+		}
+	case tLParen:
+		p.next()
+		e := p.compare()
+		if p.tok.kind != tRParen {
+			p.fail("missing )")
+		}
+		p.next()
+		return e
+	case tEOF:
+		p.fail("unexpected end of formula")
+	default:
+		p.fail("unexpected %q", t.text)
+	}
+	return nil
+}
+
+var (
+	wordRE     = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_.$]*`)
+	numberRE   = regexp.MustCompile(`^[0-9]*\.?[0-9]+([eE][-+]?[0-9]+)?`)
+	refRE      = regexp.MustCompile(`^\$?[A-Za-z]{1,3}\$?[0-9]*(:\$?[A-Za-z]{1,3}\$?[0-9]*|:\$?[0-9]+)?`)
+	errorRE    = regexp.MustCompile(`^(?i)#(REF!|N/A|NAME\?|VALUE!|DIV/0!|NUM!|NULL!|ERROR!)`)
+	rowsRE     = regexp.MustCompile(`^\$?[0-9]+:\$?[0-9]+`)
+	tabRangeRE = regexp.MustCompile(`^\$?[A-Za-z]{0,3}\$?[0-9]*(:\$?[A-Za-z]{0,3}\$?[0-9]*)?`)
+)
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '.' || c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z'
+}
+
+// next advances to the next token.
+func (p *parser) next() {
+	if p.err != nil {
+		return
+	}
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\n' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+	p.start = p.pos
+	if p.pos >= len(p.src) {
+		p.tok = token{kind: tEOF}
+		return
+	}
+	rest := p.src[p.pos:]
+	switch c := rest[0]; {
+	case c == '#':
+		m := errorRE.FindString(rest)
+		if m == "" {
+			p.fail("unexpected character %q", c)
+			return
+		}
+		p.pos += len(m)
+		p.tok = token{kind: tError, text: strings.ToUpper(m)}
+	case c == '"':
+		var b strings.Builder
+		i := 1
+		for {
+			if i >= len(rest) {
+				p.fail("unterminated string")
+				return
+			}
+			if rest[i] == '"' {
+				if i+1 < len(rest) && rest[i+1] == '"' {
+					b.WriteByte('"')
+					i += 2
+					continue
+				}
+				break
+			}
+			b.WriteByte(rest[i])
+			i++
+		}
+		p.pos += i + 1
+		p.tok = token{kind: tString, text: b.String()}
+	case c == '\'':
+		// A quoted tab name, which must be followed by a range.
+		i := 1
+		var b strings.Builder
+		for {
+			if i >= len(rest) {
+				p.fail("unterminated tab name")
+				return
+			}
+			if rest[i] == '\'' {
+				if i+1 < len(rest) && rest[i+1] == '\'' {
+					b.WriteByte('\'')
+					i += 2
+					continue
+				}
+				break
+			}
+			b.WriteByte(rest[i])
+			i++
+		}
+		if i+1 >= len(rest) || rest[i+1] != '!' {
+			p.fail("expected ! after tab name")
+			return
+		}
+		p.pos += i + 2
+		p.rangeAfterTab(b.String())
+	case c >= '0' && c <= '9' || c == '.':
+		// Row ranges such as 2:5 start like numbers.
+		if m := rowsRE.FindString(rest); m != "" {
+			p.pos += len(m)
+			p.tok = token{kind: tRef, text: m, ref: p.ref("", m)}
+			return
+		}
+		m := numberRE.FindString(rest)
+		if m == "" {
+			p.fail("invalid number")
+			return
+		}
+		p.pos += len(m)
+		p.tok = token{kind: tNumber, text: m}
+	case wordRE.MatchString(rest):
+		w := wordRE.FindString(rest)
+		after := strings.TrimLeft(rest[len(w):], " ")
+		switch {
+		case strings.HasPrefix(after, "!"):
+			p.pos += len(w) + 1
+			p.rangeAfterTab(w)
+		case strings.HasPrefix(after, "("):
+			p.pos += len(w)
+			p.tok = token{kind: tFunc, text: w}
+		default:
+			// A cell or range unless the match is the start of a longer word.
+			m := refRE.FindString(rest)
+			if end := rest[len(m):]; m != "" && (end == "" || !isWordChar(end[0])) &&
+				(strings.Contains(m, ":") || partRE.FindStringSubmatch(m)[2] != "") {
+				p.pos += len(m)
+				p.tok = token{kind: tRef, text: m, ref: p.ref("", m)}
+				return
+			}
+			p.pos += len(w)
+			p.tok = token{kind: tWord, text: w}
+		}
+	case c == '(':
+		p.pos++
+		p.tok = token{kind: tLParen, text: "("}
+	case c == ')':
+		p.pos++
+		p.tok = token{kind: tRParen, text: ")"}
+	case c == ',' || c == ';':
+		p.pos++
+		p.tok = token{kind: tComma, text: ","}
+	case strings.HasPrefix(rest, "<>") || strings.HasPrefix(rest, "<=") || strings.HasPrefix(rest, ">="):
+		p.pos += 2
+		p.tok = token{kind: tOp, text: rest[:2]}
+	case strings.IndexByte("+-*/^&=<>%", c) >= 0:
+		p.pos++
+		p.tok = token{kind: tOp, text: string(c)}
+	default:
+		p.fail("unexpected character %q", c)
+	}
+}
+
+func (p *parser) rangeAfterTab(tab string) {
+	if m := errorRE.FindString(p.src[p.pos:]); m != "" {
+		// Tab!#REF! once the referenced cells are deleted.
+		p.pos += len(m)
+		p.tok = token{kind: tError, text: strings.ToUpper(m)}
+		return
+	}
+	m := tabRangeRE.FindString(p.src[p.pos:])
+	if m == "" {
+		p.fail("expected a range after %s!", tab)
+		return
+	}
+	p.pos += len(m)
+	p.tok = token{kind: tRef, text: tab + "!" + m, ref: p.ref(tab, m)}
+}
+
+func (p *parser) ref(tab, s string) Ref {
+	parts := strings.SplitN(s, ":", 2)
+	c1, r1, ok := parsePart(parts[0])
+	if !ok || (len(parts) == 1 && (c1 < 0 || r1 < 0)) {
+		p.fail("invalid reference %q", s)
+		return Ref{}
+	}
+	c2, r2 := c1, r1
+	if len(parts) == 2 {
+		if c2, r2, ok = parsePart(parts[1]); !ok {
+			p.fail("invalid reference %q", s)
+			return Ref{}
+		}
+	}
+	if c1 > c2 && c2 >= 0 {
+		c1, c2 = c2, c1
+	}
+	if r1 > r2 && r2 >= 0 {
+		r1, r2 = r2, r1
+	}
+	return Ref{Tab: tab, Range: Range{Col1: c1, Row1: r1, Col2: c2, Row2: r2}}
+}
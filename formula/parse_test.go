@@ -0,0 +1,106 @@
+package formula
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// show prints e fully parenthesized, to check how Parse grouped it.
+func show(e Expr) string {
+	switch e := e.(type) {
+	case nil:
+		return "_"
+	case Number:
+		return fmt.Sprint(float64(e))
+	case String:
+		return fmt.Sprintf("%q", string(e))
+	case Bool:
+		return fmt.Sprint(bool(e))
+	case Name:
+		return "name:" + string(e)
+	case ErrorLit:
+		return string(e)
+	case Ref:
+		return e.String()
+	case Unary:
+		if e.Op == "%" {
+			return "(" + show(e.X) + "%)"
+		}
+		return "(" + e.Op + show(e.X) + ")"
+	case Binary:
+		return "(" + show(e.L) + " " + e.Op + " " + show(e.R) + ")"
+	case Call:
+		args := make([]string, len(e.Args))
+		for i, a := range e.Args {
+			args[i] = show(a)
+		}
+		return e.Func + "(" + strings.Join(args, ", ") + ")"
+	}
+	return fmt.Sprintf("%#v", e)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct{ src, want string }{
+		// Precedence, loosest first: comparison, &, + -, * /, ^, unary, %.
+		{"=1+2*3", "(1 + (2 * 3))"},
+		{"1*2+3", "((1 * 2) + 3)"},
+		{"1-2-3", "((1 - 2) - 3)"},
+		{"2^3^2", "((2 ^ 3) ^ 2)"},
+		{"-2^2", "((-2) ^ 2)"},
+		{"2*-3", "(2 * (-3))"},
+		{"50%*2", "((50%) * 2)"},
+		{"1+2&3", "((1 + 2) & 3)"},
+		{"A1&B1=C1", "((A1 & B1) = C1)"},
+		{"1<2=TRUE", "((1 < 2) = true)"},
+		{"A1<>B1", "(A1 <> B1)"},
+		{"(1+2)*3", "((1 + 2) * 3)"},
+		{"1e-5*2", "(1e-05 * 2)"},
+		{".5+1.5E2", "(0.5 + 150)"},
+		// Calls and references.
+		{"SUM(A1:B2, 3)", "SUM(A1:B2, 3)"},
+		{"if(A1,,1)", "IF(A1, _, 1)"},
+		{"NOW()", "NOW()"},
+		{"$A$1+B:B+2:5", "((A1 + B:B) + 2:5)"},
+		{"Data!A2:C", "'Data'!A2:C"},
+		{"Data!#REF!", "#REF!"},
+		{"Total+LOG10", "(name:Total + LOG10)"},
+		// Quoting.
+		{`"a ""b"" c"&"d"`, `("a \"b\" c" & "d")`},
+		{`'My Tab'!B2`, "'My Tab'!B2"},
+		{`'It''s'!A1:A3`, "'It''s'!A1:A3"},
+		{`"'not a tab'"`, `"'not a tab'"`},
+	}
+	for _, tt := range tests {
+		e, err := Parse(tt.src)
+		if err != nil {
+			t.Errorf("Parse(%s): %v", tt.src, err)
+			continue
+		}
+		if got := show(e); got != tt.want {
+			t.Errorf("Parse(%s) = %s, want %s", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct{ src, err string }{
+		{"=1+", "unexpected end of formula at offset 3"},
+		{"1+", "unexpected end of formula at offset 2"},
+		{"  =1 2", "unexpected \"2\" at offset 5"},
+		{"(1+2", "missing ) at offset 4"},
+		{`="abc`, "unterminated string at offset 1"},
+		{`'Tab`, "unterminated tab name at offset 0"},
+		{`'Tab'A1`, "expected ! after tab name at offset 0"},
+		{"SUM(1 2)", "expected , or ) in call to SUM at offset 6"},
+		{"1+@", "unexpected character '@' at offset 2"},
+		{"Data!", "expected a range after Data! at offset 0"},
+		{"*2", "unexpected \"*\" at offset 0"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.src)
+		if err == nil || !strings.HasSuffix(err.Error(), ": "+tt.err) {
+			t.Errorf("Parse(%s) = %v, want an error ending in %q", tt.src, err, tt.err)
+		}
+	}
+}
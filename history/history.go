@@ -0,0 +1,321 @@
+// Package history records snapshots of spreadsheet tabs in a git
+// repository, one file per tab, so that the history of a sheet can be
+// browsed, diffed and reviewed with ordinary git tools.
+//
+// Each Record writes the current content of every tab and, if anything
+// changed, makes one commit whose message summarizes the rows added,
+// changed and deleted per tab.
+package history
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+	"github.com/prantoran/GoogleSheets_GO/watch"
+)
+
+// Tab is a tab to record. KeyColumn names the header column identifying
+// rows in commit messages; without it rows are matched by position.
+type Tab struct {
+	Name      string
+	KeyColumn string
+}
+
+// ParseTab parses a "Name" or "Name:KeyColumn" specification.
+func ParseTab(spec string) Tab {
+	if i := strings.LastIndex(spec, ":"); i >= 0 {
+		return Tab{Name: spec[:i], KeyColumn: spec[i+1:]}
+	}
+	return Tab{Name: spec}
+}
+
+// Format is the file format of recorded tabs.
+type Format string
+
+const (
+	CSV  Format = "csv"
+	JSON Format = "json"
+)
+
+// Recorder commits snapshots of tabs into the git repository at Dir,
+// which is initialized if needed. It runs the git command line tool.
+type Recorder struct {
+	Sheets        *sheets.Service
+	SpreadsheetID string
+	Tabs          []Tab
+	Dir           string
+	Format        Format
+	// Author, if set, overrides the commit author, e.g.
+	// "Sheets History <history@example.com>".
+	Author string
+}
+
+// TabChange counts the row changes of one tab in a commit.
+type TabChange struct {
+	Tab                     string
+	Added, Changed, Deleted []string // row keys
+}
+
+func (c *TabChange) empty() bool {
+	return len(c.Added)+len(c.Changed)+len(c.Deleted) == 0
+}
+
+func (c *TabChange) String() string {
+	var parts []string
+	for _, p := range []struct {
+		n    int
+		verb string
+	}{{len(c.Added), "added"}, {len(c.Changed), "changed"}, {len(c.Deleted), "deleted"}} {
+		if p.n == 1 {
+			parts = append(parts, "1 row "+p.verb)
+		} else if p.n > 1 {
+			parts = append(parts, fmt.Sprintf("%d rows %s", p.n, p.verb))
+		}
+	}
+	if len(parts) == 0 {
+		return c.Tab + ": layout changed"
+	}
+	return c.Tab + ": " + strings.Join(parts, ", ")
+}
+
+// Record snapshots every tab and commits the result. It returns the
+// changes that were committed, or none if the tabs did not change.
+func (r *Recorder) Record(ctx context.Context) ([]*TabChange, error) {
+	if err := r.init(ctx); err != nil {
+		return nil, err
+	}
+
+	var ranges []string
+	for _, t := range r.Tabs {
+		ranges = append(ranges, sheetsclient.QuoteTab(t.Name))
+	}
+	resp, err := r.Sheets.Spreadsheets.Values.BatchGet(r.SpreadsheetID).Ranges(ranges...).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("history: unable to read tabs: %w", err)
+	}
+
+	var changes []*TabChange
+	var files []string
+	names := fileNames(r.Tabs, r.Format)
+	for i, t := range r.Tabs {
+		cur := watch.NewSnapshot(resp.ValueRanges[i].Values)
+		file := names[i]
+		path := filepath.Join(r.Dir, file)
+		prev, err := r.read(path)
+		if err != nil {
+			return nil, err
+		}
+		data, err := r.encode(cur)
+		if err != nil {
+			return nil, err
+		}
+		if old, err := ioutil.ReadFile(path); err == nil && bytes.Equal(old, data) {
+			continue
+		}
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			return nil, fmt.Errorf("history: unable to write %s: %w", path, err)
+		}
+		files = append(files, file)
+
+		c := &TabChange{Tab: t.Name}
+		if prev == nil {
+			for j, row := range cur.Rows {
+				c.Added = append(c.Added, keyOf(cur.Header, row, t.KeyColumn, j))
+			}
+		}
+		for _, e := range watch.Diff(prev, cur, t.KeyColumn) {
+			switch e.Type {
+			case watch.RowAdded:
+				c.Added = append(c.Added, e.Key)
+			case watch.RowChanged:
+				c.Changed = append(c.Changed, e.Key)
+			case watch.RowDeleted:
+				c.Deleted = append(c.Deleted, e.Key)
+			}
+		}
+		changes = append(changes, c)
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	if _, err := r.git(ctx, append([]string{"add", "--"}, files...)...); err != nil {
+		return nil, err
+	}
+	args := []string{"commit", "-q", "-m", commitMessage(changes)}
+	if r.Author != "" {
+		args = append(args, "--author", r.Author)
+	}
+	if _, err := r.git(ctx, args...); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// commitMessage summarizes changes with a subject line and, for each tab,
+// a line listing up to a few of the affected keys.
+func commitMessage(changes []*TabChange) string {
+	var subject []string
+	var body strings.Builder
+	for _, c := range changes {
+		subject = append(subject, c.String())
+		if c.empty() {
+			continue
+		}
+		fmt.Fprintf(&body, "\n%s\n", c.Tab)
+		for _, l := range []struct {
+			verb string
+			keys []string
+		}{{"added", c.Added}, {"changed", c.Changed}, {"deleted", c.Deleted}} {
+			if len(l.keys) == 0 {
+				continue
+			}
+			keys := l.keys
+			more := ""
+			if len(keys) > 10 {
+				keys, more = keys[:10], fmt.Sprintf(" and %d more", len(l.keys)-10)
+			}
+			fmt.Fprintf(&body, "  %s: %s%s\n", l.verb, strings.Join(keys, ", "), more)
+		}
+	}
+	msg := strings.Join(subject, "; ")
+	if len(changes) > 1 {
+		msg = fmt.Sprintf("Update %d tabs\n\n%s", len(changes), strings.Join(subject, "\n"))
+	}
+	return msg + "\n" + body.String()
+}
+
+func (r *Recorder) init(ctx context.Context) error {
+	if _, err := os.Stat(filepath.Join(r.Dir, ".git")); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(r.Dir, 0755); err != nil {
+		return err
+	}
+	_, err := r.git(ctx, "init", "-q")
+	return err
+}
+
+func (r *Recorder) git(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.Dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("history: git %s: %v: %s", args[0], err, bytes.TrimSpace(out))
+	}
+	return out, nil
+}
+
+// read parses a previously recorded file, returning nil if there is none.
+func (r *Recorder) read(path string) (*watch.Snapshot, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := &watch.Snapshot{}
+	if r.Format == JSON {
+		if err := json.Unmarshal(data, s); err != nil {
+			return nil, fmt.Errorf("history: unable to parse %s: %w", path, err)
+		}
+		return s, nil
+	}
+	cr := csv.NewReader(bytes.NewReader(data))
+	cr.FieldsPerRecord = -1
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("history: unable to parse %s: %w", path, err)
+	}
+	if len(rows) > 0 {
+		s.Header, s.Rows = rows[0], rows[1:]
+	}
+	return s, nil
+}
+
+// encode formats a snapshot with one row per line, so that git diffs
+// show changed rows.
+func (r *Recorder) encode(s *watch.Snapshot) ([]byte, error) {
+	var b bytes.Buffer
+	if r.Format == JSON {
+		h, err := json.Marshal(s.Header)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, "{\n  \"header\": %s,\n  \"rows\": [", h)
+		for i, row := range s.Rows {
+			v, err := json.Marshal(row)
+			if err != nil {
+				return nil, err
+			}
+			if i > 0 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(&b, "\n    %s", v)
+		}
+		b.WriteString("\n  ]\n}\n")
+		return b.Bytes(), nil
+	}
+	w := csv.NewWriter(&b)
+	if s.Header != nil {
+		w.Write(s.Header)
+	}
+	w.WriteAll(s.Rows)
+	return b.Bytes(), w.Error()
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// fileNames derives a file name from each tab name. Names that would
+// collide, such as those of "Sales 2024" and "Sales/2024", or that differ
+// only in case, get a numeric suffix in the order of tabs, e.g.
+// "Sales_2024_2.csv", so keep the order of tabs stable between records.
+func fileNames(tabs []Tab, f Format) []string {
+	ext := ".csv"
+	if f == JSON {
+		ext = ".json"
+	}
+	used := map[string]bool{}
+	names := make([]string, len(tabs))
+	for i, t := range tabs {
+		base := strings.Trim(unsafeChars.ReplaceAllString(t.Name, "_"), "_")
+		if base == "" {
+			base = "tab"
+		}
+		name := base
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			name = fmt.Sprintf("%s_%d", base, n)
+		}
+		used[strings.ToLower(name)] = true
+		names[i] = name + ext
+	}
+	return names
+}
+
+// keyOf returns the key of the i-th data row, matching the keys of
+// watch.Diff.
+func keyOf(header, row []string, keyColumn string, i int) string {
+	if keyColumn == "" {
+		return strconv.Itoa(i + 2)
+	}
+	for i, h := range header {
+		if h == keyColumn && i < len(row) {
+			return row[i]
+		}
+	}
+	return ""
+}
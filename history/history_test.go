@@ -0,0 +1,79 @@
+package history
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetstest"
+)
+
+func TestFileNames(t *testing.T) {
+	tabs := []Tab{{Name: "Sales 2024"}, {Name: "Sales/2024"}, {Name: "Sales-2024 "}, {Name: "Sales_2024_2"}, {Name: "sales 2024"}, {Name: "!!"}}
+	want := []string{"Sales_2024.csv", "Sales_2024_2.csv", "Sales-2024.csv", "Sales_2024_2_2.csv", "sales_2024_3.csv", "tab.csv"}
+	if got := fileNames(tabs, CSV); !reflect.DeepEqual(got, want) {
+		t.Errorf("fileNames = %q, want %q", got, want)
+	}
+}
+
+func TestRecordCollidingTabs(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	ctx := context.Background()
+	srv := sheetstest.NewServer()
+	defer srv.Close()
+	srv.Seed(&sheetstest.Spreadsheet{ID: "s", Tabs: []*sheetstest.Tab{
+		{Title: "Sales 2024", Rows: [][]interface{}{{"id"}, {"a"}}},
+		{Title: "Sales/2024", Rows: [][]interface{}{{"id"}, {"b"}, {"c"}}},
+	}})
+	svc, err := srv.SheetsService(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r := &Recorder{
+		Sheets:        svc,
+		SpreadsheetID: "s",
+		Tabs:          []Tab{{Name: "Sales 2024"}, {Name: "Sales/2024"}},
+		Dir:           dir,
+		Format:        CSV,
+		Author:        "Test <test@example.com>",
+	}
+	os.Setenv("GIT_COMMITTER_NAME", "Test")
+	os.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	changes, err := r.Record(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || len(changes[0].Added) != 1 || len(changes[1].Added) != 2 {
+		t.Fatalf("Record = %v, want 1 and 2 rows added", changes)
+	}
+	for file, want := range map[string]string{
+		"Sales_2024.csv":   "id\na\n",
+		"Sales_2024_2.csv": "id\nb\nc\n",
+	} {
+		got, err := ioutil.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", file, got, want)
+		}
+	}
+
+	// Recording again finds each tab's own previous file.
+	if changes, err := r.Record(ctx); err != nil || changes != nil {
+		t.Errorf("second Record = %v, %v, want no changes", changes, err)
+	}
+}
@@ -0,0 +1,230 @@
+package i18nsheet
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Message is one translatable string.
+type Message struct {
+	ID          string
+	Description string
+	Text        string
+}
+
+// Catalog holds the messages of one locale.
+type Catalog struct {
+	Locale   string
+	Messages []Message
+}
+
+// Lookup returns the message with the given ID.
+func (c *Catalog) Lookup(id string) (Message, bool) {
+	for _, m := range c.Messages {
+		if m.ID == id {
+			return m, true
+		}
+	}
+	return Message{}, false
+}
+
+// pluralSep separates a message ID from a plural category in flattened
+// go-i18n messages, e.g. "unread_messages#one".
+const pluralSep = "#"
+
+// ctxtSep separates the msgctxt of a PO entry from its msgid in IDs.
+// gettext uses "\x04", which does not survive a round trip through a
+// spreadsheet cell.
+const ctxtSep = "|"
+
+var pluralForms = map[string]bool{"zero": true, "one": true, "two": true, "few": true, "many": true, "other": true}
+
+// ReadJSON reads a go-i18n v2 JSON message file. Messages can be plain
+// strings or objects with a description and plural forms; each plural form
+// other than "other" becomes its own message with the ID suffixed by
+// "#" and the category.
+func ReadJSON(r io.Reader, locale string) (*Catalog, error) {
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("i18nsheet: invalid message file: %w", err)
+	}
+	c := &Catalog{Locale: locale}
+	for id, v := range raw {
+		var s string
+		if json.Unmarshal(v, &s) == nil {
+			c.Messages = append(c.Messages, Message{ID: id, Text: s})
+			continue
+		}
+		var obj map[string]string
+		if err := json.Unmarshal(v, &obj); err != nil {
+			return nil, fmt.Errorf("i18nsheet: message %q: %w", id, err)
+		}
+		for form, text := range obj {
+			switch {
+			case form == "other":
+				c.Messages = append(c.Messages, Message{ID: id, Description: obj["description"], Text: text})
+			case pluralForms[form]:
+				c.Messages = append(c.Messages, Message{ID: id + pluralSep + form, Description: obj["description"], Text: text})
+			}
+		}
+	}
+	sort.Slice(c.Messages, func(i, j int) bool { return c.Messages[i].ID < c.Messages[j].ID })
+	return c, nil
+}
+
+// WriteJSON writes c as a go-i18n v2 JSON message file, regrouping
+// flattened plural forms.
+func WriteJSON(w io.Writer, c *Catalog) error {
+	out := map[string]interface{}{}
+	objs := map[string]map[string]string{}
+	for _, m := range c.Messages {
+		id, form := m.ID, "other"
+		if i := strings.LastIndex(m.ID, pluralSep); i >= 0 && pluralForms[m.ID[i+1:]] {
+			id, form = m.ID[:i], m.ID[i+1:]
+		}
+		if form == "other" && m.Description == "" && objs[id] == nil {
+			out[id] = m.Text
+			continue
+		}
+		obj := objs[id]
+		if obj == nil {
+			obj = map[string]string{}
+			if s, ok := out[id].(string); ok {
+				obj["other"] = s
+			}
+			objs[id] = obj
+			out[id] = obj
+		}
+		obj[form] = m.Text
+		if m.Description != "" {
+			obj["description"] = m.Description
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(out)
+}
+
+// ReadPO reads a gettext PO or POT file, dropping the header entry.
+// Extracted comments ("#.") become
+// the description, and msgctxt, when present, is prepended to the ID as
+// "context|msgid". Plural entries are not supported
+// and are skipped.
+func ReadPO(r io.Reader, locale string) (*Catalog, error) {
+	c := &Catalog{Locale: locale}
+	var cur struct {
+		desc, ctxt, id, str []string
+		plural              bool
+	}
+	var field *[]string
+	flush := func() {
+		id := strings.Join(cur.id, "")
+		if id != "" && !cur.plural {
+			if len(cur.ctxt) > 0 {
+				id = strings.Join(cur.ctxt, "") + ctxtSep + id
+			}
+			c.Messages = append(c.Messages, Message{
+				ID:          id,
+				Description: strings.Join(cur.desc, "\n"),
+				Text:        strings.Join(cur.str, ""),
+			})
+		}
+		cur.desc, cur.ctxt, cur.id, cur.str, cur.plural = nil, nil, nil, nil, false
+		field = nil
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		var rest string
+		switch {
+		case line == "":
+			flush()
+			continue
+		case strings.HasPrefix(line, "#."):
+			if cur.id != nil {
+				flush()
+			}
+			cur.desc = append(cur.desc, strings.TrimSpace(line[2:]))
+			continue
+		case strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "msgctxt "):
+			if cur.id != nil {
+				flush()
+			}
+			field, rest = &cur.ctxt, line[len("msgctxt "):]
+		case strings.HasPrefix(line, "msgid_plural "):
+			cur.plural = true
+			field = nil
+			continue
+		case strings.HasPrefix(line, "msgid "):
+			if cur.id != nil {
+				flush()
+			}
+			field, rest = &cur.id, line[len("msgid "):]
+		case strings.HasPrefix(line, "msgstr["):
+			cur.plural = true
+			field = nil
+			continue
+		case strings.HasPrefix(line, "msgstr "):
+			field, rest = &cur.str, line[len("msgstr "):]
+		case strings.HasPrefix(line, `"`):
+			rest = line
+		default:
+			return nil, fmt.Errorf("i18nsheet: line %d: unexpected %q", n, line)
+		}
+		s, err := strconv.Unquote(rest)
+		if err != nil {
+			return nil, fmt.Errorf("i18nsheet: line %d: invalid string %s", n, rest)
+		}
+		if field != nil {
+			*field = append(*field, s)
+		}
+	}
+	flush()
+	return c, sc.Err()
+}
+
+// WritePO writes c as a PO file, starting with a header entry naming the
+// locale.
+func WritePO(w io.Writer, c *Catalog) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "msgid \"\"\nmsgstr %s\n\n",
+		quotePO("Content-Type: text/plain; charset=UTF-8\nLanguage: "+c.Locale+"\n"))
+	for _, m := range c.Messages {
+		for _, d := range strings.Split(m.Description, "\n") {
+			if d != "" {
+				fmt.Fprintf(bw, "#. %s\n", d)
+			}
+		}
+		id := m.ID
+		if i := strings.Index(id, ctxtSep); i >= 0 {
+			fmt.Fprintf(bw, "msgctxt %s\n", quotePO(id[:i]))
+			id = id[i+len(ctxtSep):]
+		}
+		fmt.Fprintf(bw, "msgid %s\nmsgstr %s\n\n", quotePO(id), quotePO(m.Text))
+	}
+	return bw.Flush()
+}
+
+// quotePO quotes s, splitting it after newlines the way gettext tools do.
+func quotePO(s string) string {
+	if !strings.Contains(strings.TrimSuffix(s, "\n"), "\n") {
+		return strconv.Quote(s)
+	}
+	parts := []string{`""`}
+	for _, line := range strings.SplitAfter(s, "\n") {
+		if line != "" {
+			parts = append(parts, strconv.Quote(line))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
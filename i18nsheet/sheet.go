@@ -0,0 +1,261 @@
+// Package i18nsheet moves message catalogs between translation files and
+// a translators' sheet with one row per message and one column per locale.
+//
+// Export writes the source catalog into the sheet, keeping translations
+// already entered there, and marks rows whose source text changed since
+// the previous export so translators know to review them. Import reads the
+// translations back per locale, keyed by the original message IDs.
+package i18nsheet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// Fixed columns of the translations tab; every column after them is a
+// locale.
+var fixedColumns = []string{"ID", "Description", "Source", "Status"}
+
+// Statuses written to the Status column. StatusChanged stays until a
+// translator clears the cell, even if the row is exported again.
+const (
+	StatusChanged = "source changed"
+	statusMissing = "missing: "
+)
+
+// Sheet is a translations tab.
+type Sheet struct {
+	Sheets        *sheets.Service
+	SpreadsheetID string
+	Tab           string
+}
+
+// ExportReport summarizes an export.
+type ExportReport struct {
+	Messages int
+	Added    []string // IDs new to the sheet
+	Changed  []string // IDs whose source text changed
+	Removed  []string // IDs dropped from the source
+	// Missing counts untranslated messages per locale.
+	Missing map[string]int
+}
+
+// ImportReport summarizes an import.
+type ImportReport struct {
+	// Missing lists untranslated message IDs per locale.
+	Missing map[string][]string
+	// Changed lists the messages still flagged for review; their
+	// translations are imported anyway.
+	Changed []string
+}
+
+type row struct {
+	id, desc, source, status string
+	tr                       map[string]string
+}
+
+// read returns the locale columns and rows of the tab, or no rows if the
+// tab does not exist yet.
+func (s *Sheet) read(ctx context.Context) ([]string, map[string]*row, bool, error) {
+	ss, err := s.Sheets.Spreadsheets.Get(s.SpreadsheetID).Fields("sheets.properties.title").Context(ctx).Do()
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("i18nsheet: unable to get spreadsheet: %w", err)
+	}
+	exists := false
+	for _, sh := range ss.Sheets {
+		exists = exists || sh.Properties.Title == s.Tab
+	}
+	rows := map[string]*row{}
+	if !exists {
+		return nil, rows, false, nil
+	}
+	resp, err := s.Sheets.Spreadsheets.Values.Get(s.SpreadsheetID, sheetsclient.QuoteTab(s.Tab)).Context(ctx).Do()
+	if err != nil {
+		return nil, nil, true, fmt.Errorf("i18nsheet: unable to read tab %q: %w", s.Tab, err)
+	}
+	if len(resp.Values) == 0 {
+		return nil, rows, true, nil
+	}
+	header := toStrings(resp.Values[0])
+	for i, h := range fixedColumns {
+		if i >= len(header) || header[i] != h {
+			return nil, nil, true, fmt.Errorf("i18nsheet: tab %q must start with the columns %s", s.Tab, strings.Join(fixedColumns, ", "))
+		}
+	}
+	locales := header[len(fixedColumns):]
+	for _, v := range resp.Values[1:] {
+		cells := toStrings(v)
+		get := func(i int) string {
+			if i < len(cells) {
+				return cells[i]
+			}
+			return ""
+		}
+		r := &row{id: get(0), desc: get(1), source: get(2), status: get(3), tr: map[string]string{}}
+		if r.id == "" {
+			continue
+		}
+		for i, loc := range locales {
+			if t := get(len(fixedColumns) + i); t != "" {
+				r.tr[loc] = t
+			}
+		}
+		rows[r.id] = r
+	}
+	return locales, rows, true, nil
+}
+
+// Export writes source into the tab, creating it if needed. Translations
+// from the given catalogs fill cells that are still empty in the sheet;
+// text entered by translators is never overwritten.
+func (s *Sheet) Export(ctx context.Context, source *Catalog, translations []*Catalog) (*ExportReport, error) {
+	locales, existing, exists, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range translations {
+		if indexOf(locales, c.Locale) < 0 {
+			locales = append(locales, c.Locale)
+		}
+	}
+
+	rep := &ExportReport{Messages: len(source.Messages), Missing: map[string]int{}}
+	header := append(append([]string{}, fixedColumns...), locales...)
+	values := [][]interface{}{toValues(header)}
+	seen := map[string]bool{}
+	for _, m := range source.Messages {
+		seen[m.ID] = true
+		r, ok := existing[m.ID]
+		if !ok {
+			r = &row{id: m.ID, tr: map[string]string{}}
+			rep.Added = append(rep.Added, m.ID)
+		} else if r.source != m.Text && len(r.tr) > 0 {
+			r.status = StatusChanged
+			rep.Changed = append(rep.Changed, m.ID)
+		}
+		r.desc, r.source = m.Description, m.Text
+		for _, c := range translations {
+			if t, ok := c.Lookup(m.ID); ok && t.Text != "" && r.tr[c.Locale] == "" {
+				r.tr[c.Locale] = t.Text
+			}
+		}
+
+		var missing []string
+		cells := []string{r.id, r.desc, r.source, ""}
+		for _, loc := range locales {
+			if r.tr[loc] == "" {
+				missing = append(missing, loc)
+				rep.Missing[loc]++
+			}
+			cells = append(cells, r.tr[loc])
+		}
+		switch {
+		case r.status == StatusChanged:
+			cells[3] = StatusChanged
+		case len(missing) > 0:
+			cells[3] = statusMissing + strings.Join(missing, ", ")
+		}
+		values = append(values, toValues(cells))
+	}
+	for id := range existing {
+		if !seen[id] {
+			rep.Removed = append(rep.Removed, id)
+		}
+	}
+	sort.Strings(rep.Removed)
+
+	if !exists {
+		req := &sheets.BatchUpdateSpreadsheetRequest{Requests: []*sheets.Request{{
+			AddSheet: &sheets.AddSheetRequest{Properties: &sheets.SheetProperties{
+				Title:          s.Tab,
+				GridProperties: &sheets.GridProperties{FrozenRowCount: 1, FrozenColumnCount: 1},
+			}},
+		}}}
+		if _, err := s.Sheets.Spreadsheets.BatchUpdate(s.SpreadsheetID, req).Context(ctx).Do(); err != nil {
+			return nil, fmt.Errorf("i18nsheet: unable to add tab %q: %w", s.Tab, err)
+		}
+	} else if _, err := s.Sheets.Spreadsheets.Values.Clear(s.SpreadsheetID, sheetsclient.QuoteTab(s.Tab), &sheets.ClearValuesRequest{}).Context(ctx).Do(); err != nil {
+		return nil, fmt.Errorf("i18nsheet: unable to clear tab %q: %w", s.Tab, err)
+	}
+	vr := &sheets.ValueRange{Values: values}
+	if _, err := s.Sheets.Spreadsheets.Values.Update(s.SpreadsheetID, sheetsclient.QuoteTab(s.Tab)+"!A1", vr).ValueInputOption("RAW").Context(ctx).Do(); err != nil {
+		return nil, fmt.Errorf("i18nsheet: unable to write tab %q: %w", s.Tab, err)
+	}
+	return rep, nil
+}
+
+// Import reads the translations of the given locales, or of every locale
+// column if none are given. Untranslated messages are left out of the
+// catalogs and listed in the report.
+func (s *Sheet) Import(ctx context.Context, locales ...string) ([]*Catalog, *ImportReport, error) {
+	columns, rows, exists, err := s.read(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !exists {
+		return nil, nil, fmt.Errorf("i18nsheet: tab %q not found", s.Tab)
+	}
+	if len(locales) == 0 {
+		locales = columns
+	}
+	ids := make([]string, 0, len(rows))
+	for id := range rows {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	rep := &ImportReport{Missing: map[string][]string{}}
+	for _, id := range ids {
+		if rows[id].status == StatusChanged {
+			rep.Changed = append(rep.Changed, id)
+		}
+	}
+	var out []*Catalog
+	for _, loc := range locales {
+		if indexOf(columns, loc) < 0 {
+			return nil, nil, fmt.Errorf("i18nsheet: tab %q has no column for %q", s.Tab, loc)
+		}
+		c := &Catalog{Locale: loc}
+		for _, id := range ids {
+			r := rows[id]
+			if t := r.tr[loc]; t != "" {
+				c.Messages = append(c.Messages, Message{ID: id, Description: r.desc, Text: t})
+			} else {
+				rep.Missing[loc] = append(rep.Missing[loc], id)
+			}
+		}
+		out = append(out, c)
+	}
+	return out, rep, nil
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func toStrings(row []interface{}) []string {
+	out := make([]string, len(row))
+	for i, v := range row {
+		out[i] = fmt.Sprint(v)
+	}
+	return out
+}
+
+func toValues(row []string) []interface{} {
+	out := make([]interface{}, len(row))
+	for i, v := range row {
+		out[i] = v
+	}
+	return out
+}
@@ -0,0 +1,157 @@
+// Package importrange copies a range from one spreadsheet into another, as
+// a controlled alternative to IMPORTRANGE formulas: the copy is made with
+// the credentials of the tool, so it does not break when the readers of
+// the destination lose access to the source.
+package importrange
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// Job copies SourceRange of SourceID to DestRange of DestID.
+type Job struct {
+	Sheets      *sheets.Service
+	SourceID    string
+	SourceRange string
+	DestID      string
+	// DestRange is the destination tab, optionally with the top left cell
+	// of the copy, e.g. "Imported" or "Imported!B2". Everything below and
+	// right of that cell belongs to the copy and is cleared on each run.
+	// A missing tab is created.
+	DestRange string
+	// Formatted copies the values as displayed in the source instead of
+	// the underlying numbers, dates as serial numbers.
+	Formatted bool
+	// NoNote skips the provenance note on the top left cell.
+	NoNote bool
+}
+
+// Result describes a completed copy.
+type Result struct {
+	Rows, Columns int
+	Time          time.Time
+}
+
+// Run performs the copy. The destination is cleared and written in a
+// single batch update, so readers never see it half written.
+func (j *Job) Run(ctx context.Context) (*Result, error) {
+	render := "UNFORMATTED_VALUE"
+	if j.Formatted {
+		render = "FORMATTED_VALUE"
+	}
+	resp, err := j.Sheets.Spreadsheets.Values.Get(j.SourceID, j.SourceRange).
+		ValueRenderOption(render).DateTimeRenderOption("SERIAL_NUMBER").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("importrange: unable to read %s: %w", j.SourceRange, err)
+	}
+	res := &Result{Rows: len(resp.Values), Time: time.Now()}
+	for _, row := range resp.Values {
+		if len(row) > res.Columns {
+			res.Columns = len(row)
+		}
+	}
+
+	tab, cell := sheetsclient.SplitRange(j.DestRange)
+	if cell == "" {
+		cell = "A1"
+	}
+	g, err := sheetsclient.GridRange(0, cell)
+	if err != nil {
+		return nil, err
+	}
+	col, row := g.StartColumnIndex, g.StartRowIndex
+	props, err := j.destSheet(ctx, tab)
+	if err != nil {
+		return nil, err
+	}
+	id := props.SheetId
+	start := &sheets.GridCoordinate{SheetId: id, RowIndex: row, ColumnIndex: col, ForceSendFields: []string{"SheetId", "RowIndex", "ColumnIndex"}}
+
+	var reqs []*sheets.Request
+	grid := props.GridProperties
+	if n := row + int64(res.Rows) - grid.RowCount; n > 0 {
+		reqs = append(reqs, &sheets.Request{AppendDimension: &sheets.AppendDimensionRequest{SheetId: id, Dimension: "ROWS", Length: n}})
+	}
+	if n := col + int64(res.Columns) - grid.ColumnCount; n > 0 {
+		reqs = append(reqs, &sheets.Request{AppendDimension: &sheets.AppendDimensionRequest{SheetId: id, Dimension: "COLUMNS", Length: n}})
+	}
+	// Clear the previous copy, which may have been larger.
+	reqs = append(reqs, &sheets.Request{UpdateCells: &sheets.UpdateCellsRequest{
+		Range: &sheets.GridRange{
+			SheetId:          id,
+			StartRowIndex:    row,
+			StartColumnIndex: col,
+			ForceSendFields:  []string{"SheetId", "StartRowIndex", "StartColumnIndex"},
+		},
+		Fields: "userEnteredValue,note",
+	}})
+	if res.Rows > 0 {
+		rows := make([]*sheets.RowData, len(resp.Values))
+		for i, r := range resp.Values {
+			rows[i] = &sheets.RowData{}
+			for _, v := range r {
+				rows[i].Values = append(rows[i].Values, &sheets.CellData{UserEnteredValue: extendedValue(v)})
+			}
+		}
+		reqs = append(reqs, &sheets.Request{UpdateCells: &sheets.UpdateCellsRequest{Start: start, Rows: rows, Fields: "userEnteredValue"}})
+	}
+	if !j.NoNote {
+		note := fmt.Sprintf("Imported from https://docs.google.com/spreadsheets/d/%s range %s on %s.\nEdits to the imported cells are overwritten by the next import.",
+			j.SourceID, j.SourceRange, res.Time.UTC().Format("2006-01-02 15:04 MST"))
+		reqs = append(reqs, &sheets.Request{UpdateCells: &sheets.UpdateCellsRequest{
+			Start:  start,
+			Rows:   []*sheets.RowData{{Values: []*sheets.CellData{{Note: note}}}},
+			Fields: "note",
+		}})
+	}
+	_, err = j.Sheets.Spreadsheets.BatchUpdate(j.DestID, &sheets.BatchUpdateSpreadsheetRequest{Requests: reqs}).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("importrange: unable to write %s: %w", j.DestRange, err)
+	}
+	return res, nil
+}
+
+// destSheet returns the properties of the destination tab, creating it if
+// needed.
+func (j *Job) destSheet(ctx context.Context, tab string) (*sheets.SheetProperties, error) {
+	resp, err := j.Sheets.Spreadsheets.Get(j.DestID).Fields("sheets.properties(sheetId,title,gridProperties)").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("importrange: unable to get tabs: %w", err)
+	}
+	for _, sh := range resp.Sheets {
+		if sh.Properties.Title == tab {
+			return sh.Properties, nil
+		}
+	}
+	add, err := j.Sheets.Spreadsheets.BatchUpdate(j.DestID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{AddSheet: &sheets.AddSheetRequest{Properties: &sheets.SheetProperties{Title: tab}}}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("importrange: unable to create tab %s: %w", tab, err)
+	}
+	return add.Replies[0].AddSheet.Properties, nil
+}
+
+func extendedValue(v interface{}) *sheets.ExtendedValue {
+	switch v := v.(type) {
+	case float64:
+		return &sheets.ExtendedValue{NumberValue: &v}
+	case bool:
+		return &sheets.ExtendedValue{BoolValue: &v, ForceSendFields: []string{"BoolValue"}}
+	case string:
+		if v == "" {
+			return nil
+		}
+		// Text is copied as is: a source value starting with "=" stays
+		// text rather than becoming a formula.
+		return &sheets.ExtendedValue{StringValue: &v}
+	}
+	s := fmt.Sprint(v)
+	return &sheets.ExtendedValue{StringValue: &s}
+}
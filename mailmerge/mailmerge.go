@@ -0,0 +1,167 @@
+package mailmerge
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// Merge sends the messages of one tab. The first row of the tab is the
+// header; rows whose status cell is already filled in are skipped.
+type Merge struct {
+	Sheets        *sheets.Service
+	SpreadsheetID string
+	Tab           string
+	Template      *Template
+	Sender        Sender
+	From          string
+	// StatusColumn is the header of the column recording when each
+	// row was sent; defaults to "Sent At". It is added if missing.
+	StatusColumn string
+	// DryRun renders every message without sending it or touching the
+	// sheet.
+	DryRun bool
+	// Interval is the minimum delay between two messages, to stay under
+	// the sending limits of the mail provider.
+	Interval time.Duration
+	// Limit caps the number of messages sent by one run; 0 is no limit.
+	Limit int
+	// OnMessage, if set, is called for every rendered row with the
+	// outcome of sending it.
+	OnMessage func(row int, m *Message, err error)
+}
+
+// Report counts the rows handled by a run.
+type Report struct {
+	Sent, Skipped, Failed int
+}
+
+func (r *Report) String() string {
+	return fmt.Sprintf("%d sent, %d already sent, %d failed", r.Sent, r.Skipped, r.Failed)
+}
+
+// Run renders and sends a message for every pending row. A row that
+// fails to render or send is reported to OnMessage and left pending; Run
+// only returns an error if the sheet cannot be read or updated.
+func (m *Merge) Run(ctx context.Context) (*Report, error) {
+	status := m.StatusColumn
+	if status == "" {
+		status = "Sent At"
+	}
+	resp, err := m.Sheets.Spreadsheets.Values.Get(m.SpreadsheetID, sheetsclient.QuoteTab(m.Tab)).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("mailmerge: unable to read tab %q: %w", m.Tab, err)
+	}
+	if len(resp.Values) == 0 {
+		return nil, fmt.Errorf("mailmerge: tab %q has no header row", m.Tab)
+	}
+	header := toStrings(resp.Values[0])
+	col := -1
+	for i, h := range header {
+		if h == status {
+			col = i
+		}
+	}
+	if col < 0 {
+		col = len(header)
+		if !m.DryRun {
+			if err := m.writeCell(ctx, 1, col, status); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	rep := &Report{}
+	var last time.Time
+	for i, v := range resp.Values[1:] {
+		rowNum := i + 2
+		row := toStrings(v)
+		if col < len(row) && row[col] != "" {
+			rep.Skipped++
+			continue
+		}
+		if len(strings.Join(row, "")) == 0 {
+			continue
+		}
+		if m.Limit > 0 && rep.Sent >= m.Limit {
+			break
+		}
+		fields := make(map[string]string, len(header))
+		for j, h := range header {
+			if j < len(row) {
+				fields[h] = row[j]
+			} else {
+				fields[h] = ""
+			}
+		}
+		msg, err := m.Template.Render(fields)
+		if err == nil && msg.To == "" {
+			err = fmt.Errorf("mailmerge: row %d has no recipient", rowNum)
+		}
+		if err != nil {
+			rep.Failed++
+			m.report(rowNum, msg, err)
+			continue
+		}
+		msg.From = m.From
+		if m.DryRun {
+			rep.Sent++
+			m.report(rowNum, msg, nil)
+			continue
+		}
+
+		if wait := m.Interval - time.Since(last); !last.IsZero() && wait > 0 {
+			select {
+			case <-ctx.Done():
+				return rep, ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+		last = time.Now()
+		if err := m.Sender.Send(ctx, msg); err != nil {
+			rep.Failed++
+			m.report(rowNum, msg, err)
+			continue
+		}
+		// Record the send right away so an interrupted run does not mail
+		// this row again.
+		if err := m.writeCell(ctx, rowNum, col, time.Now().Format(time.RFC3339)); err != nil {
+			return rep, err
+		}
+		rep.Sent++
+		m.report(rowNum, msg, nil)
+	}
+	return rep, nil
+}
+
+func (m *Merge) report(row int, msg *Message, err error) {
+	if m.OnMessage != nil {
+		m.OnMessage(row, msg, err)
+	}
+}
+
+// writeCell sets the cell at a 1-based row and 0-based column.
+func (m *Merge) writeCell(ctx context.Context, row, col int, value string) error {
+	rng := fmt.Sprintf("%s!%s%d", sheetsclient.QuoteTab(m.Tab), sheetsclient.ColumnLetters(col+1), row)
+	vr := &sheets.ValueRange{Values: [][]interface{}{{value}}}
+	_, err := m.Sheets.Spreadsheets.Values.Update(m.SpreadsheetID, rng, vr).ValueInputOption("RAW").Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("mailmerge: unable to update %s: %w", rng, err)
+	}
+	return nil
+}
+
+// columnName converts a 0-based column index to its A1 letters.
+
+func toStrings(row []interface{}) []string {
+	out := make([]string, len(row))
+	for i, v := range row {
+		out[i] = fmt.Sprint(v)
+	}
+	return out
+}
@@ -0,0 +1,223 @@
+// Package mailmerge sends one templated email per spreadsheet row and
+// records in the sheet when each was sent, so that a merge can be stopped
+// and resumed without mailing anyone twice.
+package mailmerge
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"text/template"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/gmail/v1"
+)
+
+// Message is a rendered email.
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Body    string
+	HTML    bool
+}
+
+// addresses parses the sender, if any, and the recipients of m. Line
+// breaks are rejected so that sheet data cannot add headers.
+func (m *Message) addresses() (*mail.Address, []*mail.Address, error) {
+	var from *mail.Address
+	if m.From != "" {
+		var err error
+		if from, err = parseAddress(m.From); err != nil {
+			return nil, nil, fmt.Errorf("mailmerge: invalid sender %q: %w", m.From, err)
+		}
+	}
+	to, err := parseAddressList(m.To)
+	if err != nil {
+		return nil, nil, fmt.Errorf("mailmerge: invalid recipient %q: %w", m.To, err)
+	}
+	return from, to, nil
+}
+
+var errLineBreak = errors.New("contains a line break")
+
+func parseAddress(s string) (*mail.Address, error) {
+	if strings.ContainsAny(s, "\r\n") {
+		return nil, errLineBreak
+	}
+	return mail.ParseAddress(s)
+}
+
+func parseAddressList(s string) ([]*mail.Address, error) {
+	if strings.ContainsAny(s, "\r\n") {
+		return nil, errLineBreak
+	}
+	return mail.ParseAddressList(s)
+}
+
+// bytes formats m as an RFC 5322 message, writing the address headers
+// from the parsed addresses.
+func (m *Message) bytes() ([]byte, error) {
+	from, to, err := m.addresses()
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	ctype := "text/plain"
+	if m.HTML {
+		ctype = "text/html"
+	}
+	if from != nil {
+		fmt.Fprintf(&b, "From: %s\r\n", from)
+	}
+	rcpt := make([]string, len(to))
+	for i, a := range to {
+		rcpt[i] = a.String()
+	}
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(rcpt, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: %s; charset=\"UTF-8\"\r\n", ctype)
+	b.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+	enc := base64.StdEncoding.EncodeToString([]byte(m.Body))
+	for len(enc) > 76 {
+		b.WriteString(enc[:76] + "\r\n")
+		enc = enc[76:]
+	}
+	b.WriteString(enc + "\r\n")
+	return b.Bytes(), nil
+}
+
+// Template renders messages from the fields of a row. Fields are looked up
+// by header name, e.g. {{.Email}} or {{index . "First name"}}; a field
+// missing from the header is an error.
+type Template struct {
+	to, subject *template.Template
+	text        *template.Template
+	html        *htmltemplate.Template
+}
+
+// NewTemplate parses the templates of the recipient, subject and body.
+// With html set the body is an HTML template and values are escaped.
+func NewTemplate(to, subject, body string, html bool) (*Template, error) {
+	t := &Template{}
+	var err error
+	if t.to, err = template.New("to").Option("missingkey=error").Parse(to); err != nil {
+		return nil, fmt.Errorf("mailmerge: invalid recipient template: %w", err)
+	}
+	if t.subject, err = template.New("subject").Option("missingkey=error").Parse(subject); err != nil {
+		return nil, fmt.Errorf("mailmerge: invalid subject template: %w", err)
+	}
+	if html {
+		t.html, err = htmltemplate.New("body").Option("missingkey=error").Parse(body)
+	} else {
+		t.text, err = template.New("body").Option("missingkey=error").Parse(body)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mailmerge: invalid body template: %w", err)
+	}
+	return t, nil
+}
+
+// Render builds the message for one row.
+func (t *Template) Render(fields map[string]string) (*Message, error) {
+	m := &Message{HTML: t.html != nil}
+	var b strings.Builder
+	if err := t.to.Execute(&b, fields); err != nil {
+		return nil, err
+	}
+	m.To = strings.TrimSpace(b.String())
+	if m.To != "" {
+		if _, err := parseAddressList(m.To); err != nil {
+			return nil, fmt.Errorf("mailmerge: invalid recipient %q: %w", m.To, err)
+		}
+	}
+	b.Reset()
+	if err := t.subject.Execute(&b, fields); err != nil {
+		return nil, err
+	}
+	m.Subject = strings.TrimSpace(b.String())
+	b.Reset()
+	var err error
+	if t.html != nil {
+		err = t.html.Execute(&b, fields)
+	} else {
+		err = t.text.Execute(&b, fields)
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.Body = b.String()
+	return m, nil
+}
+
+// Sender delivers rendered messages.
+type Sender interface {
+	Send(ctx context.Context, m *Message) error
+}
+
+// SMTP sends through an SMTP server, using STARTTLS when the server
+// offers it and PLAIN authentication when Username is set.
+type SMTP struct {
+	Addr     string // host:port
+	Username string
+	Password string
+}
+
+// Send implements Sender.
+func (s *SMTP) Send(ctx context.Context, m *Message) error {
+	if m.From == "" {
+		return errors.New("mailmerge: SMTP needs a sender")
+	}
+	from, to, err := m.addresses()
+	if err != nil {
+		return err
+	}
+	data, err := m.bytes()
+	if err != nil {
+		return err
+	}
+	rcpt := make([]string, len(to))
+	for i, a := range to {
+		rcpt[i] = a.Address
+	}
+	var auth smtp.Auth
+	if s.Username != "" {
+		host := s.Addr
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[:i]
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+	if err := smtp.SendMail(s.Addr, auth, from.Address, rcpt, data); err != nil {
+		return fmt.Errorf("mailmerge: unable to send to %s: %w", m.To, err)
+	}
+	return nil
+}
+
+// Gmail sends through the Gmail API as the authorized user. It needs the
+// gmail.send scope.
+type Gmail struct {
+	Service *gmail.Service
+}
+
+// Send implements Sender.
+func (g *Gmail) Send(ctx context.Context, m *Message) error {
+	data, err := m.bytes()
+	if err != nil {
+		return err
+	}
+	msg := &gmail.Message{Raw: base64.URLEncoding.EncodeToString(data)}
+	if _, err := g.Service.Users.Messages.Send("me", msg).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("mailmerge: unable to send to %s: %w", m.To, err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package mailmerge
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderRejectsHeaderInjection(t *testing.T) {
+	tmpl, err := NewTemplate("{{.Email}}", "Hi", "Body", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, to := range []string{
+		"a@example.com\r\nBcc: b@example.com",
+		"a@example.com\nBcc: b@example.com",
+		"not an address",
+	} {
+		if _, err := tmpl.Render(map[string]string{"Email": to}); err == nil {
+			t.Errorf("Render(%q) succeeded, want an error", to)
+		}
+	}
+}
+
+func TestMessageBytes(t *testing.T) {
+	m := &Message{From: "Me <me@example.com>", To: `"Doe, Jane" <jane@example.com>, bob@example.com`, Subject: "Hi", Body: "Body"}
+	data, err := m.bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := string(data[:strings.Index(string(data), "\r\n\r\n")])
+	for _, want := range []string{
+		"From: \"Me\" <me@example.com>\r\n",
+		"To: \"Doe, Jane\" <jane@example.com>, <bob@example.com>\r\n",
+	} {
+		if !strings.Contains(header+"\r\n", want) {
+			t.Errorf("header %q does not contain %q", header, want)
+		}
+	}
+
+	m.From = "me@example.com\r\nBcc: x@example.com"
+	if _, err := m.bytes(); err == nil {
+		t.Error("bytes accepted a sender with a line break")
+	}
+}
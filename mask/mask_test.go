@@ -0,0 +1,63 @@
+package mask
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestTableDuplicateColumns(t *testing.T) {
+	c := &Config{Salt: "s", Rules: []Rule{
+		{Column: "Email", Transform: Redact},
+		{Column: "Notes", Transform: Drop},
+	}}
+	got, err := Table(c, [][]string{
+		{"Email", "Name", "Notes", " Email ", "Notes"},
+		{"a@x", "Ann", "n1", "b@x", "n2"},
+		{"", "Bob", "", "c@x", ""},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"Email", "Name", " Email "},
+		{"REDACTED", "Ann", "REDACTED"},
+		{"", "Bob", "REDACTED"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Table = %q, want %q", got, want)
+	}
+}
+
+func TestFakes(t *testing.T) {
+	m, err := New(&Config{Salt: "s", Rules: []Rule{
+		{Column: "Phone", Transform: FakePhone},
+		{Column: "Email", Transform: FakeEmail},
+	}}, []string{"Phone", "Email"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	phone := regexp.MustCompile(`^\+1 [2-9][0-9]{2}-555-01[0-9]{2}$`)
+	for _, v := range []string{"555 1234", "+44 20 7946 0000", "x", "(212) 555-0000"} {
+		row := m.Row([]string{v, v + "@Example.com"})
+		if !phone.MatchString(row[0]) || row[0][4:6] == "11" {
+			t.Errorf("fake phone of %q = %q", v, row[0])
+		}
+		if again := m.Row([]string{v, v + "@example.COM"}); !reflect.DeepEqual(again, row) {
+			t.Errorf("masking %q again = %q, want %q", v, again, row)
+		}
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	header := []string{"Email", "Name"}
+	for _, r := range []Rule{
+		{Column: "Phone", Transform: Redact},
+		{Column: "Name", Transform: "scramble"},
+		{Column: "Name", Transform: Truncate},
+	} {
+		if _, err := New(&Config{Rules: []Rule{r}}, header); err == nil {
+			t.Errorf("New accepted %+v", r)
+		}
+	}
+}
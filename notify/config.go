@@ -0,0 +1,80 @@
+// Package notify posts human readable summaries of detected row changes to
+// Slack or Google Chat incoming webhooks.
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// Config describes what to watch and who to tell about it. It is usually
+// read from a JSON file, e.g.
+//
+//	{
+//	  "spreadsheet_id": "1zFjra05ZGfaVgKNorPdvAU-bh0QDkOn-CVoXjWtiw2w",
+//	  "range": "Candidates!A1:H",
+//	  "name": "Hiring Pipeline",
+//	  "key": "Email",
+//	  "channels": [
+//	    {"url": "https://hooks.slack.com/services/T000/B000/XXXX",
+//	     "columns": ["Stage", "Owner"]},
+//	    {"url": "https://chat.googleapis.com/v1/spaces/AAAA/messages?key=...",
+//	     "events": ["added"]}
+//	  ]
+//	}
+type Config struct {
+	SpreadsheetID string `json:"spreadsheet_id"`
+	Range         string `json:"range"`
+	// Name is used in messages; it defaults to Range.
+	Name string `json:"name"`
+	// Key is the header of the column identifying rows.
+	Key      string    `json:"key"`
+	Channels []Channel `json:"channels"`
+}
+
+// Channel is one incoming webhook and the changes it cares about.
+type Channel struct {
+	URL string `json:"url"`
+	// Columns limits the channel to these columns: changed rows are only
+	// reported when one of them changed, and only they are shown. All
+	// columns are considered when empty.
+	Columns []string `json:"columns"`
+	// Events lists the change types to report, out of "added", "changed"
+	// and "deleted"; all of them when empty.
+	Events []string `json:"events"`
+}
+
+// LoadConfig reads and checks a configuration file.
+func LoadConfig(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("notify: invalid config %s: %w", path, err)
+	}
+	switch {
+	case c.SpreadsheetID == "":
+		return nil, fmt.Errorf("notify: %s: spreadsheet_id is required", path)
+	case c.Range == "":
+		return nil, fmt.Errorf("notify: %s: range is required", path)
+	case len(c.Channels) == 0:
+		return nil, fmt.Errorf("notify: %s: no channels configured", path)
+	}
+	if c.Name == "" {
+		c.Name = c.Range
+	}
+	for i, ch := range c.Channels {
+		if ch.URL == "" {
+			return nil, fmt.Errorf("notify: %s: channel %d has no url", path, i)
+		}
+		for _, e := range ch.Events {
+			if e != "added" && e != "changed" && e != "deleted" {
+				return nil, fmt.Errorf("notify: %s: channel %d: unknown event %q", path, i, e)
+			}
+		}
+	}
+	return c, nil
+}
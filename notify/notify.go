@@ -0,0 +1,186 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/prantoran/GoogleSheets_GO/watch"
+)
+
+// maxLines caps the number of rows listed in one message.
+const maxLines = 10
+
+// Notifier posts summaries of watcher events to the channels of a Config.
+type Notifier struct {
+	Config *Config
+	// Client defaults to a client with a 30 second timeout.
+	Client *http.Client
+}
+
+// Handler returns a Watcher.OnBatch callback. A failed post makes the
+// watcher retry the poll, so a channel may occasionally see a summary
+// twice.
+func (n *Notifier) Handler() func(ctx context.Context, events []watch.Event) error {
+	return func(ctx context.Context, events []watch.Event) error {
+		var failed []string
+		for _, ch := range n.Config.Channels {
+			text := Summarize(n.Config.Name, ch, events)
+			if text == "" {
+				continue
+			}
+			if err := n.post(ctx, ch.URL, text); err != nil {
+				failed = append(failed, err.Error())
+			}
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("notify: %s", strings.Join(failed, "; "))
+		}
+		return nil
+	}
+}
+
+// Summarize formats the events relevant to ch, e.g.
+//
+//	*Hiring Pipeline*: 3 new rows added, 1 row changed
+//	• added ada@example.com: Stage=Phone screen, Owner=Sam
+//	...
+//
+// It returns "" when none of the events concern the channel.
+func Summarize(name string, ch Channel, events []watch.Event) string {
+	var counts [3]int
+	var lines []string
+	for _, e := range events {
+		if !wants(ch, e.Type) {
+			continue
+		}
+		var line string
+		switch e.Type {
+		case watch.RowAdded:
+			line = fmt.Sprintf("• added %s: %s", e.Key, fields(ch, *e.New))
+		case watch.RowDeleted:
+			line = fmt.Sprintf("• deleted %s", e.Key)
+		case watch.RowChanged:
+			diff := changes(ch, *e.Old, *e.New)
+			if diff == "" {
+				continue
+			}
+			line = fmt.Sprintf("• %s: %s", e.Key, diff)
+		}
+		counts[e.Type]++
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	var parts []string
+	if c := counts[watch.RowAdded]; c > 0 {
+		parts = append(parts, fmt.Sprintf("%d new %s added", c, plural(c, "row")))
+	}
+	if c := counts[watch.RowChanged]; c > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s changed", c, plural(c, "row")))
+	}
+	if c := counts[watch.RowDeleted]; c > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s deleted", c, plural(c, "row")))
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s*: %s", name, strings.Join(parts, ", "))
+	for i, l := range lines {
+		if i == maxLines {
+			fmt.Fprintf(&b, "\n…and %d more", len(lines)-maxLines)
+			break
+		}
+		b.WriteString("\n" + l)
+	}
+	return b.String()
+}
+
+func wants(ch Channel, t watch.EventType) bool {
+	if len(ch.Events) == 0 {
+		return true
+	}
+	for _, e := range ch.Events {
+		if e == t.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// columns returns the columns of r the channel cares about.
+func columns(ch Channel, r watch.Row) []string {
+	if len(ch.Columns) > 0 {
+		return ch.Columns
+	}
+	return r.Header()
+}
+
+func fields(ch Channel, r watch.Row) string {
+	var out []string
+	for _, c := range columns(ch, r) {
+		if v := r.Get(c); v != "" {
+			out = append(out, c+"="+v)
+		}
+	}
+	return strings.Join(out, ", ")
+}
+
+// changes describes the watched columns that differ between old and new,
+// or returns "" if none do.
+func changes(ch Channel, old, new watch.Row) string {
+	var out []string
+	for _, c := range columns(ch, new) {
+		if a, b := old.Get(c), new.Get(c); a != b {
+			out = append(out, fmt.Sprintf("%s %q → %q", c, a, b))
+		}
+	}
+	return strings.Join(out, ", ")
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+// post sends text to an incoming webhook. Slack and Google Chat both
+// accept a JSON object with a "text" field and the same *bold* markup.
+func (n *Notifier) post(ctx context.Context, url, text string) error {
+	body, _ := json.Marshal(map[string]string{"text": text})
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	client := n.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("unable to post to %s: %w", redact(url), err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("posting to %s: %s", redact(url), resp.Status)
+	}
+	return nil
+}
+
+// redact strips the secret parts of a webhook URL for error messages.
+func redact(url string) string {
+	if i := strings.Index(url, "?"); i >= 0 {
+		url = url[:i]
+	}
+	if i := strings.Index(url, "/services/"); i >= 0 {
+		url = url[:i] + "/services/…"
+	}
+	return url
+}
@@ -0,0 +1,190 @@
+// Package peek fetches small windows of large tabs: the first rows, the
+// last rows or a random sample, without downloading the whole tab.
+package peek
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// Window is a set of rows of a tab. The header is always row 1.
+type Window struct {
+	Header []interface{}
+	Rows   [][]interface{}
+	// Numbers holds the 1-based sheet row number of each row.
+	Numbers []int
+	// Total is the number of data rows below the header, when it was
+	// computed.
+	Total int
+}
+
+// Head returns the first n data rows of tab.
+func Head(ctx context.Context, srv *sheets.Service, spreadsheetID, tab string, n int) (*Window, error) {
+	resp, err := srv.Spreadsheets.Values.Get(spreadsheetID, rowsRange(tab, 1, n+1)).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("peek: unable to read %s: %w", tab, err)
+	}
+	w := &Window{}
+	for i, row := range resp.Values {
+		if i == 0 {
+			w.Header = row
+			continue
+		}
+		w.Rows = append(w.Rows, row)
+		w.Numbers = append(w.Numbers, i+1)
+	}
+	return w, nil
+}
+
+// Tail returns the last n data rows of tab.
+func Tail(ctx context.Context, srv *sheets.Service, spreadsheetID, tab string, n int) (*Window, error) {
+	last, err := LastRow(ctx, srv, spreadsheetID, tab)
+	if err != nil {
+		return nil, err
+	}
+	first := last - n + 1
+	if first < 2 {
+		first = 2
+	}
+	w := &Window{Total: last - 1}
+	if last < 2 {
+		w.Total = 0
+	}
+	ranges := []string{rowsRange(tab, 1, 1)}
+	if last >= 2 {
+		ranges = append(ranges, rowsRange(tab, first, last))
+	}
+	resp, err := srv.Spreadsheets.Values.BatchGet(spreadsheetID).Ranges(ranges...).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("peek: unable to read %s: %w", tab, err)
+	}
+	if vs := resp.ValueRanges[0].Values; len(vs) > 0 {
+		w.Header = vs[0]
+	}
+	if len(resp.ValueRanges) > 1 {
+		for i, row := range resp.ValueRanges[1].Values {
+			w.Rows = append(w.Rows, row)
+			w.Numbers = append(w.Numbers, first+i)
+		}
+	}
+	return w, nil
+}
+
+// Sample returns n data rows of tab picked at random, in sheet order. The
+// same seed picks the same rows as long as the tab does not grow.
+func Sample(ctx context.Context, srv *sheets.Service, spreadsheetID, tab string, n int, seed int64) (*Window, error) {
+	last, err := LastRow(ctx, srv, spreadsheetID, tab)
+	if err != nil {
+		return nil, err
+	}
+	total := last - 1
+	if total < 0 {
+		total = 0
+	}
+	var picked []int
+	if n >= total {
+		for r := 2; r <= last; r++ {
+			picked = append(picked, r)
+		}
+	} else {
+		for _, i := range rand.New(rand.NewSource(seed)).Perm(total)[:n] {
+			picked = append(picked, i+2)
+		}
+		sort.Ints(picked)
+	}
+
+	w := &Window{Total: total}
+	// Fetch runs of consecutive rows as one range, a bounded number of
+	// ranges per request to keep the URL short.
+	type run struct{ first, last int }
+	var runs []run
+	for _, r := range picked {
+		if len(runs) > 0 && runs[len(runs)-1].last == r-1 {
+			runs[len(runs)-1].last = r
+		} else {
+			runs = append(runs, run{r, r})
+		}
+	}
+	runs = append([]run{{1, 1}}, runs...)
+	const perRequest = 200
+	for len(runs) > 0 {
+		batch := runs
+		if len(batch) > perRequest {
+			batch = batch[:perRequest]
+		}
+		runs = runs[len(batch):]
+		ranges := make([]string, len(batch))
+		for i, r := range batch {
+			ranges[i] = rowsRange(tab, r.first, r.last)
+		}
+		resp, err := srv.Spreadsheets.Values.BatchGet(spreadsheetID).Ranges(ranges...).Context(ctx).Do()
+		if err != nil {
+			return nil, fmt.Errorf("peek: unable to read %s: %w", tab, err)
+		}
+		for i, vr := range resp.ValueRanges {
+			r := batch[i]
+			for j := 0; j <= r.last-r.first; j++ {
+				var row []interface{}
+				if j < len(vr.Values) {
+					row = vr.Values[j]
+				}
+				if r.first == 1 {
+					w.Header = row
+					continue
+				}
+				w.Rows = append(w.Rows, row)
+				w.Numbers = append(w.Numbers, r.first+j)
+			}
+		}
+	}
+	return w, nil
+}
+
+// LastRow returns the number of the last non-empty row of tab, or 0 if
+// the tab is empty. It reads windows backwards from the end of the grid,
+// doubling their size while they are empty, so only the last window with
+// data is downloaded.
+func LastRow(ctx context.Context, srv *sheets.Service, spreadsheetID, tab string) (int, error) {
+	resp, err := srv.Spreadsheets.Get(spreadsheetID).Fields("sheets.properties(title,gridProperties.rowCount)").Context(ctx).Do()
+	if err != nil {
+		return 0, fmt.Errorf("peek: unable to get tabs: %w", err)
+	}
+	end := -1
+	for _, sh := range resp.Sheets {
+		if sh.Properties.Title == tab && sh.Properties.GridProperties != nil {
+			end = int(sh.Properties.GridProperties.RowCount)
+		}
+	}
+	if end < 0 {
+		return 0, fmt.Errorf("peek: no tab named %q", tab)
+	}
+	for size := 100; end > 0; size *= 2 {
+		start := end - size + 1
+		if start < 1 {
+			start = 1
+		}
+		vr, err := srv.Spreadsheets.Values.Get(spreadsheetID, rowsRange(tab, start, end)).Context(ctx).Do()
+		if err != nil {
+			return 0, fmt.Errorf("peek: unable to read %s: %w", tab, err)
+		}
+		// Trailing empty rows are left out of the response, leading ones
+		// are not.
+		for i := len(vr.Values) - 1; i >= 0; i-- {
+			if len(vr.Values[i]) > 0 {
+				return start + i, nil
+			}
+		}
+		end = start - 1
+	}
+	return 0, nil
+}
+
+func rowsRange(tab string, first, last int) string {
+	return fmt.Sprintf("%s!%d:%d", sheetsclient.QuoteTab(tab), first, last)
+}
@@ -0,0 +1,291 @@
+// Package pivot creates pivot tables from a definition naming source
+// columns by header, and reads the definitions of existing pivot tables
+// back, so pivots can be kept in files and rebuilt after data refreshes.
+package pivot
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// Table defines a pivot table. Columns are referred to by their header in
+// the first row of Source.
+type Table struct {
+	// Source is the data range in A1 notation, e.g. "Data!A1:F". Leaving
+	// the last row open lets the pivot pick up appended rows.
+	Source string `json:"source"`
+	// Anchor is the top left cell of the pivot, e.g. "Pivot!A1".
+	Anchor  string   `json:"anchor"`
+	Rows    []Group  `json:"rows,omitempty"`
+	Columns []Group  `json:"columns,omitempty"`
+	Values  []Value  `json:"values"`
+	Filters []Filter `json:"filters,omitempty"`
+	// ValueLayout is "HORIZONTAL" (default) or "VERTICAL".
+	ValueLayout string `json:"valueLayout,omitempty"`
+}
+
+// Group groups rows or columns by the values of a source column.
+type Group struct {
+	Column string `json:"column"`
+	Label  string `json:"label,omitempty"`
+	// Descending sorts groups from high to low.
+	Descending bool `json:"descending,omitempty"`
+	HideTotals bool `json:"hideTotals,omitempty"`
+}
+
+// Value is an aggregated column.
+type Value struct {
+	// Column is the aggregated source column; empty for calculated values.
+	Column string `json:"column,omitempty"`
+	// Function is SUM, COUNTA, COUNT, COUNTUNIQUE, AVERAGE, MAX, MIN,
+	// MEDIAN, PRODUCT, STDEV, STDEVP, VAR, VARP or CUSTOM; defaults to SUM.
+	Function string `json:"function,omitempty"`
+	Name     string `json:"name,omitempty"`
+	// Formula defines a calculated value, e.g. "=Revenue/Units", and
+	// requires Function CUSTOM.
+	Formula string `json:"formula,omitempty"`
+	// Display shows the value as "PERCENT_OF_ROW_TOTAL",
+	// "PERCENT_OF_COLUMN_TOTAL" or "PERCENT_OF_GRAND_TOTAL".
+	Display string `json:"display,omitempty"`
+}
+
+// Filter limits the source rows included in the pivot.
+type Filter struct {
+	Column string `json:"column"`
+	// Visible lists the values to keep.
+	Visible []string `json:"visible,omitempty"`
+	// Formula, if set, keeps the rows for which the custom formula is
+	// true, e.g. "=Amount > 100".
+	Formula string `json:"formula,omitempty"`
+}
+
+// Create writes t at its anchor, replacing any pivot table already there.
+func Create(ctx context.Context, srv *sheets.Service, spreadsheetID string, t *Table) error {
+	tabs, err := sheetsclient.LoadTabs(ctx, srv, spreadsheetID)
+	if err != nil {
+		return err
+	}
+	source, err := tabs.GridRange(t.Source)
+	if err != nil {
+		return err
+	}
+	anchor, err := tabs.GridRange(t.Anchor)
+	if err != nil {
+		return err
+	}
+	header, err := readHeader(ctx, srv, spreadsheetID, tabs.Titles[source.SheetId], source)
+	if err != nil {
+		return err
+	}
+	pt, err := t.spec(source, header)
+	if err != nil {
+		return err
+	}
+	_, err = srv.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{UpdateCells: &sheets.UpdateCellsRequest{
+			Start: &sheets.GridCoordinate{
+				SheetId:         anchor.SheetId,
+				RowIndex:        anchor.StartRowIndex,
+				ColumnIndex:     anchor.StartColumnIndex,
+				ForceSendFields: []string{"SheetId", "RowIndex", "ColumnIndex"},
+			},
+			Rows:   []*sheets.RowData{{Values: []*sheets.CellData{{PivotTable: pt}}}},
+			Fields: "pivotTable",
+		}}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("pivot: unable to create pivot table at %s: %w", t.Anchor, err)
+	}
+	return nil
+}
+
+// Read returns the definitions of all pivot tables in a spreadsheet.
+func Read(ctx context.Context, srv *sheets.Service, spreadsheetID string) ([]*Table, error) {
+	resp, err := srv.Spreadsheets.Get(spreadsheetID).
+		Fields("sheets(properties(sheetId,title),data(startRow,startColumn,rowData.values.pivotTable))").
+		Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("pivot: unable to get pivot tables: %w", err)
+	}
+	names := map[int64]string{}
+	for _, sh := range resp.Sheets {
+		names[sh.Properties.SheetId] = sh.Properties.Title
+	}
+	var out []*Table
+	for _, sh := range resp.Sheets {
+		for _, d := range sh.Data {
+			for r, row := range d.RowData {
+				for c, cell := range row.Values {
+					pt := cell.PivotTable
+					if pt == nil || pt.Source == nil {
+						continue
+					}
+					tab := names[pt.Source.SheetId]
+					header, err := readHeader(ctx, srv, spreadsheetID, tab, pt.Source)
+					if err != nil {
+						return nil, err
+					}
+					anchor := fmt.Sprintf("%s!%s%d", sheetsclient.QuoteTab(sh.Properties.Title),
+						sheetsclient.ColumnLetters(int(d.StartColumn)+c+1), d.StartRow+int64(r)+1)
+					out = append(out, fromSpec(pt, sheetsclient.FormatRange(tab, pt.Source), anchor, header))
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+func (t *Table) spec(source *sheets.GridRange, header []string) (*sheets.PivotTable, error) {
+	offset := func(col string) (int64, error) {
+		for i, h := range header {
+			if h == col {
+				return int64(i), nil
+			}
+		}
+		return 0, fmt.Errorf("pivot: source has no column %q", col)
+	}
+	group := func(g Group) (*sheets.PivotGroup, error) {
+		off, err := offset(g.Column)
+		if err != nil {
+			return nil, err
+		}
+		pg := &sheets.PivotGroup{
+			SourceColumnOffset: off,
+			Label:              g.Label,
+			ShowTotals:         !g.HideTotals,
+			SortOrder:          "ASCENDING",
+			ForceSendFields:    []string{"SourceColumnOffset", "ShowTotals"},
+		}
+		if g.Descending {
+			pg.SortOrder = "DESCENDING"
+		}
+		return pg, nil
+	}
+
+	pt := &sheets.PivotTable{Source: source, ValueLayout: t.ValueLayout}
+	for _, g := range t.Rows {
+		pg, err := group(g)
+		if err != nil {
+			return nil, err
+		}
+		pt.Rows = append(pt.Rows, pg)
+	}
+	for _, g := range t.Columns {
+		pg, err := group(g)
+		if err != nil {
+			return nil, err
+		}
+		pt.Columns = append(pt.Columns, pg)
+	}
+	if len(t.Values) == 0 {
+		return nil, fmt.Errorf("pivot: pivot table at %s has no values", t.Anchor)
+	}
+	for _, v := range t.Values {
+		pv := &sheets.PivotValue{
+			SummarizeFunction:     v.Function,
+			Name:                  v.Name,
+			Formula:               v.Formula,
+			CalculatedDisplayType: v.Display,
+		}
+		if pv.SummarizeFunction == "" {
+			pv.SummarizeFunction = "SUM"
+		}
+		if v.Formula == "" {
+			off, err := offset(v.Column)
+			if err != nil {
+				return nil, err
+			}
+			pv.SourceColumnOffset = off
+			pv.ForceSendFields = []string{"SourceColumnOffset"}
+		}
+		pt.Values = append(pt.Values, pv)
+	}
+	for _, f := range t.Filters {
+		off, err := offset(f.Column)
+		if err != nil {
+			return nil, err
+		}
+		crit := &sheets.PivotFilterCriteria{VisibleValues: f.Visible}
+		if f.Formula != "" {
+			crit.Condition = &sheets.BooleanCondition{
+				Type:   "CUSTOM_FORMULA",
+				Values: []*sheets.ConditionValue{{UserEnteredValue: f.Formula}},
+			}
+		}
+		pt.FilterSpecs = append(pt.FilterSpecs, &sheets.PivotFilterSpec{
+			ColumnOffsetIndex: off,
+			FilterCriteria:    crit,
+			ForceSendFields:   []string{"ColumnOffsetIndex"},
+		})
+	}
+	return pt, nil
+}
+
+func fromSpec(pt *sheets.PivotTable, source, anchor string, header []string) *Table {
+	name := func(off int64) string {
+		if off >= 0 && off < int64(len(header)) {
+			return header[off]
+		}
+		return fmt.Sprintf("column %d", off+1)
+	}
+	group := func(pg *sheets.PivotGroup) Group {
+		return Group{
+			Column:     name(pg.SourceColumnOffset),
+			Label:      pg.Label,
+			Descending: pg.SortOrder == "DESCENDING",
+			HideTotals: !pg.ShowTotals,
+		}
+	}
+	t := &Table{Source: source, Anchor: anchor, ValueLayout: pt.ValueLayout}
+	if t.ValueLayout == "HORIZONTAL" {
+		t.ValueLayout = ""
+	}
+	for _, pg := range pt.Rows {
+		t.Rows = append(t.Rows, group(pg))
+	}
+	for _, pg := range pt.Columns {
+		t.Columns = append(t.Columns, group(pg))
+	}
+	for _, pv := range pt.Values {
+		v := Value{Function: pv.SummarizeFunction, Name: pv.Name, Formula: pv.Formula, Display: pv.CalculatedDisplayType}
+		if pv.Formula == "" {
+			v.Column = name(pv.SourceColumnOffset)
+		}
+		t.Values = append(t.Values, v)
+	}
+	for _, fs := range pt.FilterSpecs {
+		f := Filter{Column: name(fs.ColumnOffsetIndex)}
+		if c := fs.FilterCriteria; c != nil {
+			f.Visible = c.VisibleValues
+			if c.Condition != nil && c.Condition.Type == "CUSTOM_FORMULA" && len(c.Condition.Values) > 0 {
+				f.Formula = c.Condition.Values[0].UserEnteredValue
+			}
+		}
+		t.Filters = append(t.Filters, f)
+	}
+	return t
+}
+
+// readHeader returns the first row of the source range.
+func readHeader(ctx context.Context, srv *sheets.Service, spreadsheetID, tab string, source *sheets.GridRange) ([]string, error) {
+	// Read the whole row, so an open ended source needs no column bound.
+	rng := fmt.Sprintf("%s!%d:%d", sheetsclient.QuoteTab(tab), source.StartRowIndex+1, source.StartRowIndex+1)
+	resp, err := srv.Spreadsheets.Values.Get(spreadsheetID, rng).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("pivot: unable to read header of %s: %w", tab, err)
+	}
+	var header []string
+	if len(resp.Values) > 0 {
+		for i, v := range resp.Values[0] {
+			if int64(i) < source.StartColumnIndex || (source.EndColumnIndex > 0 && int64(i) >= source.EndColumnIndex) {
+				continue
+			}
+			header = append(header, fmt.Sprint(v))
+		}
+	}
+	return header, nil
+}
@@ -0,0 +1,48 @@
+package publish
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"golang.org/x/net/context"
+)
+
+// Kafka publishes messages to a Kafka topic. Messages with the same key
+// land on the same partition, preserving their order.
+type Kafka struct {
+	w *kafka.Writer
+}
+
+// NewKafka returns a publisher writing to topic on the given brokers. Every
+// write waits for all in-sync replicas to acknowledge it.
+func NewKafka(brokers []string, topic string) *Kafka {
+	return &Kafka{w: &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		MaxAttempts:  5,
+		BatchTimeout: 10 * time.Millisecond,
+	}}
+}
+
+// Publish implements Publisher.
+func (k *Kafka) Publish(ctx context.Context, msgs []*Message) error {
+	out := make([]kafka.Message, len(msgs))
+	for i, m := range msgs {
+		b, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		out[i] = kafka.Message{
+			Key:     []byte(m.Key),
+			Value:   b,
+			Headers: []kafka.Header{{Key: "id", Value: []byte(m.ID)}},
+		}
+	}
+	return k.w.WriteMessages(ctx, out...)
+}
+
+// Close implements Publisher.
+func (k *Kafka) Close() error { return k.w.Close() }
@@ -0,0 +1,70 @@
+package publish
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+	"golang.org/x/net/context"
+)
+
+// NATS publishes messages through JetStream, which acknowledges each
+// message once stored. A stream must be configured for the subject.
+type NATS struct {
+	nc      *nats.Conn
+	js      nats.JetStreamContext
+	subject string
+}
+
+// NewNATS connects to the server at url and returns a publisher for
+// subject. The message key is appended to the subject, as in
+// "subject.key", so consumers can filter on it.
+func NewNATS(url, subject string) (*NATS, error) {
+	nc, err := nats.Connect(url)
+	if err != nil {
+		return nil, fmt.Errorf("publish: unable to connect to %s: %w", url, err)
+	}
+	js, err := nc.JetStream()
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("publish: JetStream unavailable: %w", err)
+	}
+	return &NATS{nc: nc, js: js, subject: subject}, nil
+}
+
+// Publish implements Publisher. JetStream drops messages whose ID it has
+// already stored within its duplicate window.
+func (n *NATS) Publish(ctx context.Context, msgs []*Message) error {
+	for _, m := range msgs {
+		b, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		subj := n.subject
+		if m.Key != "" {
+			subj += "." + subjectToken(m.Key)
+		}
+		if _, err := n.js.Publish(subj, b, nats.MsgId(m.ID), nats.Context(ctx)); err != nil {
+			return fmt.Errorf("publish: unable to publish to %s: %w", subj, err)
+		}
+	}
+	return nil
+}
+
+// subjectToken replaces the characters NATS reserves in subjects.
+func subjectToken(s string) string {
+	out := []rune(s)
+	for i, r := range out {
+		switch r {
+		case '.', '*', '>', ' ', '\t', '\n', '\r':
+			out[i] = '_'
+		}
+	}
+	return string(out)
+}
+
+// Close implements Publisher.
+func (n *NATS) Close() error {
+	n.nc.Close()
+	return nil
+}
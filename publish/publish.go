@@ -0,0 +1,131 @@
+// Package publish forwards detected row changes to a message broker as
+// JSON events.
+//
+// Publishers send synchronously and only return once the broker has
+// acknowledged the message. Combined with Watcher.OnBatch, which retries
+// a poll until its events are delivered, this gives at-least-once
+// delivery: consumers may see an event twice and should deduplicate on
+// Message.ID.
+package publish
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsync"
+	"github.com/prantoran/GoogleSheets_GO/watch"
+)
+
+// Message is the JSON payload of a row change event.
+type Message struct {
+	// ID identifies the change; redelivered events keep the same ID,
+	// while a row changed to a value it had before gets a new one.
+	ID            string            `json:"id"`
+	Type          string            `json:"type"` // added, changed or deleted
+	SpreadsheetID string            `json:"spreadsheet_id"`
+	Range         string            `json:"range"`
+	Key           string            `json:"key"`
+	Old           map[string]string `json:"old,omitempty"`
+	New           map[string]string `json:"new,omitempty"`
+	// Direction is set for changes applied by a sync: "push" for edits
+	// written to the sheet, "pull" for edits written to the local store.
+	Direction string    `json:"direction,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// Publisher sends messages to a broker.
+type Publisher interface {
+	// Publish sends msgs, keyed by Message.Key, and returns once they
+	// have all been acknowledged.
+	Publish(ctx context.Context, msgs []*Message) error
+	Close() error
+}
+
+// Open returns a publisher for a URL of the form
+// kafka://broker1:9092,broker2:9092/topic or nats://host:4222/subject.
+func Open(rawurl string) (Publisher, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, fmt.Errorf("publish: invalid URL %q: %w", rawurl, err)
+	}
+	topic := strings.TrimPrefix(u.Path, "/")
+	if topic == "" {
+		return nil, fmt.Errorf("publish: URL %q has no topic", rawurl)
+	}
+	switch u.Scheme {
+	case "kafka":
+		return NewKafka(strings.Split(u.Host, ","), topic), nil
+	case "nats":
+		return NewNATS("nats://"+u.Host, topic)
+	}
+	return nil, fmt.Errorf("publish: unsupported scheme %q", u.Scheme)
+}
+
+// FromWatch converts watcher events on the given range.
+func FromWatch(spreadsheetID, rng string, events []watch.Event) []*Message {
+	now := time.Now().UTC()
+	msgs := make([]*Message, len(events))
+	for i, e := range events {
+		m := &Message{Type: e.Type.String(), SpreadsheetID: spreadsheetID, Range: rng, Key: e.Key, Time: now}
+		if e.Old != nil {
+			m.Old = e.Old.Fields()
+		}
+		if e.New != nil {
+			m.New = e.New.Fields()
+		}
+		m.ID = messageID(m, e.Since)
+		msgs[i] = m
+	}
+	return msgs
+}
+
+// FromSync converts the changes applied by a sync of one tab.
+func FromSync(spreadsheetID string, res *sheetsync.Result) []*Message {
+	now := time.Now().UTC()
+	var msgs []*Message
+	for _, dir := range []struct {
+		name    string
+		changes []sheetsync.Change
+	}{{"push", res.Pushed}, {"pull", res.Pulled}} {
+		for _, c := range dir.changes {
+			m := &Message{SpreadsheetID: spreadsheetID, Range: res.Tab, Key: c.Record.Key, Direction: dir.name, Time: now}
+			fields := map[string]string{}
+			for i, h := range res.Header {
+				if i < len(c.Record.Values) {
+					fields[h] = c.Record.Values[i]
+				} else {
+					fields[h] = ""
+				}
+			}
+			switch c.Op {
+			case sheetsync.Insert:
+				m.Type, m.New = "added", fields
+			case sheetsync.Update:
+				m.Type, m.New = "changed", fields
+			case sheetsync.Delete:
+				m.Type, m.Old = "deleted", fields
+			}
+			m.ID = messageID(m, now)
+			msgs = append(msgs, m)
+		}
+	}
+	return msgs
+}
+
+// messageID hashes the content of m and since, which tells apart changes
+// that look alike. For watcher events it is Event.Since, so that the same
+// change detected again after a failed delivery gets the same ID; a sync
+// does not detect applied changes again and uses its own time.
+func messageID(m *Message, since time.Time) string {
+	h := sha1.New()
+	b, _ := json.Marshal([]interface{}{m.Type, m.SpreadsheetID, m.Range, m.Key, m.Old, m.New, m.Direction, since.UnixNano()})
+	h.Write(b)
+	return hex.EncodeToString(h.Sum(nil))
+}
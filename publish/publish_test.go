@@ -0,0 +1,29 @@
+package publish
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prantoran/GoogleSheets_GO/watch"
+)
+
+func TestFromWatchIDs(t *testing.T) {
+	old := watch.NewSnapshot([][]interface{}{{"sku", "qty"}, {"a", "1"}})
+	cur := watch.NewSnapshot([][]interface{}{{"sku", "qty"}, {"a", "2"}})
+	events := watch.Diff(old, cur, "sku")
+	if len(events) != 1 {
+		t.Fatalf("Diff = %v, want one change", events)
+	}
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	id := func(since time.Time) string {
+		e := events[0]
+		e.Since = since
+		return FromWatch("s", "Data", []watch.Event{e})[0].ID
+	}
+	if id(t0) != id(t0) {
+		t.Error("the same change detected again got a new ID")
+	}
+	if id(t0) == id(t0.Add(time.Minute)) {
+		t.Error("a repeated change reused the ID of an earlier one")
+	}
+}
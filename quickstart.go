@@ -1,171 +1,160 @@
 package main
 
 import (
-	"encoding/csv"
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
+	"errors"
+	"flag"
 	"log"
 	"net/http"
-	"net/url"
 	"os"
-	"os/user"
-	"path/filepath"
 
 	"golang.org/x/net/context"
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
+	"google.golang.org/api/drive/v3"
 	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
 )
 
-// getClient uses a Context and Config to retrieve a Token
-// then generate a Client. It returns the generated Client.
-func getClient(ctx context.Context, config *oauth2.Config) *http.Client {
-	cacheFile, err := tokenCacheFile()
-	if err != nil {
-		log.Fatalf("Unable to get path to cached credential file. %v", err)
+// scopes lists the OAuth scopes requested by every command; commands
+// needing more append to it before creating a service. A cached token
+// missing one of them is authorized again.
+var scopes = append([]string(nil), sheetsclient.Scopes...)
+
+// Global flags, given before the command name.
+var (
+	credentialsFile = flag.String("credentials", "", "service account JSON key")
+	noBrowser       = flag.Bool("no-browser", false, "authorize by pasting a code instead of through a local redirect")
+	impersonate     = flag.String("impersonate", "", "user the service account of -credentials acts as through domain-wide delegation")
+	readRate        = flag.Int("read-rate", 60, "read requests per minute; -1 disables the limit")
+	writeRate       = flag.Int("write-rate", 60, "write requests per minute; -1 disables the limit")
+	timeout         = flag.Duration("timeout", 0, "cancel the command after this long, e.g. 10m; 0 means no limit")
+	maxAttempts     = flag.Int("max-attempts", 5, "tries per API request when rate limited or on server errors; 1 disables retries")
+	encryptToken    = flag.String("encrypt-token", "", "encrypt the cached OAuth token with a key bound to this machine (machine) or $SHEETS_TOKEN_PASSPHRASE (passphrase)")
+	profile         = flag.String("profile", "", "named credential profile to act as, see the profile command (default the active profile, if any)")
+)
+
+// limiter paces the requests of every client made by newHTTPClient, as
+// quotas are per user rather than per client.
+var limiter *sheetsclient.Limiter
+
+// tokenFile is the OAuth token cache used, for the hint of checkError.
+var tokenFile string
+
+// newHTTPClient returns an authorized Client, acting as the service account
+// of -credentials if given, then trying Application Default Credentials,
+// and finally authorizing as a user with client_secret.json. With a
+// profile, from -profile or made active with "profile use", its own
+// credentials are used instead of the last two. Requests are paced by
+// -read-rate and -write-rate and retried as configured by -max-attempts.
+func newHTTPClient(ctx context.Context) *http.Client {
+	auth := &sheetsclient.Chain{Scopes: scopes}
+	name := *profile
+	if name == "" {
+		var err error
+		name, err = sheetsclient.ActiveProfile()
+		checkError("Unable to read active profile: ", err)
 	}
-	tok, err := tokenFromFile(cacheFile)
-	if err != nil {
-		tok = getTokenFromWeb(config)
-		saveToken(cacheFile, tok)
+	if name != "" {
+		p, err := sheetsclient.OpenProfile(name)
+		checkError("Unable to open profile: ", err)
+		if !p.Exists() {
+			log.Fatalf("No profile named %q; create it with: profile add %s -client-secret FILE", name, name)
+		}
+		auth = p.Chain(scopes)
+		tokenFile = p.TokenFile()
 	}
-	return config.Client(ctx, tok)
-}
-
-// getTokenFromWeb uses Config to request a Token.
-// It returns the retrieved Token.
-func getTokenFromWeb(config *oauth2.Config) *oauth2.Token {
-	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
-	fmt.Printf("Go to the following link in your browser then type the "+
-		"authorization code: \n%v\n", authURL)
-
-	var code string
-	if _, err := fmt.Scan(&code); err != nil {
-		log.Fatalf("Unable to read authorization code %v", err)
+	if *credentialsFile != "" {
+		auth.KeyFile = *credentialsFile
 	}
-
-	tok, err := config.Exchange(oauth2.NoContext, code)
-	if err != nil {
-		log.Fatalf("Unable to retrieve token from web %v", err)
+	auth.Subject = *impersonate
+	auth.OAuth.NoBrowser = *noBrowser
+	switch *encryptToken {
+	case "":
+	case "machine":
+		auth.OAuth.Encryption = &sheetsclient.TokenEncryption{}
+	case "passphrase":
+		pass := os.Getenv("SHEETS_TOKEN_PASSPHRASE")
+		if pass == "" {
+			log.Fatal("-encrypt-token passphrase needs $SHEETS_TOKEN_PASSPHRASE")
+		}
+		auth.OAuth.Encryption = &sheetsclient.TokenEncryption{Passphrase: pass}
+	default:
+		log.Fatalf("Unknown -encrypt-token %q, want machine or passphrase", *encryptToken)
 	}
-	return tok
-}
-
-// tokenCacheFile generates credential file path/filename.
-// It returns the generated credential path/filename.
-func tokenCacheFile() (string, error) {
-	usr, err := user.Current()
-	if err != nil {
-		return "", err
+	client, err := auth.HTTPClient(ctx)
+	checkError("Unable to authorize: ", err)
+	if limiter == nil {
+		limiter = &sheetsclient.Limiter{ReadsPerMinute: *readRate, WritesPerMinute: *writeRate}
 	}
-	tokenCacheDir := filepath.Join(usr.HomeDir, ".credentials")
-	os.MkdirAll(tokenCacheDir, 0700)
-	return filepath.Join(tokenCacheDir,
-		url.QueryEscape("sheets.googleapis.com-go-quickstart.json")), err
+	client = sheetsclient.WithLimit(client, limiter)
+	return sheetsclient.WithRetry(client, &sheetsclient.Retry{MaxAttempts: *maxAttempts})
 }
 
-// tokenFromFile retrieves a Token from a given file path.
-// It returns the retrieved Token and any read error encountered.
-func tokenFromFile(file string) (*oauth2.Token, error) {
-	log.Printf("tokenFromFile() file: %v \n\n", file)
-	f, err := os.Open(file)
-	if err != nil {
-		return nil, err
-	}
-	t := &oauth2.Token{}
-	err = json.NewDecoder(f).Decode(t)
-
-	log.Printf("tokenFromFile()\nt.AccessToken: %v \nt.tokenType: %v\nt.RefreshToken: %v \nt.expiry: %v\n\n", t.AccessToken, t.TokenType, t.RefreshToken, t.Expiry)
-
-	defer f.Close()
-	return t, err
+// newClient returns an authorized sheetsclient.Client.
+func newClient(ctx context.Context) *sheetsclient.Client {
+	return sheetsclient.FromService(newSheetsService(ctx))
 }
 
-// saveToken uses a file path to create a file and store the
-// token in it.
-func saveToken(file string, token *oauth2.Token) {
-	fmt.Printf("Saving credential file to: %s\n", file)
-	f, err := os.OpenFile(file, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+// newSheetsService returns an authorized Sheets service.
+func newSheetsService(ctx context.Context) *sheets.Service {
+	srv, err := sheets.New(newHTTPClient(ctx))
 	if err != nil {
-		log.Fatalf("Unable to cache oauth token: %v", err)
+		log.Fatalf("Unable to retrieve Sheets Client %v", err)
 	}
-	defer f.Close()
-	json.NewEncoder(f).Encode(token)
+	return srv
 }
 
-func main() {
-	ctx := context.Background()
-
-	b, err := ioutil.ReadFile("client_secret.json")
+// newDriveService returns an authorized Drive service.
+func newDriveService(ctx context.Context) *drive.Service {
+	srv, err := drive.New(newHTTPClient(ctx))
 	if err != nil {
-		log.Fatalf("Unable to read client secret file: %v", err)
+		log.Fatalf("Unable to retrieve Drive Client %v", err)
 	}
+	return srv
+}
 
-	// If modifying these scopes, delete your previously saved credentials
-	// at ~/.credentials/sheets.googleapis.com-go-quickstart.json
-	config, err := google.ConfigFromJSON(b, "https://www.googleapis.com/auth/spreadsheets.readonly")
-	if err != nil {
-		log.Fatalf("Unable to parse client secret file to config: %v", err)
-	}
-	client := getClient(ctx, config)
-
-	srv, err := sheets.New(client)
-	if err != nil {
-		log.Fatalf("Unable to retrieve Sheets Client %v", err)
-	}
-
-	// Prints the names and majors of students in a sample spreadsheet:
-	// https://docs.google.com/spreadsheets/d/1BxiMVs0XRA5nFMdKvBdBZjgmUUqptlbs74OgvE2upms/edit
-	//spreadsheetId := "1BxiMVs0XRA5nFMdKvBdBZjgmUUqptlbs74OgvE2upms"
-
-	// https://docs.google.com/spreadsheets/d/1zFjra05ZGfaVgKNorPdvAU-bh0QDkOn-CVoXjWtiw2w/edit
-	spreadsheetId := "1zFjra05ZGfaVgKNorPdvAU-bh0QDkOn-CVoXjWtiw2w"
-
-	//readRange := "Class Data!A2:E"
-	readRange := "A3:F6"
-	resp, err := srv.Spreadsheets.Values.Get(spreadsheetId, readRange).Do()
-	if err != nil {
-		log.Fatalf("Unable to retrieve data from sheet. %v", err)
-	}
+// cmdCtx is the context of the running command, cancelled after -timeout.
+var cmdCtx = context.Background()
 
-	log.Printf("\nresp: %v\n\nresp.Values: %v\n\nresp.Values type: %T \n\n", resp, resp.Values, resp.Values)
+// commandContext returns the context commands pass to API calls, so that
+// -timeout stops a hanging command with an error instead of leaving it
+// waiting on the network.
+func commandContext() context.Context { return cmdCtx }
 
-	if len(resp.Values) > 0 {
-		fmt.Println("Name, Major:")
-		for _, row := range resp.Values {
-			// Print columns A and E, which correspond to indices 0 and 4.
-			// fmt.Printf("%s, %s\n", row[0], row[4])
-			fmt.Printf("Row Type: %T\n", row)
-			for _, item := range row {
-				fmt.Printf("%s \t", item)
-			}
-			fmt.Printf("\n")
-		}
-	} else {
-		fmt.Print("No data found.")
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
 	}
-
-	file, err := os.Create("gsheet_result.csv")
-	checkError("Cannot create file", err)
-	defer file.Close()
-
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-
-	for _, value := range resp.Values {
-		cur := []string{}
-		for _, item := range value {
-			cur = append(cur, item.(string))
-		}
-		err := writer.Write(cur)
-		checkError("Cannot write to file", err)
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		cmdCtx, cancel = context.WithTimeout(cmdCtx, *timeout)
+		defer cancel()
 	}
-
+	runCommand(flag.Arg(0), flag.Args()[1:])
 }
 
+// checkError exits with message and err, if err is not nil, adding a hint
+// for errors users can fix themselves.
 func checkError(message string, err error) {
-	if err != nil {
-		log.Fatal(message, err)
+	if err == nil {
+		return
+	}
+	err = sheetsclient.Classify(err)
+	switch {
+	case errors.Is(err, sheetsclient.ErrTokenExpired):
+		token := tokenFile
+		if token == "" {
+			token, _ = sheetsclient.DefaultTokenFile()
+		}
+		log.Fatal(message, err, "\nDelete ", token, " to authorize again.")
+	case errors.Is(err, sheetsclient.ErrQuotaExceeded):
+		log.Fatal(message, err, "\nLower -read-rate or -write-rate, or raise -max-attempts.")
+	case errors.Is(err, sheetsclient.ErrPermissionDenied):
+		log.Fatal(message, err, "\nCheck that the authorized account may change the spreadsheet or file.")
+	case errors.Is(err, sheetsclient.ErrNotFound):
+		log.Fatal(message, err, "\nCheck the spreadsheet ID and that it is shared with the authorized account.")
 	}
+	log.Fatal(message, err)
 }
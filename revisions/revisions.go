@@ -0,0 +1,111 @@
+// Package revisions lists the Drive revision history of a spreadsheet and
+// exports the spreadsheet as it was at a given revision.
+package revisions
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/drive/v3"
+)
+
+// Export formats offered by Drive for spreadsheet revisions.
+var Formats = map[string]string{
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"ods":  "application/x-vnd.oasis.opendocument.spreadsheet",
+	"pdf":  "application/pdf",
+	"csv":  "text/csv", // first tab only
+	"tsv":  "text/tab-separated-values",
+}
+
+// Revision is one saved version of a file.
+type Revision struct {
+	ID       string    `json:"id"`
+	Modified time.Time `json:"modified"`
+	// Author is who made the changes saved in this revision.
+	Author string `json:"author"`
+	Email  string `json:"email,omitempty"`
+	// ExportLinks maps MIME types to download URLs of this revision.
+	ExportLinks map[string]string `json:"-"`
+}
+
+// List returns the revisions of a file, oldest first. Drive merges
+// revisions that are close together, so this is coarser than the edit
+// history shown in the Sheets UI.
+func List(ctx context.Context, srv *drive.Service, fileID string) ([]*Revision, error) {
+	var out []*Revision
+	call := srv.Revisions.List(fileID).PageSize(200).
+		Fields("nextPageToken,revisions(id,modifiedTime,lastModifyingUser(displayName,emailAddress),exportLinks)")
+	err := call.Pages(ctx, func(page *drive.RevisionList) error {
+		for _, r := range page.Revisions {
+			rev := &Revision{ID: r.Id, ExportLinks: r.ExportLinks}
+			rev.Modified, _ = time.Parse(time.RFC3339, r.ModifiedTime)
+			if u := r.LastModifyingUser; u != nil {
+				rev.Author, rev.Email = u.DisplayName, u.EmailAddress
+			}
+			out = append(out, rev)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("revisions: unable to list revisions of %s: %w", fileID, err)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Modified.Before(out[j].Modified) })
+	return out, nil
+}
+
+// At returns the revision that was current at t: the last one saved at
+// or before t, or nil if the file did not exist yet.
+func At(revs []*Revision, t time.Time) *Revision {
+	var cur *Revision
+	for _, r := range revs {
+		if r.Modified.After(t) {
+			break
+		}
+		cur = r
+	}
+	return cur
+}
+
+// Find returns the revision with the given ID.
+func Find(revs []*Revision, id string) *Revision {
+	for _, r := range revs {
+		if r.ID == id {
+			return r
+		}
+	}
+	return nil
+}
+
+// Export downloads rev in the given format, one of the keys of Formats,
+// and writes it to w. client must be authorized to read file contents.
+func Export(ctx context.Context, client *http.Client, rev *Revision, format string, w io.Writer) error {
+	mime, ok := Formats[format]
+	if !ok {
+		return fmt.Errorf("revisions: unknown format %q", format)
+	}
+	link, ok := rev.ExportLinks[mime]
+	if !ok {
+		return fmt.Errorf("revisions: revision %s cannot be exported as %s", rev.ID, format)
+	}
+	req, err := http.NewRequest("GET", link, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("revisions: unable to export revision %s: %w", rev.ID, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("revisions: unable to export revision %s: %s", rev.ID, resp.Status)
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("revisions: unable to export revision %s: %w", rev.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+package rowfilter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// node is a parsed expression.
+type node interface {
+	eval(row []interface{}) interface{}
+}
+
+type literal struct{ v interface{} }
+
+func (l literal) eval([]interface{}) interface{} { return l.v }
+
+type column int
+
+func (c column) eval(row []interface{}) interface{} {
+	if int(c) < len(row) {
+		return row[c]
+	}
+	return nil
+}
+
+type not struct{ x node }
+
+func (n not) eval(row []interface{}) interface{} { return !truthy(n.x.eval(row)) }
+
+type logical struct {
+	and  bool
+	l, r node
+}
+
+func (l logical) eval(row []interface{}) interface{} {
+	if truthy(l.l.eval(row)) != l.and {
+		return !l.and
+	}
+	return truthy(l.r.eval(row))
+}
+
+type comparison struct {
+	op   string
+	l, r node
+	re   *regexp.Regexp
+}
+
+func (c comparison) eval(row []interface{}) interface{} {
+	l, r := c.l.eval(row), c.r.eval(row)
+	if c.op == "~=" {
+		return c.re.MatchString(text(l))
+	}
+	var cmp int
+	ln, lok := number(l)
+	rn, rok := number(r)
+	switch {
+	case lok && rok:
+		switch {
+		case ln < rn:
+			cmp = -1
+		case ln > rn:
+			cmp = 1
+		}
+	default:
+		cmp = strings.Compare(text(l), text(r))
+	}
+	switch c.op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	}
+	return cmp >= 0
+}
+
+// parser is a recursive descent parser over the grammar
+//
+//	or      = and { ("or" | "||") and }
+//	and     = unary { ("and" | "&&") unary }
+//	unary   = ("not" | "!") unary | compare
+//	compare = operand [ op operand ]
+//	operand = NUMBER | STRING | IDENT | `NAME` | "(" or ")"
+type parser struct {
+	f   *Filter
+	src string
+	pos int
+	tok string
+	// start is the offset of tok in src.
+	start int
+	// kind is the kind of tok: 'n'umber, 's'tring, 'i'dent, 'o'perator
+	// or 0 at the end.
+	kind byte
+}
+
+func (p *parser) parse() (node, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.kind != 0 {
+		return nil, p.errorf("unexpected %q", p.tok)
+	}
+	return n, nil
+}
+
+// errorf reports an error at the current token.
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return p.errorAt(p.start, format, args...)
+}
+
+func (p *parser) errorAt(offset int, format string, args ...interface{}) error {
+	return fmt.Errorf("rowfilter: %s at offset %d of %q", fmt.Sprintf(format, args...), offset, p.src)
+}
+
+func (p *parser) is(words ...string) bool {
+	for _, w := range words {
+		if (p.kind == 'o' && p.tok == w) || (p.kind == 'i' && strings.EqualFold(p.tok, w)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) or() (node, error) {
+	l, err := p.and()
+	for err == nil && p.is("or", "||") {
+		var r node
+		if err = p.next(); err == nil {
+			if r, err = p.and(); err == nil {
+				l = logical{false, l, r}
+			}
+		}
+	}
+	return l, err
+}
+
+func (p *parser) and() (node, error) {
+	l, err := p.unary()
+	for err == nil && p.is("and", "&&") {
+		var r node
+		if err = p.next(); err == nil {
+			if r, err = p.unary(); err == nil {
+				l = logical{true, l, r}
+			}
+		}
+	}
+	return l, err
+}
+
+func (p *parser) unary() (node, error) {
+	if p.is("not", "!") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		x, err := p.unary()
+		return not{x}, err
+	}
+	return p.compare()
+}
+
+func (p *parser) compare() (node, error) {
+	l, err := p.operand()
+	if err != nil || !p.is("==", "!=", "<", "<=", ">", ">=", "~=", "=") {
+		return l, err
+	}
+	op := p.tok
+	if op == "=" {
+		op = "=="
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	at := p.start
+	r, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	c := comparison{op: op, l: l, r: r}
+	if op == "~=" {
+		lit, ok := r.(literal)
+		if !ok {
+			return nil, p.errorAt(at, "~= needs a literal pattern")
+		}
+		if c.re, err = regexp.Compile(text(lit.v)); err != nil {
+			return nil, p.errorAt(at, "invalid pattern: %v", err)
+		}
+	}
+	return c, nil
+}
+
+func (p *parser) operand() (node, error) {
+	tok, kind := p.tok, p.kind
+	switch {
+	case kind == 'o' && tok == "(":
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.is(")") {
+			return nil, p.errorf("missing )")
+		}
+		return n, p.next()
+	case kind == 'n':
+		n, _ := strconv.ParseFloat(tok, 64)
+		return literal{n}, p.next()
+	case kind == 's':
+		return literal{tok}, p.next()
+	case kind == 'i' || kind == 'q':
+		switch strings.ToLower(tok) {
+		case "true":
+			if kind == 'i' {
+				return literal{true}, p.next()
+			}
+		case "false":
+			if kind == 'i' {
+				return literal{false}, p.next()
+			}
+		}
+		i := p.f.column(tok)
+		if i < 0 {
+			return nil, p.errorf("no column %q", tok)
+		}
+		return column(i), p.next()
+	case kind == 0:
+		return nil, p.errorf("unexpected end")
+	}
+	return nil, p.errorf("unexpected %q", tok)
+}
+
+// next reads the next token.
+func (p *parser) next() error {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+	start := p.pos
+	p.start = start
+	if p.pos >= len(p.src) {
+		p.tok, p.kind = "", 0
+		return nil
+	}
+	c := p.src[p.pos]
+	switch {
+	case c == '\'' || c == '"' || c == '`':
+		var b strings.Builder
+		for p.pos++; ; p.pos++ {
+			if p.pos >= len(p.src) {
+				p.pos = start
+				return p.errorf("unterminated %c", c)
+			}
+			if p.src[p.pos] == c {
+				// A doubled quote stands for itself.
+				if p.pos+1 < len(p.src) && p.src[p.pos+1] == c {
+					p.pos++
+				} else {
+					p.pos++
+					break
+				}
+			}
+			b.WriteByte(p.src[p.pos])
+		}
+		p.tok, p.kind = b.String(), 's'
+		if c == '`' {
+			p.kind = 'q'
+		}
+	case c >= '0' && c <= '9' || c == '.' || c == '-' && p.pos+1 < len(p.src) && (p.src[p.pos+1] >= '0' && p.src[p.pos+1] <= '9'):
+		digits := func() {
+			for p.pos < len(p.src) && (p.src[p.pos] >= '0' && p.src[p.pos] <= '9' || p.src[p.pos] == '.') {
+				p.pos++
+			}
+		}
+		p.pos++
+		digits()
+		// An exponent, as in 1e-5 or 2.5E+3.
+		if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+			p.pos++
+			if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+				p.pos++
+			}
+			digits()
+		}
+		p.tok, p.kind = p.src[start:p.pos], 'n'
+		if _, err := strconv.ParseFloat(p.tok, 64); err != nil {
+			return p.errorf("invalid number %q", p.tok)
+		}
+	case c == '_' || unicode.IsLetter(rune(c)) || c >= 0x80:
+		for p.pos < len(p.src) {
+			r := rune(p.src[p.pos])
+			if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) && r < 0x80 {
+				break
+			}
+			p.pos++
+		}
+		p.tok, p.kind = p.src[start:p.pos], 'i'
+	default:
+		for _, op := range []string{"==", "!=", "<=", ">=", "~=", "&&", "||", "<", ">", "=", "!", "(", ")"} {
+			if strings.HasPrefix(p.src[p.pos:], op) {
+				p.pos += len(op)
+				p.tok, p.kind = op, 'o'
+				return nil
+			}
+		}
+		return p.errorf("unexpected %q", string(c))
+	}
+	return nil
+}
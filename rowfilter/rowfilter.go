@@ -0,0 +1,174 @@
+// Package rowfilter selects columns and rows of a range whose first row is
+// the header, e.g. to export only part of a tab:
+//
+//	rows, err := rowfilter.Apply(rows, []string{"name", "major"}, "major == 'CS' and year >= 3")
+//
+// Columns are named by header, ignoring case, or by upper case letter. In expressions, header names
+// that are not plain identifiers are written in backquotes, e.g.
+// `Unit Price` > 10; column letters work too. Values compare as numbers
+// when both sides are numbers and as text otherwise; number literals may
+// have an exponent, as in 1e-5. The operators are
+// == != < <= > >=, ~= for a regular expression match, and, or, not, and
+// parentheses.
+package rowfilter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter projects and filters the data rows of one range.
+type Filter struct {
+	header []string
+	cols   []int
+	where  node
+}
+
+// New returns a filter for rows with the given header. Without columns
+// every column is kept; without where every row.
+func New(header []interface{}, columns []string, where string) (*Filter, error) {
+	f := &Filter{header: make([]string, len(header))}
+	for i, h := range header {
+		f.header[i] = text(h)
+	}
+	for _, c := range columns {
+		c = strings.TrimSpace(c)
+		i := f.column(c)
+		if i < 0 {
+			return nil, fmt.Errorf("rowfilter: no column %q", c)
+		}
+		f.cols = append(f.cols, i)
+	}
+	if strings.TrimSpace(where) != "" {
+		p := &parser{f: f, src: where}
+		n, err := p.parse()
+		if err != nil {
+			return nil, err
+		}
+		f.where = n
+	}
+	return f, nil
+}
+
+// column returns the index of the column named by header, ignoring case,
+// or by upper case letter, or -1.
+func (f *Filter) column(name string) int {
+	for i, h := range f.header {
+		if strings.EqualFold(strings.TrimSpace(h), name) {
+			return i
+		}
+	}
+	if name == "" || len(name) > 3 {
+		return -1
+	}
+	n := 0
+	for _, r := range name {
+		if r < 'A' || r > 'Z' {
+			return -1
+		}
+		n = n*26 + int(r-'A'+1)
+	}
+	return n - 1
+}
+
+// Header returns the projected header row.
+func (f *Filter) Header() []interface{} {
+	row := make([]interface{}, len(f.header))
+	for i, h := range f.header {
+		row[i] = h
+	}
+	return f.Project(row)
+}
+
+// Match reports whether a data row satisfies the where expression.
+func (f *Filter) Match(row []interface{}) bool {
+	return f.where == nil || truthy(f.where.eval(row))
+}
+
+// Project returns the selected columns of row.
+func (f *Filter) Project(row []interface{}) []interface{} {
+	if f.cols == nil {
+		return row
+	}
+	out := make([]interface{}, len(f.cols))
+	for i, c := range f.cols {
+		if c < len(row) {
+			out[i] = row[c]
+		}
+	}
+	return trimRow(out)
+}
+
+// Apply keeps the header and the matching data rows of rows, projected to
+// columns.
+func Apply(rows [][]interface{}, columns []string, where string) ([][]interface{}, error) {
+	if len(rows) == 0 || (len(columns) == 0 && where == "") {
+		return rows, nil
+	}
+	f, err := New(rows[0], columns, where)
+	if err != nil {
+		return nil, err
+	}
+	out := [][]interface{}{f.Header()}
+	for _, row := range rows[1:] {
+		if f.Match(row) {
+			out = append(out, f.Project(row))
+		}
+	}
+	return out, nil
+}
+
+func trimRow(row []interface{}) []interface{} {
+	for len(row) > 0 && (row[len(row)-1] == nil || row[len(row)-1] == "") {
+		row = row[:len(row)-1]
+	}
+	return row
+}
+
+// text renders a cell for comparison; dates and times in ISO 8601, so
+// that they compare in order.
+func text(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 {
+			return v.Format("2006-01-02")
+		}
+		return v.Format("2006-01-02 15:04:05")
+	}
+	return fmt.Sprint(v)
+}
+
+func number(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func truthy(v interface{}) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case nil:
+		return false
+	case string:
+		return v != "" && !strings.EqualFold(v, "false")
+	case float64:
+		return v != 0
+	}
+	return true
+}
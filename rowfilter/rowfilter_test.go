@@ -0,0 +1,122 @@
+package rowfilter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetstest"
+)
+
+var header = []interface{}{"name", "major", "year", "Unit Price", "note"}
+
+func TestMatch(t *testing.T) {
+	row := []interface{}{"Ann", "CS", 3.0, "0.00002", `it's "fine"`}
+	tests := []struct {
+		where string
+		want  bool
+	}{
+		{"major == 'CS'", true},
+		{"major = \"cs\"", false},
+		{"MAJOR != 'EE'", true},
+		{"year >= 3 and year < 4", true},
+		{"year > 3", false},
+		{"B == 'CS'", true},
+		{"C > 2.5", true},
+		// and binds tighter than or, not tighter than and.
+		{"year > 5 and major == 'CS' or name == 'Ann'", true},
+		{"year > 5 and (major == 'CS' or name == 'Ann')", false},
+		{"not year > 5 and major == 'CS'", true},
+		{"not (year > 1 and major == 'CS')", false},
+		{"! year > 5 && major == 'CS' || false", true},
+		{"true or false and false", true},
+		// Quoting: doubled quotes stand for themselves, backquotes name
+		// columns.
+		{`note == 'it''s "fine"'`, true},
+		{`note == "it's ""fine"""`, true},
+		{"`Unit Price` < 1e-4", true},
+		{"`Unit Price` > 1e-5", true},
+		{"`Unit Price` == 2E-5", true},
+		{"`Unit Price` < -1.5e+3", false},
+		{"year == 3e0", true},
+		{"year == '3'", true},
+		{"name ~= '^A.n$'", true},
+		{"name ~= 'b'", false},
+		{"`note` ~= 'fine'", true},
+	}
+	for _, tt := range tests {
+		f, err := New(header, nil, tt.where)
+		if err != nil {
+			t.Errorf("New(%s): %v", tt.where, err)
+			continue
+		}
+		if got := f.Match(row); got != tt.want {
+			t.Errorf("%s matched %v, want %v", tt.where, got, tt.want)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct{ where, err string }{
+		{"year >", "unexpected end at offset 6"},
+		{"year > 3 and", "unexpected end at offset 12"},
+		{"year 3", `unexpected "3" at offset 5`},
+		{"(year > 3", "missing ) at offset 9"},
+		{"major == 'CS", "unterminated ' at offset 9"},
+		{"`Unit Price > 1", "unterminated ` at offset 0"},
+		{"grade > 3", `no column "grade" at offset 0`},
+		{"year > 1e", `invalid number "1e" at offset 7`},
+		{"year > 1.2.3", `invalid number "1.2.3" at offset 7`},
+		{"year > 3 # x", `unexpected "#" at offset 9`},
+		{"name ~= major", "~= needs a literal pattern at offset 8"},
+		{"name ~= '('", "invalid pattern: error parsing regexp: missing closing ): `(` at offset 8"},
+		{"year > 3)", `unexpected ")" at offset 8`},
+	}
+	for _, tt := range tests {
+		_, err := New(header, nil, tt.where)
+		if err == nil || !strings.HasPrefix(err.Error(), "rowfilter: "+tt.err+" of ") {
+			t.Errorf("New(%s) = %v, want rowfilter: %s of ...", tt.where, err, tt.err)
+		}
+	}
+	if _, err := New(header, []string{"name", "grade"}, ""); err == nil {
+		t.Error("New with a missing column succeeded")
+	}
+}
+
+func TestApply(t *testing.T) {
+	ctx := context.Background()
+	srv := sheetstest.NewServer()
+	defer srv.Close()
+	srv.Seed(&sheetstest.Spreadsheet{ID: "s", Tabs: []*sheetstest.Tab{{Title: "Students", Rows: [][]interface{}{
+		header,
+		{"Ann", "CS", 3.0, 10.0},
+		{"Bob", "EE", 4.0, 12.5},
+		{"Cy", "CS", 1.0, 9.0, "new"},
+		{"Di", "CS", 4.0},
+	}}}})
+	svc, err := srv.SheetsService(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vr, err := svc.Spreadsheets.Values.Get("s", "Students").ValueRenderOption("UNFORMATTED_VALUE").Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Apply(vr.Values, []string{"name", " YEAR ", "D"}, "major == 'CS' and year >= 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]interface{}{{"name", "year", "Unit Price"}, {"Ann", 3.0, 10.0}, {"Di", 4.0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply = %v, want %v", got, want)
+	}
+	if got, err := Apply(vr.Values, nil, ""); err != nil || !reflect.DeepEqual(got, vr.Values) {
+		t.Errorf("Apply without columns or where = %v, %v", got, err)
+	}
+	if got, err := Apply(vr.Values, []string{"note"}, "note != ''"); err != nil || len(got) != 2 {
+		t.Errorf("Apply(note) = %v, %v, want the header and one row", got, err)
+	}
+}
@@ -0,0 +1,278 @@
+// Package schema checks spreadsheets against a declared schema of tabs,
+// headers and column types, so edits by other teams can be gated in CI.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// Schema declares the expected structure of a spreadsheet.
+type Schema struct {
+	Tabs []*Tab `json:"tabs"`
+}
+
+// Tab declares a tab whose first row is a header.
+type Tab struct {
+	Name    string    `json:"name"`
+	Columns []*Column `json:"columns"`
+	// Ordered requires the columns to appear in the declared order.
+	Ordered bool `json:"ordered"`
+	// AllowExtra accepts header columns that are not declared.
+	AllowExtra bool `json:"allowExtra"`
+}
+
+// Column declares a header column and the values below it.
+type Column struct {
+	Name string `json:"name"`
+	// Type is string (default), number, integer, boolean, date, email or
+	// url. Dates must be real dates, not text.
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+	// Pattern is a regular expression non-empty values must match.
+	Pattern string `json:"pattern"`
+	// Values lists the allowed values.
+	Values []string `json:"values"`
+
+	pattern *regexp.Regexp
+}
+
+// Load reads a JSON schema.
+func Load(path string) (*Schema, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("schema: unable to read %s: %w", path, err)
+	}
+	var s Schema
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("schema: unable to parse %s: %w", path, err)
+	}
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *Schema) compile() error {
+	for _, t := range s.Tabs {
+		for _, c := range t.Columns {
+			switch c.Type {
+			case "", "string", "number", "integer", "boolean", "date", "email", "url":
+			default:
+				return fmt.Errorf("schema: column %s of %s has unknown type %q", c.Name, t.Name, c.Type)
+			}
+			if c.Pattern != "" && c.pattern == nil {
+				re, err := regexp.Compile("^(?:" + c.Pattern + ")$")
+				if err != nil {
+					return fmt.Errorf("schema: column %s of %s: %w", c.Name, t.Name, err)
+				}
+				c.pattern = re
+			}
+		}
+	}
+	return nil
+}
+
+// Violation is one way a spreadsheet differs from its schema. Row is the
+// 1-based sheet row, or 0 for violations of the tab or header.
+type Violation struct {
+	Tab     string `json:"tab"`
+	Column  string `json:"column,omitempty"`
+	Row     int    `json:"row,omitempty"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (v *Violation) String() string {
+	loc := v.Tab
+	if v.Column != "" {
+		loc += "." + v.Column
+	}
+	if v.Row > 0 {
+		loc += fmt.Sprintf(" row %d", v.Row)
+	}
+	return fmt.Sprintf("%s: %s (%s)", loc, v.Message, v.Rule)
+}
+
+// Report holds the violations found by Validate.
+type Report struct {
+	SpreadsheetID string       `json:"spreadsheetId"`
+	Violations    []*Violation `json:"violations"`
+}
+
+// OK reports whether the spreadsheet matches its schema.
+func (r *Report) OK() bool { return len(r.Violations) == 0 }
+
+// Validate checks a spreadsheet against s.
+func Validate(ctx context.Context, srv *sheets.Service, spreadsheetID string, s *Schema) (*Report, error) {
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+	resp, err := srv.Spreadsheets.Get(spreadsheetID).Fields("sheets.properties.title").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("schema: unable to get tabs: %w", err)
+	}
+	exists := map[string]bool{}
+	for _, sh := range resp.Sheets {
+		exists[sh.Properties.Title] = true
+	}
+
+	r := &Report{SpreadsheetID: spreadsheetID, Violations: []*Violation{}}
+	var tabs []*Tab
+	var ranges []string
+	for _, t := range s.Tabs {
+		if !exists[t.Name] {
+			r.add(t.Name, "", 0, "tab", "tab is missing")
+			continue
+		}
+		tabs = append(tabs, t)
+		ranges = append(ranges, sheetsclient.QuoteTab(t.Name))
+	}
+	if len(ranges) == 0 {
+		return r, nil
+	}
+	values, err := srv.Spreadsheets.Values.BatchGet(spreadsheetID).Ranges(ranges...).
+		ValueRenderOption("UNFORMATTED_VALUE").DateTimeRenderOption("SERIAL_NUMBER").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("schema: unable to read tabs: %w", err)
+	}
+	for i, vr := range values.ValueRanges {
+		r.checkTab(tabs[i], vr.Values)
+	}
+	return r, nil
+}
+
+func (r *Report) add(tab, column string, row int, rule, format string, args ...interface{}) {
+	r.Violations = append(r.Violations, &Violation{Tab: tab, Column: column, Row: row, Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *Report) checkTab(t *Tab, rows [][]interface{}) {
+	var header []string
+	if len(rows) > 0 {
+		for _, v := range rows[0] {
+			header = append(header, strings.TrimSpace(fmt.Sprint(v)))
+		}
+	}
+	index := map[string]int{}
+	for i, h := range header {
+		if _, dup := index[h]; dup && h != "" {
+			r.add(t.Name, h, 0, "header", "column appears more than once")
+			continue
+		}
+		index[h] = i
+	}
+
+	declared := map[string]bool{}
+	last := -1
+	for _, c := range t.Columns {
+		declared[c.Name] = true
+		i, ok := index[c.Name]
+		if !ok {
+			r.add(t.Name, c.Name, 0, "header", "column is missing")
+			continue
+		}
+		if t.Ordered && i < last {
+			r.add(t.Name, c.Name, 0, "order", "column is out of order, found in position %d", i+1)
+		}
+		last = i
+	}
+	if !t.AllowExtra {
+		for _, h := range header {
+			if h != "" && !declared[h] {
+				r.add(t.Name, h, 0, "header", "column is not declared")
+			}
+		}
+	}
+
+	for n, row := range rows {
+		if n == 0 || blank(row) {
+			continue
+		}
+		for _, c := range t.Columns {
+			i, ok := index[c.Name]
+			if !ok {
+				continue
+			}
+			var v interface{}
+			if i < len(row) {
+				v = row[i]
+			}
+			if msg := c.check(v); msg != "" {
+				rule := "type"
+				if msg == "value is required" {
+					rule = "required"
+				}
+				r.add(t.Name, c.Name, n+1, rule, "%s", msg)
+			}
+		}
+	}
+}
+
+// check returns why v is not valid for c, or "".
+func (c *Column) check(v interface{}) string {
+	if v == nil || v == "" {
+		if c.Required {
+			return "value is required"
+		}
+		return ""
+	}
+	text := fmt.Sprint(v)
+	switch c.Type {
+	case "number", "date":
+		if _, ok := v.(float64); !ok {
+			return fmt.Sprintf("%q is not a %s", text, c.Type)
+		}
+	case "integer":
+		if f, ok := v.(float64); !ok || f != math.Trunc(f) {
+			return fmt.Sprintf("%q is not an integer", text)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Sprintf("%q is not TRUE or FALSE", text)
+		}
+	case "email":
+		if a, err := mail.ParseAddress(text); err != nil || a.Address != text {
+			return fmt.Sprintf("%q is not an email address", text)
+		}
+	case "url":
+		if u, err := url.Parse(text); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Sprintf("%q is not an http(s) URL", text)
+		}
+	}
+	if f, ok := v.(float64); ok {
+		text = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	if c.pattern != nil && !c.pattern.MatchString(text) {
+		return fmt.Sprintf("%q does not match %s", text, c.Pattern)
+	}
+	if len(c.Values) > 0 {
+		for _, allowed := range c.Values {
+			if text == allowed {
+				return ""
+			}
+		}
+		return fmt.Sprintf("%q is not one of %s", text, strings.Join(c.Values, ", "))
+	}
+	return ""
+}
+
+func blank(row []interface{}) bool {
+	for _, v := range row {
+		if v != nil && v != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,332 @@
+// Package sheetapi serves spreadsheet tabs as a small JSON REST API.
+//
+//	GET  /tabs/{tab}   rows as objects keyed by header; supports
+//	                   filter=Column=value (also != and ~ for "contains"),
+//	                   limit and offset
+//	POST /tabs/{tab}   appends the JSON object in the body as a row
+//
+// Requests authenticate with an API key sent as "Authorization: Bearer
+// KEY" or "X-API-Key: KEY".
+package sheetapi
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"golang.org/x/time/rate"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// Tab is a tab exposed by the server.
+type Tab struct {
+	Name string
+	// Append allows POST requests to add rows.
+	Append bool
+}
+
+// Server is an http.Handler serving the configured tabs.
+type Server struct {
+	Sheets        *sheets.Service
+	SpreadsheetID string
+	Tabs          []Tab
+	// APIKeys lists the accepted keys. A server without keys rejects
+	// every request.
+	APIKeys []string
+	// CacheTTL is how long a tab read is reused; zero disables caching.
+	CacheTTL time.Duration
+	// RateLimit is the number of requests per second allowed per key,
+	// with bursts up to Burst. Zero means unlimited.
+	RateLimit float64
+	Burst     int
+	// UserEntered parses appended values as if typed into Sheets, turning
+	// text into numbers, dates and formulas. By default they are stored
+	// as they are sent; only enable it for trusted clients, since it lets
+	// any key holder write formulas such as =IMPORTXML into the sheet.
+	UserEntered bool
+
+	mu       sync.Mutex
+	cache    map[string]*cached
+	limiters map[string]*rate.Limiter
+}
+
+type cached struct {
+	header  []string
+	rows    [][]string
+	fetched time.Time
+}
+
+func (s *Server) tab(name string) (Tab, bool) {
+	for _, t := range s.Tabs {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Tab{}, false
+}
+
+func (s *Server) authorize(r *http.Request) (string, bool) {
+	key := r.Header.Get("X-API-Key")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		key = strings.TrimPrefix(auth, "Bearer ")
+	}
+	for _, k := range s.APIKeys {
+		if key != "" && subtle.ConstantTimeCompare([]byte(key), []byte(k)) == 1 {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+func (s *Server) allow(key string) bool {
+	if s.RateLimit <= 0 {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.limiters == nil {
+		s.limiters = map[string]*rate.Limiter{}
+	}
+	l, ok := s.limiters[key]
+	if !ok {
+		burst := s.Burst
+		if burst <= 0 {
+			burst = 1
+		}
+		l = rate.NewLimiter(rate.Limit(s.RateLimit), burst)
+		s.limiters[key] = l
+	}
+	return l.Allow()
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key, ok := s.authorize(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "missing or invalid API key")
+		return
+	}
+	if !s.allow(key) {
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/tabs/")
+	tab, ok := s.tab(name)
+	if name == r.URL.Path || !ok {
+		writeError(w, http.StatusNotFound, "unknown tab")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet:
+		s.get(w, r, tab)
+	case r.Method == http.MethodPost && tab.Append:
+		s.post(w, r, tab)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// read returns the content of tab, from the cache when fresh enough.
+func (s *Server) read(ctx context.Context, tab string) (*cached, error) {
+	s.mu.Lock()
+	c := s.cache[tab]
+	s.mu.Unlock()
+	if c != nil && time.Since(c.fetched) < s.CacheTTL {
+		return c, nil
+	}
+
+	resp, err := s.Sheets.Spreadsheets.Values.Get(s.SpreadsheetID, sheetsclient.QuoteTab(tab)).Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+	c = &cached{fetched: time.Now()}
+	for i, v := range resp.Values {
+		row := make([]string, len(v))
+		for j, cell := range v {
+			row[j] = fmt.Sprint(cell)
+		}
+		if i == 0 {
+			c.header = row
+		} else {
+			c.rows = append(c.rows, row)
+		}
+	}
+
+	s.mu.Lock()
+	if s.cache == nil {
+		s.cache = map[string]*cached{}
+	}
+	s.cache[tab] = c
+	s.mu.Unlock()
+	return c, nil
+}
+
+func (s *Server) invalidate(tab string) {
+	s.mu.Lock()
+	delete(s.cache, tab)
+	s.mu.Unlock()
+}
+
+// filter is one filter=Column<op>value condition.
+type filter struct {
+	column int
+	op     string
+	value  string
+}
+
+func parseFilter(expr string, header []string) (filter, error) {
+	for _, op := range []string{"!=", "=", "~"} {
+		i := strings.Index(expr, op)
+		if i < 0 {
+			continue
+		}
+		f := filter{column: -1, op: op, value: expr[i+len(op):]}
+		for j, h := range header {
+			if h == expr[:i] {
+				f.column = j
+			}
+		}
+		if f.column < 0 {
+			return f, fmt.Errorf("unknown column %q", expr[:i])
+		}
+		return f, nil
+	}
+	return filter{}, fmt.Errorf("invalid filter %q", expr)
+}
+
+func (f filter) match(row []string) bool {
+	v := ""
+	if f.column < len(row) {
+		v = row[f.column]
+	}
+	switch f.op {
+	case "=":
+		return v == f.value
+	case "!=":
+		return v != f.value
+	}
+	return strings.Contains(strings.ToLower(v), strings.ToLower(f.value))
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request, tab Tab) {
+	c, err := s.read(r.Context(), tab.Name)
+	if err != nil {
+		log.Printf("sheetapi: unable to read %s: %v", tab.Name, err)
+		writeError(w, http.StatusBadGateway, "unable to read tab")
+		return
+	}
+
+	q := r.URL.Query()
+	var filters []filter
+	for _, expr := range q["filter"] {
+		f, err := parseFilter(expr, c.header)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		filters = append(filters, f)
+	}
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	limit, err := strconv.Atoi(q.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = len(c.rows)
+	}
+
+	out := []map[string]string{}
+rows:
+	for _, row := range c.rows {
+		for _, f := range filters {
+			if !f.match(row) {
+				continue rows
+			}
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		if len(out) == limit {
+			break
+		}
+		obj := make(map[string]string, len(c.header))
+		for i, h := range c.header {
+			if i < len(row) {
+				obj[h] = row[i]
+			} else {
+				obj[h] = ""
+			}
+		}
+		out = append(out, obj)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) post(w http.ResponseWriter, r *http.Request, tab Tab) {
+	var obj map[string]interface{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&obj); err != nil {
+		writeError(w, http.StatusBadRequest, "body must be a JSON object")
+		return
+	}
+	c, err := s.read(r.Context(), tab.Name)
+	if err != nil {
+		log.Printf("sheetapi: unable to read %s: %v", tab.Name, err)
+		writeError(w, http.StatusBadGateway, "unable to read tab")
+		return
+	}
+
+	row := make([]interface{}, len(c.header))
+	for i := range row {
+		row[i] = ""
+	}
+	for k, v := range obj {
+		col := -1
+		for i, h := range c.header {
+			if h == k {
+				col = i
+			}
+		}
+		if col < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown column %q", k))
+			return
+		}
+		if v != nil {
+			row[col] = v
+		}
+	}
+
+	input := "RAW"
+	if s.UserEntered {
+		input = "USER_ENTERED"
+	}
+	vr := &sheets.ValueRange{Values: [][]interface{}{row}}
+	resp, err := s.Sheets.Spreadsheets.Values.Append(s.SpreadsheetID, sheetsclient.QuoteTab(tab.Name)+"!A1", vr).
+		ValueInputOption(input).InsertDataOption("INSERT_ROWS").Context(r.Context()).Do()
+	if err != nil {
+		log.Printf("sheetapi: unable to append to %s: %v", tab.Name, err)
+		writeError(w, http.StatusBadGateway, "unable to append row")
+		return
+	}
+	s.invalidate(tab.Name)
+	writeJSON(w, http.StatusCreated, map[string]string{"range": resp.Updates.UpdatedRange})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
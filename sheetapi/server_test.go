@@ -0,0 +1,51 @@
+package sheetapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetstest"
+)
+
+func TestPostValueInput(t *testing.T) {
+	ctx := context.Background()
+	for _, tt := range []struct {
+		userEntered bool
+		want        []interface{}
+	}{
+		{false, []interface{}{"=IMPORTXML(\"http://x\",\"//a\")", "00123"}},
+		{true, []interface{}{"=IMPORTXML(\"http://x\",\"//a\")", 123.0}},
+	} {
+		fake := sheetstest.NewServer()
+		fake.Seed(&sheetstest.Spreadsheet{ID: "s", Tabs: []*sheetstest.Tab{{
+			Title: "Orders",
+			Rows:  [][]interface{}{{"note", "code"}},
+		}}})
+		svc, err := fake.SheetsService(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := &Server{
+			Sheets:        svc,
+			SpreadsheetID: "s",
+			Tabs:          []Tab{{Name: "Orders", Append: true}},
+			APIKeys:       []string{"k"},
+			UserEntered:   tt.userEntered,
+		}
+		req := httptest.NewRequest("POST", "/tabs/Orders", strings.NewReader(`{"note": "=IMPORTXML(\"http://x\",\"//a\")", "code": "00123"}`))
+		req.Header.Set("X-API-Key", "k")
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if rec.Code != http.StatusCreated {
+			t.Errorf("UserEntered=%v: POST = %d %s", tt.userEntered, rec.Code, rec.Body)
+		} else if got := fake.Values("s", "Orders"); len(got) != 2 || !reflect.DeepEqual(got[1], tt.want) {
+			t.Errorf("UserEntered=%v: Orders = %#v, want row %#v", tt.userEntered, got, tt.want)
+		}
+		fake.Close()
+	}
+}
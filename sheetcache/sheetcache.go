@@ -0,0 +1,169 @@
+// Package sheetcache keeps range values on disk and serves them again for
+// as long as the spreadsheet is unchanged, which it checks with one cheap
+// Drive metadata request instead of reading the values. Scripts polling
+// slowly changing spreadsheets every few minutes then use almost none of
+// the Sheets read quota.
+package sheetcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/sheets/v4"
+)
+
+// Cache reads ranges through a directory of cached values.
+type Cache struct {
+	// Dir holds one subdirectory per spreadsheet.
+	Dir    string
+	Sheets *sheets.Service
+	// Drive is used to find when spreadsheets were last modified.
+	Drive *drive.Service
+	// ValueRenderOption is passed to the Sheets API like
+	// sheetsclient.Client.ValueRenderOption. Values rendered differently
+	// are cached apart.
+	ValueRenderOption string
+}
+
+// entry is the file cached for a range.
+type entry struct {
+	Range    string          `json:"range"`
+	Modified string          `json:"modified"`
+	Values   [][]interface{} `json:"values"`
+}
+
+// Stats reports how a read was served.
+type Stats struct {
+	Hits, Misses int
+	// Modified is the modification time of the spreadsheet, as given by
+	// Drive.
+	Modified string
+}
+
+// BatchGet returns the values of ranges like sheetsclient.Client.BatchGet,
+// keyed by range as given. Ranges cached at the current modification time
+// of the spreadsheet are read from disk; the others are fetched in a
+// single request and cached.
+func (c *Cache) BatchGet(ctx context.Context, spreadsheetID string, ranges ...string) (map[string][][]interface{}, *Stats, error) {
+	f, err := c.Drive.Files.Get(spreadsheetID).Fields("modifiedTime").SupportsAllDrives(true).Context(ctx).Do()
+	if err != nil {
+		return nil, nil, fmt.Errorf("sheetcache: unable to get modification time: %w", err)
+	}
+	st := &Stats{Modified: f.ModifiedTime}
+	out := map[string][][]interface{}{}
+	var missing []string
+	for _, rng := range ranges {
+		if _, ok := out[rng]; ok {
+			continue
+		}
+		e, err := c.load(spreadsheetID, rng)
+		if err != nil {
+			return nil, nil, err
+		}
+		if e != nil && e.Modified == f.ModifiedTime {
+			out[rng] = e.Values
+			st.Hits++
+		} else {
+			missing = append(missing, rng)
+		}
+	}
+	if len(missing) == 0 {
+		return out, st, nil
+	}
+	call := c.Sheets.Spreadsheets.Values.BatchGet(spreadsheetID).Ranges(missing...)
+	if c.ValueRenderOption != "" {
+		call.ValueRenderOption(c.ValueRenderOption)
+	}
+	resp, err := call.Context(ctx).Do()
+	if err != nil {
+		return nil, nil, fmt.Errorf("sheetcache: unable to read ranges: %w", err)
+	}
+	if len(resp.ValueRanges) != len(missing) {
+		return nil, nil, fmt.Errorf("sheetcache: asked for %d ranges but got %d", len(missing), len(resp.ValueRanges))
+	}
+	for i, rng := range missing {
+		values := resp.ValueRanges[i].Values
+		out[rng] = values
+		st.Misses++
+		if err := c.store(spreadsheetID, &entry{Range: rng, Modified: f.ModifiedTime, Values: values}); err != nil {
+			return nil, nil, err
+		}
+	}
+	return out, st, nil
+}
+
+// ReadRange returns the values of one range; see BatchGet.
+func (c *Cache) ReadRange(ctx context.Context, spreadsheetID, rng string) ([][]interface{}, error) {
+	values, _, err := c.BatchGet(ctx, spreadsheetID, rng)
+	if err != nil {
+		return nil, err
+	}
+	return values[rng], nil
+}
+
+// Purge removes the cached ranges of a spreadsheet.
+func (c *Cache) Purge(spreadsheetID string) error {
+	return os.RemoveAll(filepath.Join(c.Dir, spreadsheetID))
+}
+
+func (c *Cache) path(spreadsheetID, rng string) string {
+	key := rng
+	if c.ValueRenderOption != "" && c.ValueRenderOption != "FORMATTED_VALUE" {
+		key = c.ValueRenderOption + " " + rng
+	}
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, spreadsheetID, hex.EncodeToString(sum[:8])+".json")
+}
+
+// load returns the cached entry of rng, or nil if there is none.
+func (c *Cache) load(spreadsheetID, rng string) (*entry, error) {
+	b, err := ioutil.ReadFile(c.path(spreadsheetID, rng))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sheetcache: %w", err)
+	}
+	e := &entry{}
+	// A corrupt or foreign entry is a miss rather than an error.
+	if err := json.Unmarshal(b, e); err != nil || e.Range != rng {
+		return nil, nil
+	}
+	return e, nil
+}
+
+// store writes e atomically, so concurrent readers never see half of it.
+func (c *Cache) store(spreadsheetID string, e *entry) error {
+	path := c.path(spreadsheetID, e.Range)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("sheetcache: %w", err)
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("sheetcache: %w", err)
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return fmt.Errorf("sheetcache: %w", err)
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("sheetcache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("sheetcache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("sheetcache: %w", err)
+	}
+	return nil
+}
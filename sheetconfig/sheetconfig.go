@@ -0,0 +1,273 @@
+// Package sheetconfig loads configuration from a spreadsheet tab into a Go
+// struct and reloads it when the tab changes, so operators can adjust
+// service parameters from a sheet.
+//
+// A key/value tab has a header row followed by rows of key and value:
+//
+//	Key            | Value
+//	max_workers    | 8
+//	db.host        | db.internal
+//	allowed_hosts  | a.example.com, b.example.com
+//
+// which loads into
+//
+//	type Config struct {
+//		MaxWorkers   int      `sheet:"max_workers,required"`
+//		DB           struct{ Host string }
+//		AllowedHosts []string `sheet:"allowed_hosts"`
+//	}
+//
+// A table tab instead loads every row after the header into an element of
+// a slice of structs, matching columns to fields by name.
+//
+// A new configuration only replaces the old one once it has been read,
+// decoded and validated completely; a broken edit in the sheet is reported
+// and the service keeps running with the last good configuration.
+package sheetconfig
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+)
+
+// Validator is implemented by configurations that check themselves once
+// decoded.
+type Validator interface {
+	Validate() error
+}
+
+// Layout is the shape of a config tab.
+type Layout int
+
+const (
+	// KeyValue tabs hold one setting per row, key in the first column
+	// and value in the second. Keys starting with "#" are comments.
+	KeyValue Layout = iota
+	// Table tabs hold one record per row, decoded into a slice.
+	Table
+)
+
+// Loader reads a config range. The first row of the range is a header and
+// is not part of the configuration.
+type Loader struct {
+	Sheets        *sheets.Service
+	SpreadsheetID string
+	Range         string
+	Layout        Layout
+	// Interval between checks for changes in Watch; defaults to a minute.
+	Interval time.Duration
+	// OnError, if set, is called by Watch when a changed configuration
+	// cannot be loaded.
+	OnError func(error)
+
+	last [][]interface{}
+}
+
+// Load reads the range and decodes it into dst, which must be a pointer to
+// a struct for KeyValue, or to a slice of structs for Table. If the decoded
+// value implements Validator it is validated too.
+func (l *Loader) Load(ctx context.Context, dst interface{}) error {
+	values, err := l.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	if err := l.decode(values, dst); err != nil {
+		return err
+	}
+	l.last = values
+	return nil
+}
+
+// Watch checks the range for changes until ctx is done. Every time the
+// content changes, it is decoded into a new value of the same type as dst
+// and, if that succeeds, passed to reload. dst itself is never modified,
+// so callers swap in the new configuration, e.g. with an atomic.Value.
+// Watch returns ctx.Err().
+func (l *Loader) Watch(ctx context.Context, dst interface{}, reload func(cfg interface{})) error {
+	t := reflect.TypeOf(dst)
+	if t == nil || t.Kind() != reflect.Ptr {
+		return fmt.Errorf("sheetconfig: Watch needs a pointer, not %T", dst)
+	}
+	interval := l.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+		values, err := l.fetch(ctx)
+		if err != nil {
+			l.report(err)
+			continue
+		}
+		if reflect.DeepEqual(values, l.last) {
+			continue
+		}
+		cfg := reflect.New(t.Elem()).Interface()
+		if err := l.decode(values, cfg); err != nil {
+			l.report(err)
+			continue
+		}
+		l.last = values
+		reload(cfg)
+	}
+}
+
+func (l *Loader) report(err error) {
+	if l.OnError != nil {
+		l.OnError(err)
+	}
+}
+
+func (l *Loader) fetch(ctx context.Context) ([][]interface{}, error) {
+	resp, err := l.Sheets.Spreadsheets.Values.Get(l.SpreadsheetID, l.Range).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheetconfig: unable to read %s: %w", l.Range, err)
+	}
+	return resp.Values, nil
+}
+
+func (l *Loader) decode(values [][]interface{}, dst interface{}) error {
+	rows := make([][]string, 0, len(values))
+	for _, v := range values {
+		row := make([]string, len(v))
+		for i, c := range v {
+			row[i] = strings.TrimSpace(fmt.Sprint(c))
+		}
+		rows = append(rows, row)
+	}
+	var header []string
+	if len(rows) > 0 {
+		header, rows = rows[0], rows[1:]
+	}
+
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("sheetconfig: cannot decode into %T", dst)
+	}
+	var err error
+	switch e := v.Elem(); {
+	case l.Layout == KeyValue && e.Kind() == reflect.Struct:
+		err = decodeKeyValue(rows, e)
+	case l.Layout == Table && e.Kind() == reflect.Slice && e.Type().Elem().Kind() == reflect.Struct:
+		err = decodeTable(header, rows, e)
+	default:
+		err = fmt.Errorf("cannot decode this layout into %T", dst)
+	}
+	if err != nil {
+		return fmt.Errorf("sheetconfig: %s: %w", l.Range, err)
+	}
+	if val, ok := dst.(Validator); ok {
+		if err := val.Validate(); err != nil {
+			return fmt.Errorf("sheetconfig: %s: invalid config: %w", l.Range, err)
+		}
+	}
+	return nil
+}
+
+func decodeKeyValue(rows [][]string, v reflect.Value) error {
+	byName := map[string]field{}
+	for _, f := range fields(v, "") {
+		byName[strings.ToLower(f.name)] = f
+	}
+	seen := map[string]bool{}
+	for i, row := range rows {
+		if len(row) == 0 || row[0] == "" || strings.HasPrefix(row[0], "#") {
+			continue
+		}
+		key := strings.ToLower(row[0])
+		f, ok := byName[key]
+		if !ok {
+			return fmt.Errorf("row %d: unknown key %q", i+2, row[0])
+		}
+		if seen[key] {
+			return fmt.Errorf("row %d: duplicate key %q", i+2, row[0])
+		}
+		seen[key] = true
+		value := ""
+		if len(row) > 1 {
+			value = row[1]
+		}
+		if err := set(f.value, value); err != nil {
+			return fmt.Errorf("row %d: %s: %w", i+2, row[0], err)
+		}
+	}
+	for key, f := range byName {
+		if f.required && !seen[key] {
+			return fmt.Errorf("missing required key %q", f.name)
+		}
+	}
+	return nil
+}
+
+// decodeTable decodes one struct per row. A map[string]string field
+// tagged `sheet:",rest"` receives the non-empty cells of every column not
+// matched by another field, keyed by header.
+func decodeTable(header []string, rows [][]string, v reflect.Value) error {
+	elem := v.Type().Elem()
+	out := reflect.MakeSlice(v.Type(), 0, len(rows))
+	for i, row := range rows {
+		if strings.Join(row, "") == "" {
+			continue
+		}
+		rv := reflect.New(elem).Elem()
+		used := make([]bool, len(header))
+		var rest *field
+		for _, f := range fields(rv, "") {
+			if f.rest {
+				f := f
+				rest = &f
+				continue
+			}
+			col := -1
+			for j, h := range header {
+				if strings.EqualFold(h, f.name) {
+					col = j
+				}
+			}
+			if col < 0 {
+				if f.required {
+					return fmt.Errorf("missing required column %q", f.name)
+				}
+				continue
+			}
+			used[col] = true
+			cell := ""
+			if col < len(row) {
+				cell = row[col]
+			}
+			if cell == "" {
+				if f.required {
+					return fmt.Errorf("row %d: %s is required", i+2, header[col])
+				}
+				continue
+			}
+			if err := set(f.value, cell); err != nil {
+				return fmt.Errorf("row %d: %s: %w", i+2, header[col], err)
+			}
+		}
+		if rest != nil {
+			if rest.value.Type() != reflect.TypeOf(map[string]string(nil)) {
+				return fmt.Errorf("field %s: rest needs a map[string]string", rest.name)
+			}
+			m := map[string]string{}
+			for j, h := range header {
+				if !used[j] && h != "" && j < len(row) && row[j] != "" {
+					m[h] = row[j]
+				}
+			}
+			rest.value.Set(reflect.ValueOf(m))
+		}
+		out = reflect.Append(out, rv)
+	}
+	v.Set(out)
+	return nil
+}
@@ -0,0 +1,136 @@
+package sheetimport
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// JSON reads a JSON array of objects, or a stream of newline delimited
+// objects, into rows. The header row is the union of the keys in the order
+// they first appear; objects lacking a key get an empty cell. Nested
+// objects and arrays are written as JSON text and nulls as empty cells.
+func JSON(r io.Reader) ([][]interface{}, error) {
+	br := bufio.NewReader(r)
+	array := false
+	for {
+		c, _, err := br.ReadRune()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !unicode.IsSpace(c) && c != '\uFEFF' {
+			array = c == '['
+			br.UnreadRune()
+			break
+		}
+	}
+
+	dec := json.NewDecoder(br)
+	dec.UseNumber()
+	if array {
+		dec.Token()
+	}
+	var header []string
+	index := map[string]int{}
+	var objects []map[string]interface{}
+	for n := 1; ; n++ {
+		if array && !dec.More() {
+			break
+		}
+		keys, obj, err := readObject(dec)
+		if err == io.EOF && !array {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("sheetimport: object %d: %w", n, err)
+		}
+		for _, k := range keys {
+			if _, ok := index[k]; !ok {
+				index[k] = len(header)
+				header = append(header, k)
+			}
+		}
+		objects = append(objects, obj)
+	}
+
+	if len(objects) == 0 {
+		return nil, nil
+	}
+	rows := make([][]interface{}, 0, len(objects)+1)
+	hr := make([]interface{}, len(header))
+	for i, h := range header {
+		hr[i] = h
+	}
+	rows = append(rows, hr)
+	for _, obj := range objects {
+		row := make([]interface{}, len(header))
+		for i, h := range header {
+			v, err := cell(obj[h])
+			if err != nil {
+				return nil, err
+			}
+			row[i] = v
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// readObject decodes the next object, keeping the order of its keys.
+func readObject(dec *json.Decoder) ([]string, map[string]interface{}, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+	if d, ok := t.(json.Delim); !ok || d != '{' {
+		return nil, nil, fmt.Errorf("expected an object, found %v", t)
+	}
+	var keys []string
+	obj := map[string]interface{}{}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key := t.(string)
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, nil, err
+		}
+		if _, dup := obj[key]; !dup {
+			keys = append(keys, key)
+		}
+		obj[key] = v
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+	return keys, obj, nil
+}
+
+// cell converts a decoded JSON value to a cell value.
+func cell(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string, bool:
+		return v, nil
+	case json.Number:
+		// Integers beyond the precision of a double, such as IDs, stay text.
+		if !strings.ContainsAny(string(v), ".eE") && len(strings.TrimPrefix(string(v), "-")) > 15 {
+			return text(string(v)), nil
+		}
+		return v.Float64()
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
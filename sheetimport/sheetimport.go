@@ -0,0 +1,150 @@
+// Package sheetimport writes local data files into spreadsheets.
+package sheetimport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// Options tune Write.
+type Options struct {
+	// Append adds the rows after the table already in the target instead
+	// of replacing its content.
+	Append bool
+	// Raw stores values as given. By default they are parsed as if typed
+	// into the UI, so numbers, dates and formulas are recognized.
+	Raw bool
+}
+
+// Result describes what Write did.
+type Result struct {
+	Range      string
+	Rows       int64
+	Cells      int64
+	CreatedTab bool
+}
+
+// Write puts rows into target, a tab name or an A1 range such as
+// "Data!B2". A missing tab is created. Unless appending, the target is
+// cleared first: the whole tab when target names only a tab, and the range
+// otherwise, so a shorter import leaves no stale rows behind.
+func Write(ctx context.Context, srv *sheets.Service, spreadsheetID, target string, rows [][]interface{}, opts Options) (*Result, error) {
+	tab, cells := splitTab(target)
+	created, err := ensureTab(ctx, srv, spreadsheetID, tab)
+	if err != nil {
+		return nil, err
+	}
+	rng := sheetsclient.QuoteTab(tab)
+	if cells != "" {
+		rng += "!" + cells
+	}
+	input := "USER_ENTERED"
+	if opts.Raw {
+		input = "RAW"
+	}
+	res := &Result{CreatedTab: created}
+	vr := &sheets.ValueRange{Values: rows}
+	if opts.Append {
+		resp, err := srv.Spreadsheets.Values.Append(spreadsheetID, rng, vr).
+			ValueInputOption(input).InsertDataOption("INSERT_ROWS").Context(ctx).Do()
+		if err != nil {
+			return nil, fmt.Errorf("sheetimport: unable to append to %s: %w", rng, err)
+		}
+		if u := resp.Updates; u != nil {
+			res.Range, res.Rows, res.Cells = u.UpdatedRange, u.UpdatedRows, u.UpdatedCells
+		}
+		return res, nil
+	}
+
+	if !created {
+		if _, err := srv.Spreadsheets.Values.Clear(spreadsheetID, rng, &sheets.ClearValuesRequest{}).Context(ctx).Do(); err != nil {
+			return nil, fmt.Errorf("sheetimport: unable to clear %s: %w", rng, err)
+		}
+	}
+	if len(rows) == 0 {
+		return res, nil
+	}
+	resp, err := srv.Spreadsheets.Values.Update(spreadsheetID, rng, vr).ValueInputOption(input).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheetimport: unable to write %s: %w", rng, err)
+	}
+	res.Range, res.Rows, res.Cells = resp.UpdatedRange, resp.UpdatedRows, resp.UpdatedCells
+	return res, nil
+}
+
+// ensureTab creates tab unless it exists, and reports whether it did.
+func ensureTab(ctx context.Context, srv *sheets.Service, spreadsheetID, tab string) (bool, error) {
+	resp, err := srv.Spreadsheets.Get(spreadsheetID).Fields("sheets.properties.title").Context(ctx).Do()
+	if err != nil {
+		return false, fmt.Errorf("sheetimport: unable to get tabs: %w", err)
+	}
+	for _, sh := range resp.Sheets {
+		if sh.Properties.Title == tab {
+			return false, nil
+		}
+	}
+	_, err = srv.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{AddSheet: &sheets.AddSheetRequest{Properties: &sheets.SheetProperties{Title: tab}}}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return false, fmt.Errorf("sheetimport: unable to create tab %s: %w", tab, err)
+	}
+	return true, nil
+}
+
+// CSV reads comma separated values, or values separated by another comma
+// such as '\t'. Rows may have different lengths and a leading byte order
+// mark is skipped.
+func CSV(r io.Reader, comma rune) ([][]interface{}, error) {
+	br := bufio.NewReader(r)
+	if b, err := br.Peek(3); err == nil && bytes.Equal(b, []byte("\xef\xbb\xbf")) {
+		br.Discard(3)
+	}
+	cr := csv.NewReader(br)
+	cr.Comma = comma
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("sheetimport: unable to read CSV: %w", err)
+	}
+	rows := make([][]interface{}, len(records))
+	for i, rec := range records {
+		rows[i] = make([]interface{}, len(rec))
+		for j, v := range rec {
+			rows[i][j] = v
+		}
+	}
+	return rows, nil
+}
+
+// splitTab splits a range into the unquoted tab name and the cells, which
+// are empty when rng names a whole tab.
+func splitTab(rng string) (string, string) {
+	if strings.HasPrefix(rng, "'") {
+		// A quoted name may itself contain "!".
+		for i := 1; i < len(rng); i++ {
+			if rng[i] != '\'' {
+				continue
+			}
+			if i+1 < len(rng) && rng[i+1] == '\'' {
+				i++
+				continue
+			}
+			tab := strings.Replace(rng[1:i], "''", "'", -1)
+			return tab, strings.TrimPrefix(rng[i+1:], "!")
+		}
+	}
+	if i := strings.LastIndex(rng, "!"); i >= 0 {
+		return rng[:i], rng[i+1:]
+	}
+	return rng, ""
+}
@@ -0,0 +1,332 @@
+package sheetimport
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// Sheet is a worksheet read from a workbook, with values ready to be
+// written with USER_ENTERED input: numbers and booleans as such, dates as
+// "2006-01-02 15:04:05" text the sheet parses back into dates, formulas
+// as "=..." and text that would otherwise be parsed escaped with a
+// leading apostrophe.
+type Sheet struct {
+	Name string
+	Rows [][]interface{}
+}
+
+type xlsxWorkbook struct {
+	Props struct {
+		Date1904 bool `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRels struct {
+	Rels []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, r := range t.Runs {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type xlsxStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	Xfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R      string   `xml:"r,attr"`
+			S      int      `xml:"s,attr"`
+			T      string   `xml:"t,attr"`
+			V      string   `xml:"v"`
+			F      string   `xml:"f"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// XLSX reads the worksheets of an Excel workbook. Chart sheets are
+// skipped. Formulas are kept, and cells formatted as dates or times are
+// converted to date text.
+func XLSX(r io.ReaderAt, size int64) ([]*Sheet, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("sheetimport: not an xlsx file: %w", err)
+	}
+	files := map[string]*zip.File{}
+	for _, f := range z.File {
+		files[strings.TrimPrefix(f.Name, "/")] = f
+	}
+	decode := func(name string, v interface{}, optional bool) error {
+		f := files[name]
+		if f == nil {
+			if optional {
+				return nil
+			}
+			return fmt.Errorf("sheetimport: xlsx file has no %s", name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		if err := xml.NewDecoder(rc).Decode(v); err != nil {
+			return fmt.Errorf("sheetimport: unable to parse %s: %w", name, err)
+		}
+		return nil
+	}
+
+	var wb xlsxWorkbook
+	var rels xlsxRels
+	var styles xlsxStyles
+	var shared struct {
+		Items []xlsxText `xml:"si"`
+	}
+	if err := decode("xl/workbook.xml", &wb, false); err != nil {
+		return nil, err
+	}
+	if err := decode("xl/_rels/workbook.xml.rels", &rels, false); err != nil {
+		return nil, err
+	}
+	if err := decode("xl/styles.xml", &styles, true); err != nil {
+		return nil, err
+	}
+	if err := decode("xl/sharedStrings.xml", &shared, true); err != nil {
+		return nil, err
+	}
+
+	dateStyle := make([]bool, len(styles.Xfs))
+	custom := map[int]string{}
+	for _, f := range styles.NumFmts {
+		custom[f.ID] = f.Code
+	}
+	for i, xf := range styles.Xfs {
+		id := xf.NumFmtID
+		if code, ok := custom[id]; ok {
+			dateStyle[i] = isDateFormat(code)
+		} else {
+			dateStyle[i] = (id >= 14 && id <= 22) || (id >= 45 && id <= 47)
+		}
+	}
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if wb.Props.Date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	targets := map[string]string{}
+	for _, rel := range rels.Rels {
+		t := rel.Target
+		if strings.HasPrefix(t, "/") {
+			t = strings.TrimPrefix(t, "/")
+		} else {
+			t = path.Join("xl", t)
+		}
+		targets[rel.ID] = t
+	}
+
+	var out []*Sheet
+	for _, s := range wb.Sheets {
+		name := targets[s.RID]
+		if !strings.Contains(name, "worksheets/") {
+			continue
+		}
+		var ws xlsxSheet
+		if err := decode(name, &ws, false); err != nil {
+			return nil, err
+		}
+		sheet := &Sheet{Name: s.Name}
+		for _, row := range ws.Rows {
+			r := row.R - 1
+			if r < 0 {
+				r = len(sheet.Rows)
+			}
+			for len(sheet.Rows) <= r {
+				sheet.Rows = append(sheet.Rows, []interface{}{})
+			}
+			for _, c := range row.Cells {
+				col := len(sheet.Rows[r])
+				if c.R != "" {
+					col = columnIndex(c.R)
+				}
+				var v interface{}
+				switch {
+				case c.F != "":
+					v = "=" + c.F
+				case c.T == "s":
+					i, err := strconv.Atoi(c.V)
+					if err != nil || i < 0 || i >= len(shared.Items) {
+						return nil, fmt.Errorf("sheetimport: %s!%s: invalid shared string %q", s.Name, c.R, c.V)
+					}
+					v = text(shared.Items[i].String())
+				case c.T == "inlineStr":
+					v = text(c.Inline.String())
+				case c.T == "str", c.T == "e":
+					v = text(c.V)
+				case c.T == "b":
+					v = c.V == "1"
+				case c.V == "":
+					continue
+				default:
+					f, err := strconv.ParseFloat(c.V, 64)
+					if err != nil {
+						return nil, fmt.Errorf("sheetimport: %s!%s: invalid number %q", s.Name, c.R, c.V)
+					}
+					v = f
+					if c.S >= 0 && c.S < len(dateStyle) && dateStyle[c.S] {
+						v = dateText(epoch, f)
+					}
+				}
+				for len(sheet.Rows[r]) <= col {
+					sheet.Rows[r] = append(sheet.Rows[r], "")
+				}
+				sheet.Rows[r][col] = v
+			}
+		}
+		out = append(out, sheet)
+	}
+	return out, nil
+}
+
+// isDateFormat reports whether a number format code shows dates or times,
+// ignoring quoted literals, escapes and bracketed colors or locales.
+func isDateFormat(code string) bool {
+	inQuote, inBracket := false, false
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case inQuote:
+			inQuote = c != '"'
+		case inBracket:
+			inBracket = c != ']'
+		case c == '"':
+			inQuote = true
+		case c == '[':
+			// Elapsed time such as [h]:mm is a time too.
+			if i+1 < len(code) && strings.ContainsRune("hms", rune(code[i+1]|0x20)) {
+				return true
+			}
+			inBracket = true
+		case c == '\\':
+			i++
+		case strings.ContainsRune("ymdhsYMDHS", rune(c)):
+			return true
+		}
+	}
+	return false
+}
+
+func dateText(epoch time.Time, serial float64) string {
+	ms := math.Round(serial * 24 * 60 * 60 * 1000)
+	t := epoch.Add(time.Duration(ms) * time.Millisecond)
+	switch {
+	case serial < 1:
+		return t.Format("15:04:05")
+	case t.Equal(t.Truncate(24 * time.Hour)):
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// text escapes strings USER_ENTERED input would turn into something else,
+// like formulas or numbers.
+func text(s string) string {
+	if s == "" {
+		return s
+	}
+	if strings.ContainsRune("=+'", rune(s[0])) {
+		return "'" + s
+	}
+	if _, err := strconv.ParseFloat(strings.Replace(strings.TrimSuffix(s, "%"), ",", "", -1), 64); err == nil {
+		return "'" + s
+	}
+	return s
+}
+
+// columnIndex returns the zero based column of a cell reference like
+// "AB12".
+func columnIndex(ref string) int {
+	col := 0
+	for _, c := range strings.ToUpper(ref) {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		col = col*26 + int(c-'A'+1)
+	}
+	return col - 1
+}
+
+// Create makes a new spreadsheet with tabs as its tabs, writes their
+// values in a single request and returns its ID.
+func Create(ctx context.Context, srv *sheets.Service, title string, tabs []*Sheet) (string, error) {
+	ss := &sheets.Spreadsheet{Properties: &sheets.SpreadsheetProperties{Title: title}}
+	req := &sheets.BatchUpdateValuesRequest{ValueInputOption: "USER_ENTERED"}
+	for _, t := range tabs {
+		rows, cols := int64(1000), int64(26)
+		if n := int64(len(t.Rows)); n > rows {
+			rows = n
+		}
+		for _, row := range t.Rows {
+			if n := int64(len(row)); n > cols {
+				cols = n
+			}
+		}
+		ss.Sheets = append(ss.Sheets, &sheets.Sheet{Properties: &sheets.SheetProperties{
+			Title:          t.Name,
+			GridProperties: &sheets.GridProperties{RowCount: rows, ColumnCount: cols},
+		}})
+		if len(t.Rows) > 0 {
+			req.Data = append(req.Data, &sheets.ValueRange{Range: sheetsclient.QuoteTab(t.Name), Values: t.Rows})
+		}
+	}
+	resp, err := srv.Spreadsheets.Create(ss).Context(ctx).Do()
+	if err != nil {
+		return "", fmt.Errorf("sheetimport: unable to create spreadsheet: %w", err)
+	}
+	if len(req.Data) > 0 {
+		if _, err := srv.Spreadsheets.Values.BatchUpdate(resp.SpreadsheetId, req).Context(ctx).Do(); err != nil {
+			return resp.SpreadsheetId, fmt.Errorf("sheetimport: unable to write values: %w", err)
+		}
+	}
+	return resp.SpreadsheetId, nil
+}
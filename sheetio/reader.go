@@ -0,0 +1,67 @@
+// Package sheetio exposes sheet data as standard byte streams, so that it
+// can be plugged into encoders, compressors, archive writers and anything
+// else that speaks io.Reader and io.Writer.
+package sheetio
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+)
+
+// csvReader streams a range as CSV. The range is fetched on the first
+// Read and encoded a row at a time as the caller consumes it.
+type csvReader struct {
+	ctx    context.Context
+	srv    *sheets.Service
+	id     string
+	rng    string
+	rows   [][]interface{}
+	loaded bool
+	buf    bytes.Buffer
+	w      *csv.Writer
+	err    error
+}
+
+// NewCSVReader returns a reader producing the given range as CSV. Errors
+// reading the sheet are returned by Read.
+func NewCSVReader(ctx context.Context, srv *sheets.Service, spreadsheetID, rng string) io.Reader {
+	r := &csvReader{ctx: ctx, srv: srv, id: spreadsheetID, rng: rng}
+	r.w = csv.NewWriter(&r.buf)
+	return r
+}
+
+func (r *csvReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if !r.loaded {
+		resp, err := r.srv.Spreadsheets.Values.Get(r.id, r.rng).Context(r.ctx).Do()
+		if err != nil {
+			r.err = fmt.Errorf("sheetio: unable to read %s: %w", r.rng, err)
+			return 0, r.err
+		}
+		r.rows, r.loaded = resp.Values, true
+	}
+	for r.buf.Len() < len(p) && len(r.rows) > 0 {
+		row := make([]string, len(r.rows[0]))
+		for i, v := range r.rows[0] {
+			row[i] = fmt.Sprint(v)
+		}
+		r.rows = r.rows[1:]
+		if err := r.w.Write(row); err != nil {
+			r.err = err
+			return 0, err
+		}
+		r.w.Flush()
+	}
+	if r.buf.Len() == 0 {
+		r.err = io.EOF
+		return 0, io.EOF
+	}
+	return r.buf.Read(p)
+}
@@ -0,0 +1,233 @@
+package sheetio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// RowWriter appends the records written to it as rows of a tab. The input
+// is either CSV or NDJSON, told apart by its first character: NDJSON lines
+// are arrays of cells or objects keyed by the tab's header. When the tab
+// is empty, the keys of the first object, sorted, become its header.
+//
+// Rows are sent in batches; Close sends the last one and must be called.
+type RowWriter struct {
+	// BatchSize is the number of rows per append; defaults to 500.
+	BatchSize int
+	// ValueInputOption is RAW or USER_ENTERED, the default.
+	ValueInputOption string
+
+	ctx  context.Context
+	srv  *sheets.Service
+	id   string
+	tab  string
+	pw   *io.PipeWriter
+	done chan error
+}
+
+// NewRowWriter returns a writer appending to the tab.
+func NewRowWriter(ctx context.Context, srv *sheets.Service, spreadsheetID, tab string) *RowWriter {
+	return &RowWriter{ctx: ctx, srv: srv, id: spreadsheetID, tab: tab}
+}
+
+// Write implements io.Writer. A failure to parse the input or append rows
+// is returned by the next Write or by Close.
+func (w *RowWriter) Write(p []byte) (int, error) {
+	if w.pw == nil {
+		w.start()
+	}
+	return w.pw.Write(p)
+}
+
+// Close flushes the remaining rows.
+func (w *RowWriter) Close() error {
+	if w.pw == nil {
+		w.start()
+	}
+	w.pw.Close()
+	return <-w.done
+}
+
+func (w *RowWriter) start() {
+	pr, pw := io.Pipe()
+	w.pw, w.done = pw, make(chan error, 1)
+	go func() {
+		err := w.consume(bufio.NewReader(pr))
+		pr.CloseWithError(err)
+		w.done <- err
+	}()
+}
+
+func (w *RowWriter) consume(br *bufio.Reader) error {
+	for {
+		b, err := br.Peek(1)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !strings.ContainsRune(" \t\r\n", rune(b[0])) {
+			break
+		}
+		br.ReadByte()
+	}
+	b, _ := br.Peek(1)
+	if b[0] == '{' || b[0] == '[' {
+		return w.consumeJSON(br)
+	}
+
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = -1
+	var batch [][]interface{}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return w.flush(batch)
+		}
+		if err != nil {
+			return fmt.Errorf("sheetio: invalid CSV: %w", err)
+		}
+		row := make([]interface{}, len(rec))
+		for i, v := range rec {
+			row[i] = v
+		}
+		if batch, err = w.add(batch, row); err != nil {
+			return err
+		}
+	}
+}
+
+func (w *RowWriter) consumeJSON(br *bufio.Reader) error {
+	dec := json.NewDecoder(br)
+	dec.UseNumber()
+	var header []string
+	var batch [][]interface{}
+	for n := 1; ; n++ {
+		var v interface{}
+		err := dec.Decode(&v)
+		if errors.Is(err, io.EOF) {
+			return w.flush(batch)
+		}
+		if err != nil {
+			return fmt.Errorf("sheetio: invalid JSON record %d: %w", n, err)
+		}
+		var row []interface{}
+		switch v := v.(type) {
+		case []interface{}:
+			row = v
+		case map[string]interface{}:
+			if header == nil {
+				if header, err = w.header(); err != nil {
+					return err
+				}
+				if header == nil {
+					// The tab was empty; start it with a header row.
+					for k := range v {
+						header = append(header, k)
+					}
+					sort.Strings(header)
+					hrow := make([]interface{}, len(header))
+					for i, h := range header {
+						hrow[i] = h
+					}
+					if batch, err = w.add(batch, hrow); err != nil {
+						return err
+					}
+				}
+			}
+			row = make([]interface{}, len(header))
+			for i, h := range header {
+				if c, ok := v[h]; ok && c != nil {
+					row[i] = c
+				} else {
+					row[i] = ""
+				}
+			}
+			for k := range v {
+				if indexOf(header, k) < 0 {
+					return fmt.Errorf("sheetio: record %d: no column %q in tab %q", n, k, w.tab)
+				}
+			}
+		default:
+			return fmt.Errorf("sheetio: record %d is neither an array nor an object", n)
+		}
+		for i, c := range row {
+			switch c := c.(type) {
+			case json.Number:
+				row[i] = c.String()
+			case map[string]interface{}, []interface{}:
+				b, _ := json.Marshal(c)
+				row[i] = string(b)
+			}
+		}
+		if batch, err = w.add(batch, row); err != nil {
+			return err
+		}
+	}
+}
+
+// header returns the header row of the tab, or nil if the tab is empty.
+func (w *RowWriter) header() ([]string, error) {
+	resp, err := w.srv.Spreadsheets.Values.Get(w.id, sheetsclient.QuoteTab(w.tab)+"!1:1").Context(w.ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheetio: unable to read header of %q: %w", w.tab, err)
+	}
+	if len(resp.Values) == 0 {
+		return nil, nil
+	}
+	header := make([]string, len(resp.Values[0]))
+	for i, v := range resp.Values[0] {
+		header[i] = fmt.Sprint(v)
+	}
+	return header, nil
+}
+
+func (w *RowWriter) add(batch [][]interface{}, row []interface{}) ([][]interface{}, error) {
+	batch = append(batch, row)
+	size := w.BatchSize
+	if size <= 0 {
+		size = 500
+	}
+	if len(batch) < size {
+		return batch, nil
+	}
+	return nil, w.flush(batch)
+}
+
+func (w *RowWriter) flush(batch [][]interface{}) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	opt := w.ValueInputOption
+	if opt == "" {
+		opt = "USER_ENTERED"
+	}
+	vr := &sheets.ValueRange{Values: batch}
+	_, err := w.srv.Spreadsheets.Values.Append(w.id, sheetsclient.QuoteTab(w.tab)+"!A1", vr).
+		ValueInputOption(opt).InsertDataOption("INSERT_ROWS").Context(w.ctx).Do()
+	if err != nil {
+		return fmt.Errorf("sheetio: unable to append to %q: %w", w.tab, err)
+	}
+	return nil
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,110 @@
+// Package sheetmetrics exposes spreadsheet values as Prometheus gauges.
+package sheetmetrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// Config describes which values to export. It is usually read from a JSON
+// file, e.g.
+//
+//	{
+//	  "spreadsheet_id": "1zFjra05ZGfaVgKNorPdvAU-bh0QDkOn-CVoXjWtiw2w",
+//	  "interval": "1m",
+//	  "listen": ":9464",
+//	  "metrics": [
+//	    {"name": "inventory_quantity", "range": "Inventory!A1:F",
+//	     "labels": ["Warehouse", "SKU"], "value": "Quantity"},
+//	    {"name": "budget_remaining_dollars", "cell": "Summary!B2"}
+//	  ]
+//	}
+type Config struct {
+	SpreadsheetID string   `json:"spreadsheet_id"`
+	Interval      Duration `json:"interval"`
+	Listen        string   `json:"listen"`
+	Metrics       []Metric `json:"metrics"`
+}
+
+// Metric maps either a single cell or a column of a table to a gauge.
+type Metric struct {
+	Name string `json:"name"`
+	Help string `json:"help"`
+	// Cell is an A1 reference to a single value.
+	Cell string `json:"cell"`
+	// Range is a table whose first row is the header. Every data row
+	// yields a sample taken from the Value column, labelled with the
+	// Labels columns. Label names are the sanitized header names.
+	Range  string   `json:"range"`
+	Labels []string `json:"labels"`
+	Value  string   `json:"value"`
+}
+
+// Duration is a time.Duration read from a string such as "30s".
+type Duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	*d = Duration(v)
+	return err
+}
+
+// LoadConfig reads and checks a configuration file.
+func LoadConfig(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{Interval: Duration(time.Minute), Listen: ":9464"}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("sheetmetrics: invalid config %s: %w", path, err)
+	}
+	if c.SpreadsheetID == "" {
+		return nil, fmt.Errorf("sheetmetrics: %s: spreadsheet_id is required", path)
+	}
+	if c.Interval <= 0 {
+		return nil, fmt.Errorf("sheetmetrics: %s: interval must be positive", path)
+	}
+	// Names already taken, including the exporter's own metrics.
+	names := map[string]bool{
+		"sheets_exporter_poll_errors_total":              true,
+		"sheets_exporter_last_success_timestamp_seconds": true,
+	}
+	for i, m := range c.Metrics {
+		switch {
+		case m.Name == "":
+			return nil, fmt.Errorf("sheetmetrics: %s: metric %d has no name", path, i)
+		case !model.IsValidMetricName(model.LabelValue(m.Name)):
+			return nil, fmt.Errorf("sheetmetrics: %s: invalid metric name %q", path, m.Name)
+		case names[m.Name]:
+			return nil, fmt.Errorf("sheetmetrics: %s: metric %s is defined twice", path, m.Name)
+		case (m.Cell == "") == (m.Range == ""):
+			return nil, fmt.Errorf("sheetmetrics: %s: metric %s needs exactly one of cell or range", path, m.Name)
+		case m.Range != "" && m.Value == "":
+			return nil, fmt.Errorf("sheetmetrics: %s: metric %s needs a value column", path, m.Name)
+		case m.Cell != "" && len(m.Labels) > 0:
+			return nil, fmt.Errorf("sheetmetrics: %s: metric %s has labels but reads a single cell", path, m.Name)
+		}
+		names[m.Name] = true
+		// Header names are sanitized, so "SKU" and "sku" are the same
+		// label.
+		labels := map[string]string{}
+		for _, l := range m.Labels {
+			name := labelName(l)
+			if prev, ok := labels[name]; ok {
+				return nil, fmt.Errorf("sheetmetrics: %s: metric %s: labels %q and %q are both named %s", path, m.Name, prev, l, name)
+			}
+			labels[name] = l
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,47 @@
+package sheetmetrics
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sheetmetrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		config string
+		err    string
+	}{
+		{`{"spreadsheet_id": "s", "metrics": [{"name": "budget", "cell": "B2"}]}`, ""},
+		{`{"spreadsheet_id": "s", "interval": "0s", "metrics": []}`, "interval must be positive"},
+		{`{"spreadsheet_id": "s", "interval": "-1m", "metrics": []}`, "interval must be positive"},
+		{`{"spreadsheet_id": "s", "metrics": [{"name": "", "cell": "B2"}]}`, "has no name"},
+		{`{"spreadsheet_id": "s", "metrics": [{"name": "budget", "cell": "B2"}, {"name": "budget", "cell": "B3"}]}`, "defined twice"},
+		{`{"spreadsheet_id": "s", "metrics": [{"name": "sheets_exporter_poll_errors_total", "cell": "B2"}]}`, "defined twice"},
+		{`{"spreadsheet_id": "s", "metrics": [{"name": "stock", "range": "Data", "value": "Qty", "labels": ["SKU", "sku"]}]}`, `labels "SKU" and "sku" are both named sku`},
+		{`{"spreadsheet_id": "s", "metrics": [{"name": "stock", "range": "Data", "value": "Qty", "labels": ["Ware house", "ware-house"]}]}`, "both named ware_house"},
+		{`{"spreadsheet_id": "s", "metrics": [{"name": "budget", "cell": "B2", "labels": ["SKU"]}]}`, "single cell"},
+		{`{"spreadsheet_id": "s", "metrics": [{"name": "stock", "range": "Data"}]}`, "needs a value column"},
+		{`{"metrics": []}`, "spreadsheet_id is required"},
+	}
+	for i, tt := range tests {
+		path := filepath.Join(dir, "config.json")
+		if err := ioutil.WriteFile(path, []byte(tt.config), 0600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadConfig(path)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%d: LoadConfig(%s) = %v", i, tt.config, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%d: LoadConfig(%s) = %v, want an error containing %q", i, tt.config, err, tt.err)
+		}
+	}
+}
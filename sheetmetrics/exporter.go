@@ -0,0 +1,214 @@
+package sheetmetrics
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+)
+
+// Exporter polls the configured values and serves the latest ones as a
+// prometheus.Collector. Scrapes never hit the Sheets API.
+type Exporter struct {
+	srv    *sheets.Service
+	config *Config
+	descs  []*prometheus.Desc
+
+	scrapeErrors prometheus.Counter
+	lastSuccess  prometheus.Gauge
+
+	mu      sync.Mutex
+	samples []prometheus.Metric
+}
+
+var notLabel = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// labelName sanitizes a header cell into a Prometheus label name.
+func labelName(s string) string {
+	s = strings.Trim(notLabel.ReplaceAllString(strings.ToLower(strings.TrimSpace(s)), "_"), "_")
+	if s == "" || (s[0] >= '0' && s[0] <= '9') {
+		s = "_" + s
+	}
+	return s
+}
+
+// NewExporter returns an exporter for config. Call Run to start polling.
+func NewExporter(srv *sheets.Service, config *Config) *Exporter {
+	e := &Exporter{
+		srv:    srv,
+		config: config,
+		scrapeErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "sheets_exporter_poll_errors_total",
+			Help: "Number of failed polls of the spreadsheet.",
+		}),
+		lastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "sheets_exporter_last_success_timestamp_seconds",
+			Help: "Time of the last successful poll of the spreadsheet.",
+		}),
+	}
+	for _, m := range config.Metrics {
+		help := m.Help
+		if help == "" {
+			help = fmt.Sprintf("Value of %s%s in spreadsheet %s.", m.Cell, m.Range, config.SpreadsheetID)
+		}
+		labels := make([]string, len(m.Labels))
+		for i, l := range m.Labels {
+			labels[i] = labelName(l)
+		}
+		e.descs = append(e.descs, prometheus.NewDesc(m.Name, help, labels, nil))
+	}
+	return e
+}
+
+// Describe implements prometheus.Collector.
+func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range e.descs {
+		ch <- d
+	}
+	e.scrapeErrors.Describe(ch)
+	e.lastSuccess.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
+	e.mu.Lock()
+	samples := e.samples
+	e.mu.Unlock()
+	for _, s := range samples {
+		ch <- s
+	}
+	e.scrapeErrors.Collect(ch)
+	e.lastSuccess.Collect(ch)
+}
+
+// Run polls until ctx is done. Failed polls keep the previous samples and
+// are counted in sheets_exporter_poll_errors_total; onError, if not nil,
+// is called with the cause.
+func (e *Exporter) Run(ctx context.Context, onError func(error)) {
+	for {
+		if err := e.Poll(ctx); err != nil {
+			e.scrapeErrors.Inc()
+			if onError != nil {
+				onError(err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(e.config.Interval)):
+		}
+	}
+}
+
+// Poll fetches every configured value in one request and replaces the
+// exported samples.
+func (e *Exporter) Poll(ctx context.Context) error {
+	ranges := make([]string, len(e.config.Metrics))
+	for i, m := range e.config.Metrics {
+		ranges[i] = m.Cell + m.Range
+	}
+	resp, err := e.srv.Spreadsheets.Values.BatchGet(e.config.SpreadsheetID).Ranges(ranges...).
+		ValueRenderOption("UNFORMATTED_VALUE").Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("sheetmetrics: unable to read values: %w", err)
+	}
+	if len(resp.ValueRanges) != len(ranges) {
+		return fmt.Errorf("sheetmetrics: expected %d ranges, got %d", len(ranges), len(resp.ValueRanges))
+	}
+
+	var samples []prometheus.Metric
+	for i, m := range e.config.Metrics {
+		s, err := e.samplesOf(e.descs[i], m, resp.ValueRanges[i].Values)
+		if err != nil {
+			return err
+		}
+		samples = append(samples, s...)
+	}
+
+	e.mu.Lock()
+	e.samples = samples
+	e.mu.Unlock()
+	e.lastSuccess.SetToCurrentTime()
+	return nil
+}
+
+func (e *Exporter) samplesOf(desc *prometheus.Desc, m Metric, values [][]interface{}) ([]prometheus.Metric, error) {
+	if m.Cell != "" {
+		if len(values) == 0 || len(values[0]) == 0 {
+			return nil, nil
+		}
+		v, ok := number(values[0][0])
+		if !ok {
+			return nil, nil
+		}
+		return []prometheus.Metric{prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v)}, nil
+	}
+
+	if len(values) == 0 {
+		return nil, nil
+	}
+	col := map[string]int{}
+	for i, h := range values[0] {
+		col[fmt.Sprint(h)] = i
+	}
+	valueCol, ok := col[m.Value]
+	if !ok {
+		return nil, fmt.Errorf("sheetmetrics: metric %s: no column %q in %s", m.Name, m.Value, m.Range)
+	}
+	labelCols := make([]int, len(m.Labels))
+	for i, l := range m.Labels {
+		if labelCols[i], ok = col[l]; !ok {
+			return nil, fmt.Errorf("sheetmetrics: metric %s: no column %q in %s", m.Name, l, m.Range)
+		}
+	}
+
+	var out []prometheus.Metric
+	seen := map[string]bool{}
+	for _, row := range values[1:] {
+		if valueCol >= len(row) {
+			continue
+		}
+		v, ok := number(row[valueCol])
+		if !ok {
+			continue
+		}
+		labels := make([]string, len(labelCols))
+		for i, c := range labelCols {
+			if c < len(row) {
+				labels[i] = fmt.Sprint(row[c])
+			}
+		}
+		// A registry refuses duplicate label sets, keep the first.
+		id := strings.Join(labels, "\xff")
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		out = append(out, prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, labels...))
+	}
+	return out, nil
+}
+
+// number converts an unformatted cell value to a sample value. Blank and
+// non-numeric cells are skipped.
+func number(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case bool:
+		if t {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
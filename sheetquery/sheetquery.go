@@ -0,0 +1,109 @@
+// Package sheetquery runs SQL over spreadsheet ranges without exporting
+// them first. The ranges named in the query are loaded into an in-memory
+// SQLite database, so the whole SQLite dialect is available:
+//
+//	SELECT name, SUM(amount) FROM 'Sheet1!A1:D' GROUP BY name
+//
+// Ranges are written as string literals after FROM or JOIN, and their
+// first row names the columns, sanitized as by export.SQLite.
+package sheetquery
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/export"
+)
+
+var fromRE = regexp.MustCompile(`(?i)\b(FROM|JOIN)\s+'((?:[^']|'')*)'`)
+
+// Ranges returns the ranges a query reads, in order of first use, and the
+// query rewritten to read them as tables range1, range2 and so on.
+func Ranges(query string) ([]string, string) {
+	var ranges []string
+	names := map[string]string{}
+	rewritten := fromRE.ReplaceAllStringFunc(query, func(m string) string {
+		sub := fromRE.FindStringSubmatch(m)
+		rng := strings.Replace(sub[2], "''", "'", -1)
+		name, ok := names[rng]
+		if !ok {
+			ranges = append(ranges, rng)
+			name = fmt.Sprintf("range%d", len(ranges))
+			names[rng] = name
+		}
+		return sub[1] + " " + name
+	})
+	return ranges, rewritten
+}
+
+// Run executes query over the ranges of a spreadsheet and returns the
+// result with the column names as the first row. Values are string,
+// float64, int64 or nil; schema overrides the inferred column types, as
+// for export.SQLite.
+func Run(ctx context.Context, srv *sheets.Service, spreadsheetID, query string, schema map[string]string) ([][]interface{}, error) {
+	ranges, rewritten := Ranges(query)
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("sheetquery: the query reads no range; name one as FROM 'Sheet1!A1:D'")
+	}
+	tables, err := export.Load(ctx, srv, spreadsheetID, ranges...)
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) != len(ranges) {
+		return nil, fmt.Errorf("sheetquery: asked for %d ranges but got %d", len(ranges), len(tables))
+	}
+	for i, t := range tables {
+		t.Name = fmt.Sprintf("range%d", i+1)
+	}
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		return nil, fmt.Errorf("sheetquery: %w", err)
+	}
+	defer db.Close()
+	// Every connection has its own in-memory database.
+	db.SetMaxOpenConns(1)
+	if err := export.SQLite(ctx, db, tables, schema); err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, rewritten)
+	if err != nil {
+		return nil, fmt.Errorf("sheetquery: %w", err)
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("sheetquery: %w", err)
+	}
+	header := make([]interface{}, len(cols))
+	for i, c := range cols {
+		header[i] = c
+	}
+	out := [][]interface{}{header}
+	for rows.Next() {
+		row := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range row {
+			ptrs[i] = &row[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("sheetquery: %w", err)
+		}
+		for i, v := range row {
+			if b, ok := v.([]byte); ok {
+				row[i] = string(b)
+			}
+		}
+		out = append(out, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sheetquery: %w", err)
+	}
+	return out, nil
+}
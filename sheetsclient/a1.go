@@ -0,0 +1,153 @@
+package sheetsclient
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+)
+
+// a1Cell matches a cell, column or row of A1 notation, either part of
+// which may be made absolute with "$", e.g. "B12", "$B$12", "B" or "12".
+var a1Cell = regexp.MustCompile(`^\$?([A-Za-z]*)(?:\$?([0-9]+))?$`)
+
+// SplitRange splits an A1 range such as "'My Tab'!A1:B2" into the
+// unquoted tab name and the cells, which are empty for a whole tab.
+func SplitRange(rng string) (tab, cells string) {
+	return splitTab(rng)
+}
+
+// GridRange converts cells of A1 notation, such as "A1:C", "$B$2", "B:D"
+// or "2:5", to a grid range on the tab sheetID. Empty cells are the whole
+// tab. Rows start at 1.
+func GridRange(sheetID int64, cells string) (*sheets.GridRange, error) {
+	g := &sheets.GridRange{SheetId: sheetID, ForceSendFields: []string{"SheetId"}}
+	if cells == "" {
+		return g, nil
+	}
+	from, to := cells, cells
+	if i := strings.Index(cells, ":"); i >= 0 {
+		from, to = cells[:i], cells[i+1:]
+	}
+	if from == "" || to == "" {
+		return nil, fmt.Errorf("sheetsclient: invalid range %q", cells)
+	}
+	startCol, startRow, err := splitCell(from)
+	if err != nil {
+		return nil, err
+	}
+	endCol, endRow, err := splitCell(to)
+	if err != nil {
+		return nil, err
+	}
+	if startCol != "" {
+		g.StartColumnIndex = int64(ColumnNumber(startCol) - 1)
+	}
+	if startRow > 0 {
+		g.StartRowIndex = int64(startRow - 1)
+	}
+	if endCol != "" {
+		g.EndColumnIndex = int64(ColumnNumber(endCol))
+	}
+	if endRow > 0 {
+		g.EndRowIndex = int64(endRow)
+	}
+	if (g.EndColumnIndex > 0 && g.EndColumnIndex <= g.StartColumnIndex) || (g.EndRowIndex > 0 && g.EndRowIndex <= g.StartRowIndex) {
+		return nil, fmt.Errorf("sheetsclient: range %q ends before it starts", cells)
+	}
+	g.ForceSendFields = append(g.ForceSendFields, "StartRowIndex", "StartColumnIndex")
+	return g, nil
+}
+
+// FormatRange converts a grid range on tab to A1 notation, e.g.
+// "'Data'!A1:F" for a range without an end row; it is the inverse of
+// SplitRange and GridRange.
+func FormatRange(tab string, g *sheets.GridRange) string {
+	s := QuoteTab(tab)
+	if g.StartRowIndex == 0 && g.StartColumnIndex == 0 && g.EndRowIndex == 0 && g.EndColumnIndex == 0 {
+		return s
+	}
+	if g.EndColumnIndex == 0 && g.StartColumnIndex == 0 && g.EndRowIndex > 0 {
+		// Whole rows.
+		return s + "!" + strconv.FormatInt(g.StartRowIndex+1, 10) + ":" + strconv.FormatInt(g.EndRowIndex, 10)
+	}
+	end := ""
+	if g.EndColumnIndex > 0 {
+		end = ColumnLetters(int(g.EndColumnIndex))
+	}
+	if g.EndRowIndex > 0 {
+		end += strconv.FormatInt(g.EndRowIndex, 10)
+	}
+	return s + "!" + ColumnLetters(int(g.StartColumnIndex+1)) + strconv.FormatInt(g.StartRowIndex+1, 10) + ":" + end
+}
+
+// ColumnNumber converts column letters to their number, e.g. "AB" to 28.
+func ColumnNumber(letters string) int {
+	n := 0
+	for _, r := range strings.ToUpper(letters) {
+		n = n*26 + int(r-'A'+1)
+	}
+	return n
+}
+
+// ColumnLetters is the inverse of ColumnNumber.
+func ColumnLetters(n int) string {
+	s := ""
+	for ; n > 0; n = (n - 1) / 26 {
+		s = string(rune('A'+(n-1)%26)) + s
+	}
+	return s
+}
+
+// gridRange converts rng, an A1 range, a bare tab name or the name of a
+// named range, to a grid range on its tab.
+func (c *Client) gridRange(ctx context.Context, spreadsheetID, rng string) (*sheets.GridRange, error) {
+	rng, err := c.ResolveRange(ctx, spreadsheetID, rng)
+	if err != nil {
+		return nil, err
+	}
+	title, cells := splitTab(rng)
+	t, err := c.Tab(ctx, spreadsheetID, title)
+	if err != nil {
+		return nil, err
+	}
+	return GridRange(t.ID, cells)
+}
+
+// splitCell splits "B12" or "$B$12" into "B" and 12; either part may be
+// missing, but rows start at 1.
+func splitCell(cell string) (string, int, error) {
+	m := a1Cell.FindStringSubmatch(cell)
+	if m == nil {
+		return "", 0, fmt.Errorf("sheetsclient: invalid cell %q", cell)
+	}
+	if m[2] == "" {
+		return strings.ToUpper(m[1]), 0, nil
+	}
+	n, err := strconv.Atoi(m[2])
+	if err != nil || n < 1 {
+		return "", 0, fmt.Errorf("sheetsclient: invalid cell %q", cell)
+	}
+	return strings.ToUpper(m[1]), n, nil
+}
+
+// splitTab splits "Tab!A1:B2" into the unquoted tab name and the cells.
+func splitTab(rng string) (string, string) {
+	tab, cells := rng, ""
+	if i := strings.LastIndex(rng, "!"); i >= 0 {
+		tab, cells = rng[:i], rng[i+1:]
+	}
+	if strings.HasPrefix(tab, "'") && strings.HasSuffix(tab, "'") && len(tab) >= 2 {
+		tab = strings.Replace(tab[1:len(tab)-1], "''", "'", -1)
+	}
+	return tab, cells
+}
+
+// QuoteTab quotes a tab name for use in an A1 range, as in "'Q1 Sales'!A1",
+// doubling the single quotes within it.
+func QuoteTab(tab string) string {
+	return "'" + strings.Replace(tab, "'", "''", -1) + "'"
+}
@@ -0,0 +1,96 @@
+package sheetsclient
+
+import (
+	"testing"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+func TestGridRange(t *testing.T) {
+	tests := []struct {
+		cells                  string
+		startRow, endRow       int64
+		startColumn, endColumn int64
+	}{
+		{"", 0, 0, 0, 0},
+		{"A1", 0, 1, 0, 1},
+		{"B2:D10", 1, 10, 1, 4},
+		{"$B$2:$D$10", 1, 10, 1, 4},
+		{"b$2:D", 1, 0, 1, 4},
+		{"A2:F", 1, 0, 0, 6},
+		{"C:C", 0, 0, 2, 3},
+		{"C", 0, 0, 2, 3},
+		{"3:5", 2, 5, 0, 0},
+		{"AA1", 0, 1, 26, 27},
+	}
+	for _, tt := range tests {
+		g, err := GridRange(7, tt.cells)
+		if err != nil {
+			t.Errorf("GridRange(%q): %v", tt.cells, err)
+			continue
+		}
+		if g.SheetId != 7 || g.StartRowIndex != tt.startRow || g.EndRowIndex != tt.endRow ||
+			g.StartColumnIndex != tt.startColumn || g.EndColumnIndex != tt.endColumn {
+			t.Errorf("GridRange(%q) = rows %d-%d columns %d-%d, want rows %d-%d columns %d-%d", tt.cells,
+				g.StartRowIndex, g.EndRowIndex, g.StartColumnIndex, g.EndColumnIndex,
+				tt.startRow, tt.endRow, tt.startColumn, tt.endColumn)
+		}
+	}
+
+	for _, cells := range []string{"A0", "A0:B2", "A1:B0", "A1:", ":B2", "A1$", "$$", "A-1", "1A", "D1:B1", "A5:A2"} {
+		if g, err := GridRange(0, cells); err == nil {
+			t.Errorf("GridRange(%q) = %+v, want an error", cells, g)
+		}
+	}
+}
+
+func TestSplitRange(t *testing.T) {
+	tests := []struct{ rng, tab, cells string }{
+		{"Data", "Data", ""},
+		{"Data!A1:B2", "Data", "A1:B2"},
+		{"'My Tab'!A1", "My Tab", "A1"},
+		{"'It''s'!B:B", "It's", "B:B"},
+		{"'a!b'!C3", "a!b", "C3"},
+	}
+	for _, tt := range tests {
+		if tab, cells := SplitRange(tt.rng); tab != tt.tab || cells != tt.cells {
+			t.Errorf("SplitRange(%q) = %q, %q; want %q, %q", tt.rng, tab, cells, tt.tab, tt.cells)
+		}
+	}
+}
+
+func TestFormatRange(t *testing.T) {
+	tests := []struct {
+		g    sheets.GridRange
+		want string
+	}{
+		{sheets.GridRange{}, "'Data'"},
+		{sheets.GridRange{StartRowIndex: 1, EndRowIndex: 10, StartColumnIndex: 1, EndColumnIndex: 4}, "'Data'!B2:D10"},
+		{sheets.GridRange{StartRowIndex: 1, EndColumnIndex: 6}, "'Data'!A2:F"},
+		{sheets.GridRange{StartRowIndex: 2, EndRowIndex: 5}, "'Data'!3:5"},
+	}
+	for _, tt := range tests {
+		got := FormatRange("Data", &tt.g)
+		if got != tt.want {
+			t.Errorf("FormatRange(%+v) = %q, want %q", tt.g, got, tt.want)
+			continue
+		}
+		tab, cells := SplitRange(got)
+		g, err := GridRange(0, cells)
+		if err != nil || tab != "Data" || g.StartRowIndex != tt.g.StartRowIndex || g.EndRowIndex != tt.g.EndRowIndex ||
+			g.StartColumnIndex != tt.g.StartColumnIndex || g.EndColumnIndex != tt.g.EndColumnIndex {
+			t.Errorf("%q does not parse back to %+v: %+v, %v", got, tt.g, g, err)
+		}
+	}
+}
+
+func TestColumnLetters(t *testing.T) {
+	for n, letters := range map[int]string{1: "A", 26: "Z", 27: "AA", 28: "AB", 702: "ZZ", 703: "AAA"} {
+		if got := ColumnLetters(n); got != letters {
+			t.Errorf("ColumnLetters(%d) = %q, want %q", n, got, letters)
+		}
+		if got := ColumnNumber(letters); got != n {
+			t.Errorf("ColumnNumber(%q) = %d, want %d", letters, got, n)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package sheetsclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// OAuth authorizes requests on behalf of a user with the installed
+// application flow: the browser is opened on the consent page and
+// redirected back to a server listening on localhost once access is
+// granted. The resulting token is cached so that this only happens once.
+type OAuth struct {
+	// ClientSecretFile is the client_secret.json downloaded from the API
+	// console; defaults to client_secret.json in the working directory.
+	ClientSecretFile string
+	// TokenFile caches the token and the scopes it was granted; defaults
+	// to DefaultTokenFile. A cached token missing one of Scopes is
+	// authorized again, keeping the scopes granted before.
+	TokenFile string
+	// Encryption, if set, encrypts the cached token; see TokenEncryption.
+	Encryption *TokenEncryption
+	Scopes     []string
+	// NoBrowser switches to the manual flow for machines without a browser
+	// or where localhost is not reachable from it: the user opens the link
+	// anywhere and pastes the authorization code back.
+	NoBrowser bool
+	// In and Out are used to show the link and prompt for the code;
+	// default to os.Stdin and os.Stdout.
+	In  io.Reader
+	Out io.Writer
+}
+
+// DefaultTokenFile returns
+// ~/.credentials/sheets.googleapis.com-go-quickstart.json, creating the
+// directory if needed.
+func DefaultTokenFile() (string, error) {
+	usr, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(usr.HomeDir, ".credentials")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, url.QueryEscape("sheets.googleapis.com-go-quickstart.json")), nil
+}
+
+// HTTPClient returns a client authorized with the cached token, running
+// the authorization flow first if there is none.
+func (o *OAuth) HTTPClient(ctx context.Context) (*http.Client, error) {
+	secretFile := o.ClientSecretFile
+	if secretFile == "" {
+		secretFile = "client_secret.json"
+	}
+	b, err := ioutil.ReadFile(secretFile)
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to read client secret file: %w", err)
+	}
+	config, err := google.ConfigFromJSON(b, o.Scopes...)
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to parse client secret file: %w", err)
+	}
+
+	tokenFile := o.TokenFile
+	if tokenFile == "" {
+		if tokenFile, err = DefaultTokenFile(); err != nil {
+			return nil, fmt.Errorf("sheetsclient: unable to get path to cached credential file: %w", err)
+		}
+	}
+	cached, plain, err := readToken(tokenFile, o.Encryption)
+	if errors.Is(err, ErrTokenEncrypted) {
+		return nil, fmt.Errorf("%w; check the passphrase or delete %s to authorize again", err, tokenFile)
+	}
+	if err == nil && !hasScopes(cached.Scopes, o.Scopes) {
+		// Ask for the missing scopes along with those granted before, so
+		// that commands needing either keep working with the new token.
+		config.Scopes = unionScopes(cached.Scopes, o.Scopes)
+		err = errMissingScopes
+	}
+	if err == nil && plain && o.Encryption != nil {
+		// Encrypt a token cached before encryption was turned on.
+		if err := saveToken(tokenFile, cached, o.Encryption); err != nil {
+			return nil, err
+		}
+	}
+	if err != nil {
+		var tok *oauth2.Token
+		if o.NoBrowser {
+			tok, err = o.tokenFromWeb(ctx, config)
+		} else {
+			tok, err = o.tokenFromLoopback(ctx, config)
+		}
+		if err != nil {
+			return nil, err
+		}
+		cached = &cachedToken{Token: tok, Scopes: grantedScopes(tok, config.Scopes)}
+		if err := saveToken(tokenFile, cached, o.Encryption); err != nil {
+			return nil, err
+		}
+	}
+	return config.Client(ctx, cached.Token), nil
+}
+
+// cachedToken is the file format of a cached token: the token's own fields
+// and the scopes it was granted.
+type cachedToken struct {
+	*oauth2.Token
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+var errMissingScopes = errors.New("sheetsclient: cached token lacks a requested scope")
+
+// grantedScopes returns the scopes the token response says were granted,
+// or the requested ones if it does not say.
+func grantedScopes(tok *oauth2.Token, requested []string) []string {
+	if s, ok := tok.Extra("scope").(string); ok && s != "" {
+		return strings.Fields(s)
+	}
+	return requested
+}
+
+// hasScopes reports whether every scope in want is in granted. Tokens
+// cached before scopes were recorded have none, and so are authorized
+// again once.
+func hasScopes(granted, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, g := range granted {
+			if g == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func unionScopes(a, b []string) []string {
+	out := append([]string(nil), a...)
+	for _, s := range b {
+		if !hasScopes(out, []string{s}) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func (o *OAuth) out() io.Writer {
+	if o.Out == nil {
+		return os.Stdout
+	}
+	return o.Out
+}
+
+// tokenFromWeb asks the user to authorize access and exchanges the code
+// for a token.
+func (o *OAuth) tokenFromWeb(ctx context.Context, config *oauth2.Config) (*oauth2.Token, error) {
+	in, out := o.In, o.out()
+	if in == nil {
+		in = os.Stdin
+	}
+	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
+	fmt.Fprintf(out, "Go to the following link in your browser then type the "+
+		"authorization code: \n%v\n", authURL)
+
+	var code string
+	if _, err := fmt.Fscan(in, &code); err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to read authorization code: %w", err)
+	}
+	tok, err := config.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to retrieve token from web: %w", err)
+	}
+	return tok, nil
+}
+
+// saveToken stores a token and its scopes in a file readable only by the
+// user, encrypted with enc if it is not nil.
+func saveToken(file string, token *cachedToken, enc *TokenEncryption) error {
+	b, err := json.Marshal(token)
+	if err == nil && enc != nil {
+		b, err = enc.seal(b)
+	}
+	if err != nil {
+		return fmt.Errorf("sheetsclient: unable to encode oauth token: %w", err)
+	}
+	if err := ioutil.WriteFile(file, append(b, '\n'), 0600); err != nil {
+		return fmt.Errorf("sheetsclient: unable to cache oauth token: %w", err)
+	}
+	return nil
+}
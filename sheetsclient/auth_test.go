@@ -0,0 +1,68 @@
+package sheetsclient
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestSaveTokenScopes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "token.json")
+
+	for _, enc := range []*TokenEncryption{nil, {Passphrase: "pw"}} {
+		want := &cachedToken{Token: &oauth2.Token{AccessToken: "a", RefreshToken: "r"}, Scopes: []string{"s1", "s2"}}
+		if err := saveToken(file, want, enc); err != nil {
+			t.Fatal(err)
+		}
+		got, plain, err := readToken(file, enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plain != (enc == nil) {
+			t.Errorf("plain = %v with encryption %v", plain, enc)
+		}
+		if got.RefreshToken != "r" || !reflect.DeepEqual(got.Scopes, want.Scopes) {
+			t.Errorf("readToken = %+v, %v; want %+v, %v", got.Token, got.Scopes, want.Token, want.Scopes)
+		}
+	}
+
+	// Tokens cached before scopes were recorded still load, without scopes.
+	if err := ioutil.WriteFile(file, []byte(`{"access_token":"a","refresh_token":"r"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	got, _, err := readToken(file, nil)
+	if err != nil || got.RefreshToken != "r" || len(got.Scopes) != 0 {
+		t.Errorf("readToken of a legacy token = %+v, %v", got, err)
+	}
+}
+
+func TestScopes(t *testing.T) {
+	if !hasScopes([]string{"a", "b"}, []string{"b"}) {
+		t.Error("hasScopes([a b], [b]) = false")
+	}
+	if hasScopes([]string{"a"}, []string{"a", "drive"}) {
+		t.Error("hasScopes([a], [a drive]) = true")
+	}
+	if hasScopes(nil, []string{"a"}) {
+		t.Error("hasScopes(nil, [a]) = true")
+	}
+	if got := unionScopes([]string{"a", "b"}, []string{"b", "c"}); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("unionScopes = %v", got)
+	}
+	tok := (&oauth2.Token{}).WithExtra(map[string]interface{}{"scope": "a c"})
+	if got := grantedScopes(tok, []string{"a", "b"}); !reflect.DeepEqual(got, []string{"a", "c"}) {
+		t.Errorf("grantedScopes = %v, want the granted [a c]", got)
+	}
+	if got := grantedScopes(&oauth2.Token{}, []string{"a"}); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("grantedScopes without a scope field = %v, want the requested [a]", got)
+	}
+}
@@ -0,0 +1,61 @@
+package sheetsclient
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// Authenticator returns HTTP clients authorized to call the APIs.
+// OAuth and ServiceAccount implement it.
+type Authenticator interface {
+	HTTPClient(ctx context.Context) (*http.Client, error)
+}
+
+// Chain finds credentials the way most Google tools do, so the same
+// binary works on a laptop and on GCE, GKE or Cloud Run without changes.
+// The first of these that is available is used:
+//
+//  1. the service account key KeyFile, if set;
+//  2. Application Default Credentials: the key named by
+//     $GOOGLE_APPLICATION_CREDENTIALS, the gcloud default credentials,
+//     or the metadata server when running on Google Cloud, unless
+//     NoDefault is set;
+//  3. the interactive OAuth flow, if the client secret file exists.
+type Chain struct {
+	KeyFile string
+	// Subject is the user impersonated with KeyFile; see ServiceAccount.
+	Subject string
+	Scopes  []string
+	// NoDefault skips Application Default Credentials, as for a Profile.
+	NoDefault bool
+	// OAuth configures the last step; its Scopes are replaced by Scopes.
+	OAuth OAuth
+}
+
+// HTTPClient returns a client authorized by the first available source.
+func (c *Chain) HTTPClient(ctx context.Context) (*http.Client, error) {
+	if c.KeyFile != "" {
+		return (&ServiceAccount{KeyFile: c.KeyFile, Scopes: c.Scopes, Subject: c.Subject}).HTTPClient(ctx)
+	}
+	if !c.NoDefault {
+		if creds, err := google.FindDefaultCredentials(ctx, c.Scopes...); err == nil {
+			return oauth2.NewClient(ctx, creds.TokenSource), nil
+		}
+	}
+	o := c.OAuth
+	o.Scopes = c.Scopes
+	secretFile := o.ClientSecretFile
+	if secretFile == "" {
+		secretFile = "client_secret.json"
+	}
+	if _, err := os.Stat(secretFile); err != nil {
+		return nil, fmt.Errorf("sheetsclient: no credentials found: pass a service account key, "+
+			"set up Application Default Credentials or add %s", secretFile)
+	}
+	return o.HTTPClient(ctx)
+}
@@ -0,0 +1,260 @@
+// Package sheetsclient is a small client for reading and writing
+// spreadsheet ranges, for use by other Go programs as well as the
+// command line tool in this repository:
+//
+//	httpClient, err := (&sheetsclient.OAuth{Scopes: sheetsclient.Scopes}).HTTPClient(ctx)
+//	...
+//	c, err := sheetsclient.New(ctx, httpClient)
+//	...
+//	rows, err := c.ReadRange(ctx, spreadsheetID, "Data!A1:F")
+package sheetsclient
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+)
+
+// Scopes are the OAuth scopes needed by every method of Client.
+var Scopes = []string{
+	sheets.SpreadsheetsScope,
+	drive.DriveMetadataReadonlyScope,
+}
+
+// Client reads and writes spreadsheet values.
+type Client struct {
+	// Sheets is the underlying service, for calls Client does not wrap.
+	Sheets *sheets.Service
+	// ValueInputOption controls how written values are interpreted:
+	// "USER_ENTERED" (the default) parses them as if typed into the UI,
+	// so "=SUM(A:A)" is a formula and "3" a number; "RAW" stores them
+	// as given.
+	ValueInputOption string
+	// ValueRenderOption controls how read values are rendered:
+	// "FORMATTED_VALUE" (the default) returns them as displayed, e.g.
+	// "$1,234.50"; "UNFORMATTED_VALUE" returns numbers and booleans as
+	// such; "FORMULA" returns the formulas of cells that have one. See
+	// ParseValueRender.
+	ValueRenderOption string
+	// DateTimeRenderOption controls how dates and times are read when
+	// ValueRenderOption is not "FORMATTED_VALUE": "SERIAL_NUMBER" (the
+	// default) returns them as serial numbers, which SerialTime converts,
+	// and "FORMATTED_STRING" as displayed.
+	DateTimeRenderOption string
+	// SkipRows is the number of rows above the header of a range, such as
+	// a title, that RowsInto skips.
+	SkipRows int
+}
+
+// New returns a client sending requests with httpClient, which must add
+// authorization, e.g. the one returned by OAuth.HTTPClient. Requests are
+// paced by a default Limiter, and rate limited or failed requests are
+// retried as described by Retry; pass a client made with WithRetry to
+// choose both policies.
+func New(ctx context.Context, httpClient *http.Client, opts ...option.ClientOption) (*Client, error) {
+	if _, ok := httpClient.Transport.(*Retry); !ok {
+		httpClient = WithRetry(WithLimit(httpClient, nil), nil)
+	}
+	srv, err := sheets.NewService(ctx, append([]option.ClientOption{option.WithHTTPClient(httpClient)}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to create Sheets service: %w", err)
+	}
+	return &Client{Sheets: srv}, nil
+}
+
+// FromService returns a client using an existing Sheets service.
+func FromService(srv *sheets.Service) *Client {
+	return &Client{Sheets: srv}
+}
+
+// ParseValueRender returns the ValueRenderOption named by s: formatted,
+// unformatted or formula, or the option itself, in any case.
+func ParseValueRender(s string) (string, error) {
+	switch strings.ToUpper(strings.Replace(s, "-", "_", -1)) {
+	case "", "FORMATTED", "FORMATTED_VALUE":
+		return "FORMATTED_VALUE", nil
+	case "UNFORMATTED", "UNFORMATTED_VALUE":
+		return "UNFORMATTED_VALUE", nil
+	case "FORMULA", "FORMULAS":
+		return "FORMULA", nil
+	}
+	return "", fmt.Errorf("sheetsclient: unknown value render option %q", s)
+}
+
+func (c *Client) inputOption() string {
+	if c.ValueInputOption == "" {
+		return "USER_ENTERED"
+	}
+	return c.ValueInputOption
+}
+
+// ReadRange returns the values of an A1 range, e.g. "Data!A2:E". Trailing
+// empty rows and cells are omitted, so rows may have different lengths.
+func (c *Client) ReadRange(ctx context.Context, spreadsheetID, rng string) ([][]interface{}, error) {
+	call := c.Sheets.Spreadsheets.Values.Get(spreadsheetID, rng)
+	if c.ValueRenderOption != "" {
+		call.ValueRenderOption(c.ValueRenderOption)
+	}
+	if c.DateTimeRenderOption != "" {
+		call.DateTimeRenderOption(c.DateTimeRenderOption)
+	}
+	resp, err := call.Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to read %s: %w", rng, Classify(err))
+	}
+	return resp.Values, nil
+}
+
+// BatchGet reads several ranges, possibly from different tabs, in a single
+// request. The result maps each of the given ranges, as spelled by the
+// caller, to its values.
+func (c *Client) BatchGet(ctx context.Context, spreadsheetID string, ranges ...string) (map[string][][]interface{}, error) {
+	if len(ranges) == 0 {
+		return map[string][][]interface{}{}, nil
+	}
+	call := c.Sheets.Spreadsheets.Values.BatchGet(spreadsheetID).Ranges(ranges...)
+	if c.ValueRenderOption != "" {
+		call.ValueRenderOption(c.ValueRenderOption)
+	}
+	if c.DateTimeRenderOption != "" {
+		call.DateTimeRenderOption(c.DateTimeRenderOption)
+	}
+	resp, err := call.Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to read %s: %w", strings.Join(ranges, ", "), Classify(err))
+	}
+	if len(resp.ValueRanges) != len(ranges) {
+		return nil, fmt.Errorf("sheetsclient: asked for %d ranges but got %d", len(ranges), len(resp.ValueRanges))
+	}
+	out := make(map[string][][]interface{}, len(ranges))
+	for i, vr := range resp.ValueRanges {
+		out[ranges[i]] = vr.Values
+	}
+	return out, nil
+}
+
+// ReadStrings is like ReadRange but formats every value as a string.
+func (c *Client) ReadStrings(ctx context.Context, spreadsheetID, rng string) ([][]string, error) {
+	values, err := c.ReadRange(ctx, spreadsheetID, rng)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([][]string, len(values))
+	for i, row := range values {
+		rows[i] = make([]string, len(row))
+		for j, v := range row {
+			rows[i][j] = fmt.Sprint(v)
+		}
+	}
+	return rows, nil
+}
+
+// WriteRange overwrites a range starting at its top left cell with values,
+// and returns the number of cells updated. Cells of the range outside
+// values are left alone.
+func (c *Client) WriteRange(ctx context.Context, spreadsheetID, rng string, values [][]interface{}) (int64, error) {
+	resp, err := c.Sheets.Spreadsheets.Values.Update(spreadsheetID, rng, &sheets.ValueRange{Values: values}).
+		ValueInputOption(c.inputOption()).Context(ctx).Do()
+	if err != nil {
+		return 0, fmt.Errorf("sheetsclient: unable to write %s: %w", rng, Classify(err))
+	}
+	return resp.UpdatedCells, nil
+}
+
+// BatchResult summarizes a BatchUpdate.
+type BatchResult struct {
+	// Ranges are the ranges written, as normalized by the API.
+	Ranges               []string
+	Rows, Columns, Cells int64
+}
+
+// BatchUpdate writes several ranges in one request: either every range is
+// updated or, on error, none is. data maps A1 ranges to the values written
+// from their top left cell.
+func (c *Client) BatchUpdate(ctx context.Context, spreadsheetID string, data map[string][][]interface{}) (*BatchResult, error) {
+	ranges := make([]string, 0, len(data))
+	for rng := range data {
+		ranges = append(ranges, rng)
+	}
+	sort.Strings(ranges)
+	req := &sheets.BatchUpdateValuesRequest{ValueInputOption: c.inputOption()}
+	for _, rng := range ranges {
+		req.Data = append(req.Data, &sheets.ValueRange{Range: rng, Values: data[rng]})
+	}
+	res := &BatchResult{}
+	if len(req.Data) == 0 {
+		return res, nil
+	}
+	resp, err := c.Sheets.Spreadsheets.Values.BatchUpdate(spreadsheetID, req).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to write %s: %w", strings.Join(ranges, ", "), Classify(err))
+	}
+	for _, u := range resp.Responses {
+		res.Ranges = append(res.Ranges, u.UpdatedRange)
+	}
+	res.Rows, res.Columns, res.Cells = resp.TotalUpdatedRows, resp.TotalUpdatedColumns, resp.TotalUpdatedCells
+	return res, nil
+}
+
+// Clear empties the values of the given ranges, keeping formatting, in a
+// single request, and returns the ranges cleared as normalized by the API.
+func (c *Client) Clear(ctx context.Context, spreadsheetID string, ranges ...string) ([]string, error) {
+	switch len(ranges) {
+	case 0:
+		return nil, nil
+	case 1:
+		resp, err := c.Sheets.Spreadsheets.Values.Clear(spreadsheetID, ranges[0], &sheets.ClearValuesRequest{}).Context(ctx).Do()
+		if err != nil {
+			return nil, fmt.Errorf("sheetsclient: unable to clear %s: %w", ranges[0], Classify(err))
+		}
+		return []string{resp.ClearedRange}, nil
+	}
+	resp, err := c.Sheets.Spreadsheets.Values.BatchClear(spreadsheetID, &sheets.BatchClearValuesRequest{Ranges: ranges}).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to clear %s: %w", strings.Join(ranges, ", "), Classify(err))
+	}
+	return resp.ClearedRanges, nil
+}
+
+// AppendOptions tune Append.
+type AppendOptions struct {
+	// ValueInputOption overrides the client's for this call.
+	ValueInputOption string
+	// InsertDataOption is "OVERWRITE" (the default), writing over the
+	// empty cells below the table, or "INSERT_ROWS", inserting new rows so
+	// that content further down is pushed rather than overwritten.
+	InsertDataOption string
+}
+
+// Append adds rows after the table found in rng: the sheet looks for the
+// last contiguous block of data in the range and writes below it, which
+// makes it suitable for logging from scripts. opts may be nil. It returns
+// the range that was written.
+func (c *Client) Append(ctx context.Context, spreadsheetID, rng string, rows [][]interface{}, opts *AppendOptions) (*sheets.UpdateValuesResponse, error) {
+	if opts == nil {
+		opts = &AppendOptions{}
+	}
+	input := opts.ValueInputOption
+	if input == "" {
+		input = c.inputOption()
+	}
+	call := c.Sheets.Spreadsheets.Values.Append(spreadsheetID, rng, &sheets.ValueRange{Values: rows}).
+		ValueInputOption(input)
+	if opts.InsertDataOption != "" {
+		call = call.InsertDataOption(opts.InsertDataOption)
+	}
+	resp, err := call.Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to append to %s: %w", rng, Classify(err))
+	}
+	if resp.Updates == nil {
+		return &sheets.UpdateValuesResponse{SpreadsheetId: spreadsheetID}, nil
+	}
+	return resp.Updates, nil
+}
@@ -0,0 +1,47 @@
+package sheetsclient
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// epoch is day zero of spreadsheet serial numbers.
+var epoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// SerialTime converts a serial number, the days since December 30, 1899
+// with the time of day as the fraction that values read with
+// DateTimeRenderOption "SERIAL_NUMBER" use for dates and times, to the
+// time it stands for in loc, usually the spreadsheet's time zone as
+// returned by Location. Times are rounded to the millisecond.
+func SerialTime(serial float64, loc *time.Location) time.Time {
+	ms := time.Duration(math.Round(serial*24*60*60*1000)) * time.Millisecond
+	t := epoch.Add(ms)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+// TimeSerial is the inverse of SerialTime: it returns the serial number of
+// t's wall clock time in its location.
+func TimeSerial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return float64(wall.Sub(epoch)) / float64(24*time.Hour)
+}
+
+// Location returns the time zone of a spreadsheet, in which its dates and
+// times are given.
+func (c *Client) Location(ctx context.Context, spreadsheetID string) (*time.Location, error) {
+	s, err := c.Sheets.Spreadsheets.Get(spreadsheetID).Fields("properties(timeZone)").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to get time zone of %s: %w", spreadsheetID, Classify(err))
+	}
+	if s.Properties == nil || s.Properties.TimeZone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(s.Properties.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to load time zone of %s: %w", spreadsheetID, err)
+	}
+	return loc, nil
+}
@@ -0,0 +1,274 @@
+package sheetsclient
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// structField is a field of a row struct and the column it maps to.
+type structField struct {
+	column   string
+	index    []int
+	required bool
+}
+
+// structFields lists the columns of the struct type t. The column of a
+// field is given by its `sheet:"column_name"` tag and defaults to the
+// field name; "-" skips the field and a ",required" option makes decoding
+// fail when the column is missing or the cell empty. Fields of embedded
+// structs are promoted, as with encoding/json.
+func structFields(t reflect.Type) []structField {
+	var out []structField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("sheet")
+		if tag == "-" {
+			continue
+		}
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct && tag == "" {
+			for _, f := range structFields(sf.Type) {
+				f.index = append([]int{i}, f.index...)
+				out = append(out, f)
+			}
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue // unexported
+		}
+		name, opts := tag, ""
+		if j := strings.Index(tag, ","); j >= 0 {
+			name, opts = tag[:j], tag[j+1:]
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		f := structField{column: name, index: []int{i}}
+		for _, o := range strings.Split(opts, ",") {
+			f.required = f.required || o == "required"
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// RowsInto reads a range whose first row, after c.SkipRows, is a header
+// and stores the rows below it in dst, a pointer to a slice of structs or
+// of pointers to structs. See Unmarshal. When values are read as serial
+// numbers, time fields are decoded in the spreadsheet's time zone.
+func (c *Client) RowsInto(ctx context.Context, spreadsheetID, rng string, dst interface{}) error {
+	values, err := c.ReadRange(ctx, spreadsheetID, rng)
+	if err != nil {
+		return err
+	}
+	loc := time.UTC
+	if c.ValueRenderOption != "" && c.ValueRenderOption != "FORMATTED_VALUE" && c.DateTimeRenderOption != "FORMATTED_STRING" {
+		if loc, err = c.Location(ctx, spreadsheetID); err != nil {
+			return err
+		}
+	}
+	return UnmarshalIn(SkipRows(values, c.SkipRows), dst, loc)
+}
+
+// Unmarshal decodes rows into dst, a pointer to a slice of structs or of
+// pointers to structs. The first row is the header: each field is filled
+// from the column whose header matches its `sheet` tag, ignoring case and
+// surrounding spaces, so columns can be reordered in the sheet without
+// breaking the program:
+//
+//	type Student struct {
+//		Name  string `sheet:"Student Name,required"`
+//		Major string `sheet:"Major"`
+//		Year  int    `sheet:"Class Level"`
+//	}
+//
+// Columns without a field are ignored, and so are rows that are entirely
+// empty. Empty cells leave fields at their zero value. Numbers may contain
+// thousands separators; booleans accept TRUE/FALSE, yes/no and 1/0; times
+// accept RFC 3339 and the common date formats, and anything implementing
+// encoding.TextUnmarshaler is given the cell text. Numbers decoded into
+// times are serial numbers, taken in UTC; see UnmarshalIn.
+func Unmarshal(rows [][]interface{}, dst interface{}) error {
+	return UnmarshalIn(rows, dst, time.UTC)
+}
+
+// UnmarshalIn is like Unmarshal but decodes serial numbers into times in
+// loc, the spreadsheet's time zone, as SerialTime does.
+func UnmarshalIn(rows [][]interface{}, dst interface{}, loc *time.Location) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("sheetsclient: Unmarshal needs a pointer to a slice, not %T", dst)
+	}
+	slice := v.Elem()
+	elem := slice.Type().Elem()
+	ptr := elem.Kind() == reflect.Ptr
+	if ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return fmt.Errorf("sheetsclient: Unmarshal needs a slice of structs, not %s", slice.Type())
+	}
+
+	var header []interface{}
+	if len(rows) > 0 {
+		header, rows = rows[0], rows[1:]
+	}
+	fields := structFields(elem)
+	cols := make([]int, len(fields))
+	for i, f := range fields {
+		cols[i] = HeaderColumn(header, f.column)
+		if cols[i] < 0 && f.required {
+			return fmt.Errorf("sheetsclient: missing required column %q", f.column)
+		}
+	}
+
+	out := reflect.MakeSlice(slice.Type(), 0, len(rows))
+	for i, row := range rows {
+		if blank(row) {
+			continue
+		}
+		rv := reflect.New(elem).Elem()
+		for k, f := range fields {
+			var cell interface{}
+			if cols[k] >= 0 && cols[k] < len(row) {
+				cell = row[cols[k]]
+			}
+			if cell == nil || strings.TrimSpace(fmt.Sprint(cell)) == "" {
+				if f.required {
+					return fmt.Errorf("sheetsclient: row %d: %s is required", i+2, f.column)
+				}
+				continue
+			}
+			if err := setField(rv.FieldByIndex(f.index), cell, loc); err != nil {
+				return fmt.Errorf("sheetsclient: row %d: %s: %w", i+2, f.column, err)
+			}
+		}
+		if ptr {
+			rv = rv.Addr()
+		}
+		out = reflect.Append(out, rv)
+	}
+	slice.Set(out)
+	return nil
+}
+
+func blank(row []interface{}) bool {
+	for _, v := range row {
+		if strings.TrimSpace(fmt.Sprint(v)) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// timeLayouts are tried in order when decoding a time.
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"1/2/2006 15:04:05",
+	"1/2/2006",
+}
+
+// setField stores a cell in v. Cells read as FORMATTED_VALUE are strings;
+// numbers and booleans read unformatted are used as they are, and numbers
+// stored in times are serial numbers in loc. Texts without an offset are
+// times in loc too.
+func setField(v reflect.Value, cell interface{}, loc *time.Location) error {
+	if v.Kind() == reflect.Ptr {
+		p := reflect.New(v.Type().Elem())
+		if err := setField(p.Elem(), cell, loc); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	}
+	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
+		v.Set(reflect.ValueOf(cell))
+		return nil
+	}
+	s := strings.TrimSpace(fmt.Sprint(cell))
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok && v.Type() != timeType {
+		return u.UnmarshalText([]byte(s))
+	}
+	switch v.Type() {
+	case timeType:
+		if f, ok := cell.(float64); ok {
+			v.Set(reflect.ValueOf(SerialTime(f, loc)))
+			return nil
+		}
+		for _, layout := range timeLayouts {
+			if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+				v.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid time %q", s)
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	if f, ok := cell.(float64); ok {
+		switch v.Kind() {
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(f)
+			return nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v.SetInt(int64(f))
+			return nil
+		}
+	}
+	number := strings.Replace(s, ",", "", -1)
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		switch strings.ToLower(s) {
+		case "true", "yes", "y", "1":
+			v.SetBool(true)
+		case "false", "no", "n", "0":
+			v.SetBool(false)
+		default:
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(number, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(number, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSuffix(number, "%"), v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(number, "%") {
+			f /= 100
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package sheetsclient
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Marshal is the inverse of Unmarshal: it turns src, a slice of structs or
+// of pointers to structs, into a header row of column names followed by
+// one row per element. Nil pointers and nil elements become empty cells.
+// Numbers and booleans are kept as such so the sheet can compute with
+// them, times are written as "2006-01-02 15:04:05", or "2006-01-02" at
+// midnight, which USER_ENTERED input turns into dates, and values
+// implementing encoding.TextMarshaler or fmt.Stringer are written as text.
+func Marshal(src interface{}) ([][]interface{}, error) {
+	v := reflect.ValueOf(src)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("sheetsclient: Marshal needs a slice, not %T", src)
+	}
+	elem := v.Type().Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("sheetsclient: Marshal needs a slice of structs, not %s", v.Type())
+	}
+
+	fields := structFields(elem)
+	header := make([]interface{}, len(fields))
+	for i, f := range fields {
+		header[i] = f.column
+	}
+	rows := [][]interface{}{header}
+	for i := 0; i < v.Len(); i++ {
+		ev := v.Index(i)
+		row := make([]interface{}, len(fields))
+		for j, f := range fields {
+			row[j] = ""
+			if ev.Kind() == reflect.Ptr && ev.IsNil() {
+				continue
+			}
+			fv, ok := fieldByIndex(reflect.Indirect(ev), f.index)
+			if !ok {
+				continue
+			}
+			cell, err := cellValue(fv)
+			if err != nil {
+				return nil, fmt.Errorf("sheetsclient: element %d: %s: %w", i, f.column, err)
+			}
+			row[j] = cell
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex but reports false
+// instead of panicking on nil embedded pointers.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// cellValue converts a field to the value written to its cell.
+func cellValue(v reflect.Value) (interface{}, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	switch x := v.Interface().(type) {
+	case time.Time:
+		if x.IsZero() {
+			return "", nil
+		}
+		if x.Hour() == 0 && x.Minute() == 0 && x.Second() == 0 && x.Nanosecond() == 0 {
+			return x.Format("2006-01-02"), nil
+		}
+		return x.Format("2006-01-02 15:04:05"), nil
+	case time.Duration:
+		return x.String(), nil
+	case encoding.TextMarshaler:
+		b, err := x.MarshalText()
+		return string(b), err
+	case fmt.Stringer:
+		return x.String(), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			// Not representable in JSON; the sheet shows these as text.
+			return fmt.Sprint(f), nil
+		}
+		return f, nil
+	case reflect.Slice, reflect.Array:
+		// Lists go into one cell, comma separated.
+		parts := make([]string, v.Len())
+		for i := range parts {
+			c, err := cellValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			parts[i] = fmt.Sprint(c)
+		}
+		return strings.Join(parts, ", "), nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// WriteStructs marshals src and writes the header and rows to rng,
+// returning the number of cells updated.
+func (c *Client) WriteStructs(ctx context.Context, spreadsheetID, rng string, src interface{}) (int64, error) {
+	rows, err := Marshal(src)
+	if err != nil {
+		return 0, err
+	}
+	return c.WriteRange(ctx, spreadsheetID, rng, rows)
+}
+
+// AppendStructs marshals src and appends the rows, without the header, to
+// the table found in rng.
+func (c *Client) AppendStructs(ctx context.Context, spreadsheetID, rng string, src interface{}) error {
+	rows, err := Marshal(src)
+	if err != nil {
+		return err
+	}
+	_, err = c.Append(ctx, spreadsheetID, rng, rows[1:], nil)
+	return err
+}
@@ -0,0 +1,89 @@
+package sheetsclient
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+)
+
+// Errors returned by Client methods can be tested with errors.Is against
+// these kinds. The underlying *googleapi.Error, if any, is still available
+// through errors.As.
+var (
+	// ErrTokenExpired means the credentials were rejected: the OAuth
+	// token expired or was revoked, and authorizing again is needed.
+	ErrTokenExpired = errors.New("authorization expired or revoked")
+	// ErrNotFound means the spreadsheet does not exist or is not visible
+	// to the authorized user.
+	ErrNotFound = errors.New("spreadsheet not found")
+	// ErrRangeNotFound means a range names a tab that does not exist or
+	// cannot be parsed.
+	ErrRangeNotFound = errors.New("range not found")
+	// ErrPermissionDenied means the user may see, but not change, the
+	// spreadsheet, or the OAuth scopes do not allow the call.
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrQuotaExceeded means the request was rate limited even after the
+	// retries of Retry.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+)
+
+// kindError attaches one of the error kinds above to an error without
+// changing its message.
+type kindError struct {
+	kind, err error
+}
+
+func (e *kindError) Error() string   { return e.err.Error() }
+func (e *kindError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// Classify returns err annotated with its kind, so that errors.Is(err,
+// ErrQuotaExceeded) and the like work for errors of calls made directly
+// with the Sheets or Drive services. Errors of an unknown kind, and nil,
+// are returned as they are.
+func Classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	for _, kind := range []error{ErrTokenExpired, ErrNotFound, ErrRangeNotFound, ErrPermissionDenied, ErrQuotaExceeded} {
+		if errors.Is(err, kind) {
+			return err
+		}
+	}
+	if kind := kindOf(err); kind != nil {
+		return &kindError{kind, err}
+	}
+	return err
+}
+
+func kindOf(err error) error {
+	var re *oauth2.RetrieveError
+	if errors.As(err, &re) {
+		return ErrTokenExpired
+	}
+	var ge *googleapi.Error
+	if !errors.As(err, &ge) {
+		return nil
+	}
+	switch ge.Code {
+	case 401:
+		return ErrTokenExpired
+	case 404:
+		return ErrNotFound
+	case 429:
+		return ErrQuotaExceeded
+	case 400:
+		if strings.Contains(ge.Message, "Unable to parse range") {
+			return ErrRangeNotFound
+		}
+	case 403:
+		for _, e := range ge.Errors {
+			if e.Reason == "rateLimitExceeded" || e.Reason == "userRateLimitExceeded" {
+				return ErrQuotaExceeded
+			}
+		}
+		return ErrPermissionDenied
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package sheetsclient
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SkipRows drops the first n rows, such as a title and notes above the
+// header row of a table.
+func SkipRows(rows [][]interface{}, n int) [][]interface{} {
+	if n <= 0 {
+		return rows
+	}
+	if n >= len(rows) {
+		return nil
+	}
+	return rows[n:]
+}
+
+// HeaderColumn returns the index of the column of header named name,
+// ignoring case and surrounding spaces, or -1. This is how RowsInto and
+// the other header-aware methods match names to columns.
+func HeaderColumn(header []interface{}, name string) int {
+	name = strings.TrimSpace(name)
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(fmt.Sprint(h)), name) {
+			return i
+		}
+	}
+	return -1
+}
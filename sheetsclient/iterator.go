@@ -0,0 +1,174 @@
+package sheetsclient
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// RowIterator reads a range in chunks of rows, fetching the next chunk
+// only once the rows of the previous one have been consumed, so that
+// sheets of any size can be processed in constant memory:
+//
+//	it := c.Rows(ctx, spreadsheetID, "Data!A:F", 5000)
+//	for it.Next() {
+//		row := it.Row()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type RowIterator struct {
+	c             *Client
+	ctx           context.Context
+	spreadsheetID string
+	tab           string
+	// startCol and endCol are the column letters of the range, empty
+	// for whole rows.
+	startCol, endCol string
+	// next is the number of the first row of the next chunk, last the
+	// final row to read, found from the grid size if not in the range.
+	next, last int
+	chunk      int
+	// named is set while the range may still be a named range rather
+	// than a tab.
+	named bool
+
+	buf [][]interface{}
+	// blank counts empty rows seen at the end of chunks, which are
+	// only yielded once a later chunk turns out to have more data.
+	blank int
+	row   []interface{}
+	num   int
+	done  bool
+	err   error
+}
+
+// Rows returns an iterator over the rows of rng, e.g. "Data" or
+// "Data!A2:F", or a named range, read chunkSize rows per request;
+// chunkSize defaults to 5000. Rows within the range that are empty are yielded as empty slices,
+// while trailing empty rows are not yielded at all.
+func (c *Client) Rows(ctx context.Context, spreadsheetID, rng string, chunkSize int) *RowIterator {
+	if chunkSize <= 0 {
+		chunkSize = 5000
+	}
+	it := &RowIterator{c: c, ctx: ctx, spreadsheetID: spreadsheetID, chunk: chunkSize}
+	var cells string
+	it.tab, cells = splitTab(rng)
+	it.named = !strings.ContainsAny(rng, "!'")
+	if err := it.parse(cells); err != nil {
+		it.err = err
+	}
+	return it
+}
+
+// parse reads the bounds of cells, such as "A2:F100", "A:F" or "2:10".
+func (it *RowIterator) parse(cells string) error {
+	it.next = 1
+	if cells == "" {
+		return nil
+	}
+	from, to := cells, ""
+	if i := strings.Index(cells, ":"); i >= 0 {
+		from, to = cells[:i], cells[i+1:]
+	}
+	col, row, err := splitCell(from)
+	if err != nil {
+		return err
+	}
+	it.startCol = col
+	if row > 0 {
+		it.next = row
+	}
+	if to == "" {
+		// A single cell.
+		it.endCol, it.last = col, it.next
+		return nil
+	}
+	if it.endCol, it.last, err = splitCell(to); err != nil {
+		return err
+	}
+	if (it.startCol == "") != (it.endCol == "") {
+		return fmt.Errorf("sheetsclient: invalid range %q", cells)
+	}
+	return nil
+}
+
+// Next advances to the next row, fetching a chunk if needed. It returns
+// false at the end of the range or on error.
+func (it *RowIterator) Next() bool {
+	for len(it.buf) == 0 {
+		if it.err != nil || it.done {
+			it.row = nil
+			return false
+		}
+		it.fetch()
+	}
+	it.row, it.buf = it.buf[0], it.buf[1:]
+	it.num++
+	return true
+}
+
+func (it *RowIterator) fetch() {
+	if it.last == 0 {
+		t, err := it.c.Tab(it.ctx, it.spreadsheetID, it.tab)
+		if errors.Is(err, ErrRangeNotFound) && it.named {
+			// Not a tab; read the range the name refers to.
+			it.named = false
+			rng, rerr := it.c.ResolveRange(it.ctx, it.spreadsheetID, it.tab)
+			if rerr != nil {
+				err = rerr
+			} else if rng != it.tab {
+				var cells string
+				it.tab, cells = splitTab(rng)
+				if err = it.parse(cells); err == nil {
+					it.fetch()
+					return
+				}
+			}
+		}
+		if err != nil {
+			it.err = err
+			return
+		}
+		it.last = int(t.Rows)
+	}
+	if it.next > it.last {
+		it.done = true
+		return
+	}
+	end := it.next + it.chunk - 1
+	if end > it.last {
+		end = it.last
+	}
+	rng := fmt.Sprintf("%s!%s%d:%s%d", QuoteTab(it.tab), it.startCol, it.next, it.endCol, end)
+	rows, err := it.c.ReadRange(it.ctx, it.spreadsheetID, rng)
+	if err != nil {
+		it.err = err
+		return
+	}
+	if it.num == 0 && len(it.buf) == 0 {
+		// Number rows from the first of the range.
+		it.num = it.next - 1 - it.blank
+	}
+	if len(rows) > 0 {
+		for ; it.blank > 0; it.blank-- {
+			it.buf = append(it.buf, []interface{}{})
+		}
+		it.buf = append(it.buf, rows...)
+	}
+	it.blank += end - it.next + 1 - len(rows)
+	it.next = end + 1
+}
+
+// Row returns the current row.
+func (it *RowIterator) Row() []interface{} { return it.row }
+
+// RowNumber returns the row number of the current row in the sheet,
+// starting at 1.
+func (it *RowIterator) RowNumber() int { return it.num }
+
+// Err returns the error that stopped the iteration, if any.
+func (it *RowIterator) Err() error { return it.err }
@@ -0,0 +1,240 @@
+package sheetsclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+)
+
+// MergeType says how Merge combines the cells of a range.
+type MergeType string
+
+// Merge types.
+const (
+	// MergeAll makes the range a single cell.
+	MergeAll MergeType = "MERGE_ALL"
+	// MergeColumns merges each column of the range into one cell.
+	MergeColumns MergeType = "MERGE_COLUMNS"
+	// MergeRows merges each row of the range into one cell.
+	MergeRows MergeType = "MERGE_ROWS"
+)
+
+// ParseMergeType returns the MergeType named by s: all, columns or rows.
+func ParseMergeType(s string) (MergeType, error) {
+	switch strings.ToLower(s) {
+	case "", "all":
+		return MergeAll, nil
+	case "columns":
+		return MergeColumns, nil
+	case "rows":
+		return MergeRows, nil
+	}
+	return "", fmt.Errorf("sheetsclient: unknown merge type %q", s)
+}
+
+// Merge merges the cells of rng, e.g. "Report!A1:F1" for a title spanning
+// a table, or of a named range. Only the top left value of each merged cell is kept.
+func (c *Client) Merge(ctx context.Context, spreadsheetID, rng string, typ MergeType) error {
+	g, err := c.gridRange(ctx, spreadsheetID, rng)
+	if err != nil {
+		return err
+	}
+	if typ == "" {
+		typ = MergeAll
+	}
+	_, err = c.batch(ctx, spreadsheetID, &sheets.Request{MergeCells: &sheets.MergeCellsRequest{Range: g, MergeType: string(typ)}})
+	if err != nil {
+		return fmt.Errorf("sheetsclient: unable to merge %s: %w", rng, Classify(err))
+	}
+	return nil
+}
+
+// Unmerge splits every merged cell within rng back into its cells.
+func (c *Client) Unmerge(ctx context.Context, spreadsheetID, rng string) error {
+	g, err := c.gridRange(ctx, spreadsheetID, rng)
+	if err != nil {
+		return err
+	}
+	if _, err := c.batch(ctx, spreadsheetID, &sheets.Request{UnmergeCells: &sheets.UnmergeCellsRequest{Range: g}}); err != nil {
+		return fmt.Errorf("sheetsclient: unable to unmerge %s: %w", rng, Classify(err))
+	}
+	return nil
+}
+
+// AutoResizeColumns fits the width of the columns of rng, e.g.
+// "Data!A1:F20", a whole tab or a named range, to their content.
+func (c *Client) AutoResizeColumns(ctx context.Context, spreadsheetID, rng string) error {
+	g, err := c.gridRange(ctx, spreadsheetID, rng)
+	if err != nil {
+		return err
+	}
+	dims := &sheets.DimensionRange{SheetId: g.SheetId, Dimension: "COLUMNS", StartIndex: g.StartColumnIndex, EndIndex: g.EndColumnIndex}
+	dims.ForceSendFields = []string{"SheetId", "StartIndex"}
+	_, err = c.batch(ctx, spreadsheetID, &sheets.Request{AutoResizeDimensions: &sheets.AutoResizeDimensionsRequest{Dimensions: dims}})
+	if err != nil {
+		return fmt.Errorf("sheetsclient: unable to resize columns of %s: %w", rng, Classify(err))
+	}
+	return nil
+}
+
+// Extent returns the range covered by rows written from the top left cell
+// of rng, e.g. "'Data'!B2:D11" for ten rows of three values at "Data!B2".
+func Extent(rng string, rows [][]interface{}) (string, error) {
+	tab, cells := splitTab(rng)
+	from := cells
+	if i := strings.Index(cells, ":"); i >= 0 {
+		from = cells[:i]
+	}
+	col, row, err := splitCell(from)
+	if err != nil {
+		return "", err
+	}
+	if col == "" {
+		col = "A"
+	}
+	if row == 0 {
+		row = 1
+	}
+	width := 1
+	for _, r := range rows {
+		if len(r) > width {
+			width = len(r)
+		}
+	}
+	height := len(rows)
+	if height == 0 {
+		height = 1
+	}
+	first := ColumnNumber(col)
+	return fmt.Sprintf("%s!%s%d:%s%d", QuoteTab(tab), col, row, ColumnLetters(first+width-1), row+height-1), nil
+}
+
+// SortKey orders rows by a column: either its position within the range,
+// 1 for the range's first column, or its letter, e.g. "3" or "C".
+type SortKey struct {
+	Column     string
+	Descending bool
+}
+
+// ParseSortKeys parses keys such as "3:desc,1:asc" or "C,A:desc";
+// the order defaults to ascending.
+func ParseSortKeys(s string) ([]SortKey, error) {
+	var keys []SortKey
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		col, order := part, "asc"
+		if i := strings.Index(part, ":"); i >= 0 {
+			col, order = part[:i], strings.ToLower(part[i+1:])
+		}
+		if col == "" || (order != "asc" && order != "desc") {
+			return nil, fmt.Errorf("sheetsclient: invalid sort key %q", part)
+		}
+		keys = append(keys, SortKey{Column: col, Descending: order == "desc"})
+	}
+	return keys, nil
+}
+
+// Sort sorts the rows of rng, e.g. "Data!A2:F" to leave a header in row 1
+// or a named range, on the server by keys in order of precedence, without reading and
+// rewriting the values.
+func (c *Client) Sort(ctx context.Context, spreadsheetID, rng string, keys ...SortKey) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("sheetsclient: no sort keys for %s", rng)
+	}
+	g, err := c.gridRange(ctx, spreadsheetID, rng)
+	if err != nil {
+		return err
+	}
+	specs := make([]*sheets.SortSpec, len(keys))
+	for i, k := range keys {
+		var index int64
+		if n, err := strconv.Atoi(k.Column); err == nil && n > 0 {
+			index = g.StartColumnIndex + int64(n) - 1
+		} else if col, row, err := splitCell(k.Column); err == nil && col != "" && row == 0 {
+			index = int64(ColumnNumber(col) - 1)
+		} else {
+			return fmt.Errorf("sheetsclient: invalid sort column %q", k.Column)
+		}
+		if index < g.StartColumnIndex || (g.EndColumnIndex > 0 && index >= g.EndColumnIndex) {
+			return fmt.Errorf("sheetsclient: sort column %s is outside %s", k.Column, rng)
+		}
+		specs[i] = &sheets.SortSpec{DimensionIndex: index, SortOrder: "ASCENDING", ForceSendFields: []string{"DimensionIndex"}}
+		if k.Descending {
+			specs[i].SortOrder = "DESCENDING"
+		}
+	}
+	_, err = c.batch(ctx, spreadsheetID, &sheets.Request{SortRange: &sheets.SortRangeRequest{Range: g, SortSpecs: specs}})
+	if err != nil {
+		return fmt.Errorf("sheetsclient: unable to sort %s: %w", rng, Classify(err))
+	}
+	return nil
+}
+
+// dimension converts rng, whole rows such as "Data!3:5" or whole columns
+// such as "Data!C:D", or a named range of either, to a dimension range.
+func (c *Client) dimension(ctx context.Context, spreadsheetID, rng string) (*sheets.DimensionRange, error) {
+	resolved, err := c.ResolveRange(ctx, spreadsheetID, rng)
+	if err != nil {
+		return nil, err
+	}
+	if _, cells := splitTab(resolved); cells == "" {
+		return nil, fmt.Errorf("sheetsclient: %s names neither rows nor columns", rng)
+	}
+	g, err := c.gridRange(ctx, spreadsheetID, resolved)
+	if err != nil {
+		return nil, err
+	}
+	d := &sheets.DimensionRange{SheetId: g.SheetId, ForceSendFields: []string{"SheetId", "StartIndex"}}
+	switch {
+	case g.EndColumnIndex == 0 && g.EndRowIndex > 0:
+		d.Dimension, d.StartIndex, d.EndIndex = "ROWS", g.StartRowIndex, g.EndRowIndex
+	case g.EndRowIndex == 0 && g.StartRowIndex == 0 && g.EndColumnIndex > 0:
+		d.Dimension, d.StartIndex, d.EndIndex = "COLUMNS", g.StartColumnIndex, g.EndColumnIndex
+	default:
+		return nil, fmt.Errorf("sheetsclient: %s must be whole rows, e.g. 3:5, or whole columns, e.g. C:D", rng)
+	}
+	if d.EndIndex <= d.StartIndex {
+		return nil, fmt.Errorf("sheetsclient: %s is empty", rng)
+	}
+	return d, nil
+}
+
+// InsertDimension inserts empty rows or columns where rng is, e.g.
+// "Data!C:C" for a new column C or "Data!2:4" for three rows after the
+// header, moving the cells there down or right. The new cells take the
+// format of the row or column before them if inheritFromBefore is set,
+// else of the one after.
+func (c *Client) InsertDimension(ctx context.Context, spreadsheetID, rng string, inheritFromBefore bool) error {
+	d, err := c.dimension(ctx, spreadsheetID, rng)
+	if err != nil {
+		return err
+	}
+	if inheritFromBefore && d.StartIndex == 0 {
+		return fmt.Errorf("sheetsclient: nothing before %s to inherit from", rng)
+	}
+	_, err = c.batch(ctx, spreadsheetID, &sheets.Request{InsertDimension: &sheets.InsertDimensionRequest{
+		Range:             d,
+		InheritFromBefore: inheritFromBefore,
+	}})
+	if err != nil {
+		return fmt.Errorf("sheetsclient: unable to insert %s: %w", rng, Classify(err))
+	}
+	return nil
+}
+
+// DeleteDimension deletes the rows or columns of rng, e.g. "Data!C:D" or
+// "Data!10:12", moving the cells after them up or left.
+func (c *Client) DeleteDimension(ctx context.Context, spreadsheetID, rng string) error {
+	d, err := c.dimension(ctx, spreadsheetID, rng)
+	if err != nil {
+		return err
+	}
+	_, err = c.batch(ctx, spreadsheetID, &sheets.Request{DeleteDimension: &sheets.DeleteDimensionRequest{Range: d}})
+	if err != nil {
+		return fmt.Errorf("sheetsclient: unable to delete %s: %w", rng, Classify(err))
+	}
+	return nil
+}
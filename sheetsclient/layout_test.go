@@ -0,0 +1,46 @@
+package sheetsclient
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetstest"
+)
+
+func TestDimensionNamedRange(t *testing.T) {
+	ctx := context.Background()
+	srv := sheetstest.NewServer()
+	defer srv.Close()
+	srv.Seed(&sheetstest.Spreadsheet{
+		ID: "s",
+		Tabs: []*sheetstest.Tab{
+			{Title: "Other"},
+			{Title: "Data", Rows: [][]interface{}{{"h"}, {"a"}, {"b"}, {"c"}}},
+		},
+		NamedRanges: map[string]string{"Middle": "Data!2:3"},
+	})
+	svc, err := srv.SheetsService(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := FromService(svc)
+
+	d, err := c.dimension(ctx, "s", "Middle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.SheetId != 1 || d.Dimension != "ROWS" || d.StartIndex != 1 || d.EndIndex != 3 {
+		t.Errorf("dimension(Middle) = %+v, want rows 1-3 of sheet 1", d)
+	}
+	if err := c.DeleteDimension(ctx, "s", "Middle"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := srv.Values("s", "Data"), [][]interface{}{{"h"}, {"c"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after deleting Middle, Data = %v, want %v", got, want)
+	}
+	if _, err := c.dimension(ctx, "s", "Data"); err == nil {
+		t.Error("dimension of a whole tab succeeded")
+	}
+}
@@ -0,0 +1,122 @@
+package sheetsclient
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Limiter paces requests with two token buckets, one for reads (GET
+// requests) and one for writes, so that batch jobs stay under the per user
+// quotas of the Sheets API, by default 60 reads and 60 writes a minute,
+// instead of running into 429 errors. A Limiter may be shared by clients
+// used from many goroutines.
+type Limiter struct {
+	// ReadsPerMinute and WritesPerMinute are the sustained rates;
+	// they default to 60 and a negative rate disables the bucket.
+	ReadsPerMinute, WritesPerMinute int
+	// Burst is the number of requests of each kind that may be sent at
+	// once after a quiet period; defaults to 10.
+	Burst int
+
+	once          sync.Once
+	reads, writes *bucket
+}
+
+// WithLimit returns a copy of client pacing its requests with l, or with
+// a new Limiter using the default rates if l is nil. Use the same Limiter
+// for every client acting as the same user.
+func WithLimit(client *http.Client, l *Limiter) *http.Client {
+	if l == nil {
+		l = &Limiter{}
+	}
+	c := *client
+	c.Transport = &limitTransport{l, client.Transport}
+	return &c
+}
+
+// Wait blocks until a read, or a write, may be sent or ctx is done.
+func (l *Limiter) Wait(ctx context.Context, write bool) error {
+	l.once.Do(func() {
+		burst := l.Burst
+		if burst <= 0 {
+			burst = 10
+		}
+		l.reads = newBucket(l.ReadsPerMinute, burst)
+		l.writes = newBucket(l.WritesPerMinute, burst)
+	})
+	if write {
+		return l.writes.wait(ctx)
+	}
+	return l.reads.wait(ctx)
+}
+
+type limitTransport struct {
+	limiter *Limiter
+	base    http.RoundTripper
+}
+
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	write := req.Method != http.MethodGet && req.Method != http.MethodHead
+	if err := t.limiter.Wait(req.Context(), write); err != nil {
+		return nil, err
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+// bucket is a token bucket refilled at a constant rate. A nil bucket
+// never waits.
+type bucket struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(perMinute, burst int) *bucket {
+	if perMinute < 0 {
+		return nil
+	}
+	if perMinute == 0 {
+		perMinute = 60
+	}
+	return &bucket{rate: float64(perMinute) / 60, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait takes a token, sleeping until one is available or ctx is done.
+func (b *bucket) wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	// Take the token now, possibly going into debt, so that concurrent
+	// callers queue up behind each other.
+	b.tokens--
+	delay := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
@@ -0,0 +1,111 @@
+package sheetsclient
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// tokenFromLoopback authorizes through the browser: it listens on a random
+// local port, sends the user to the consent page with that port as the
+// redirect URI, and exchanges the code the browser comes back with.
+// Redirects without the state of this flow are refused and the flow keeps
+// waiting, so another local page cannot end or hijack it.
+func (o *OAuth) tokenFromLoopback(ctx context.Context, config *oauth2.Config) (*oauth2.Token, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to listen for the OAuth redirect: %w", err)
+	}
+	defer l.Close()
+	c := *config
+	c.RedirectURL = fmt.Sprintf("http://127.0.0.1:%d/callback", l.Addr().(*net.TCPAddr).Port)
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	state := hex.EncodeToString(b)
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/callback" {
+			http.NotFound(w, r)
+			return
+		}
+		q := r.URL.Query()
+		if q.Get("state") != state {
+			http.Error(w, "OAuth redirect has the wrong state", http.StatusBadRequest)
+			return
+		}
+		var res result
+		switch {
+		case q.Get("error") != "":
+			res.err = fmt.Errorf("sheetsclient: authorization failed: %s", q.Get("error"))
+		default:
+			res.code = q.Get("code")
+		}
+		if res.err != nil {
+			http.Error(w, res.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Authorization complete, you can close this window.")
+		}
+		select {
+		case results <- res:
+		default:
+		}
+	})}
+	go srv.Serve(l)
+	defer func() {
+		// Let the browser get its page before closing.
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	authURL := c.AuthCodeURL(state, oauth2.AccessTypeOffline)
+	if err := openBrowser(authURL); err != nil {
+		fmt.Fprintf(o.out(), "Go to the following link in your browser to authorize access: \n%v\n", authURL)
+	} else {
+		fmt.Fprintf(o.out(), "Your browser has been opened to authorize access. If it did not open, go to: \n%v\n", authURL)
+	}
+
+	var res result
+	select {
+	case res = <-results:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+	tok, err := c.Exchange(ctx, res.code)
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to retrieve token from web: %w", err)
+	}
+	return tok, nil
+}
+
+// openBrowser opens url with the desktop's default browser. It is a
+// variable for tests.
+var openBrowser = func(url string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	default:
+		return exec.Command("xdg-open", url).Start()
+	}
+}
@@ -0,0 +1,69 @@
+package sheetsclient
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+func TestTokenFromLoopback(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.URL.Path != "/token" || r.Form.Get("code") != "good-code" || !strings.HasPrefix(r.Form.Get("redirect_uri"), "http://127.0.0.1:") {
+			http.Error(w, "bad token request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "tok", "token_type": "Bearer"})
+	}))
+	defer ts.Close()
+	config := &oauth2.Config{
+		ClientID: "id",
+		Endpoint: oauth2.Endpoint{AuthURL: ts.URL + "/auth", TokenURL: ts.URL + "/token"},
+	}
+
+	// The browser first comes back with a forged state, then the real one.
+	statuses := make(chan []int, 1)
+	defer func(f func(string) error) { openBrowser = f }(openBrowser)
+	openBrowser = func(authURL string) error {
+		u, err := url.Parse(authURL)
+		if err != nil {
+			return err
+		}
+		redirect, state := u.Query().Get("redirect_uri"), u.Query().Get("state")
+		go func() {
+			var got []int
+			for _, q := range []string{"state=forged&code=evil", "state=" + state + "&code=good-code"} {
+				resp, err := http.Get(redirect + "?" + q)
+				if err != nil {
+					got = append(got, 0)
+					continue
+				}
+				ioutil.ReadAll(resp.Body)
+				resp.Body.Close()
+				got = append(got, resp.StatusCode)
+			}
+			statuses <- got
+		}()
+		return nil
+	}
+
+	o := &OAuth{Out: ioutil.Discard}
+	tok, err := o.tokenFromLoopback(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "tok" {
+		t.Errorf("token = %+v", tok)
+	}
+	if got := <-statuses; len(got) != 2 || got[0] != http.StatusBadRequest || got[1] != http.StatusOK {
+		t.Errorf("callback statuses = %v, want [400 200]", got)
+	}
+}
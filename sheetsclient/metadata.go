@@ -0,0 +1,203 @@
+package sheetsclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+)
+
+// Metadata is a key and value attached to a spreadsheet, a tab, or whole
+// rows or columns. Metadata on rows and columns moves with them when
+// they are moved, sorted or have rows inserted above, so it can tag rows
+// with IDs that outlive their position.
+type Metadata struct {
+	ID    int64
+	Key   string
+	Value string
+	// Location is empty for the spreadsheet, a tab name for a tab, or
+	// whole rows or columns such as "'Data'!5:5" or "'Data'!C:C".
+	Location string
+}
+
+// metadataLocation converts a Metadata location to its API form.
+func (c *Client) metadataLocation(ctx context.Context, spreadsheetID, loc string) (*sheets.DeveloperMetadataLocation, error) {
+	if loc == "" {
+		return &sheets.DeveloperMetadataLocation{Spreadsheet: true}, nil
+	}
+	if _, cells := splitTab(loc); cells == "" {
+		t, err := c.Tab(ctx, spreadsheetID, strings.TrimSuffix(loc, "!"))
+		if err != nil {
+			return nil, err
+		}
+		return &sheets.DeveloperMetadataLocation{SheetId: t.ID, ForceSendFields: []string{"SheetId"}}, nil
+	}
+	d, err := c.dimension(ctx, spreadsheetID, loc)
+	if err != nil {
+		return nil, err
+	}
+	return &sheets.DeveloperMetadataLocation{DimensionRange: d}, nil
+}
+
+// newMetadata converts developer metadata, naming tabs by titles.
+func newMetadata(m *sheets.DeveloperMetadata, titles map[int64]string) *Metadata {
+	md := &Metadata{ID: m.MetadataId, Key: m.MetadataKey, Value: m.MetadataValue}
+	switch loc := m.Location; {
+	case loc == nil || loc.Spreadsheet:
+	case loc.DimensionRange != nil:
+		d := loc.DimensionRange
+		from, to := strconv.FormatInt(d.StartIndex+1, 10), strconv.FormatInt(d.EndIndex, 10)
+		if d.Dimension == "COLUMNS" {
+			from, to = ColumnLetters(int(d.StartIndex+1)), ColumnLetters(int(d.EndIndex))
+		}
+		md.Location = QuoteTab(titles[d.SheetId]) + "!" + from + ":" + to
+	default:
+		md.Location = titles[loc.SheetId]
+	}
+	return md
+}
+
+// AddMetadata attaches key and value to loc, see Metadata.Location; loc
+// is a range such as "Data!5:5" or "Data!C:C" for rows and columns.
+func (c *Client) AddMetadata(ctx context.Context, spreadsheetID, loc, key, value string) (*Metadata, error) {
+	l, err := c.metadataLocation(ctx, spreadsheetID, loc)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.batch(ctx, spreadsheetID, &sheets.Request{CreateDeveloperMetadata: &sheets.CreateDeveloperMetadataRequest{
+		DeveloperMetadata: &sheets.DeveloperMetadata{
+			MetadataKey:   key,
+			MetadataValue: value,
+			Visibility:    "DOCUMENT",
+			Location:      l,
+		},
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to add metadata %q: %w", key, Classify(err))
+	}
+	titles, err := c.titles(ctx, spreadsheetID)
+	if err != nil {
+		return nil, err
+	}
+	return newMetadata(resp.Replies[0].CreateDeveloperMetadata.DeveloperMetadata, titles), nil
+}
+
+// metadataFilter returns the data filter matching metadata with key, or any key
+// if empty, at or within loc, or anywhere if loc is empty.
+func (c *Client) metadataFilter(ctx context.Context, spreadsheetID, key, loc string) (*sheets.DataFilter, error) {
+	if key == "" && loc == "" {
+		return nil, fmt.Errorf("sheetsclient: metadata lookup needs a key or a location")
+	}
+	lookup := &sheets.DeveloperMetadataLookup{MetadataKey: key}
+	if loc != "" {
+		l, err := c.metadataLocation(ctx, spreadsheetID, loc)
+		if err != nil {
+			return nil, err
+		}
+		lookup.MetadataLocation = l
+		lookup.LocationMatchingStrategy = "INTERSECTING_LOCATION"
+	}
+	return &sheets.DataFilter{DeveloperMetadataLookup: lookup}, nil
+}
+
+// FindMetadata returns the metadata with key, or any key if empty,
+// attached at or within loc, e.g. the rows of a tab, or anywhere if loc is
+// empty.
+func (c *Client) FindMetadata(ctx context.Context, spreadsheetID, key, loc string) ([]*Metadata, error) {
+	f, err := c.metadataFilter(ctx, spreadsheetID, key, loc)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.Sheets.Spreadsheets.DeveloperMetadata.Search(spreadsheetID, &sheets.SearchDeveloperMetadataRequest{
+		DataFilters: []*sheets.DataFilter{f},
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to search metadata: %w", Classify(err))
+	}
+	if len(resp.MatchedDeveloperMetadata) == 0 {
+		return nil, nil
+	}
+	titles, err := c.titles(ctx, spreadsheetID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*Metadata, len(resp.MatchedDeveloperMetadata))
+	for i, m := range resp.MatchedDeveloperMetadata {
+		out[i] = newMetadata(m.DeveloperMetadata, titles)
+	}
+	return out, nil
+}
+
+// MetadataRows returns the current row number, starting at 1, of each
+// row of tab tagged with key, by the tag's value.
+func (c *Client) MetadataRows(ctx context.Context, spreadsheetID, tab, key string) (map[string]int, error) {
+	t, err := c.Tab(ctx, spreadsheetID, tab)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.Sheets.Spreadsheets.DeveloperMetadata.Search(spreadsheetID, &sheets.SearchDeveloperMetadataRequest{
+		DataFilters: []*sheets.DataFilter{{DeveloperMetadataLookup: &sheets.DeveloperMetadataLookup{
+			MetadataKey:  key,
+			LocationType: "ROW",
+		}}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to search metadata: %w", Classify(err))
+	}
+	rows := map[string]int{}
+	for _, m := range resp.MatchedDeveloperMetadata {
+		loc := m.DeveloperMetadata.Location
+		if loc == nil || loc.DimensionRange == nil || loc.DimensionRange.SheetId != t.ID {
+			continue
+		}
+		rows[m.DeveloperMetadata.MetadataValue] = int(loc.DimensionRange.StartIndex) + 1
+	}
+	return rows, nil
+}
+
+// DeleteMetadata deletes the metadata FindMetadata would return for key
+// and loc, and returns how many were deleted.
+func (c *Client) DeleteMetadata(ctx context.Context, spreadsheetID, key, loc string) (int, error) {
+	f, err := c.metadataFilter(ctx, spreadsheetID, key, loc)
+	if err != nil {
+		return 0, err
+	}
+	return c.deleteMetadata(ctx, spreadsheetID, f)
+}
+
+// DeleteMetadataID deletes the metadata with the given ID.
+func (c *Client) DeleteMetadataID(ctx context.Context, spreadsheetID string, id int64) error {
+	n, err := c.deleteMetadata(ctx, spreadsheetID, &sheets.DataFilter{
+		DeveloperMetadataLookup: &sheets.DeveloperMetadataLookup{MetadataId: id},
+	})
+	if err == nil && n == 0 {
+		err = fmt.Errorf("sheetsclient: no metadata with ID %d: %w", id, ErrRangeNotFound)
+	}
+	return err
+}
+
+func (c *Client) deleteMetadata(ctx context.Context, spreadsheetID string, f *sheets.DataFilter) (int, error) {
+	resp, err := c.batch(ctx, spreadsheetID, &sheets.Request{DeleteDeveloperMetadata: &sheets.DeleteDeveloperMetadataRequest{DataFilter: f}})
+	if err != nil {
+		return 0, fmt.Errorf("sheetsclient: unable to delete metadata: %w", Classify(err))
+	}
+	if r := resp.Replies[0].DeleteDeveloperMetadata; r != nil {
+		return len(r.DeletedDeveloperMetadata), nil
+	}
+	return 0, nil
+}
+
+// titles returns the tab titles of a spreadsheet by tab ID.
+func (c *Client) titles(ctx context.Context, spreadsheetID string) (map[int64]string, error) {
+	tabs, err := c.Tabs(ctx, spreadsheetID)
+	if err != nil {
+		return nil, err
+	}
+	titles := make(map[int64]string, len(tabs))
+	for _, t := range tabs {
+		titles[t.ID] = t.Title
+	}
+	return titles, nil
+}
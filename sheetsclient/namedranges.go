@@ -0,0 +1,157 @@
+package sheetsclient
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+)
+
+// NamedRange is a name for a range of a spreadsheet. The value methods of
+// Client accept names wherever they take an A1 range.
+type NamedRange struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Range is the range in A1 notation, e.g. "'Data'!A1:F20".
+	Range string `json:"range"`
+}
+
+// NamedRanges lists the named ranges of a spreadsheet.
+func (c *Client) NamedRanges(ctx context.Context, spreadsheetID string) ([]*NamedRange, error) {
+	resp, err := c.Sheets.Spreadsheets.Get(spreadsheetID).
+		Fields("sheets.properties(sheetId,title),namedRanges").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to get named ranges: %w", Classify(err))
+	}
+	names := map[int64]string{}
+	for _, s := range resp.Sheets {
+		names[s.Properties.SheetId] = s.Properties.Title
+	}
+	out := make([]*NamedRange, len(resp.NamedRanges))
+	for i, nr := range resp.NamedRanges {
+		out[i] = &NamedRange{ID: nr.NamedRangeId, Name: nr.Name, Range: FormatRange(names[nr.Range.SheetId], nr.Range)}
+	}
+	return out, nil
+}
+
+// NamedRange returns the named range called name.
+func (c *Client) NamedRange(ctx context.Context, spreadsheetID, name string) (*NamedRange, error) {
+	all, err := c.NamedRanges(ctx, spreadsheetID)
+	if err != nil {
+		return nil, err
+	}
+	for _, nr := range all {
+		if nr.Name == name {
+			return nr, nil
+		}
+	}
+	return nil, fmt.Errorf("sheetsclient: no named range %q: %w", name, ErrRangeNotFound)
+}
+
+// AddNamedRange names rng, e.g. "Data!A1:F", and returns the new named
+// range. Names must start with a letter or underscore and must not look
+// like A1 ranges.
+func (c *Client) AddNamedRange(ctx context.Context, spreadsheetID, name, rng string) (*NamedRange, error) {
+	g, err := c.gridRange(ctx, spreadsheetID, rng)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.batch(ctx, spreadsheetID, &sheets.Request{AddNamedRange: &sheets.AddNamedRangeRequest{
+		NamedRange: &sheets.NamedRange{Name: name, Range: g},
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to add named range %q: %w", name, Classify(err))
+	}
+	nr := resp.Replies[0].AddNamedRange.NamedRange
+	tab, _ := splitTab(rng)
+	return &NamedRange{ID: nr.NamedRangeId, Name: nr.Name, Range: FormatRange(tab, nr.Range)}, nil
+}
+
+// DeleteNamedRange removes the name; the cells it referred to are kept.
+// Formulas using the name break.
+func (c *Client) DeleteNamedRange(ctx context.Context, spreadsheetID, name string) error {
+	nr, err := c.NamedRange(ctx, spreadsheetID, name)
+	if err != nil {
+		return err
+	}
+	_, err = c.batch(ctx, spreadsheetID, &sheets.Request{DeleteNamedRange: &sheets.DeleteNamedRangeRequest{NamedRangeId: nr.ID}})
+	if err != nil {
+		return fmt.Errorf("sheetsclient: unable to delete named range %q: %w", name, Classify(err))
+	}
+	return nil
+}
+
+// ResolveRange returns the A1 range a named range refers to, or rng
+// itself if it is not the name of one, for code that needs to know the
+// tab and cells of a range.
+func (c *Client) ResolveRange(ctx context.Context, spreadsheetID, rng string) (string, error) {
+	if strings.ContainsAny(rng, "!'") {
+		return rng, nil
+	}
+	all, err := c.NamedRanges(ctx, spreadsheetID)
+	if err != nil {
+		return "", err
+	}
+	for _, nr := range all {
+		if nr.Name == rng {
+			return nr.Range, nil
+		}
+	}
+	return rng, nil
+}
+
+// Tabs maps the tabs and named ranges of a spreadsheet, so that packages
+// working on a sheets.Service can convert ranges to grid ranges without a
+// request for each.
+type Tabs struct {
+	IDs    map[string]int64
+	Titles map[int64]string
+	Named  map[string]*sheets.GridRange
+}
+
+// LoadTabs gets the tabs and named ranges of a spreadsheet.
+func LoadTabs(ctx context.Context, srv *sheets.Service, spreadsheetID string) (*Tabs, error) {
+	ss, err := srv.Spreadsheets.Get(spreadsheetID).
+		Fields("sheets.properties(sheetId,title),namedRanges").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to get tabs: %w", Classify(err))
+	}
+	return NewTabs(ss), nil
+}
+
+// NewTabs maps the tabs and named ranges of ss, fetched with at least the
+// fields sheets.properties(sheetId,title) and namedRanges.
+func NewTabs(ss *sheets.Spreadsheet) *Tabs {
+	t := &Tabs{IDs: map[string]int64{}, Titles: map[int64]string{}, Named: map[string]*sheets.GridRange{}}
+	for _, sh := range ss.Sheets {
+		t.IDs[sh.Properties.Title] = sh.Properties.SheetId
+		t.Titles[sh.Properties.SheetId] = sh.Properties.Title
+	}
+	for _, nr := range ss.NamedRanges {
+		t.Named[nr.Name] = nr.Range
+	}
+	return t
+}
+
+// GridRange converts rng, an A1 range such as "Data!A2:F", a bare tab name
+// or the name of a named range, to a grid range. As in ResolveRange, a
+// named range wins over a tab of the same name.
+func (t *Tabs) GridRange(rng string) (*sheets.GridRange, error) {
+	if g, ok := t.Named[rng]; ok && !strings.ContainsAny(rng, "!'") {
+		c := *g
+		c.ForceSendFields = []string{"SheetId", "StartRowIndex", "StartColumnIndex"}
+		return &c, nil
+	}
+	tab, cells := splitTab(rng)
+	id, ok := t.IDs[tab]
+	if !ok {
+		return nil, fmt.Errorf("sheetsclient: no tab named %q: %w", tab, ErrRangeNotFound)
+	}
+	return GridRange(id, cells)
+}
+
+// A1 converts g, a grid range on one of the tabs, to A1 notation.
+func (t *Tabs) A1(g *sheets.GridRange) string {
+	return FormatRange(t.Titles[g.SheetId], g)
+}
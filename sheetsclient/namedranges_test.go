@@ -0,0 +1,45 @@
+package sheetsclient
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+func TestTabsGridRange(t *testing.T) {
+	tabs := NewTabs(&sheets.Spreadsheet{
+		Sheets: []*sheets.Sheet{
+			{Properties: &sheets.SheetProperties{SheetId: 0, Title: "Data"}},
+			{Properties: &sheets.SheetProperties{SheetId: 9, Title: "Totals"}},
+		},
+		NamedRanges: []*sheets.NamedRange{
+			{Name: "Prices", Range: &sheets.GridRange{SheetId: 9, StartRowIndex: 1, EndRowIndex: 20, StartColumnIndex: 2, EndColumnIndex: 3}},
+			{Name: "Data", Range: &sheets.GridRange{SheetId: 9, EndRowIndex: 1}},
+		},
+	})
+	tests := []struct {
+		rng  string
+		want string
+	}{
+		{"Data!B2:C", "'Data'!B2:C"},
+		{"Totals", "'Totals'"},
+		{"Prices", "'Totals'!C2:C20"},
+		// A named range wins over a tab of the same name, unless quoted.
+		{"Data", "'Totals'!1:1"},
+		{"'Data'", "'Data'"},
+	}
+	for _, tt := range tests {
+		g, err := tabs.GridRange(tt.rng)
+		if err != nil {
+			t.Errorf("GridRange(%q): %v", tt.rng, err)
+			continue
+		}
+		if got := tabs.A1(g); got != tt.want {
+			t.Errorf("GridRange(%q) = %s, want %s", tt.rng, got, tt.want)
+		}
+	}
+	if _, err := tabs.GridRange("Missing!A1"); !errors.Is(err, ErrRangeNotFound) {
+		t.Errorf("GridRange of a missing tab: %v, want ErrRangeNotFound", err)
+	}
+}
@@ -0,0 +1,81 @@
+package sheetsclient
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+)
+
+// Notes returns the notes of the cells of rng, or of a named range, row by
+// row from its top left cell like ReadRange; cells without a note are
+// empty strings.
+func (c *Client) Notes(ctx context.Context, spreadsheetID, rng string) ([][]string, error) {
+	resp, err := c.Sheets.Spreadsheets.Get(spreadsheetID).Ranges(rng).
+		Fields("sheets(data(rowData(values(note))))").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to read notes of %s: %w", rng, Classify(err))
+	}
+	var notes [][]string
+	for _, sh := range resp.Sheets {
+		for _, d := range sh.Data {
+			for _, r := range d.RowData {
+				row := make([]string, len(r.Values))
+				for i, v := range r.Values {
+					row[i] = v.Note
+				}
+				notes = append(notes, row)
+			}
+		}
+	}
+	return notes, nil
+}
+
+// SetNotes sets the notes of the cells from the top left cell of rng, an
+// A1 or named range, one row of notes per row of cells; an empty string
+// removes a cell's note. Values and formats are left as they are.
+func (c *Client) SetNotes(ctx context.Context, spreadsheetID, rng string, notes [][]string) error {
+	g, err := c.gridRange(ctx, spreadsheetID, rng)
+	if err != nil {
+		return err
+	}
+	rows := make([]*sheets.RowData, len(notes))
+	for i, r := range notes {
+		rows[i] = &sheets.RowData{Values: make([]*sheets.CellData, len(r))}
+		for j, note := range r {
+			rows[i].Values[j] = &sheets.CellData{Note: note}
+		}
+	}
+	_, err = c.batch(ctx, spreadsheetID, &sheets.Request{UpdateCells: &sheets.UpdateCellsRequest{
+		Start: &sheets.GridCoordinate{
+			SheetId:         g.SheetId,
+			RowIndex:        g.StartRowIndex,
+			ColumnIndex:     g.StartColumnIndex,
+			ForceSendFields: []string{"SheetId", "RowIndex", "ColumnIndex"},
+		},
+		Rows:   rows,
+		Fields: "note",
+	}})
+	if err != nil {
+		return fmt.Errorf("sheetsclient: unable to set notes of %s: %w", rng, Classify(err))
+	}
+	return nil
+}
+
+// SetNote sets the note of the top left cell of rng, e.g. "Data!A2".
+func (c *Client) SetNote(ctx context.Context, spreadsheetID, rng, note string) error {
+	return c.SetNotes(ctx, spreadsheetID, rng, [][]string{{note}})
+}
+
+// ClearNotes removes the notes of every cell of rng, an A1 or named range.
+func (c *Client) ClearNotes(ctx context.Context, spreadsheetID, rng string) error {
+	g, err := c.gridRange(ctx, spreadsheetID, rng)
+	if err != nil {
+		return err
+	}
+	_, err = c.batch(ctx, spreadsheetID, &sheets.Request{UpdateCells: &sheets.UpdateCellsRequest{Range: g, Fields: "note"}})
+	if err != nil {
+		return fmt.Errorf("sheetsclient: unable to clear notes of %s: %w", rng, Classify(err))
+	}
+	return nil
+}
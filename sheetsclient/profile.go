@@ -0,0 +1,174 @@
+package sheetsclient
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Profile is a named set of credentials kept in its own directory, like a
+// gcloud configuration, so that one machine can act for several accounts,
+// e.g. "work" and "personal":
+//
+//	~/.credentials/profiles/work/client_secret.json
+//	~/.credentials/profiles/work/token.json
+//	~/.credentials/profiles/work/service_account.json (optional)
+type Profile struct {
+	Name string
+	Dir  string
+}
+
+var profileName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// ProfilesDir returns ~/.credentials/profiles.
+func ProfilesDir() (string, error) {
+	usr, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(usr.HomeDir, ".credentials", "profiles"), nil
+}
+
+// OpenProfile returns the profile called name, which need not exist yet.
+func OpenProfile(name string) (*Profile, error) {
+	if !profileName.MatchString(name) {
+		return nil, fmt.Errorf("sheetsclient: invalid profile name %q", name)
+	}
+	dir, err := ProfilesDir()
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to find profiles: %w", err)
+	}
+	return &Profile{Name: name, Dir: filepath.Join(dir, name)}, nil
+}
+
+// Profiles returns the names of the existing profiles, sorted.
+func Profiles() ([]string, error) {
+	dir, err := ProfilesDir()
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to find profiles: %w", err)
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to list profiles: %w", err)
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && profileName.MatchString(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ActiveProfile returns the profile chosen with SetActiveProfile, or ""
+// if there is none.
+func ActiveProfile() (string, error) {
+	dir, err := ProfilesDir()
+	if err != nil {
+		return "", fmt.Errorf("sheetsclient: unable to find profiles: %w", err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "active"))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("sheetsclient: unable to read active profile: %w", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// SetActiveProfile makes name the profile used when none is given; an
+// empty name goes back to the default credentials.
+func SetActiveProfile(name string) error {
+	dir, err := ProfilesDir()
+	if err != nil {
+		return fmt.Errorf("sheetsclient: unable to find profiles: %w", err)
+	}
+	file := filepath.Join(dir, "active")
+	if name == "" {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("sheetsclient: unable to reset active profile: %w", err)
+		}
+		return nil
+	}
+	if _, err := OpenProfile(name); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("sheetsclient: unable to create %s: %w", dir, err)
+	}
+	if err := ioutil.WriteFile(file, []byte(name+"\n"), 0600); err != nil {
+		return fmt.Errorf("sheetsclient: unable to set active profile: %w", err)
+	}
+	return nil
+}
+
+// ClientSecretFile returns the path of the profile's OAuth client secret.
+func (p *Profile) ClientSecretFile() string { return filepath.Join(p.Dir, "client_secret.json") }
+
+// TokenFile returns the path of the profile's cached OAuth token.
+func (p *Profile) TokenFile() string { return filepath.Join(p.Dir, "token.json") }
+
+// KeyFile returns the path of the profile's service account key.
+func (p *Profile) KeyFile() string { return filepath.Join(p.Dir, "service_account.json") }
+
+// Exists reports whether the profile has been created.
+func (p *Profile) Exists() bool {
+	fi, err := os.Stat(p.Dir)
+	return err == nil && fi.IsDir()
+}
+
+// Create makes the profile's directory and copies the given client secret
+// and service account key into it; either may be empty.
+func (p *Profile) Create(clientSecretFile, keyFile string) error {
+	if err := os.MkdirAll(p.Dir, 0700); err != nil {
+		return fmt.Errorf("sheetsclient: unable to create profile %s: %w", p.Name, err)
+	}
+	for src, dst := range map[string]string{clientSecretFile: p.ClientSecretFile(), keyFile: p.KeyFile()} {
+		if src == "" {
+			continue
+		}
+		b, err := ioutil.ReadFile(src)
+		if err != nil {
+			return fmt.Errorf("sheetsclient: unable to read %s: %w", src, err)
+		}
+		if err := ioutil.WriteFile(dst, b, 0600); err != nil {
+			return fmt.Errorf("sheetsclient: unable to write %s: %w", dst, err)
+		}
+	}
+	return nil
+}
+
+// Delete removes the profile and its credentials.
+func (p *Profile) Delete() error {
+	if err := os.RemoveAll(p.Dir); err != nil {
+		return fmt.Errorf("sheetsclient: unable to delete profile %s: %w", p.Name, err)
+	}
+	if active, _ := ActiveProfile(); active == p.Name {
+		return SetActiveProfile("")
+	}
+	return nil
+}
+
+// Chain returns the credential chain of the profile: its service account
+// key if it has one, else the OAuth flow with its client secret and token
+// cache. Application Default Credentials are not used, so the profile's
+// account is the one acting.
+func (p *Profile) Chain(scopes []string) *Chain {
+	c := &Chain{Scopes: scopes, NoDefault: true}
+	if _, err := os.Stat(p.KeyFile()); err == nil {
+		c.KeyFile = p.KeyFile()
+	}
+	c.OAuth.ClientSecretFile = p.ClientSecretFile()
+	c.OAuth.TokenFile = p.TokenFile()
+	return c
+}
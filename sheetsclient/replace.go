@@ -0,0 +1,94 @@
+package sheetsclient
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+)
+
+// ReplaceOptions says how Replace matches the text to find.
+type ReplaceOptions struct {
+	// Regexp takes the text as a regular expression; the replacement
+	// may refer to its groups as $1, $2 and so on.
+	Regexp bool
+	// MatchCase matches letter case exactly.
+	MatchCase bool
+	// MatchEntireCell only matches cells whose whole value is the text.
+	MatchEntireCell bool
+	// IncludeFormulas also searches formulas instead of only values.
+	IncludeFormulas bool
+}
+
+// Replaced counts the changes made by Replace.
+type Replaced struct {
+	Occurrences int64
+	Values      int64
+	Formulas    int64
+	Rows        int64
+	Tabs        int64
+}
+
+// Replace replaces find with replacement in scope, an A1 range, a tab
+// name or a named range, or every tab if scope is empty. opts may be nil
+// for a case-insensitive search of values.
+func (c *Client) Replace(ctx context.Context, spreadsheetID, scope, find, replacement string, opts *ReplaceOptions) (*Replaced, error) {
+	if find == "" {
+		return nil, fmt.Errorf("sheetsclient: nothing to find")
+	}
+	if opts == nil {
+		opts = &ReplaceOptions{}
+	}
+	req := &sheets.FindReplaceRequest{
+		Find:            find,
+		Replacement:     replacement,
+		SearchByRegex:   opts.Regexp,
+		MatchCase:       opts.MatchCase,
+		MatchEntireCell: opts.MatchEntireCell,
+		IncludeFormulas: opts.IncludeFormulas,
+		ForceSendFields: []string{"Replacement"},
+	}
+	switch {
+	case scope == "":
+		req.AllSheets = true
+	case !strings.ContainsAny(scope, "!'"):
+		// A tab, or else a named range.
+		t, err := c.Tab(ctx, spreadsheetID, scope)
+		if err == nil {
+			req.SheetId, req.ForceSendFields = t.ID, append(req.ForceSendFields, "SheetId")
+			break
+		}
+		rng, rerr := c.ResolveRange(ctx, spreadsheetID, scope)
+		if rerr != nil {
+			return nil, rerr
+		}
+		if rng == scope {
+			return nil, err
+		}
+		if req.Range, err = c.gridRange(ctx, spreadsheetID, rng); err != nil {
+			return nil, err
+		}
+	default:
+		g, err := c.gridRange(ctx, spreadsheetID, scope)
+		if err != nil {
+			return nil, err
+		}
+		req.Range = g
+	}
+	resp, err := c.batch(ctx, spreadsheetID, &sheets.Request{FindReplace: req})
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to replace %q: %w", find, Classify(err))
+	}
+	r := resp.Replies[0].FindReplace
+	if r == nil {
+		return &Replaced{}, nil
+	}
+	return &Replaced{
+		Occurrences: r.OccurrencesChanged,
+		Values:      r.ValuesChanged,
+		Formulas:    r.FormulasChanged,
+		Rows:        r.RowsChanged,
+		Tabs:        r.SheetsChanged,
+	}, nil
+}
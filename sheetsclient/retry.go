@@ -0,0 +1,128 @@
+package sheetsclient
+
+import (
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Retry is an http.RoundTripper that retries requests rejected with 429
+// Too Many Requests or a transient 5xx status, waiting with jittered
+// exponential backoff between attempts, or as long as the server asks with
+// Retry-After. Requests whose body cannot be replayed are sent once.
+//
+// POST requests, such as values:append and batchUpdate, are not
+// idempotent: a 500, 502 or 504 may come after the change was applied, so
+// they are only retried on 429 and 503, which reject a request before it
+// runs.
+type Retry struct {
+	// Transport sends the requests; defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+	// MaxAttempts is the number of tries, the first included; defaults to
+	// 5. 1 disables retries.
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry, doubled for every
+	// later one up to MaxDelay; they default to 1 and 32 seconds.
+	BaseDelay, MaxDelay time.Duration
+}
+
+// WithRetry returns a copy of client retrying its requests with r, or
+// with the default policy if r is nil. Clients already retrying are
+// returned as they are.
+func WithRetry(client *http.Client, r *Retry) *http.Client {
+	if _, ok := client.Transport.(*Retry); ok {
+		return client
+	}
+	if r == nil {
+		r = &Retry{}
+	}
+	rt := *r
+	if rt.Transport == nil {
+		rt.Transport = client.Transport
+	}
+	c := *client
+	c.Transport = &rt
+	return &c
+}
+
+// retryable reports whether a request with method answered with status
+// code may succeed if sent again without applying it twice.
+func retryable(method string, code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return method != http.MethodPost && method != http.MethodPatch
+	}
+	return false
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Retry) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	attempts := r.MaxAttempts
+	if attempts <= 0 {
+		attempts = 5
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		attempts = 1
+	}
+	for n := 1; ; n++ {
+		attempt := req
+		if n > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attempt = req.Clone(req.Context())
+			attempt.Body = body
+		}
+		resp, err := transport.RoundTrip(attempt)
+		if err != nil || n >= attempts || !retryable(req.Method, resp.StatusCode) {
+			return resp, err
+		}
+		delay := r.delay(n, resp.Header.Get("Retry-After"))
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// delay returns the wait before retry n, honoring a Retry-After header
+// given in seconds or as a date.
+func (r *Retry) delay(n int, retryAfter string) time.Duration {
+	if s, err := strconv.Atoi(retryAfter); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second
+	}
+	if t, err := http.ParseTime(retryAfter); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+		return 0
+	}
+	base, max := r.BaseDelay, r.MaxDelay
+	if base <= 0 {
+		base = time.Second
+	}
+	if max <= 0 {
+		max = 32 * time.Second
+	}
+	d := base
+	for i := 1; i < n && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
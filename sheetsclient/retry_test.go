@@ -0,0 +1,111 @@
+package sheetsclient
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetstest"
+)
+
+func TestRetryDelay(t *testing.T) {
+	r := &Retry{BaseDelay: time.Second, MaxDelay: 8 * time.Second}
+	// Retry n waits a jittered half to whole of base * 2^(n-1), capped.
+	for n, full := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 8 * time.Second, 5: 8 * time.Second, 10: 8 * time.Second} {
+		for i := 0; i < 50; i++ {
+			if d := r.delay(n, ""); d < full/2 || d > full {
+				t.Fatalf("delay(%d) = %v, want between %v and %v", n, d, full/2, full)
+			}
+		}
+	}
+	if d := (&Retry{}).delay(1, ""); d < 500*time.Millisecond || d > time.Second {
+		t.Errorf("default first delay = %v, want 0.5s to 1s", d)
+	}
+	if d := (&Retry{}).delay(20, ""); d > 32*time.Second {
+		t.Errorf("default delay(20) = %v, want at most 32s", d)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	r := &Retry{}
+	if d := r.delay(1, "7"); d != 7*time.Second {
+		t.Errorf("Retry-After 7 gave %v", d)
+	}
+	if d := r.delay(3, "0"); d != 0 {
+		t.Errorf("Retry-After 0 gave %v", d)
+	}
+	at := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	if d := r.delay(1, at); d < 8*time.Second || d > 10*time.Second {
+		t.Errorf("Retry-After %s gave %v, want about 10s", at, d)
+	}
+	past := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	if d := r.delay(1, past); d != 0 {
+		t.Errorf("Retry-After in the past gave %v, want 0", d)
+	}
+	// Invalid values fall back to the backoff schedule.
+	for _, v := range []string{"-1", "soon"} {
+		if d := r.delay(1, v); d < 500*time.Millisecond || d > time.Second {
+			t.Errorf("Retry-After %q gave %v, want the backoff delay", v, d)
+		}
+	}
+}
+
+func TestRetryMethods(t *testing.T) {
+	ctx := context.Background()
+	srv := sheetstest.NewServer()
+	defer srv.Close()
+	srv.Seed(&sheetstest.Spreadsheet{ID: "s", Tabs: []*sheetstest.Tab{{Title: "Data", Rows: [][]interface{}{{"a"}}}}})
+	client := WithRetry(srv.Client(), &Retry{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	svc, err := sheets.NewService(ctx, option.WithEndpoint(srv.URL+"/"), option.WithHTTPClient(client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendRow := func() error {
+		_, err := svc.Spreadsheets.Values.Append("s", "Data", &sheets.ValueRange{Values: [][]interface{}{{"b"}}}).
+			ValueInputOption("RAW").Context(ctx).Do()
+		return err
+	}
+
+	tests := []struct {
+		op       string
+		code     int
+		call     func() error
+		attempts int
+		ok       bool
+	}{
+		{"values.get", 500, func() error { _, err := svc.Spreadsheets.Values.Get("s", "Data").Do(); return err }, 2, true},
+		{"values.get", 504, func() error { _, err := svc.Spreadsheets.Values.Get("s", "Data").Do(); return err }, 2, true},
+		{"values.append", 429, appendRow, 2, true},
+		{"values.append", 503, appendRow, 2, true},
+		// The append may have been applied; sending it again would add
+		// the row twice.
+		{"values.append", 500, appendRow, 1, false},
+		{"values.append", 502, appendRow, 1, false},
+		{"values.append", 504, appendRow, 1, false},
+		{"values.get", 404, func() error { _, err := svc.Spreadsheets.Values.Get("s", "Data").Do(); return err }, 1, false},
+	}
+	for _, tt := range tests {
+		srv.Reset()
+		srv.Fail(tt.op, tt.code, 1)
+		err := tt.call()
+		if (err == nil) != tt.ok {
+			t.Errorf("%s failing once with %d: err = %v, want success %v", tt.op, tt.code, err, tt.ok)
+		}
+		if n := srv.Count(tt.op); n != tt.attempts {
+			t.Errorf("%s failing once with %d was sent %d times, want %d", tt.op, tt.code, n, tt.attempts)
+		}
+	}
+
+	srv.Reset()
+	srv.Fail("values.get", 503, 5)
+	if _, err := svc.Spreadsheets.Values.Get("s", "Data").Do(); err == nil {
+		t.Error("values.get failing 5 times succeeded with 3 attempts")
+	}
+	if n := srv.Count("values.get"); n != 3 {
+		t.Errorf("values.get was sent %d times, want MaxAttempts 3", n)
+	}
+}
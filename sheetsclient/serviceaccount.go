@@ -0,0 +1,36 @@
+package sheetsclient
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2/google"
+)
+
+// ServiceAccount authorizes requests as a service account, for running
+// unattended on servers and in CI. The spreadsheets must be shared with
+// the account's email address, unless Subject is used.
+type ServiceAccount struct {
+	// KeyFile is the JSON key of the account.
+	KeyFile string
+	Scopes  []string
+	// Subject, if set, is the user impersonated through domain-wide
+	// delegation.
+	Subject string
+}
+
+// HTTPClient returns a client authorized with a JWT signed by the key.
+func (s *ServiceAccount) HTTPClient(ctx context.Context) (*http.Client, error) {
+	b, err := ioutil.ReadFile(s.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to read service account key: %w", err)
+	}
+	config, err := google.JWTConfigFromJSON(b, s.Scopes...)
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to parse service account key %s: %w", s.KeyFile, err)
+	}
+	config.Subject = s.Subject
+	return config.Client(ctx), nil
+}
@@ -0,0 +1,114 @@
+package sheetsclient
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+)
+
+// Spreadsheet describes a spreadsheet and its tabs.
+type Spreadsheet struct {
+	ID       string `json:"id"`
+	URL      string `json:"url"`
+	Title    string `json:"title"`
+	Locale   string `json:"locale"`
+	TimeZone string `json:"timeZone"`
+	Tabs     []*Tab `json:"tabs"`
+}
+
+// Tab describes a tab of a spreadsheet.
+type Tab struct {
+	ID      int64  `json:"sheetId"`
+	Title   string `json:"title"`
+	Index   int64  `json:"index"`
+	Type    string `json:"type"`
+	Rows    int64  `json:"rows"`
+	Columns int64  `json:"columns"`
+	Hidden  bool   `json:"hidden"`
+	// Protected is set when the whole tab is protected from edits;
+	// ProtectedRanges counts protections of parts of it.
+	Protected       bool `json:"protected"`
+	ProtectedRanges int  `json:"protectedRanges"`
+	// FrozenRows and FrozenColumns stay in view when scrolling.
+	FrozenRows    int64 `json:"frozenRows"`
+	FrozenColumns int64 `json:"frozenColumns"`
+}
+
+func newSpreadsheet(s *sheets.Spreadsheet) *Spreadsheet {
+	out := &Spreadsheet{ID: s.SpreadsheetId, URL: s.SpreadsheetUrl}
+	if p := s.Properties; p != nil {
+		out.Title, out.Locale, out.TimeZone = p.Title, p.Locale, p.TimeZone
+	}
+	for _, sh := range s.Sheets {
+		t := newTab(sh.Properties)
+		for _, pr := range sh.ProtectedRanges {
+			if r := pr.Range; r == nil || (r.StartRowIndex == 0 && r.EndRowIndex == 0 && r.StartColumnIndex == 0 && r.EndColumnIndex == 0) {
+				t.Protected = true
+			} else {
+				t.ProtectedRanges++
+			}
+		}
+		out.Tabs = append(out.Tabs, t)
+	}
+	return out
+}
+
+func newTab(p *sheets.SheetProperties) *Tab {
+	t := &Tab{ID: p.SheetId, Title: p.Title, Index: p.Index, Type: p.SheetType, Hidden: p.Hidden}
+	if g := p.GridProperties; g != nil {
+		t.Rows, t.Columns = g.RowCount, g.ColumnCount
+		t.FrozenRows, t.FrozenColumns = g.FrozenRowCount, g.FrozenColumnCount
+	}
+	return t
+}
+
+// CreateOptions describe a new spreadsheet.
+type CreateOptions struct {
+	Title string
+	// Locale, e.g. "en_GB", decides date and number formats and the
+	// function names in formulas; defaults to the creator's.
+	Locale string
+	// TimeZone is a CLDR zone such as "Europe/Berlin"; defaults to the
+	// creator's.
+	TimeZone string
+	// Tabs names the initial tabs; without any, the spreadsheet has one
+	// tab named "Sheet1".
+	Tabs []string
+}
+
+// Create makes a new spreadsheet, owned by the authorized user or service
+// account, and returns it with its ID and URL.
+func (c *Client) Create(ctx context.Context, opts *CreateOptions) (*Spreadsheet, error) {
+	req := &sheets.Spreadsheet{Properties: &sheets.SpreadsheetProperties{
+		Title:    opts.Title,
+		Locale:   opts.Locale,
+		TimeZone: opts.TimeZone,
+	}}
+	for _, tab := range opts.Tabs {
+		req.Sheets = append(req.Sheets, &sheets.Sheet{Properties: &sheets.SheetProperties{Title: tab}})
+	}
+	resp, err := c.Sheets.Spreadsheets.Create(req).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to create spreadsheet %q: %w", opts.Title, Classify(err))
+	}
+	return newSpreadsheet(resp), nil
+}
+
+// CreateSpreadsheet is a shorthand for Create with a title and tab names.
+func (c *Client) CreateSpreadsheet(ctx context.Context, title string, tabs ...string) (*Spreadsheet, error) {
+	return c.Create(ctx, &CreateOptions{Title: title, Tabs: tabs})
+}
+
+// Info returns the properties of a spreadsheet and of each of its tabs,
+// without any cell data.
+func (c *Client) Info(ctx context.Context, spreadsheetID string) (*Spreadsheet, error) {
+	resp, err := c.Sheets.Spreadsheets.Get(spreadsheetID).Fields("spreadsheetId,spreadsheetUrl," +
+		"properties(title,locale,timeZone)," +
+		"sheets(properties(sheetId,title,index,sheetType,hidden,gridProperties(rowCount,columnCount,frozenRowCount,frozenColumnCount)),protectedRanges(range))").
+		Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to get spreadsheet: %w", Classify(err))
+	}
+	return newSpreadsheet(resp), nil
+}
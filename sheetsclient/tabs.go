@@ -0,0 +1,134 @@
+package sheetsclient
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+)
+
+// batch sends spreadsheet update requests in one call.
+func (c *Client) batch(ctx context.Context, spreadsheetID string, reqs ...*sheets.Request) (*sheets.BatchUpdateSpreadsheetResponse, error) {
+	return c.Sheets.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{Requests: reqs}).Context(ctx).Do()
+}
+
+// Tabs lists the tabs of a spreadsheet in display order.
+func (c *Client) Tabs(ctx context.Context, spreadsheetID string) ([]*Tab, error) {
+	resp, err := c.Sheets.Spreadsheets.Get(spreadsheetID).
+		Fields("sheets.properties(sheetId,title,index,sheetType,gridProperties(rowCount,columnCount,frozenRowCount,frozenColumnCount))").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to get tabs: %w", Classify(err))
+	}
+	tabs := make([]*Tab, len(resp.Sheets))
+	for i, s := range resp.Sheets {
+		tabs[i] = newTab(s.Properties)
+	}
+	return tabs, nil
+}
+
+// Tab returns the tab with the given title.
+func (c *Client) Tab(ctx context.Context, spreadsheetID, title string) (*Tab, error) {
+	tabs, err := c.Tabs(ctx, spreadsheetID)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tabs {
+		if t.Title == title {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("sheetsclient: no tab named %q: %w", title, ErrRangeNotFound)
+}
+
+// AddTab appends an empty tab and returns it.
+func (c *Client) AddTab(ctx context.Context, spreadsheetID, title string) (*Tab, error) {
+	resp, err := c.batch(ctx, spreadsheetID, &sheets.Request{AddSheet: &sheets.AddSheetRequest{
+		Properties: &sheets.SheetProperties{Title: title},
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to add tab %q: %w", title, Classify(err))
+	}
+	return newTab(resp.Replies[0].AddSheet.Properties), nil
+}
+
+// DeleteTab deletes a tab and everything on it.
+func (c *Client) DeleteTab(ctx context.Context, spreadsheetID, title string) error {
+	t, err := c.Tab(ctx, spreadsheetID, title)
+	if err != nil {
+		return err
+	}
+	if _, err := c.batch(ctx, spreadsheetID, &sheets.Request{DeleteSheet: &sheets.DeleteSheetRequest{SheetId: t.ID}}); err != nil {
+		return fmt.Errorf("sheetsclient: unable to delete tab %q: %w", title, Classify(err))
+	}
+	return nil
+}
+
+// RenameTab changes the title of a tab. Formulas referring to it are
+// updated by the sheet.
+func (c *Client) RenameTab(ctx context.Context, spreadsheetID, title, newTitle string) error {
+	t, err := c.Tab(ctx, spreadsheetID, title)
+	if err != nil {
+		return err
+	}
+	_, err = c.batch(ctx, spreadsheetID, &sheets.Request{UpdateSheetProperties: &sheets.UpdateSheetPropertiesRequest{
+		Properties: &sheets.SheetProperties{SheetId: t.ID, Title: newTitle},
+		Fields:     "title",
+	}})
+	if err != nil {
+		return fmt.Errorf("sheetsclient: unable to rename tab %q: %w", title, Classify(err))
+	}
+	return nil
+}
+
+// Freeze keeps the first rows and columns of a tab in view when
+// scrolling, e.g. 1 and 0 for a header row. Zero unfreezes.
+func (c *Client) Freeze(ctx context.Context, spreadsheetID, title string, rows, columns int64) error {
+	t, err := c.Tab(ctx, spreadsheetID, title)
+	if err != nil {
+		return err
+	}
+	_, err = c.batch(ctx, spreadsheetID, &sheets.Request{UpdateSheetProperties: &sheets.UpdateSheetPropertiesRequest{
+		Properties: &sheets.SheetProperties{SheetId: t.ID, GridProperties: &sheets.GridProperties{
+			FrozenRowCount:    rows,
+			FrozenColumnCount: columns,
+			ForceSendFields:   []string{"FrozenRowCount", "FrozenColumnCount"},
+		}},
+		Fields: "gridProperties.frozenRowCount,gridProperties.frozenColumnCount",
+	}})
+	if err != nil {
+		return fmt.Errorf("sheetsclient: unable to freeze %q: %w", title, Classify(err))
+	}
+	return nil
+}
+
+// DuplicateTab copies a tab, content and formatting included, to a new tab
+// named newTitle at position index. An index that is negative or past the
+// last tab puts the copy last.
+func (c *Client) DuplicateTab(ctx context.Context, spreadsheetID, title, newTitle string, index int64) (*Tab, error) {
+	tabs, err := c.Tabs(ctx, spreadsheetID)
+	if err != nil {
+		return nil, err
+	}
+	var src *Tab
+	for _, t := range tabs {
+		if t.Title == title {
+			src = t
+		}
+	}
+	if src == nil {
+		return nil, fmt.Errorf("sheetsclient: no tab named %q: %w", title, ErrRangeNotFound)
+	}
+	if index < 0 || index > int64(len(tabs)) {
+		index = int64(len(tabs))
+	}
+	resp, err := c.batch(ctx, spreadsheetID, &sheets.Request{DuplicateSheet: &sheets.DuplicateSheetRequest{
+		SourceSheetId:    src.ID,
+		NewSheetName:     newTitle,
+		InsertSheetIndex: index,
+		ForceSendFields:  []string{"SourceSheetId", "InsertSheetIndex"},
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("sheetsclient: unable to duplicate tab %q: %w", title, Classify(err))
+	}
+	return newTab(resp.Replies[0].DuplicateSheet.Properties), nil
+}
@@ -0,0 +1,167 @@
+package sheetsclient
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/oauth2"
+)
+
+// ErrTokenEncrypted is returned when the cached token is encrypted and no
+// TokenEncryption, or one with another key, is configured.
+var ErrTokenEncrypted = errors.New("sheetsclient: cached token is encrypted")
+
+// TokenEncryption encrypts the token cached by OAuth, which holds a
+// refresh token giving lasting access, with AES-GCM. Tokens are decrypted
+// transparently on load, and a plaintext token found in the cache is
+// encrypted the next time it is saved.
+type TokenEncryption struct {
+	// Passphrase, if set, derives the key with scrypt. Otherwise the key
+	// is bound to the machine and user, so the file is useless once
+	// copied elsewhere; this protects against leaked backups, not against
+	// other programs run by the same user.
+	Passphrase string
+}
+
+// sealedToken is the file format of an encrypted token.
+type sealedToken struct {
+	Encryption string `json:"encryption"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+const (
+	encPassphrase = "aes-256-gcm+scrypt"
+	encMachine    = "aes-256-gcm+machine"
+)
+
+func (e *TokenEncryption) kind() string {
+	if e.Passphrase != "" {
+		return encPassphrase
+	}
+	return encMachine
+}
+
+// key derives the AES key for salt.
+func (e *TokenEncryption) key(salt []byte) ([]byte, error) {
+	if e.Passphrase != "" {
+		return scrypt.Key([]byte(e.Passphrase), salt, 1<<15, 8, 1, 32)
+	}
+	id, err := machineID()
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	h.Write([]byte("sheetsclient token key\x00"))
+	h.Write([]byte(id))
+	h.Write([]byte{0})
+	h.Write(salt)
+	return h.Sum(nil), nil
+}
+
+// machineID identifies the machine and the user running the program.
+func machineID() (string, error) {
+	var id string
+	for _, f := range []string{"/etc/machine-id", "/var/lib/dbus/machine-id"} {
+		if b, err := ioutil.ReadFile(f); err == nil && len(strings.TrimSpace(string(b))) > 0 {
+			id = strings.TrimSpace(string(b))
+			break
+		}
+	}
+	if id == "" {
+		host, err := os.Hostname()
+		if err != nil {
+			return "", fmt.Errorf("sheetsclient: unable to identify machine: %w", err)
+		}
+		id = host
+	}
+	usr, err := user.Current()
+	if err != nil {
+		return "", fmt.Errorf("sheetsclient: unable to identify user: %w", err)
+	}
+	return id + "\x00" + usr.Uid, nil
+}
+
+func (e *TokenEncryption) seal(plain []byte) ([]byte, error) {
+	s := &sealedToken{Encryption: e.kind(), Salt: make([]byte, 16)}
+	if _, err := rand.Read(s.Salt); err != nil {
+		return nil, err
+	}
+	aead, err := e.aead(s.Salt)
+	if err != nil {
+		return nil, err
+	}
+	s.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(s.Nonce); err != nil {
+		return nil, err
+	}
+	s.Ciphertext = aead.Seal(nil, s.Nonce, plain, []byte(s.Encryption))
+	return json.Marshal(s)
+}
+
+func (e *TokenEncryption) open(s *sealedToken) ([]byte, error) {
+	if s.Encryption != e.kind() {
+		return nil, fmt.Errorf("%w with %s", ErrTokenEncrypted, s.Encryption)
+	}
+	aead, err := e.aead(s.Salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("sheetsclient: invalid encrypted token")
+	}
+	plain, err := aead.Open(nil, s.Nonce, s.Ciphertext, []byte(s.Encryption))
+	if err != nil {
+		return nil, fmt.Errorf("%w: wrong passphrase or another machine", ErrTokenEncrypted)
+	}
+	return plain, nil
+}
+
+func (e *TokenEncryption) aead(salt []byte) (cipher.AEAD, error) {
+	key, err := e.key(salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// readToken loads a cached token and the scopes it was granted, decrypting
+// it with enc if it is encrypted. plain reports whether it was stored
+// unencrypted.
+func readToken(file string, enc *TokenEncryption) (tok *cachedToken, plain bool, err error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, false, err
+	}
+	plain = true
+	var s sealedToken
+	if err := json.Unmarshal(b, &s); err == nil && s.Encryption != "" {
+		if enc == nil {
+			return nil, false, ErrTokenEncrypted
+		}
+		if b, err = enc.open(&s); err != nil {
+			return nil, false, err
+		}
+		plain = false
+	}
+	tok = &cachedToken{Token: &oauth2.Token{}}
+	if err := json.Unmarshal(b, tok); err != nil {
+		return nil, false, err
+	}
+	return tok, plain, nil
+}
@@ -0,0 +1,140 @@
+package sheetsclient
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+)
+
+// UpsertResult counts the rows written by Upsert.
+type UpsertResult struct {
+	Updated, Appended int
+}
+
+// Upsert writes rows into the table in rng, e.g. "Data" or "Data!A1:F",
+// whose first row after c.SkipRows is the header. Each row holds the
+// table's columns in order and is matched on the column of the header
+// named keyColumn: a row whose key is already in that column overwrites
+// the row there, and the others are appended after the last non-empty
+// row of the table, all in a single batch write. Keys are compared as
+// text, ignoring surrounding spaces; the last of rows sharing a key wins
+// and is counted once.
+func (c *Client) Upsert(ctx context.Context, spreadsheetID, rng string, rows [][]interface{}, keyColumn string) (*UpsertResult, error) {
+	rng, err := c.ResolveRange(ctx, spreadsheetID, rng)
+	if err != nil {
+		return nil, err
+	}
+	title, cells := splitTab(rng)
+	t, err := c.Tab(ctx, spreadsheetID, title)
+	if err != nil {
+		return nil, err
+	}
+	// Parse the bounds of the range as Rows does.
+	it := &RowIterator{}
+	if err := it.parse(cells); err != nil {
+		return nil, err
+	}
+	startCol := it.startCol
+	if startCol == "" {
+		startCol = "A"
+	}
+	first := ColumnNumber(startCol)
+	headerRow := it.next + c.SkipRows
+
+	hr := fmt.Sprintf("%s!%d:%d", QuoteTab(title), headerRow, headerRow)
+	if it.endCol != "" {
+		hr = fmt.Sprintf("%s!%s%d:%s%d", QuoteTab(title), startCol, headerRow, it.endCol, headerRow)
+	}
+	values, err := c.ReadRange(ctx, spreadsheetID, hr)
+	if err != nil {
+		return nil, err
+	}
+	var header []interface{}
+	if len(values) > 0 {
+		header = values[0]
+		if it.endCol == "" {
+			// The whole row was read; drop the cells left of the table.
+			if first-1 < len(header) {
+				header = header[first-1:]
+			} else {
+				header = nil
+			}
+		}
+	}
+	k := HeaderColumn(header, keyColumn)
+	if k < 0 {
+		return nil, fmt.Errorf("sheetsclient: no column %q in the header of %s", keyColumn, rng)
+	}
+	// Read the whole table below the header, so that new rows go after
+	// its last row even if that row has no key.
+	endCol := it.endCol
+	if endCol == "" {
+		endCol = ColumnLetters(first + len(header) - 1)
+	}
+	tr := fmt.Sprintf("%s!%s%d:%s", QuoteTab(title), startCol, headerRow+1, endCol)
+	if it.last > 0 {
+		tr += fmt.Sprint(it.last)
+	}
+	table, err := c.ReadRange(ctx, spreadsheetID, tr)
+	if err != nil {
+		return nil, err
+	}
+
+	// at maps each key to its row number, existing or appended.
+	at := map[string]int{}
+	for i, r := range table {
+		if k < len(r) {
+			if key := strings.TrimSpace(fmt.Sprint(r[k])); key != "" {
+				at[key] = headerRow + 1 + i
+			}
+		}
+	}
+	next := headerRow + 1 + len(table)
+	res := &UpsertResult{}
+	data := map[string][][]interface{}{}
+	var appended [][]interface{}
+	for _, row := range rows {
+		if k >= len(row) || strings.TrimSpace(fmt.Sprint(row[k])) == "" {
+			return nil, fmt.Errorf("sheetsclient: row %v has no %s", row, keyColumn)
+		}
+		key := strings.TrimSpace(fmt.Sprint(row[k]))
+		n, ok := at[key]
+		switch {
+		case !ok:
+			at[key] = next + len(appended)
+			appended = append(appended, row)
+			res.Appended++
+		case n >= next:
+			appended[n-next] = row
+		default:
+			cell := fmt.Sprintf("%s!%s%d", QuoteTab(title), startCol, n)
+			if _, dup := data[cell]; !dup {
+				res.Updated++
+			}
+			data[cell] = [][]interface{}{row}
+		}
+	}
+	if len(appended) > 0 {
+		if it.last > 0 && next+len(appended)-1 > it.last {
+			return nil, fmt.Errorf("sheetsclient: %d new rows do not fit in %s", len(appended), rng)
+		}
+		data[fmt.Sprintf("%s!%s%d", QuoteTab(title), startCol, next)] = appended
+		if grow := int64(next+len(appended)-1) - t.Rows; grow > 0 {
+			_, err := c.batch(ctx, spreadsheetID, &sheets.Request{AppendDimension: &sheets.AppendDimensionRequest{
+				SheetId:         t.ID,
+				Dimension:       "ROWS",
+				Length:          grow,
+				ForceSendFields: []string{"SheetId"},
+			}})
+			if err != nil {
+				return nil, fmt.Errorf("sheetsclient: unable to add rows to %q: %w", title, Classify(err))
+			}
+		}
+	}
+	if _, err := c.BatchUpdate(ctx, spreadsheetID, data); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
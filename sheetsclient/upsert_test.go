@@ -0,0 +1,67 @@
+package sheetsclient
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetstest"
+)
+
+func TestUpsert(t *testing.T) {
+	ctx := context.Background()
+	srv := sheetstest.NewServer()
+	defer srv.Close()
+	srv.Seed(&sheetstest.Spreadsheet{ID: "s", Tabs: []*sheetstest.Tab{{
+		Title: "Data",
+		Rows: [][]interface{}{
+			{"sku", "qty", "note"},
+			{"a", "1"},
+			{"b", "2"},
+			// Rows below the last key are still part of the table.
+			{"", "", "no key"},
+			{},
+			{"", "3"},
+		},
+	}}})
+	svc, err := srv.SheetsService(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := FromService(svc)
+
+	res, err := c.Upsert(ctx, "s", "Data", [][]interface{}{
+		{"b", "20"},
+		{"c", "30"},
+		{" b ", 21.0},
+		{"d", 40.0},
+		{"c", 31.0},
+	}, "sku")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&UpsertResult{Updated: 1, Appended: 2}); *res != *want {
+		t.Errorf("Upsert = %+v, want %+v", res, want)
+	}
+	want := [][]interface{}{
+		{"sku", "qty", "note"},
+		{"a", "1"},
+		{" b ", 21.0},
+		{"", "", "no key"},
+		{},
+		{"", "3"},
+		{"c", 31.0},
+		{"d", 40.0},
+	}
+	if got := srv.Values("s", "Data"); !reflect.DeepEqual(got, want) {
+		t.Errorf("after Upsert, Data =\n%#v\nwant\n%#v", got, want)
+	}
+
+	if _, err := c.Upsert(ctx, "s", "Data", [][]interface{}{{"e"}}, "id"); err == nil {
+		t.Error("Upsert on a missing key column succeeded")
+	}
+	if _, err := c.Upsert(ctx, "s", "Data", [][]interface{}{{""}}, "sku"); err == nil {
+		t.Error("Upsert of a row without a key succeeded")
+	}
+}
@@ -0,0 +1,48 @@
+package sheetsrpc
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestTokenAuth(t *testing.T) {
+	auth := TokenAuth{Tokens: []string{"s3cret"}}
+	tests := []struct {
+		md   metadata.MD
+		want codes.Code
+	}{
+		{nil, codes.Unauthenticated},
+		{metadata.Pairs("authorization", "s3cret"), codes.Unauthenticated},
+		{metadata.Pairs("authorization", "Bearer "), codes.Unauthenticated},
+		{metadata.Pairs("authorization", "Bearer wrong"), codes.Unauthenticated},
+		{metadata.Pairs("authorization", "Bearer s3cret"), codes.OK},
+	}
+	for _, tt := range tests {
+		ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+		if got := status.Code(auth.check(ctx)); got != tt.want {
+			t.Errorf("check(%v) = %v, want %v", tt.md, got, tt.want)
+		}
+	}
+	if err := (TokenAuth{}).check(metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "))); err == nil {
+		t.Error("TokenAuth without tokens accepted a call")
+	}
+}
+
+func TestServerSpreadsheets(t *testing.T) {
+	s := &Server{Spreadsheets: []string{"abc"}}
+	_, err := s.Read(context.Background(), &ReadRequest{SpreadsheetId: "other", Range: "A1"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Read of an unlisted spreadsheet: %v, want PermissionDenied", err)
+	}
+	_, err = s.Update(context.Background(), &WriteRequest{SpreadsheetId: "other", Range: "A1"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Update of an unlisted spreadsheet: %v, want PermissionDenied", err)
+	}
+	if err := s.allowed("abc"); err != nil {
+		t.Errorf("allowed(abc) = %v", err)
+	}
+}
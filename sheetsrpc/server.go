@@ -0,0 +1,226 @@
+// Package sheetsrpc exposes the Sheets API as a gRPC service, so that
+// services can read, write and watch spreadsheets through a typed,
+// streaming interface without holding Google credentials themselves.
+// Calls authenticate with a TokenAuth interceptor or client certificates,
+// and may only use the spreadsheets the Server lists.
+package sheetsrpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative sheets.proto
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/sheets/v4"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/prantoran/GoogleSheets_GO/watch"
+)
+
+// Server implements SheetsServer over a Sheets service.
+type Server struct {
+	UnimplementedSheetsServer
+
+	Sheets *sheets.Service
+	// Spreadsheets lists the spreadsheet IDs calls may use. A server
+	// without spreadsheets rejects every call.
+	Spreadsheets []string
+	// DefaultInterval is the poll interval of Watch calls that do not
+	// request one; defaults to 30 seconds.
+	DefaultInterval time.Duration
+}
+
+// Read implements SheetsServer.
+func (s *Server) Read(ctx context.Context, req *ReadRequest) (*ReadResponse, error) {
+	if req.SpreadsheetId == "" || req.Range == "" {
+		return nil, status.Error(codes.InvalidArgument, "spreadsheet_id and range are required")
+	}
+	if err := s.allowed(req.SpreadsheetId); err != nil {
+		return nil, err
+	}
+	resp, err := s.Sheets.Spreadsheets.Values.Get(req.SpreadsheetId, req.Range).Context(ctx).Do()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	out := &ReadResponse{Range: resp.Range}
+	for _, v := range resp.Values {
+		out.Rows = append(out.Rows, toRow(v))
+	}
+	return out, nil
+}
+
+// Append implements SheetsServer.
+func (s *Server) Append(ctx context.Context, req *WriteRequest) (*WriteResponse, error) {
+	vr, err := s.valueRange(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.Sheets.Spreadsheets.Values.Append(req.SpreadsheetId, req.Range, vr).
+		ValueInputOption(inputOption(req)).InsertDataOption("INSERT_ROWS").Context(ctx).Do()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	u := resp.Updates
+	return &WriteResponse{UpdatedRange: u.UpdatedRange, UpdatedRows: u.UpdatedRows, UpdatedCells: u.UpdatedCells}, nil
+}
+
+// Update implements SheetsServer.
+func (s *Server) Update(ctx context.Context, req *WriteRequest) (*WriteResponse, error) {
+	vr, err := s.valueRange(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.Sheets.Spreadsheets.Values.Update(req.SpreadsheetId, req.Range, vr).
+		ValueInputOption(inputOption(req)).Context(ctx).Do()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &WriteResponse{UpdatedRange: resp.UpdatedRange, UpdatedRows: resp.UpdatedRows, UpdatedCells: resp.UpdatedCells}, nil
+}
+
+// Watch implements SheetsServer. Transient read errors are retried with
+// backoff; the call ends when the client cancels it or the range cannot be
+// read at all.
+func (s *Server) Watch(req *WatchRequest, stream Sheets_WatchServer) error {
+	if req.SpreadsheetId == "" || req.Range == "" {
+		return status.Error(codes.InvalidArgument, "spreadsheet_id and range are required")
+	}
+	if err := s.allowed(req.SpreadsheetId); err != nil {
+		return err
+	}
+	interval := time.Duration(req.IntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = s.DefaultInterval
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	var sendErr, fatal error
+	w := &watch.Watcher{
+		Sheets:        s.Sheets,
+		SpreadsheetID: req.SpreadsheetId,
+		Range:         req.Range,
+		KeyColumn:     req.KeyColumn,
+		Interval:      interval,
+	}
+	w.OnEvent(func(e watch.Event) {
+		if sendErr != nil {
+			return
+		}
+		if sendErr = stream.Send(toEvent(e)); sendErr != nil {
+			cancel()
+		}
+	})
+	w.OnError(func(err error) {
+		switch st := toStatus(err); status.Code(st) {
+		case codes.InvalidArgument, codes.Unauthenticated, codes.PermissionDenied, codes.NotFound:
+			fatal = st
+			cancel()
+		}
+	})
+	err := w.Run(ctx)
+	if sendErr != nil {
+		return sendErr
+	}
+	if fatal != nil {
+		return fatal
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return nil
+	}
+	return toStatus(err)
+}
+
+// allowed reports a PermissionDenied error unless calls may use the
+// spreadsheet.
+func (s *Server) allowed(spreadsheetID string) error {
+	for _, id := range s.Spreadsheets {
+		if id == spreadsheetID {
+			return nil
+		}
+	}
+	return status.Errorf(codes.PermissionDenied, "spreadsheet %s is not served", spreadsheetID)
+}
+
+func (s *Server) valueRange(req *WriteRequest) (*sheets.ValueRange, error) {
+	if req.SpreadsheetId == "" || req.Range == "" {
+		return nil, status.Error(codes.InvalidArgument, "spreadsheet_id and range are required")
+	}
+	if err := s.allowed(req.SpreadsheetId); err != nil {
+		return nil, err
+	}
+	vr := &sheets.ValueRange{}
+	for _, r := range req.Rows {
+		row := make([]interface{}, len(r.Values))
+		for i, v := range r.Values {
+			row[i] = v
+		}
+		vr.Values = append(vr.Values, row)
+	}
+	return vr, nil
+}
+
+func inputOption(req *WriteRequest) string {
+	if req.Raw {
+		return "RAW"
+	}
+	return "USER_ENTERED"
+}
+
+func toRow(v []interface{}) *Row {
+	r := &Row{Values: make([]string, len(v))}
+	for i, c := range v {
+		r.Values[i] = fmt.Sprint(c)
+	}
+	return r
+}
+
+func toEvent(e watch.Event) *RowEvent {
+	ev := &RowEvent{Key: e.Key}
+	switch e.Type {
+	case watch.RowAdded:
+		ev.Type = RowEvent_ADDED
+	case watch.RowChanged:
+		ev.Type = RowEvent_CHANGED
+	case watch.RowDeleted:
+		ev.Type = RowEvent_DELETED
+	}
+	if e.Old != nil {
+		ev.Header = e.Old.Header()
+		ev.Old = &Row{Values: e.Old.Values}
+	}
+	if e.New != nil {
+		ev.Header = e.New.Header()
+		ev.New = &Row{Values: e.New.Values}
+	}
+	return ev
+}
+
+// toStatus maps Sheets API errors to gRPC status codes.
+func toStatus(err error) error {
+	var gerr *googleapi.Error
+	if !errors.As(err, &gerr) {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	code := codes.Unknown
+	switch gerr.Code {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		code = codes.Unavailable
+	}
+	return status.Error(code, gerr.Message)
+}
@@ -0,0 +1,620 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: sheetsrpc/sheets.proto
+
+package sheetsrpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type RowEvent_Type int32
+
+const (
+	RowEvent_TYPE_UNSPECIFIED RowEvent_Type = 0
+	RowEvent_ADDED            RowEvent_Type = 1
+	RowEvent_CHANGED          RowEvent_Type = 2
+	RowEvent_DELETED          RowEvent_Type = 3
+)
+
+// Enum value maps for RowEvent_Type.
+var (
+	RowEvent_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "ADDED",
+		2: "CHANGED",
+		3: "DELETED",
+	}
+	RowEvent_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED": 0,
+		"ADDED":            1,
+		"CHANGED":          2,
+		"DELETED":          3,
+	}
+)
+
+func (x RowEvent_Type) Enum() *RowEvent_Type {
+	p := new(RowEvent_Type)
+	*p = x
+	return p
+}
+
+func (x RowEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RowEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_sheetsrpc_sheets_proto_enumTypes[0].Descriptor()
+}
+
+func (RowEvent_Type) Type() protoreflect.EnumType {
+	return &file_sheetsrpc_sheets_proto_enumTypes[0]
+}
+
+func (x RowEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RowEvent_Type.Descriptor instead.
+func (RowEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_sheetsrpc_sheets_proto_rawDescGZIP(), []int{6, 0}
+}
+
+// Row is one row of cell values, formatted as displayed in the sheet.
+type Row struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []string               `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Row) Reset() {
+	*x = Row{}
+	mi := &file_sheetsrpc_sheets_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Row) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Row) ProtoMessage() {}
+
+func (x *Row) ProtoReflect() protoreflect.Message {
+	mi := &file_sheetsrpc_sheets_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Row.ProtoReflect.Descriptor instead.
+func (*Row) Descriptor() ([]byte, []int) {
+	return file_sheetsrpc_sheets_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Row) GetValues() []string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type ReadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SpreadsheetId string                 `protobuf:"bytes,1,opt,name=spreadsheet_id,json=spreadsheetId,proto3" json:"spreadsheet_id,omitempty"`
+	// Range in A1 notation, e.g. "Sheet1!A1:F".
+	Range         string `protobuf:"bytes,2,opt,name=range,proto3" json:"range,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadRequest) Reset() {
+	*x = ReadRequest{}
+	mi := &file_sheetsrpc_sheets_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadRequest) ProtoMessage() {}
+
+func (x *ReadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sheetsrpc_sheets_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadRequest.ProtoReflect.Descriptor instead.
+func (*ReadRequest) Descriptor() ([]byte, []int) {
+	return file_sheetsrpc_sheets_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ReadRequest) GetSpreadsheetId() string {
+	if x != nil {
+		return x.SpreadsheetId
+	}
+	return ""
+}
+
+func (x *ReadRequest) GetRange() string {
+	if x != nil {
+		return x.Range
+	}
+	return ""
+}
+
+type ReadResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Range actually covered by the returned rows.
+	Range         string `protobuf:"bytes,1,opt,name=range,proto3" json:"range,omitempty"`
+	Rows          []*Row `protobuf:"bytes,2,rep,name=rows,proto3" json:"rows,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadResponse) Reset() {
+	*x = ReadResponse{}
+	mi := &file_sheetsrpc_sheets_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadResponse) ProtoMessage() {}
+
+func (x *ReadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sheetsrpc_sheets_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadResponse.ProtoReflect.Descriptor instead.
+func (*ReadResponse) Descriptor() ([]byte, []int) {
+	return file_sheetsrpc_sheets_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ReadResponse) GetRange() string {
+	if x != nil {
+		return x.Range
+	}
+	return ""
+}
+
+func (x *ReadResponse) GetRows() []*Row {
+	if x != nil {
+		return x.Rows
+	}
+	return nil
+}
+
+type WriteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SpreadsheetId string                 `protobuf:"bytes,1,opt,name=spreadsheet_id,json=spreadsheetId,proto3" json:"spreadsheet_id,omitempty"`
+	Range         string                 `protobuf:"bytes,2,opt,name=range,proto3" json:"range,omitempty"`
+	Rows          []*Row                 `protobuf:"bytes,3,rep,name=rows,proto3" json:"rows,omitempty"`
+	// Raw stores values as given instead of parsing them like user input.
+	Raw           bool `protobuf:"varint,4,opt,name=raw,proto3" json:"raw,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteRequest) Reset() {
+	*x = WriteRequest{}
+	mi := &file_sheetsrpc_sheets_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteRequest) ProtoMessage() {}
+
+func (x *WriteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sheetsrpc_sheets_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteRequest.ProtoReflect.Descriptor instead.
+func (*WriteRequest) Descriptor() ([]byte, []int) {
+	return file_sheetsrpc_sheets_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *WriteRequest) GetSpreadsheetId() string {
+	if x != nil {
+		return x.SpreadsheetId
+	}
+	return ""
+}
+
+func (x *WriteRequest) GetRange() string {
+	if x != nil {
+		return x.Range
+	}
+	return ""
+}
+
+func (x *WriteRequest) GetRows() []*Row {
+	if x != nil {
+		return x.Rows
+	}
+	return nil
+}
+
+func (x *WriteRequest) GetRaw() bool {
+	if x != nil {
+		return x.Raw
+	}
+	return false
+}
+
+type WriteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UpdatedRange  string                 `protobuf:"bytes,1,opt,name=updated_range,json=updatedRange,proto3" json:"updated_range,omitempty"`
+	UpdatedRows   int64                  `protobuf:"varint,2,opt,name=updated_rows,json=updatedRows,proto3" json:"updated_rows,omitempty"`
+	UpdatedCells  int64                  `protobuf:"varint,3,opt,name=updated_cells,json=updatedCells,proto3" json:"updated_cells,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteResponse) Reset() {
+	*x = WriteResponse{}
+	mi := &file_sheetsrpc_sheets_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteResponse) ProtoMessage() {}
+
+func (x *WriteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sheetsrpc_sheets_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteResponse.ProtoReflect.Descriptor instead.
+func (*WriteResponse) Descriptor() ([]byte, []int) {
+	return file_sheetsrpc_sheets_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *WriteResponse) GetUpdatedRange() string {
+	if x != nil {
+		return x.UpdatedRange
+	}
+	return ""
+}
+
+func (x *WriteResponse) GetUpdatedRows() int64 {
+	if x != nil {
+		return x.UpdatedRows
+	}
+	return 0
+}
+
+func (x *WriteResponse) GetUpdatedCells() int64 {
+	if x != nil {
+		return x.UpdatedCells
+	}
+	return 0
+}
+
+type WatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SpreadsheetId string                 `protobuf:"bytes,1,opt,name=spreadsheet_id,json=spreadsheetId,proto3" json:"spreadsheet_id,omitempty"`
+	// Range whose first row is the header.
+	Range string `protobuf:"bytes,2,opt,name=range,proto3" json:"range,omitempty"`
+	// Header of the column identifying rows; rows are compared by position
+	// when empty.
+	KeyColumn string `protobuf:"bytes,3,opt,name=key_column,json=keyColumn,proto3" json:"key_column,omitempty"`
+	// Seconds between polls; the server picks a default when zero.
+	IntervalSeconds int32 `protobuf:"varint,4,opt,name=interval_seconds,json=intervalSeconds,proto3" json:"interval_seconds,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_sheetsrpc_sheets_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sheetsrpc_sheets_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_sheetsrpc_sheets_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *WatchRequest) GetSpreadsheetId() string {
+	if x != nil {
+		return x.SpreadsheetId
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetRange() string {
+	if x != nil {
+		return x.Range
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetKeyColumn() string {
+	if x != nil {
+		return x.KeyColumn
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetIntervalSeconds() int32 {
+	if x != nil {
+		return x.IntervalSeconds
+	}
+	return 0
+}
+
+type RowEvent struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Type   RowEvent_Type          `protobuf:"varint,1,opt,name=type,proto3,enum=gsheets.v1.RowEvent_Type" json:"type,omitempty"`
+	Key    string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Header []string               `protobuf:"bytes,3,rep,name=header,proto3" json:"header,omitempty"`
+	// Previous version of the row, unset for added rows.
+	Old *Row `protobuf:"bytes,4,opt,name=old,proto3" json:"old,omitempty"`
+	// Current version of the row, unset for deleted rows.
+	New           *Row `protobuf:"bytes,5,opt,name=new,proto3" json:"new,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RowEvent) Reset() {
+	*x = RowEvent{}
+	mi := &file_sheetsrpc_sheets_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RowEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RowEvent) ProtoMessage() {}
+
+func (x *RowEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_sheetsrpc_sheets_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RowEvent.ProtoReflect.Descriptor instead.
+func (*RowEvent) Descriptor() ([]byte, []int) {
+	return file_sheetsrpc_sheets_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RowEvent) GetType() RowEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return RowEvent_TYPE_UNSPECIFIED
+}
+
+func (x *RowEvent) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *RowEvent) GetHeader() []string {
+	if x != nil {
+		return x.Header
+	}
+	return nil
+}
+
+func (x *RowEvent) GetOld() *Row {
+	if x != nil {
+		return x.Old
+	}
+	return nil
+}
+
+func (x *RowEvent) GetNew() *Row {
+	if x != nil {
+		return x.New
+	}
+	return nil
+}
+
+var File_sheetsrpc_sheets_proto protoreflect.FileDescriptor
+
+const file_sheetsrpc_sheets_proto_rawDesc = "" +
+	"\n" +
+	"\x16sheetsrpc/sheets.proto\x12\n" +
+	"gsheets.v1\"\x1d\n" +
+	"\x03Row\x12\x16\n" +
+	"\x06values\x18\x01 \x03(\tR\x06values\"J\n" +
+	"\vReadRequest\x12%\n" +
+	"\x0espreadsheet_id\x18\x01 \x01(\tR\rspreadsheetId\x12\x14\n" +
+	"\x05range\x18\x02 \x01(\tR\x05range\"I\n" +
+	"\fReadResponse\x12\x14\n" +
+	"\x05range\x18\x01 \x01(\tR\x05range\x12#\n" +
+	"\x04rows\x18\x02 \x03(\v2\x0f.gsheets.v1.RowR\x04rows\"\x82\x01\n" +
+	"\fWriteRequest\x12%\n" +
+	"\x0espreadsheet_id\x18\x01 \x01(\tR\rspreadsheetId\x12\x14\n" +
+	"\x05range\x18\x02 \x01(\tR\x05range\x12#\n" +
+	"\x04rows\x18\x03 \x03(\v2\x0f.gsheets.v1.RowR\x04rows\x12\x10\n" +
+	"\x03raw\x18\x04 \x01(\bR\x03raw\"|\n" +
+	"\rWriteResponse\x12#\n" +
+	"\rupdated_range\x18\x01 \x01(\tR\fupdatedRange\x12!\n" +
+	"\fupdated_rows\x18\x02 \x01(\x03R\vupdatedRows\x12#\n" +
+	"\rupdated_cells\x18\x03 \x01(\x03R\fupdatedCells\"\x95\x01\n" +
+	"\fWatchRequest\x12%\n" +
+	"\x0espreadsheet_id\x18\x01 \x01(\tR\rspreadsheetId\x12\x14\n" +
+	"\x05range\x18\x02 \x01(\tR\x05range\x12\x1d\n" +
+	"\n" +
+	"key_column\x18\x03 \x01(\tR\tkeyColumn\x12)\n" +
+	"\x10interval_seconds\x18\x04 \x01(\x05R\x0fintervalSeconds\"\xec\x01\n" +
+	"\bRowEvent\x12-\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x19.gsheets.v1.RowEvent.TypeR\x04type\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12\x16\n" +
+	"\x06header\x18\x03 \x03(\tR\x06header\x12!\n" +
+	"\x03old\x18\x04 \x01(\v2\x0f.gsheets.v1.RowR\x03old\x12!\n" +
+	"\x03new\x18\x05 \x01(\v2\x0f.gsheets.v1.RowR\x03new\"A\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\t\n" +
+	"\x05ADDED\x10\x01\x12\v\n" +
+	"\aCHANGED\x10\x02\x12\v\n" +
+	"\aDELETED\x10\x032\xfc\x01\n" +
+	"\x06Sheets\x129\n" +
+	"\x04Read\x12\x17.gsheets.v1.ReadRequest\x1a\x18.gsheets.v1.ReadResponse\x12=\n" +
+	"\x06Append\x12\x18.gsheets.v1.WriteRequest\x1a\x19.gsheets.v1.WriteResponse\x12=\n" +
+	"\x06Update\x12\x18.gsheets.v1.WriteRequest\x1a\x19.gsheets.v1.WriteResponse\x129\n" +
+	"\x05Watch\x12\x18.gsheets.v1.WatchRequest\x1a\x14.gsheets.v1.RowEvent0\x01B0Z.github.com/prantoran/GoogleSheets_GO/sheetsrpcb\x06proto3"
+
+var (
+	file_sheetsrpc_sheets_proto_rawDescOnce sync.Once
+	file_sheetsrpc_sheets_proto_rawDescData []byte
+)
+
+func file_sheetsrpc_sheets_proto_rawDescGZIP() []byte {
+	file_sheetsrpc_sheets_proto_rawDescOnce.Do(func() {
+		file_sheetsrpc_sheets_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_sheetsrpc_sheets_proto_rawDesc), len(file_sheetsrpc_sheets_proto_rawDesc)))
+	})
+	return file_sheetsrpc_sheets_proto_rawDescData
+}
+
+var file_sheetsrpc_sheets_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_sheetsrpc_sheets_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_sheetsrpc_sheets_proto_goTypes = []any{
+	(RowEvent_Type)(0),    // 0: gsheets.v1.RowEvent.Type
+	(*Row)(nil),           // 1: gsheets.v1.Row
+	(*ReadRequest)(nil),   // 2: gsheets.v1.ReadRequest
+	(*ReadResponse)(nil),  // 3: gsheets.v1.ReadResponse
+	(*WriteRequest)(nil),  // 4: gsheets.v1.WriteRequest
+	(*WriteResponse)(nil), // 5: gsheets.v1.WriteResponse
+	(*WatchRequest)(nil),  // 6: gsheets.v1.WatchRequest
+	(*RowEvent)(nil),      // 7: gsheets.v1.RowEvent
+}
+var file_sheetsrpc_sheets_proto_depIdxs = []int32{
+	1, // 0: gsheets.v1.ReadResponse.rows:type_name -> gsheets.v1.Row
+	1, // 1: gsheets.v1.WriteRequest.rows:type_name -> gsheets.v1.Row
+	0, // 2: gsheets.v1.RowEvent.type:type_name -> gsheets.v1.RowEvent.Type
+	1, // 3: gsheets.v1.RowEvent.old:type_name -> gsheets.v1.Row
+	1, // 4: gsheets.v1.RowEvent.new:type_name -> gsheets.v1.Row
+	2, // 5: gsheets.v1.Sheets.Read:input_type -> gsheets.v1.ReadRequest
+	4, // 6: gsheets.v1.Sheets.Append:input_type -> gsheets.v1.WriteRequest
+	4, // 7: gsheets.v1.Sheets.Update:input_type -> gsheets.v1.WriteRequest
+	6, // 8: gsheets.v1.Sheets.Watch:input_type -> gsheets.v1.WatchRequest
+	3, // 9: gsheets.v1.Sheets.Read:output_type -> gsheets.v1.ReadResponse
+	5, // 10: gsheets.v1.Sheets.Append:output_type -> gsheets.v1.WriteResponse
+	5, // 11: gsheets.v1.Sheets.Update:output_type -> gsheets.v1.WriteResponse
+	7, // 12: gsheets.v1.Sheets.Watch:output_type -> gsheets.v1.RowEvent
+	9, // [9:13] is the sub-list for method output_type
+	5, // [5:9] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_sheetsrpc_sheets_proto_init() }
+func file_sheetsrpc_sheets_proto_init() {
+	if File_sheetsrpc_sheets_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sheetsrpc_sheets_proto_rawDesc), len(file_sheetsrpc_sheets_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_sheetsrpc_sheets_proto_goTypes,
+		DependencyIndexes: file_sheetsrpc_sheets_proto_depIdxs,
+		EnumInfos:         file_sheetsrpc_sheets_proto_enumTypes,
+		MessageInfos:      file_sheetsrpc_sheets_proto_msgTypes,
+	}.Build()
+	File_sheetsrpc_sheets_proto = out.File
+	file_sheetsrpc_sheets_proto_goTypes = nil
+	file_sheetsrpc_sheets_proto_depIdxs = nil
+}
@@ -0,0 +1,372 @@
+package sheetstest
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tab is a tab of a fake spreadsheet.
+type Tab struct {
+	ID    int64           `json:"id"`
+	Title string          `json:"title"`
+	Rows  [][]interface{} `json:"rows"`
+	// RowCount and ColumnCount are the grid size, which grows as cells
+	// are written. They default to 1000 by 26 like new tabs in Sheets.
+	RowCount    int `json:"rowCount,omitempty"`
+	ColumnCount int `json:"columnCount,omitempty"`
+}
+
+// Spreadsheet is a fake spreadsheet.
+type Spreadsheet struct {
+	ID       string    `json:"id"`
+	Title    string    `json:"title"`
+	TimeZone string    `json:"timeZone,omitempty"`
+	Tabs     []*Tab    `json:"tabs"`
+	Modified time.Time `json:"modified"`
+	// NamedRanges maps names to the A1 ranges they name, e.g.
+	// "Prices": "Data!C2:C".
+	NamedRanges map[string]string `json:"namedRanges,omitempty"`
+}
+
+func (s *Spreadsheet) tab(title string) *Tab {
+	for _, t := range s.Tabs {
+		if t.Title == title {
+			return t
+		}
+	}
+	return nil
+}
+
+// region is a zero based, inclusive block of cells; a negative end is
+// open.
+type region struct {
+	tab            *Tab
+	r1, c1, r2, c2 int
+}
+
+var cellRE = regexp.MustCompile(`^\$?([A-Za-z]*)\$?([0-9]*)$`)
+
+// resolve parses an A1 range such as "Tab!A1:C", "Tab" or "A1:B2", the
+// latter on the first tab, or the name of a named range.
+func (s *Spreadsheet) resolve(rng string) (*region, error) {
+	if named, ok := s.NamedRanges[rng]; ok {
+		rng = named
+	}
+	tabName, cells := "", rng
+	if i := strings.LastIndex(rng, "!"); i >= 0 {
+		tabName, cells = rng[:i], rng[i+1:]
+	} else if s.tab(unquote(rng)) != nil {
+		tabName, cells = rng, ""
+	}
+	tabName = unquote(tabName)
+	var t *Tab
+	if tabName == "" {
+		if len(s.Tabs) == 0 {
+			return nil, fmt.Errorf("Unable to parse range: %s", rng)
+		}
+		t = s.Tabs[0]
+	} else if t = s.tab(tabName); t == nil {
+		return nil, fmt.Errorf("Unable to parse range: %s", rng)
+	}
+	reg := &region{tab: t, r2: -1, c2: -1}
+	if cells == "" {
+		return reg, nil
+	}
+	parts := strings.SplitN(cells, ":", 2)
+	parse := func(p string) (row, col int, err error) {
+		m := cellRE.FindStringSubmatch(p)
+		if m == nil || p == "" {
+			return 0, 0, fmt.Errorf("Unable to parse range: %s", rng)
+		}
+		row, col = -1, -1
+		if m[1] != "" {
+			col = 0
+			for _, c := range strings.ToUpper(m[1]) {
+				col = col*26 + int(c-'A'+1)
+			}
+			col--
+		}
+		if m[2] != "" {
+			n, _ := strconv.Atoi(m[2])
+			if n < 1 {
+				return 0, 0, fmt.Errorf("Unable to parse range: %s", rng)
+			}
+			row = n - 1
+		}
+		return row, col, nil
+	}
+	r1, c1, err := parse(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	r2, c2 := r1, c1
+	if len(parts) == 2 {
+		if r2, c2, err = parse(parts[1]); err != nil {
+			return nil, err
+		}
+	}
+	reg.r1, reg.c1, reg.r2, reg.c2 = max0(r1), max0(c1), r2, c2
+	if r1 < 0 {
+		reg.r2 = -1
+	}
+	if c1 < 0 {
+		reg.c2 = -1
+	}
+	return reg, nil
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1)
+	}
+	return s
+}
+
+func max0(i int) int {
+	if i < 0 {
+		return 0
+	}
+	return i
+}
+
+// a1 formats a block of tab as the API does, e.g. "Sheet1!A1:C3". The
+// fake has its own A1 helpers rather than those of sheetsclient, whose
+// tests use the fake.
+func a1(t *Tab, r1, c1, r2, c2 int) string {
+	name := t.Title
+	if !regexp.MustCompile(`^[A-Za-z0-9_]+$`).MatchString(name) {
+		name = "'" + strings.Replace(name, "'", "''", -1) + "'"
+	}
+	return fmt.Sprintf("%s!%s%d:%s%d", name, columnName(c1), r1+1, columnName(c2), r2+1)
+}
+
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// bounds returns the end of reg, clipping open ends to the data or, when
+// the tab is empty, the start.
+func (reg *region) bounds() (r2, c2 int) {
+	r2, c2 = reg.r2, reg.c2
+	if r2 < 0 {
+		r2 = len(reg.tab.Rows) - 1
+	}
+	if c2 < 0 {
+		for _, row := range reg.tab.Rows {
+			if len(row)-1 > c2 {
+				c2 = len(row) - 1
+			}
+		}
+	}
+	if r2 < reg.r1 {
+		r2 = reg.r1
+	}
+	if c2 < reg.c1 {
+		c2 = reg.c1
+	}
+	return r2, c2
+}
+
+// read returns the values of reg with trailing empty cells and rows
+// removed, and the range they were read from.
+func (reg *region) read() ([][]interface{}, string) {
+	r2, c2 := reg.bounds()
+	var out [][]interface{}
+	for r := reg.r1; r <= r2; r++ {
+		var row []interface{}
+		if r < len(reg.tab.Rows) {
+			src := reg.tab.Rows[r]
+			for c := reg.c1; c <= c2 && c < len(src); c++ {
+				row = append(row, src[c])
+			}
+		}
+		for len(row) > 0 && empty(row[len(row)-1]) {
+			row = row[:len(row)-1]
+		}
+		if row == nil {
+			row = []interface{}{}
+		}
+		out = append(out, row)
+	}
+	for len(out) > 0 && len(out[len(out)-1]) == 0 {
+		out = out[:len(out)-1]
+	}
+	return out, a1(reg.tab, reg.r1, reg.c1, r2, c2)
+}
+
+// write stores values starting at the top left of reg and returns the
+// range written.
+func (reg *region) write(values [][]interface{}) (string, int, int, int) {
+	t := reg.tab
+	rows, cols, cells := len(values), 0, 0
+	for i, row := range values {
+		r := reg.r1 + i
+		for len(t.Rows) <= r {
+			t.Rows = append(t.Rows, nil)
+		}
+		for j, v := range row {
+			c := reg.c1 + j
+			for len(t.Rows[r]) <= c {
+				t.Rows[r] = append(t.Rows[r], "")
+			}
+			t.Rows[r][c] = v
+			cells++
+		}
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+	if rows == 0 || cols == 0 {
+		return a1(t, reg.r1, reg.c1, reg.r1, reg.c1), 0, 0, 0
+	}
+	t.grow(reg.r1+rows, reg.c1+cols)
+	return a1(t, reg.r1, reg.c1, reg.r1+rows-1, reg.c1+cols-1), rows, cols, cells
+}
+
+// clear empties the cells of reg.
+func (reg *region) clear() string {
+	r2, c2 := reg.bounds()
+	for r := reg.r1; r <= r2 && r < len(reg.tab.Rows); r++ {
+		row := reg.tab.Rows[r]
+		for c := reg.c1; c <= c2 && c < len(row); c++ {
+			row[c] = ""
+		}
+	}
+	reg.tab.trim()
+	return a1(reg.tab, reg.r1, reg.c1, r2, c2)
+}
+
+// lastRow returns the last row of reg's columns holding data, or -1.
+func (reg *region) lastRow() int {
+	for r := len(reg.tab.Rows) - 1; r >= reg.r1; r-- {
+		row := reg.tab.Rows[r]
+		for c := reg.c1; c < len(row) && (reg.c2 < 0 || c <= reg.c2); c++ {
+			if !empty(row[c]) {
+				return r
+			}
+		}
+	}
+	return -1
+}
+
+func (t *Tab) grow(rows, cols int) {
+	t.defaults()
+	if rows > t.RowCount {
+		t.RowCount = rows
+	}
+	if cols > t.ColumnCount {
+		t.ColumnCount = cols
+	}
+}
+
+func (t *Tab) defaults() {
+	if t.RowCount == 0 {
+		t.RowCount = 1000
+	}
+	if t.ColumnCount == 0 {
+		t.ColumnCount = 26
+	}
+	if len(t.Rows) > t.RowCount {
+		t.RowCount = len(t.Rows)
+	}
+	for _, row := range t.Rows {
+		if len(row) > t.ColumnCount {
+			t.ColumnCount = len(row)
+		}
+	}
+}
+
+// deleteCells deletes the zero based rows r1 to r2 of the columns c1 to
+// c2, exclusive ends that are open when 0, shifting the cells below up.
+func (t *Tab) deleteCells(r1, r2, c1, c2 int) {
+	if r2 <= 0 || r2 > len(t.Rows) {
+		r2 = len(t.Rows)
+	}
+	if c2 <= 0 {
+		for _, row := range t.Rows {
+			if len(row) > c2 {
+				c2 = len(row)
+			}
+		}
+	}
+	n := r2 - r1
+	for r := r1; r < len(t.Rows); r++ {
+		for c := c1; c < c2; c++ {
+			var v interface{} = ""
+			if r+n < len(t.Rows) && c < len(t.Rows[r+n]) {
+				v = t.Rows[r+n][c]
+			}
+			for len(t.Rows[r]) <= c && !empty(v) {
+				t.Rows[r] = append(t.Rows[r], "")
+			}
+			if c < len(t.Rows[r]) {
+				t.Rows[r][c] = v
+			}
+		}
+	}
+	t.trim()
+}
+
+// trim drops trailing empty cells and rows.
+func (t *Tab) trim() {
+	for i, row := range t.Rows {
+		for len(row) > 0 && empty(row[len(row)-1]) {
+			row = row[:len(row)-1]
+		}
+		t.Rows[i] = row
+	}
+	for len(t.Rows) > 0 && len(t.Rows[len(t.Rows)-1]) == 0 {
+		t.Rows = t.Rows[:len(t.Rows)-1]
+	}
+}
+
+func empty(v interface{}) bool { return v == nil || v == "" }
+
+// render converts a stored value for a response. Formatted values are
+// strings, as in the API.
+func render(v interface{}, option string) interface{} {
+	if option == "UNFORMATTED_VALUE" || option == "FORMULA" {
+		if s, ok := v.(string); ok && option == "UNFORMATTED_VALUE" && strings.HasPrefix(s, "=") {
+			// The fake does not evaluate formulas.
+			return s
+		}
+		return v
+	}
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// parseInput converts a written value as the given value input option
+// does: USER_ENTERED turns numeric and boolean text into numbers and
+// booleans, RAW stores strings as they are.
+func parseInput(v interface{}, option string) interface{} {
+	if s, ok := v.(string); ok && option == "USER_ENTERED" {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+			return f
+		}
+		switch strings.ToUpper(s) {
+		case "TRUE":
+			return true
+		case "FALSE":
+			return false
+		}
+	}
+	return v
+}
@@ -0,0 +1,784 @@
+// Package sheetstest provides an in-process fake of the subset of the
+// Sheets and Drive APIs this module uses, for fast hermetic tests without
+// credentials:
+//
+//	srv := sheetstest.NewServer()
+//	defer srv.Close()
+//	srv.Seed(&sheetstest.Spreadsheet{ID: "sheet1", Tabs: []*sheetstest.Tab{
+//		{Title: "Data", Rows: [][]interface{}{{"Name", "Qty"}, {"a", 1.0}}},
+//	}})
+//	svc, _ := srv.SheetsService(ctx)
+//	// code under test uses svc ...
+//	if srv.Count("values.append") != 1 { ... }
+//
+// Formulas are stored but not evaluated, and formatting, field masks and
+// most batch update requests are ignored or rejected.
+package sheetstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+)
+
+// Request is a request received by the server.
+type Request struct {
+	// Op names the API method, e.g. "values.get", "values.append",
+	// "spreadsheets.batchUpdate" or "drive.files.get".
+	Op     string
+	Method string
+	Path   string
+	Query  url.Values
+	Body   []byte
+}
+
+// Server is a fake Sheets and Drive API server.
+type Server struct {
+	URL string
+
+	srv      *httptest.Server
+	mu       sync.Mutex
+	sheets   map[string]*Spreadsheet
+	requests []*Request
+	failures map[string][]failure
+	nextID   int
+	now      func() time.Time
+}
+
+type failure struct {
+	code    int
+	message string
+}
+
+// NewServer starts a server with no spreadsheets.
+func NewServer() *Server {
+	s := &Server{sheets: map[string]*Spreadsheet{}, failures: map[string][]failure{}, now: time.Now}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serve))
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() { s.srv.Close() }
+
+// Client returns an HTTP client for the server.
+func (s *Server) Client() *http.Client { return s.srv.Client() }
+
+// SheetsService returns a Sheets client talking to the server.
+func (s *Server) SheetsService(ctx context.Context) (*sheets.Service, error) {
+	return sheets.NewService(ctx, option.WithEndpoint(s.URL+"/"), option.WithHTTPClient(s.Client()))
+}
+
+// DriveService returns a Drive client talking to the server.
+func (s *Server) DriveService(ctx context.Context) (*drive.Service, error) {
+	return drive.NewService(ctx, option.WithEndpoint(s.URL+"/drive/v3/"), option.WithHTTPClient(s.Client()))
+}
+
+// Seed adds or replaces spreadsheets. Tabs without an ID get their index,
+// or the next ID not used by another tab.
+func (s *Server) Seed(sheets ...*Spreadsheet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sh := range sheets {
+		// The first tab without an ID keeps 0, like the first tab of a
+		// new spreadsheet.
+		used := map[int64]bool{}
+		for i, t := range sh.Tabs {
+			if t.ID != 0 || i == 0 {
+				used[t.ID] = true
+			}
+		}
+		for i, t := range sh.Tabs {
+			if t.ID == 0 && i > 0 {
+				id := int64(i)
+				for used[id] {
+					id++
+				}
+				t.ID, used[id] = id, true
+			}
+			t.defaults()
+		}
+		if sh.Title == "" {
+			sh.Title = "Untitled spreadsheet"
+		}
+		if sh.TimeZone == "" {
+			sh.TimeZone = "Etc/GMT"
+		}
+		if sh.Modified.IsZero() {
+			sh.Modified = s.now()
+		}
+		s.sheets[sh.ID] = sh
+	}
+}
+
+// LoadFixture seeds the spreadsheets of a JSON file holding a list of
+// Spreadsheet objects.
+func (s *Server) LoadFixture(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var list []*Spreadsheet
+	if err := json.Unmarshal(b, &list); err != nil {
+		return fmt.Errorf("sheetstest: unable to parse %s: %w", path, err)
+	}
+	s.Seed(list...)
+	return nil
+}
+
+// Values returns a copy of the stored values of a tab, or nil if there is
+// no such tab.
+func (s *Server) Values(spreadsheetID, tab string) [][]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sh := s.sheets[spreadsheetID]
+	if sh == nil || sh.tab(tab) == nil {
+		return nil
+	}
+	var out [][]interface{}
+	for _, row := range sh.tab(tab).Rows {
+		out = append(out, append([]interface{}{}, row...))
+	}
+	return out
+}
+
+// Spreadsheet returns a stored spreadsheet, for inspection.
+func (s *Server) Spreadsheet(id string) *Spreadsheet {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sheets[id]
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []*Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Request(nil), s.requests...)
+}
+
+// Count returns the number of requests received for op.
+func (s *Server) Count(op string) int {
+	n := 0
+	for _, r := range s.Requests() {
+		if r.Op == op {
+			n++
+		}
+	}
+	return n
+}
+
+// Reset forgets the recorded requests.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+// Fail makes the next times requests for op fail with the given HTTP
+// status, e.g. Fail("values.get", 429, 2) to exercise retries.
+func (s *Server) Fail(op string, code, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < times; i++ {
+		s.failures[op] = append(s.failures[op], failure{code, http.StatusText(code)})
+	}
+}
+
+// apiError is returned by handlers to produce a Google API error response.
+type apiError struct {
+	code    int
+	message string
+}
+
+func (e *apiError) Error() string { return e.message }
+
+func errorf(code int, format string, args ...interface{}) *apiError {
+	return &apiError{code, fmt.Sprintf(format, args...)}
+}
+
+var statuses = map[int]string{
+	400: "INVALID_ARGUMENT", 401: "UNAUTHENTICATED", 403: "PERMISSION_DENIED", 404: "NOT_FOUND",
+	409: "ALREADY_EXISTS", 429: "RESOURCE_EXHAUSTED", 500: "INTERNAL", 501: "UNIMPLEMENTED", 503: "UNAVAILABLE",
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	path := r.URL.EscapedPath()
+	op, args := route(r.Method, path)
+	s.mu.Lock()
+	s.requests = append(s.requests, &Request{Op: op, Method: r.Method, Path: path, Query: r.URL.Query(), Body: body})
+	var resp interface{}
+	var err *apiError
+	if fs := s.failures[op]; len(fs) > 0 {
+		s.failures[op] = fs[1:]
+		err = &apiError{fs[0].code, fs[0].message}
+	} else if op == "" {
+		err = errorf(404, "sheetstest: no fake for %s %s", r.Method, path)
+	} else {
+		resp, err = s.handle(op, args, r.URL.Query(), body)
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		w.WriteHeader(err.code)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]interface{}{
+			"code": err.code, "message": err.message, "status": statuses[err.code],
+		}})
+		return
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// route maps a request to an op and its path arguments: the spreadsheet
+// or file ID, and the range.
+func route(method, path string) (string, []string) {
+	unescape := func(s string) string {
+		u, err := url.PathUnescape(s)
+		if err != nil {
+			return s
+		}
+		return u
+	}
+	if strings.HasPrefix(path, "/drive/v3/files") {
+		rest := strings.TrimPrefix(path, "/drive/v3/files")
+		switch {
+		case rest == "" && method == "GET":
+			return "drive.files.list", nil
+		case strings.HasPrefix(rest, "/") && method == "GET" && !strings.Contains(rest[1:], "/"):
+			return "drive.files.get", []string{unescape(rest[1:])}
+		}
+		return "", nil
+	}
+	if !strings.HasPrefix(path, "/v4/spreadsheets") {
+		return "", nil
+	}
+	rest := strings.TrimPrefix(path, "/v4/spreadsheets")
+	if rest == "" && method == "POST" {
+		return "spreadsheets.create", nil
+	}
+	rest = strings.TrimPrefix(rest, "/")
+	i := strings.Index(rest, "/")
+	if i < 0 {
+		switch {
+		case strings.HasSuffix(rest, ":batchUpdate") && method == "POST":
+			return "spreadsheets.batchUpdate", []string{unescape(strings.TrimSuffix(rest, ":batchUpdate"))}
+		case method == "GET":
+			return "spreadsheets.get", []string{unescape(rest)}
+		}
+		return "", nil
+	}
+	id, rest := unescape(rest[:i]), rest[i+1:]
+	switch {
+	case rest == "values:batchGet" && method == "GET":
+		return "values.batchGet", []string{id}
+	case rest == "values:batchUpdate" && method == "POST":
+		return "values.batchUpdate", []string{id}
+	case rest == "values:batchClear" && method == "POST":
+		return "values.batchClear", []string{id}
+	case !strings.HasPrefix(rest, "values/"):
+		return "", nil
+	}
+	rng := strings.TrimPrefix(rest, "values/")
+	switch {
+	case strings.HasSuffix(rng, ":append") && method == "POST":
+		return "values.append", []string{id, unescape(strings.TrimSuffix(rng, ":append"))}
+	case strings.HasSuffix(rng, ":clear") && method == "POST":
+		return "values.clear", []string{id, unescape(strings.TrimSuffix(rng, ":clear"))}
+	case method == "GET":
+		return "values.get", []string{id, unescape(rng)}
+	case method == "PUT":
+		return "values.update", []string{id, unescape(rng)}
+	}
+	return "", nil
+}
+
+func (s *Server) handle(op string, args []string, q url.Values, body []byte) (interface{}, *apiError) {
+	decode := func(v interface{}) *apiError {
+		if err := json.Unmarshal(body, v); err != nil {
+			return errorf(400, "Invalid JSON payload: %v", err)
+		}
+		return nil
+	}
+	switch op {
+	case "spreadsheets.create":
+		var req sheets.Spreadsheet
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+		return s.create(&req), nil
+	case "drive.files.list":
+		return s.listFiles(), nil
+	}
+
+	sh := s.sheets[args[0]]
+	if sh == nil {
+		return nil, errorf(404, "Requested entity was not found.")
+	}
+	switch op {
+	case "drive.files.get":
+		return file(sh), nil
+	case "spreadsheets.get":
+		out := metadata(sh, q.Get("includeGridData") == "true")
+		if ranges := q["ranges"]; len(ranges) > 0 {
+			// Keep the tabs of the ranges; grid data is not cut to the
+			// cells of the ranges.
+			keep := map[*Tab]bool{}
+			for _, rng := range ranges {
+				reg, err := sh.resolve(rng)
+				if err != nil {
+					return nil, errorf(400, "%v", err)
+				}
+				keep[reg.tab] = true
+			}
+			var kept []*sheets.Sheet
+			for i, t := range sh.Tabs {
+				if keep[t] {
+					kept = append(kept, out.Sheets[i])
+				}
+			}
+			out.Sheets = kept
+		}
+		return out, nil
+	case "spreadsheets.batchUpdate":
+		var req sheets.BatchUpdateSpreadsheetRequest
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+		return s.batchUpdate(sh, &req)
+	case "values.get":
+		return getValues(sh, args[1], q)
+	case "values.batchGet":
+		resp := &sheets.BatchGetValuesResponse{SpreadsheetId: sh.ID}
+		for _, rng := range q["ranges"] {
+			vr, err := getValues(sh, rng, q)
+			if err != nil {
+				return nil, err
+			}
+			resp.ValueRanges = append(resp.ValueRanges, vr)
+		}
+		return resp, nil
+	case "values.update":
+		var vr sheets.ValueRange
+		if err := decode(&vr); err != nil {
+			return nil, err
+		}
+		return s.update(sh, args[1], &vr, q.Get("valueInputOption"))
+	case "values.batchUpdate":
+		var req sheets.BatchUpdateValuesRequest
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+		resp := &sheets.BatchUpdateValuesResponse{SpreadsheetId: sh.ID}
+		for _, vr := range req.Data {
+			u, err := s.update(sh, vr.Range, vr, req.ValueInputOption)
+			if err != nil {
+				return nil, err
+			}
+			resp.Responses = append(resp.Responses, u)
+			resp.TotalUpdatedCells += u.UpdatedCells
+			resp.TotalUpdatedRows += u.UpdatedRows
+		}
+		return resp, nil
+	case "values.append":
+		var vr sheets.ValueRange
+		if err := decode(&vr); err != nil {
+			return nil, err
+		}
+		return s.append(sh, args[1], &vr, q.Get("valueInputOption"))
+	case "values.clear":
+		reg, err := sh.resolve(args[1])
+		if err != nil {
+			return nil, errorf(400, "%v", err)
+		}
+		sh.Modified = s.now()
+		return &sheets.ClearValuesResponse{SpreadsheetId: sh.ID, ClearedRange: reg.clear()}, nil
+	case "values.batchClear":
+		var req sheets.BatchClearValuesRequest
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+		resp := &sheets.BatchClearValuesResponse{SpreadsheetId: sh.ID}
+		for _, rng := range req.Ranges {
+			reg, err := sh.resolve(rng)
+			if err != nil {
+				return nil, errorf(400, "%v", err)
+			}
+			resp.ClearedRanges = append(resp.ClearedRanges, reg.clear())
+		}
+		sh.Modified = s.now()
+		return resp, nil
+	}
+	return nil, errorf(501, "sheetstest: %s is not implemented", op)
+}
+
+func getValues(sh *Spreadsheet, rng string, q url.Values) (*sheets.ValueRange, *apiError) {
+	reg, err := sh.resolve(rng)
+	if err != nil {
+		return nil, errorf(400, "%v", err)
+	}
+	values, read := reg.read()
+	option := q.Get("valueRenderOption")
+	for _, row := range values {
+		for i, v := range row {
+			row[i] = render(v, option)
+		}
+	}
+	vr := &sheets.ValueRange{Range: read, MajorDimension: "ROWS", Values: values}
+	if q.Get("majorDimension") == "COLUMNS" {
+		vr.MajorDimension, vr.Values = "COLUMNS", transpose(values)
+	}
+	return vr, nil
+}
+
+func (s *Server) update(sh *Spreadsheet, rng string, vr *sheets.ValueRange, option string) (*sheets.UpdateValuesResponse, *apiError) {
+	if option == "" {
+		return nil, errorf(400, "'valueInputOption' is required but not specified")
+	}
+	reg, err := sh.resolve(rng)
+	if err != nil {
+		return nil, errorf(400, "%v", err)
+	}
+	values := vr.Values
+	if vr.MajorDimension == "COLUMNS" {
+		values = transpose(values)
+	}
+	for _, row := range values {
+		for i, v := range row {
+			row[i] = parseInput(v, option)
+		}
+	}
+	written, rows, cols, cells := reg.write(values)
+	sh.Modified = s.now()
+	return &sheets.UpdateValuesResponse{
+		SpreadsheetId:  sh.ID,
+		UpdatedRange:   written,
+		UpdatedRows:    int64(rows),
+		UpdatedColumns: int64(cols),
+		UpdatedCells:   int64(cells),
+	}, nil
+}
+
+func (s *Server) append(sh *Spreadsheet, rng string, vr *sheets.ValueRange, option string) (*sheets.AppendValuesResponse, *apiError) {
+	reg, err := sh.resolve(rng)
+	if err != nil {
+		return nil, errorf(400, "%v", err)
+	}
+	// Values go below the last row holding data in the range's columns.
+	at := *reg
+	at.r1 = reg.lastRow() + 1
+	if at.r1 < reg.r1 {
+		at.r1 = reg.r1
+	}
+	_, table := reg.read()
+	u, apiErr := s.update(sh, a1(at.tab, at.r1, at.c1, at.r1, at.c1), vr, option)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return &sheets.AppendValuesResponse{SpreadsheetId: sh.ID, TableRange: table, Updates: u}, nil
+}
+
+func (s *Server) create(req *sheets.Spreadsheet) *sheets.Spreadsheet {
+	s.nextID++
+	sh := &Spreadsheet{ID: fmt.Sprintf("fake-spreadsheet-%d", s.nextID)}
+	if req.Properties != nil {
+		sh.Title, sh.TimeZone = req.Properties.Title, req.Properties.TimeZone
+	}
+	for i, t := range req.Sheets {
+		tab := &Tab{}
+		if t.Properties != nil {
+			tab.Title = t.Properties.Title
+			if t.Properties.SheetId != 0 {
+				tab.ID = t.Properties.SheetId
+			}
+		}
+		if tab.Title == "" {
+			tab.Title = fmt.Sprintf("Sheet%d", i+1)
+		}
+		sh.Tabs = append(sh.Tabs, tab)
+	}
+	if len(sh.Tabs) == 0 {
+		sh.Tabs = []*Tab{{Title: "Sheet1"}}
+	}
+	s.mu.Unlock()
+	s.Seed(sh)
+	s.mu.Lock()
+	return metadata(sh, false)
+}
+
+func (s *Server) batchUpdate(sh *Spreadsheet, req *sheets.BatchUpdateSpreadsheetRequest) (*sheets.BatchUpdateSpreadsheetResponse, *apiError) {
+	resp := &sheets.BatchUpdateSpreadsheetResponse{SpreadsheetId: sh.ID}
+	byID := func(id int64) *Tab {
+		for _, t := range sh.Tabs {
+			if t.ID == id {
+				return t
+			}
+		}
+		return nil
+	}
+	for _, r := range req.Requests {
+		reply := &sheets.Response{}
+		switch {
+		case r.AddSheet != nil:
+			p := r.AddSheet.Properties
+			if p == nil {
+				p = &sheets.SheetProperties{}
+			}
+			if sh.tab(p.Title) != nil {
+				return nil, errorf(400, "Invalid requests[0].addSheet: A sheet with the name %q already exists.", p.Title)
+			}
+			t := &Tab{ID: p.SheetId, Title: p.Title}
+			if t.ID == 0 {
+				for _, o := range sh.Tabs {
+					if o.ID >= t.ID {
+						t.ID = o.ID + 1
+					}
+				}
+			}
+			if t.Title == "" {
+				t.Title = fmt.Sprintf("Sheet%d", len(sh.Tabs)+1)
+			}
+			if g := p.GridProperties; g != nil {
+				t.RowCount, t.ColumnCount = int(g.RowCount), int(g.ColumnCount)
+			}
+			t.defaults()
+			sh.Tabs = append(sh.Tabs, t)
+			reply.AddSheet = &sheets.AddSheetResponse{Properties: properties(t, len(sh.Tabs)-1)}
+		case r.DeleteSheet != nil:
+			t := byID(r.DeleteSheet.SheetId)
+			if t == nil {
+				return nil, errorf(400, "No sheet with id: %d", r.DeleteSheet.SheetId)
+			}
+			for i, o := range sh.Tabs {
+				if o == t {
+					sh.Tabs = append(sh.Tabs[:i], sh.Tabs[i+1:]...)
+					break
+				}
+			}
+		case r.UpdateSheetProperties != nil:
+			p := r.UpdateSheetProperties.Properties
+			t := byID(p.SheetId)
+			if t == nil {
+				return nil, errorf(400, "No sheet with id: %d", p.SheetId)
+			}
+			if p.Title != "" {
+				t.Title = p.Title
+			}
+			if g := p.GridProperties; g != nil && g.RowCount > 0 {
+				t.RowCount = int(g.RowCount)
+			}
+			if g := p.GridProperties; g != nil && g.ColumnCount > 0 {
+				t.ColumnCount = int(g.ColumnCount)
+			}
+		case r.DuplicateSheet != nil:
+			d := r.DuplicateSheet
+			src := byID(d.SourceSheetId)
+			if src == nil {
+				return nil, errorf(400, "No sheet with id: %d", d.SourceSheetId)
+			}
+			t := &Tab{ID: d.NewSheetId, Title: d.NewSheetName, RowCount: src.RowCount, ColumnCount: src.ColumnCount}
+			if t.Title == "" {
+				t.Title = "Copy of " + src.Title
+			}
+			if sh.tab(t.Title) != nil {
+				return nil, errorf(400, "Invalid requests[0].duplicateSheet: A sheet with the name %q already exists.", t.Title)
+			}
+			if t.ID == 0 {
+				for _, o := range sh.Tabs {
+					if o.ID >= t.ID {
+						t.ID = o.ID + 1
+					}
+				}
+			}
+			for _, row := range src.Rows {
+				t.Rows = append(t.Rows, append([]interface{}(nil), row...))
+			}
+			i := len(sh.Tabs)
+			if int(d.InsertSheetIndex) < i {
+				i = int(d.InsertSheetIndex)
+			}
+			sh.Tabs = append(sh.Tabs[:i], append([]*Tab{t}, sh.Tabs[i:]...)...)
+			reply.DuplicateSheet = &sheets.DuplicateSheetResponse{Properties: properties(t, i)}
+		case r.AppendDimension != nil:
+			t := byID(r.AppendDimension.SheetId)
+			if t == nil {
+				return nil, errorf(400, "No sheet with id: %d", r.AppendDimension.SheetId)
+			}
+			if r.AppendDimension.Dimension == "COLUMNS" {
+				t.ColumnCount += int(r.AppendDimension.Length)
+			} else {
+				t.RowCount += int(r.AppendDimension.Length)
+			}
+		case r.DeleteDimension != nil && r.DeleteDimension.Range.Dimension == "ROWS":
+			d := r.DeleteDimension.Range
+			t := byID(d.SheetId)
+			if t == nil {
+				return nil, errorf(400, "No sheet with id: %d", d.SheetId)
+			}
+			start, end := int(d.StartIndex), int(d.EndIndex)
+			if end > len(t.Rows) {
+				end = len(t.Rows)
+			}
+			if start < end {
+				t.Rows = append(t.Rows[:start], t.Rows[end:]...)
+			}
+			t.RowCount -= int(d.EndIndex - d.StartIndex)
+		case r.DeleteRange != nil && r.DeleteRange.ShiftDimension == "ROWS":
+			g := r.DeleteRange.Range
+			t := byID(g.SheetId)
+			if t == nil {
+				return nil, errorf(400, "No sheet with id: %d", g.SheetId)
+			}
+			t.deleteCells(int(g.StartRowIndex), int(g.EndRowIndex), int(g.StartColumnIndex), int(g.EndColumnIndex))
+		case r.InsertDimension != nil && r.InsertDimension.Range.Dimension == "ROWS":
+			d := r.InsertDimension.Range
+			t := byID(d.SheetId)
+			if t == nil {
+				return nil, errorf(400, "No sheet with id: %d", d.SheetId)
+			}
+			n := int(d.EndIndex - d.StartIndex)
+			if int(d.StartIndex) < len(t.Rows) {
+				rows := append(make([][]interface{}, n), t.Rows[d.StartIndex:]...)
+				t.Rows = append(t.Rows[:d.StartIndex], rows...)
+			}
+			t.RowCount += n
+		default:
+			b, _ := json.Marshal(r)
+			return nil, errorf(501, "sheetstest: unsupported batch update request %s", bytes.TrimSpace(b))
+		}
+		resp.Replies = append(resp.Replies, reply)
+	}
+	sh.Modified = s.now()
+	return resp, nil
+}
+
+func properties(t *Tab, index int) *sheets.SheetProperties {
+	t.defaults()
+	return &sheets.SheetProperties{
+		SheetId:   t.ID,
+		Title:     t.Title,
+		Index:     int64(index),
+		SheetType: "GRID",
+		GridProperties: &sheets.GridProperties{
+			RowCount:    int64(t.RowCount),
+			ColumnCount: int64(t.ColumnCount),
+		},
+		ForceSendFields: []string{"SheetId", "Index"},
+	}
+}
+
+func metadata(sh *Spreadsheet, gridData bool) *sheets.Spreadsheet {
+	out := &sheets.Spreadsheet{
+		SpreadsheetId:  sh.ID,
+		SpreadsheetUrl: "https://docs.google.com/spreadsheets/d/" + sh.ID + "/edit",
+		Properties:     &sheets.SpreadsheetProperties{Title: sh.Title, TimeZone: sh.TimeZone, Locale: "en_US"},
+	}
+	for i, t := range sh.Tabs {
+		s := &sheets.Sheet{Properties: properties(t, i)}
+		if gridData {
+			data := &sheets.GridData{}
+			for _, row := range t.Rows {
+				rd := &sheets.RowData{}
+				for _, v := range row {
+					rd.Values = append(rd.Values, cellData(v))
+				}
+				data.RowData = append(data.RowData, rd)
+			}
+			s.Data = []*sheets.GridData{data}
+		}
+		out.Sheets = append(out.Sheets, s)
+	}
+	for name, rng := range sh.NamedRanges {
+		reg, err := sh.resolve(rng)
+		if err != nil {
+			continue
+		}
+		g := &sheets.GridRange{SheetId: reg.tab.ID, StartRowIndex: int64(reg.r1), StartColumnIndex: int64(reg.c1)}
+		if reg.r2 >= 0 {
+			g.EndRowIndex = int64(reg.r2 + 1)
+		}
+		if reg.c2 >= 0 {
+			g.EndColumnIndex = int64(reg.c2 + 1)
+		}
+		out.NamedRanges = append(out.NamedRanges, &sheets.NamedRange{NamedRangeId: name, Name: name, Range: g})
+	}
+	sort.Slice(out.NamedRanges, func(i, j int) bool { return out.NamedRanges[i].Name < out.NamedRanges[j].Name })
+	return out
+}
+
+func cellData(v interface{}) *sheets.CellData {
+	c := &sheets.CellData{FormattedValue: render(v, "FORMATTED_VALUE").(string)}
+	ev := &sheets.ExtendedValue{}
+	switch v := v.(type) {
+	case float64:
+		ev.NumberValue = &v
+	case bool:
+		ev.BoolValue, ev.ForceSendFields = &v, []string{"BoolValue"}
+	case string:
+		if v == "" {
+			return &sheets.CellData{}
+		}
+		if strings.HasPrefix(v, "=") {
+			c.UserEnteredValue = &sheets.ExtendedValue{FormulaValue: &v}
+			return c
+		}
+		ev.StringValue = &v
+	default:
+		return &sheets.CellData{}
+	}
+	c.UserEnteredValue, c.EffectiveValue = ev, ev
+	return c
+}
+
+func file(sh *Spreadsheet) *drive.File {
+	return &drive.File{
+		Id:           sh.ID,
+		Name:         sh.Title,
+		MimeType:     "application/vnd.google-apps.spreadsheet",
+		ModifiedTime: sh.Modified.UTC().Format(time.RFC3339Nano),
+		WebViewLink:  "https://docs.google.com/spreadsheets/d/" + sh.ID + "/edit",
+		Version:      sh.Modified.UnixNano(),
+	}
+}
+
+func (s *Server) listFiles() *drive.FileList {
+	ids := make([]string, 0, len(s.sheets))
+	for id := range s.sheets {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	list := &drive.FileList{}
+	for _, id := range ids {
+		list.Files = append(list.Files, file(s.sheets[id]))
+	}
+	return list
+}
+
+func transpose(values [][]interface{}) [][]interface{} {
+	var out [][]interface{}
+	for r, row := range values {
+		for c, v := range row {
+			for len(out) <= c {
+				out = append(out, nil)
+			}
+			for len(out[c]) < r {
+				out[c] = append(out[c], "")
+			}
+			out[c] = append(out[c], v)
+		}
+	}
+	return out
+}
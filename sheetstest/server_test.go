@@ -0,0 +1,188 @@
+package sheetstest
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/sheets/v4"
+)
+
+func newService(t *testing.T, seed ...*Spreadsheet) (*Server, *sheets.Service) {
+	srv := NewServer()
+	t.Cleanup(srv.Close)
+	srv.Seed(seed...)
+	svc, err := srv.SheetsService(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, svc
+}
+
+func TestSeedIDs(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Seed(&Spreadsheet{ID: "s", Tabs: []*Tab{
+		{Title: "A"},
+		{Title: "B"},
+		{Title: "C", ID: 1},
+		{Title: "D"},
+		{Title: "E", ID: 4},
+	}})
+	var got []int64
+	for _, tab := range srv.Spreadsheet("s").Tabs {
+		got = append(got, tab.ID)
+	}
+	if want := []int64{0, 2, 1, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("seeded tab IDs = %v, want %v", got, want)
+	}
+
+	svc, err := srv.SheetsService(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss, err := svc.Spreadsheets.Create(&sheets.Spreadsheet{Sheets: []*sheets.Sheet{
+		{},
+		{Properties: &sheets.SheetProperties{SheetId: 2, Title: "Fixed"}},
+		{},
+	}}).Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for _, sh := range ss.Sheets {
+		got = append(got, sh.Properties.SheetId)
+	}
+	if want := []int64{0, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("created tab IDs = %v, want %v", got, want)
+	}
+}
+
+func TestValues(t *testing.T) {
+	ctx := context.Background()
+	srv, svc := newService(t, &Spreadsheet{ID: "s", Tabs: []*Tab{
+		{Title: "Data", Rows: [][]interface{}{{"Name", "Qty"}, {"a", 1.0}, {"b", 2.0}}},
+		{Title: "My Tab", Rows: [][]interface{}{{"x"}}},
+	}})
+
+	tests := []struct {
+		rng  string
+		want [][]interface{}
+		read string
+	}{
+		{"Data", [][]interface{}{{"Name", "Qty"}, {"a", "1"}, {"b", "2"}}, "Data!A1:B3"},
+		{"Data!B2:B", [][]interface{}{{"1"}, {"2"}}, "Data!B2:B3"},
+		{"Data!A2:A2", [][]interface{}{{"a"}}, "Data!A2:A2"},
+		{"'My Tab'!A1", [][]interface{}{{"x"}}, "'My Tab'!A1:A1"},
+		{"A1:B1", [][]interface{}{{"Name", "Qty"}}, "Data!A1:B1"},
+	}
+	for _, tt := range tests {
+		vr, err := svc.Spreadsheets.Values.Get("s", tt.rng).Context(ctx).Do()
+		if err != nil {
+			t.Errorf("get %s: %v", tt.rng, err)
+			continue
+		}
+		if !reflect.DeepEqual(vr.Values, tt.want) || vr.Range != tt.read {
+			t.Errorf("get %s = %v from %s, want %v from %s", tt.rng, vr.Values, vr.Range, tt.want, tt.read)
+		}
+	}
+	if _, err := svc.Spreadsheets.Values.Get("s", "Missing!A1").Context(ctx).Do(); !isCode(err, 400) {
+		t.Errorf("get of a missing tab: %v, want 400", err)
+	}
+	if _, err := svc.Spreadsheets.Values.Get("nope", "A1").Context(ctx).Do(); !isCode(err, 404) {
+		t.Errorf("get of a missing spreadsheet: %v, want 404", err)
+	}
+
+	up, err := svc.Spreadsheets.Values.Update("s", "Data!B3", &sheets.ValueRange{Values: [][]interface{}{{"20"}}}).
+		ValueInputOption("USER_ENTERED").Context(ctx).Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if up.UpdatedRange != "Data!B3:B3" || up.UpdatedCells != 1 {
+		t.Errorf("update = %s, %d cells", up.UpdatedRange, up.UpdatedCells)
+	}
+	if _, err := svc.Spreadsheets.Values.Update("s", "Data!B3", &sheets.ValueRange{Values: [][]interface{}{{"20"}}}).
+		Context(ctx).Do(); !isCode(err, 400) {
+		t.Errorf("update without valueInputOption: %v, want 400", err)
+	}
+
+	ap, err := svc.Spreadsheets.Values.Append("s", "Data", &sheets.ValueRange{Values: [][]interface{}{{"c", "3"}}}).
+		ValueInputOption("RAW").Context(ctx).Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ap.TableRange != "Data!A1:B3" || ap.Updates.UpdatedRange != "Data!A4:B4" {
+		t.Errorf("append = table %s, updated %s", ap.TableRange, ap.Updates.UpdatedRange)
+	}
+	want := [][]interface{}{{"Name", "Qty"}, {"a", 1.0}, {"b", 20.0}, {"c", "3"}}
+	if got := srv.Values("s", "Data"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Data = %#v, want %#v", got, want)
+	}
+
+	bg, err := svc.Spreadsheets.Values.BatchGet("s").Ranges("Data!A4:B4", "'My Tab'").
+		ValueRenderOption("UNFORMATTED_VALUE").Context(ctx).Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bg.ValueRanges) != 2 {
+		t.Fatalf("batchGet returned %d ranges, want 2", len(bg.ValueRanges))
+	}
+	if got, want := bg.ValueRanges[0].Values, [][]interface{}{{"c", "3"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("batchGet Data!A4:B4 = %v, want %v", got, want)
+	}
+	if got, want := bg.ValueRanges[1].Values, [][]interface{}{{"x"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("batchGet 'My Tab' = %v, want %v", got, want)
+	}
+}
+
+func TestRequests(t *testing.T) {
+	ctx := context.Background()
+	srv, svc := newService(t, &Spreadsheet{ID: "s", Tabs: []*Tab{{Title: "Data"}}})
+
+	if _, err := svc.Spreadsheets.Values.Get("s", "Data!A1").Context(ctx).Do(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Spreadsheets.Values.Append("s", "Data", &sheets.ValueRange{Values: [][]interface{}{{"a"}}}).
+		ValueInputOption("RAW").Context(ctx).Do(); err != nil {
+		t.Fatal(err)
+	}
+	reqs := srv.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("got %d requests, want 2", len(reqs))
+	}
+	if r := reqs[0]; r.Op != "values.get" || r.Method != "GET" {
+		t.Errorf("first request = %s %s", r.Method, r.Op)
+	}
+	r := reqs[1]
+	if r.Op != "values.append" || r.Method != "POST" || r.Query.Get("valueInputOption") != "RAW" {
+		t.Errorf("second request = %s %s %v", r.Method, r.Op, r.Query)
+	}
+	var body sheets.ValueRange
+	if err := json.Unmarshal(r.Body, &body); err != nil || !reflect.DeepEqual(body.Values, [][]interface{}{{"a"}}) {
+		t.Errorf("append body = %s", r.Body)
+	}
+	if n := srv.Count("values.append"); n != 1 {
+		t.Errorf("Count(values.append) = %d, want 1", n)
+	}
+
+	srv.Reset()
+	srv.Fail("values.get", 429, 2)
+	for i := 0; i < 2; i++ {
+		if _, err := svc.Spreadsheets.Values.Get("s", "Data!A1").Context(ctx).Do(); !isCode(err, 429) {
+			t.Errorf("get %d after Fail: %v, want 429", i, err)
+		}
+	}
+	if _, err := svc.Spreadsheets.Values.Get("s", "Data!A1").Context(ctx).Do(); err != nil {
+		t.Errorf("get after the failures: %v", err)
+	}
+	if n := srv.Count("values.get"); n != 3 {
+		t.Errorf("after Reset, Count(values.get) = %d, want 3", n)
+	}
+}
+
+func isCode(err error, code int) bool {
+	e, ok := err.(*googleapi.Error)
+	return ok && e.Code == code
+}
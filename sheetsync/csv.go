@@ -0,0 +1,300 @@
+package sheetsync
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+)
+
+// Resolution decides what a CSV sync does with rows edited on both sides.
+type Resolution int
+
+const (
+	// Abort stops the sync, with nothing written, if there is any
+	// conflict.
+	Abort Resolution = iota
+	// PreferLocal keeps the CSV version of conflicting rows.
+	PreferLocal
+	// PreferRemote keeps the sheet version of conflicting rows.
+	PreferRemote
+)
+
+// ParseResolution converts "abort", "local" or "remote" into a Resolution.
+func ParseResolution(s string) (Resolution, bool) {
+	switch s {
+	case "abort":
+		return Abort, true
+	case "local":
+		return PreferLocal, true
+	case "remote":
+		return PreferRemote, true
+	}
+	return Abort, false
+}
+
+// ConflictError is returned by CSVSync.Sync under Abort.
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	keys := make([]string, len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		keys[i] = c.Key
+	}
+	return fmt.Sprintf("sheetsync: %d rows changed on both sides: %s", len(keys), strings.Join(keys, ", "))
+}
+
+// CSVSync reconciles a local CSV file, whose first line is the header of
+// the tab, with a tab identified by a key column. The base snapshot lives
+// in a JSON state file next to the CSV.
+type CSVSync struct {
+	Sheets        *sheets.Service
+	SpreadsheetID string
+	// Tab must have a KeyColumn.
+	Tab  Tab
+	Path string
+	// StatePath defaults to Path with ".sync.json" appended.
+	StatePath  string
+	Resolution Resolution
+}
+
+// csvState is the content of the state file.
+type csvState struct {
+	SpreadsheetID string              `json:"spreadsheetId"`
+	Tab           string              `json:"tab"`
+	Header        []string            `json:"header"`
+	Rows          map[string][]string `json:"rows"`
+	Time          time.Time           `json:"time"`
+}
+
+func (s *CSVSync) statePath() string {
+	if s.StatePath != "" {
+		return s.StatePath
+	}
+	return s.Path + ".sync.json"
+}
+
+// readCSV returns the header and rows of the CSV file, or nothing if it
+// does not exist yet.
+func (s *CSVSync) readCSV() ([]string, [][]string, error) {
+	f, err := os.Open(s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("sheetsync: %w", err)
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("sheetsync: unable to read %s: %w", s.Path, err)
+	}
+	if len(records) == 0 {
+		return nil, nil, nil
+	}
+	return records[0], records[1:], nil
+}
+
+func (s *CSVSync) loadState() (*csvState, error) {
+	st := &csvState{SpreadsheetID: s.SpreadsheetID, Tab: s.Tab.Name, Rows: map[string][]string{}}
+	b, err := ioutil.ReadFile(s.statePath())
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sheetsync: %w", err)
+	}
+	if err := json.Unmarshal(b, st); err != nil {
+		return nil, fmt.Errorf("sheetsync: corrupt state file %s: %w", s.statePath(), err)
+	}
+	if st.SpreadsheetID != s.SpreadsheetID || st.Tab != s.Tab.Name {
+		return nil, fmt.Errorf("sheetsync: state file %s belongs to tab %q of %s", s.statePath(), st.Tab, st.SpreadsheetID)
+	}
+	return st, nil
+}
+
+// csvPlan is a reconciliation of the CSV with the tab.
+type csvPlan struct {
+	*Plan
+	remote *remoteTab
+	state  *csvState
+	// order lists the keys of the CSV rows in file order.
+	order []string
+	local Snapshot
+}
+
+func (s *CSVSync) plan(ctx context.Context) (*csvPlan, error) {
+	if s.Tab.useMetadata() {
+		return nil, fmt.Errorf("sheetsync: syncing %s needs a key column", s.Path)
+	}
+	rt, err := fetchRemote(ctx, s.Sheets, s.SpreadsheetID, s.Tab)
+	if err != nil {
+		return nil, err
+	}
+	header, rows, err := s.readCSV()
+	if err != nil {
+		return nil, err
+	}
+	if header != nil && !Equal(header, rt.header) {
+		return nil, fmt.Errorf("sheetsync: header of %s differs from tab %q", s.Path, s.Tab.Name)
+	}
+	st, err := s.loadState()
+	if err != nil {
+		return nil, err
+	}
+	p := &csvPlan{remote: rt, state: st, local: Snapshot{}}
+	keyCol := indexOf(rt.header, s.Tab.KeyColumn)
+	for i, row := range rows {
+		if keyCol >= len(row) || row[keyCol] == "" {
+			continue
+		}
+		key := row[keyCol]
+		if _, dup := p.local[key]; dup {
+			return nil, fmt.Errorf("sheetsync: duplicate key %q on line %d of %s", key, i+2, s.Path)
+		}
+		p.local[key] = Record{Key: key, Values: row}
+		p.order = append(p.order, key)
+	}
+	base := Snapshot{}
+	for k, v := range st.Rows {
+		base[k] = Record{Key: k, Values: v}
+	}
+	p.Plan = Reconcile(base, p.local, rt.rows, Manual, time.Time{})
+	return p, nil
+}
+
+// Status reports what the next sync would do.
+func (s *CSVSync) Status(ctx context.Context) (*TabStatus, error) {
+	p, err := s.plan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &TabStatus{Tab: s.Tab.Name, LastSync: p.state.Time, Outgoing: len(p.Push), Incoming: len(p.Pull), Conflicts: p.Conflicts}, nil
+}
+
+// Sync pushes rows changed in the CSV to the tab and rewrites the CSV with
+// the rows changed in the tab. Conflicts are settled by Resolution; under
+// Abort, a *ConflictError is returned and nothing is written.
+func (s *CSVSync) Sync(ctx context.Context) (*Result, error) {
+	p, err := s.plan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.Conflicts) > 0 && s.Resolution == Abort {
+		return nil, &ConflictError{Conflicts: p.Conflicts}
+	}
+	for _, c := range p.Conflicts {
+		l, r := c.Local != nil, c.Remote != nil
+		var lv, rv Record
+		if l {
+			lv = *c.Local
+		}
+		if r {
+			rv = *c.Remote
+		}
+		winner, present := rv, r
+		if s.Resolution == PreferLocal {
+			winner, present = lv, l
+			p.Push = append(p.Push, change(r, lv, l, rv))
+		} else {
+			p.Pull = append(p.Pull, change(l, rv, r, lv))
+		}
+		if present {
+			p.Base[c.Key] = winner
+		} else {
+			delete(p.Base, c.Key)
+		}
+	}
+	res := &Result{Tab: s.Tab.Name, Header: p.remote.header, Pushed: p.Push, Pulled: p.Pull}
+
+	if err := pushChanges(ctx, s.Sheets, s.SpreadsheetID, s.Tab, p.remote, p.Push); err != nil {
+		return nil, err
+	}
+	if err := s.writeCSV(p); err != nil {
+		return nil, err
+	}
+	st := &csvState{SpreadsheetID: s.SpreadsheetID, Tab: s.Tab.Name, Header: p.remote.header, Rows: map[string][]string{}, Time: time.Now().UTC()}
+	for k, r := range p.Base {
+		st.Rows[k] = r.Values
+	}
+	b, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(s.statePath(), b, 0600); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// writeCSV applies the pulled changes to the local rows, keeping the file
+// order and appending new rows, and replaces the CSV file.
+func (s *CSVSync) writeCSV(p *csvPlan) error {
+	rows := map[string][]string{}
+	for k, r := range p.local {
+		rows[k] = r.Values
+	}
+	order := p.order
+	for _, c := range p.Pull {
+		k := c.Record.Key
+		switch c.Op {
+		case Delete:
+			delete(rows, k)
+		case Insert:
+			order = append(order, k)
+			fallthrough
+		case Update:
+			rows[k] = c.Record.Values
+		}
+	}
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write(p.remote.header)
+	for _, k := range order {
+		if row, ok := rows[k]; ok {
+			w.Write(row)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return writeFile(s.Path, []byte(b.String()), 0644)
+}
+
+// writeFile replaces path atomically.
+func writeFile(path string, b []byte, perm os.FileMode) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".sheetsync-")
+	if err != nil {
+		return fmt.Errorf("sheetsync: %w", err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("sheetsync: %w", err)
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("sheetsync: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("sheetsync: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("sheetsync: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package sheetsync
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/sheets/v4"
+)
+
+// Engine syncs tabs of one spreadsheet with a Mirror.
+type Engine struct {
+	Sheets        *sheets.Service
+	Mirror        *Mirror
+	SpreadsheetID string
+	Policy        Policy
+	// Drive is used to find when the spreadsheet was last modified. It is
+	// only required by LastWriterWins.
+	Drive *drive.Service
+	// OnChanges, if set, is called with the changes of a sync before they
+	// are written. An error aborts the sync with nothing written, so every
+	// change that gets applied is reported at least once.
+	OnChanges func(ctx context.Context, res *Result) error
+}
+
+// Result summarizes the outcome of syncing one tab.
+type Result struct {
+	Tab       string
+	Header    []string
+	Pushed    []Change
+	Pulled    []Change
+	Conflicts []Conflict
+}
+
+func (r *Result) String() string {
+	return fmt.Sprintf("%s: pushed %d, pulled %d, %d conflicts",
+		r.Tab, len(r.Pushed), len(r.Pulled), len(r.Conflicts))
+}
+
+// plan reads both sides of tab and reconciles them.
+func (e *Engine) plan(ctx context.Context, tab Tab) (*remoteTab, *Plan, error) {
+	rt, err := fetchRemote(ctx, e.Sheets, e.SpreadsheetID, tab)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := e.Mirror.ensureTable(tab, rt.header); err != nil {
+		return nil, nil, err
+	}
+	local, err := e.Mirror.load(tab, rt.header)
+	if err != nil {
+		return nil, nil, err
+	}
+	base, err := e.Mirror.base(tab.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var modified time.Time
+	if e.Policy == LastWriterWins {
+		if e.Drive == nil {
+			return nil, nil, fmt.Errorf("sheetsync: %v requires a Drive service", e.Policy)
+		}
+		f, err := e.Drive.Files.Get(e.SpreadsheetID).Fields("modifiedTime").Context(ctx).Do()
+		if err != nil {
+			return nil, nil, fmt.Errorf("sheetsync: unable to get modification time: %w", err)
+		}
+		if modified, err = time.Parse(time.RFC3339, f.ModifiedTime); err != nil {
+			return nil, nil, err
+		}
+	}
+	return rt, Reconcile(base, local, rt.rows, e.Policy, modified), nil
+}
+
+// Sync reconciles tab, pushing local edits to the sheet and pulling remote
+// edits into the mirror. Conflicting rows are left alone under Manual and
+// reported in the result.
+func (e *Engine) Sync(ctx context.Context, tab Tab) (*Result, error) {
+	rt, plan, err := e.plan(ctx, tab)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Tab: tab.Name, Header: rt.header, Pushed: plan.Push, Pulled: plan.Pull, Conflicts: plan.Conflicts}
+	if e.OnChanges != nil && (len(plan.Push) > 0 || len(plan.Pull) > 0) {
+		if err := e.OnChanges(ctx, res); err != nil {
+			return nil, err
+		}
+	}
+	if err := rt.tag(ctx, e.Sheets, e.SpreadsheetID); err != nil {
+		return nil, err
+	}
+	if err := pushChanges(ctx, e.Sheets, e.SpreadsheetID, tab, rt, plan.Push); err != nil {
+		return nil, err
+	}
+	if err := e.Mirror.apply(e.SpreadsheetID, tab, rt.header, plan); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// TabStatus describes the pending work on a tab without changing anything.
+type TabStatus struct {
+	Tab string
+	// LastSync is the zero time if the tab was never synced.
+	LastSync time.Time
+	// Outgoing and Incoming count the changes the next sync would push
+	// and pull.
+	Outgoing, Incoming int
+	Conflicts          []Conflict
+}
+
+// Status reports what the next sync of tab would do, along with conflicts
+// recorded by previous syncs.
+func (e *Engine) Status(ctx context.Context, tab Tab) (*TabStatus, error) {
+	_, plan, err := e.plan(ctx, tab)
+	if err != nil {
+		return nil, err
+	}
+	st := &TabStatus{Tab: tab.Name, Outgoing: len(plan.Push), Incoming: len(plan.Pull), Conflicts: plan.Conflicts}
+	if st.LastSync, err = e.Mirror.lastSync(tab.Name); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
@@ -0,0 +1,152 @@
+package sheetsync
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetstest"
+)
+
+func TestReconcile(t *testing.T) {
+	rec := func(key string, values ...string) Record { return Record{Key: key, Values: values} }
+	snap := func(rs ...Record) Snapshot {
+		s := Snapshot{}
+		for _, r := range rs {
+			s[r.Key] = r
+		}
+		return s
+	}
+	base := snap(rec("1", "a"), rec("2", "b"), rec("3", "c"))
+	now := time.Now()
+
+	tests := []struct {
+		name          string
+		local, remote Snapshot
+		policy        Policy
+		push, pull    []Change
+		conflicts     int
+	}{
+		{"unchanged", base, base, Manual, nil, nil, 0},
+		{"local edit", snap(rec("1", "A"), rec("2", "b"), rec("3", "c")), base, Manual,
+			[]Change{{Op: Update, Record: rec("1", "A")}}, nil, 0},
+		{"remote insert and local delete", snap(rec("1", "a"), rec("2", "b")), snap(rec("1", "a"), rec("2", "b"), rec("3", "c"), rec("4", "d")), Manual,
+			[]Change{{Op: Delete, Record: rec("3", "c")}}, []Change{{Op: Insert, Record: rec("4", "d")}}, 0},
+		{"same edit on both sides", snap(rec("1", "x"), rec("2", "b"), rec("3", "c")), snap(rec("1", "x"), rec("2", "b"), rec("3", "c")), Manual,
+			nil, nil, 0},
+		{"conflict", snap(rec("1", "x"), rec("2", "b"), rec("3", "c")), snap(rec("1", "y"), rec("2", "b"), rec("3", "c")), Manual,
+			nil, nil, 1},
+		{"remote wins", snap(Record{Key: "1", Values: []string{"x"}, Modified: now.Add(-time.Hour)}, rec("2", "b"), rec("3", "c")),
+			snap(rec("1", "y"), rec("2", "b"), rec("3", "c")), LastWriterWins,
+			nil, []Change{{Op: Update, Record: rec("1", "y")}}, 0},
+	}
+	for _, tt := range tests {
+		plan := Reconcile(base, tt.local, tt.remote, tt.policy, now)
+		if !reflect.DeepEqual(plan.Push, tt.push) {
+			t.Errorf("%s: push = %+v, want %+v", tt.name, plan.Push, tt.push)
+		}
+		if !reflect.DeepEqual(plan.Pull, tt.pull) {
+			t.Errorf("%s: pull = %+v, want %+v", tt.name, plan.Pull, tt.pull)
+		}
+		if len(plan.Conflicts) != tt.conflicts {
+			t.Errorf("%s: %d conflicts, want %d", tt.name, len(plan.Conflicts), tt.conflicts)
+		}
+	}
+}
+
+func TestEngineSync(t *testing.T) {
+	ctx := context.Background()
+	srv := sheetstest.NewServer()
+	defer srv.Close()
+	srv.Seed(&sheetstest.Spreadsheet{ID: "s", Tabs: []*sheetstest.Tab{{
+		Title: "Items",
+		Rows:  [][]interface{}{{"id", "name", "qty"}, {"1", "apple", "3"}, {"2", "pear", "5"}, {"3", "plum", "7"}},
+	}}})
+	svc, err := srv.SheetsService(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "mirror.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	m, err := OpenMirror(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &Engine{Sheets: svc, Mirror: m, SpreadsheetID: "s"}
+	tab := Tab{Name: "Items", KeyColumn: "id"}
+	exec := func(q string, args ...interface{}) {
+		if _, err := db.Exec(q, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sheet := func() [][]interface{} {
+		vr, err := svc.Spreadsheets.Values.Get("s", "Items").Do()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return vr.Values
+	}
+
+	res, err := e.Sync(ctx, tab)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Pulled) != 3 || len(res.Pushed) != 0 {
+		t.Fatalf("first sync: %v", res)
+	}
+
+	// Edit both sides, on different rows.
+	exec(`UPDATE Items SET qty = '4' WHERE id = '1'`)
+	exec(`DELETE FROM Items WHERE id = '3'`)
+	exec(`INSERT INTO Items (id, name, qty) VALUES ('4', 'kiwi', '1')`)
+	if _, err := svc.Spreadsheets.Values.Update("s", "Items!B3", &sheets.ValueRange{Values: [][]interface{}{{"pears"}}}).
+		ValueInputOption("RAW").Do(); err != nil {
+		t.Fatal(err)
+	}
+	if res, err = e.Sync(ctx, tab); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Pushed) != 3 || len(res.Pulled) != 1 || len(res.Conflicts) != 0 {
+		t.Errorf("second sync: %v", res)
+	}
+	want := [][]interface{}{{"id", "name", "qty"}, {"1", "apple", "4"}, {"2", "pears", "5"}, {"4", "kiwi", "1"}}
+	if got := sheet(); !reflect.DeepEqual(got, want) {
+		t.Errorf("after the second sync, the sheet holds %v, want %v", got, want)
+	}
+	var name string
+	if err := db.QueryRow(`SELECT name FROM Items WHERE id = '2'`).Scan(&name); err != nil || name != "pears" {
+		t.Errorf("mirrored name of row 2 = %q, %v", name, err)
+	}
+
+	// Edit the same row on both sides.
+	exec(`UPDATE Items SET qty = '6' WHERE id = '2'`)
+	if _, err := svc.Spreadsheets.Values.Update("s", "Items!C3", &sheets.ValueRange{Values: [][]interface{}{{"9"}}}).
+		ValueInputOption("RAW").Do(); err != nil {
+		t.Fatal(err)
+	}
+	st, err := e.Status(ctx, tab)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Conflicts) != 1 || st.Conflicts[0].Key != "2" || st.LastSync.IsZero() {
+		t.Errorf("status = %+v, want one conflict on row 2", st)
+	}
+	if res, err = e.Sync(ctx, tab); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Pushed) != 0 || len(res.Pulled) != 0 || len(res.Conflicts) != 1 {
+		t.Errorf("conflicting sync: %v", res)
+	}
+	if got := sheet()[2]; !reflect.DeepEqual(got, []interface{}{"2", "pears", "9"}) {
+		t.Errorf("a conflicting sync changed the sheet row to %v", got)
+	}
+}
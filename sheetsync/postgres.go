@@ -0,0 +1,382 @@
+package sheetsync
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+)
+
+// Direction selects which way a PostgresSync copies rows.
+type Direction int
+
+const (
+	// SheetToDB makes the table match the sheet.
+	SheetToDB Direction = iota
+	// DBToSheet makes the sheet match the table.
+	DBToSheet
+	// Both merges edits from either side since the previous run.
+	Both
+)
+
+// ParseDirection converts "sheet-to-db", "db-to-sheet" or "both".
+func ParseDirection(s string) (Direction, bool) {
+	switch s {
+	case "sheet-to-db":
+		return SheetToDB, true
+	case "db-to-sheet":
+		return DBToSheet, true
+	case "both":
+		return Both, true
+	}
+	return SheetToDB, false
+}
+
+// PostgresSync keeps a tab and a PostgreSQL table in sync. The tab must
+// have a key column, which becomes the primary key of the table.
+type PostgresSync struct {
+	Sheets        *sheets.Service
+	DB            *sql.DB
+	SpreadsheetID string
+	Tab           Tab
+	Table         string
+	// Columns maps header names to table columns. When empty, every
+	// header column is mapped to a sanitized version of its name.
+	// Sheet columns left out of the mapping are never written.
+	Columns   map[string]string
+	Direction Direction
+	// BatchSize bounds the number of rows per upsert statement; defaults
+	// to 500.
+	BatchSize int
+}
+
+// Report is the reconciliation summary of a PostgresSync run.
+type Report struct {
+	Unchanged int
+	// DB and Sheet count the rows inserted, updated and deleted on each
+	// side, indexed by Op.
+	DB, Sheet [3]int
+	// Conflicts lists rows edited on both sides, when syncing in both
+	// directions. They are left untouched.
+	Conflicts []Conflict
+}
+
+func (r *Report) String() string {
+	return fmt.Sprintf("table: %d inserted, %d updated, %d deleted; sheet: %d inserted, %d updated, %d deleted; %d unchanged, %d conflicts",
+		r.DB[Insert], r.DB[Update], r.DB[Delete], r.Sheet[Insert], r.Sheet[Update], r.Sheet[Delete], r.Unchanged, len(r.Conflicts))
+}
+
+var notIdent = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// SanitizeColumn turns a header cell into a lower case SQL identifier.
+func SanitizeColumn(name string) string {
+	s := strings.Trim(notIdent.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if s == "" || (s[0] >= '0' && s[0] <= '9') {
+		s = "c_" + s
+	}
+	return s
+}
+
+// pgMapping is the resolved column mapping of a run.
+type pgMapping struct {
+	sheetIdx []int    // header index of each mapped column
+	cols     []string // table column of each mapped column
+	key      int      // index of the key column within cols
+}
+
+func (p *PostgresSync) mapping(header []string) (*pgMapping, error) {
+	m := &pgMapping{key: -1}
+	for i, h := range header {
+		col := p.Columns[h]
+		if len(p.Columns) == 0 && h != "" {
+			col = SanitizeColumn(h)
+		}
+		if col == "" {
+			continue
+		}
+		if h == p.Tab.KeyColumn {
+			m.key = len(m.cols)
+		}
+		m.sheetIdx = append(m.sheetIdx, i)
+		m.cols = append(m.cols, col)
+	}
+	if m.key < 0 {
+		return nil, fmt.Errorf("sheetsync: key column %q is not mapped", p.Tab.KeyColumn)
+	}
+	return m, nil
+}
+
+// project reduces full sheet rows to the mapped columns.
+func (m *pgMapping) project(s Snapshot) Snapshot {
+	out := Snapshot{}
+	for k, r := range s {
+		vals := make([]string, len(m.cols))
+		for i, idx := range m.sheetIdx {
+			if idx < len(r.Values) {
+				vals[i] = r.Values[idx]
+			}
+		}
+		out[k] = Record{Key: k, Values: vals}
+	}
+	return out
+}
+
+// expand writes the mapped values of r into a full sheet row, keeping the
+// unmapped cells of the current row.
+func (m *pgMapping) expand(r Record, current []string, width int) Record {
+	row := pad(append([]string(nil), current...), width)
+	for i, idx := range m.sheetIdx {
+		row[idx] = r.Values[i]
+	}
+	return Record{Key: r.Key, Values: row}
+}
+
+// Run performs one reconciliation between the tab and the table, creating
+// the table first if needed.
+func (p *PostgresSync) Run(ctx context.Context) (*Report, error) {
+	if p.Tab.useMetadata() {
+		return nil, fmt.Errorf("sheetsync: syncing with PostgreSQL requires a key column")
+	}
+	rt, err := fetchRemote(ctx, p.Sheets, p.SpreadsheetID, p.Tab)
+	if err != nil {
+		return nil, err
+	}
+	m, err := p.mapping(rt.header)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.ensureSchema(ctx, m); err != nil {
+		return nil, err
+	}
+	local, err := p.load(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	remote := m.project(rt.rows)
+
+	var plan *Plan
+	switch p.Direction {
+	case SheetToDB:
+		plan = Reconcile(local, local, remote, Manual, time.Time{})
+	case DBToSheet:
+		plan = Reconcile(remote, local, remote, Manual, time.Time{})
+	default:
+		base, err := p.base(ctx)
+		if err != nil {
+			return nil, err
+		}
+		plan = Reconcile(base, local, remote, Manual, time.Time{})
+	}
+
+	report := &Report{Conflicts: plan.Conflicts}
+	touched := map[string]bool{}
+	for _, c := range plan.Push {
+		report.Sheet[c.Op]++
+		touched[c.Record.Key] = true
+	}
+	for _, c := range plan.Pull {
+		report.DB[c.Op]++
+		touched[c.Record.Key] = true
+	}
+	for _, c := range plan.Conflicts {
+		touched[c.Key] = true
+	}
+	for _, s := range []Snapshot{local, remote} {
+		for k := range s {
+			if !touched[k] {
+				report.Unchanged++
+				touched[k] = true
+			}
+		}
+	}
+
+	push := make([]Change, len(plan.Push))
+	for i, c := range plan.Push {
+		push[i] = c
+		if c.Op != Delete {
+			push[i].Record = m.expand(c.Record, rt.rows[c.Record.Key].Values, len(rt.header))
+		}
+	}
+	if err := pushChanges(ctx, p.Sheets, p.SpreadsheetID, p.Tab, rt, push); err != nil {
+		return nil, err
+	}
+	if err := p.apply(ctx, m, plan); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (p *PostgresSync) scope() string {
+	return p.SpreadsheetID + "/" + p.Tab.Name + "/" + p.Table
+}
+
+func (p *PostgresSync) ensureSchema(ctx context.Context, m *pgMapping) error {
+	t := quoteIdent(p.Table)
+	defs := make([]string, len(m.cols))
+	for i, c := range m.cols {
+		defs[i] = quoteIdent(c) + " TEXT"
+	}
+	stmts := []string{
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s, PRIMARY KEY (%s))", t, strings.Join(defs, ", "), quoteIdent(m.cols[m.key])),
+		`CREATE TABLE IF NOT EXISTS sheetsync_base (
+			scope TEXT NOT NULL,
+			key TEXT NOT NULL,
+			vals TEXT NOT NULL,
+			PRIMARY KEY (scope, key)
+		)`,
+	}
+	for i, c := range m.cols {
+		if i != m.key {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s TEXT", t, quoteIdent(c)))
+		}
+	}
+	for _, s := range stmts {
+		if _, err := p.DB.ExecContext(ctx, s); err != nil {
+			return fmt.Errorf("sheetsync: unable to prepare table %s: %w", t, err)
+		}
+	}
+	return nil
+}
+
+func (p *PostgresSync) load(ctx context.Context, m *pgMapping) (Snapshot, error) {
+	sel := make([]string, len(m.cols))
+	for i, c := range m.cols {
+		sel[i] = quoteIdent(c) + "::text"
+	}
+	rows, err := p.DB.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s", strings.Join(sel, ", "), quoteIdent(p.Table)))
+	if err != nil {
+		return nil, fmt.Errorf("sheetsync: unable to read table %s: %w", p.Table, err)
+	}
+	defer rows.Close()
+	snap := Snapshot{}
+	for rows.Next() {
+		vals := make([]sql.NullString, len(m.cols))
+		dest := make([]interface{}, len(vals))
+		for i := range vals {
+			dest[i] = &vals[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		rec := Record{Key: vals[m.key].String, Values: make([]string, len(vals))}
+		for i, v := range vals {
+			rec.Values[i] = v.String
+		}
+		snap[rec.Key] = rec
+	}
+	return snap, rows.Err()
+}
+
+func (p *PostgresSync) base(ctx context.Context) (Snapshot, error) {
+	rows, err := p.DB.QueryContext(ctx, `SELECT key, vals FROM sheetsync_base WHERE scope = $1`, p.scope())
+	if err != nil {
+		return nil, fmt.Errorf("sheetsync: unable to read base snapshot: %w", err)
+	}
+	defer rows.Close()
+	snap := Snapshot{}
+	for rows.Next() {
+		rec := Record{}
+		var vals string
+		if err := rows.Scan(&rec.Key, &vals); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(vals), &rec.Values); err != nil {
+			return nil, fmt.Errorf("sheetsync: corrupt base row %q: %w", rec.Key, err)
+		}
+		snap[rec.Key] = rec
+	}
+	return snap, rows.Err()
+}
+
+// apply writes the pulled rows with batched upserts and deletes, and
+// stores the new base snapshot, in one transaction.
+func (p *PostgresSync) apply(ctx context.Context, m *pgMapping, plan *Plan) error {
+	batch := p.BatchSize
+	if batch <= 0 {
+		batch = 500
+	}
+	tx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var upserts []Record
+	var deletes []interface{}
+	for _, c := range plan.Pull {
+		if c.Op == Delete {
+			deletes = append(deletes, c.Record.Key)
+		} else {
+			upserts = append(upserts, c.Record)
+		}
+	}
+
+	t := quoteIdent(p.Table)
+	quoted := make([]string, len(m.cols))
+	var sets []string
+	for i, c := range m.cols {
+		quoted[i] = quoteIdent(c)
+		if i != m.key {
+			sets = append(sets, fmt.Sprintf("%s = EXCLUDED.%s", quoted[i], quoted[i]))
+		}
+	}
+	conflict := "DO NOTHING"
+	if len(sets) > 0 {
+		conflict = "DO UPDATE SET " + strings.Join(sets, ", ")
+	}
+	for len(upserts) > 0 {
+		n := batch
+		if n > len(upserts) {
+			n = len(upserts)
+		}
+		var tuples []string
+		var args []interface{}
+		for _, r := range upserts[:n] {
+			marks := make([]string, len(m.cols))
+			for i, v := range pad(r.Values, len(m.cols))[:len(m.cols)] {
+				args = append(args, v)
+				marks[i] = fmt.Sprintf("$%d", len(args))
+			}
+			tuples = append(tuples, "("+strings.Join(marks, ", ")+")")
+		}
+		q := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s ON CONFLICT (%s) %s",
+			t, strings.Join(quoted, ", "), strings.Join(tuples, ", "), quoted[m.key], conflict)
+		if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+			return fmt.Errorf("sheetsync: unable to upsert into %s: %w", t, err)
+		}
+		upserts = upserts[n:]
+	}
+	for len(deletes) > 0 {
+		n := batch
+		if n > len(deletes) {
+			n = len(deletes)
+		}
+		marks := make([]string, n)
+		for i := range marks {
+			marks[i] = fmt.Sprintf("$%d", i+1)
+		}
+		q := fmt.Sprintf("DELETE FROM %s WHERE %s IN (%s)", t, quoted[m.key], strings.Join(marks, ", "))
+		if _, err := tx.ExecContext(ctx, q, deletes[:n]...); err != nil {
+			return fmt.Errorf("sheetsync: unable to delete from %s: %w", t, err)
+		}
+		deletes = deletes[n:]
+	}
+
+	if p.Direction == Both {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM sheetsync_base WHERE scope = $1`, p.scope()); err != nil {
+			return err
+		}
+		for k, r := range plan.Base {
+			vals, _ := json.Marshal(r.Values)
+			if _, err := tx.ExecContext(ctx, `INSERT INTO sheetsync_base (scope, key, vals) VALUES ($1, $2, $3)`, p.scope(), k, string(vals)); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
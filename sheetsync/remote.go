@@ -0,0 +1,312 @@
+package sheetsync
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// MetadataKey is the developer metadata key used to tag rows when a tab is
+// synced without a key column.
+const MetadataKey = "sheetsync.row"
+
+// Tab selects a spreadsheet tab to sync and how its rows are identified.
+// When KeyColumn is empty every row is tagged with developer metadata
+// instead, which keeps its identity even if users sort or move rows.
+type Tab struct {
+	Name      string
+	KeyColumn string
+}
+
+// ParseTab parses a "Name" or "Name:KeyColumn" specification.
+func ParseTab(spec string) Tab {
+	if i := strings.LastIndex(spec, ":"); i >= 0 {
+		return Tab{Name: spec[:i], KeyColumn: spec[i+1:]}
+	}
+	return Tab{Name: spec}
+}
+
+func (t Tab) useMetadata() bool { return t.KeyColumn == "" }
+
+// remoteTab is a tab as read from the spreadsheet.
+type remoteTab struct {
+	sheetID int64
+	header  []string
+	rows    Snapshot
+	// index maps a key to the 0-based row index in the sheet.
+	index map[string]int64
+	// untagged holds identities made up for rows that carry no row
+	// metadata yet, keyed by row index. See tag.
+	untagged map[int64]string
+}
+
+// quoteTab returns a tab name usable in A1 notation.
+
+func fetchRemote(ctx context.Context, srv *sheets.Service, spreadsheetID string, tab Tab) (*remoteTab, error) {
+	ss, err := srv.Spreadsheets.Get(spreadsheetID).Fields("sheets.properties").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheetsync: unable to get spreadsheet: %w", err)
+	}
+	rt := &remoteTab{sheetID: -1, rows: Snapshot{}, index: map[string]int64{}, untagged: map[int64]string{}}
+	for _, s := range ss.Sheets {
+		if s.Properties.Title == tab.Name {
+			rt.sheetID = s.Properties.SheetId
+		}
+	}
+	if rt.sheetID < 0 {
+		return nil, fmt.Errorf("sheetsync: tab %q not found", tab.Name)
+	}
+
+	resp, err := srv.Spreadsheets.Values.Get(spreadsheetID, sheetsclient.QuoteTab(tab.Name)).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheetsync: unable to read tab %q: %w", tab.Name, err)
+	}
+	if len(resp.Values) == 0 {
+		return nil, fmt.Errorf("sheetsync: tab %q has no header row", tab.Name)
+	}
+	rt.header = toStrings(resp.Values[0])
+
+	var ids map[int64]string
+	keyCol := -1
+	if tab.useMetadata() {
+		if ids, err = rowIDs(ctx, srv, spreadsheetID, rt.sheetID); err != nil {
+			return nil, err
+		}
+	} else if keyCol = indexOf(rt.header, tab.KeyColumn); keyCol < 0 {
+		return nil, fmt.Errorf("sheetsync: key column %q not found in tab %q", tab.KeyColumn, tab.Name)
+	}
+
+	for i, v := range resp.Values[1:] {
+		row := toStrings(v)
+		idx := int64(i + 1)
+		var key string
+		if keyCol >= 0 {
+			if keyCol < len(row) {
+				key = row[keyCol]
+			}
+		} else {
+			key = ids[idx]
+		}
+		if key == "" {
+			// Rows added by hand in metadata mode get a fresh identity
+			// that is stored by tag; a blank key cell has no identity.
+			if !tab.useMetadata() || len(trim(row)) == 0 {
+				continue
+			}
+			key = newRowID()
+			rt.untagged[idx] = key
+		}
+		if _, dup := rt.rows[key]; dup {
+			return nil, fmt.Errorf("sheetsync: duplicate key %q in tab %q", key, tab.Name)
+		}
+		rt.index[key] = idx
+		rt.rows[key] = Record{Key: key, Values: row}
+	}
+	return rt, nil
+}
+
+// rowIDs returns the row identities recorded as developer metadata on the
+// given sheet, keyed by 0-based row index.
+func rowIDs(ctx context.Context, srv *sheets.Service, spreadsheetID string, sheetID int64) (map[int64]string, error) {
+	req := &sheets.SearchDeveloperMetadataRequest{
+		DataFilters: []*sheets.DataFilter{{
+			DeveloperMetadataLookup: &sheets.DeveloperMetadataLookup{
+				MetadataKey:  MetadataKey,
+				LocationType: "ROW",
+			},
+		}},
+	}
+	resp, err := srv.Spreadsheets.DeveloperMetadata.Search(spreadsheetID, req).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheetsync: unable to search row metadata: %w", err)
+	}
+	ids := map[int64]string{}
+	for _, m := range resp.MatchedDeveloperMetadata {
+		loc := m.DeveloperMetadata.Location
+		if loc == nil || loc.DimensionRange == nil || loc.DimensionRange.SheetId != sheetID {
+			continue
+		}
+		ids[loc.DimensionRange.StartIndex] = m.DeveloperMetadata.MetadataValue
+	}
+	return ids, nil
+}
+
+// tag stores the identities of rows that were read without row metadata.
+func (rt *remoteTab) tag(ctx context.Context, srv *sheets.Service, spreadsheetID string) error {
+	if err := tagRows(ctx, srv, spreadsheetID, rt.sheetID, rt.untagged); err != nil {
+		return err
+	}
+	rt.untagged = map[int64]string{}
+	return nil
+}
+
+// tagRows attaches row identities to the given 0-based row indexes.
+func tagRows(ctx context.Context, srv *sheets.Service, spreadsheetID string, sheetID int64, ids map[int64]string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var reqs []*sheets.Request
+	for idx, id := range ids {
+		reqs = append(reqs, &sheets.Request{
+			CreateDeveloperMetadata: &sheets.CreateDeveloperMetadataRequest{
+				DeveloperMetadata: &sheets.DeveloperMetadata{
+					MetadataKey:   MetadataKey,
+					MetadataValue: id,
+					Visibility:    "DOCUMENT",
+					Location: &sheets.DeveloperMetadataLocation{
+						DimensionRange: &sheets.DimensionRange{
+							SheetId:    sheetID,
+							Dimension:  "ROWS",
+							StartIndex: idx,
+							EndIndex:   idx + 1,
+						},
+					},
+				},
+			},
+		})
+	}
+	req := &sheets.BatchUpdateSpreadsheetRequest{Requests: reqs}
+	if _, err := srv.Spreadsheets.BatchUpdate(spreadsheetID, req).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("sheetsync: unable to tag rows: %w", err)
+	}
+	return nil
+}
+
+// pushChanges writes local changes to the sheet. Updates go first, then
+// deletes from the bottom up so earlier indexes stay valid, then appends.
+func pushChanges(ctx context.Context, srv *sheets.Service, spreadsheetID string, tab Tab, rt *remoteTab, changes []Change) error {
+	var updates []*sheets.ValueRange
+	var deletes []int64
+	var inserts []Record
+	for _, c := range changes {
+		switch c.Op {
+		case Update:
+			updates = append(updates, &sheets.ValueRange{
+				Range:  fmt.Sprintf("%s!A%d", sheetsclient.QuoteTab(tab.Name), rt.index[c.Record.Key]+1),
+				Values: [][]interface{}{toValues(pad(c.Record.Values, len(rt.header)))},
+			})
+		case Delete:
+			deletes = append(deletes, rt.index[c.Record.Key])
+		case Insert:
+			inserts = append(inserts, c.Record)
+		}
+	}
+
+	if len(updates) > 0 {
+		req := &sheets.BatchUpdateValuesRequest{ValueInputOption: "USER_ENTERED", Data: updates}
+		if _, err := srv.Spreadsheets.Values.BatchUpdate(spreadsheetID, req).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("sheetsync: unable to update rows: %w", err)
+		}
+	}
+
+	if len(deletes) > 0 {
+		sort.Slice(deletes, func(i, j int) bool { return deletes[i] > deletes[j] })
+		var reqs []*sheets.Request
+		for _, idx := range deletes {
+			reqs = append(reqs, &sheets.Request{
+				DeleteDimension: &sheets.DeleteDimensionRequest{
+					Range: &sheets.DimensionRange{
+						SheetId:    rt.sheetID,
+						Dimension:  "ROWS",
+						StartIndex: idx,
+						EndIndex:   idx + 1,
+					},
+				},
+			})
+		}
+		req := &sheets.BatchUpdateSpreadsheetRequest{Requests: reqs}
+		if _, err := srv.Spreadsheets.BatchUpdate(spreadsheetID, req).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("sheetsync: unable to delete rows: %w", err)
+		}
+	}
+
+	if len(inserts) > 0 {
+		vr := &sheets.ValueRange{}
+		for _, r := range inserts {
+			vr.Values = append(vr.Values, toValues(r.Values))
+		}
+		resp, err := srv.Spreadsheets.Values.Append(spreadsheetID, sheetsclient.QuoteTab(tab.Name)+"!A1", vr).
+			ValueInputOption("USER_ENTERED").InsertDataOption("INSERT_ROWS").Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("sheetsync: unable to append rows: %w", err)
+		}
+		if tab.useMetadata() {
+			first, err := firstRow(resp.Updates.UpdatedRange)
+			if err != nil {
+				return err
+			}
+			ids := map[int64]string{}
+			for i, r := range inserts {
+				ids[first+int64(i)] = r.Key
+			}
+			if err := tagRows(ctx, srv, spreadsheetID, rt.sheetID, ids); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var a1Start = regexp.MustCompile(`![A-Z]*([0-9]+)`)
+
+// firstRow returns the 0-based index of the first row of an A1 range such
+// as "Sheet1!A7:F9".
+func firstRow(rng string) (int64, error) {
+	m := a1Start.FindStringSubmatch(rng)
+	if m == nil {
+		return 0, fmt.Errorf("sheetsync: unexpected range %q", rng)
+	}
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	return n - 1, err
+}
+
+func newRowID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func toStrings(row []interface{}) []string {
+	out := make([]string, len(row))
+	for i, v := range row {
+		out[i] = fmt.Sprint(v)
+	}
+	return out
+}
+
+func toValues(row []string) []interface{} {
+	out := make([]interface{}, len(row))
+	for i, v := range row {
+		out[i] = v
+	}
+	return out
+}
+
+// pad extends row with empty cells up to n columns so that an update also
+// clears cells that were emptied locally.
+func pad(row []string, n int) []string {
+	if len(row) >= n {
+		return row
+	}
+	out := make([]string, n)
+	copy(out, row)
+	return out
+}
+
+func indexOf(header []string, name string) int {
+	for i, h := range header {
+		if h == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,226 @@
+// Package sheetsync mirrors spreadsheet tabs into a local store and
+// reconciles edits made on either side since the last sync.
+//
+// Every synced tab keeps a base snapshot: the rows as they looked after the
+// previous successful sync. Comparing the local and remote rows against that
+// base tells us which side changed a row, so edits only flow in one direction
+// unless both sides touched the same row, which is reported as a Conflict.
+package sheetsync
+
+import (
+	"sort"
+	"time"
+)
+
+// Record is a single row of a tab, identified by Key.
+type Record struct {
+	Key    string
+	Values []string
+	// Modified is when the row was last written, if the side that holds
+	// the record tracks it. It is only consulted to break conflicts.
+	Modified time.Time
+	// Deleted marks a tombstone: the row was removed at Modified.
+	Deleted bool
+}
+
+// Snapshot is a set of records indexed by their key.
+type Snapshot map[string]Record
+
+// get returns the live record stored under k, skipping tombstones.
+func (s Snapshot) get(k string) (Record, bool) {
+	r, ok := s[k]
+	return r, ok && !r.Deleted
+}
+
+// Policy selects how conflicting edits are resolved.
+type Policy int
+
+const (
+	// Manual leaves conflicting rows untouched on both sides and records
+	// them until they are settled with Mirror.Resolve.
+	Manual Policy = iota
+	// LastWriterWins keeps whichever side wrote the row most recently.
+	LastWriterWins
+)
+
+// ParsePolicy converts "manual" or "lww" into a Policy.
+func ParsePolicy(s string) (Policy, bool) {
+	switch s {
+	case "manual":
+		return Manual, true
+	case "lww", "last-writer-wins":
+		return LastWriterWins, true
+	}
+	return Manual, false
+}
+
+func (p Policy) String() string {
+	if p == LastWriterWins {
+		return "last-writer-wins"
+	}
+	return "manual"
+}
+
+// Op is the kind of change applied to one side.
+type Op int
+
+const (
+	Insert Op = iota
+	Update
+	Delete
+)
+
+func (o Op) String() string {
+	switch o {
+	case Insert:
+		return "insert"
+	case Update:
+		return "update"
+	}
+	return "delete"
+}
+
+// Change is an edit that has to be applied to one side to bring it in line
+// with the other.
+type Change struct {
+	Op     Op
+	Record Record
+}
+
+// Conflict describes a row edited on both sides since the last sync.
+// A nil side means the row is absent there.
+type Conflict struct {
+	Key    string
+	Base   *Record
+	Local  *Record
+	Remote *Record
+}
+
+// Plan lists the work needed to reconcile one tab.
+type Plan struct {
+	Push      []Change // changes to write to the sheet
+	Pull      []Change // changes to write to the local store
+	Conflicts []Conflict
+	// Base is the snapshot to store once Push and Pull have been applied.
+	Base Snapshot
+}
+
+// Reconcile performs a three-way comparison of the local and remote rows
+// against base. remoteModified is the last modification time of the
+// spreadsheet, used by LastWriterWins since the Sheets API does not track
+// edits per row.
+func Reconcile(base, local, remote Snapshot, policy Policy, remoteModified time.Time) *Plan {
+	plan := &Plan{Base: Snapshot{}}
+
+	keys := map[string]bool{}
+	for _, s := range []Snapshot{base, local, remote} {
+		for k := range s {
+			keys[k] = true
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	for _, k := range sorted {
+		b, inBase := base.get(k)
+		l, inLocal := local.get(k)
+		r, inRemote := remote.get(k)
+
+		localChanged := changed(b, inBase, l, inLocal)
+		remoteChanged := changed(b, inBase, r, inRemote)
+
+		switch {
+		case !localChanged && !remoteChanged:
+			if inBase {
+				plan.Base[k] = b
+			}
+		case remoteChanged && !localChanged:
+			plan.Pull = append(plan.Pull, change(inLocal, r, inRemote, l))
+			if inRemote {
+				plan.Base[k] = r
+			}
+		case localChanged && !remoteChanged:
+			plan.Push = append(plan.Push, change(inRemote, l, inLocal, r))
+			if inLocal {
+				plan.Base[k] = l
+			}
+		case inLocal == inRemote && (!inLocal || Equal(l.Values, r.Values)):
+			// Both sides made the same edit.
+			if inLocal {
+				plan.Base[k] = r
+			}
+		case policy == LastWriterWins:
+			// For a local delete, the tombstone carries the time.
+			if local[k].Modified.After(remoteModified) {
+				plan.Push = append(plan.Push, change(inRemote, l, inLocal, r))
+				if inLocal {
+					plan.Base[k] = l
+				}
+			} else {
+				plan.Pull = append(plan.Pull, change(inLocal, r, inRemote, l))
+				if inRemote {
+					plan.Base[k] = r
+				}
+			}
+		default:
+			c := Conflict{Key: k}
+			if inBase {
+				c.Base = &b
+				plan.Base[k] = b
+			}
+			if inLocal {
+				c.Local = &l
+			}
+			if inRemote {
+				c.Remote = &r
+			}
+			plan.Conflicts = append(plan.Conflicts, c)
+		}
+	}
+	return plan
+}
+
+// change builds the edit that turns the target side into src. present
+// reports whether the row exists on the target side already.
+func change(present bool, src Record, srcPresent bool, target Record) Change {
+	switch {
+	case !srcPresent:
+		return Change{Op: Delete, Record: target}
+	case present:
+		return Change{Op: Update, Record: src}
+	}
+	return Change{Op: Insert, Record: src}
+}
+
+func changed(b Record, inBase bool, s Record, inSide bool) bool {
+	if inBase != inSide {
+		return true
+	}
+	return inBase && !Equal(b.Values, s.Values)
+}
+
+// Equal compares two rows, ignoring trailing empty cells which the Sheets
+// API omits from responses.
+func Equal(a, b []string) bool {
+	a, b = trim(a), trim(b)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func trim(row []string) []string {
+	n := len(row)
+	for n > 0 && row[n-1] == "" {
+		n--
+	}
+	return row[:n]
+}
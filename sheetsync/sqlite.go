@@ -0,0 +1,427 @@
+package sheetsync
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// timeFormat matches the timestamps written by the mirror's triggers.
+const timeFormat = "2006-01-02T15:04:05.000Z"
+
+const sqliteNow = `strftime('%Y-%m-%dT%H:%M:%fZ', 'now')`
+
+// Mirror is a local SQLite copy of synced tabs. Each tab becomes a table
+// with one column per header cell, which can be queried and edited freely;
+// the next sync pushes those edits back. Tables in metadata mode carry an
+// extra _rowid column holding the row identity; leave it empty for new rows.
+//
+// Bookkeeping lives in tables prefixed with _sheetsync.
+type Mirror struct {
+	db *sql.DB
+}
+
+// OpenMirror prepares db, an SQLite database, for use as a mirror.
+func OpenMirror(db *sql.DB) (*Mirror, error) {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS _sheetsync_state (
+			tab TEXT PRIMARY KEY,
+			spreadsheet TEXT NOT NULL,
+			key_column TEXT NOT NULL,
+			header TEXT NOT NULL,
+			last_sync TEXT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS _sheetsync_base (
+			tab TEXT NOT NULL,
+			key TEXT NOT NULL,
+			vals TEXT NOT NULL,
+			PRIMARY KEY (tab, key)
+		)`,
+		`CREATE TABLE IF NOT EXISTS _sheetsync_deleted (
+			tab TEXT NOT NULL,
+			key TEXT NOT NULL,
+			deleted_at TEXT NOT NULL,
+			PRIMARY KEY (tab, key)
+		)`,
+		`CREATE TABLE IF NOT EXISTS _sheetsync_conflicts (
+			tab TEXT NOT NULL,
+			key TEXT NOT NULL,
+			base TEXT,
+			local TEXT,
+			remote TEXT,
+			detected_at TEXT NOT NULL,
+			PRIMARY KEY (tab, key)
+		)`,
+	}
+	for _, s := range stmts {
+		if _, err := db.Exec(s); err != nil {
+			return nil, fmt.Errorf("sheetsync: unable to initialize mirror: %w", err)
+		}
+	}
+	return &Mirror{db: db}, nil
+}
+
+func quoteIdent(s string) string {
+	return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+}
+
+// columns maps header cells to distinct, non-empty column names.
+func columns(header []string) []string {
+	cols := make([]string, len(header))
+	seen := map[string]bool{"_rowid": true, "_modified": true}
+	for i, h := range header {
+		name := strings.TrimSpace(h)
+		if name == "" {
+			name = fmt.Sprintf("column_%d", i+1)
+		}
+		for base, n := name, 2; seen[strings.ToLower(name)]; n++ {
+			name = fmt.Sprintf("%s_%d", base, n)
+		}
+		seen[strings.ToLower(name)] = true
+		cols[i] = name
+	}
+	return cols
+}
+
+// keyExpr returns the SQL expression holding the identity of a row.
+func keyExpr(tab Tab, cols, header []string) string {
+	if tab.useMetadata() {
+		return "_rowid"
+	}
+	return quoteIdent(cols[indexOf(header, tab.KeyColumn)])
+}
+
+// ensureTable creates or widens the table mirroring tab.
+func (m *Mirror) ensureTable(tab Tab, header []string) error {
+	cols := columns(header)
+	t := quoteIdent(tab.Name)
+
+	existing := map[string]bool{}
+	rows, err := m.db.Query(`SELECT name FROM pragma_table_info(?)`, tab.Name)
+	if err != nil {
+		return fmt.Errorf("sheetsync: unable to inspect table %s: %w", t, err)
+	}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[strings.ToLower(name)] = true
+	}
+	rows.Close()
+
+	if len(existing) == 0 {
+		defs := []string{"_modified TEXT"}
+		if tab.useMetadata() {
+			defs = append(defs, "_rowid TEXT UNIQUE")
+		}
+		for _, c := range cols {
+			defs = append(defs, quoteIdent(c)+" TEXT")
+		}
+		if _, err := m.db.Exec(fmt.Sprintf("CREATE TABLE %s (%s)", t, strings.Join(defs, ", "))); err != nil {
+			return fmt.Errorf("sheetsync: unable to create table %s: %w", t, err)
+		}
+	} else {
+		for _, c := range cols {
+			if existing[strings.ToLower(c)] {
+				continue
+			}
+			if _, err := m.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s TEXT", t, quoteIdent(c))); err != nil {
+				return fmt.Errorf("sheetsync: unable to add column %s: %w", c, err)
+			}
+		}
+	}
+
+	// Triggers stamp local edits and remember local deletes so that
+	// LastWriterWins can tell when a row was last touched.
+	key := keyExpr(tab, cols, header)
+	trig := func(suffix string) string { return quoteIdent("_sheetsync_" + tab.Name + "_" + suffix) }
+	stmts := []string{
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %s AFTER INSERT ON %s
+			WHEN NEW._modified IS NULL
+			BEGIN UPDATE %s SET _modified = %s WHERE rowid = NEW.rowid; END`,
+			trig("insert"), t, t, sqliteNow),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %s AFTER UPDATE ON %s
+			WHEN NEW._modified IS OLD._modified
+			BEGIN UPDATE %s SET _modified = %s WHERE rowid = NEW.rowid; END`,
+			trig("update"), t, t, sqliteNow),
+	}
+	// Drop and recreate the delete trigger, its key column may have moved.
+	if _, err := m.db.Exec("DROP TRIGGER IF EXISTS " + trig("delete")); err != nil {
+		return err
+	}
+	stmts = append(stmts, fmt.Sprintf(`CREATE TRIGGER %s AFTER DELETE ON %s
+		WHEN OLD.%s IS NOT NULL
+		BEGIN INSERT OR REPLACE INTO _sheetsync_deleted (tab, key, deleted_at) VALUES (%s, OLD.%s, %s); END`,
+		trig("delete"), t, key, sqlString(tab.Name), key, sqliteNow))
+	for _, s := range stmts {
+		if _, err := m.db.Exec(s); err != nil {
+			return fmt.Errorf("sheetsync: unable to create triggers on %s: %w", t, err)
+		}
+	}
+	return nil
+}
+
+func sqlString(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// load reads the local rows of tab, aligned to header. In metadata mode,
+// rows inserted without an identity are given one.
+func (m *Mirror) load(tab Tab, header []string) (Snapshot, error) {
+	cols := columns(header)
+	t := quoteIdent(tab.Name)
+
+	if tab.useMetadata() {
+		rows, err := m.db.Query(fmt.Sprintf("SELECT rowid FROM %s WHERE _rowid IS NULL OR _rowid = ''", t))
+		if err != nil {
+			return nil, fmt.Errorf("sheetsync: unable to read %s: %w", t, err)
+		}
+		var ids []int64
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		for _, id := range ids {
+			if _, err := m.db.Exec(fmt.Sprintf("UPDATE %s SET _rowid = ? WHERE rowid = ?", t), newRowID(), id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sel := []string{keyExpr(tab, cols, header), "_modified"}
+	for _, c := range cols {
+		sel = append(sel, quoteIdent(c))
+	}
+	rows, err := m.db.Query(fmt.Sprintf("SELECT %s FROM %s", strings.Join(sel, ", "), t))
+	if err != nil {
+		return nil, fmt.Errorf("sheetsync: unable to read %s: %w", t, err)
+	}
+	defer rows.Close()
+
+	snap := Snapshot{}
+	for rows.Next() {
+		dest := make([]interface{}, len(sel))
+		vals := make([]sql.NullString, len(sel))
+		for i := range vals {
+			dest[i] = &vals[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		key := vals[0].String
+		if key == "" {
+			continue
+		}
+		if _, dup := snap[key]; dup {
+			return nil, fmt.Errorf("sheetsync: duplicate key %q in table %s", key, t)
+		}
+		rec := Record{Key: key, Values: make([]string, len(cols))}
+		rec.Modified, _ = time.Parse(timeFormat, vals[1].String)
+		for i := range cols {
+			rec.Values[i] = vals[i+2].String
+		}
+		snap[key] = rec
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tomb, err := m.db.Query(`SELECT key, deleted_at FROM _sheetsync_deleted WHERE tab = ?`, tab.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer tomb.Close()
+	for tomb.Next() {
+		var key, at string
+		if err := tomb.Scan(&key, &at); err != nil {
+			return nil, err
+		}
+		if _, live := snap[key]; !live {
+			rec := Record{Key: key, Deleted: true}
+			rec.Modified, _ = time.Parse(timeFormat, at)
+			snap[key] = rec
+		}
+	}
+	return snap, tomb.Err()
+}
+
+// base reads the snapshot stored by the last sync of tab.
+func (m *Mirror) base(tab string) (Snapshot, error) {
+	rows, err := m.db.Query(`SELECT key, vals FROM _sheetsync_base WHERE tab = ?`, tab)
+	if err != nil {
+		return nil, fmt.Errorf("sheetsync: unable to read base snapshot: %w", err)
+	}
+	defer rows.Close()
+	snap := Snapshot{}
+	for rows.Next() {
+		var key, vals string
+		if err := rows.Scan(&key, &vals); err != nil {
+			return nil, err
+		}
+		rec := Record{Key: key}
+		if err := json.Unmarshal([]byte(vals), &rec.Values); err != nil {
+			return nil, fmt.Errorf("sheetsync: corrupt base row %q: %w", key, err)
+		}
+		snap[key] = rec
+	}
+	return snap, rows.Err()
+}
+
+// apply writes pulled changes, the new base snapshot and the conflicts of
+// plan in a single transaction.
+func (m *Mirror) apply(spreadsheetID string, tab Tab, header []string, plan *Plan) error {
+	cols := columns(header)
+	t := quoteIdent(tab.Name)
+	key := keyExpr(tab, cols, header)
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	quoted := make([]string, len(cols))
+	marks := make([]string, len(cols))
+	for i, c := range cols {
+		quoted[i] = quoteIdent(c)
+		marks[i] = "?"
+	}
+	for _, c := range plan.Pull {
+		args := make([]interface{}, 0, len(cols)+1)
+		for _, v := range pad(c.Record.Values, len(cols))[:len(cols)] {
+			args = append(args, v)
+		}
+		switch c.Op {
+		case Insert:
+			names, values := quoted, marks
+			if tab.useMetadata() {
+				names = append([]string{"_rowid"}, quoted...)
+				values = append([]string{"?"}, marks...)
+				args = append([]interface{}{c.Record.Key}, args...)
+			}
+			_, err = tx.Exec(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", t, strings.Join(names, ", "), strings.Join(values, ", ")), args...)
+		case Update:
+			sets := make([]string, len(cols))
+			for i := range cols {
+				sets[i] = quoted[i] + " = ?"
+			}
+			_, err = tx.Exec(fmt.Sprintf("UPDATE %s SET %s WHERE %s = ?", t, strings.Join(sets, ", "), key), append(args, c.Record.Key)...)
+		case Delete:
+			_, err = tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s = ?", t, key), c.Record.Key)
+		}
+		if err != nil {
+			return fmt.Errorf("sheetsync: unable to %s local row %q: %w", c.Op, c.Record.Key, err)
+		}
+	}
+
+	stmts := []string{
+		`DELETE FROM _sheetsync_base WHERE tab = ?`,
+		`DELETE FROM _sheetsync_deleted WHERE tab = ?`,
+		`DELETE FROM _sheetsync_conflicts WHERE tab = ?`,
+	}
+	for _, s := range stmts {
+		if _, err := tx.Exec(s, tab.Name); err != nil {
+			return err
+		}
+	}
+	for k, r := range plan.Base {
+		vals, _ := json.Marshal(r.Values)
+		if _, err := tx.Exec(`INSERT INTO _sheetsync_base (tab, key, vals) VALUES (?, ?, ?)`, tab.Name, k, string(vals)); err != nil {
+			return err
+		}
+	}
+	now := time.Now().UTC().Format(timeFormat)
+	for _, c := range plan.Conflicts {
+		if _, err := tx.Exec(`INSERT INTO _sheetsync_conflicts (tab, key, base, local, remote, detected_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			tab.Name, c.Key, recordJSON(c.Base), recordJSON(c.Local), recordJSON(c.Remote), now); err != nil {
+			return err
+		}
+		// Keep the tombstone of a conflicting local delete, it is still
+		// pending.
+		if c.Local == nil {
+			if _, err := tx.Exec(`INSERT INTO _sheetsync_deleted (tab, key, deleted_at) VALUES (?, ?, ?)`, tab.Name, c.Key, now); err != nil {
+				return err
+			}
+		}
+	}
+	headerJSON, _ := json.Marshal(header)
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO _sheetsync_state (tab, spreadsheet, key_column, header, last_sync) VALUES (?, ?, ?, ?, ?)`,
+		tab.Name, spreadsheetID, tab.KeyColumn, string(headerJSON), now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func recordJSON(r *Record) interface{} {
+	if r == nil {
+		return nil
+	}
+	b, _ := json.Marshal(r.Values)
+	return string(b)
+}
+
+// Side names one end of a sync.
+type Side int
+
+const (
+	Local Side = iota
+	Remote
+)
+
+// Resolve settles a recorded conflict by keeping one side's version of the
+// row. The other side is brought in line by the next sync.
+func (m *Mirror) Resolve(tab, key string, keep Side) error {
+	var local, remote sql.NullString
+	err := m.db.QueryRow(`SELECT local, remote FROM _sheetsync_conflicts WHERE tab = ? AND key = ?`, tab, key).Scan(&local, &remote)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("sheetsync: no conflict for key %q in tab %q", key, tab)
+	} else if err != nil {
+		return err
+	}
+
+	// Pretend the losing side's version was the last synced state, so
+	// that the next sync sees only the winner as changed.
+	loser := remote
+	if keep == Remote {
+		loser = local
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM _sheetsync_base WHERE tab = ? AND key = ?`, tab, key); err != nil {
+		return err
+	}
+	if loser.Valid {
+		if _, err := tx.Exec(`INSERT INTO _sheetsync_base (tab, key, vals) VALUES (?, ?, ?)`, tab, key, loser.String); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM _sheetsync_conflicts WHERE tab = ? AND key = ?`, tab, key); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// lastSync returns when tab was last synced, or the zero time.
+func (m *Mirror) lastSync(tab string) (time.Time, error) {
+	var at string
+	err := m.db.QueryRow(`SELECT last_sync FROM _sheetsync_state WHERE tab = ?`, tab).Scan(&at)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	} else if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(timeFormat, at)
+}
@@ -0,0 +1,189 @@
+// Package sheettemplate creates spreadsheets from a template spreadsheet,
+// substituting {{placeholders}} in cells, tab names and the title.
+package sheettemplate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// placeholder matches {{name}}, allowing spaces inside the braces.
+var placeholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// Template is a spreadsheet used as a template.
+type Template struct {
+	Drive      *drive.Service
+	Sheets     *sheets.Service
+	TemplateID string
+	// Folder, if set, is the Drive folder the copies are created in;
+	// otherwise they go next to the template.
+	Folder string
+	// AllowMissing leaves placeholders without data as they are instead
+	// of failing.
+	AllowMissing bool
+}
+
+// File is a spreadsheet created from a template.
+type File struct {
+	ID    string
+	URL   string
+	Title string
+}
+
+// MissingError lists placeholders of the template that the data does not
+// provide.
+type MissingError struct {
+	Names []string
+}
+
+func (e *MissingError) Error() string {
+	return "sheettemplate: no data for " + strings.Join(e.Names, ", ")
+}
+
+// Placeholders returns the names of the placeholders used in the template.
+func (t *Template) Placeholders(ctx context.Context) ([]string, error) {
+	s, err := t.scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	for _, text := range append(s.texts, s.title) {
+		for _, m := range placeholder.FindAllStringSubmatch(text, -1) {
+			names[m[1]] = true
+		}
+	}
+	return sortedKeys(names), nil
+}
+
+// Render copies the template and substitutes data into the copy. The
+// title of the copy is the template's title after substitution.
+func (t *Template) Render(ctx context.Context, data map[string]string) (*File, error) {
+	s, err := t.scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Collect every spelling of every placeholder, so plain find and
+	// replace can substitute them.
+	spellings := map[string]string{}
+	missing := map[string]bool{}
+	for _, text := range append(s.texts, s.title) {
+		for _, m := range placeholder.FindAllStringSubmatch(text, -1) {
+			if v, ok := data[m[1]]; ok {
+				spellings[m[0]] = v
+			} else {
+				missing[m[1]] = true
+			}
+		}
+	}
+	if len(missing) > 0 && !t.AllowMissing {
+		return nil, &MissingError{Names: sortedKeys(missing)}
+	}
+	subst := func(text string) string {
+		return placeholder.ReplaceAllStringFunc(text, func(m string) string {
+			if v, ok := spellings[m]; ok {
+				return v
+			}
+			return m
+		})
+	}
+
+	dst := &drive.File{Name: subst(s.title)}
+	if t.Folder != "" {
+		dst.Parents = []string{t.Folder}
+	}
+	f, err := t.Drive.Files.Copy(t.TemplateID, dst).Fields("id,name,webViewLink").SupportsAllDrives(true).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheettemplate: unable to copy template: %w", err)
+	}
+
+	var reqs []*sheets.Request
+	for _, find := range sortedKeys(toSet(spellings)) {
+		reqs = append(reqs, &sheets.Request{FindReplace: &sheets.FindReplaceRequest{
+			Find:            find,
+			Replacement:     spellings[find],
+			AllSheets:       true,
+			MatchCase:       true,
+			IncludeFormulas: true,
+		}})
+	}
+	for id, name := range s.tabs {
+		if renamed := subst(name); renamed != name {
+			reqs = append(reqs, &sheets.Request{UpdateSheetProperties: &sheets.UpdateSheetPropertiesRequest{
+				Properties: &sheets.SheetProperties{SheetId: id, Title: renamed, ForceSendFields: []string{"SheetId"}},
+				Fields:     "title",
+			}})
+		}
+	}
+	if len(reqs) > 0 {
+		_, err = t.Sheets.Spreadsheets.BatchUpdate(f.Id, &sheets.BatchUpdateSpreadsheetRequest{Requests: reqs}).Context(ctx).Do()
+		if err != nil {
+			return nil, fmt.Errorf("sheettemplate: unable to fill in %s: %w", f.Id, err)
+		}
+	}
+	return &File{ID: f.Id, URL: f.WebViewLink, Title: f.Name}, nil
+}
+
+type scan struct {
+	title string
+	tabs  map[int64]string
+	// texts holds the tab names and every cell containing a placeholder.
+	texts []string
+}
+
+func (t *Template) scan(ctx context.Context) (*scan, error) {
+	f, err := t.Drive.Files.Get(t.TemplateID).Fields("name").SupportsAllDrives(true).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheettemplate: unable to get template: %w", err)
+	}
+	resp, err := t.Sheets.Spreadsheets.Get(t.TemplateID).Fields("sheets.properties(sheetId,title)").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheettemplate: unable to get template tabs: %w", err)
+	}
+	s := &scan{title: f.Name, tabs: map[int64]string{}}
+	var ranges []string
+	for _, sh := range resp.Sheets {
+		s.tabs[sh.Properties.SheetId] = sh.Properties.Title
+		s.texts = append(s.texts, sh.Properties.Title)
+		ranges = append(ranges, sheetsclient.QuoteTab(sh.Properties.Title))
+	}
+	values, err := t.Sheets.Spreadsheets.Values.BatchGet(t.TemplateID).Ranges(ranges...).
+		ValueRenderOption("FORMULA").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("sheettemplate: unable to read template: %w", err)
+	}
+	for _, vr := range values.ValueRanges {
+		for _, row := range vr.Values {
+			for _, v := range row {
+				if text, ok := v.(string); ok && strings.Contains(text, "{{") {
+					s.texts = append(s.texts, text)
+				}
+			}
+		}
+	}
+	return s, nil
+}
+
+func toSet(m map[string]string) map[string]bool {
+	set := make(map[string]bool, len(m))
+	for k := range m {
+		set[k] = true
+	}
+	return set
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,348 @@
+package spec
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// Change is one difference between the spec and the live spreadsheet,
+// along with the requests that fix it.
+type Change struct {
+	// Action is "+" for something created and "~" for something updated.
+	Action      string
+	Description string
+	requests    []*sheets.Request
+}
+
+func (c Change) String() string { return c.Action + " " + c.Description }
+
+// Plan lists the changes needed to converge a spreadsheet.
+type Plan struct {
+	SpreadsheetID string
+	Changes       []Change
+}
+
+func (p *Plan) String() string {
+	if len(p.Changes) == 0 {
+		return "No changes. The spreadsheet matches the spec.\n"
+	}
+	var b strings.Builder
+	for _, c := range p.Changes {
+		b.WriteString(c.String() + "\n")
+	}
+	fmt.Fprintf(&b, "\n%d to change.\n", len(p.Changes))
+	return b.String()
+}
+
+func (p *Plan) add(action string, requests []*sheets.Request, format string, args ...interface{}) {
+	p.Changes = append(p.Changes, Change{Action: action, Description: fmt.Sprintf(format, args...), requests: requests})
+}
+
+// Diff compares the spreadsheet with s.
+func Diff(ctx context.Context, srv *sheets.Service, spreadsheetID string, s *Spec) (*Plan, error) {
+	ss, err := srv.Spreadsheets.Get(spreadsheetID).Fields("sheets(properties,protectedRanges)", "namedRanges").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("spec: unable to get spreadsheet: %w", err)
+	}
+	live := map[string]*sheets.Sheet{}
+	ids := map[int64]bool{}
+	var ranges []string
+	for _, sh := range ss.Sheets {
+		live[sh.Properties.Title] = sh
+		ids[sh.Properties.SheetId] = true
+	}
+	for _, t := range s.Tabs {
+		if live[t.Name] != nil && len(t.Columns) > 0 {
+			ranges = append(ranges, sheetsclient.QuoteTab(t.Name)+"!1:2")
+		}
+	}
+	// The header row and the first data row are enough to see headers,
+	// formats and validations.
+	grid := map[string][]*sheets.RowData{}
+	if len(ranges) > 0 {
+		gs, err := srv.Spreadsheets.Get(spreadsheetID).Ranges(ranges...).IncludeGridData(true).
+			Fields("sheets(properties.title,data.rowData.values(userEnteredValue,userEnteredFormat.numberFormat,dataValidation))").
+			Context(ctx).Do()
+		if err != nil {
+			return nil, fmt.Errorf("spec: unable to read headers: %w", err)
+		}
+		for _, sh := range gs.Sheets {
+			if len(sh.Data) > 0 {
+				grid[sh.Properties.Title] = sh.Data[0].RowData
+			}
+		}
+	}
+
+	p := &Plan{SpreadsheetID: spreadsheetID}
+	sheetIDs := map[string]int64{}
+	for _, sh := range ss.Sheets {
+		sheetIDs[sh.Properties.Title] = sh.Properties.SheetId
+	}
+	for _, t := range s.Tabs {
+		sh := live[t.Name]
+		if sh == nil {
+			id := newSheetID(ids)
+			sheetIDs[t.Name] = id
+			sh = &sheets.Sheet{Properties: &sheets.SheetProperties{SheetId: id, Title: t.Name, GridProperties: &sheets.GridProperties{}}}
+			p.add("+", []*sheets.Request{{AddSheet: &sheets.AddSheetRequest{
+				Properties: &sheets.SheetProperties{SheetId: id, Title: t.Name},
+			}}}, "tab %s", t.Name)
+		}
+		if err := diffTab(p, t, sh, grid[t.Name]); err != nil {
+			return nil, err
+		}
+	}
+
+	named := map[string]*sheets.NamedRange{}
+	for _, n := range ss.NamedRanges {
+		named[n.Name] = n
+	}
+	for _, n := range s.NamedRanges {
+		if !strings.Contains(n.Range, "!") {
+			return nil, fmt.Errorf("spec: named range %s has no tab in %q", n.Name, n.Range)
+		}
+		tab, cells := sheetsclient.SplitRange(n.Range)
+		id, ok := sheetIDs[tab]
+		if !ok {
+			return nil, fmt.Errorf("spec: named range %s refers to unknown tab %q", n.Name, tab)
+		}
+		g, err := sheetsclient.GridRange(id, cells)
+		if err != nil {
+			return nil, err
+		}
+		switch cur := named[n.Name]; {
+		case cur == nil:
+			p.add("+", []*sheets.Request{{AddNamedRange: &sheets.AddNamedRangeRequest{
+				NamedRange: &sheets.NamedRange{Name: n.Name, Range: g},
+			}}}, "named range %s = %s", n.Name, n.Range)
+		case !sameRange(cur.Range, g):
+			p.add("~", []*sheets.Request{{UpdateNamedRange: &sheets.UpdateNamedRangeRequest{
+				NamedRange: &sheets.NamedRange{NamedRangeId: cur.NamedRangeId, Name: n.Name, Range: g},
+				Fields:     "range",
+			}}}, "named range %s = %s", n.Name, n.Range)
+		}
+	}
+	return p, nil
+}
+
+func diffTab(p *Plan, t Tab, sh *sheets.Sheet, rows []*sheets.RowData) error {
+	props := sh.Properties
+	id := props.SheetId
+	gp := props.GridProperties
+	if gp == nil {
+		gp = &sheets.GridProperties{}
+	}
+	if gp.FrozenRowCount != t.FrozenRows || gp.FrozenColumnCount != t.FrozenCols {
+		p.add("~", []*sheets.Request{{UpdateSheetProperties: &sheets.UpdateSheetPropertiesRequest{
+			Properties: &sheets.SheetProperties{SheetId: id, GridProperties: &sheets.GridProperties{
+				FrozenRowCount:    t.FrozenRows,
+				FrozenColumnCount: t.FrozenCols,
+				ForceSendFields:   []string{"FrozenRowCount", "FrozenColumnCount"},
+			}},
+			Fields: "gridProperties.frozenRowCount,gridProperties.frozenColumnCount",
+		}}}, "%s: freeze %d rows, %d columns (was %d, %d)", t.Name, t.FrozenRows, t.FrozenCols, gp.FrozenRowCount, gp.FrozenColumnCount)
+	}
+
+	cell := func(row, col int) *sheets.CellData {
+		if row < len(rows) && col < len(rows[row].Values) {
+			return rows[row].Values[col]
+		}
+		return &sheets.CellData{}
+	}
+
+	var headerCells []*sheets.CellData
+	var renamed []string
+	for i, c := range t.Columns {
+		cur := ""
+		if v := cell(0, i).UserEnteredValue; v != nil && v.StringValue != nil {
+			cur = *v.StringValue
+		}
+		if cur != c.Header {
+			renamed = append(renamed, fmt.Sprintf("%s1 %q -> %q", sheetsclient.ColumnLetters(i+1), cur, c.Header))
+		}
+		h := c.Header
+		headerCells = append(headerCells, &sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{StringValue: &h}})
+	}
+	if len(renamed) > 0 {
+		p.add("~", []*sheets.Request{{UpdateCells: &sheets.UpdateCellsRequest{
+			Start:  &sheets.GridCoordinate{SheetId: id, ForceSendFields: []string{"RowIndex", "ColumnIndex"}},
+			Rows:   []*sheets.RowData{{Values: headerCells}},
+			Fields: "userEnteredValue",
+		}}}, "%s: headers %s", t.Name, strings.Join(renamed, ", "))
+	}
+
+	for i, c := range t.Columns {
+		body := &sheets.GridRange{
+			SheetId:          id,
+			StartRowIndex:    1,
+			StartColumnIndex: int64(i),
+			EndColumnIndex:   int64(i + 1),
+		}
+		cur := cell(1, i)
+		if c.Format != nil {
+			var nf *sheets.NumberFormat
+			if cur.UserEnteredFormat != nil {
+				nf = cur.UserEnteredFormat.NumberFormat
+			}
+			if nf == nil || nf.Type != c.Format.Type || nf.Pattern != c.Format.Pattern {
+				p.add("~", []*sheets.Request{{RepeatCell: &sheets.RepeatCellRequest{
+					Range: body,
+					Cell: &sheets.CellData{UserEnteredFormat: &sheets.CellFormat{
+						NumberFormat: &sheets.NumberFormat{Type: c.Format.Type, Pattern: c.Format.Pattern},
+					}},
+					Fields: "userEnteredFormat.numberFormat",
+				}}}, "%s: format column %s (%s) as %s", t.Name, sheetsclient.ColumnLetters(i+1), c.Header, describeFormat(c.Format))
+			}
+		}
+		if c.Validation != nil {
+			want := rule(c.Validation)
+			if !sameRule(cur.DataValidation, want) {
+				p.add("~", []*sheets.Request{{SetDataValidation: &sheets.SetDataValidationRequest{
+					Range: body,
+					Rule:  want,
+				}}}, "%s: validate column %s (%s) with %s %s", t.Name, sheetsclient.ColumnLetters(i+1), c.Header, c.Validation.Type, strings.Join(c.Validation.Values, ", "))
+			}
+		}
+	}
+
+	protections := t.Protections
+	if t.ProtectHeader {
+		protections = append(protections, Protection{Range: "1:1", Description: headerProtection, WarningOnly: true})
+	}
+	existing := map[string]*sheets.ProtectedRange{}
+	for _, pr := range sh.ProtectedRanges {
+		existing[pr.Description] = pr
+	}
+	for _, want := range protections {
+		g, err := sheetsclient.GridRange(id, want.Range)
+		if err != nil {
+			return err
+		}
+		pr := &sheets.ProtectedRange{Range: g, Description: want.Description, WarningOnly: want.WarningOnly}
+		if !want.WarningOnly {
+			pr.Editors = &sheets.Editors{Users: want.Editors}
+		}
+		cur := existing[want.Description]
+		if cur == nil {
+			p.add("+", []*sheets.Request{{AddProtectedRange: &sheets.AddProtectedRangeRequest{ProtectedRange: pr}}},
+				"%s: protect %s (%s)", t.Name, describeRange(want.Range), want.Description)
+			continue
+		}
+		var editors []string
+		if cur.Editors != nil {
+			editors = cur.Editors.Users
+		}
+		if !sameRange(cur.Range, g) || cur.WarningOnly != want.WarningOnly || (!want.WarningOnly && !sameSet(editors, want.Editors)) {
+			pr.ProtectedRangeId = cur.ProtectedRangeId
+			fields := "range,warningOnly"
+			if !want.WarningOnly {
+				fields += ",editors"
+			}
+			p.add("~", []*sheets.Request{{UpdateProtectedRange: &sheets.UpdateProtectedRangeRequest{
+				ProtectedRange: pr,
+				Fields:         fields,
+			}}}, "%s: protection %s (%s)", t.Name, describeRange(want.Range), want.Description)
+		}
+	}
+	return nil
+}
+
+// Apply sends the requests of a plan as one batch update, so either every
+// change is made or none is.
+func Apply(ctx context.Context, srv *sheets.Service, p *Plan) error {
+	var reqs []*sheets.Request
+	for _, c := range p.Changes {
+		reqs = append(reqs, c.requests...)
+	}
+	if len(reqs) == 0 {
+		return nil
+	}
+	req := &sheets.BatchUpdateSpreadsheetRequest{Requests: reqs}
+	if _, err := srv.Spreadsheets.BatchUpdate(p.SpreadsheetID, req).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("spec: unable to apply changes: %w", err)
+	}
+	return nil
+}
+
+func rule(v *Validation) *sheets.DataValidationRule {
+	c := &sheets.BooleanCondition{Type: v.Type}
+	for _, s := range v.Values {
+		c.Values = append(c.Values, &sheets.ConditionValue{UserEnteredValue: s})
+	}
+	return &sheets.DataValidationRule{Condition: c, Strict: v.Strict, ShowCustomUi: v.Type == "ONE_OF_LIST"}
+}
+
+func sameRule(a, b *sheets.DataValidationRule) bool {
+	if a == nil || b == nil || a.Condition == nil {
+		return a == b
+	}
+	if a.Strict != b.Strict || a.Condition.Type != b.Condition.Type || len(a.Condition.Values) != len(b.Condition.Values) {
+		return false
+	}
+	for i, v := range a.Condition.Values {
+		if v.UserEnteredValue != b.Condition.Values[i].UserEnteredValue {
+			return false
+		}
+	}
+	return true
+}
+
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = append([]string{}, a...), append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func describeFormat(f *Format) string {
+	if f.Pattern == "" {
+		return f.Type
+	}
+	return fmt.Sprintf("%s %q", f.Type, f.Pattern)
+}
+
+func describeRange(r string) string {
+	if r == "" {
+		return "whole tab"
+	}
+	return r
+}
+
+// newSheetID picks an unused sheet ID, so that requests later in the same
+// batch can refer to a tab added by it.
+func newSheetID(used map[int64]bool) int64 {
+	for {
+		id := rand.Int63n(1 << 31)
+		if id != 0 && !used[id] {
+			used[id] = true
+			return id
+		}
+	}
+}
+
+// columnName converts a 0-based column index to its A1 letters.
+
+// sameRange compares grid ranges, treating zero end indexes as unbounded.
+func sameRange(a, b *sheets.GridRange) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.SheetId == b.SheetId &&
+		a.StartRowIndex == b.StartRowIndex && a.EndRowIndex == b.EndRowIndex &&
+		a.StartColumnIndex == b.StartColumnIndex && a.EndColumnIndex == b.EndColumnIndex
+}
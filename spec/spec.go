@@ -0,0 +1,132 @@
+// Package spec keeps the structure of spreadsheets in line with a
+// declarative YAML description: which tabs exist, their header rows,
+// column formats and validations, protections and named ranges.
+//
+// Plan compares a spec with the live spreadsheet and returns the changes
+// needed to converge it, which Apply then sends as one batch update. Only
+// what the spec mentions is managed; tabs, protections and named ranges
+// it does not declare are left alone.
+package spec
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec describes a spreadsheet, e.g.
+//
+//	tabs:
+//	  - name: Inventory
+//	    frozen_rows: 1
+//	    protect_header: true
+//	    columns:
+//	      - header: SKU
+//	        format: {type: TEXT}
+//	      - header: Quantity
+//	        format: {type: NUMBER, pattern: "#,##0"}
+//	        validation: {type: NUMBER_GREATER_THAN_EQ, values: ["0"], strict: true}
+//	      - header: Status
+//	        validation: {type: ONE_OF_LIST, values: [active, retired]}
+//	    protections:
+//	      - range: A1:Z1
+//	        description: header
+//	        editors: [ops@example.com]
+//	named_ranges:
+//	  - name: Quantities
+//	    range: Inventory!B2:B
+type Spec struct {
+	Tabs        []Tab        `yaml:"tabs"`
+	NamedRanges []NamedRange `yaml:"named_ranges"`
+}
+
+// Tab describes one tab.
+type Tab struct {
+	Name       string   `yaml:"name"`
+	FrozenRows int64    `yaml:"frozen_rows"`
+	FrozenCols int64    `yaml:"frozen_columns"`
+	Columns    []Column `yaml:"columns"`
+	// ProtectHeader protects the header row with a warning.
+	ProtectHeader bool         `yaml:"protect_header"`
+	Protections   []Protection `yaml:"protections"`
+}
+
+// Column describes a column, in order from A. Format and Validation apply
+// to every row below the header.
+type Column struct {
+	Header     string      `yaml:"header"`
+	Format     *Format     `yaml:"format"`
+	Validation *Validation `yaml:"validation"`
+}
+
+// Format is a number format, as in the Sheets API: a type such as TEXT,
+// NUMBER, PERCENT, CURRENCY, DATE or DATE_TIME, and an optional pattern.
+type Format struct {
+	Type    string `yaml:"type"`
+	Pattern string `yaml:"pattern"`
+}
+
+// Validation is a data validation rule: a Sheets API condition type, such
+// as ONE_OF_LIST or NUMBER_BETWEEN, with its values. Strict rejects
+// invalid input instead of only flagging it.
+type Validation struct {
+	Type   string   `yaml:"type"`
+	Values []string `yaml:"values"`
+	Strict bool     `yaml:"strict"`
+}
+
+// Protection protects a range of a tab, in A1 notation without the tab
+// name, or the whole tab if Range is empty. Protections are matched to the
+// live ones by Description, which must therefore be unique per tab.
+type Protection struct {
+	Range       string   `yaml:"range"`
+	Description string   `yaml:"description"`
+	Editors     []string `yaml:"editors"`
+	WarningOnly bool     `yaml:"warning_only"`
+}
+
+// NamedRange names a range given in A1 notation including the tab.
+type NamedRange struct {
+	Name  string `yaml:"name"`
+	Range string `yaml:"range"`
+}
+
+// headerProtection is the description of the protection created by
+// Tab.ProtectHeader.
+const headerProtection = "header (managed by spec)"
+
+// Load reads and checks a spec file.
+func Load(path string) (*Spec, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Spec{}
+	if err := yaml.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("spec: invalid spec %s: %w", path, err)
+	}
+	tabs := map[string]bool{}
+	for _, t := range s.Tabs {
+		if t.Name == "" {
+			return nil, fmt.Errorf("spec: %s: tab without a name", path)
+		}
+		if tabs[t.Name] {
+			return nil, fmt.Errorf("spec: %s: duplicate tab %q", path, t.Name)
+		}
+		tabs[t.Name] = true
+		descs := map[string]bool{}
+		for _, p := range t.Protections {
+			if p.Description == "" || p.Description == headerProtection || descs[p.Description] {
+				return nil, fmt.Errorf("spec: %s: tab %s: protections need unique descriptions", path, t.Name)
+			}
+			descs[p.Description] = true
+		}
+	}
+	for _, n := range s.NamedRanges {
+		if n.Name == "" || n.Range == "" {
+			return nil, fmt.Errorf("spec: %s: named ranges need a name and a range", path)
+		}
+	}
+	return s, nil
+}
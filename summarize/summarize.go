@@ -0,0 +1,304 @@
+// Package summarize computes group-by aggregations over rows as they are
+// streamed from a tab, holding only one accumulator per group in memory.
+package summarize
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// Agg is an aggregation of a column: count, sum, avg, min, max or
+// distinct (the number of distinct values). count(*) counts rows.
+type Agg struct {
+	Func   string
+	Column string
+}
+
+func (a Agg) String() string { return a.Func + "(" + a.Column + ")" }
+
+var aggRE = regexp.MustCompile(`^\s*([a-zA-Z]+)\(\s*([^()]*?)\s*\)\s*$`)
+
+// ParseAggs parses a comma separated list such as "sum(Revenue),count(*)".
+func ParseAggs(spec string) ([]Agg, error) {
+	var out []Agg
+	for _, part := range splitTop(spec) {
+		m := aggRE.FindStringSubmatch(part)
+		if m == nil {
+			return nil, fmt.Errorf("summarize: invalid aggregation %q", part)
+		}
+		a := Agg{Func: strings.ToLower(m[1]), Column: m[2]}
+		switch a.Func {
+		case "count":
+		case "sum", "avg", "min", "max", "distinct":
+			if a.Column == "*" || a.Column == "" {
+				return nil, fmt.Errorf("summarize: %s needs a column", a.Func)
+			}
+		default:
+			return nil, fmt.Errorf("summarize: unknown aggregation %q", a.Func)
+		}
+		if a.Column == "" {
+			a.Column = "*"
+		}
+		out = append(out, a)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("summarize: no aggregations")
+	}
+	return out, nil
+}
+
+// splitTop splits on commas outside parentheses, so column names may not
+// break the list apart.
+func splitTop(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if strings.TrimSpace(s[start:]) != "" {
+		parts = append(parts, s[start:])
+	}
+	return parts
+}
+
+// Summary accumulates rows into groups.
+type Summary struct {
+	groupBy []int
+	aggs    []Agg
+	cols    []int
+	names   []string
+	groups  map[string]*group
+}
+
+type group struct {
+	key   []string
+	accs  []acc
+	first int
+}
+
+type acc struct {
+	n        int
+	sum      float64
+	min, max float64
+	distinct map[string]bool
+}
+
+// New returns a summary of rows laid out as header, grouped by the values
+// of the groupBy columns. Without groupBy all rows form one group.
+func New(header []string, groupBy []string, aggs []Agg) (*Summary, error) {
+	col := func(name string) (int, error) {
+		for i, h := range header {
+			if strings.TrimSpace(h) == name {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("summarize: no column named %q", name)
+	}
+	s := &Summary{aggs: aggs, groups: map[string]*group{}}
+	for _, g := range groupBy {
+		i, err := col(g)
+		if err != nil {
+			return nil, err
+		}
+		s.groupBy = append(s.groupBy, i)
+		s.names = append(s.names, g)
+	}
+	for _, a := range aggs {
+		i := -1
+		if a.Column != "*" {
+			var err error
+			if i, err = col(a.Column); err != nil {
+				return nil, err
+			}
+		}
+		s.cols = append(s.cols, i)
+		s.names = append(s.names, a.String())
+	}
+	return s, nil
+}
+
+// Add accumulates a row. Numeric aggregations skip empty and non-numeric
+// cells.
+func (s *Summary) Add(row []interface{}) {
+	cell := func(i int) string {
+		if i < 0 || i >= len(row) || row[i] == nil {
+			return ""
+		}
+		return fmt.Sprint(row[i])
+	}
+	key := make([]string, len(s.groupBy))
+	for j, i := range s.groupBy {
+		key[j] = cell(i)
+	}
+	id := strings.Join(key, "\x00")
+	g, ok := s.groups[id]
+	if !ok {
+		g = &group{key: key, accs: make([]acc, len(s.aggs)), first: len(s.groups)}
+		s.groups[id] = g
+	}
+	for j, a := range s.aggs {
+		ac := &g.accs[j]
+		if a.Column == "*" {
+			ac.n++
+			continue
+		}
+		v := cell(s.cols[j])
+		if v == "" {
+			continue
+		}
+		switch a.Func {
+		case "count":
+			ac.n++
+		case "distinct":
+			if ac.distinct == nil {
+				ac.distinct = map[string]bool{}
+			}
+			ac.distinct[v] = true
+		default:
+			f, err := toNumber(row[s.cols[j]])
+			if err != nil {
+				continue
+			}
+			if ac.n == 0 || f < ac.min {
+				ac.min = f
+			}
+			if ac.n == 0 || f > ac.max {
+				ac.max = f
+			}
+			ac.n++
+			ac.sum += f
+		}
+	}
+}
+
+// Header returns the column names of the result: the group columns, then
+// one per aggregation.
+func (s *Summary) Header() []string { return s.names }
+
+// Rows returns one row per group, sorted by group key.
+func (s *Summary) Rows() [][]interface{} {
+	groups := make([]*group, 0, len(s.groups))
+	for _, g := range s.groups {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		a, b := groups[i].key, groups[j].key
+		for k := range a {
+			if a[k] != b[k] {
+				return less(a[k], b[k])
+			}
+		}
+		return groups[i].first < groups[j].first
+	})
+	var out [][]interface{}
+	for _, g := range groups {
+		row := make([]interface{}, 0, len(s.names))
+		for _, k := range g.key {
+			row = append(row, k)
+		}
+		for j, a := range s.aggs {
+			ac := g.accs[j]
+			var v interface{}
+			switch a.Func {
+			case "count":
+				v = float64(ac.n)
+			case "distinct":
+				v = float64(len(ac.distinct))
+			case "sum":
+				v = ac.sum
+			case "avg":
+				if ac.n > 0 {
+					v = ac.sum / float64(ac.n)
+				}
+			case "min":
+				if ac.n > 0 {
+					v = ac.min
+				}
+			case "max":
+				if ac.n > 0 {
+					v = ac.max
+				}
+			}
+			if v == nil {
+				v = ""
+			}
+			row = append(row, v)
+		}
+		out = append(out, row)
+	}
+	return out
+}
+
+// less orders numeric keys numerically and others as strings.
+func less(a, b string) bool {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		return x < y
+	}
+	return a < b
+}
+
+func toNumber(v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case bool:
+		return 0, fmt.Errorf("not a number")
+	}
+	f, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(fmt.Sprint(v)), ",", "", -1), 64)
+	if err != nil || math.IsNaN(f) {
+		return 0, fmt.Errorf("not a number")
+	}
+	return f, nil
+}
+
+// Tab streams the rows of tab into a new summary, reading pageSize rows
+// per request up to the last row of the tab's grid, so that blank rows
+// do not end the read early.
+func Tab(ctx context.Context, srv *sheets.Service, spreadsheetID, tab string, groupBy []string, aggs []Agg, pageSize int) (*Summary, error) {
+	c := sheetsclient.FromService(srv)
+	c.ValueRenderOption = "UNFORMATTED_VALUE"
+	it := c.Rows(ctx, spreadsheetID, sheetsclient.QuoteTab(tab), pageSize)
+	var header []string
+	if it.Next() {
+		for _, h := range it.Row() {
+			header = append(header, fmt.Sprint(h))
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("summarize: unable to read %s: %w", tab, err)
+	}
+	s, err := New(header, groupBy, aggs)
+	if err != nil {
+		return nil, err
+	}
+	for it.Next() {
+		if row := it.Row(); len(row) > 0 {
+			s.Add(row)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("summarize: unable to read %s: %w", tab, err)
+	}
+	return s, nil
+}
@@ -0,0 +1,51 @@
+package summarize
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetstest"
+)
+
+func TestTab(t *testing.T) {
+	ctx := context.Background()
+	srv := sheetstest.NewServer()
+	defer srv.Close()
+	srv.Seed(&sheetstest.Spreadsheet{ID: "s", Tabs: []*sheetstest.Tab{{
+		Title:    "Sales",
+		RowCount: 9,
+		Rows: [][]interface{}{
+			{"Region", "Amount"},
+			{"east", 1.0},
+			{"west", 2.0},
+			// A blank row filling a whole page must not end the read.
+			{},
+			{},
+			{"east", 3.0},
+			{"west", "4"},
+			{"east", "n/a"},
+		},
+	}}})
+	svc, err := srv.SheetsService(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Tab(ctx, svc, "s", "Sales", []string{"Region"}, []Agg{{"sum", "Amount"}, {"count", "*"}}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.Header(), []string{"Region", "sum(Amount)", "count(*)"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Header = %q, want %q", got, want)
+	}
+	got := s.Rows()
+	want := [][]interface{}{{"east", 4.0, 3.0}, {"west", 6.0, 2.0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Rows = %#v, want %#v", got, want)
+	}
+
+	if _, err := Tab(ctx, svc, "s", "Missing", nil, []Agg{{"count", "*"}}, 2); err == nil {
+		t.Error("Tab succeeded on a missing tab")
+	}
+}
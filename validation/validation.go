@@ -0,0 +1,124 @@
+// Package validation sets data validation rules on ranges, such as
+// dropdown lists of allowed values, number ranges and custom formulas,
+// for intake sheets built by scripts:
+//
+//	err := validation.Set(ctx, srv, spreadsheetID, "Intake!C2:C",
+//		validation.OneOf("Open", "In progress", "Done").Reject())
+package validation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/prantoran/GoogleSheets_GO/sheetsclient"
+)
+
+// Rule is a data validation rule: a condition type of the Sheets API,
+// such as "ONE_OF_LIST", "NUMBER_BETWEEN" or "CUSTOM_FORMULA", with its
+// values.
+type Rule struct {
+	Condition string
+	Values    []string
+	// Strict rejects invalid input instead of only flagging the cell.
+	Strict bool
+	// Dropdown shows the allowed values of list rules as a dropdown.
+	Dropdown bool
+	// Message is shown when a cell with the rule is selected.
+	Message string
+}
+
+// OneOf allows only the given values, chosen from a dropdown.
+func OneOf(values ...string) *Rule {
+	return &Rule{Condition: "ONE_OF_LIST", Values: values, Dropdown: true}
+}
+
+// OneOfRange allows only the values found in rng, e.g. "Lists!A2:A",
+// chosen from a dropdown.
+func OneOfRange(rng string) *Rule {
+	if !strings.HasPrefix(rng, "=") {
+		rng = "=" + rng
+	}
+	return &Rule{Condition: "ONE_OF_RANGE", Values: []string{rng}, Dropdown: true}
+}
+
+// NumberBetween allows numbers from min to max, inclusive.
+func NumberBetween(min, max float64) *Rule {
+	return &Rule{Condition: "NUMBER_BETWEEN", Values: []string{formatNumber(min), formatNumber(max)}}
+}
+
+// Formula allows the values for which formula, written for the top left
+// cell of the range, e.g. "=ISEMAIL(B2)", is true.
+func Formula(formula string) *Rule {
+	if !strings.HasPrefix(formula, "=") {
+		formula = "=" + formula
+	}
+	return &Rule{Condition: "CUSTOM_FORMULA", Values: []string{formula}}
+}
+
+// Checkbox turns the cells into checkboxes.
+func Checkbox() *Rule {
+	return &Rule{Condition: "BOOLEAN"}
+}
+
+// Reject makes r strict and returns it.
+func (r *Rule) Reject() *Rule {
+	r.Strict = true
+	return r
+}
+
+// WithMessage sets the help text of r and returns it.
+func (r *Rule) WithMessage(message string) *Rule {
+	r.Message = message
+	return r
+}
+
+func (r *Rule) rule() *sheets.DataValidationRule {
+	c := &sheets.BooleanCondition{Type: strings.ToUpper(r.Condition)}
+	for _, v := range r.Values {
+		c.Values = append(c.Values, &sheets.ConditionValue{UserEnteredValue: v})
+	}
+	return &sheets.DataValidationRule{
+		Condition:    c,
+		Strict:       r.Strict,
+		ShowCustomUi: r.Dropdown,
+		InputMessage: r.Message,
+	}
+}
+
+// Set applies r to every cell of rng, e.g. "Intake!C2:C" or a named
+// range, replacing any rule the cells had. A nil r removes validation, as
+// Clear does.
+func Set(ctx context.Context, srv *sheets.Service, spreadsheetID, rng string, r *Rule) error {
+	tabs, err := sheetsclient.LoadTabs(ctx, srv, spreadsheetID)
+	if err != nil {
+		return err
+	}
+	g, err := tabs.GridRange(rng)
+	if err != nil {
+		return err
+	}
+	req := &sheets.SetDataValidationRequest{Range: g}
+	if r != nil {
+		req.Rule = r.rule()
+	}
+	_, err = srv.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{SetDataValidation: req}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("validation: unable to set data validation on %s: %w", rng, err)
+	}
+	return nil
+}
+
+// Clear removes data validation from the cells of rng.
+func Clear(ctx context.Context, srv *sheets.Service, spreadsheetID, rng string) error {
+	return Set(ctx, srv, spreadsheetID, rng, nil)
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
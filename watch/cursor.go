@@ -0,0 +1,64 @@
+package watch
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cursor is the state a Watcher needs to resume after a restart: the last
+// snapshot it saw and when it was taken.
+type Cursor struct {
+	Snapshot *Snapshot `json:"snapshot"`
+	Time     time.Time `json:"time"`
+}
+
+// CursorStore persists the cursor of a Watcher.
+type CursorStore interface {
+	// Load returns the saved cursor, or nil if there is none yet.
+	Load() (*Cursor, error)
+	Save(*Cursor) error
+}
+
+// FileCursor stores the cursor as JSON in a file.
+type FileCursor string
+
+// Load implements CursorStore.
+func (f FileCursor) Load() (*Cursor, error) {
+	b, err := ioutil.ReadFile(string(f))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	c := &Cursor{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Save implements CursorStore. The file is replaced atomically so that a
+// crash never leaves a truncated cursor behind.
+func (f FileCursor) Save(c *Cursor) error {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(string(f)), ".cursor")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), string(f))
+}
@@ -0,0 +1,175 @@
+package watch
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/drive/v3"
+)
+
+// PushChannel keeps a Drive notification channel open on a spreadsheet and
+// turns the notifications into poll triggers, so a Watcher only reads the
+// range when something actually changed. Pass Notifications as the
+// Watcher's Trigger and serve the PushChannel itself at Address, e.g. with
+// http.ListenAndServe.
+type PushChannel struct {
+	Drive  *drive.Service
+	FileID string
+	// Address is the public HTTPS URL Drive delivers notifications to.
+	Address string
+	// Token, if set, is sent back by Drive with every notification and
+	// checked by ServeHTTP.
+	Token string
+	// TTL is the lifetime requested for each channel; defaults to one
+	// hour. Channels are renewed shortly before they expire.
+	TTL time.Duration
+
+	once   sync.Once
+	notify chan struct{}
+
+	mu       sync.Mutex
+	channels map[string]*drive.Channel // open channels by ID
+}
+
+func (p *PushChannel) init() {
+	p.once.Do(func() {
+		p.notify = make(chan struct{}, 1)
+		p.channels = map[string]*drive.Channel{}
+	})
+}
+
+// Notifications returns a channel that receives a value whenever Drive
+// reports a change to the file. Bursts of notifications are coalesced.
+func (p *PushChannel) Notifications() <-chan struct{} {
+	p.init()
+	return p.notify
+}
+
+// ServeHTTP handles notification requests sent by Drive.
+func (p *PushChannel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.init()
+	id := r.Header.Get("X-Goog-Channel-ID")
+	p.mu.Lock()
+	_, known := p.channels[id]
+	p.mu.Unlock()
+	if !known || (p.Token != "" && r.Header.Get("X-Goog-Channel-Token") != p.Token) {
+		http.Error(w, "unknown channel", http.StatusForbidden)
+		return
+	}
+	// The "sync" message only confirms that the channel was created.
+	if r.Header.Get("X-Goog-Resource-State") != "sync" {
+		p.trigger()
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Run opens a channel and keeps renewing it until ctx is done, at which
+// point open channels are stopped.
+func (p *PushChannel) Run(ctx context.Context) error {
+	p.init()
+	ttl := p.TTL
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	defer p.stopAll()
+
+	ch, err := p.open(ctx, ttl)
+	if err != nil {
+		return err
+	}
+	// The range may have changed before the channel was set up.
+	p.trigger()
+	for {
+		renew := time.Until(time.Unix(0, ch.Expiration*int64(time.Millisecond))) * 9 / 10
+		if renew < minRenew {
+			// The expiration is missing or already past.
+			renew = minRenew
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(renew):
+		}
+		// Open the replacement before stopping the old channel so no
+		// notification falls in between.
+		next, err := p.open(ctx, ttl)
+		if err != nil {
+			return err
+		}
+		p.stop(ctx, ch)
+		ch = next
+		p.trigger()
+	}
+}
+
+// minRenew is the shortest wait before a channel is renewed, so that a
+// channel returned without a usable expiration is not reopened in a loop.
+const minRenew = time.Minute
+
+func (p *PushChannel) trigger() {
+	select {
+	case p.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (p *PushChannel) open(ctx context.Context, ttl time.Duration) (*drive.Channel, error) {
+	b := make([]byte, 16)
+	rand.Read(b)
+	req := &drive.Channel{
+		Id:         hex.EncodeToString(b),
+		Type:       "web_hook",
+		Address:    p.Address,
+		Token:      p.Token,
+		Expiration: time.Now().Add(ttl).UnixNano() / int64(time.Millisecond),
+	}
+	// Drive sends the "sync" message before Watch returns, so the
+	// channel is known to ServeHTTP from the start.
+	p.mu.Lock()
+	p.channels[req.Id] = req
+	p.mu.Unlock()
+	ch, err := p.Drive.Files.Watch(p.FileID, req).Context(ctx).Do()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		delete(p.channels, req.Id)
+		return nil, fmt.Errorf("watch: unable to open notification channel: %w", err)
+	}
+	p.channels[ch.Id] = ch
+	return ch, nil
+}
+
+func (p *PushChannel) stop(ctx context.Context, ch *drive.Channel) {
+	p.mu.Lock()
+	_, open := p.channels[ch.Id]
+	delete(p.channels, ch.Id)
+	p.mu.Unlock()
+	if !open {
+		return
+	}
+	if err := p.Drive.Channels.Stop(&drive.Channel{Id: ch.Id, ResourceId: ch.ResourceId}).Context(ctx).Do(); err != nil {
+		log.Printf("watch: unable to stop channel %s: %v", ch.Id, err)
+	}
+}
+
+// stopAll stops the open channels once Run is done, usually because its
+// context was cancelled, so it uses a context of its own.
+func (p *PushChannel) stopAll() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	p.mu.Lock()
+	var open []*drive.Channel
+	for _, ch := range p.channels {
+		open = append(open, ch)
+	}
+	p.mu.Unlock()
+	for _, ch := range open {
+		p.stop(ctx, ch)
+	}
+}
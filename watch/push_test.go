@@ -0,0 +1,67 @@
+package watch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+)
+
+func TestPushChannelWithoutExpiration(t *testing.T) {
+	var watches, stops int32
+	var p *PushChannel
+	drv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/watch"):
+			atomic.AddInt32(&watches, 1)
+			var ch drive.Channel
+			json.NewDecoder(r.Body).Decode(&ch)
+			// Drive confirms the channel before answering.
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/", nil)
+			req.Header.Set("X-Goog-Channel-ID", ch.Id)
+			req.Header.Set("X-Goog-Channel-Token", ch.Token)
+			req.Header.Set("X-Goog-Resource-State", "sync")
+			p.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Errorf("sync message got status %d", rec.Code)
+			}
+			// No expiration, as for a channel that cannot expire.
+			json.NewEncoder(w).Encode(&drive.Channel{Id: ch.Id, ResourceId: "r"})
+		case strings.HasSuffix(r.URL.Path, "/channels/stop"):
+			atomic.AddInt32(&stops, 1)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer drv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	svc, err := drive.NewService(ctx, option.WithEndpoint(drv.URL+"/"), option.WithHTTPClient(drv.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p = &PushChannel{Drive: svc, FileID: "f", Address: "https://example.com/push", Token: "t"}
+	notify := p.Notifications()
+	if err := p.Run(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Run = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if n := atomic.LoadInt32(&watches); n != 1 {
+		t.Errorf("opened %d channels, want 1", n)
+	}
+	if n := atomic.LoadInt32(&stops); n != 1 {
+		t.Errorf("stopped %d channels, want 1", n)
+	}
+	select {
+	case <-notify:
+	default:
+		t.Error("Run did not trigger a first poll")
+	}
+}
@@ -0,0 +1,185 @@
+package watch
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Row is a data row of the watched range, typed by the range's header.
+type Row struct {
+	// Number is the 1-based row number within the range, counting the
+	// header row.
+	Number int
+	Key    string
+	Values []string
+	header []string
+}
+
+// Get returns the value of the named column, or "" if the row has no such
+// column.
+func (r Row) Get(column string) string {
+	for i, h := range r.header {
+		if h == column {
+			if i < len(r.Values) {
+				return r.Values[i]
+			}
+			break
+		}
+	}
+	return ""
+}
+
+// Header returns the column names of the range the row was read from.
+func (r Row) Header() []string { return r.header }
+
+// Fields returns the row as a map keyed by column name.
+func (r Row) Fields() map[string]string {
+	m := make(map[string]string, len(r.header))
+	for i, h := range r.header {
+		if i < len(r.Values) {
+			m[h] = r.Values[i]
+		} else {
+			m[h] = ""
+		}
+	}
+	return m
+}
+
+// Snapshot is the content of a range at one point in time. The first row
+// of the range is the header.
+type Snapshot struct {
+	Header []string   `json:"header"`
+	Rows   [][]string `json:"rows"`
+}
+
+// NewSnapshot converts the values returned by the Sheets API.
+func NewSnapshot(values [][]interface{}) *Snapshot {
+	s := &Snapshot{}
+	for i, v := range values {
+		row := make([]string, len(v))
+		for j, c := range v {
+			row[j] = fmt.Sprint(c)
+		}
+		if i == 0 {
+			s.Header = row
+		} else {
+			s.Rows = append(s.Rows, row)
+		}
+	}
+	return s
+}
+
+// rows indexes the snapshot by key. Without a key column rows are keyed
+// by their position; rows with a blank key are skipped.
+func (s *Snapshot) rows(keyColumn string) (map[string]Row, []string) {
+	col := -1
+	if keyColumn != "" {
+		for i, h := range s.Header {
+			if h == keyColumn {
+				col = i
+			}
+		}
+	}
+	m := map[string]Row{}
+	var order []string
+	for i, v := range s.Rows {
+		key := strconv.Itoa(i + 2)
+		if keyColumn != "" {
+			if col < 0 || col >= len(v) || v[col] == "" {
+				continue
+			}
+			key = v[col]
+		}
+		if _, dup := m[key]; dup {
+			continue
+		}
+		m[key] = Row{Number: i + 2, Key: key, Values: v, header: s.Header}
+		order = append(order, key)
+	}
+	return m, order
+}
+
+// EventType tells what happened to a row.
+type EventType int
+
+const (
+	RowAdded EventType = iota
+	RowChanged
+	RowDeleted
+)
+
+func (t EventType) String() string {
+	switch t {
+	case RowAdded:
+		return "added"
+	case RowChanged:
+		return "changed"
+	}
+	return "deleted"
+}
+
+// Event is a row level change between two snapshots. Old is nil for added
+// rows and New is nil for deleted ones.
+type Event struct {
+	Type EventType
+	Key  string
+	Old  *Row
+	New  *Row
+	// Since is when the snapshot the change was found against was
+	// taken, set by Watcher.Run. It stays the same when a failed delivery
+	// is detected again, and differs between two changes of a row that
+	// look alike, such as a value changed back and forth.
+	Since time.Time
+}
+
+// Diff compares two snapshots of a range and returns the row level changes,
+// deletions first, then changes and additions in the order of the new
+// snapshot. Rows are matched by the value of keyColumn, or by position if
+// keyColumn is empty. A nil old snapshot yields no events.
+func Diff(old, new *Snapshot, keyColumn string) []Event {
+	if old == nil || new == nil {
+		return nil
+	}
+	oldRows, oldOrder := old.rows(keyColumn)
+	newRows, newOrder := new.rows(keyColumn)
+
+	var events []Event
+	for _, k := range oldOrder {
+		if _, ok := newRows[k]; !ok {
+			r := oldRows[k]
+			events = append(events, Event{Type: RowDeleted, Key: k, Old: &r})
+		}
+	}
+	for _, k := range newOrder {
+		n := newRows[k]
+		o, ok := oldRows[k]
+		switch {
+		case !ok:
+			events = append(events, Event{Type: RowAdded, Key: k, New: &n})
+		case !equal(o.Values, n.Values):
+			events = append(events, Event{Type: RowChanged, Key: k, Old: &o, New: &n})
+		}
+	}
+	return events
+}
+
+// equal compares two rows, ignoring trailing empty cells which the Sheets
+// API omits from responses.
+func equal(a, b []string) bool {
+	for len(a) > 0 && a[len(a)-1] == "" {
+		a = a[:len(a)-1]
+	}
+	for len(b) > 0 && b[len(b)-1] == "" {
+		b = b[:len(b)-1]
+	}
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
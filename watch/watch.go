@@ -0,0 +1,189 @@
+// Package watch polls a spreadsheet range and reports row level changes.
+package watch
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/sheets/v4"
+)
+
+// Watcher polls a range and invokes the registered callbacks for every row
+// added, changed or deleted between two polls. Callbacks run on the
+// goroutine calling Run, in the order the events were detected.
+type Watcher struct {
+	Sheets        *sheets.Service
+	SpreadsheetID string
+	Range         string
+	// KeyColumn names the header column identifying rows. Without one,
+	// rows are compared by position, so inserting a row in the middle
+	// reports every row below it as changed.
+	KeyColumn string
+	// Interval between polls; defaults to 30 seconds.
+	Interval time.Duration
+	// MaxBackoff caps the delay between retries after failed polls;
+	// defaults to 10 minutes.
+	MaxBackoff time.Duration
+	// Trigger, when set, causes an immediate poll whenever it receives a
+	// value, e.g. from PushChannel.Notifications. Interval then only acts
+	// as a safety net and can be raised accordingly.
+	Trigger <-chan struct{}
+	// Cursor, when set, persists the last snapshot so that changes made
+	// while the watcher was stopped are reported on the next start.
+	Cursor CursorStore
+
+	added, deleted []func(Row)
+	changed        []func(old, new Row)
+	events         []func(Event)
+	batches        []func(context.Context, []Event) error
+	errors         []func(error)
+}
+
+// OnRowAdded registers fn to be called for every new row.
+func (w *Watcher) OnRowAdded(fn func(Row)) { w.added = append(w.added, fn) }
+
+// OnRowChanged registers fn to be called with the previous and current
+// version of every changed row.
+func (w *Watcher) OnRowChanged(fn func(old, new Row)) { w.changed = append(w.changed, fn) }
+
+// OnRowDeleted registers fn to be called for every removed row.
+func (w *Watcher) OnRowDeleted(fn func(Row)) { w.deleted = append(w.deleted, fn) }
+
+// OnEvent registers fn to be called for every event, whatever its type.
+func (w *Watcher) OnEvent(fn func(Event)) { w.events = append(w.events, fn) }
+
+// OnBatch registers fn to be called with all events of a poll before any
+// other callback. If it returns an error the poll is treated as failed:
+// the cursor does not move and the same changes are detected and delivered
+// again on the next attempt, which gives at-least-once delivery.
+func (w *Watcher) OnBatch(fn func(ctx context.Context, events []Event) error) {
+	w.batches = append(w.batches, fn)
+}
+
+// OnError registers fn to be called when a poll fails. The watcher keeps
+// going and retries with exponential backoff.
+func (w *Watcher) OnError(fn func(error)) { w.errors = append(w.errors, fn) }
+
+// Poll fetches the range once and returns the events since the previous
+// snapshot, which is then replaced. The first poll without a saved
+// cursor returns no events.
+func (w *Watcher) Poll(ctx context.Context, prev *Snapshot) (*Snapshot, []Event, error) {
+	resp, err := w.Sheets.Spreadsheets.Values.Get(w.SpreadsheetID, w.Range).Context(ctx).Do()
+	if err != nil {
+		return nil, nil, fmt.Errorf("watch: unable to read %s: %w", w.Range, err)
+	}
+	cur := NewSnapshot(resp.Values)
+	return cur, Diff(prev, cur, w.KeyColumn), nil
+}
+
+// Run polls until ctx is done, dispatching events to the callbacks. It
+// returns ctx.Err() on cancellation, or an error if the cursor cannot be
+// loaded or saved.
+func (w *Watcher) Run(ctx context.Context) error {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	maxBackoff := w.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = 10 * time.Minute
+	}
+
+	var prev *Snapshot
+	var since time.Time
+	if w.Cursor != nil {
+		c, err := w.Cursor.Load()
+		if err != nil {
+			return fmt.Errorf("watch: unable to load cursor: %w", err)
+		}
+		if c != nil {
+			prev, since = c.Snapshot, c.Time
+		}
+	}
+
+	delay := time.Duration(0)
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		case <-w.Trigger:
+		}
+
+		taken := time.Now()
+		cur, events, err := w.Poll(ctx, prev)
+		for i := range events {
+			events[i].Since = since
+		}
+		if err == nil && len(events) > 0 {
+			err = w.deliver(ctx, events)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			for _, fn := range w.errors {
+				fn(err)
+			}
+			failures++
+			delay = backoff(interval, maxBackoff, failures)
+			continue
+		}
+		failures = 0
+		delay = interval
+
+		for _, e := range events {
+			w.dispatch(e)
+		}
+		prev, since = cur, taken
+		if w.Cursor != nil {
+			if err := w.Cursor.Save(&Cursor{Snapshot: cur, Time: taken}); err != nil {
+				return fmt.Errorf("watch: unable to save cursor: %w", err)
+			}
+		}
+	}
+}
+
+func (w *Watcher) deliver(ctx context.Context, events []Event) error {
+	for _, fn := range w.batches {
+		if err := fn(ctx, events); err != nil {
+			return fmt.Errorf("watch: unable to deliver events: %w", err)
+		}
+	}
+	return nil
+}
+
+func (w *Watcher) dispatch(e Event) {
+	for _, fn := range w.events {
+		fn(e)
+	}
+	switch e.Type {
+	case RowAdded:
+		for _, fn := range w.added {
+			fn(*e.New)
+		}
+	case RowChanged:
+		for _, fn := range w.changed {
+			fn(*e.Old, *e.New)
+		}
+	case RowDeleted:
+		for _, fn := range w.deleted {
+			fn(*e.Old)
+		}
+	}
+}
+
+// backoff returns the jittered delay before retry number n.
+func backoff(base, max time.Duration, n int) time.Duration {
+	d := base
+	for i := 1; i < n && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
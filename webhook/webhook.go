@@ -0,0 +1,222 @@
+// Package webhook POSTs detected row changes to HTTP endpoints.
+//
+// Every delivery is a JSON Payload signed with HMAC-SHA256 over the
+// timestamp and body, so receivers can check that it came from us and is
+// recent. Failed deliveries are retried with backoff; a delivery that
+// still fails makes Sender.Handler return an error, which Watcher.OnBatch
+// turns into a retried poll. Receivers may therefore see a payload twice
+// and should deduplicate on Payload.ID.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/drive/v3"
+
+	"github.com/prantoran/GoogleSheets_GO/publish"
+	"github.com/prantoran/GoogleSheets_GO/watch"
+)
+
+// Headers set on every delivery.
+const (
+	SignatureHeader = "X-Sheets-Signature"
+	TimestampHeader = "X-Sheets-Timestamp"
+	DeliveryHeader  = "X-Sheets-Delivery"
+)
+
+// Payload is the JSON body of a delivery.
+type Payload struct {
+	// ID identifies the set of changes; redeliveries keep the same ID.
+	ID            string `json:"id"`
+	SpreadsheetID string `json:"spreadsheet_id"`
+	Range         string `json:"range"`
+	// Revision is the Drive version of the spreadsheet when the changes
+	// were detected, if the sender has a Drive service.
+	Revision int64              `json:"revision,omitempty"`
+	Time     time.Time          `json:"time"`
+	Changes  []*publish.Message `json:"changes"`
+}
+
+// Endpoint is a URL that receives deliveries, signed with Secret.
+type Endpoint struct {
+	URL    string
+	Secret string
+}
+
+// Sender delivers payloads to a set of endpoints.
+type Sender struct {
+	Endpoints []Endpoint
+	// Client defaults to a client with a 30 second timeout.
+	Client *http.Client
+	// MaxAttempts per endpoint and delivery; defaults to 5.
+	MaxAttempts int
+	// Drive, if set, is used to look up Payload.Revision.
+	Drive *drive.Service
+}
+
+// Handler returns a Watcher.OnBatch callback that delivers the events of
+// a watcher on the given range.
+func (s *Sender) Handler(spreadsheetID, rng string) func(ctx context.Context, events []watch.Event) error {
+	return func(ctx context.Context, events []watch.Event) error {
+		p := &Payload{
+			SpreadsheetID: spreadsheetID,
+			Range:         rng,
+			Time:          time.Now().UTC(),
+			Changes:       publish.FromWatch(spreadsheetID, rng, events),
+		}
+		if s.Drive != nil {
+			f, err := s.Drive.Files.Get(spreadsheetID).Fields("version").Context(ctx).Do()
+			if err != nil {
+				return fmt.Errorf("webhook: unable to get revision: %w", err)
+			}
+			p.Revision = f.Version
+		}
+		return s.Send(ctx, p)
+	}
+}
+
+// Send delivers p to every endpoint, filling in p.ID if it is empty. It
+// returns an error naming the endpoints that could not be reached.
+func (s *Sender) Send(ctx context.Context, p *Payload) error {
+	if p.ID == "" {
+		p.ID = payloadID(p)
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("webhook: unable to encode payload: %w", err)
+	}
+	var failed []string
+	for _, ep := range s.Endpoints {
+		if err := s.deliver(ctx, ep, p.ID, body); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			failed = append(failed, fmt.Sprintf("%s: %v", ep.URL, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("webhook: delivery failed: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+func (s *Sender) deliver(ctx context.Context, ep Endpoint, id string, body []byte) error {
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	attempts := s.MaxAttempts
+	if attempts <= 0 {
+		attempts = 5
+	}
+
+	var err error
+	for n := 0; n < attempts; n++ {
+		if n > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff(n)):
+			}
+		}
+		var retry bool
+		if retry, err = s.post(ctx, client, ep, id, body); err == nil || !retry {
+			return err
+		}
+	}
+	return err
+}
+
+// post makes one delivery attempt and reports whether a failure is worth
+// retrying.
+func (s *Sender) post(ctx context.Context, client *http.Client, ep Endpoint, id string, body []byte) (bool, error) {
+	req, err := http.NewRequest("POST", ep.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(DeliveryHeader, id)
+	req.Header.Set(TimestampHeader, ts)
+	if ep.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(ep.Secret, ts, body))
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return true, err
+	}
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("status %s", resp.Status)
+	}
+	return false, fmt.Errorf("status %s", resp.Status)
+}
+
+// Sign returns the signature header value for a body sent at the given
+// Unix timestamp: "sha256=" followed by the hex HMAC-SHA256 of
+// timestamp + "." + body.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of a received delivery and rejects it if
+// its timestamp is further than maxAge from now. Receivers may use it as
+//
+//	body, _ := ioutil.ReadAll(r.Body)
+//	err := webhook.Verify(secret, r.Header, body, 5*time.Minute)
+func Verify(secret string, h http.Header, body []byte, maxAge time.Duration) error {
+	ts := h.Get(TimestampHeader)
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("webhook: invalid timestamp %q", ts)
+	}
+	if age := time.Since(time.Unix(sec, 0)); age > maxAge || age < -maxAge {
+		return fmt.Errorf("webhook: timestamp %s outside the accepted window", ts)
+	}
+	want := Sign(secret, ts, body)
+	if !hmac.Equal([]byte(h.Get(SignatureHeader)), []byte(want)) {
+		return fmt.Errorf("webhook: signature mismatch")
+	}
+	return nil
+}
+
+// payloadID derives the ID of p from the IDs of its changes.
+func payloadID(p *Payload) string {
+	h := sha1.New()
+	for _, m := range p.Changes {
+		io.WriteString(h, m.ID)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// backoff returns the delay before retry n, doubling from one second up
+// to a minute, with jitter.
+func backoff(n int) time.Duration {
+	d := time.Second << uint(n-1)
+	if d > time.Minute {
+		d = time.Minute
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}